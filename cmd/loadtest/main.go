@@ -0,0 +1,136 @@
+// Command loadtest spawns concurrent bot tables against a running dx-service
+// instance (debug mode) and reports action latency percentiles. The target
+// server must expose POST /admin/debug/spawn_table, which release builds
+// never do.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"dx-service/internal/bot"
+)
+
+func main() {
+	var (
+		server     string
+		adminToken string
+		tables     int
+		seats      int
+		think      time.Duration
+		timeout    time.Duration
+	)
+	flag.StringVar(&server, "server", "http://127.0.0.1:8080", "target server origin")
+	flag.StringVar(&adminToken, "admin-token", "", "admin JWT for the spawn endpoint")
+	flag.IntVar(&tables, "tables", 1, "number of concurrent bot tables")
+	flag.IntVar(&seats, "seats", 2, "seats per table")
+	flag.DurationVar(&think, "think", 100*time.Millisecond, "bot think time per action")
+	flag.DurationVar(&timeout, "timeout", 2*time.Minute, "per-table hand deadline")
+	flag.Parse()
+
+	if adminToken == "" {
+		fmt.Fprintln(os.Stderr, "missing -admin-token")
+		os.Exit(2)
+	}
+
+	rec := &bot.Recorder{}
+	start := time.Now()
+	var wg sync.WaitGroup
+	errCh := make(chan error, tables)
+	for i := 0; i < tables; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := runTable(server, adminToken, seats, think, timeout, rec); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+
+	failed := 0
+	for err := range errCh {
+		failed++
+		fmt.Fprintf(os.Stderr, "table failed: %v\n", err)
+	}
+
+	fmt.Printf("tables=%d failed=%d actions=%d elapsed=%s\n",
+		tables, failed, rec.Count(), time.Since(start).Round(time.Millisecond))
+	fmt.Printf("latency p50=%s p90=%s p99=%s\n",
+		rec.Percentile(50).Round(time.Millisecond),
+		rec.Percentile(90).Round(time.Millisecond),
+		rec.Percentile(99).Round(time.Millisecond))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runTable spawns one bot table and plays a full hand on it.
+func runTable(server, adminToken string, seats int, think, timeout time.Duration, rec *bot.Recorder) error {
+	spawned, err := spawnTable(server, adminToken, seats)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(spawned.Seats))
+	for _, seat := range spawned.Seats {
+		wg.Add(1)
+		go func(seat bot.SpawnSeat) {
+			defer wg.Done()
+			player := &bot.Player{
+				BaseURL: server,
+				TableID: spawned.TableID,
+				Token:   seat.Token,
+				Think:   think,
+				Rec:     rec,
+			}
+			if err := player.Run(ctx); err != nil {
+				errCh <- fmt.Errorf("table %d seat %d: %w", spawned.TableID, seat.Seat, err)
+			}
+		}(seat)
+	}
+	wg.Wait()
+	close(errCh)
+	return <-errCh
+}
+
+func spawnTable(server, adminToken string, seats int) (*bot.SpawnResult, error) {
+	body, _ := json.Marshal(map[string]interface{}{"seats": seats})
+	req, err := http.NewRequest(http.MethodPost, server+"/admin/debug/spawn_table", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Code int             `json:"code"`
+		Data bot.SpawnResult `json:"data"`
+		Msg  string          `json:"msg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("spawn_table returned %d: %s", resp.StatusCode, envelope.Msg)
+	}
+	return &envelope.Data, nil
+}
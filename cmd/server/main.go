@@ -5,26 +5,36 @@ import (
 	"flag"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"dx-service/internal/api"
 	"dx-service/internal/config"
 	"dx-service/internal/repo"
 	"dx-service/internal/service"
+	"dx-service/internal/service/integrity"
+	"dx-service/internal/tracing"
+	"dx-service/pkg/buildinfo"
 	"dx-service/pkg/logger"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 )
 
 func main() {
 	var configPath string
+	var checkMode bool
 	flag.StringVar(&configPath, "config", "config.yaml", "path to config file")
+	flag.BoolVar(&checkMode, "check", false, "run the rules data integrity checks and exit")
 	flag.Parse()
 
 	rand.Seed(time.Now().UnixNano())
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
 	// 1. Load Config
@@ -34,10 +44,28 @@ func main() {
 	logger.InitLogger(config.GlobalConfig.Server.Mode)
 	defer logger.Log.Sync()
 
-	logger.Log.Info("Starting server...", zap.String("mode", config.GlobalConfig.Server.Mode))
+	logger.Log.Info("Starting server...",
+		zap.String("mode", config.GlobalConfig.Server.Mode),
+		zap.String("version", buildinfo.Version),
+		zap.String("commit", buildinfo.Commit),
+		zap.String("buildTime", buildinfo.BuildTime),
+	)
+
+	// 2.5 Init Tracing (no-op unless configured)
+	shutdownTracing, err := tracing.InitTracing(ctx)
+	if err != nil {
+		logger.Log.Fatal("failed to init tracing", zap.Error(err))
+	}
+	defer shutdownTracing(context.Background())
 
 	// 3. Init DB & Redis
 	repo.InitDB()
+
+	if checkMode {
+		runIntegrityCheck(ctx)
+		return
+	}
+
 	repo.InitRedis()
 
 	// 3.5 Init Services
@@ -51,6 +79,7 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.Default()
+	r.Use(otelgin.Middleware(tracing.ServiceName))
 
 	// Register Routes
 	api.RegisterRoutes(r, services)
@@ -58,7 +87,49 @@ func main() {
 	// 5. Start Server
 	addr := fmt.Sprintf(":%s", config.GlobalConfig.Server.Port)
 	logger.Log.Info("Server listening", zap.String("addr", addr))
-	if err := r.Run(addr); err != nil {
+
+	srv := &http.Server{Addr: addr, Handler: r}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
 		logger.Log.Fatal("Server failed to start", zap.Error(err))
+	case <-ctx.Done():
+		logger.Log.Info("Shutdown signal received, draining")
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer stopCancel()
+		// Stop accepting requests before the services drain, so no new
+		// actions land on runtimes that are being snapshotted.
+		if err := srv.Shutdown(stopCtx); err != nil {
+			logger.Log.Warn("HTTP server shutdown", zap.Error(err))
+		}
+		if err := services.Stop(stopCtx); err != nil {
+			logger.Log.Warn("Services stopped with errors", zap.Error(err))
+		}
+	}
+}
+
+// runIntegrityCheck validates the rules reference data and wallet invariants
+// without mutating anything, then exits non-zero if any errors were found.
+func runIntegrityCheck(ctx context.Context) {
+	report, err := integrity.NewService(repo.DB).Run(ctx)
+	if err != nil {
+		logger.Log.Fatal("Integrity check failed to run", zap.Error(err))
+	}
+	for _, f := range report.Warnings {
+		logger.Log.Warn("Integrity warning",
+			zap.String("check", f.Check), zap.String("target", f.Target), zap.String("detail", f.Detail))
+	}
+	for _, f := range report.Errors {
+		logger.Log.Error("Integrity error",
+			zap.String("check", f.Check), zap.String("target", f.Target), zap.String("detail", f.Detail))
+	}
+	if !report.Clean() {
+		logger.Log.Sync()
+		os.Exit(1)
 	}
+	logger.Log.Info("Integrity check passed", zap.Int("warnings", len(report.Warnings)))
 }
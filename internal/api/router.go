@@ -1,27 +1,42 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"dx-service/internal/bot"
+	"dx-service/internal/config"
 	"dx-service/internal/middleware"
+	"dx-service/internal/model"
+	"dx-service/internal/repo"
 	"dx-service/internal/service"
+	adminSvc "dx-service/internal/service/admin"
 	agentSvc "dx-service/internal/service/agent"
+	deliverySvc "dx-service/internal/service/delivery"
+	featuresSvc "dx-service/internal/service/features"
+	"dx-service/internal/service/game"
 	"dx-service/internal/service/match"
+	notifysvc "dx-service/internal/service/notify"
 	rakeSvc "dx-service/internal/service/rake"
 	sceneSvc "dx-service/internal/service/scene"
 	usersvc "dx-service/internal/service/user"
 	walletsvc "dx-service/internal/service/wallet"
 	"dx-service/internal/ws"
+	"dx-service/pkg/buildinfo"
 	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
 	"dx-service/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -31,11 +46,17 @@ type Handler struct {
 
 func RegisterRoutes(r *gin.Engine, services *service.Container) {
 	handler := &Handler{services: services}
-	wsHandler := ws.NewHandler(services.Match, services.Game)
+	wsHandler := ws.NewHandler(services.Match, services.Game, services.Notify, services.Admin, services.Rdb)
 
 	r.GET("/ping", func(c *gin.Context) {
 		response.Success(c, gin.H{"message": "pong"})
 	})
+	r.GET("/version", func(c *gin.Context) {
+		response.Success(c, buildinfo.Summary())
+	})
+	r.GET("/ws/stats", func(c *gin.Context) {
+		response.Success(c, gin.H{"connections": wsHandler.Registry().Connections()})
+	})
 
 	v1 := r.Group("/dxService/v1")
 	{
@@ -50,6 +71,7 @@ func RegisterRoutes(r *gin.Engine, services *service.Container) {
 		{
 			userGroup.GET("/profile", handler.GetProfile)
 			userGroup.PUT("/profile", handler.UpdateProfile)
+			userGroup.POST("/verify", handler.SubmitVerification)
 		}
 
 		v1.GET("/scenes", handler.ListScenes)
@@ -58,10 +80,44 @@ func RegisterRoutes(r *gin.Engine, services *service.Container) {
 		matchGroup := v1.Group("/match")
 		matchGroup.Use(middleware.AuthRequired())
 		{
-			matchGroup.POST("/join", handler.MatchJoin)
-			matchGroup.POST("/cancel", handler.MatchCancel)
+			matchGroup.POST("/join", middleware.Idempotent(repo.RDB), handler.MatchJoin)
+			matchGroup.POST("/cancel", middleware.Idempotent(repo.RDB), handler.MatchCancel)
 			matchGroup.GET("/status", handler.MatchStatus)
 		}
+
+		tableGroup := v1.Group("/table")
+		tableGroup.Use(middleware.AuthRequired())
+		{
+			tableGroup.GET("/current", handler.TableCurrent)
+			tableGroup.POST("/:id/invite", handler.TableInvite)
+			tableGroup.GET("/:id/logs", handler.TableLogs)
+		}
+
+		practiceGroup := v1.Group("/practice")
+		practiceGroup.Use(middleware.AuthRequired())
+		{
+			practiceGroup.POST("/start", handler.PracticeStart)
+		}
+
+		matchesGroup := v1.Group("/matches")
+		matchesGroup.Use(middleware.AuthRequired())
+		{
+			matchesGroup.GET("/:id/replay", handler.MatchReplay)
+		}
+
+		inviteGroup := v1.Group("/invitations")
+		inviteGroup.Use(middleware.AuthRequired())
+		{
+			inviteGroup.GET("", handler.ListInvitations)
+			inviteGroup.POST("/accept", handler.AcceptInvitation)
+		}
+
+		notifyGroup := v1.Group("/notifications")
+		notifyGroup.Use(middleware.AuthRequired())
+		{
+			notifyGroup.GET("", handler.ListNotifications)
+			notifyGroup.PUT("/:id/read", handler.MarkNotificationRead)
+		}
 	}
 
 	adminGroup := r.Group("/admin")
@@ -74,6 +130,10 @@ func RegisterRoutes(r *gin.Engine, services *service.Container) {
 			protected.GET("/scenes", handler.AdminListScenes)
 			protected.POST("/scenes", handler.AdminCreateScene)
 			protected.PUT("/scenes/:id", handler.AdminUpdateScene)
+			protected.PUT("/scenes/bulk_status", handler.AdminBulkSceneStatus)
+
+			protected.GET("/reports/rake", handler.AdminRakeReport)
+			protected.POST("/reports/rake/backfill", handler.AdminBackfillRakeReport)
 
 			protected.GET("/rake_rules", handler.AdminListRakeRules)
 			protected.POST("/rake_rules", handler.AdminCreateRakeRule)
@@ -83,14 +143,58 @@ func RegisterRoutes(r *gin.Engine, services *service.Container) {
 			protected.POST("/agent_rules", handler.AdminCreateAgentRule)
 			protected.PUT("/agent_rules/:id", handler.AdminUpdateAgentRule)
 
+			protected.GET("/tables", handler.AdminListTables)
+			protected.GET("/tables/:id/state", handler.AdminTableState)
+			protected.PUT("/tables/:id/runtime", handler.AdminAdjustTableRuntime)
+			protected.POST("/tables/:id/mute", handler.AdminMuteTableUser)
+			protected.POST("/tables/:id/dissolve", handler.AdminDissolveTable)
+
+			protected.GET("/reaper/runs", handler.AdminListReaperRuns)
+
+			protected.GET("/matches/:id/replay", handler.AdminMatchReplay)
+			protected.GET("/matches/:id/verify_log", handler.AdminVerifyMatchLog)
+
+			protected.GET("/jobs", handler.AdminListJobs)
+			protected.POST("/jobs/:name/run", handler.AdminRunJob)
+
+			protected.GET("/deliveries/failed", handler.AdminListFailedDeliveries)
+			protected.POST("/deliveries/retry", handler.AdminRetryDeliveryBatch)
+			protected.POST("/deliveries/:id/retry", handler.AdminRetryDelivery)
+
+			protected.GET("/integrity", handler.AdminIntegrityReport)
+
+			protected.GET("/breakers", handler.AdminListBreakers)
+
+			protected.POST("/panic", handler.AdminEngagePanic)
+			protected.DELETE("/panic", handler.AdminResumePanic)
+
+			// Debug-only QA/load-test facility; never mounted in release
+			// mode, and the bot package refuses to run there regardless.
+			if bot.Enabled() {
+				protected.POST("/debug/spawn_table", handler.AdminSpawnBotTable)
+			}
+
+			protected.GET("/features", handler.AdminListFeatureFlags)
+			protected.POST("/features", handler.AdminCreateFeatureFlag)
+			protected.PUT("/features/:id", handler.AdminUpdateFeatureFlag)
+			protected.DELETE("/features/:id", handler.AdminDeleteFeatureFlag)
+
+			protected.GET("/verifications", handler.AdminListVerifications)
+			protected.PUT("/verifications/:id/review", handler.AdminReviewVerification)
+
 			protected.GET("/users", handler.AdminListUsers)
 			protected.GET("/users/:id", handler.AdminGetUser)
+			protected.GET("/users/:id/behavior", handler.AdminUserBehavior)
+			protected.GET("/users/:id/ws_usage", wsHandler.AdminWSUsage)
 			protected.PUT("/users/:id/ban", handler.AdminBanUser)
+			protected.PUT("/users/:id/geo-bypass", handler.AdminSetUserGeoBypass)
 			protected.PUT("/users/:id/wallet", handler.AdminSetUserWallet)
 		}
 	}
 
 	r.GET("/ws/table/:tableId", wsHandler.HandleTableWS)
+	r.GET("/ws/lobby", wsHandler.HandleLobbyWS)
+	r.GET("/ws/admin/table/:tableId", wsHandler.HandleAdminTableWS)
 }
 
 type smsSendBody struct {
@@ -114,6 +218,15 @@ type matchCancelBody struct {
 	SceneID int64 `json:"sceneId" binding:"required"`
 }
 
+type tableInviteBody struct {
+	UserID int64  `json:"userId"`
+	Phone  string `json:"phone"`
+}
+
+type invitationAcceptBody struct {
+	Token string `json:"token" binding:"required"`
+}
+
 type updateProfileBody struct {
 	Nickname     *string  `json:"nickname"`
 	Avatar       *string  `json:"avatar"`
@@ -132,23 +245,43 @@ type adminUserBanBody struct {
 	Reason string `json:"reason"`
 }
 
+type adminGeoBypassBody struct {
+	Allow  *bool  `json:"allow" binding:"required"`
+	Reason string `json:"reason"`
+}
+
 type adminSetWalletBody struct {
 	BalanceAvailable *int64 `json:"balanceAvailable"`
 	BalanceFrozen    *int64 `json:"balanceFrozen"`
 }
 
 type sceneMutationBody struct {
-	Name               string `json:"name" binding:"required"`
-	SeatCount          int    `json:"seatCount" binding:"required,min=2,max=9"`
-	MinIn              int64  `json:"minIn" binding:"required,min=0"`
-	MaxIn              int64  `json:"maxIn" binding:"required,min=0"`
-	BasePi             int64  `json:"basePi" binding:"required,min=1"`
-	MinUnitPi          int64  `json:"minUnitPi" binding:"required,min=1"`
-	MangoEnabled       bool   `json:"mangoEnabled"`
-	BoboEnabled        bool   `json:"boboEnabled"`
-	DistanceThresholdM int    `json:"distanceThresholdM" binding:"min=0"`
-	Status             string `json:"status" binding:"omitempty,oneof=enabled disabled"`
-	RakeRuleID         int64  `json:"rakeRuleId" binding:"required,min=1"`
+	Name                  string          `json:"name" binding:"required"`
+	NameI18n              json.RawMessage `json:"nameI18n"`
+	SeatCount             int             `json:"seatCount" binding:"required,min=2,max=9"`
+	MinIn                 int64           `json:"minIn" binding:"required,min=0"`
+	MaxIn                 int64           `json:"maxIn" binding:"required,min=0"`
+	BasePi                int64           `json:"basePi" binding:"required,min=1"`
+	MinUnitPi             int64           `json:"minUnitPi" binding:"required,min=1"`
+	MangoEnabled          bool            `json:"mangoEnabled"`
+	BoboEnabled           bool            `json:"boboEnabled"`
+	DistanceThresholdM    int             `json:"distanceThresholdM" binding:"min=0"`
+	TimeBankSeconds       int             `json:"timeBankSeconds" binding:"min=0"`
+	TimeBankBudgetSeconds int             `json:"timeBankBudgetSeconds" binding:"min=0"`
+	TimeBankRefreshes     bool            `json:"timeBankRefreshes"`
+	ReadyTimeoutSeconds   int             `json:"readyTimeoutSeconds" binding:"min=0"`
+	MaxConsecutiveHands   int             `json:"maxConsecutiveHands" binding:"min=0"`
+	SpectatorDelaySeconds int             `json:"spectatorDelaySeconds" binding:"min=0"`
+	MaxPotMultiplier      int             `json:"maxPotMultiplier" binding:"min=0"`
+	Status                string          `json:"status" binding:"omitempty,oneof=enabled disabled"`
+	SortOrder             int             `json:"sortOrder" binding:"min=0"`
+	TimeoutPolicy         string          `json:"timeoutPolicy" binding:"omitempty,oneof=auto_pass auto_fold auto_call"`
+	RequireVerification   bool            `json:"requireVerification"`
+	TrialMode             bool            `json:"trialMode"`
+	RakeRuleID            int64           `json:"rakeRuleId" binding:"required,min=1"`
+	OpenHours             json.RawMessage `json:"openHours"`
+	RakeFreeWindows       json.RawMessage `json:"rakeFreeWindows"`
+	AllowedActions        json.RawMessage `json:"allowedActions"`
 }
 
 func (b sceneMutationBody) toParams() sceneSvc.SceneMutationParams {
@@ -156,18 +289,53 @@ func (b sceneMutationBody) toParams() sceneSvc.SceneMutationParams {
 	if status == "" {
 		status = "enabled"
 	}
+	timeoutPolicy := strings.ToLower(strings.TrimSpace(b.TimeoutPolicy))
+	if timeoutPolicy == "" {
+		timeoutPolicy = "auto_pass"
+	}
 	return sceneSvc.SceneMutationParams{
-		Name:               strings.TrimSpace(b.Name),
-		SeatCount:          b.SeatCount,
-		MinIn:              b.MinIn,
-		MaxIn:              b.MaxIn,
-		BasePi:             b.BasePi,
-		MinUnitPi:          b.MinUnitPi,
-		MangoEnabled:       b.MangoEnabled,
-		BoboEnabled:        b.BoboEnabled,
-		DistanceThresholdM: b.DistanceThresholdM,
-		Status:             status,
-		RakeRuleID:         b.RakeRuleID,
+		Name:                  strings.TrimSpace(b.Name),
+		NameI18nJSON:          b.NameI18n,
+		SeatCount:             b.SeatCount,
+		MinIn:                 b.MinIn,
+		MaxIn:                 b.MaxIn,
+		BasePi:                b.BasePi,
+		MinUnitPi:             b.MinUnitPi,
+		MangoEnabled:          b.MangoEnabled,
+		BoboEnabled:           b.BoboEnabled,
+		DistanceThresholdM:    b.DistanceThresholdM,
+		TimeBankSeconds:       b.TimeBankSeconds,
+		TimeBankBudgetSeconds: b.TimeBankBudgetSeconds,
+		TimeBankRefreshes:     b.TimeBankRefreshes,
+		ReadyTimeoutSeconds:   b.ReadyTimeoutSeconds,
+		MaxConsecutiveHands:   b.MaxConsecutiveHands,
+		SpectatorDelaySeconds: b.SpectatorDelaySeconds,
+		MaxPotMultiplier:      b.MaxPotMultiplier,
+		Status:                status,
+		SortOrder:             b.SortOrder,
+		TimeoutPolicy:         timeoutPolicy,
+		RequireVerification:   b.RequireVerification,
+		TrialMode:             b.TrialMode,
+		RakeRuleID:            b.RakeRuleID,
+		OpenHoursJSON:         b.OpenHours,
+		RakeFreeWindowsJSON:   b.RakeFreeWindows,
+		AllowedActionsJSON:    b.AllowedActions,
+	}
+}
+
+type featureFlagBody struct {
+	Key       string          `json:"key" binding:"required"`
+	Enabled   bool            `json:"enabled"`
+	RulesJSON json.RawMessage `json:"rulesJson"`
+	Remark    string          `json:"remark"`
+}
+
+func (b featureFlagBody) toParams() featuresSvc.FlagMutationParams {
+	return featuresSvc.FlagMutationParams{
+		Key:       strings.TrimSpace(b.Key),
+		Enabled:   b.Enabled,
+		RulesJSON: b.RulesJSON,
+		Remark:    strings.TrimSpace(b.Remark),
 	}
 }
 
@@ -248,7 +416,7 @@ func (h *Handler) SMSLogin(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.services.Auth.Login(c.Request.Context(), body.Phone, body.Code, body.InviteCode)
+	resp, err := h.services.Auth.Login(c.Request.Context(), body.Phone, body.Code, body.InviteCode, c.ClientIP())
 	if err != nil {
 		status := http.StatusInternalServerError
 		switch err {
@@ -256,7 +424,7 @@ func (h *Handler) SMSLogin(c *gin.Context) {
 			status = http.StatusBadRequest
 		case appErr.ErrSMSCodeExpired:
 			status = http.StatusGone
-		case appErr.ErrUserBanned:
+		case appErr.ErrUserBanned, appErr.ErrRegionBlocked:
 			status = http.StatusForbidden
 		default:
 			status = http.StatusInternalServerError
@@ -369,7 +537,57 @@ func (h *Handler) AdminUpdateScene(c *gin.Context) {
 	response.Success(c, scene)
 }
 
-func (h *Handler) AdminListRakeRules(c *gin.Context) {
+type bulkSceneStatusBody struct {
+	SceneIDs []int64 `json:"sceneIds" binding:"required,min=1"`
+	Status   string  `json:"status" binding:"required,oneof=enabled disabled"`
+	Reason   string  `json:"reason"`
+}
+
+// AdminBulkSceneStatus flips the status of several scenes in one call, e.g.
+// disabling every high-stakes scene during an incident. Each scene passes the
+// same busy checks a single disable would, and failures are reported per
+// scene so one busy scene does not block the rest.
+func (h *Handler) AdminBulkSceneStatus(c *gin.Context) {
+	var body bulkSceneStatusBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	guard := func(ctx context.Context, sceneID int64) error {
+		tables, queued, err := h.services.Match.SceneActivity(ctx, sceneID)
+		if err != nil {
+			return err
+		}
+		if tables > 0 || queued > 0 {
+			return fmt.Errorf("%w: %d active tables, %d queued", appErr.ErrSceneBusy, tables, queued)
+		}
+		return nil
+	}
+
+	results, err := h.services.Scene.BulkSetStatus(c.Request.Context(), body.SceneIDs, body.Status, guard)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	adminID := c.GetInt64(middleware.ContextAdminIDKey)
+	if err := h.services.Admin.RecordAudit(c.Request.Context(), adminSvc.AuditEntry{
+		AdminID:    adminID,
+		Action:     "scene_bulk_status",
+		TargetType: "scene",
+		After:      map[string]interface{}{"status": body.Status, "results": results},
+		Reason:     body.Reason,
+	}); err != nil {
+		logger.Log.Warn("audit record failed", zap.String("action", "scene_bulk_status"), zap.Error(err))
+	}
+
+	response.Success(c, gin.H{"results": results})
+}
+
+// AdminListTables pages through table rows with optional scene, status and
+// creation-time filters, flagging tables whose runtime is live here.
+func (h *Handler) AdminListTables(c *gin.Context) {
 	page, err := parsePositiveIntQuery(c, "page", 1)
 	if err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
@@ -381,7 +599,41 @@ func (h *Handler) AdminListRakeRules(c *gin.Context) {
 		return
 	}
 
-	result, err := h.services.Rake.List(c.Request.Context(), page, size)
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+	if status != "" && status != "waiting" && status != "playing" && status != "ended" {
+		response.Error(c, http.StatusBadRequest, "invalid status filter")
+		return
+	}
+
+	var sceneID int64
+	if raw := strings.TrimSpace(c.Query("sceneId")); raw != "" {
+		id, parseErr := strconv.ParseInt(raw, 10, 64)
+		if parseErr != nil || id <= 0 {
+			response.Error(c, http.StatusBadRequest, "invalid sceneId")
+			return
+		}
+		sceneID = id
+	}
+
+	from, err := parseTimeQuery(c, "createdFrom")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := parseTimeQuery(c, "createdTo")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.services.Game.AdminListTables(c.Request.Context(), game.AdminListTablesFilter{
+		Page:        page,
+		Size:        size,
+		SceneID:     sceneID,
+		Status:      status,
+		CreatedFrom: from,
+		CreatedTo:   to,
+	})
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -395,70 +647,174 @@ func (h *Handler) AdminListRakeRules(c *gin.Context) {
 	})
 }
 
-func (h *Handler) AdminCreateRakeRule(c *gin.Context) {
-	var body rakeRuleBody
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.Error(c, http.StatusBadRequest, err.Error())
+// AdminTableState returns what a table is doing right now: the loop-consistent
+// runtime snapshot when it is live on this instance, the persisted row
+// otherwise.
+func (h *Handler) AdminTableState(c *gin.Context) {
+	tableID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || tableID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid table id")
 		return
 	}
-	if !json.Valid(body.ConfigJSON) {
-		response.Error(c, http.StatusBadRequest, "configJson must be valid JSON")
+
+	snap, err := h.services.Game.InspectRuntime(c.Request.Context(), tableID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appErr.ErrTableNotFound) {
+			status = http.StatusNotFound
+		}
+		response.Error(c, status, err.Error())
 		return
 	}
 
-	params, err := body.toParams()
-	if err != nil {
+	response.Success(c, snap)
+}
+
+type tableRuntimeAdjustBody struct {
+	MangoStreak *int   `json:"mangoStreak"`
+	TurnSeconds *int   `json:"turnSeconds"`
+	Reason      string `json:"reason" binding:"required"`
+}
+
+func (h *Handler) AdminAdjustTableRuntime(c *gin.Context) {
+	idStr := c.Param("id")
+	tableID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || tableID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid table id")
+		return
+	}
+
+	var body tableRuntimeAdjustBody
+	if err := c.ShouldBindJSON(&body); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	rule, err := h.services.Rake.Create(c.Request.Context(), params)
+	before, after, err := h.services.Game.AdminAdjustRuntime(c.Request.Context(), tableID, game.AdminAdjustment{
+		MangoStreak: body.MangoStreak,
+		TurnSeconds: body.TurnSeconds,
+		Reason:      body.Reason,
+	})
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, err.Error())
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, appErr.ErrTableNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, appErr.ErrTableMidHand):
+			status = http.StatusConflict
+		case errors.Is(err, appErr.ErrInvalidAdjustment):
+			status = http.StatusBadRequest
+		}
+		response.Error(c, status, err.Error())
 		return
 	}
 
-	response.Success(c, gin.H{"id": rule.ID})
+	adminID := c.GetInt64(middleware.ContextAdminIDKey)
+	if err := h.services.Admin.RecordAudit(c.Request.Context(), adminSvc.AuditEntry{
+		AdminID:    adminID,
+		Action:     "table_runtime_adjust",
+		TargetType: "table",
+		TargetID:   tableID,
+		Before:     before,
+		After:      after,
+		Reason:     body.Reason,
+	}); err != nil {
+		logger.Log.Warn("audit record failed", zap.Int64("tableID", tableID), zap.Error(err))
+	}
+
+	response.Success(c, gin.H{"before": before, "after": after})
 }
 
-func (h *Handler) AdminUpdateRakeRule(c *gin.Context) {
+type tableMuteBody struct {
+	UserID          int64  `json:"userId" binding:"required,min=1"`
+	DurationSeconds int    `json:"durationSeconds" binding:"min=0"`
+	Reason          string `json:"reason"`
+}
+
+// AdminMuteTableUser silences a player's table chat for a duration; zero
+// seconds lifts an existing mute. Gameplay actions keep working.
+func (h *Handler) AdminMuteTableUser(c *gin.Context) {
 	idStr := c.Param("id")
-	ruleID, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil || ruleID <= 0 {
-		response.Error(c, http.StatusBadRequest, "invalid rake rule id")
+	tableID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || tableID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid table id")
 		return
 	}
 
-	var body rakeRuleBody
+	var body tableMuteBody
 	if err := c.ShouldBindJSON(&body); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	if !json.Valid(body.ConfigJSON) {
-		response.Error(c, http.StatusBadRequest, "configJson must be valid JSON")
+
+	duration := time.Duration(body.DurationSeconds) * time.Second
+	if err := h.services.Game.MuteUser(c.Request.Context(), tableID, body.UserID, duration); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appErr.ErrTableNotFound) {
+			status = http.StatusNotFound
+		}
+		response.Error(c, status, err.Error())
 		return
 	}
 
-	params, err := body.toParams()
-	if err != nil {
-		response.Error(c, http.StatusBadRequest, err.Error())
+	adminID := c.GetInt64(middleware.ContextAdminIDKey)
+	if err := h.services.Admin.RecordAudit(c.Request.Context(), adminSvc.AuditEntry{
+		AdminID:    adminID,
+		Action:     "table_mute",
+		TargetType: "table",
+		TargetID:   tableID,
+		After:      map[string]interface{}{"userId": body.UserID, "durationSeconds": body.DurationSeconds},
+		Reason:     body.Reason,
+	}); err != nil {
+		logger.Log.Warn("audit record failed", zap.Int64("tableID", tableID), zap.Error(err))
+	}
+
+	response.Success(c, gin.H{"userId": body.UserID, "durationSeconds": body.DurationSeconds})
+}
+
+type tableDissolveBody struct {
+	Reason string `json:"reason"`
+}
+
+// AdminDissolveTable force-closes a wedged table: the hand aborts, every
+// seat is refunded through a zero-sum settlement and the runtime goes away.
+func (h *Handler) AdminDissolveTable(c *gin.Context) {
+	idStr := c.Param("id")
+	tableID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || tableID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid table id")
 		return
 	}
 
-	rule, err := h.services.Rake.Update(c.Request.Context(), ruleID, params)
-	if err != nil {
+	var body tableDissolveBody
+	_ = c.ShouldBindJSON(&body) // reason is optional
+
+	if err := h.services.Game.AdminDissolveTable(c.Request.Context(), tableID); err != nil {
 		status := http.StatusInternalServerError
-		if errors.Is(err, appErr.ErrRakeRuleNotFound) {
+		if errors.Is(err, appErr.ErrTableNotFound) {
 			status = http.StatusNotFound
 		}
 		response.Error(c, status, err.Error())
 		return
 	}
 
-	response.Success(c, rule)
+	adminID := c.GetInt64(middleware.ContextAdminIDKey)
+	if err := h.services.Admin.RecordAudit(c.Request.Context(), adminSvc.AuditEntry{
+		AdminID:    adminID,
+		Action:     "table_dissolve",
+		TargetType: "table",
+		TargetID:   tableID,
+		Reason:     body.Reason,
+	}); err != nil {
+		logger.Log.Warn("audit record failed", zap.Int64("tableID", tableID), zap.Error(err))
+	}
+
+	response.Success(c, gin.H{"tableId": tableID, "status": "dissolved"})
 }
 
-func (h *Handler) AdminListAgentRules(c *gin.Context) {
+// AdminListReaperRuns pages the stale-table reaper's run reports, newest
+// first; operators review dry runs here before enabling automatic cleanup.
+func (h *Handler) AdminListReaperRuns(c *gin.Context) {
 	page, err := parsePositiveIntQuery(c, "page", 1)
 	if err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
@@ -470,83 +826,133 @@ func (h *Handler) AdminListAgentRules(c *gin.Context) {
 		return
 	}
 
-	result, err := h.services.Agent.List(c.Request.Context(), page, size)
+	items, total, err := h.services.Game.ListReaperRuns(c.Request.Context(), page, size)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	response.Success(c, gin.H{
-		"items": result.Items,
-		"total": result.Total,
+		"items": items,
+		"total": total,
 		"page":  page,
 		"size":  size,
 	})
 }
 
-func (h *Handler) AdminCreateAgentRule(c *gin.Context) {
-	var body agentRuleBody
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.Error(c, http.StatusBadRequest, err.Error())
+// AdminListBreakers reports the circuit breakers guarding the Redis-dependent
+// operation groups, so the dashboard shows which paths run degraded.
+func (h *Handler) AdminListBreakers(c *gin.Context) {
+	response.Success(c, gin.H{"breakers": h.services.Breakers.Snapshots()})
+}
+
+func (h *Handler) AdminListJobs(c *gin.Context) {
+	response.Success(c, gin.H{"jobs": h.services.Jobs.Jobs()})
+}
+
+type spawnBotTableBody struct {
+	Seats    int   `json:"seats" binding:"min=0,max=8"`
+	BasePi   int64 `json:"basePi" binding:"min=0"`
+	BuyIn    int64 `json:"buyIn" binding:"min=0"`
+	Autoplay bool  `json:"autoplay"`
+}
+
+// AdminSpawnBotTable composes a bot-seated scene/table/match for QA or load
+// testing and returns a WS token per seat. With autoplay the seats are
+// driven through a full hand against this instance. Debug mode only.
+func (h *Handler) AdminSpawnBotTable(c *gin.Context) {
+	if !bot.Enabled() {
+		response.Error(c, http.StatusForbidden, bot.ErrDisabled.Error())
 		return
 	}
-
-	params, err := body.toParams()
-	if err != nil {
+	var body spawnBotTableBody
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	rule, err := h.services.Agent.Create(c.Request.Context(), params)
+	result, err := bot.SpawnTable(c.Request.Context(), repo.DB, bot.SpawnOptions{
+		Seats:  body.Seats,
+		BasePi: body.BasePi,
+		BuyIn:  body.BuyIn,
+	})
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, appErr.ErrInvalidAgentRule) {
-			status = http.StatusBadRequest
-		}
-		response.Error(c, status, err.Error())
+		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	response.Success(c, gin.H{"id": rule.ID})
+	if body.Autoplay {
+		go runBotHand(result)
+	}
+	response.Success(c, result)
 }
 
-func (h *Handler) AdminUpdateAgentRule(c *gin.Context) {
-	idStr := c.Param("id")
-	ruleID, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil || ruleID <= 0 {
-		response.Error(c, http.StatusBadRequest, "invalid agent rule id")
-		return
-	}
+// runBotHand plays one full hand on a freshly spawned bot table against the
+// local listener.
+func runBotHand(result *bot.SpawnResult) {
+	base := fmt.Sprintf("http://127.0.0.1:%s", config.GlobalConfig.Server.Port)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, seat := range result.Seats {
+		wg.Add(1)
+		go func(seat bot.SpawnSeat) {
+			defer wg.Done()
+			player := &bot.Player{
+				BaseURL: base,
+				TableID: result.TableID,
+				Token:   seat.Token,
+				Think:   200 * time.Millisecond,
+			}
+			if err := player.Run(ctx); err != nil {
+				logger.Log.Warn("bot player exited",
+					zap.Int64("tableID", result.TableID),
+					zap.Int64("userID", seat.UserID),
+					zap.Error(err))
+			}
+		}(seat)
+	}
+	wg.Wait()
+	logger.Log.Info("bot hand finished", zap.Int64("tableID", result.TableID))
+}
 
-	var body agentRuleBody
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.Error(c, http.StatusBadRequest, err.Error())
+// AdminIntegrityReport runs the read-only reference-data and wallet
+// validations and returns the findings grouped by severity.
+func (h *Handler) AdminIntegrityReport(c *gin.Context) {
+	report, err := h.services.Integrity.Run(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	response.Success(c, report)
+}
 
-	params, err := body.toParams()
-	if err != nil {
-		response.Error(c, http.StatusBadRequest, err.Error())
+func (h *Handler) AdminRunJob(c *gin.Context) {
+	name := strings.TrimSpace(c.Param("name"))
+	if name == "" {
+		response.Error(c, http.StatusBadRequest, "invalid job name")
 		return
 	}
 
-	rule, err := h.services.Agent.Update(c.Request.Context(), ruleID, params)
-	if err != nil {
+	if err := h.services.Jobs.RunNow(name); err != nil {
 		status := http.StatusInternalServerError
 		switch {
-		case errors.Is(err, appErr.ErrAgentRuleNotFound):
+		case errors.Is(err, appErr.ErrJobNotFound):
 			status = http.StatusNotFound
-		case errors.Is(err, appErr.ErrInvalidAgentRule):
-			status = http.StatusBadRequest
+		case errors.Is(err, appErr.ErrJobAlreadyRunning):
+			status = http.StatusConflict
 		}
 		response.Error(c, status, err.Error())
 		return
 	}
 
-	response.Success(c, rule)
+	response.Success(c, gin.H{"name": name})
 }
 
-func (h *Handler) AdminListUsers(c *gin.Context) {
+// AdminListFailedDeliveries pages the dead-letter queue, optionally filtered
+// by delivery type. The per-type depths ride along so an operator can spot a
+// stalling worker without a second call.
+func (h *Handler) AdminListFailedDeliveries(c *gin.Context) {
 	page, err := parsePositiveIntQuery(c, "page", 1)
 	if err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
@@ -558,168 +964,1007 @@ func (h *Handler) AdminListUsers(c *gin.Context) {
 		return
 	}
 
-	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
-	if status != "" && status != "normal" && status != "banned" {
-		response.Error(c, http.StatusBadRequest, "invalid status filter")
+	result, err := h.services.Delivery.ListFailed(c.Request.Context(), deliverySvc.ListFilter{
+		Type: strings.TrimSpace(c.Query("type")),
+		Page: page,
+		Size: size,
+	})
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	phone := strings.TrimSpace(c.Query("phone"))
-	inviteCode := strings.TrimSpace(c.Query("inviteCode"))
-	agentIDStr := strings.TrimSpace(c.Query("agentId"))
-	var agentID *int64
-	if agentIDStr != "" {
-		id, parseErr := strconv.ParseInt(agentIDStr, 10, 64)
-		if parseErr != nil || id <= 0 {
-			response.Error(c, http.StatusBadRequest, "invalid agentId")
-			return
-		}
-		agentID = &id
-	}
-
-	result, err := h.services.User.AdminListUsers(c.Request.Context(), usersvc.AdminListUsersFilter{
-		Page:         page,
-		Size:         size,
-		Status:       status,
-		PhoneKeyword: phone,
-		InviteCode:   inviteCode,
-		AgentID:      agentID,
-	})
+	depths, err := h.services.Delivery.Depths(c.Request.Context())
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	response.Success(c, gin.H{
-		"items": result.Items,
-		"total": result.Total,
-		"page":  page,
-		"size":  size,
+		"items":  result.Items,
+		"total":  result.Total,
+		"depths": depths,
+		"page":   page,
+		"size":   size,
 	})
 }
 
-func (h *Handler) AdminGetUser(c *gin.Context) {
-	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil || userID <= 0 {
-		response.Error(c, http.StatusBadRequest, "invalid user id")
+func (h *Handler) AdminRetryDelivery(c *gin.Context) {
+	failureID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || failureID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid delivery failure id")
 		return
 	}
 
-	user, err := h.services.User.AdminGetUser(c.Request.Context(), userID)
-	if err != nil {
+	adminID := c.GetInt64(middleware.ContextAdminIDKey)
+	if err := h.services.Delivery.Retry(c.Request.Context(), failureID, adminID); err != nil {
 		status := http.StatusInternalServerError
-		if errors.Is(err, appErr.ErrUserNotFound) {
+		switch {
+		case errors.Is(err, appErr.ErrDeliveryFailureNotFound):
 			status = http.StatusNotFound
+		case errors.Is(err, appErr.ErrDeliveryAlreadyReplayed):
+			status = http.StatusConflict
+		case errors.Is(err, appErr.ErrUnknownDeliveryType):
+			status = http.StatusBadRequest
 		}
 		response.Error(c, status, err.Error())
 		return
 	}
 
-	response.Success(c, gin.H{"user": user})
+	response.Success(c, gin.H{"id": failureID, "status": deliverySvc.StatusReplayed})
 }
 
-func (h *Handler) AdminBanUser(c *gin.Context) {
-	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil || userID <= 0 {
+// AdminRetryDeliveryBatch replays every failed delivery of one type (or all
+// of them without a filter); rows that fail again stay queued.
+func (h *Handler) AdminRetryDeliveryBatch(c *gin.Context) {
+	adminID := c.GetInt64(middleware.ContextAdminIDKey)
+	result, err := h.services.Delivery.RetryBatch(c.Request.Context(),
+		strings.TrimSpace(c.Query("type")), adminID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+type adminPanicBody struct {
+	SceneID int64  `json:"sceneId"` // zero freezes money movement globally
+	Reason  string `json:"reason" binding:"required"`
+}
+
+// AdminEngagePanic raises the panic-stop flag: settlements defer through the
+// dead-letter queue and wallet adjustments are rejected until resume.
+func (h *Handler) AdminEngagePanic(c *gin.Context) {
+	var body adminPanicBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.Panic.Engage(c.Request.Context(), body.SceneID, body.Reason); err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	adminID := c.GetInt64(middleware.ContextAdminIDKey)
+	if err := h.services.Admin.RecordAudit(c.Request.Context(), adminSvc.AuditEntry{
+		AdminID:    adminID,
+		Action:     "panic_engage",
+		TargetType: "scene",
+		TargetID:   body.SceneID,
+		Reason:     body.Reason,
+	}); err != nil {
+		logger.Log.Warn("audit record failed", zap.String("action", "panic_engage"), zap.Error(err))
+	}
+
+	response.Success(c, gin.H{"sceneId": body.SceneID, "engaged": true})
+}
+
+// AdminResumePanic lowers the panic-stop flag and drains the settlements
+// deferred while it was up, oldest first.
+func (h *Handler) AdminResumePanic(c *gin.Context) {
+	sceneID := int64(0)
+	if raw := strings.TrimSpace(c.Query("sceneId")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			response.Error(c, http.StatusBadRequest, "invalid scene id")
+			return
+		}
+		sceneID = parsed
+	}
+
+	if err := h.services.Panic.Resume(c.Request.Context(), sceneID); err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	adminID := c.GetInt64(middleware.ContextAdminIDKey)
+	drained, err := h.services.Delivery.RetryBatch(c.Request.Context(), deliverySvc.TypeSettlement, adminID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := h.services.Admin.RecordAudit(c.Request.Context(), adminSvc.AuditEntry{
+		AdminID:    adminID,
+		Action:     "panic_resume",
+		TargetType: "scene",
+		TargetID:   sceneID,
+		After:      drained,
+	}); err != nil {
+		logger.Log.Warn("audit record failed", zap.String("action", "panic_resume"), zap.Error(err))
+	}
+
+	response.Success(c, gin.H{"sceneId": sceneID, "engaged": false, "drained": drained})
+}
+
+func (h *Handler) AdminListFeatureFlags(c *gin.Context) {
+	page, err := parsePositiveIntQuery(c, "page", 1)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	size, err := parsePositiveIntQuery(c, "size", 20)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.services.Features.ListFlags(c.Request.Context(), page, size)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"items": result.Items,
+		"total": result.Total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+func (h *Handler) AdminCreateFeatureFlag(c *gin.Context) {
+	var body featureFlagBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	flag, err := h.services.Features.CreateFlag(c.Request.Context(), body.toParams())
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, appErr.ErrInvalidFlagRules):
+			status = http.StatusBadRequest
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			status = http.StatusConflict
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"id": flag.ID})
+}
+
+func (h *Handler) AdminUpdateFeatureFlag(c *gin.Context) {
+	idStr := c.Param("id")
+	flagID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || flagID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid feature flag id")
+		return
+	}
+
+	var body featureFlagBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	flag, err := h.services.Features.UpdateFlag(c.Request.Context(), flagID, body.toParams())
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, appErr.ErrFeatureFlagNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, appErr.ErrInvalidFlagRules):
+			status = http.StatusBadRequest
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			status = http.StatusConflict
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, flag)
+}
+
+func (h *Handler) AdminDeleteFeatureFlag(c *gin.Context) {
+	idStr := c.Param("id")
+	flagID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || flagID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid feature flag id")
+		return
+	}
+
+	if err := h.services.Features.DeleteFlag(c.Request.Context(), flagID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appErr.ErrFeatureFlagNotFound) {
+			status = http.StatusNotFound
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"id": flagID})
+}
+
+func (h *Handler) AdminListRakeRules(c *gin.Context) {
+	page, err := parsePositiveIntQuery(c, "page", 1)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	size, err := parsePositiveIntQuery(c, "size", 20)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.services.Rake.List(c.Request.Context(), page, size)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"items": result.Items,
+		"total": result.Total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+func (h *Handler) AdminRakeReport(c *gin.Context) {
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	page, err := parsePositiveIntQuery(c, "page", 1)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	size, err := parsePositiveIntQuery(c, "size", 20)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.services.Rake.Report(c.Request.Context(), rakeSvc.ReportFilter{
+		From:    from,
+		To:      to,
+		GroupBy: c.DefaultQuery("groupBy", rakeSvc.GroupByScene),
+		Page:    page,
+		Size:    size,
+	})
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"items": result.Items,
+		"total": result.Total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+func (h *Handler) AdminBackfillRakeReport(c *gin.Context) {
+	created, err := h.services.Rake.BackfillMatchRake(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, gin.H{"created": created})
+}
+
+func (h *Handler) AdminCreateRakeRule(c *gin.Context) {
+	var body rakeRuleBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !json.Valid(body.ConfigJSON) {
+		response.Error(c, http.StatusBadRequest, "configJson must be valid JSON")
+		return
+	}
+
+	params, err := body.toParams()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := h.services.Rake.Create(c.Request.Context(), params)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"id": rule.ID})
+}
+
+func (h *Handler) AdminUpdateRakeRule(c *gin.Context) {
+	idStr := c.Param("id")
+	ruleID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || ruleID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid rake rule id")
+		return
+	}
+
+	var body rakeRuleBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !json.Valid(body.ConfigJSON) {
+		response.Error(c, http.StatusBadRequest, "configJson must be valid JSON")
+		return
+	}
+
+	params, err := body.toParams()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := h.services.Rake.Update(c.Request.Context(), ruleID, params)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appErr.ErrRakeRuleNotFound) {
+			status = http.StatusNotFound
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+func (h *Handler) AdminListAgentRules(c *gin.Context) {
+	page, err := parsePositiveIntQuery(c, "page", 1)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	size, err := parsePositiveIntQuery(c, "size", 20)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.services.Agent.List(c.Request.Context(), page, size)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"items": result.Items,
+		"total": result.Total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+func (h *Handler) AdminCreateAgentRule(c *gin.Context) {
+	var body agentRuleBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params, err := body.toParams()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := h.services.Agent.Create(c.Request.Context(), params)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appErr.ErrInvalidAgentRule) {
+			status = http.StatusBadRequest
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"id": rule.ID})
+}
+
+func (h *Handler) AdminUpdateAgentRule(c *gin.Context) {
+	idStr := c.Param("id")
+	ruleID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || ruleID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid agent rule id")
+		return
+	}
+
+	var body agentRuleBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params, err := body.toParams()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := h.services.Agent.Update(c.Request.Context(), ruleID, params)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, appErr.ErrAgentRuleNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, appErr.ErrInvalidAgentRule):
+			status = http.StatusBadRequest
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+func (h *Handler) AdminListUsers(c *gin.Context) {
+	page, err := parsePositiveIntQuery(c, "page", 1)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	size, err := parsePositiveIntQuery(c, "size", 20)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+	if status != "" && status != "normal" && status != "banned" {
+		response.Error(c, http.StatusBadRequest, "invalid status filter")
+		return
+	}
+
+	phone := strings.TrimSpace(c.Query("phone"))
+	inviteCode := strings.TrimSpace(c.Query("inviteCode"))
+	agentIDStr := strings.TrimSpace(c.Query("agentId"))
+	var agentID *int64
+	if agentIDStr != "" {
+		id, parseErr := strconv.ParseInt(agentIDStr, 10, 64)
+		if parseErr != nil || id <= 0 {
+			response.Error(c, http.StatusBadRequest, "invalid agentId")
+			return
+		}
+		agentID = &id
+	}
+
+	result, err := h.services.User.AdminListUsers(c.Request.Context(), usersvc.AdminListUsersFilter{
+		Page:         page,
+		Size:         size,
+		Status:       status,
+		PhoneKeyword: phone,
+		InviteCode:   inviteCode,
+		AgentID:      agentID,
+	})
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"items": result.Items,
+		"total": result.Total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+func (h *Handler) AdminGetUser(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	user, err := h.services.User.AdminGetUser(c.Request.Context(), userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appErr.ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"user": user})
+}
+
+func (h *Handler) AdminUserBehavior(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	window, err := parseWindowQuery(c, "window", 30*24*time.Hour)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	summary, err := h.services.Game.UserBehavior(c.Request.Context(), userID, window)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, summary)
+}
+
+// parseWindowQuery accepts Go durations plus a day suffix ("30d") since admin
+// report windows are usually counted in days.
+func parseWindowQuery(c *gin.Context, key string, defaultVal time.Duration) (time.Duration, error) {
+	val := c.Query(key)
+	if val == "" {
+		return defaultVal, nil
+	}
+	if strings.HasSuffix(val, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(val, "d"))
+		if err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	dur, err := time.ParseDuration(val)
+	if err != nil || dur <= 0 {
+		return 0, fmt.Errorf("invalid %s", key)
+	}
+	return dur, nil
+}
+
+func (h *Handler) AdminBanUser(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	var body adminUserBanBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := strings.ToLower(strings.TrimSpace(body.Status))
+	if status != "normal" && status != "banned" {
+		response.Error(c, http.StatusBadRequest, "status must be 'normal' or 'banned'")
+		return
+	}
+
+	updated, err := h.services.User.AdminUpdateUserStatus(c.Request.Context(), userID, status, body.Reason)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, appErr.ErrUserNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, appErr.ErrInvalidUserStatus):
+			statusCode = http.StatusBadRequest
+		}
+		response.Error(c, statusCode, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"user": updated})
+}
+
+func (h *Handler) AdminSetUserGeoBypass(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
 		response.Error(c, http.StatusBadRequest, "invalid user id")
 		return
 	}
 
-	var body adminUserBanBody
+	var body adminGeoBypassBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := h.services.User.AdminSetGeoBypass(c.Request.Context(), userID, *body.Allow, body.Reason)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, appErr.ErrUserNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		response.Error(c, statusCode, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"user": updated})
+}
+
+func (h *Handler) AdminSetUserWallet(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	var body adminSetWalletBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wallet, err := h.services.Wallet.AdminSetWallet(c.Request.Context(), userID, walletsvc.AdminSetWalletRequest{
+		BalanceAvailable: body.BalanceAvailable,
+		BalanceFrozen:    body.BalanceFrozen,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, appErr.ErrInvalidWalletPayload):
+			status = http.StatusBadRequest
+		case errors.Is(err, appErr.ErrWalletBusy):
+			status = http.StatusConflict
+		case errors.Is(err, appErr.ErrPanicStop):
+			status = http.StatusServiceUnavailable
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"wallet": wallet})
+}
+
+func (h *Handler) MatchJoin(c *gin.Context) {
+	var body matchJoinBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	req := match.JoinQueueRequest{
+		UserID:  userID,
+		SceneID: body.SceneID,
+		BuyIn:   body.BuyIn,
+		GPSLat:  body.GPSLat,
+		GPSLng:  body.GPSLng,
+		IP:      c.ClientIP(),
+	}
+
+	queueID, err := h.services.Match.JoinQueue(c.Request.Context(), req)
+	if err != nil {
+		h.handleMatchError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"queueId": queueID,
+		"status":  match.QueueStatusQueued,
+	})
+}
+
+func (h *Handler) MatchCancel(c *gin.Context) {
+	var body matchCancelBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.services.Match.CancelQueue(c.Request.Context(), match.CancelQueueRequest{
+		UserID:  userID,
+		SceneID: body.SceneID,
+		Reason:  "user_cancel",
+	}); err != nil {
+		h.handleMatchError(c, err)
+		return
+	}
+
+	response.SuccessWithMsg(c, gin.H{"status": "cancelled"}, "")
+}
+
+func (h *Handler) MatchStatus(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// sceneId is optional: without it the status covers every queued scene.
+	var sceneID int64
+	if c.Query("sceneId") != "" {
+		var err error
+		sceneID, err = parseInt64Query(c, "sceneId")
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	status, err := h.services.Match.GetStatus(c.Request.Context(), userID, sceneID)
+	if err != nil {
+		h.handleMatchError(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// TableCurrent reports where the caller is still seated so a freshly
+// reopened app can reconnect its table WS directly instead of re-queueing.
+func (h *Handler) TableCurrent(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	current, err := h.services.Game.CurrentTable(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if current == nil {
+		response.Success(c, gin.H{"active": false})
+		return
+	}
+
+	response.Success(c, gin.H{"active": true, "current": current})
+}
+
+// PracticeStart opens a single-player chexuan practice table against house
+// bots and returns the table ID the client connects its WS to. Idempotent:
+// tapping again reattaches to the learner's live practice table.
+func (h *Handler) PracticeStart(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	tableID, err := h.services.Game.StartPractice(c.Request.Context(), userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appErr.ErrServerBusy) {
+			status = http.StatusServiceUnavailable
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"tableId": tableID})
+}
+
+func (h *Handler) TableInvite(c *gin.Context) {
+	tableID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || tableID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid table id")
+		return
+	}
+
+	var body tableInviteBody
 	if err := c.ShouldBindJSON(&body); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	if body.UserID == 0 && body.Phone == "" {
+		response.Error(c, http.StatusBadRequest, "userId or phone required")
+		return
+	}
 
-	status := strings.ToLower(strings.TrimSpace(body.Status))
-	if status != "normal" && status != "banned" {
-		response.Error(c, http.StatusBadRequest, "status must be 'normal' or 'banned'")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	updated, err := h.services.User.AdminUpdateUserStatus(c.Request.Context(), userID, status, body.Reason)
+	inv, err := h.services.Game.Invite(c.Request.Context(), game.InviteRequest{
+		InviterID: userID,
+		TableID:   tableID,
+		InviteeID: body.UserID,
+		Phone:     body.Phone,
+	})
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		switch {
-		case errors.Is(err, appErr.ErrUserNotFound):
-			statusCode = http.StatusNotFound
-		case errors.Is(err, appErr.ErrInvalidUserStatus):
-			statusCode = http.StatusBadRequest
-		}
-		response.Error(c, statusCode, err.Error())
+		h.handleInviteError(c, err)
 		return
 	}
 
-	response.Success(c, gin.H{"user": updated})
+	response.Success(c, gin.H{"invitation": inv})
 }
 
-func (h *Handler) AdminSetUserWallet(c *gin.Context) {
-	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil || userID <= 0 {
-		response.Error(c, http.StatusBadRequest, "invalid user id")
-		return
+// replayQueryFromRequest reads the shared round-range pagination of the
+// replay endpoints: fromRound (default 0) and rounds (default all).
+func replayQueryFromRequest(c *gin.Context) (game.ReplayQuery, error) {
+	var q game.ReplayQuery
+	if c.Query("fromRound") != "" {
+		fromRound, err := parseInt64Query(c, "fromRound")
+		if err != nil || fromRound < 0 {
+			return q, fmt.Errorf("invalid fromRound")
+		}
+		q.FromRound = int(fromRound)
+	}
+	if c.Query("rounds") != "" {
+		rounds, err := parseInt64Query(c, "rounds")
+		if err != nil || rounds <= 0 {
+			return q, fmt.Errorf("invalid rounds")
+		}
+		q.RoundCount = int(rounds)
 	}
+	return q, nil
+}
 
-	var body adminSetWalletBody
-	if err := c.ShouldBindJSON(&body); err != nil {
+// MatchReplay returns the stitched action timeline of a match the caller
+// played in; only their own cards are decrypted.
+func (h *Handler) MatchReplay(c *gin.Context) {
+	matchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || matchID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid match id")
+		return
+	}
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	q, err := replayQueryFromRequest(c)
+	if err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	q.ViewerID = userID
 
-	wallet, err := h.services.Wallet.AdminSetWallet(c.Request.Context(), userID, walletsvc.AdminSetWalletRequest{
-		BalanceAvailable: body.BalanceAvailable,
-		BalanceFrozen:    body.BalanceFrozen,
-	})
+	replay, err := h.services.Game.MatchReplay(c.Request.Context(), matchID, q)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, appErr.ErrInvalidWalletPayload) {
-			status = http.StatusBadRequest
+		switch {
+		case errors.Is(err, appErr.ErrMatchNotFound):
+			response.Error(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, appErr.ErrTableAccessDenied):
+			response.Error(c, http.StatusForbidden, err.Error())
+		default:
+			response.Error(c, http.StatusInternalServerError, err.Error())
 		}
-		response.Error(c, status, err.Error())
 		return
 	}
-
-	response.Success(c, gin.H{"wallet": wallet})
+	response.Success(c, replay)
 }
 
-func (h *Handler) MatchJoin(c *gin.Context) {
-	var body matchJoinBody
-	if err := c.ShouldBindJSON(&body); err != nil {
+// AdminMatchReplay is the operator variant: every seat's cards decrypt.
+func (h *Handler) AdminMatchReplay(c *gin.Context) {
+	matchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || matchID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid match id")
+		return
+	}
+	q, err := replayQueryFromRequest(c)
+	if err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	q.AllSeats = true
 
-	userID, ok := getUserID(c)
-	if !ok {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+	replay, err := h.services.Game.MatchReplay(c.Request.Context(), matchID, q)
+	if err != nil {
+		if errors.Is(err, appErr.ErrMatchNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, replay)
+}
+
+// AdminVerifyMatchLog recomputes a match's round-log HMAC chain and reports
+// the first row that no longer matches, for dispute resolution.
+func (h *Handler) AdminVerifyMatchLog(c *gin.Context) {
+	matchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || matchID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid match id")
 		return
 	}
 
-	req := match.JoinQueueRequest{
-		UserID:  userID,
-		SceneID: body.SceneID,
-		BuyIn:   body.BuyIn,
-		GPSLat:  body.GPSLat,
-		GPSLng:  body.GPSLng,
-		IP:      c.ClientIP(),
+	report, err := h.services.Game.VerifyMatchLog(c.Request.Context(), matchID)
+	if err != nil {
+		if errors.Is(err, appErr.ErrMatchNotFound) {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, report)
+}
+
+// TableLogs pages the persisted table narrative for rejoining clients whose
+// state export only carries the most recent entries.
+func (h *Handler) TableLogs(c *gin.Context) {
+	tableID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || tableID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid table id")
+		return
 	}
 
-	queueID, err := h.services.Match.JoinQueue(c.Request.Context(), req)
+	offset := int64(0)
+	if c.Query("offset") != "" {
+		if offset, err = parseInt64Query(c, "offset"); err != nil || offset < 0 {
+			response.Error(c, http.StatusBadRequest, "invalid offset")
+			return
+		}
+	}
+	limit := int64(50)
+	if c.Query("limit") != "" {
+		if limit, err = parseInt64Query(c, "limit"); err != nil || limit <= 0 {
+			response.Error(c, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	logs, err := h.services.Game.TableLogs(c.Request.Context(), tableID, offset, limit)
 	if err != nil {
-		h.handleMatchError(c, err)
+		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	response.Success(c, gin.H{
-		"queueId": queueID,
-		"status":  match.QueueStatusQueued,
+		"items":  logs,
+		"offset": offset,
+		"limit":  limit,
 	})
 }
 
-func (h *Handler) MatchCancel(c *gin.Context) {
-	var body matchCancelBody
+func (h *Handler) ListInvitations(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	invitations, err := h.services.Game.PendingInvitations(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"invitations": invitations})
+}
+
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	var body invitationAcceptBody
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -729,42 +1974,100 @@ func (h *Handler) MatchCancel(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.Match.CancelQueue(c.Request.Context(), match.CancelQueueRequest{
-		UserID:  userID,
-		SceneID: body.SceneID,
-		Reason:  "user_cancel",
-	}); err != nil {
-		h.handleMatchError(c, err)
+	inv, err := h.services.Game.AcceptInvitation(c.Request.Context(), userID, body.Token)
+	if err != nil {
+		h.handleInviteError(c, err)
 		return
 	}
 
-	response.SuccessWithMsg(c, gin.H{"status": "cancelled"}, "")
+	response.Success(c, gin.H{"tableId": inv.TableID, "sceneId": inv.SceneID})
 }
 
-func (h *Handler) MatchStatus(c *gin.Context) {
+func (h *Handler) ListNotifications(c *gin.Context) {
 	userID, ok := getUserID(c)
 	if !ok {
 		response.Error(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	sceneID, err := parseInt64Query(c, "sceneId")
+	page, err := parsePositiveIntQuery(c, "page", 1)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	size, err := parsePositiveIntQuery(c, "size", 20)
 	if err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	status, err := h.services.Match.GetStatus(c.Request.Context(), userID, sceneID)
+	result, err := h.services.Notify.List(c.Request.Context(), userID, notifysvc.ListFilter{
+		UnreadOnly: c.Query("unread") == "true",
+		Page:       page,
+		Size:       size,
+	})
 	if err != nil {
-		h.handleMatchError(c, err)
+		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	response.Success(c, status)
+	response.Success(c, gin.H{
+		"items": result.Items,
+		"total": result.Total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+func (h *Handler) MarkNotificationRead(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	notificationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || notificationID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid notification id")
+		return
+	}
+
+	if err := h.services.Notify.MarkRead(c.Request.Context(), userID, notificationID); err != nil {
+		if err == appErr.ErrNotificationNotFound {
+			response.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.SuccessWithMsg(c, gin.H{"status": "read"}, "")
+}
+
+func (h *Handler) handleInviteError(c *gin.Context, err error) {
+	switch err {
+	case appErr.ErrTableNotFound, appErr.ErrSceneNotFound, appErr.ErrUserNotFound, appErr.ErrInvitationNotFound:
+		response.Error(c, http.StatusNotFound, err.Error())
+	case appErr.ErrTableMidHand, appErr.ErrTableFull, appErr.ErrAlreadySeated:
+		response.Error(c, http.StatusConflict, err.Error())
+	case appErr.ErrTableAccessDenied:
+		response.Error(c, http.StatusForbidden, err.Error())
+	case appErr.ErrInsufficientBalance:
+		response.Error(c, http.StatusBadRequest, "余额不足")
+	case appErr.ErrUserBanned:
+		response.Error(c, http.StatusForbidden, err.Error())
+	case appErr.ErrVerificationRequired:
+		response.JSON(c, http.StatusForbidden,
+			gin.H{"reason": "verification_required", "verifyUrl": "/dxService/v1/user/verify"},
+			"请先完成实名认证")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
 }
 
 func (h *Handler) ListScenes(c *gin.Context) {
-	scenes, err := h.services.Scene.ListScenes(c.Request.Context())
+	locale := sceneSvc.ResolveLocale(c.GetHeader("Accept-Language"))
+	scenes, err := h.services.Scene.ListScenesWithSchedule(c.Request.Context(), locale)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -797,7 +2100,17 @@ func (h *Handler) GetProfile(c *gin.Context) {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
-	response.Success(c, profile)
+
+	unread, err := h.services.Notify.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, struct {
+		*model.User
+		UnreadNotifications int64 `json:"unreadNotifications"`
+	}{profile, unread})
 }
 
 func (h *Handler) UpdateProfile(c *gin.Context) {
@@ -827,7 +2140,128 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	response.Success(c, updated)
 }
 
+type verifyBody struct {
+	Name     string `json:"name" binding:"required"`
+	IDNumber string `json:"idNumber" binding:"required"`
+}
+
+// SubmitVerification runs the real-name check; provider failures park the
+// user as pending with the submission queued for admin review.
+func (h *Handler) SubmitVerification(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var body verifyBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.services.User.SubmitVerification(c.Request.Context(), userID, body.Name, body.IDNumber)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, appErr.ErrInvalidVerification):
+			status = http.StatusBadRequest
+		case errors.Is(err, appErr.ErrAlreadyVerified):
+			status = http.StatusConflict
+		case errors.Is(err, appErr.ErrUserNotFound):
+			status = http.StatusNotFound
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// AdminListVerifications pages the review queue; operators normally filter
+// to status=failed.
+func (h *Handler) AdminListVerifications(c *gin.Context) {
+	page, err := parsePositiveIntQuery(c, "page", 1)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	size, err := parsePositiveIntQuery(c, "size", 20)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+	if status != "" && status != "pending" && status != "verified" && status != "failed" && status != "rejected" {
+		response.Error(c, http.StatusBadRequest, "invalid status filter")
+		return
+	}
+
+	items, total, err := h.services.User.AdminListVerifications(c.Request.Context(), status, page, size)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"items": items,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+type verificationReviewBody struct {
+	Approve bool   `json:"approve"`
+	Reason  string `json:"reason"`
+}
+
+// AdminReviewVerification settles a queued submission by hand.
+func (h *Handler) AdminReviewVerification(c *gin.Context) {
+	requestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || requestID <= 0 {
+		response.Error(c, http.StatusBadRequest, "invalid verification id")
+		return
+	}
+
+	var body verificationReviewBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	adminID := c.GetInt64(middleware.ContextAdminIDKey)
+	request, err := h.services.User.AdminReviewVerification(c.Request.Context(), requestID, adminID, body.Approve)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appErr.ErrVerificationNotFound) {
+			status = http.StatusNotFound
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	if err := h.services.Admin.RecordAudit(c.Request.Context(), adminSvc.AuditEntry{
+		AdminID:    adminID,
+		Action:     "verification_review",
+		TargetType: "verification",
+		TargetID:   requestID,
+		After:      map[string]interface{}{"status": request.Status, "userId": request.UserID},
+		Reason:     body.Reason,
+	}); err != nil {
+		logger.Log.Warn("audit record failed", zap.Int64("verificationID", requestID), zap.Error(err))
+	}
+
+	response.Success(c, request)
+}
+
 func (h *Handler) handleMatchError(c *gin.Context, err error) {
+	// ErrSceneClosed is wrapped to carry the next opening time, so match it
+	// before the equality switch.
+	if errors.Is(err, appErr.ErrSceneClosed) {
+		response.Error(c, http.StatusForbidden, err.Error())
+		return
+	}
 	switch err {
 	case appErr.ErrSceneNotFound:
 		response.Error(c, http.StatusNotFound, err.Error())
@@ -835,10 +2269,26 @@ func (h *Handler) handleMatchError(c *gin.Context, err error) {
 		response.Error(c, http.StatusBadRequest, "买入金额不合法")
 	case appErr.ErrInsufficientBalance:
 		response.Error(c, http.StatusBadRequest, "余额不足")
-	case appErr.ErrAlreadyInQueue:
+	case appErr.ErrWalletNotInitialized:
+		// First-time users get a distinct reason plus where to top up,
+		// instead of the confusing generic insufficient-balance message.
+		response.JSON(c, http.StatusBadRequest,
+			gin.H{"reason": "wallet_not_initialized", "walletUrl": "/dxService/v1/wallet"},
+			"钱包未初始化，请先充值")
+	case appErr.ErrAlreadyInQueue, appErr.ErrTooManyQueues:
 		response.Error(c, http.StatusConflict, err.Error())
 	case appErr.ErrQueueProcessing:
 		response.Error(c, http.StatusTooManyRequests, err.Error())
+	case appErr.ErrServerBusy, appErr.ErrMatchmakingUnavailable:
+		response.Error(c, http.StatusServiceUnavailable, err.Error())
+	case appErr.ErrRegionBlocked:
+		response.Error(c, http.StatusForbidden, err.Error())
+	case appErr.ErrVerificationRequired:
+		// Point the client straight at the verification flow rather than a
+		// bare 403.
+		response.JSON(c, http.StatusForbidden,
+			gin.H{"reason": "verification_required", "verifyUrl": "/dxService/v1/user/verify"},
+			"请先完成实名认证")
 	default:
 		response.Error(c, http.StatusInternalServerError, err.Error())
 	}
@@ -870,6 +2320,18 @@ func getUserID(c *gin.Context) (int64, bool) {
 	return id, ok
 }
 
+func parseTimeQuery(c *gin.Context, key string) (time.Time, error) {
+	val := c.Query(key)
+	if val == "" {
+		return time.Time{}, nil
+	}
+	ts, err := parseTimeWithLayouts(val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s, expected RFC3339 or '2006-01-02 15:04:05'", key)
+	}
+	return *ts, nil
+}
+
 func parseTimeWithLayouts(value string) (*time.Time, error) {
 	layouts := []string{
 		time.RFC3339,
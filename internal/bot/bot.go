@@ -0,0 +1,226 @@
+// Package bot drives fake players through the real WS table protocol for QA
+// and load testing. It is a debug facility: every entry point refuses to run
+// when the server is in release mode.
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	mrand "math/rand"
+	"net/url"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/internal/service/game"
+
+	"github.com/gorilla/websocket"
+)
+
+// Enabled reports whether bot support may run in this process. Bots are a
+// debug/QA facility and are hard-disabled in release mode regardless of any
+// endpoint or flag.
+func Enabled() bool {
+	return config.GlobalConfig == nil || config.GlobalConfig.Server.Mode != "release"
+}
+
+// Player drives one seat through the real WS protocol: connect, ready up,
+// then pick a legal action from AllowedActions whenever the turn comes
+// around, echoing the current turn token. One Run plays one hand.
+type Player struct {
+	BaseURL string // http(s) origin of the target server
+	TableID int64
+	Token   string
+	// Script is an optional fixed action preference order; empty means a
+	// uniform random pick from the allowed actions.
+	Script []string
+	// Think delays each action, capped at half the remaining countdown so a
+	// bot never times itself out. Zero acts immediately.
+	Think time.Duration
+	Rng   *mrand.Rand
+	Rec   *Recorder
+}
+
+// Run connects and plays until the hand ends, the server closes the
+// connection, or the context is cancelled.
+func (p *Player) Run(ctx context.Context) error {
+	if !Enabled() {
+		return ErrDisabled
+	}
+	target, err := p.wsURL()
+	if err != nil {
+		return err
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, target, nil)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// Unblock ReadMessage when the caller gives up.
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	defer conn.Close()
+
+	var (
+		pending        time.Time // write time of the last action, for latency
+		readySent      bool
+		lastActedToken int64 = -1
+		scriptPos      int
+	)
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if !pending.IsZero() && p.Rec != nil {
+			p.Rec.Record(time.Since(pending))
+			pending = time.Time{}
+		}
+
+		var frame struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &frame); err != nil {
+			continue
+		}
+		switch frame.Type {
+		case "close":
+			return nil
+		case "state":
+			var state game.TableState
+			if err := json.Unmarshal(frame.Data, &state); err != nil {
+				continue
+			}
+			if state.Phase == game.PhaseEnded {
+				return nil
+			}
+			// Act at most once per turn: broadcasts repeat while the same
+			// turn is still open.
+			if state.Phase == game.PhasePlaying && state.TurnToken == lastActedToken {
+				continue
+			}
+			action, data := p.chooseAction(state, &scriptPos)
+			if action == "" {
+				continue
+			}
+			if action == "ready" {
+				if readySent {
+					continue
+				}
+				readySent = true
+			} else if state.Phase == game.PhasePlaying {
+				lastActedToken = state.TurnToken
+			}
+			if err := p.think(ctx, state.Countdown); err != nil {
+				return err
+			}
+			pending = time.Now()
+			if err := conn.WriteJSON(struct {
+				Type string      `json:"type"`
+				Data interface{} `json:"data,omitempty"`
+			}{Type: action, Data: data}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *Player) wsURL() (string, error) {
+	u, err := url.Parse(p.BaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base url: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	u.Path = fmt.Sprintf("/ws/table/%d", p.TableID)
+	u.RawQuery = url.Values{"token": {p.Token}}.Encode()
+	return u.String(), nil
+}
+
+func (p *Player) think(ctx context.Context, countdown int) error {
+	if p.Think <= 0 {
+		return nil
+	}
+	wait := p.Think
+	if countdown > 0 {
+		if cap := time.Duration(countdown) * time.Second / 2; wait > cap {
+			wait = cap
+		}
+	}
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// chooseAction picks a legal action from the exported state, or "" when the
+// bot has nothing to do. request_time is never picked at random so bots do
+// not stall tables.
+func (p *Player) chooseAction(state game.TableState, scriptPos *int) (string, interface{}) {
+	if len(state.AllowedActions) == 0 {
+		return "", nil
+	}
+	if len(p.Script) > 0 {
+		if *scriptPos >= len(p.Script) {
+			return "", nil
+		}
+		for _, allowed := range state.AllowedActions {
+			if allowed == p.Script[*scriptPos] {
+				*scriptPos++
+				return allowed, p.actionData(allowed, state)
+			}
+		}
+		return "", nil
+	}
+	candidates := make([]string, 0, len(state.AllowedActions))
+	for _, allowed := range state.AllowedActions {
+		if allowed == "request_time" {
+			continue
+		}
+		candidates = append(candidates, allowed)
+	}
+	if len(candidates) == 0 {
+		return "", nil
+	}
+	action := candidates[p.rng().Intn(len(candidates))]
+	return action, p.actionData(action, state)
+}
+
+func (p *Player) actionData(action string, state game.TableState) interface{} {
+	data := map[string]interface{}{}
+	if state.TurnToken != 0 {
+		data["turnToken"] = state.TurnToken
+	}
+	if action == "raise" && state.MinRaise != nil {
+		data["amount"] = *state.MinRaise
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+func (p *Player) rng() *mrand.Rand {
+	if p.Rng == nil {
+		p.Rng = mrand.New(mrand.NewSource(time.Now().UnixNano()))
+	}
+	return p.Rng
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+	mrand "math/rand"
+	"testing"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/internal/model"
+	"dx-service/internal/service/game"
+	pkgAuth "dx-service/pkg/auth"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupBotDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&model.User{}, &model.Wallet{}, &model.Scene{}, &model.Table{}, &model.Match{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func withTestConfig(t *testing.T, mode string) {
+	t.Helper()
+	prev := config.GlobalConfig
+	config.GlobalConfig = &config.Config{
+		Server: config.ServerConfig{Mode: mode},
+		JWT:    config.JWTConfig{Secret: "bot-test-secret", Expire: 1},
+	}
+	t.Cleanup(func() { config.GlobalConfig = prev })
+}
+
+func TestSpawnTableCreatesSeatedRows(t *testing.T) {
+	withTestConfig(t, "debug")
+	db := setupBotDB(t)
+
+	result, err := SpawnTable(t.Context(), db, SpawnOptions{Seats: 3})
+	if err != nil {
+		t.Fatalf("spawn failed: %v", err)
+	}
+	if len(result.Seats) != 3 {
+		t.Fatalf("expected 3 seats, got %d", len(result.Seats))
+	}
+	for _, seat := range result.Seats {
+		claims, err := pkgAuth.ParseUserToken(seat.Token)
+		if err != nil {
+			t.Fatalf("seat %d token invalid: %v", seat.Seat, err)
+		}
+		if claims.SubjectID != seat.UserID {
+			t.Fatalf("seat %d token for wrong user: %d", seat.Seat, claims.SubjectID)
+		}
+	}
+
+	var table model.Table
+	if err := db.First(&table, result.TableID).Error; err != nil {
+		t.Fatalf("table lookup failed: %v", err)
+	}
+	if table.SeatCount != 3 || table.SceneID != result.SceneID {
+		t.Fatalf("unexpected table row: %+v", table)
+	}
+	var match model.Match
+	if err := db.First(&match, result.MatchID).Error; err != nil {
+		t.Fatalf("match lookup failed: %v", err)
+	}
+	if match.TableID != result.TableID {
+		t.Fatalf("match bound to wrong table: %+v", match)
+	}
+	var wallets int64
+	db.Model(&model.Wallet{}).Count(&wallets)
+	if wallets != 3 {
+		t.Fatalf("expected 3 funded wallets, got %d", wallets)
+	}
+}
+
+func TestSpawnTableRefusedInReleaseMode(t *testing.T) {
+	withTestConfig(t, "release")
+	db := setupBotDB(t)
+
+	if _, err := SpawnTable(t.Context(), db, SpawnOptions{}); err != ErrDisabled {
+		t.Fatalf("expected ErrDisabled, got %v", err)
+	}
+	var users int64
+	db.Model(&model.User{}).Count(&users)
+	if users != 0 {
+		t.Fatalf("release mode still created %d users", users)
+	}
+}
+
+func TestChooseActionEchoesTurnTokenAndRaiseAmount(t *testing.T) {
+	minRaise := int64(20)
+	state := game.TableState{
+		Phase:          game.PhasePlaying,
+		TurnToken:      7,
+		AllowedActions: []string{"raise", "request_time"},
+		MinRaise:       &minRaise,
+	}
+	player := &Player{Rng: mrand.New(mrand.NewSource(1))}
+
+	pos := 0
+	action, data := player.chooseAction(state, &pos)
+	if action != "raise" {
+		t.Fatalf("expected raise (request_time excluded), got %q", action)
+	}
+	payload, ok := data.(map[string]interface{})
+	if !ok || payload["turnToken"] != int64(7) || payload["amount"] != minRaise {
+		t.Fatalf("unexpected action payload: %#v", data)
+	}
+}
+
+func TestChooseActionFollowsScript(t *testing.T) {
+	player := &Player{Script: []string{"ready", "fold"}}
+	pos := 0
+
+	action, _ := player.chooseAction(game.TableState{AllowedActions: []string{"ready"}}, &pos)
+	if action != "ready" {
+		t.Fatalf("expected scripted ready, got %q", action)
+	}
+	action, _ = player.chooseAction(game.TableState{AllowedActions: []string{"pass", "fold"}}, &pos)
+	if action != "fold" {
+		t.Fatalf("expected scripted fold, got %q", action)
+	}
+	if action, _ = player.chooseAction(game.TableState{AllowedActions: []string{"fold"}}, &pos); action != "" {
+		t.Fatalf("exhausted script should act no further, got %q", action)
+	}
+}
+
+func TestRecorderPercentiles(t *testing.T) {
+	rec := &Recorder{}
+	for i := 1; i <= 100; i++ {
+		rec.Record(time.Duration(i) * time.Millisecond)
+	}
+	if got := rec.Percentile(50); got != 50*time.Millisecond {
+		t.Fatalf("p50 = %s", got)
+	}
+	if got := rec.Percentile(99); got != 99*time.Millisecond {
+		t.Fatalf("p99 = %s", got)
+	}
+	if got := (&Recorder{}).Percentile(50); got != 0 {
+		t.Fatalf("empty recorder p50 = %s", got)
+	}
+}
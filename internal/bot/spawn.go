@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"dx-service/internal/model"
+	pkgAuth "dx-service/pkg/auth"
+	"dx-service/pkg/utils/random"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ErrDisabled is returned by every bot entry point in release mode.
+var ErrDisabled = errors.New("bot support is disabled in release mode")
+
+// SpawnOptions configure a throwaway bot table. Zero values fall back to a
+// small two-seat table with sane stakes.
+type SpawnOptions struct {
+	Seats  int
+	BasePi int64
+	BuyIn  int64
+}
+
+// SpawnSeat is one bot seat plus the WS token that drives it.
+type SpawnSeat struct {
+	Seat   int    `json:"seat"`
+	UserID int64  `json:"userId"`
+	Token  string `json:"token"`
+}
+
+// SpawnResult describes the rows SpawnTable created.
+type SpawnResult struct {
+	SceneID int64       `json:"sceneId"`
+	TableID int64       `json:"tableId"`
+	MatchID int64       `json:"matchId"`
+	WSPath  string      `json:"wsPath"`
+	Seats   []SpawnSeat `json:"seats"`
+}
+
+// SpawnTable creates a scene, table and match seated entirely with fresh bot
+// users (wallets funded for the buy-in), mirroring what the matcher composes
+// for real players, and returns a WS token per seat. Nothing here starts the
+// hand; callers drive the seats with Player.
+func SpawnTable(ctx context.Context, db *gorm.DB, opts SpawnOptions) (*SpawnResult, error) {
+	if !Enabled() {
+		return nil, ErrDisabled
+	}
+	if opts.Seats < 2 {
+		opts.Seats = 2
+	}
+	if opts.BasePi <= 0 {
+		opts.BasePi = 10
+	}
+	if opts.BuyIn <= 0 {
+		opts.BuyIn = opts.BasePi * 100
+	}
+
+	result := &SpawnResult{}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		scene := model.Scene{
+			Name:      fmt.Sprintf("debug-bots-%s", random.Code(6)),
+			SeatCount: opts.Seats,
+			MinIn:     opts.BuyIn,
+			MaxIn:     opts.BuyIn * 10,
+			BasePi:    opts.BasePi,
+			MinUnitPi: 1,
+			Status:    "enabled",
+		}
+		if err := tx.Create(&scene).Error; err != nil {
+			return err
+		}
+		result.SceneID = scene.ID
+
+		playerMap := make(map[string]map[string]interface{}, opts.Seats)
+		for seat := 1; seat <= opts.Seats; seat++ {
+			user := model.User{
+				Phone:      fmt.Sprintf("bot-%d-%s", time.Now().UnixNano(), random.Numeric(4)),
+				Nickname:   fmt.Sprintf("bot_%d", seat),
+				InviteCode: fmt.Sprintf("BOT%s", random.Code(8)),
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			wallet := model.Wallet{
+				UserID:           user.ID,
+				BalanceTotal:     opts.BuyIn * 10,
+				BalanceAvailable: opts.BuyIn * 10,
+			}
+			if err := tx.Create(&wallet).Error; err != nil {
+				return err
+			}
+			playerMap[strconv.Itoa(seat)] = map[string]interface{}{
+				"userId": user.ID,
+				"alias":  user.Nickname,
+				"status": "waiting",
+				"chips":  opts.BuyIn,
+			}
+			result.Seats = append(result.Seats, SpawnSeat{Seat: seat, UserID: user.ID})
+		}
+		playerBytes, err := json.Marshal(playerMap)
+		if err != nil {
+			return err
+		}
+
+		table := model.Table{
+			SceneID:     scene.ID,
+			Status:      "waiting",
+			SeatCount:   opts.Seats,
+			PlayersJSON: datatypes.JSON(playerBytes),
+		}
+		if err := tx.Create(&table).Error; err != nil {
+			return err
+		}
+		result.TableID = table.ID
+
+		match := model.Match{
+			TableID: table.ID,
+			SceneID: scene.ID,
+		}
+		if err := tx.Create(&match).Error; err != nil {
+			return err
+		}
+		result.MatchID = match.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.WSPath = fmt.Sprintf("/ws/table/%d", result.TableID)
+	for i := range result.Seats {
+		token, err := pkgAuth.GenerateToken(result.Seats[i].UserID)
+		if err != nil {
+			return nil, err
+		}
+		result.Seats[i].Token = token
+	}
+	return result, nil
+}
@@ -13,11 +13,71 @@ type Config struct {
 	JWT      JWTConfig       `mapstructure:"jwt"`
 	Features FeatureConfig   `mapstructure:"features"`
 	Admin    AdminSeedConfig `mapstructure:"admin"`
+	Tracing  TracingConfig   `mapstructure:"tracing"`
+	Capacity CapacityConfig  `mapstructure:"capacity"`
+	Geo      GeoConfig       `mapstructure:"geo"`
+	Chat     ChatConfig      `mapstructure:"chat"`
+	Archive  ArchiveConfig   `mapstructure:"archive"`
+	Game     GameConfig      `mapstructure:"game"`
+	Verify   VerifyConfig    `mapstructure:"verify"`
+	WSUsage  WSUsageConfig   `mapstructure:"wsUsage"`
+	Trial    TrialConfig     `mapstructure:"trial"`
+	Breaker  BreakerConfig   `mapstructure:"breaker"`
+	Reaper   ReaperConfig    `mapstructure:"reaper"`
+}
+
+// ReaperConfig drives the stale-table reaper job. The job always runs and
+// records its decisions; until autoClean is enabled it stays in dry-run so
+// operators can review runs before trusting automatic cleanup.
+type ReaperConfig struct {
+	AutoClean       bool `mapstructure:"autoClean"`       // false = dry-run, record decisions without touching rows
+	StaleAfterHours int  `mapstructure:"staleAfterHours"` // table age before it counts as stuck; 0 = 24h
+}
+
+// BreakerConfig tunes the circuit breakers guarding the Redis-dependent
+// operation groups. Zero fields keep the built-in defaults.
+type BreakerConfig struct {
+	WindowSeconds   int     `mapstructure:"windowSeconds"`   // error-rate sampling window
+	MinCalls        int     `mapstructure:"minCalls"`        // calls needed before the rate is trusted
+	FailureRatio    float64 `mapstructure:"failureRatio"`    // windowed error rate that opens the breaker
+	CooldownSeconds int     `mapstructure:"cooldownSeconds"` // open duration before the half-open probe
+}
+
+// TrialConfig tunes the play-money balance backing trial-mode scenes. A zero
+// cap keeps the built-in default.
+type TrialConfig struct {
+	BalanceCap int64 `mapstructure:"balanceCap"` // trial balance granted at first login and restored daily
+}
+
+// VerifyConfig drives the real-name verification flow: which provider checks
+// submissions and the key material protecting stored ID numbers. An empty
+// Provider uses the accept-everything stub.
+type VerifyConfig struct {
+	Provider  string `mapstructure:"provider"`  // "stub" (default) or "http"
+	Endpoint  string `mapstructure:"endpoint"`  // http provider URL
+	TimeoutMS int    `mapstructure:"timeoutMs"` // http provider timeout, defaults to 5000
+	// IDSecret derives the AES key encrypting stored ID numbers; supports
+	// secret refs like the other credential fields.
+	IDSecret string `mapstructure:"idSecret"`
+}
+
+// GameConfig tunes table runtime behavior shared by every scene.
+type GameConfig struct {
+	// ResultGraceSeconds is how long the result screen stays up after a hand
+	// ends before the table moves on; zero keeps the built-in default.
+	ResultGraceSeconds int `mapstructure:"resultGraceSeconds"`
+	// StateFlushMS caps full state broadcasts to one per interval during
+	// action bursts; zero keeps the built-in default.
+	StateFlushMS int `mapstructure:"stateFlushMs"`
+	// FreezeOnAnomaly turns game-state invariant violations from log-only
+	// alerts into a table freeze awaiting admin review.
+	FreezeOnAnomaly bool `mapstructure:"freezeOnAnomaly"`
 }
 
 type ServerConfig struct {
-	Port string `mapstructure:"port"`
-	Mode string `mapstructure:"mode"` // debug, release
+	Port     string `mapstructure:"port"`
+	Mode     string `mapstructure:"mode"`     // debug, release
+	Timezone string `mapstructure:"timezone"` // IANA name or fixed offset like "+08:00"; scene open hours are evaluated in it
 }
 
 type DatabaseConfig struct {
@@ -38,6 +98,55 @@ type JWTConfig struct {
 type FeatureConfig struct {
 	SkipLocationValidation bool `mapstructure:"skipLocationValidation"`
 	SkipNetworkValidation  bool `mapstructure:"skipNetworkValidation"`
+	// RequireTurnToken closes the deprecation window for clients that send
+	// turn actions without echoing the state's turnToken.
+	RequireTurnToken bool `mapstructure:"requireTurnToken"`
+}
+
+type TracingConfig struct {
+	Enabled      bool    `mapstructure:"enabled"`
+	OTLPEndpoint string  `mapstructure:"otlpEndpoint"` // host:port of the OTLP/HTTP collector
+	SampleRatio  float64 `mapstructure:"sampleRatio"`  // 0..1, defaults to 1 when unset
+}
+
+// CapacityConfig caps what one instance hosts before it starts shedding
+// load instead of degrading everything it already serves. Zero disables
+// the corresponding ceiling.
+type CapacityConfig struct {
+	MaxRuntimes    int `mapstructure:"maxRuntimes"`    // concurrent table runtimes
+	MaxConnections int `mapstructure:"maxConnections"` // live WS connections
+}
+
+// WSUsageConfig bounds a single user's websocket footprint before a risk
+// flag is raised for review. Zero disables the corresponding check. Flags
+// never block by themselves; disconnectOnFlag additionally drops the user's
+// live connections when a flag trips.
+type WSUsageConfig struct {
+	MaxConcurrentConns int  `mapstructure:"maxConcurrentConns"` // live connections per user on this instance
+	MaxMessagesPerHour int  `mapstructure:"maxMessagesPerHour"` // inbound frames per user per hour
+	DisconnectOnFlag   bool `mapstructure:"disconnectOnFlag"`   // enforcement hook, off by default
+}
+
+// GeoConfig drives the region-compliance gate: where the IP-to-country data
+// lives and which countries are refused at registration and queue join. An
+// empty DBPath disables lookups entirely.
+type GeoConfig struct {
+	DBPath           string   `mapstructure:"dbPath"`
+	BlockedCountries []string `mapstructure:"blockedCountries"` // ISO 3166-1 alpha-2 codes
+}
+
+// ChatConfig tunes table chat moderation. An empty BannedWords keeps the
+// built-in vocabulary.
+type ChatConfig struct {
+	BannedWords []string `mapstructure:"bannedWords"`
+}
+
+// ArchiveConfig drives the nightly cold-storage job for round logs: where
+// the archive files go and how long rows stay in the hot table. An empty Dir
+// disables archival entirely.
+type ArchiveConfig struct {
+	Dir           string `mapstructure:"dir"`
+	RetentionDays int    `mapstructure:"retentionDays"` // defaults to 90
 }
 
 type AdminSeedConfig struct {
@@ -59,5 +168,8 @@ func LoadConfig(path string) {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		log.Fatalf("Unable to decode into struct, %v", err)
 	}
+	if err := resolveSecretRefs(&cfg); err != nil {
+		log.Fatalf("Failed to resolve config secrets: %v", err)
+	}
 	GlobalConfig = &cfg
 }
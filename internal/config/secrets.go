@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Secrets are referenced from the YAML instead of written into it, e.g.
+//
+//	jwt:
+//	  secret: secretRef://env/JWT_SECRET
+//	database:
+//	  dsn: secretRef://file//run/secrets/db_dsn
+//
+// References are resolved once at LoadConfig time; a ref that cannot be
+// resolved is fatal at startup so a misconfigured instance never boots with
+// an empty secret.
+
+const secretRefPrefix = "secretRef://"
+
+// SecretProvider resolves one reference for its scheme. Implementations for
+// external managers (Vault, KMS) register themselves via
+// RegisterSecretProvider before LoadConfig runs.
+type SecretProvider interface {
+	Resolve(ref string) (string, error)
+}
+
+var secretProviders = map[string]SecretProvider{
+	"env":  envProvider{},
+	"file": fileProvider{},
+}
+
+// RegisterSecretProvider installs a provider for a scheme, replacing any
+// existing one. Call before LoadConfig.
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	secretProviders[scheme] = p
+}
+
+// envProvider resolves secretRef://env/NAME from the process environment.
+type envProvider struct{}
+
+func (envProvider) Resolve(ref string) (string, error) {
+	val, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return val, nil
+}
+
+// fileProvider resolves secretRef://file/<path> from a mounted secret file,
+// trimming trailing whitespace the way mounted secrets usually carry it.
+type fileProvider struct{}
+
+func (fileProvider) Resolve(ref string) (string, error) {
+	if !strings.HasPrefix(ref, "/") {
+		ref = "/" + ref
+	}
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", fmt.Errorf("reading secret file %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ResolveSecretValue resolves a single config value, passing non-refs through
+// unchanged.
+func ResolveSecretValue(value string) (string, error) {
+	if !strings.HasPrefix(value, secretRefPrefix) {
+		return value, nil
+	}
+	rest := strings.TrimPrefix(value, secretRefPrefix)
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("secret ref %q is malformed, want secretRef://<provider>/<ref>", value)
+	}
+	provider, ok := secretProviders[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("secret ref %q uses unknown provider %q", value, parts[0])
+	}
+	resolved, err := provider.Resolve(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("secret ref %q: %w", value, err)
+	}
+	return resolved, nil
+}
+
+// resolveSecretRefs walks every string field of the config and resolves any
+// secretRef:// values in place.
+func resolveSecretRefs(cfg *Config) error {
+	return resolveStrings(reflect.ValueOf(cfg).Elem())
+}
+
+func resolveStrings(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if err := resolveStrings(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if v.CanSet() {
+			resolved, err := ResolveSecretValue(v.String())
+			if err != nil {
+				return err
+			}
+			v.SetString(resolved)
+		}
+	}
+	return nil
+}
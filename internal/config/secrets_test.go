@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecretValuePassthrough(t *testing.T) {
+	got, err := ResolveSecretValue("plain-value")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "plain-value" {
+		t.Fatalf("expected passthrough, got %q", got)
+	}
+}
+
+func TestResolveSecretValueEnv(t *testing.T) {
+	t.Setenv("TEST_JWT_SECRET", "s3cret")
+
+	got, err := ResolveSecretValue("secretRef://env/TEST_JWT_SECRET")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "s3cret" {
+		t.Fatalf("expected env value, got %q", got)
+	}
+}
+
+func TestResolveSecretValueEnvMissing(t *testing.T) {
+	_, err := ResolveSecretValue("secretRef://env/TEST_MISSING_SECRET")
+	if err == nil {
+		t.Fatal("expected error for missing env var")
+	}
+	if !strings.Contains(err.Error(), "TEST_MISSING_SECRET") {
+		t.Fatalf("error should name the missing ref, got %v", err)
+	}
+}
+
+func TestResolveSecretValueFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret file: %v", err)
+	}
+
+	got, err := ResolveSecretValue("secretRef://file" + path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "from-file" {
+		t.Fatalf("expected trimmed file value, got %q", got)
+	}
+}
+
+func TestResolveSecretValueUnknownProvider(t *testing.T) {
+	_, err := ResolveSecretValue("secretRef://vault/kv/jwt")
+	if err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+	if !strings.Contains(err.Error(), "vault") {
+		t.Fatalf("error should name the provider, got %v", err)
+	}
+}
+
+type staticProvider struct{ value string }
+
+func (p staticProvider) Resolve(string) (string, error) { return p.value, nil }
+
+func TestRegisterSecretProvider(t *testing.T) {
+	RegisterSecretProvider("static", staticProvider{value: "injected"})
+	defer delete(secretProviders, "static")
+
+	got, err := ResolveSecretValue("secretRef://static/anything")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "injected" {
+		t.Fatalf("expected injected value, got %q", got)
+	}
+}
+
+func TestResolveSecretRefsWalksConfig(t *testing.T) {
+	t.Setenv("TEST_DSN", "postgres://resolved")
+
+	cfg := &Config{}
+	cfg.Database.DSN = "secretRef://env/TEST_DSN"
+	cfg.JWT.Secret = "inline-secret"
+
+	if err := resolveSecretRefs(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Database.DSN != "postgres://resolved" {
+		t.Fatalf("expected resolved DSN, got %q", cfg.Database.DSN)
+	}
+	if cfg.JWT.Secret != "inline-secret" {
+		t.Fatalf("expected inline secret untouched, got %q", cfg.JWT.Secret)
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	idempotencyTTL    = 24 * time.Hour
+	maxIdempotentBody = 1 << 20 // cap bodies we hash/replay at 1MB
+)
+
+// idempotencyRecord is what we persist in Redis: enough to detect body
+// mismatches and to replay the original response verbatim.
+type idempotencyRecord struct {
+	BodyHash string `json:"bodyHash"`
+	Status   int    `json:"status"`
+	Response string `json:"response"`
+}
+
+func buildIdempotencyKey(userID int64, route, key string) string {
+	return fmt.Sprintf("idem:%d:%s:%s", userID, route, key)
+}
+
+// Idempotent makes a money-moving POST route safe to retry: the first
+// response for a given Idempotency-Key header is stored for 24 hours keyed by
+// user+route+key and replayed on subsequent calls; reusing a key with a
+// different request body returns 409. Requests without the header pass
+// through untouched. Apply after AuthRequired so the user ID is available.
+func Idempotent(rdb *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyHeader)
+		if key == "" || rdb == nil {
+			c.Next()
+			return
+		}
+
+		userID := c.GetInt64(ContextUserIDKey)
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		bodyHash := hex.EncodeToString(hash[:])
+		redisKey := buildIdempotencyKey(userID, c.FullPath(), key)
+		ctx := c.Request.Context()
+
+		if replayed := replayStored(ctx, rdb, c, redisKey, bodyHash); replayed {
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		record := idempotencyRecord{
+			BodyHash: bodyHash,
+			Status:   recorder.Status(),
+			Response: recorder.body.String(),
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return
+		}
+		// Only the first writer wins; a concurrent retry that raced us keeps
+		// its own stored copy.
+		rdb.SetNX(context.Background(), redisKey, data, idempotencyTTL)
+	}
+}
+
+func replayStored(ctx context.Context, rdb *redis.Client, c *gin.Context, redisKey, bodyHash string) bool {
+	raw, err := rdb.Get(ctx, redisKey).Result()
+	if err != nil {
+		return false
+	}
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(raw), &record); err != nil {
+		return false
+	}
+	if record.BodyHash != bodyHash {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "idempotency key reused with a different request body"})
+		return true
+	}
+	c.Abort()
+	c.Data(record.Status, "application/json; charset=utf-8", []byte(record.Response))
+	return true
+}
+
+// responseRecorder tees the response so it can be stored for replay.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,100 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dx-service/internal/middleware"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func setupIdempotentRouter(t *testing.T) (*gin.Engine, *miniredis.Miniredis, *int) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	calls := 0
+	r.POST("/pay", middleware.Idempotent(rdb), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+	return r, mr, &calls
+}
+
+func doPost(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/pay", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	r, _, calls := setupIdempotentRouter(t)
+
+	first := doPost(r, "key-1", `{"amount":100}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", first.Code)
+	}
+	second := doPost(r, "key-1", `{"amount":100}`)
+	if second.Code != http.StatusOK {
+		t.Fatalf("expected 200 on replay, got %d", second.Code)
+	}
+	if *calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", *calls)
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Fatalf("replay body mismatch: %q vs %q", first.Body.String(), second.Body.String())
+	}
+}
+
+func TestIdempotentConflictingBody(t *testing.T) {
+	r, _, calls := setupIdempotentRouter(t)
+
+	doPost(r, "key-1", `{"amount":100}`)
+	conflict := doPost(r, "key-1", `{"amount":999}`)
+	if conflict.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for conflicting body, got %d", conflict.Code)
+	}
+	if *calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", *calls)
+	}
+}
+
+func TestIdempotentKeyExpiry(t *testing.T) {
+	r, mr, calls := setupIdempotentRouter(t)
+
+	doPost(r, "key-1", `{"amount":100}`)
+	mr.FastForward(24*time.Hour + time.Second)
+
+	again := doPost(r, "key-1", `{"amount":100}`)
+	if again.Code != http.StatusOK {
+		t.Fatalf("expected 200 after expiry, got %d", again.Code)
+	}
+	if *calls != 2 {
+		t.Fatalf("expected handler to run twice after expiry, ran %d times", *calls)
+	}
+}
+
+func TestIdempotentWithoutHeader(t *testing.T) {
+	r, _, calls := setupIdempotentRouter(t)
+
+	doPost(r, "", `{"amount":100}`)
+	doPost(r, "", `{"amount":100}`)
+	if *calls != 2 {
+		t.Fatalf("expected handler to run on every call without key, ran %d times", *calls)
+	}
+}
@@ -16,12 +16,38 @@ type User struct {
 	LocationCity string
 	GPSLat       float64
 	GPSLng       float64
+	CountryCode  string // ISO 3166-1 alpha-2, resolved from the last login IP
+	GeoBypass    bool   `gorm:"default:false;not null"` // admin override: exempt from the region blocklist
 	InviteCode   string `gorm:"unique"`
 	BindAgentID  *int64
 	AgentPath    string // "A>B>C"
 	Status       string `gorm:"default:normal;not null"` // normal/banned
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+
+	// Real-name verification: unverified/pending/verified. The raw ID number
+	// is stored AES-GCM encrypted and never serialized; APIs expose only the
+	// masked form captured at submission time.
+	VerifyStatus   string `gorm:"default:unverified;not null"`
+	VerifiedName   string
+	IDNumberEnc    string `json:"-"`
+	IDNumberMasked string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// VerificationRequest is one real-name submission. Provider failures keep
+// the row in the admin review queue until an operator approves or rejects it.
+type VerificationRequest struct {
+	ID             int64 `gorm:"primaryKey;autoIncrement"`
+	UserID         int64 `gorm:"index"`
+	Name           string
+	IDNumberEnc    string `json:"-"`
+	IDNumberMasked string
+	Status         string `gorm:"index"` // pending/verified/failed/rejected
+	ProviderMsg    string
+	ReviewedBy     int64
+	ReviewedAt     *time.Time
+	CreatedAt      time.Time
 }
 
 type Admin struct {
@@ -61,11 +87,14 @@ type Wallet struct {
 	BalanceTotal     int64
 	BalanceAvailable int64
 	BalanceFrozen    int64
-	TotalRecharge    int64
-	TotalWin         int64
-	TotalConsume     int64
-	TotalRake        int64
-	UpdatedAt        time.Time
+	// TrialBalance is play money for trial-mode scenes: granted at first
+	// login, refilled daily to the cap, never convertible to points.
+	TrialBalance  int64
+	TotalRecharge int64
+	TotalWin      int64
+	TotalConsume  int64
+	TotalRake     int64
+	UpdatedAt     time.Time
 }
 
 type RechargeOrder struct {
@@ -94,20 +123,37 @@ type BillingLog struct {
 // 2.3 Scene, Table, Match
 
 type Scene struct {
-	ID                 int64 `gorm:"primaryKey;autoIncrement"`
-	Name               string
-	SeatCount          int
-	MinIn              int64
-	MaxIn              int64
-	BasePi             int64 // 皮
-	MinUnitPi          int64 // 屁
-	MangoEnabled       bool
-	BoboEnabled        bool
-	DistanceThresholdM int
-	Status             string `gorm:"default:enabled"` // enabled/disabled
-	RakeRuleID         int64
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
+	ID                    int64 `gorm:"primaryKey;autoIncrement"`
+	Name                  string
+	NameI18nJSON          datatypes.JSON `gorm:"type:jsonb"` // locale -> display name, missing locales fall back to Name
+	SeatCount             int
+	MinIn                 int64
+	MaxIn                 int64
+	BasePi                int64 // 皮
+	MinUnitPi             int64 // 屁
+	MangoEnabled          bool
+	BoboEnabled           bool
+	DistanceThresholdM    int
+	TimeBankSeconds       int            `gorm:"default:0"`         // 0 disables the per-hand time bank
+	TimeBankBudgetSeconds int            `gorm:"default:0"`         // per-seat auto time bank burned before timeout auto-actions, 0 = disabled
+	TimeBankRefreshes     bool           `gorm:"default:false"`     // refill the auto bank at every hand start instead of once per session
+	ReadyTimeoutSeconds   int            `gorm:"default:0"`         // waiting-phase deadline before auto-start/dissolve, 0 = 30s default
+	MaxConsecutiveHands   int            `gorm:"default:0"`         // 0 = unlimited; otherwise force a resting hand after N in a row
+	MaxHands              int            `gorm:"default:0"`         // table closes after this many settled hands, 0 = unlimited
+	MaxDurationMinutes    int            `gorm:"default:0"`         // table closes after this much wall time (checked between hands), 0 = unlimited
+	SpectatorDelaySeconds int            `gorm:"default:0"`         // anti-ghosting delay for spectator message delivery, 0 = real time
+	MaxPotMultiplier      int            `gorm:"default:0"`         // pot cap as a multiple of basePi, 0 = uncapped
+	Status                string         `gorm:"default:enabled"`   // enabled/disabled
+	SortOrder             int            `gorm:"default:0"`         // lobby listing position, ascending; ties fall back to id
+	OpenHoursJSON         datatypes.JSON `gorm:"type:jsonb"`        // weekly open windows, empty = always open
+	RakeFreeWindowsJSON   datatypes.JSON `gorm:"type:jsonb"`        // weekly rake-free "happy hour" windows, empty = rake always applies
+	AllowedActionsJSON    datatypes.JSON `gorm:"type:jsonb"`        // optional actions permitted (raise/knock_bobo/pass), empty = all
+	TimeoutPolicy         string         `gorm:"default:auto_pass"` // turn-timeout auto-action: auto_pass / auto_fold / auto_call
+	RequireVerification   bool           `gorm:"default:false"`     // real-name verification required before queuing or joining
+	TrialMode             bool           `gorm:"default:false"`     // play-money scene: settles against Wallet.TrialBalance, no rake or agent shares
+	RakeRuleID            int64
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
 }
 
 type RakeRule struct {
@@ -130,13 +176,18 @@ type AgentRule struct {
 }
 
 type Table struct {
-	ID          int64 `gorm:"primaryKey;autoIncrement"`
-	SceneID     int64
-	Status      string // waiting/playing/ended
-	SeatCount   int
-	MangoStreak int            `gorm:"default:0"`
-	PlayersJSON datatypes.JSON `gorm:"type:jsonb"` // seat->userId->alias
-	CreatedAt   time.Time
+	ID               int64 `gorm:"primaryKey;autoIncrement"`
+	SceneID          int64
+	Status           string // waiting/playing/ended
+	SeatCount        int
+	MangoStreak      int            `gorm:"default:0"`
+	BankerSeat       int            `gorm:"default:0"`  // dealer button, rotated each hand; 0 = not started
+	MangoHistoryJSON datatypes.JSON `gorm:"type:jsonb"` // recent mango events (hand, trigger, carried)
+	PlayersJSON      datatypes.JSON `gorm:"type:jsonb"` // seat->userId->alias
+	// RuntimeSnapshotJSON holds the resumable mid-hand runtime state (cards
+	// encrypted); cleared outside PhasePlaying.
+	RuntimeSnapshotJSON datatypes.JSON `gorm:"type:jsonb"`
+	CreatedAt           time.Time
 }
 
 type Match struct {
@@ -149,11 +200,130 @@ type Match struct {
 	EndedAt    *time.Time
 }
 
+// MatchRake is the normalized per-match rake record written at settlement so
+// revenue reports do not have to unpack Match.RakeJSON row by row.
+type MatchRake struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"`
+	MatchID    int64 `gorm:"uniqueIndex"`
+	SceneID    int64 `gorm:"index"`
+	RuleID     int64 `gorm:"index"`
+	TotalRake  int64
+	Platform   int64
+	AgentTotal int64
+	TotalPot   int64 // gross winnings before rake, the denominator for rake percentage
+	CreatedAt  time.Time
+}
+
+type FeatureFlag struct {
+	ID        int64  `gorm:"primaryKey;autoIncrement"`
+	Key       string `gorm:"size:128;uniqueIndex"`
+	Enabled   bool
+	RulesJSON datatypes.JSON `gorm:"type:jsonb"` // targeting: sceneIds/userIds/userPercent
+	Remark    string         `gorm:"size:255"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type AdminAuditLog struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"`
+	AdminID    int64
+	Action     string `gorm:"size:64"`
+	TargetType string `gorm:"size:32"`
+	TargetID   int64
+	BeforeJSON datatypes.JSON `gorm:"type:jsonb"`
+	AfterJSON  datatypes.JSON `gorm:"type:jsonb"`
+	Reason     string         `gorm:"size:255"`
+	CreatedAt  time.Time
+}
+
+// 2.4 Notifications
+
+type Notification struct {
+	ID          int64          `gorm:"primaryKey;autoIncrement"`
+	UserID      int64          `gorm:"index"`
+	Type        string         `gorm:"size:64"` // withdrawal_approved/commission_released/...
+	PayloadJSON datatypes.JSON `gorm:"type:jsonb"`
+	ReadAt      *time.Time
+	CreatedAt   time.Time
+}
+
+// DeliveryFailure is the dead-letter row for an async delivery (notification
+// push, settlement retry, ...) that exhausted its retries. The payload is
+// kept verbatim so an admin replay routes it back through the worker that
+// first produced it.
+type DeliveryFailure struct {
+	ID          int64          `gorm:"primaryKey;autoIncrement"`
+	Type        string         `gorm:"size:64;index"` // notification/...
+	PayloadJSON datatypes.JSON `gorm:"type:jsonb"`
+	ErrorsJSON  datatypes.JSON `gorm:"type:jsonb"` // error history, oldest first
+	Attempts    int
+	Status      string `gorm:"size:16;index"` // failed/replayed
+	RetriedBy   int64  // admin who triggered the last replay
+	RetriedAt   *time.Time
+	CreatedAt   time.Time
+}
+
 type MatchRoundLog struct {
 	ID          int64 `gorm:"primaryKey;autoIncrement"`
 	MatchID     int64
 	RoundNo     int
 	ActionsJSON datatypes.JSON `gorm:"type:jsonb"`
 	CardsJSON   datatypes.JSON `gorm:"type:jsonb"`
-	CreatedAt   time.Time
+	// ChainHMAC links this row into the match's running log HMAC so
+	// after-the-fact edits are detectable; empty on rows predating chaining.
+	ChainHMAC string `gorm:"size:64"`
+	CreatedAt time.Time
+}
+
+// ArchiveManifest records one cold-storage file of archived round logs:
+// where the blob lives, the match ID range it covers and how many rows it
+// holds. Readers consult it when a match's rows are gone from the hot table.
+type ArchiveManifest struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement"`
+	FilePath   string `gorm:"size:512"`
+	MatchIDMin int64  `gorm:"index"`
+	MatchIDMax int64  `gorm:"index"`
+	RowCount   int64
+	CreatedAt  time.Time
+}
+
+// ReaperRun records one pass of the stale-table reaper: how many tables it
+// examined and what it decided for each, so operators can audit dry runs
+// before enabling automatic cleanup.
+type ReaperRun struct {
+	ID        int64 `gorm:"primaryKey;autoIncrement"`
+	DryRun    bool
+	Examined  int
+	Closed    int
+	Abandoned int
+	// DecisionsJSON lists per-table outcomes: tableId, matchId, decision
+	// (closed/closed_no_match/abandoned) and the reason it qualified.
+	DecisionsJSON datatypes.JSON `gorm:"type:jsonb"`
+	CreatedAt     time.Time
+}
+
+// MatchBehavior is the per-player behavioral summary of one match, written
+// after settlement so integrity analysis never replays round logs online.
+type MatchBehavior struct {
+	ID               int64 `gorm:"primaryKey;autoIncrement"`
+	MatchID          int64 `gorm:"index"`
+	UserID           int64 `gorm:"index"`
+	SeatIndex        int
+	ActionCountsJSON datatypes.JSON `gorm:"type:jsonb"` // action -> count
+	AvgDecisionMS    int64
+	P95DecisionMS    int64
+	Timeouts         int
+	FoldsToRaiseJSON datatypes.JSON `gorm:"type:jsonb"` // raiser userId -> folds right after their raise
+	CreatedAt        time.Time
+}
+
+// RiskFlag marks a user for manual review. Detectors (WS usage accounting,
+// behavior analysis) append flags when a threshold trips; nothing is enforced
+// from here.
+type RiskFlag struct {
+	ID         int64          `gorm:"primaryKey;autoIncrement"`
+	UserID     int64          `gorm:"index"`
+	Kind       string         `gorm:"size:64"`    // which detector raised it, e.g. ws_usage
+	DetailJSON datatypes.JSON `gorm:"type:jsonb"` // detector-specific evidence
+	CreatedAt  time.Time
 }
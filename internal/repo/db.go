@@ -11,6 +11,7 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
 )
 
 var DB *gorm.DB
@@ -25,6 +26,10 @@ func InitDB() {
 		)
 	}
 
+	if err := DB.Use(gormtracing.NewPlugin(gormtracing.WithoutMetrics())); err != nil {
+		logger.Log.Warn("Failed to enable gorm tracing", zap.Error(err))
+	}
+
 	models := []interface{}{
 		&model.Admin{},
 		&model.Agent{},
@@ -37,7 +42,17 @@ func InitDB() {
 		&model.AgentRule{},
 		&model.Table{},
 		&model.Match{},
+		&model.MatchRake{},
 		&model.MatchRoundLog{},
+		&model.MatchBehavior{},
+		&model.RiskFlag{},
+		&model.FeatureFlag{},
+		&model.AdminAuditLog{},
+		&model.Notification{},
+		&model.DeliveryFailure{},
+		&model.ArchiveManifest{},
+		&model.VerificationRequest{},
+		&model.ReaperRun{},
 	}
 
 	if os.Getenv("SKIP_USER_MIGRATE") != "1" {
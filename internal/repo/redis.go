@@ -5,6 +5,7 @@ import (
 	"dx-service/internal/config"
 	"dx-service/pkg/logger"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
@@ -23,4 +24,8 @@ func InitRedis() {
 	if err != nil {
 		logger.Log.Fatal("Failed to connect to Redis", zap.Error(err))
 	}
+
+	if err := redisotel.InstrumentTracing(RDB); err != nil {
+		logger.Log.Warn("Failed to enable redis tracing", zap.Error(err))
+	}
 }
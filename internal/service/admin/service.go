@@ -2,6 +2,7 @@ package admin
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 	"time"
 
@@ -39,6 +40,53 @@ func NewService(db *gorm.DB) *Service {
 	return &Service{db: db}
 }
 
+// AuditEntry captures one admin mutation for the audit trail. Before and
+// After are marshalled to JSON as-is.
+type AuditEntry struct {
+	AdminID    int64
+	Action     string
+	TargetType string
+	TargetID   int64
+	Before     interface{}
+	After      interface{}
+	Reason     string
+}
+
+func (s *Service) RecordAudit(ctx context.Context, entry AuditEntry) error {
+	beforeJSON, err := json.Marshal(entry.Before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := json.Marshal(entry.After)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Create(&model.AdminAuditLog{
+		AdminID:    entry.AdminID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		BeforeJSON: beforeJSON,
+		AfterJSON:  afterJSON,
+		Reason:     entry.Reason,
+	}).Error
+}
+
+// RaiseRiskFlag appends a review flag for the user; detail is marshalled
+// as-is. Detectors call it when a threshold trips — the flag itself never
+// blocks anything.
+func (s *Service) RaiseRiskFlag(ctx context.Context, userID int64, kind string, detail map[string]interface{}) error {
+	detailJSON, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Create(&model.RiskFlag{
+		UserID:     userID,
+		Kind:       kind,
+		DetailJSON: detailJSON,
+	}).Error
+}
+
 func (s *Service) Login(ctx context.Context, username, password string) (*LoginResult, error) {
 	username = strings.TrimSpace(username)
 	password = strings.TrimSpace(password)
@@ -101,9 +101,9 @@ func (s *Service) Update(ctx context.Context, id int64, params MutationParams) (
 		Model(&model.AgentRule{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"max_level":            params.MaxLevel,
-			"level_ratios_json":    datatypes.JSON(params.LevelRatiosJSON),
-			"base_platform_ratio":  params.BasePlatformRatio,
+			"max_level":           params.MaxLevel,
+			"level_ratios_json":   datatypes.JSON(params.LevelRatiosJSON),
+			"base_platform_ratio": params.BasePlatformRatio,
 		})
 	if result.Error != nil {
 		return nil, result.Error
@@ -131,4 +131,3 @@ func validateMutationParams(params MutationParams) error {
 	}
 	return nil
 }
-
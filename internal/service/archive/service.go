@@ -0,0 +1,210 @@
+// Package archive moves aged MatchRoundLog rows into compressed JSONL blobs
+// so the hot table stays bounded. Each file gets an ArchiveManifest row with
+// its path, match ID range and row count; readers fall back to the archive
+// transparently when a match's rows are gone from the database.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+	"dx-service/pkg/storage"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRetentionDays = 90
+
+	// fileRowCap bounds the rows per archive file so one job run streams
+	// modest payloads instead of marshalling months of backlog at once.
+	fileRowCap = 5000
+
+	// deleteBatch bounds the rows per DELETE so archival never holds a long
+	// lock against the hot insert path.
+	deleteBatch = 500
+)
+
+// Service runs the archival job and serves archived rows back.
+type Service struct {
+	db        *gorm.DB
+	store     storage.Store
+	retention time.Duration
+
+	now func() time.Time
+}
+
+func NewService(db *gorm.DB, store storage.Store, retention time.Duration) *Service {
+	if retention <= 0 {
+		retention = defaultRetentionDays * 24 * time.Hour
+	}
+	return &Service{db: db, store: store, retention: retention, now: time.Now}
+}
+
+// NewFromConfig builds the service from the loaded global config, or nil
+// when archival is disabled (no directory configured).
+func NewFromConfig(db *gorm.DB) *Service {
+	if config.GlobalConfig == nil || config.GlobalConfig.Archive.Dir == "" {
+		return nil
+	}
+	cfg := config.GlobalConfig.Archive
+	retention := time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	return NewService(db, storage.NewFileStore(cfg.Dir), retention)
+}
+
+// Run is the scheduler entry point: it drains every round-log row older than
+// the retention window into archive files, one bounded batch at a time.
+func (s *Service) Run(ctx context.Context) error {
+	cutoff := s.now().Add(-s.retention)
+	for {
+		var logs []model.MatchRoundLog
+		if err := s.db.WithContext(ctx).
+			Where("created_at < ?", cutoff).
+			Order("id ASC").
+			Limit(fileRowCap).
+			Find(&logs).Error; err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+		if err := s.archiveBatch(ctx, logs); err != nil {
+			return err
+		}
+	}
+}
+
+// archiveBatch writes one batch to storage, records its manifest and only
+// then deletes the rows — a crash between the steps leaves duplicates in the
+// hot table, never a gap.
+func (s *Service) archiveBatch(ctx context.Context, logs []model.MatchRoundLog) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	matchMin, matchMax := logs[0].MatchID, logs[0].MatchID
+	for _, log := range logs {
+		line, err := json.Marshal(log)
+		if err != nil {
+			return err
+		}
+		if _, err := zw.Write(append(line, '\n')); err != nil {
+			return err
+		}
+		if log.MatchID < matchMin {
+			matchMin = log.MatchID
+		}
+		if log.MatchID > matchMax {
+			matchMax = log.MatchID
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("round_logs/%s_rows%d-%d.jsonl.gz",
+		s.now().Format("20060102T150405"), logs[0].ID, logs[len(logs)-1].ID)
+	if err := s.store.Put(ctx, path, buf.Bytes()); err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Create(&model.ArchiveManifest{
+		FilePath:   path,
+		MatchIDMin: matchMin,
+		MatchIDMax: matchMax,
+		RowCount:   int64(len(logs)),
+		CreatedAt:  s.now(),
+	}).Error; err != nil {
+		return err
+	}
+
+	ids := make([]int64, 0, len(logs))
+	for _, log := range logs {
+		ids = append(ids, log.ID)
+	}
+	for start := 0; start < len(ids); start += deleteBatch {
+		end := start + deleteBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := s.db.WithContext(ctx).
+			Delete(&model.MatchRoundLog{}, "id IN ?", ids[start:end]).Error; err != nil {
+			return err
+		}
+	}
+
+	logger.WithContext(ctx).Info("round logs archived",
+		zap.String("path", path),
+		zap.Int("rows", len(logs)),
+		zap.Int64("matchMin", matchMin),
+		zap.Int64("matchMax", matchMax),
+	)
+	return nil
+}
+
+// MatchRoundLogs loads a match's round logs, merging the hot table with any
+// archive files whose manifest covers the match. Callers see one ordered
+// slice regardless of where the rows live.
+func (s *Service) MatchRoundLogs(ctx context.Context, matchID int64) ([]model.MatchRoundLog, error) {
+	var logs []model.MatchRoundLog
+	if err := s.db.WithContext(ctx).
+		Where("match_id = ?", matchID).
+		Order("id ASC").
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	var manifests []model.ArchiveManifest
+	if err := s.db.WithContext(ctx).
+		Where("match_id_min <= ? AND match_id_max >= ?", matchID, matchID).
+		Order("id ASC").
+		Find(&manifests).Error; err != nil {
+		return nil, err
+	}
+	for _, manifest := range manifests {
+		archived, err := s.readArchived(ctx, manifest.FilePath, matchID)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, archived...)
+	}
+
+	sort.Slice(logs, func(i, j int) bool { return logs[i].ID < logs[j].ID })
+	return logs, nil
+}
+
+func (s *Service) readArchived(ctx context.Context, path string, matchID int64) ([]model.MatchRoundLog, error) {
+	blob, err := s.store.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var logs []model.MatchRoundLog
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var log model.MatchRoundLog
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			return nil, fmt.Errorf("corrupt archive %s: %w", path, err)
+		}
+		if log.MatchID == matchID {
+			logs = append(logs, log)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
@@ -0,0 +1,177 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeStore is the in-memory storage backend for tests.
+type fakeStore struct {
+	blobs map[string][]byte
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{blobs: make(map[string][]byte)}
+}
+
+func (s *fakeStore) Put(_ context.Context, path string, data []byte) error {
+	s.blobs[path] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *fakeStore) Get(_ context.Context, path string) ([]byte, error) {
+	blob, ok := s.blobs[path]
+	if !ok {
+		return nil, fmt.Errorf("no blob at %s", path)
+	}
+	return blob, nil
+}
+
+func newArchiveTestService(t *testing.T) (*gorm.DB, *Service, *fakeStore) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.MatchRoundLog{}, &model.ArchiveManifest{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	store := newFakeStore()
+	return db, NewService(db, store, 90*24*time.Hour), store
+}
+
+func seedLog(t *testing.T, db *gorm.DB, matchID int64, roundNo int, age time.Duration) {
+	t.Helper()
+	if err := db.Create(&model.MatchRoundLog{
+		MatchID:     matchID,
+		RoundNo:     roundNo,
+		ActionsJSON: []byte(fmt.Sprintf(`[{"action":"raise","seat":%d}]`, roundNo)),
+		CardsJSON:   []byte(`{"1":["a1","b2"]}`),
+		CreatedAt:   time.Now().Add(-age),
+	}).Error; err != nil {
+		t.Fatalf("seed round log: %v", err)
+	}
+}
+
+func TestRunArchivesOldRowsAndRecordsManifest(t *testing.T) {
+	db, svc, store := newArchiveTestService(t)
+	ctx := context.Background()
+
+	seedLog(t, db, 5, 1, 100*24*time.Hour)
+	seedLog(t, db, 5, 2, 100*24*time.Hour)
+	seedLog(t, db, 8, 1, 95*24*time.Hour)
+	seedLog(t, db, 9, 1, time.Hour) // inside the window, stays hot
+
+	if err := svc.Run(ctx); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	var remaining []model.MatchRoundLog
+	if err := db.Find(&remaining).Error; err != nil {
+		t.Fatalf("load remaining: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].MatchID != 9 {
+		t.Fatalf("expected only the recent row to stay, got %+v", remaining)
+	}
+
+	var manifests []model.ArchiveManifest
+	if err := db.Find(&manifests).Error; err != nil {
+		t.Fatalf("load manifests: %v", err)
+	}
+	if len(manifests) != 1 {
+		t.Fatalf("expected 1 manifest, got %d", len(manifests))
+	}
+	m := manifests[0]
+	if m.RowCount != 3 || m.MatchIDMin != 5 || m.MatchIDMax != 8 {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	if _, ok := store.blobs[m.FilePath]; !ok {
+		t.Fatalf("manifest points at missing blob %s", m.FilePath)
+	}
+
+	// A second run finds nothing left to archive.
+	if err := svc.Run(ctx); err != nil {
+		t.Fatalf("second run failed: %v", err)
+	}
+	if err := db.Find(&manifests).Error; err != nil {
+		t.Fatalf("reload manifests: %v", err)
+	}
+	if len(manifests) != 1 {
+		t.Fatalf("idle run created manifests: %d", len(manifests))
+	}
+}
+
+func TestMatchRoundLogsReadsThroughArchive(t *testing.T) {
+	db, svc, _ := newArchiveTestService(t)
+	ctx := context.Background()
+
+	seedLog(t, db, 5, 1, 100*24*time.Hour)
+	seedLog(t, db, 5, 2, 100*24*time.Hour)
+	seedLog(t, db, 8, 1, 100*24*time.Hour)
+
+	before, err := svc.MatchRoundLogs(ctx, 5)
+	if err != nil {
+		t.Fatalf("load before archive: %v", err)
+	}
+
+	if err := svc.Run(ctx); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	after, err := svc.MatchRoundLogs(ctx, 5)
+	if err != nil {
+		t.Fatalf("load after archive: %v", err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("expected %d archived rows, got %d", len(before), len(after))
+	}
+	for i := range after {
+		if after[i].ID != before[i].ID ||
+			after[i].RoundNo != before[i].RoundNo ||
+			string(after[i].ActionsJSON) != string(before[i].ActionsJSON) ||
+			string(after[i].CardsJSON) != string(before[i].CardsJSON) {
+			t.Fatalf("archived row %d lost fidelity:\nbefore %+v\nafter  %+v", i, before[i], after[i])
+		}
+	}
+
+	// Other matches never leak into the read.
+	other, err := svc.MatchRoundLogs(ctx, 8)
+	if err != nil {
+		t.Fatalf("load other match: %v", err)
+	}
+	if len(other) != 1 || other[0].MatchID != 8 {
+		t.Fatalf("unexpected rows for match 8: %+v", other)
+	}
+}
+
+func TestMatchRoundLogsMergesHotAndArchivedRows(t *testing.T) {
+	db, svc, _ := newArchiveTestService(t)
+	ctx := context.Background()
+
+	seedLog(t, db, 5, 1, 100*24*time.Hour)
+	if err := svc.Run(ctx); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	seedLog(t, db, 5, 2, time.Hour) // a later round still in the hot table
+
+	logs, err := svc.MatchRoundLogs(ctx, 5)
+	if err != nil {
+		t.Fatalf("load merged: %v", err)
+	}
+	if len(logs) != 2 || logs[0].RoundNo != 1 || logs[1].RoundNo != 2 {
+		t.Fatalf("expected merged ordered rows, got %+v", logs)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"dx-service/internal/config"
 	"dx-service/internal/model"
 	pkgAuth "dx-service/pkg/auth"
+	"dx-service/pkg/breaker"
 	appErr "dx-service/pkg/errors"
 	"dx-service/pkg/logger"
 
@@ -24,6 +25,24 @@ type Service struct {
 	db      *gorm.DB
 	rdb     *redis.Client
 	codeTTL time.Duration
+
+	// geo gates registration and login by the client's resolved region;
+	// nil (bare test services) skips the check.
+	geo GeoChecker
+
+	// trial seeds new accounts with play money for trial-mode scenes; nil
+	// skips the grant.
+	trial TrialGranter
+
+	// codes guards the OTP storage against Redis outages; nil leaves the
+	// path unguarded.
+	codes *breaker.Breaker
+}
+
+// GeoChecker resolves a client IP's country and whether the compliance
+// blocklist bars it.
+type GeoChecker interface {
+	Check(ctx context.Context, ip string) (country string, blocked bool)
 }
 
 type LoginResult struct {
@@ -40,6 +59,28 @@ func NewService(db *gorm.DB, rdb *redis.Client) *Service {
 	}
 }
 
+// SetGeoChecker wires the region gate in after construction.
+func (s *Service) SetGeoChecker(g GeoChecker) {
+	s.geo = g
+}
+
+// TrialGranter is the slice of the wallet service registration needs: topping
+// a fresh account's trial balance up to the cap.
+type TrialGranter interface {
+	GrantTrialBalance(ctx context.Context, userID int64) error
+}
+
+// SetTrialGranter wires the trial-balance grant in after construction.
+func (s *Service) SetTrialGranter(g TrialGranter) {
+	s.trial = g
+}
+
+// SetCodeBreaker wires the auth-codes circuit breaker in after construction.
+// While it is open SendSMS fails fast instead of stalling on a dead Redis.
+func (s *Service) SetCodeBreaker(b *breaker.Breaker) {
+	s.codes = b
+}
+
 const testOTPCode = "123456"
 
 func (s *Service) SendSMS(ctx context.Context, phone string) error {
@@ -57,8 +98,15 @@ func (s *Service) SendSMS(ctx context.Context, phone string) error {
 		}
 	}
 
+	if s.codes != nil && !s.codes.Allow() {
+		return appErr.ErrServerBusy
+	}
 	key := buildSMSKey(phone)
-	if err := s.rdb.Set(ctx, key, code, s.codeTTL).Err(); err != nil {
+	err := s.rdb.Set(ctx, key, code, s.codeTTL).Err()
+	if s.codes != nil {
+		s.codes.Record(err)
+	}
+	if err != nil {
 		return err
 	}
 	logger.Log.Info("otp generated",
@@ -68,7 +116,7 @@ func (s *Service) SendSMS(ctx context.Context, phone string) error {
 	return nil
 }
 
-func (s *Service) Login(ctx context.Context, phone, code, inviteCode string) (*LoginResult, error) {
+func (s *Service) Login(ctx context.Context, phone, code, inviteCode, ip string) (*LoginResult, error) {
 	if strings.TrimSpace(phone) == "" || strings.TrimSpace(code) == "" {
 		return nil, appErr.ErrInvalidPhone
 	}
@@ -76,9 +124,9 @@ func (s *Service) Login(ctx context.Context, phone, code, inviteCode string) (*L
 	key := buildSMSKey(phone)
 	stored, err := s.rdb.Get(ctx, key).Result()
 	if err != nil {
-	if err == redis.Nil {
-		return nil, appErr.ErrSMSCodeExpired
-	}
+		if err == redis.Nil {
+			return nil, appErr.ErrSMSCodeExpired
+		}
 		return nil, err
 	}
 	if stored != code {
@@ -86,13 +134,23 @@ func (s *Service) Login(ctx context.Context, phone, code, inviteCode string) (*L
 	}
 	s.rdb.Del(ctx, key)
 
+	country, regionBlocked := "", false
+	if s.geo != nil {
+		country, regionBlocked = s.geo.Check(ctx, ip)
+	}
+
 	var user model.User
 	err = s.db.WithContext(ctx).Where("phone = ?", phone).First(&user).Error
 	if err != nil {
 		if err != gorm.ErrRecordNotFound {
 			return nil, err
 		}
-		user, err = s.createUser(ctx, phone)
+		// New accounts from blocked regions are never created; existing
+		// ones can carry an admin-granted bypass.
+		if regionBlocked {
+			return nil, appErr.ErrRegionBlocked
+		}
+		user, err = s.createUser(ctx, phone, country)
 		if err != nil {
 			return nil, err
 		}
@@ -104,6 +162,15 @@ func (s *Service) Login(ctx context.Context, phone, code, inviteCode string) (*L
 	if strings.EqualFold(user.Status, "banned") {
 		return nil, appErr.ErrUserBanned
 	}
+	if regionBlocked && !user.GeoBypass {
+		return nil, appErr.ErrRegionBlocked
+	}
+	if country != "" && country != user.CountryCode {
+		if err := s.db.WithContext(ctx).Model(&user).Update("country_code", country).Error; err != nil {
+			return nil, err
+		}
+		user.CountryCode = country
+	}
 	if err := s.bindAgentIfNeeded(ctx, &user, inviteCode); err != nil {
 		return nil, err
 	}
@@ -121,26 +188,34 @@ func (s *Service) Login(ctx context.Context, phone, code, inviteCode string) (*L
 	}, nil
 }
 
-func (s *Service) createUser(ctx context.Context, phone string) (model.User, error) {
+func (s *Service) createUser(ctx context.Context, phone, country string) (model.User, error) {
 	inviteCode := generateInviteCode()
 	user := model.User{
-			Phone:      phone,
-			Status:     "normal",
-		InviteCode: inviteCode,
+		Phone:       phone,
+		Status:      "normal",
+		InviteCode:  inviteCode,
+		CountryCode: country,
 	}
 	if err := s.db.WithContext(ctx).Create(&user).Error; err != nil {
 		return model.User{}, err
+	}
+	if s.trial != nil {
+		// Best effort: a failed grant must not block registration; the daily
+		// refill catches the wallet up.
+		if err := s.trial.GrantTrialBalance(ctx, user.ID); err != nil {
+			logger.Log.Warn("trial balance grant failed", zap.Int64("userID", user.ID), zap.Error(err))
 		}
+	}
 	return user, nil
 }
 
 func (s *Service) ensureInviteCode(ctx context.Context, user *model.User) error {
 	if user.InviteCode != "" {
 		return nil
-		}
+	}
 	code := generateInviteCode()
 	if err := s.db.WithContext(ctx).Model(user).Update("invite_code", code).Error; err != nil {
-			return err
+		return err
 	}
 	user.InviteCode = code
 	return nil
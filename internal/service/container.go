@@ -2,50 +2,198 @@ package service
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"dx-service/internal/config"
 	"dx-service/internal/service/admin"
 	"dx-service/internal/service/agent"
+	"dx-service/internal/service/archive"
 	"dx-service/internal/service/auth"
+	"dx-service/internal/service/delivery"
+	"dx-service/internal/service/features"
 	"dx-service/internal/service/game"
+	"dx-service/internal/service/geoip"
+	"dx-service/internal/service/integrity"
 	"dx-service/internal/service/match"
+	"dx-service/internal/service/notify"
+	"dx-service/internal/service/panicstop"
 	"dx-service/internal/service/rake"
 	"dx-service/internal/service/scene"
+	"dx-service/internal/service/scheduler"
 	"dx-service/internal/service/user"
 	"dx-service/internal/service/wallet"
+	"dx-service/pkg/breaker"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 type Container struct {
-	Match  *match.Service
-	Game   *game.Service
-	Scene  *scene.Service
-	Rake   *rake.Service
-	Agent  *agent.Service
-	Auth   *auth.Service
-	User   *user.Service
-	Wallet *wallet.Service
-	Admin  *admin.Service
+	Match     *match.Service
+	Game      *game.Service
+	Scene     *scene.Service
+	Rake      *rake.Service
+	Agent     *agent.Service
+	Auth      *auth.Service
+	User      *user.Service
+	Wallet    *wallet.Service
+	Admin     *admin.Service
+	Notify    *notify.Service
+	Delivery  *delivery.Service
+	Features  *features.Service
+	Integrity *integrity.Service
+	Panic     *panicstop.Service
+	// Archive is nil when no archive directory is configured.
+	Archive *archive.Service
+	Jobs    *scheduler.Scheduler
+
+	// Breakers collects the circuit breakers guarding the Redis-dependent
+	// operation groups, for the admin dashboard export.
+	Breakers *breaker.Registry
+
+	// Rdb is shared with infrastructure that lives outside the service
+	// layer, e.g. the WS connection registry.
+	Rdb *redis.Client
 }
 
 func NewContainer(db *gorm.DB, rdb *redis.Client) *Container {
-	return &Container{
-		Admin:  admin.NewService(db),
-		Agent:  agent.NewService(db),
-		Auth:   auth.NewService(db, rdb),
-		Match:  match.NewService(db, rdb),
-		Game:   game.NewService(db),
-		Rake:   rake.NewService(db),
-		Scene:  scene.NewService(db),
-		User:   user.NewService(db),
-		Wallet: wallet.NewService(db),
+	featuresSvc := features.NewService(db)
+	notifySvc := notify.NewService(db, rdb)
+	deliverySvc := delivery.NewService(db)
+	c := &Container{
+		Admin:     admin.NewService(db),
+		Agent:     agent.NewService(db),
+		Auth:      auth.NewService(db, rdb),
+		Match:     match.NewService(db, rdb, featuresSvc),
+		Game:      game.NewService(db, rdb, featuresSvc, notifySvc),
+		Rake:      rake.NewService(db),
+		Scene:     scene.NewService(db),
+		User:      user.NewService(db),
+		Wallet:    wallet.NewService(db, rdb),
+		Notify:    notifySvc,
+		Delivery:  deliverySvc,
+		Features:  featuresSvc,
+		Integrity: integrity.NewService(db),
+		Panic:     panicstop.NewService(rdb),
+		Archive:   archive.NewFromConfig(db),
+		Jobs:      scheduler.NewScheduler(rdb),
+		Rdb:       rdb,
+	}
+	// Expired tables hand their players back to the matchmaker.
+	c.Game.SetMatchmaker(c.Match)
+	// The matcher stops composing tables once this instance cannot host
+	// another runtime.
+	c.Match.SetHostCapacity(c.Game)
+	// Leftover queue members top up live tables that still have free seats.
+	c.Match.SetTableSeater(c.Game)
+	// Replays route back through the original worker path so its idempotency
+	// still applies.
+	deliverySvc.RegisterHandler(delivery.TypeNotification, notifySvc.Replay)
+	deliverySvc.RegisterHandler(delivery.TypeSettlement, c.Game.ReplaySettlement)
+	c.Game.SetDeadLetter(deliverySvc)
+	// The panic stop freezes money movement: settlements defer to the
+	// dead-letter queue, wallet adjustments are rejected.
+	c.Game.SetPanicChecker(c.Panic)
+	c.Wallet.SetPanicChecker(c.Panic)
+	// The region blocklist gates both entry points: registration and queue
+	// joins.
+	geoSvc := geoip.NewFromConfig()
+	c.Auth.SetGeoChecker(geoSvc)
+	c.Match.SetGeoChecker(geoSvc)
+	// New accounts start with play money for trial-mode scenes.
+	c.Auth.SetTrialGranter(c.Wallet)
+	// Real-name verification provider; the stub unless a vendor is
+	// configured.
+	c.User.SetVerificationProvider(user.ProviderFromConfig())
+	// One circuit breaker per Redis operation group: an outage degrades
+	// queueing, auth codes and notification pushes predictably instead of
+	// surfacing raw infrastructure errors.
+	breakerCfg := breakerConfigFromGlobal()
+	c.Breakers = breaker.NewRegistry()
+	c.Match.SetBreaker(c.Breakers.Add(breaker.New("queueing", breakerCfg)))
+	c.Auth.SetCodeBreaker(c.Breakers.Add(breaker.New("auth_codes", breakerCfg)))
+	c.Notify.SetBreaker(c.Breakers.Add(breaker.New("notifications", breakerCfg)))
+	if config.GlobalConfig != nil {
+		game.ConfigureChatFilter(config.GlobalConfig.Chat.BannedWords)
+	}
+	return c
+}
+
+// breakerConfigFromGlobal translates the breaker config section; zero fields
+// fall through to the package defaults.
+func breakerConfigFromGlobal() breaker.Config {
+	cfg := breaker.Config{}
+	if config.GlobalConfig == nil {
+		return cfg
 	}
+	section := config.GlobalConfig.Breaker
+	cfg.Window = time.Duration(section.WindowSeconds) * time.Second
+	cfg.MinCalls = section.MinCalls
+	cfg.FailureRatio = section.FailureRatio
+	cfg.Cooldown = time.Duration(section.CooldownSeconds) * time.Second
+	return cfg
 }
 
 func (c *Container) Start(ctx context.Context) error {
 	if err := c.Admin.EnsureDefaultAdmin(ctx); err != nil {
 		return err
 	}
+	c.registerJobs()
+	c.Jobs.Start(ctx)
 	return c.Match.Start(ctx)
 }
+
+// Stop shuts services down in dependency order: matchers first so no new
+// tables are composed, then the live table runtimes. Scheduler jobs stop with
+// the context passed to Start.
+func (c *Container) Stop(ctx context.Context) error {
+	return errors.Join(
+		c.Match.Stop(ctx),
+		c.Game.Stop(ctx),
+	)
+}
+
+func (c *Container) registerJobs() {
+	c.Jobs.Register(scheduler.Job{
+		Name:      "queue_cleanup",
+		Interval:  30 * time.Second,
+		NeedsLock: true,
+		Run:       c.Match.CleanupExpiredQueues,
+	})
+	// The runtime map is per-instance state, so every instance sweeps its own.
+	c.Jobs.Register(scheduler.Job{
+		Name:      "runtime_sweeper",
+		Interval:  time.Minute,
+		NeedsLock: false,
+		Run:       c.Game.SweepIdleRuntimes,
+	})
+	c.Jobs.Register(scheduler.Job{
+		Name:      "trial_balance_refill",
+		Interval:  24 * time.Hour,
+		NeedsLock: true,
+		Run:       c.Wallet.RefillTrialBalances,
+	})
+	// Dry-run by default; reaper.autoClean arms the actual cleanup.
+	c.Jobs.Register(scheduler.Job{
+		Name:      "stale_table_reaper",
+		Interval:  10 * time.Minute,
+		NeedsLock: true,
+		Run:       c.Game.ReapStaleTables,
+	})
+	c.Jobs.Register(scheduler.Job{
+		Name:      "notification_trim",
+		Interval:  1 * time.Hour,
+		NeedsLock: true,
+		Run:       c.Notify.TrimRead,
+	})
+	if c.Archive != nil {
+		c.Jobs.Register(scheduler.Job{
+			Name:      "round_log_archive",
+			Interval:  24 * time.Hour,
+			Timeout:   10 * time.Minute,
+			NeedsLock: true,
+			Run:       c.Archive.Run,
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	// TypeNotification is the dead-letter type for inbox/WebSocket pushes
+	// that failed to persist; replays route back through notify.Service.
+	TypeNotification = "notification"
+
+	// TypeSettlement is the dead-letter type for settlements deferred by a
+	// panic stop; replays route back through game.Service.SettleMatch.
+	TypeSettlement = "settlement"
+
+	// StatusFailed marks rows still waiting for a replay.
+	StatusFailed = "failed"
+	// StatusReplayed marks rows an admin successfully pushed back through
+	// their original worker path.
+	StatusReplayed = "replayed"
+)
+
+// Handler replays one dead-lettered payload through the worker path that
+// originally produced it, so the worker's own idempotency applies unchanged.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Service is the unified dead-letter queue: any async delivery (notification
+// push, settlement retry, ...) lands here after exhausting its retries, with
+// the original payload and error history, and can be replayed by an admin.
+type Service struct {
+	db *gorm.DB
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, handlers: make(map[string]Handler)}
+}
+
+// RegisterHandler wires the replay path for one delivery type. Registration
+// happens at container wiring time, before any admin can trigger a retry.
+func (s *Service) RegisterHandler(deliveryType string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[deliveryType] = handler
+}
+
+// Record dead-letters a payload after its worker gave up on it. The payload
+// is stored verbatim so a later replay sends exactly what the worker tried.
+func (s *Service) Record(ctx context.Context, deliveryType string, payload interface{}, attempts int, deliveryErrs []string) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	errorsJSON, err := json.Marshal(deliveryErrs)
+	if err != nil {
+		return err
+	}
+
+	failure := model.DeliveryFailure{
+		Type:        deliveryType,
+		PayloadJSON: datatypes.JSON(payloadJSON),
+		ErrorsJSON:  datatypes.JSON(errorsJSON),
+		Attempts:    attempts,
+		Status:      StatusFailed,
+		CreatedAt:   time.Now(),
+	}
+	return s.db.WithContext(ctx).Create(&failure).Error
+}
+
+type ListFilter struct {
+	Type string
+	Page int
+	Size int
+}
+
+type ListResult struct {
+	Items []model.DeliveryFailure
+	Total int64
+}
+
+// ListFailed pages the rows still waiting for a replay, optionally filtered
+// by delivery type.
+func (s *Service) ListFailed(ctx context.Context, filter ListFilter) (*ListResult, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Size <= 0 {
+		filter.Size = defaultPageSize
+	}
+	if filter.Size > maxPageSize {
+		filter.Size = maxPageSize
+	}
+
+	query := s.db.WithContext(ctx).Model(&model.DeliveryFailure{}).Where("status = ?", StatusFailed)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	result := &ListResult{
+		Items: make([]model.DeliveryFailure, 0),
+		Total: total,
+	}
+	if total == 0 {
+		return result, nil
+	}
+
+	if err := query.
+		Order("id ASC").
+		Limit(filter.Size).
+		Offset((filter.Page - 1) * filter.Size).
+		Find(&result.Items).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Depths reports the outstanding dead-letter count per delivery type, the
+// metric an operator watches to notice a stalling worker.
+func (s *Service) Depths(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Type  string
+		Count int64
+	}
+	if err := s.db.WithContext(ctx).Model(&model.DeliveryFailure{}).
+		Select("type, COUNT(*) AS count").
+		Where("status = ?", StatusFailed).
+		Group("type").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	depths := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		depths[row.Type] = row.Count
+	}
+	return depths, nil
+}
+
+// Retry replays one failure through its registered handler. On success the
+// row is marked replayed with the triggering admin; on failure the error is
+// appended to the history and the row stays in the queue.
+func (s *Service) Retry(ctx context.Context, failureID, adminID int64) error {
+	var failure model.DeliveryFailure
+	err := s.db.WithContext(ctx).Limit(1).Find(&failure, "id = ?", failureID).Error
+	if err != nil {
+		return err
+	}
+	if failure.ID == 0 {
+		return appErr.ErrDeliveryFailureNotFound
+	}
+	if failure.Status == StatusReplayed {
+		return appErr.ErrDeliveryAlreadyReplayed
+	}
+	return s.replay(ctx, &failure, adminID)
+}
+
+type BatchResult struct {
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
+// RetryBatch replays every failed row of one type (or all types when empty).
+// Rows whose replay fails again keep their place in the queue; one bad row
+// never aborts the batch.
+func (s *Service) RetryBatch(ctx context.Context, deliveryType string, adminID int64) (*BatchResult, error) {
+	query := s.db.WithContext(ctx).Where("status = ?", StatusFailed)
+	if deliveryType != "" {
+		query = query.Where("type = ?", deliveryType)
+	}
+	var failures []model.DeliveryFailure
+	if err := query.Order("id ASC").Find(&failures).Error; err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{}
+	for i := range failures {
+		if err := s.replay(ctx, &failures[i], adminID); err != nil {
+			result.Failed++
+			continue
+		}
+		result.Replayed++
+	}
+	return result, nil
+}
+
+func (s *Service) replay(ctx context.Context, failure *model.DeliveryFailure, adminID int64) error {
+	s.mu.RLock()
+	handler, ok := s.handlers[failure.Type]
+	s.mu.RUnlock()
+	if !ok {
+		return appErr.ErrUnknownDeliveryType
+	}
+
+	now := time.Now()
+	if err := handler(ctx, json.RawMessage(failure.PayloadJSON)); err != nil {
+		var history []string
+		_ = json.Unmarshal(failure.ErrorsJSON, &history)
+		history = append(history, err.Error())
+		errorsJSON, marshalErr := json.Marshal(history)
+		if marshalErr != nil {
+			return err
+		}
+		if updateErr := s.db.WithContext(ctx).Model(failure).Updates(map[string]interface{}{
+			"attempts":    failure.Attempts + 1,
+			"errors_json": datatypes.JSON(errorsJSON),
+			"retried_by":  adminID,
+			"retried_at":  now,
+		}).Error; updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	return s.db.WithContext(ctx).Model(failure).Updates(map[string]interface{}{
+		"status":     StatusReplayed,
+		"attempts":   failure.Attempts + 1,
+		"retried_by": adminID,
+		"retried_at": now,
+	}).Error
+}
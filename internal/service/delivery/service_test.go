@@ -0,0 +1,188 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.DeliveryFailure{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return NewService(db)
+}
+
+func TestRecordListAndDepths(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	if err := s.Record(ctx, TypeNotification, map[string]interface{}{"userId": 1}, 3, []string{"timeout", "timeout"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := s.Record(ctx, TypeNotification, map[string]interface{}{"userId": 2}, 1, []string{"db closed"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := s.Record(ctx, "settlement", map[string]interface{}{"matchId": 9}, 5, nil); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	result, err := s.ListFailed(ctx, ListFilter{Type: TypeNotification})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if result.Total != 2 || len(result.Items) != 2 {
+		t.Fatalf("expected 2 notification failures, got total=%d items=%d", result.Total, len(result.Items))
+	}
+	if result.Items[0].Attempts != 3 || result.Items[0].Status != StatusFailed {
+		t.Fatalf("unexpected first row: %+v", result.Items[0])
+	}
+	var history []string
+	if err := json.Unmarshal(result.Items[0].ErrorsJSON, &history); err != nil || len(history) != 2 {
+		t.Fatalf("expected 2 recorded errors, got %v (%v)", history, err)
+	}
+
+	depths, err := s.Depths(ctx)
+	if err != nil {
+		t.Fatalf("depths: %v", err)
+	}
+	if depths[TypeNotification] != 2 || depths["settlement"] != 1 {
+		t.Fatalf("unexpected depths: %v", depths)
+	}
+}
+
+func TestRetryRoutesThroughHandlerAndRecordsAdmin(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	var replayed []json.RawMessage
+	s.RegisterHandler(TypeNotification, func(_ context.Context, payload json.RawMessage) error {
+		replayed = append(replayed, payload)
+		return nil
+	})
+
+	if err := s.Record(ctx, TypeNotification, map[string]interface{}{"userId": 7}, 1, []string{"timeout"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	if err := s.Retry(ctx, 1, 42); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if len(replayed) != 1 {
+		t.Fatalf("expected 1 replay through the handler, got %d", len(replayed))
+	}
+
+	var failure model.DeliveryFailure
+	if err := s.db.First(&failure, 1).Error; err != nil {
+		t.Fatalf("load failure: %v", err)
+	}
+	if failure.Status != StatusReplayed || failure.RetriedBy != 42 || failure.RetriedAt == nil {
+		t.Fatalf("replay not recorded: %+v", failure)
+	}
+	if failure.Attempts != 2 {
+		t.Fatalf("expected attempt count bumped to 2, got %d", failure.Attempts)
+	}
+
+	// A replayed row cannot be replayed twice.
+	if err := s.Retry(ctx, 1, 42); !errors.Is(err, appErr.ErrDeliveryAlreadyReplayed) {
+		t.Fatalf("expected ErrDeliveryAlreadyReplayed, got %v", err)
+	}
+}
+
+func TestRetryFailureExtendsHistoryAndKeepsRow(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	s.RegisterHandler(TypeNotification, func(context.Context, json.RawMessage) error {
+		return fmt.Errorf("still down")
+	})
+	if err := s.Record(ctx, TypeNotification, nil, 1, []string{"timeout"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	if err := s.Retry(ctx, 1, 42); err == nil {
+		t.Fatal("expected replay error to surface")
+	}
+
+	var failure model.DeliveryFailure
+	if err := s.db.First(&failure, 1).Error; err != nil {
+		t.Fatalf("load failure: %v", err)
+	}
+	if failure.Status != StatusFailed || failure.Attempts != 2 {
+		t.Fatalf("expected row kept failed with bumped attempts, got %+v", failure)
+	}
+	var history []string
+	if err := json.Unmarshal(failure.ErrorsJSON, &history); err != nil || len(history) != 2 || history[1] != "still down" {
+		t.Fatalf("expected error appended to history, got %v (%v)", history, err)
+	}
+}
+
+func TestRetryErrors(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	if err := s.Retry(ctx, 99, 1); !errors.Is(err, appErr.ErrDeliveryFailureNotFound) {
+		t.Fatalf("expected ErrDeliveryFailureNotFound, got %v", err)
+	}
+
+	if err := s.Record(ctx, "unwired", nil, 1, nil); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := s.Retry(ctx, 1, 1); !errors.Is(err, appErr.ErrUnknownDeliveryType) {
+		t.Fatalf("expected ErrUnknownDeliveryType, got %v", err)
+	}
+}
+
+func TestRetryBatchFiltersByType(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	calls := 0
+	s.RegisterHandler(TypeNotification, func(context.Context, json.RawMessage) error {
+		calls++
+		if calls == 1 {
+			return fmt.Errorf("first still failing")
+		}
+		return nil
+	})
+
+	if err := s.Record(ctx, TypeNotification, nil, 1, nil); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := s.Record(ctx, TypeNotification, nil, 1, nil); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := s.Record(ctx, "settlement", nil, 1, nil); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	result, err := s.RetryBatch(ctx, TypeNotification, 42)
+	if err != nil {
+		t.Fatalf("retry batch: %v", err)
+	}
+	if result.Replayed != 1 || result.Failed != 1 {
+		t.Fatalf("expected 1 replayed and 1 failed, got %+v", result)
+	}
+
+	depths, err := s.Depths(ctx)
+	if err != nil {
+		t.Fatalf("depths: %v", err)
+	}
+	if depths[TypeNotification] != 1 || depths["settlement"] != 1 {
+		t.Fatalf("expected untouched rows to stay queued, got %v", depths)
+	}
+}
@@ -0,0 +1,282 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Well-known flag keys.
+const (
+	// FlagSkipLocationValidation supersedes features.skipLocationValidation in
+	// config.yaml; the config value remains the fallback when no row exists.
+	FlagSkipLocationValidation = "skip_location_validation"
+	// FlagSidePots gates side-pot settlement while it rolls out per scene.
+	FlagSidePots = "side_pots"
+	// FlagAdminLiveCards lets targeted admins see unredacted per-seat cards
+	// in the live table view.
+	FlagAdminLiveCards = "admin_live_cards"
+)
+
+const cacheTTL = 15 * time.Second
+
+// Subject identifies who/where a flag is evaluated for; zero fields are
+// ignored by targeting rules.
+type Subject struct {
+	UserID  int64
+	SceneID int64
+}
+
+// targetingRules is the shape of FeatureFlag.RulesJSON. Every populated rule
+// must pass; an empty rules object targets everyone.
+type targetingRules struct {
+	SceneIDs    []int64 `json:"sceneIds,omitempty"`
+	UserIDs     []int64 `json:"userIds,omitempty"`
+	UserPercent *int    `json:"userPercent,omitempty"` // 0-100 rollout bucketed by userID
+}
+
+type cacheEntry struct {
+	flag     *model.FeatureFlag // nil: key has no row, fall back to config
+	loadedAt time.Time
+}
+
+// Service evaluates feature flags with a short in-memory cache so hot paths
+// like the matcher tick don't hit the DB per call.
+type Service struct {
+	db *gorm.DB
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, cache: make(map[string]cacheEntry)}
+}
+
+// Enabled reports whether the flag identified by key is on for the subject.
+// Unknown keys fall back to the static config value where one exists.
+func (s *Service) Enabled(ctx context.Context, key string, subject Subject) bool {
+	flag, err := s.load(ctx, key)
+	if err != nil {
+		logger.Log.Warn("feature flag lookup failed", zap.String("key", key), zap.Error(err))
+		return configFallback(key)
+	}
+	if flag == nil {
+		return configFallback(key)
+	}
+	if !flag.Enabled {
+		return false
+	}
+
+	var rules targetingRules
+	if len(flag.RulesJSON) > 0 {
+		if err := json.Unmarshal(flag.RulesJSON, &rules); err != nil {
+			logger.Log.Warn("feature flag rules invalid", zap.String("key", key), zap.Error(err))
+			return false
+		}
+	}
+	return rules.matches(subject)
+}
+
+func configFallback(key string) bool {
+	if key == FlagSkipLocationValidation {
+		return config.GlobalConfig != nil && config.GlobalConfig.Features.SkipLocationValidation
+	}
+	return false
+}
+
+func (r targetingRules) matches(subject Subject) bool {
+	if len(r.SceneIDs) > 0 && !containsID(r.SceneIDs, subject.SceneID) {
+		return false
+	}
+	if len(r.UserIDs) > 0 && !containsID(r.UserIDs, subject.UserID) {
+		return false
+	}
+	if r.UserPercent != nil {
+		if subject.UserID == 0 {
+			return false
+		}
+		if subject.UserID%100 >= int64(*r.UserPercent) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) load(ctx context.Context, key string) (*model.FeatureFlag, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && time.Since(entry.loadedAt) < cacheTTL {
+		return entry.flag, nil
+	}
+
+	var flags []model.FeatureFlag
+	err := s.db.WithContext(ctx).
+		Where("key = ?", key).
+		Limit(1).
+		Find(&flags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var flag *model.FeatureFlag
+	if len(flags) > 0 {
+		flag = &flags[0]
+	}
+	s.mu.Lock()
+	s.cache[key] = cacheEntry{flag: flag, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return flag, nil
+}
+
+func (s *Service) invalidate(key string) {
+	s.mu.Lock()
+	delete(s.cache, key)
+	s.mu.Unlock()
+}
+
+type FlagListResult struct {
+	Items []model.FeatureFlag
+	Total int64
+}
+
+type FlagMutationParams struct {
+	Key       string
+	Enabled   bool
+	RulesJSON json.RawMessage
+	Remark    string
+}
+
+func (s *Service) ListFlags(ctx context.Context, page, size int) (*FlagListResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size <= 0 {
+		size = 20
+	}
+	if size > 100 {
+		size = 100
+	}
+
+	var total int64
+	if err := s.db.WithContext(ctx).
+		Model(&model.FeatureFlag{}).
+		Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var flags []model.FeatureFlag
+	if total > 0 {
+		offset := (page - 1) * size
+		if err := s.db.WithContext(ctx).
+			Model(&model.FeatureFlag{}).
+			Order("id DESC").
+			Limit(size).
+			Offset(offset).
+			Find(&flags).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &FlagListResult{
+		Items: flags,
+		Total: total,
+	}, nil
+}
+
+func (s *Service) CreateFlag(ctx context.Context, params FlagMutationParams) (*model.FeatureFlag, error) {
+	if err := validateRules(params.RulesJSON); err != nil {
+		return nil, err
+	}
+	flag := model.FeatureFlag{
+		Key:       params.Key,
+		Enabled:   params.Enabled,
+		RulesJSON: datatypes.JSON(params.RulesJSON),
+		Remark:    params.Remark,
+	}
+	if err := s.db.WithContext(ctx).Create(&flag).Error; err != nil {
+		return nil, err
+	}
+	s.invalidate(flag.Key)
+	return &flag, nil
+}
+
+func (s *Service) UpdateFlag(ctx context.Context, id int64, params FlagMutationParams) (*model.FeatureFlag, error) {
+	if err := validateRules(params.RulesJSON); err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{
+		"key":        params.Key,
+		"enabled":    params.Enabled,
+		"rules_json": datatypes.JSON(params.RulesJSON),
+		"remark":     params.Remark,
+	}
+
+	result := s.db.WithContext(ctx).
+		Model(&model.FeatureFlag{}).
+		Where("id = ?", id).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, appErr.ErrFeatureFlagNotFound
+	}
+
+	var flag model.FeatureFlag
+	if err := s.db.WithContext(ctx).First(&flag, id).Error; err != nil {
+		return nil, err
+	}
+	s.invalidate(params.Key)
+	s.invalidate(flag.Key)
+	return &flag, nil
+}
+
+func (s *Service) DeleteFlag(ctx context.Context, id int64) error {
+	var flag model.FeatureFlag
+	if err := s.db.WithContext(ctx).First(&flag, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return appErr.ErrFeatureFlagNotFound
+		}
+		return err
+	}
+	if err := s.db.WithContext(ctx).Delete(&model.FeatureFlag{}, id).Error; err != nil {
+		return err
+	}
+	s.invalidate(flag.Key)
+	return nil
+}
+
+func validateRules(raw json.RawMessage) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	var rules targetingRules
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return appErr.ErrInvalidFlagRules
+	}
+	if rules.UserPercent != nil && (*rules.UserPercent < 0 || *rules.UserPercent > 100) {
+		return appErr.ErrInvalidFlagRules
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	appErr "dx-service/pkg/errors"
+)
+
+func maskRuntime(mask map[string]bool, bobo bool, round int) *TableRuntime {
+	return &TableRuntime{
+		basePi:      10,
+		minUnitPi:   1,
+		phase:       PhasePlaying,
+		round:       round,
+		turnSeat:    1,
+		lastRaise:   10,
+		boboEnabled: bobo,
+		actionMask:  mask,
+		roundActed:  map[int]bool{2: true},
+		seatByUser:  map[int64]int{101: 1, 102: 2},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Bet: 10, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 1000, Bet: 10, Status: "playing"},
+		},
+	}
+}
+
+func TestActionMaskCombinations(t *testing.T) {
+	cases := []struct {
+		name    string
+		mask    map[string]bool
+		bobo    bool
+		round   int
+		want    []string
+		wantNot []string
+	}{
+		{
+			name:    "nil mask keeps current behavior",
+			mask:    nil,
+			round:   1,
+			want:    []string{"fold", "pass", "raise"},
+			wantNot: []string{"knock_bobo"},
+		},
+		{
+			name:    "call only variant",
+			mask:    map[string]bool{},
+			round:   1,
+			want:    []string{"fold", "call"},
+			wantNot: []string{"pass", "raise", "knock_bobo"},
+		},
+		{
+			name:    "raise disabled",
+			mask:    map[string]bool{"pass": true, "knock_bobo": true},
+			round:   1,
+			want:    []string{"fold", "pass"},
+			wantNot: []string{"raise"},
+		},
+		{
+			name:    "knock disabled on chexuan scene falls back to raise",
+			mask:    map[string]bool{"raise": true, "pass": true},
+			bobo:    true,
+			round:   2,
+			want:    []string{"fold", "pass", "raise"},
+			wantNot: []string{"knock_bobo"},
+		},
+		{
+			name:    "bobo scene keeps knock when allowed",
+			mask:    map[string]bool{"raise": true, "pass": true, "knock_bobo": true},
+			bobo:    true,
+			round:   2,
+			want:    []string{"knock_bobo"},
+			wantNot: []string{"raise"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := maskRuntime(tc.mask, tc.bobo, tc.round)
+			actions := rt.allowedActionsLocked(101)
+			for _, want := range tc.want {
+				if !containsAction(actions, want) {
+					t.Fatalf("expected %q in %v", want, actions)
+				}
+			}
+			for _, not := range tc.wantNot {
+				if containsAction(actions, not) {
+					t.Fatalf("expected %q absent from %v", not, actions)
+				}
+			}
+		})
+	}
+}
+
+func TestMaskedActionRejected(t *testing.T) {
+	rt := maskRuntime(map[string]bool{"pass": true}, false, 1)
+
+	err := rt.handleTurnActionLocked("raise", 1, raiseData(t, 30))
+	if !errors.Is(err, appErr.ErrActionDisabled) {
+		t.Fatalf("expected ErrActionDisabled, got %v", err)
+	}
+	if err := rt.handleTurnActionLocked("pass", 1, nil); err != nil {
+		t.Fatalf("expected allowed pass to work, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	appErr "dx-service/pkg/errors"
+)
+
+func TestAdminAdjustBetweenHands(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+
+	streak := 2
+	turn := 30
+	before, after, err := rt.AdminAdjust(AdminAdjustment{
+		MangoStreak: &streak,
+		TurnSeconds: &turn,
+		Reason:      "dispute resolved",
+	})
+	if err != nil {
+		t.Fatalf("adjust failed: %v", err)
+	}
+	if before["mangoStreak"] != 0 || before["turnSeconds"] != defaultTurnSeconds {
+		t.Fatalf("unexpected before snapshot %v", before)
+	}
+	if after["mangoStreak"] != 2 || after["turnSeconds"] != 30 {
+		t.Fatalf("unexpected after snapshot %v", after)
+	}
+	if rt.mangoStreak != 2 || rt.turnSeconds != 30 {
+		t.Fatalf("expected runtime updated, got streak %d turn %d", rt.mangoStreak, rt.turnSeconds)
+	}
+}
+
+func TestAdminAdjustRejectedMidHand(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+	startTimeBankHand(t, rt)
+
+	streak := 1
+	_, _, err := rt.AdminAdjust(AdminAdjustment{MangoStreak: &streak, Reason: "test"})
+	if !errors.Is(err, appErr.ErrTableMidHand) {
+		t.Fatalf("expected ErrTableMidHand, got %v", err)
+	}
+}
+
+func TestAdminAdjustValidatesRanges(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+
+	streak := 5
+	if _, _, err := rt.AdminAdjust(AdminAdjustment{MangoStreak: &streak, Reason: "test"}); !errors.Is(err, appErr.ErrInvalidAdjustment) {
+		t.Fatalf("expected ErrInvalidAdjustment for streak out of range, got %v", err)
+	}
+
+	turn := 1
+	if _, _, err := rt.AdminAdjust(AdminAdjustment{TurnSeconds: &turn, Reason: "test"}); !errors.Is(err, appErr.ErrInvalidAdjustment) {
+		t.Fatalf("expected ErrInvalidAdjustment for turn seconds out of range, got %v", err)
+	}
+
+	if _, _, err := rt.AdminAdjust(AdminAdjustment{Reason: "test"}); !errors.Is(err, appErr.ErrInvalidAdjustment) {
+		t.Fatalf("expected ErrInvalidAdjustment for empty adjustment, got %v", err)
+	}
+}
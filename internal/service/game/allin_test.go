@@ -0,0 +1,168 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"dx-service/internal/model"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupAllInRuntime seats two players with asymmetric stacks: user 101 is the
+// short stack, user 102 the deep one. The deck is fixed so showdown tests can
+// pick the winner.
+func setupAllInRuntime(t *testing.T, shortChips int64, deck []string) *TableRuntime {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}, &model.Match{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	scene := model.Scene{ID: 1, Name: "test", SeatCount: 2, BasePi: 10, MinUnitPi: 1}
+	table := model.Table{
+		ID:          1,
+		SceneID:     scene.ID,
+		SeatCount:   2,
+		PlayersJSON: []byte(fmt.Sprintf(`{"1":{"userId":101,"chips":%d},"2":{"userId":102,"chips":1000}}`, shortChips)),
+	}
+
+	rt, err := newTableRuntime(context.Background(), db, table, scene, 0, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	rt.fixedDeck = deck
+	t.Cleanup(rt.stop)
+	return rt
+}
+
+func raisePayload(amount int64) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"amount":%d}`, amount))
+}
+
+func TestRaiseToStackBelowMinimumIsAllIn(t *testing.T) {
+	// Aces to the short stack so the side-pot cap is what limits its win.
+	rt := setupAllInRuntime(t, 40, []string{"As", "2s", "Ah", "7d"})
+	startTimeBankHand(t, rt)
+	if rt.turnSeat != 2 {
+		t.Fatalf("fixture expects seat 2 to act first, got %d", rt.turnSeat)
+	}
+
+	// Round 1: the deep stack opens at 2*basePi, the short stack calls.
+	if err := rt.HandleAction(102, "raise", raisePayload(20)); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if err := rt.HandleAction(101, "call", nil); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if rt.round != 2 {
+		t.Fatalf("expected round 2, got %d", rt.round)
+	}
+
+	// Round 2: a raise to 120 leaves the short stack (bet 20, chips 20)
+	// below the raise minimum.
+	if err := rt.HandleAction(102, "raise", raisePayload(120)); err != nil {
+		t.Fatalf("raise failed: %v", err)
+	}
+	if err := rt.HandleAction(101, "raise", raisePayload(25)); err == nil {
+		t.Fatal("expected a short raise below the minimum rejected")
+	}
+	if err := rt.HandleAction(101, "raise", raisePayload(40)); err != nil {
+		t.Fatalf("raise to the whole stack must be accepted as all-in: %v", err)
+	}
+
+	seat := rt.findSeatLocked(1)
+	if seat.Status != "allin" || seat.Bet != 40 {
+		t.Fatalf("expected seat 1 all-in for 40, got status %s bet %d", seat.Status, seat.Bet)
+	}
+	if rt.lastRaise != 120 {
+		t.Fatalf("an all-in for less must not reopen the betting, lastRaise %d", rt.lastRaise)
+	}
+
+	// Everyone else has acted, so the hand runs straight to showdown; the
+	// all-in winner collects only the 40 it matched, the excess 80 returns.
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected showdown settled, got %s", rt.phase)
+	}
+	if got := rt.findSeatLocked(1).Chips; got != 80 {
+		t.Fatalf("expected the all-in winner at 80 chips, got %d", got)
+	}
+	if got := rt.findSeatLocked(2).Chips; got != 960 {
+		t.Fatalf("expected the covered loser at 960 chips, got %d", got)
+	}
+}
+
+func TestAllInActionAsCall(t *testing.T) {
+	// The deep stack holds the pair this time; the all-in seat still reaches
+	// showdown but loses only what it put in.
+	rt := setupAllInRuntime(t, 100, []string{"3c", "Ks", "8h", "Kd"})
+	startTimeBankHand(t, rt)
+
+	if err := rt.HandleAction(102, "raise", raisePayload(20)); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if err := rt.HandleAction(101, "raise", raisePayload(60)); err != nil {
+		t.Fatalf("raise failed: %v", err)
+	}
+	if err := rt.HandleAction(102, "call", nil); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if rt.round != 2 {
+		t.Fatalf("expected round 2, got %d", rt.round)
+	}
+
+	// Round 2: the raise to 300 is more than the short stack can cover, so
+	// its only ways forward are fold or the all-in call.
+	if err := rt.HandleAction(102, "raise", raisePayload(300)); err != nil {
+		t.Fatalf("raise failed: %v", err)
+	}
+	actions := rt.allowedActionsLocked(101)
+	found := false
+	for _, a := range actions {
+		if a == "allin" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected allin offered, got %v", actions)
+	}
+
+	if err := rt.HandleAction(101, "allin", nil); err != nil {
+		t.Fatalf("allin failed: %v", err)
+	}
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected showdown settled, got %s", rt.phase)
+	}
+	if got := rt.findSeatLocked(1).Chips; got != 0 {
+		t.Fatalf("expected the all-in loser felted, got %d", got)
+	}
+	if got := rt.findSeatLocked(2).Chips; got != 1100 {
+		t.Fatalf("expected the winner capped at the matched 100, got %d chips", got)
+	}
+}
+
+func TestNextActiveSkipsAllInSeat(t *testing.T) {
+	rt := &TableRuntime{
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Status: "allin"},
+			{SeatIndex: 3, UserID: 103, Status: "playing"},
+		},
+	}
+	if got := rt.nextActiveAfterLocked(1); got != 3 {
+		t.Fatalf("rotation must skip the all-in seat, got %d", got)
+	}
+
+	rt.seats[0].Status = "allin"
+	rt.seats[2].Status = "allin"
+	if got := rt.nextActiveAfterLocked(1); got != 0 {
+		t.Fatalf("with everyone all-in there is no next actor, got %d", got)
+	}
+}
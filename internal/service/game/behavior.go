@@ -0,0 +1,181 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+type seatBehavior struct {
+	counts       map[string]int
+	latencies    []int64
+	timeouts     int
+	foldsToRaise map[int64]int
+}
+
+// recordMatchBehavior replays the persisted round logs of a settled match and
+// writes one match_behaviors row per player. It runs off the table loop so it
+// never delays the finish broadcast; failures only cost analysis data.
+func (s *Service) recordMatchBehavior(ctx context.Context, matchID int64, seatUsers map[int]int64) {
+	var logs []model.MatchRoundLog
+	if err := s.db.WithContext(ctx).
+		Where("match_id = ?", matchID).
+		Order("id ASC").
+		Find(&logs).Error; err != nil {
+		logger.WithContext(ctx).Warn("behavior aggregation failed to load round logs",
+			zap.Int64("matchID", matchID), zap.Error(err))
+		return
+	}
+
+	stats := make(map[int]*seatBehavior)
+	lastRaiseSeat := 0
+	for _, roundLog := range logs {
+		var actions []actionEntry
+		if err := json.Unmarshal(roundLog.ActionsJSON, &actions); err != nil {
+			continue
+		}
+		for _, entry := range actions {
+			if strings.HasSuffix(entry.Action, "_start") {
+				lastRaiseSeat = 0
+				continue
+			}
+			st := stats[entry.Seat]
+			if st == nil {
+				st = &seatBehavior{counts: make(map[string]int), foldsToRaise: make(map[int64]int)}
+				stats[entry.Seat] = st
+			}
+			st.counts[entry.Action]++
+			if entry.LatencyMS > 0 {
+				st.latencies = append(st.latencies, entry.LatencyMS)
+			}
+			if reason, _ := entry.Meta["reason"].(string); reason == "timeout" {
+				st.timeouts++
+			}
+			switch entry.Action {
+			case "raise":
+				lastRaiseSeat = entry.Seat
+			case "fold", "auto_fold":
+				if lastRaiseSeat != 0 && lastRaiseSeat != entry.Seat {
+					if raiser, ok := seatUsers[lastRaiseSeat]; ok {
+						st.foldsToRaise[raiser]++
+					}
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	rows := make([]model.MatchBehavior, 0, len(stats))
+	for seatIdx, st := range stats {
+		userID, ok := seatUsers[seatIdx]
+		if !ok || userID == 0 {
+			continue
+		}
+		avg, p95 := latencyStats(st.latencies)
+		rows = append(rows, model.MatchBehavior{
+			MatchID:          matchID,
+			UserID:           userID,
+			SeatIndex:        seatIdx,
+			ActionCountsJSON: mustJSON(st.counts),
+			AvgDecisionMS:    avg,
+			P95DecisionMS:    p95,
+			Timeouts:         st.timeouts,
+			FoldsToRaiseJSON: mustJSON(st.foldsToRaise),
+			CreatedAt:        now,
+		})
+	}
+	if len(rows) == 0 {
+		return
+	}
+	if err := s.db.WithContext(ctx).Create(&rows).Error; err != nil {
+		logger.WithContext(ctx).Warn("behavior aggregation failed to save",
+			zap.Int64("matchID", matchID), zap.Error(err))
+	}
+}
+
+func latencyStats(samples []int64) (avg, p95 int64) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	sorted := append([]int64(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var sum int64
+	for _, v := range sorted {
+		sum += v
+	}
+	avg = sum / int64(len(sorted))
+	idx := (len(sorted)*95 + 99) / 100
+	if idx > 0 {
+		idx--
+	}
+	p95 = sorted[idx]
+	return avg, p95
+}
+
+// BehaviorSummary aggregates a user's match_behaviors rows over a window.
+type BehaviorSummary struct {
+	UserID           int64            `json:"userId,string"`
+	Matches          int64            `json:"matches"`
+	Actions          map[string]int64 `json:"actions"`
+	AvgDecisionMS    int64            `json:"avgDecisionMs"`
+	MaxP95DecisionMS int64            `json:"maxP95DecisionMs"`
+	Timeouts         int64            `json:"timeouts"`
+	FoldsToRaise     map[string]int64 `json:"foldsToRaise"` // opponent userId -> count
+}
+
+// UserBehavior summarizes a player's recorded behavior across matches settled
+// within the window.
+func (s *Service) UserBehavior(ctx context.Context, userID int64, window time.Duration) (*BehaviorSummary, error) {
+	query := s.db.WithContext(ctx).Where("user_id = ?", userID)
+	if window > 0 {
+		query = query.Where("created_at >= ?", time.Now().Add(-window))
+	}
+
+	var rows []model.MatchBehavior
+	if err := query.Order("id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	summary := &BehaviorSummary{
+		UserID:       userID,
+		Matches:      int64(len(rows)),
+		Actions:      make(map[string]int64),
+		FoldsToRaise: make(map[string]int64),
+	}
+	var avgSum int64
+	var avgCount int64
+	for _, row := range rows {
+		var counts map[string]int64
+		if err := json.Unmarshal(row.ActionCountsJSON, &counts); err == nil {
+			for action, n := range counts {
+				summary.Actions[action] += n
+			}
+		}
+		var folds map[int64]int64
+		if err := json.Unmarshal(row.FoldsToRaiseJSON, &folds); err == nil {
+			for raiser, n := range folds {
+				summary.FoldsToRaise[strconv.FormatInt(raiser, 10)] += n
+			}
+		}
+		if row.AvgDecisionMS > 0 {
+			avgSum += row.AvgDecisionMS
+			avgCount++
+		}
+		if row.P95DecisionMS > summary.MaxP95DecisionMS {
+			summary.MaxP95DecisionMS = row.P95DecisionMS
+		}
+		summary.Timeouts += int64(row.Timeouts)
+	}
+	if avgCount > 0 {
+		summary.AvgDecisionMS = avgSum / avgCount
+	}
+	return summary, nil
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newBehaviorTestService(t *testing.T) (*gorm.DB, *Service) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.MatchRoundLog{}, &model.MatchBehavior{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db, &Service{db: db}
+}
+
+func seedRoundLog(t *testing.T, db *gorm.DB, matchID int64, actions string) {
+	t.Helper()
+	if err := db.Create(&model.MatchRoundLog{
+		MatchID:     matchID,
+		RoundNo:     1,
+		ActionsJSON: []byte(actions),
+		CreatedAt:   time.Now(),
+	}).Error; err != nil {
+		t.Fatalf("failed to seed round log: %v", err)
+	}
+}
+
+func TestRecordMatchBehavior(t *testing.T) {
+	db, svc := newBehaviorTestService(t)
+	ctx := context.Background()
+
+	seedRoundLog(t, db, 9, `[{"action":"round1_start","seat":1}]`)
+	seedRoundLog(t, db, 9, `[{"action":"raise","seat":1,"amount":50,"latencyMs":1200}]`)
+	seedRoundLog(t, db, 9, `[{"action":"fold","seat":2,"latencyMs":3000}]`)
+	seedRoundLog(t, db, 9, `[{"action":"auto_fold","seat":3,"latencyMs":15000,"meta":{"reason":"timeout"}}]`)
+
+	seatUsers := map[int]int64{1: 101, 2: 102, 3: 103}
+	svc.recordMatchBehavior(ctx, 9, seatUsers)
+
+	var rows []model.MatchBehavior
+	if err := db.Order("user_id ASC").Find(&rows).Error; err != nil {
+		t.Fatalf("failed to load behavior rows: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 behavior rows, got %d", len(rows))
+	}
+
+	folder := rows[1]
+	if folder.UserID != 102 || folder.AvgDecisionMS != 3000 {
+		t.Fatalf("unexpected folder row: %+v", folder)
+	}
+	if string(folder.FoldsToRaiseJSON) != `{"101":1}` {
+		t.Fatalf("expected fold attributed to raiser 101, got %s", folder.FoldsToRaiseJSON)
+	}
+
+	timedOut := rows[2]
+	if timedOut.Timeouts != 1 {
+		t.Fatalf("expected 1 timeout for seat 3, got %d", timedOut.Timeouts)
+	}
+}
+
+func TestUserBehaviorSummarizesWindow(t *testing.T) {
+	db, svc := newBehaviorTestService(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	rows := []model.MatchBehavior{
+		{MatchID: 1, UserID: 7, ActionCountsJSON: []byte(`{"fold":2,"call":1}`), FoldsToRaiseJSON: []byte(`{"9":2}`), AvgDecisionMS: 1000, P95DecisionMS: 2000, Timeouts: 1, CreatedAt: now},
+		{MatchID: 2, UserID: 7, ActionCountsJSON: []byte(`{"fold":1}`), FoldsToRaiseJSON: []byte(`{"9":1}`), AvgDecisionMS: 3000, P95DecisionMS: 5000, CreatedAt: now},
+		{MatchID: 3, UserID: 7, ActionCountsJSON: []byte(`{"raise":4}`), FoldsToRaiseJSON: []byte(`{}`), AvgDecisionMS: 100, P95DecisionMS: 100, CreatedAt: now.Add(-60 * 24 * time.Hour)},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("failed to seed behavior rows: %v", err)
+	}
+
+	summary, err := svc.UserBehavior(ctx, 7, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("user behavior failed: %v", err)
+	}
+	if summary.Matches != 2 {
+		t.Fatalf("expected 2 matches in window, got %d", summary.Matches)
+	}
+	if summary.Actions["fold"] != 3 || summary.Actions["raise"] != 0 {
+		t.Fatalf("unexpected action counts: %v", summary.Actions)
+	}
+	if summary.FoldsToRaise["9"] != 3 {
+		t.Fatalf("expected 3 folds to raiser 9, got %v", summary.FoldsToRaise)
+	}
+	if summary.AvgDecisionMS != 2000 || summary.MaxP95DecisionMS != 5000 {
+		t.Fatalf("unexpected latency summary: %+v", summary)
+	}
+	if summary.Timeouts != 1 {
+		t.Fatalf("expected 1 timeout, got %d", summary.Timeouts)
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	avg, p95 := latencyStats([]int64{100, 200, 300, 400})
+	if avg != 250 {
+		t.Fatalf("expected avg 250, got %d", avg)
+	}
+	if p95 != 400 {
+		t.Fatalf("expected p95 400, got %d", p95)
+	}
+	if avg, p95 = latencyStats(nil); avg != 0 || p95 != 0 {
+		t.Fatalf("expected zero stats for no samples")
+	}
+}
@@ -0,0 +1,77 @@
+package game
+
+import (
+	"testing"
+)
+
+// The first actor of round 1 owes the special 2*basePi opening amount; the
+// exported BetInfo must reflect it, net of the ante already posted, and only
+// appear for the user holding the turn.
+func TestBetInfoRound1FirstActor(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+	actor := startTimeBankHand(t, rt)
+
+	state := rt.exportStateLocked(actor)
+	if state.BetInfo == nil {
+		t.Fatal("expected BetInfo for the acting user")
+	}
+	// requiredCallAmountLocked targets 2*basePi (20); the ante (10) is
+	// already in, so 10 chips remain to call.
+	if state.BetInfo.CallAmount != 10 {
+		t.Fatalf("expected first-actor call of 10, got %d", state.BetInfo.CallAmount)
+	}
+	if state.BetInfo.RaiseConvertsToBobo {
+		t.Fatal("round 1 raises must not convert to knock_bobo")
+	}
+	if state.CallAmount == nil || *state.CallAmount != state.BetInfo.CallAmount {
+		t.Fatalf("legacy call amount must match BetInfo, got %v", state.CallAmount)
+	}
+	if state.BetInfo.MinRaise != rt.minRaiseAmountLocked() {
+		t.Fatalf("expected validator min raise %d, got %d", rt.minRaiseAmountLocked(), state.BetInfo.MinRaise)
+	}
+
+	var other int64
+	for _, seat := range rt.seats {
+		if seat.UserID != actor {
+			other = seat.UserID
+		}
+	}
+	if waiting := rt.exportStateLocked(other); waiting.BetInfo != nil {
+		t.Fatalf("BetInfo must stay private to the acting user, got %+v", waiting.BetInfo)
+	}
+}
+
+// In round 2 with bobo enabled a raise is redirected to knock_bobo; the
+// exported flag mirrors that redirect so the client swaps the slider for the
+// knock control.
+func TestBetInfoRound2BoboConversion(t *testing.T) {
+	rt := &TableRuntime{
+		phase:       PhasePlaying,
+		round:       2,
+		turnSeat:    1,
+		boboEnabled: true,
+		basePi:      10,
+		minUnitPi:   1,
+		lastRaise:   20,
+		seatByUser:  map[int64]int{101: 1, 102: 2},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 980, Bet: 20, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 980, Bet: 20, Status: "playing"},
+		},
+	}
+
+	state := rt.exportStateLocked(101)
+	if state.BetInfo == nil || !state.BetInfo.RaiseConvertsToBobo {
+		t.Fatalf("expected the bobo conversion flagged, got %+v", state.BetInfo)
+	}
+	if state.BetInfo.CallAmount != 0 {
+		t.Fatalf("matched bet owes nothing to call, got %d", state.BetInfo.CallAmount)
+	}
+
+	// Once this round's knock has happened, raises validate normally again.
+	rt.round2Knock = true
+	state = rt.exportStateLocked(101)
+	if state.BetInfo == nil || state.BetInfo.RaiseConvertsToBobo {
+		t.Fatalf("expected no conversion after the knock, got %+v", state.BetInfo)
+	}
+}
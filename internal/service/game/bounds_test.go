@@ -0,0 +1,55 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestCallAmountMatchesHandleCall(t *testing.T) {
+	rt := potCapRuntime(95, 100)
+	rt.lastRaise = 40
+
+	want := rt.callAmountLocked(1)
+	before := rt.findSeatLocked(1).Chips
+	if err := rt.handleCallLocked(1); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if paid := before - rt.findSeatLocked(1).Chips; paid != want {
+		t.Fatalf("callAmountLocked said %d but handleCallLocked charged %d", want, paid)
+	}
+}
+
+func TestRaiseBoundsMatchValidation(t *testing.T) {
+	rt := potCapRuntime(80, 100)
+
+	minRaise, maxRaise := rt.raiseBoundsLocked(1)
+	if maxRaise != 30 {
+		t.Fatalf("expected max raise capped at 30 by the pot cap, got %d", maxRaise)
+	}
+
+	if err := rt.handleRaiseLocked(1, raiseData(t, minRaise-1)); err == nil {
+		t.Fatal("expected raise below the minimum to be rejected")
+	}
+	if err := rt.handleRaiseLocked(1, raiseData(t, maxRaise+1)); err == nil {
+		t.Fatal("expected raise above the maximum to be rejected")
+	}
+	if err := rt.handleRaiseLocked(1, raiseData(t, maxRaise)); err != nil {
+		t.Fatalf("raise at the advertised maximum rejected: %v", err)
+	}
+}
+
+func TestStateExposesBoundsOnlyOnTurn(t *testing.T) {
+	rt := potCapRuntime(80, 100)
+
+	mine := rt.exportStateLocked(101)
+	if mine.CallAmount == nil || mine.MinRaise == nil || mine.MaxRaise == nil {
+		t.Fatal("expected betting bounds for the seat on turn")
+	}
+	if *mine.MaxRaise != 30 {
+		t.Fatalf("expected max raise 30, got %d", *mine.MaxRaise)
+	}
+
+	other := rt.exportStateLocked(102)
+	if other.CallAmount != nil || other.MinRaise != nil || other.MaxRaise != nil {
+		t.Fatal("expected no betting bounds for a seat not on turn")
+	}
+}
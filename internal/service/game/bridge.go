@@ -0,0 +1,382 @@
+package game
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"dx-service/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// The broadcast bridge lets WebSocket subscribers work across multiple
+// instances: the instance owning a TableRuntime publishes every
+// OutgoingMessage to table:{id}:events, and instances hosting WS clients for
+// a table they don't own relay those events locally and forward actions back
+// through table:{id}:actions. Ownership is advertised via table:owner:{id}.
+
+const (
+	ownershipTTL     = 30 * time.Second
+	ownershipRefresh = 10 * time.Second
+)
+
+// TableSession abstracts a table for WS clients: either the local
+// TableRuntime or a remote relay backed by the Redis bridge.
+type TableSession interface {
+	Subscribe(userID int64) chan OutgoingMessage
+	// SubscribeSpectator is the read-only variant; nil means the table is at
+	// its spectator cap.
+	SubscribeSpectator(userID int64) chan OutgoingMessage
+	Unsubscribe(userID int64)
+	HandleAction(userID int64, action string, data json.RawMessage) error
+	// HandleActionID carries the client's retry token so duplicate attempts
+	// replay their first outcome instead of reapplying.
+	HandleActionID(userID int64, action string, data json.RawMessage, requestID string) ActionResult
+}
+
+type eventEnvelope struct {
+	UserID int64           `json:"userId"` // target subscriber
+	Msg    OutgoingMessage `json:"msg"`
+}
+
+type actionEnvelope struct {
+	UserID    int64           `json:"userId"`
+	Action    string          `json:"action"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+}
+
+const (
+	bridgeActionSubscribe   = "__subscribe"
+	bridgeActionSpectate    = "__spectate"
+	bridgeActionUnsubscribe = "__unsubscribe"
+)
+
+type bridge struct {
+	rdb        *redis.Client
+	instanceID string
+
+	// queueMu guards queues, one ordered publisher per hosted table.
+	queueMu sync.Mutex
+	queues  map[int64]*eventQueue
+}
+
+// eventQueue feeds one table's events to Redis from a single goroutine so
+// they hit the channel in emit order.
+type eventQueue struct {
+	ch   chan []byte
+	stop chan struct{}
+}
+
+// eventQueueSize bounds a table's publish backlog; a full queue drops the
+// frame, which the client recovers via its next state refresh.
+const eventQueueSize = 256
+
+func newBridge(rdb *redis.Client) *bridge {
+	if rdb == nil {
+		return nil
+	}
+	host, _ := os.Hostname()
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	return &bridge{
+		rdb:        rdb,
+		instanceID: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(buf)),
+		queues:     make(map[int64]*eventQueue),
+	}
+}
+
+func buildTableEventsChannel(tableID int64) string {
+	return fmt.Sprintf("table:%d:events", tableID)
+}
+
+func buildTableActionsChannel(tableID int64) string {
+	return fmt.Sprintf("table:%d:actions", tableID)
+}
+
+func buildTableOwnerKey(tableID int64) string {
+	return fmt.Sprintf("table:owner:%d", tableID)
+}
+
+func (b *bridge) publishEvent(tableID, userID int64, msg OutgoingMessage) {
+	if b == nil {
+		return
+	}
+	data, err := json.Marshal(eventEnvelope{UserID: userID, Msg: msg})
+	if err != nil {
+		return
+	}
+	// Enqueue off the runtime loop onto the table's ordered publisher:
+	// messages emitted back to back must reach the events channel in emit
+	// order, which per-message goroutines cannot guarantee.
+	q := b.tableQueue(tableID)
+	select {
+	case q.ch <- data:
+	default:
+		logger.Log.Warn("bridge event queue full", zap.Int64("tableID", tableID))
+	}
+}
+
+// tableQueue returns the table's publish queue, starting its drain goroutine
+// on first use.
+func (b *bridge) tableQueue(tableID int64) *eventQueue {
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+	if q, ok := b.queues[tableID]; ok {
+		return q
+	}
+	q := &eventQueue{
+		ch:   make(chan []byte, eventQueueSize),
+		stop: make(chan struct{}),
+	}
+	b.queues[tableID] = q
+	channel := buildTableEventsChannel(tableID)
+	go func() {
+		for {
+			select {
+			case data := <-q.ch:
+				b.rdb.Publish(context.Background(), channel, data)
+			case <-q.stop:
+				// Drain what was enqueued before the stop, then exit.
+				for {
+					select {
+					case data := <-q.ch:
+						b.rdb.Publish(context.Background(), channel, data)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+	return q
+}
+
+// closeTableQueue retires the table's publisher once its runtime is gone. A
+// straggling publishEvent simply spins up a fresh queue, so the shutdown
+// never races a send.
+func (b *bridge) closeTableQueue(tableID int64) {
+	if b == nil {
+		return
+	}
+	b.queueMu.Lock()
+	q, ok := b.queues[tableID]
+	delete(b.queues, tableID)
+	b.queueMu.Unlock()
+	if ok {
+		close(q.stop)
+	}
+}
+
+func (b *bridge) publishAction(tableID int64, env actionEnvelope) error {
+	if b == nil {
+		return fmt.Errorf("bridge disabled")
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return b.rdb.Publish(context.Background(), buildTableActionsChannel(tableID), data).Err()
+}
+
+// claimOwnership records this instance as the runtime host for the table and
+// keeps the claim refreshed until stop is closed.
+func (b *bridge) claimOwnership(tableID int64, stop <-chan struct{}) {
+	if b == nil {
+		return
+	}
+	ctx := context.Background()
+	key := buildTableOwnerKey(tableID)
+	b.rdb.Set(ctx, key, b.instanceID, ownershipTTL)
+
+	go func() {
+		ticker := time.NewTicker(ownershipRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.rdb.Set(ctx, key, b.instanceID, ownershipTTL)
+			case <-stop:
+				// Only release if we still hold the claim.
+				if val, err := b.rdb.Get(ctx, key).Result(); err == nil && val == b.instanceID {
+					b.rdb.Del(ctx, key)
+				}
+				b.closeTableQueue(tableID)
+				return
+			}
+		}
+	}()
+}
+
+// remoteOwner returns the owning instance ID if another instance hosts the
+// runtime, or "" when unowned or owned locally.
+func (b *bridge) remoteOwner(ctx context.Context, tableID int64) string {
+	if b == nil {
+		return ""
+	}
+	val, err := b.rdb.Get(ctx, buildTableOwnerKey(tableID)).Result()
+	if err != nil || val == b.instanceID {
+		return ""
+	}
+	return val
+}
+
+// consumeActions forwards actions published by non-owning instances into the
+// local runtime, reporting failures back through the events channel.
+func (b *bridge) consumeActions(rt *TableRuntime) {
+	if b == nil {
+		return
+	}
+	sub := b.rdb.Subscribe(context.Background(), buildTableActionsChannel(rt.tableID))
+	go func() {
+		<-rt.quitCh
+		sub.Close()
+	}()
+	go func() {
+		for msg := range sub.Channel() {
+			var env actionEnvelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+				continue
+			}
+			switch env.Action {
+			case bridgeActionSubscribe:
+				rt.subscribeRemote(env.UserID)
+			case bridgeActionSpectate:
+				rt.spectateRemote(env.UserID)
+			case bridgeActionUnsubscribe:
+				rt.unsubscribeRemote(env.UserID)
+			default:
+				if res := rt.HandleActionID(env.UserID, env.Action, env.Data, env.RequestID); res.Err != nil {
+					b.publishEvent(rt.tableID, env.UserID, OutgoingMessage{
+						Type: "error",
+						Data: ginH{"message": fmt.Sprintf("action failed: %v", res.Err)},
+					})
+				}
+			}
+		}
+	}()
+}
+
+// remoteSession relays a table hosted by another instance to local WS clients.
+type remoteSession struct {
+	tableID int64
+	bridge  *bridge
+
+	mu     sync.Mutex
+	subs   map[int64]chan OutgoingMessage
+	pubsub *redis.PubSub
+}
+
+func newRemoteSession(tableID int64, b *bridge) *remoteSession {
+	return &remoteSession{
+		tableID: tableID,
+		bridge:  b,
+		subs:    make(map[int64]chan OutgoingMessage),
+	}
+}
+
+func (rs *remoteSession) Subscribe(userID int64) chan OutgoingMessage {
+	ch := make(chan OutgoingMessage, 8)
+
+	rs.mu.Lock()
+	rs.subs[userID] = ch
+	if rs.pubsub == nil {
+		rs.pubsub = rs.bridge.rdb.Subscribe(context.Background(), buildTableEventsChannel(rs.tableID))
+		go rs.relay(rs.pubsub)
+	}
+	rs.mu.Unlock()
+
+	if err := rs.bridge.publishAction(rs.tableID, actionEnvelope{UserID: userID, Action: bridgeActionSubscribe}); err != nil {
+		logger.Log.Warn("remote subscribe failed",
+			zap.Int64("tableID", rs.tableID),
+			zap.Int64("userID", userID),
+			zap.Error(err),
+		)
+	}
+	return ch
+}
+
+// SubscribeSpectator relays a read-only subscription to the hosting
+// instance. The spectator cap is enforced there: an over-cap spectator gets
+// a close frame back instead of the broadcast stream.
+func (rs *remoteSession) SubscribeSpectator(userID int64) chan OutgoingMessage {
+	ch := make(chan OutgoingMessage, 8)
+
+	rs.mu.Lock()
+	rs.subs[userID] = ch
+	if rs.pubsub == nil {
+		rs.pubsub = rs.bridge.rdb.Subscribe(context.Background(), buildTableEventsChannel(rs.tableID))
+		go rs.relay(rs.pubsub)
+	}
+	rs.mu.Unlock()
+
+	if err := rs.bridge.publishAction(rs.tableID, actionEnvelope{UserID: userID, Action: bridgeActionSpectate}); err != nil {
+		logger.Log.Warn("remote spectate failed",
+			zap.Int64("tableID", rs.tableID),
+			zap.Int64("userID", userID),
+			zap.Error(err),
+		)
+	}
+	return ch
+}
+
+func (rs *remoteSession) Unsubscribe(userID int64) {
+	rs.mu.Lock()
+	if ch, ok := rs.subs[userID]; ok {
+		delete(rs.subs, userID)
+		close(ch)
+	}
+	empty := len(rs.subs) == 0
+	pubsub := rs.pubsub
+	if empty {
+		rs.pubsub = nil
+	}
+	rs.mu.Unlock()
+
+	rs.bridge.publishAction(rs.tableID, actionEnvelope{UserID: userID, Action: bridgeActionUnsubscribe})
+	if empty && pubsub != nil {
+		pubsub.Close()
+	}
+}
+
+func (rs *remoteSession) HandleAction(userID int64, action string, data json.RawMessage) error {
+	return rs.bridge.publishAction(rs.tableID, actionEnvelope{UserID: userID, Action: action, Data: data})
+}
+
+// HandleActionID relays the retry token to the hosting instance, where the
+// outcome cache lives. Publish success only means the action was forwarded;
+// evaluation errors come back asynchronously over the events channel.
+func (rs *remoteSession) HandleActionID(userID int64, action string, data json.RawMessage, requestID string) ActionResult {
+	err := rs.bridge.publishAction(rs.tableID, actionEnvelope{UserID: userID, Action: action, Data: data, RequestID: requestID})
+	return ActionResult{Err: err}
+}
+
+func (rs *remoteSession) relay(pubsub *redis.PubSub) {
+	for msg := range pubsub.Channel() {
+		var env eventEnvelope
+		if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+			continue
+		}
+		rs.mu.Lock()
+		ch, ok := rs.subs[env.UserID]
+		rs.mu.Unlock()
+		if !ok {
+			continue
+		}
+		select {
+		case ch <- env.Msg:
+		default:
+			logger.Log.Warn("remote relay channel full",
+				zap.Int64("tableID", rs.tableID),
+				zap.Int64("userID", env.UserID),
+			)
+		}
+	}
+}
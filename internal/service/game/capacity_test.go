@@ -0,0 +1,81 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"dx-service/internal/config"
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setRuntimeCeiling(t *testing.T, limit int) {
+	t.Helper()
+	prev := config.GlobalConfig
+	config.GlobalConfig = &config.Config{Capacity: config.CapacityConfig{MaxRuntimes: limit}}
+	t.Cleanup(func() { config.GlobalConfig = prev })
+}
+
+func seedRuntimeTable(t *testing.T, db *gorm.DB, id int64) {
+	t.Helper()
+	scene := model.Scene{ID: id, Name: "scene", SeatCount: 2, BasePi: 10, MinUnitPi: 1}
+	if err := db.Create(&scene).Error; err != nil {
+		t.Fatalf("seed scene: %v", err)
+	}
+	table := model.Table{
+		ID:          id,
+		SceneID:     scene.ID,
+		SeatCount:   2,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("seed table: %v", err)
+	}
+}
+
+func TestGetRuntimeShedsAtCeiling(t *testing.T) {
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	setRuntimeCeiling(t, 1)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Scene{}, &model.Table{}, &model.Match{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	seedRuntimeTable(t, db, 1)
+	seedRuntimeTable(t, db, 2)
+
+	s := &Service{db: db}
+	ctx := context.Background()
+
+	rt, err := s.GetRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("first runtime should fit under the ceiling: %v", err)
+	}
+	t.Cleanup(rt.stop)
+	if !s.AtRuntimeCapacity() {
+		t.Fatal("expected the instance at capacity with limit 1")
+	}
+
+	if _, err := s.GetRuntime(ctx, 2); !errors.Is(err, appErr.ErrServerBusy) {
+		t.Fatalf("expected ErrServerBusy past the ceiling, got %v", err)
+	}
+	if s.RuntimeSheds() != 1 {
+		t.Fatalf("expected 1 shed recorded, got %d", s.RuntimeSheds())
+	}
+
+	// An already-hosted runtime is still served at capacity.
+	if _, err := s.GetRuntime(ctx, 1); err != nil {
+		t.Fatalf("existing runtime must stay reachable: %v", err)
+	}
+}
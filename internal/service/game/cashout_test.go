@@ -0,0 +1,145 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCashOutRuntime(t *testing.T) (*TableRuntime, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&model.Table{}, &model.Match{}, &model.MatchRoundLog{},
+		&model.Wallet{}, &model.BillingLog{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	for _, userID := range []int64{101, 102} {
+		wallet := model.Wallet{UserID: userID, BalanceTotal: 500, BalanceAvailable: 500}
+		if err := db.Create(&wallet).Error; err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	scene := model.Scene{
+		ID:        1,
+		Name:      "test",
+		SeatCount: 2,
+		BasePi:    10,
+		MinUnitPi: 1,
+		MinIn:     100,
+		MaxIn:     5000,
+	}
+	table := model.Table{
+		ID:          1,
+		SceneID:     scene.ID,
+		SeatCount:   2,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("failed to seed table: %v", err)
+	}
+
+	rt, err := newTableRuntime(context.Background(), db, table, scene, 1, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(rt.stop)
+	return rt, db
+}
+
+func TestCashOutReleasesEscrowAndRemovesSeat(t *testing.T) {
+	rt, db := setupCashOutRuntime(t)
+
+	if err := rt.HandleAction(101, "topup", json.RawMessage(`{"amount":200}`)); err != nil {
+		t.Fatalf("topup failed: %v", err)
+	}
+	var wallet model.Wallet
+	if err := db.Where("user_id = ?", 101).First(&wallet).Error; err != nil {
+		t.Fatalf("wallet lookup failed: %v", err)
+	}
+	if wallet.BalanceAvailable != 300 || wallet.BalanceFrozen != 200 {
+		t.Fatalf("expected 200 frozen after topup, got available %d frozen %d",
+			wallet.BalanceAvailable, wallet.BalanceFrozen)
+	}
+
+	if err := rt.HandleAction(101, "leave", nil); err != nil {
+		t.Fatalf("leave failed: %v", err)
+	}
+
+	if err := db.Where("user_id = ?", 101).First(&wallet).Error; err != nil {
+		t.Fatalf("wallet lookup failed: %v", err)
+	}
+	if wallet.BalanceAvailable != 500 || wallet.BalanceFrozen != 0 {
+		t.Fatalf("expected escrow released on cash-out, got available %d frozen %d",
+			wallet.BalanceAvailable, wallet.BalanceFrozen)
+	}
+
+	if _, ok := rt.seatByUser[101]; ok {
+		t.Fatal("expected seat removed after cash-out")
+	}
+	var table model.Table
+	if err := db.First(&table, rt.tableID).Error; err != nil {
+		t.Fatalf("table lookup failed: %v", err)
+	}
+	var players map[string]map[string]interface{}
+	if err := json.Unmarshal(table.PlayersJSON, &players); err != nil {
+		t.Fatalf("players json invalid: %v", err)
+	}
+	if _, ok := players["1"]; ok {
+		t.Fatal("expected seat removed from PlayersJSON")
+	}
+
+	var count int64
+	db.Model(&model.BillingLog{}).Where("user_id = ? AND type = ?", 101, "cashout").Count(&count)
+	if count != 1 {
+		t.Fatalf("expected one cashout billing log, got %d", count)
+	}
+}
+
+func TestCashOutRejectedMidHand(t *testing.T) {
+	rt, _ := setupCashOutRuntime(t)
+	startTimeBankHand(t, rt)
+
+	if err := rt.HandleAction(101, "leave", nil); err != appErr.ErrTableMidHand {
+		t.Fatalf("expected ErrTableMidHand, got %v", err)
+	}
+}
+
+func TestCashOutDissolvesUnderSeatedTable(t *testing.T) {
+	rt, _ := setupCashOutRuntime(t)
+
+	// The first leave drops the table to one player; the runtime dissolves
+	// it and cashes the remaining seat out too.
+	if err := rt.HandleAction(101, "leave", nil); err != nil {
+		t.Fatalf("leave failed: %v", err)
+	}
+
+	if len(rt.seats) != 0 {
+		t.Fatalf("expected empty table after dissolve, got %d seats", len(rt.seats))
+	}
+	if rt.totalCashOuts != rt.totalBuyIns+rt.totalTopUps {
+		t.Fatalf("chip invariant broken: cashOuts %d buyIns %d topUps %d",
+			rt.totalCashOuts, rt.totalBuyIns, rt.totalTopUps)
+	}
+	select {
+	case <-rt.quitCh:
+	default:
+		t.Fatal("expected runtime stopped after dissolve")
+	}
+	if rt.stopReason != CloseReasonDissolved {
+		t.Fatalf("expected %s close, got %q", CloseReasonDissolved, rt.stopReason)
+	}
+}
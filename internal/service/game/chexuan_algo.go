@@ -61,7 +61,7 @@ func BestSplit(cards []string) (head, tail []string, score int64, isValid bool)
 
 			headScore := evaluatePairScore(headCodes)
 			tailScore := evaluatePairScore(tailCodes)
-			
+
 			// Compare Head vs Tail using specific logic (Score > Score or MaxRank > MaxRank)
 			// Simple int64 comparison works if scores are tiered correctly.
 			isSplitValid := headScore >= tailScore
@@ -45,7 +45,7 @@ var chexuanCardMap = map[string]ChexuanCard{
 	"B7": {ID: 13, Code: "B7", Rank: 90, Point: 7, Name: "black_7", Suit: "black"},
 	"B8": {ID: 14, Code: "B8", Rank: 90, Point: 8, Name: "black_8", Suit: "black"},
 	"B9": {ID: 15, Code: "B9", Rank: 90, Point: 9, Name: "black_9", Suit: "black"},
-	"R3": {ID: 16, Code: "R3", Rank: 90, Point: 3, Name: "red_3", Suit: "red"},     // Single
+	"R3": {ID: 16, Code: "R3", Rank: 90, Point: 3, Name: "red_3", Suit: "red"},        // Single
 	"BK": {ID: 17, Code: "BK", Rank: 90, Point: 6, Name: "big_king", Suit: "special"}, // Single
 }
 
@@ -80,11 +80,15 @@ var chexuanDeckTemplate = []string{
 
 // NewChexuanDeck returns a shuffled deck of Chexuan card codes.
 func NewChexuanDeck() []string {
+	return newShuffledChexuanDeck(nil)
+}
+
+// newShuffledChexuanDeck shuffles with the provided source, falling back to
+// crypto/rand when rng is nil.
+func newShuffledChexuanDeck(rng *mrand.Rand) []string {
 	deck := make([]string, len(chexuanDeckTemplate))
 	copy(deck, chexuanDeckTemplate)
-	mrand.Shuffle(len(deck), func(i, j int) {
-		deck[i], deck[j] = deck[j], deck[i]
-	})
+	shuffleStrings(deck, rng)
 	return deck
 }
 
@@ -141,7 +145,7 @@ func ToPokerCode(cxCode string) string {
 		return "3h" // Red 3
 	case "BK":
 		return "As" // Big King -> Spade Ace (for visual prominence)
-	
+
 	default:
 		return cxCode
 	}
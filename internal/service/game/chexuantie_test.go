@@ -0,0 +1,90 @@
+package game
+
+import (
+	"sort"
+	"testing"
+)
+
+// tiedChexuanPlayer builds a participant with a fixed score line so two of
+// them tie exactly on HeadScore, HeadMax and TailScore.
+func tiedChexuanPlayer(seatIdx int) chexuanPlayer {
+	return chexuanPlayer{
+		SeatIdx:   seatIdx,
+		UserID:    int64(100 + seatIdx),
+		Bet:       100,
+		HeadScore: 800,
+		HeadMax:   100,
+		TailScore: 490,
+	}
+}
+
+func TestChexuanExactTieBreaksBySeatAfterBanker(t *testing.T) {
+	cases := []struct {
+		name       string
+		bankerSeat int
+		seats      []int
+		wantFirst  int
+	}{
+		{name: "banker before both", bankerSeat: 1, seats: []int{2, 3}, wantFirst: 2},
+		{name: "banker between them", bankerSeat: 2, seats: []int{2, 3}, wantFirst: 3},
+		{name: "wraps past last seat", bankerSeat: 3, seats: []int{2, 3}, wantFirst: 2},
+		{name: "banker itself ranks last", bankerSeat: 1, seats: []int{1, 2}, wantFirst: 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := &TableRuntime{seatCount: 3, bankerSeat: tc.bankerSeat}
+			a := tiedChexuanPlayer(tc.seats[0])
+			b := tiedChexuanPlayer(tc.seats[1])
+
+			// The winner must not depend on the input slice order.
+			for _, input := range [][]chexuanPlayer{{a, b}, {b, a}} {
+				participants := append([]chexuanPlayer(nil), input...)
+				sort.Slice(participants, func(i, j int) bool {
+					return rt.chexuanRankLess(participants[i], participants[j])
+				})
+				if participants[0].SeatIdx != tc.wantFirst {
+					t.Fatalf("expected seat %d first, got seat %d", tc.wantFirst, participants[0].SeatIdx)
+				}
+			}
+		})
+	}
+}
+
+func TestChexuanSettleWithExactHeadTieBalancesLedger(t *testing.T) {
+	// Seats 1 and 2 hold identical hands (exact head and tail tie); seat 3
+	// folded after betting, so both tied players win from it equally.
+	rt := &TableRuntime{
+		phase:      PhaseSettling,
+		basePi:     10,
+		bankerSeat: 1,
+		seatCount:  3,
+		seatByUser: map[int64]int{101: 1, 102: 2, 103: 3},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Bet: 100, Status: "playing", cards: []string{"R6", "BJ", "B5", "B9"}},
+			{SeatIndex: 2, UserID: 102, Chips: 1000, Bet: 100, Status: "playing", cards: []string{"R6", "BJ", "B5", "B9"}},
+			{SeatIndex: 3, UserID: 103, Chips: 1000, Bet: 100, Status: "folded"},
+		},
+	}
+
+	rt.settleChexuanLocked()
+
+	nets := make(map[int64]int64, len(rt.SettlementResults))
+	sum := int64(0)
+	for _, res := range rt.SettlementResults {
+		nets[res.UserID] = res.NetPoints
+		sum += res.NetPoints
+	}
+	if sum != 0 {
+		t.Fatalf("ledger not balanced, sum = %d", sum)
+	}
+	if nets[101] != 100 || nets[102] != 100 {
+		t.Fatalf("tied players should win equally, got %d and %d", nets[101], nets[102])
+	}
+	if nets[103] != -200 {
+		t.Fatalf("folded player should pay both bets, got %d", nets[103])
+	}
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected phase ended, got %s", rt.phase)
+	}
+}
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func closeTestRuntime() *TableRuntime {
+	return &TableRuntime{
+		phase:       PhaseWaiting,
+		seatByUser:  map[int64]int{101: 1},
+		seats:       []SeatState{{SeatIndex: 1, UserID: 101, Chips: 1000}},
+		subscribers: map[int64]chan OutgoingMessage{},
+		remoteSubs:  map[int64]int{},
+		observers:   map[int64]*observerSub{},
+		cmdCh:       make(chan loopCommand, 8),
+		quitCh:      make(chan struct{}),
+	}
+}
+
+func expectCloseReason(t *testing.T, ch <-chan OutgoingMessage, want string) {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				t.Fatal("channel closed before close message arrived")
+			}
+			if msg.Type != "close" {
+				continue
+			}
+			info, ok := msg.Data.(CloseInfo)
+			if !ok {
+				t.Fatalf("unexpected close payload: %#v", msg.Data)
+			}
+			if info.Reason != want {
+				t.Fatalf("expected reason %q, got %q", want, info.Reason)
+			}
+			return
+		case <-deadline:
+			t.Fatalf("no close message with reason %q", want)
+		}
+	}
+}
+
+func TestStopSendsCloseReason(t *testing.T) {
+	rt := closeTestRuntime()
+	rt.startLoop()
+
+	ch := rt.Subscribe(101)
+	rt.stopWithReason(CloseReasonDissolved)
+	expectCloseReason(t, ch, CloseReasonDissolved)
+
+	// After the reason frame the channel itself is closed.
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("subscriber channel never closed")
+		}
+	}
+}
+
+func TestResubscribeClosesOldConnectionAsTakeover(t *testing.T) {
+	rt := closeTestRuntime()
+
+	first := make(chan OutgoingMessage, 8)
+	rt.handleCommand(loopCommand{kind: "subscribe", userID: 101, subCh: first})
+	second := make(chan OutgoingMessage, 8)
+	rt.handleCommand(loopCommand{kind: "subscribe", userID: 101, subCh: second})
+
+	expectCloseReason(t, first, CloseReasonTakeover)
+	if rt.subscribers[101] != second {
+		t.Fatal("expected the newer channel to own the subscription")
+	}
+}
@@ -0,0 +1,129 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// coalesceRuntime is a bare runtime with one subscriber and an injectable
+// clock so flush-window math is deterministic.
+func coalesceRuntime(now *time.Time) (*TableRuntime, chan OutgoingMessage) {
+	ch := make(chan OutgoingMessage, 256)
+	rt := &TableRuntime{
+		phase: PhasePlaying,
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000},
+		},
+		seatByUser:      map[int64]int{101: 1},
+		subscribers:     map[int64]chan OutgoingMessage{101: ch},
+		spectators:      map[int64]bool{},
+		stateFlushEvery: 50 * time.Millisecond,
+		now:             func() time.Time { return *now },
+	}
+	return rt, ch
+}
+
+func drainStates(ch chan OutgoingMessage) []OutgoingMessage {
+	var msgs []OutgoingMessage
+	for {
+		select {
+		case msg := <-ch:
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestStateBroadcastCoalescesBursts(t *testing.T) {
+	now := time.Now()
+	rt, ch := coalesceRuntime(&now)
+
+	// A burst within one flush window: the first broadcast goes out, the
+	// rest only mark the state dirty.
+	for i := 0; i < 5; i++ {
+		rt.seats[0].Chips = int64(1000 + i)
+		rt.broadcastStateLocked()
+		now = now.Add(time.Millisecond)
+	}
+	msgs := drainStates(ch)
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 coalesced state, got %d", len(msgs))
+	}
+	if !rt.stateDirty {
+		t.Fatal("burst did not leave the state dirty")
+	}
+
+	// The deferred flush carries the latest data, not the state at the time
+	// of the first suppressed broadcast.
+	rt.flushPendingStateLocked()
+	msgs = drainStates(ch)
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 deferred state, got %d", len(msgs))
+	}
+	state, ok := msgs[0].Data.(TableState)
+	if !ok {
+		t.Fatalf("unexpected state payload %T", msgs[0].Data)
+	}
+	if state.Seats[0].Chips != 1004 {
+		t.Fatalf("deferred state is stale: chips %d", state.Seats[0].Chips)
+	}
+
+	// Past the window a broadcast flushes immediately again.
+	now = now.Add(100 * time.Millisecond)
+	rt.broadcastStateLocked()
+	if msgs := drainStates(ch); len(msgs) != 1 {
+		t.Fatalf("expected immediate flush after quiet window, got %d", len(msgs))
+	}
+}
+
+func TestEventForcesPendingStateOut(t *testing.T) {
+	now := time.Now()
+	rt, ch := coalesceRuntime(&now)
+
+	rt.broadcastStateLocked()
+	now = now.Add(time.Millisecond)
+	rt.broadcastStateLocked() // deferred
+	drainStates(ch)
+
+	rt.broadcastMessageLocked(OutgoingMessage{Type: "chat", Data: ginH{"content": "hi"}})
+
+	msgs := drainStates(ch)
+	if len(msgs) != 2 {
+		t.Fatalf("expected state+chat, got %d messages", len(msgs))
+	}
+	if msgs[0].Type != "state" || msgs[1].Type != "chat" {
+		t.Fatalf("event overtook the pending state: %s, %s", msgs[0].Type, msgs[1].Type)
+	}
+	if msgs[0].Seq >= msgs[1].Seq {
+		t.Fatalf("seq order broken: state %d, chat %d", msgs[0].Seq, msgs[1].Seq)
+	}
+	if rt.stateDirty {
+		t.Fatal("pending state not cleared by the event flush")
+	}
+}
+
+// benchmarkBurst scripts an auto-run-like burst of five state broadcasts a
+// millisecond apart and reports how many messages actually reach the wire.
+func benchmarkBurst(b *testing.B, flushEvery time.Duration) {
+	now := time.Now()
+	rt, ch := coalesceRuntime(&now)
+	rt.stateFlushEvery = flushEvery
+
+	total := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 5; j++ {
+			rt.broadcastStateLocked()
+			now = now.Add(time.Millisecond)
+		}
+		now = now.Add(100 * time.Millisecond)
+		rt.flushPendingStateLocked()
+		total += len(drainStates(ch))
+	}
+	b.ReportMetric(float64(total)/float64(b.N), "msgs/burst")
+}
+
+func BenchmarkStateBurstUncoalesced(b *testing.B) { benchmarkBurst(b, 0) }
+
+func BenchmarkStateBurstCoalesced(b *testing.B) { benchmarkBurst(b, 50*time.Millisecond) }
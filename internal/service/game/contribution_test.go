@@ -0,0 +1,107 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// actAsTurnOwner performs the action on behalf of whichever seat currently
+// owns the turn and returns that user's ID.
+func actAsTurnOwner(t *testing.T, rt *TableRuntime, action string, data json.RawMessage) int64 {
+	t.Helper()
+
+	for _, seat := range rt.seats {
+		if seat.SeatIndex == rt.turnSeat {
+			if err := rt.HandleAction(seat.UserID, action, data); err != nil {
+				t.Fatalf("%s by user %d failed: %v", action, seat.UserID, err)
+			}
+			return seat.UserID
+		}
+	}
+	t.Fatalf("no seat owns the turn %d", rt.turnSeat)
+	return 0
+}
+
+// TestMultiRoundSettlementNetsToZero plays ante + a round-1 raise/call + a
+// round-2 raise/call through to showdown and checks the ledger against each
+// seat's cumulative contribution: the PlayerResult values must sum to zero
+// and match every player's actual chip delta.
+func TestMultiRoundSettlementNetsToZero(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+	startTimeBankHand(t, rt)
+
+	// Round 1: the first actor must open at exactly 2*basePi.
+	actAsTurnOwner(t, rt, "raise", json.RawMessage(`{"amount":20}`))
+	actAsTurnOwner(t, rt, "call", nil)
+	if rt.round != 2 {
+		t.Fatalf("expected round 2 after the round-1 raise/call, got %d", rt.round)
+	}
+
+	// Round 2: raise again so losses span multiple rounds.
+	actAsTurnOwner(t, rt, "raise", json.RawMessage(`{"amount":40}`))
+	actAsTurnOwner(t, rt, "call", nil)
+
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected hand settled, phase %s round %d", rt.phase, rt.round)
+	}
+
+	// Each seat paid 10 ante + 10 to 20 in round 1 + 20 to 40 in round 2.
+	for _, seat := range rt.seats {
+		if seat.TotalContribution != 40 {
+			t.Fatalf("seat %d contribution = %d, want 40", seat.SeatIndex, seat.TotalContribution)
+		}
+	}
+
+	results := rt.SettlementResults
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	var sum int64
+	for _, res := range results {
+		sum += res.NetPoints
+		if res.NetPoints != 40 && res.NetPoints != -40 {
+			t.Fatalf("user %d net %d, want ±40 (full contribution)", res.UserID, res.NetPoints)
+		}
+
+		seatIdx, ok := rt.seatByUser[res.UserID]
+		if !ok {
+			t.Fatalf("result for unseated user %d", res.UserID)
+		}
+		seat := rt.findSeatLocked(seatIdx)
+		if delta := seat.Chips - 1000; delta != res.NetPoints {
+			t.Fatalf("user %d chip delta %d does not match net %d", res.UserID, delta, res.NetPoints)
+		}
+	}
+	if sum != 0 {
+		t.Fatalf("net points sum to %d, want 0: %+v", sum, results)
+	}
+}
+
+// TestFoldWinLossesUseContribution folds a seat after it has already paid the
+// ante and checks the fold-win ledger charges its contribution.
+func TestFoldWinLossesUseContribution(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+	startTimeBankHand(t, rt)
+
+	folder := actAsTurnOwner(t, rt, "fold", nil)
+
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected fold to end the hand, phase %s", rt.phase)
+	}
+	var sum int64
+	for _, res := range rt.SettlementResults {
+		sum += res.NetPoints
+		if res.UserID == folder && res.NetPoints != -10 {
+			t.Fatalf("folder net %d, want -10 (the ante)", res.NetPoints)
+		}
+
+		seatIdx := rt.seatByUser[res.UserID]
+		seat := rt.findSeatLocked(seatIdx)
+		if delta := seat.Chips - 1000; delta != res.NetPoints {
+			t.Fatalf("user %d chip delta %d does not match net %d", res.UserID, delta, res.NetPoints)
+		}
+	}
+	if sum != 0 {
+		t.Fatalf("net points sum to %d, want 0: %+v", sum, rt.SettlementResults)
+	}
+}
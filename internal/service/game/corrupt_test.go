@@ -0,0 +1,58 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+)
+
+func buildRuntimeFromPlayers(t *testing.T, playersJSON string) error {
+	t.Helper()
+	scene := model.Scene{ID: 1, Name: "test", SeatCount: 2, BasePi: 10, MinUnitPi: 1}
+	table := model.Table{
+		ID:          1,
+		SceneID:     scene.ID,
+		SeatCount:   2,
+		PlayersJSON: []byte(playersJSON),
+	}
+	_, err := buildTableRuntime(nil, table, scene, 0, nil, nil)
+	return err
+}
+
+func TestBuildRuntimeRejectsCorruptPlayers(t *testing.T) {
+	cases := []struct {
+		name    string
+		players string
+	}{
+		{"duplicate seat via padded key", `{"1":{"userId":101,"chips":100},"01":{"userId":102,"chips":100}}`},
+		{"seat index out of range", `{"1":{"userId":101,"chips":100},"5":{"userId":102,"chips":100}}`},
+		{"non-numeric seat key", `{"1":{"userId":101,"chips":100},"x":{"userId":102,"chips":100}}`},
+		{"duplicate user", `{"1":{"userId":101,"chips":100},"2":{"userId":101,"chips":100}}`},
+		{"seat overflow", `{"1":{"userId":101,"chips":100},"2":{"userId":102,"chips":100},"3":{"userId":103,"chips":100}}`},
+		{"zero user id", `{"1":{"userId":101,"chips":100},"2":{"userId":0,"chips":100}}`},
+		{"not an object", `[1,2,3]`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := buildRuntimeFromPlayers(t, tc.players)
+			if !errors.Is(err, appErr.ErrCorruptTable) {
+				t.Fatalf("expected ErrCorruptTable, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildRuntimeAcceptsValidAndEmptyPlayers(t *testing.T) {
+	if err := buildRuntimeFromPlayers(t, `{"1":{"userId":101,"chips":100},"2":{"userId":102,"chips":100}}`); err != nil {
+		t.Fatalf("expected full table accepted, got %v", err)
+	}
+	if err := buildRuntimeFromPlayers(t, ""); err != nil {
+		t.Fatalf("expected unseated table accepted, got %v", err)
+	}
+	// A partial lineup is no longer corrupt: late joiners fill the gaps.
+	if err := buildRuntimeFromPlayers(t, `{"1":{"userId":101,"chips":100}}`); err != nil {
+		t.Fatalf("expected partially filled table accepted, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"dx-service/internal/model"
+)
+
+// CurrentTableResult answers a reopened client's "am I seated anywhere?"
+// probe with enough to reconnect the table WS directly.
+type CurrentTableResult struct {
+	TableID int64  `json:"tableId"`
+	MatchID int64  `json:"matchId,omitempty"`
+	Phase   string `json:"phase"`
+	// Live reports whether a runtime loop is hosting the table right now;
+	// false means the WS reconnect will rebuild it from the persisted row.
+	Live bool `json:"live"`
+}
+
+// indexTableUsers replaces the discovery index entries for one table with its
+// current seated users. Runtimes call it on every lineup change.
+func (s *Service) indexTableUsers(tableID int64, userIDs []int64) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	if s.userTables == nil {
+		s.userTables = make(map[int64]int64)
+		s.tableUsers = make(map[int64]map[int64]bool)
+	}
+	current := make(map[int64]bool, len(userIDs))
+	for _, id := range userIDs {
+		current[id] = true
+		s.userTables[id] = tableID
+	}
+	for id := range s.tableUsers[tableID] {
+		if !current[id] && s.userTables[id] == tableID {
+			delete(s.userTables, id)
+		}
+	}
+	s.tableUsers[tableID] = current
+}
+
+// dropTableIndex forgets a closed table's discovery entries.
+func (s *Service) dropTableIndex(tableID int64) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	for id := range s.tableUsers[tableID] {
+		if s.userTables[id] == tableID {
+			delete(s.userTables, id)
+		}
+	}
+	delete(s.tableUsers, tableID)
+}
+
+// liveTableFor resolves the discovery index for one user.
+func (s *Service) liveTableFor(userID int64) (int64, bool) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	tableID, ok := s.userTables[userID]
+	return tableID, ok
+}
+
+// CurrentTable locates the table a user is still seated at, for clients that
+// were killed and reopened. Sources are checked in order: live runtimes via
+// the discovery index, unended table rows whose player snapshot contains the
+// user, and finally the matchmaker's pending-match notification. A nil result
+// means nothing is active.
+func (s *Service) CurrentTable(ctx context.Context, userID int64) (*CurrentTableResult, error) {
+	if tableID, ok := s.liveTableFor(userID); ok {
+		if v, ok := s.runtimes.Load(tableID); ok {
+			if phase, matchID, ok := v.(*TableRuntime).CurrentInfo(); ok {
+				return &CurrentTableResult{
+					TableID: tableID,
+					MatchID: matchID,
+					Phase:   string(phase),
+					Live:    true,
+				}, nil
+			}
+		}
+	}
+
+	// LIKE only narrows the scan; the parse below confirms exact membership
+	// so user 12 never matches a row holding only user 123.
+	var tables []model.Table
+	if err := s.db.WithContext(ctx).
+		Where("status <> ?", "ended").
+		Where("players_json LIKE ?", fmt.Sprintf(`%%"userId":%d%%`, userID)).
+		Order("id DESC").
+		Find(&tables).Error; err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		_, seatByUser, err := parsePlayersJSON(json.RawMessage(table.PlayersJSON))
+		if err != nil {
+			continue
+		}
+		if _, seated := seatByUser[userID]; !seated {
+			continue
+		}
+		matchID := int64(0)
+		if match, err := s.loadActiveMatch(ctx, table.ID); err == nil && match != nil {
+			matchID = match.ID
+		}
+		_, live := s.runtimes.Load(table.ID)
+		return &CurrentTableResult{
+			TableID: table.ID,
+			MatchID: matchID,
+			Phase:   table.Status,
+			Live:    live,
+		}, nil
+	}
+
+	if s.matchmaker != nil {
+		if tableID, matchID, ok := s.matchmaker.PendingMatch(ctx, userID); ok {
+			return &CurrentTableResult{
+				TableID: tableID,
+				MatchID: matchID,
+				Phase:   string(PhaseWaiting),
+			}, nil
+		}
+	}
+	return nil, nil
+}
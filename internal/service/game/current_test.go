@@ -0,0 +1,183 @@
+package game
+
+import (
+	"context"
+	"testing"
+
+	"dx-service/internal/model"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// pendingStub is a Matchmaker whose only signal is a pending-match key.
+type pendingStub struct {
+	tableID int64
+	matchID int64
+}
+
+func (p *pendingStub) ClearMatched(context.Context, int64) {}
+
+func (p *pendingStub) Requeue(context.Context, int64, int64, int64) error { return nil }
+
+func (p *pendingStub) PendingMatch(context.Context, int64) (int64, int64, bool) {
+	if p.tableID == 0 {
+		return 0, 0, false
+	}
+	return p.tableID, p.matchID, true
+}
+
+func newCurrentTableService(t *testing.T) (*gorm.DB, *Service) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}, &model.Match{}, &model.Scene{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db, &Service{db: db}
+}
+
+func TestCurrentTableFromLiveRuntime(t *testing.T) {
+	db, svc := newCurrentTableService(t)
+	ctx := context.Background()
+
+	if err := db.Create(&model.Scene{ID: 1, Name: "test", SeatCount: 2, BasePi: 10, MinUnitPi: 1}).Error; err != nil {
+		t.Fatalf("seed scene: %v", err)
+	}
+	table := model.Table{
+		ID:          1,
+		SceneID:     1,
+		Status:      "waiting",
+		SeatCount:   2,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("seed table: %v", err)
+	}
+
+	rt, err := svc.GetRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("runtime creation failed: %v", err)
+	}
+	t.Cleanup(rt.stop)
+
+	current, err := svc.CurrentTable(ctx, 101)
+	if err != nil {
+		t.Fatalf("discovery failed: %v", err)
+	}
+	if current == nil || !current.Live {
+		t.Fatalf("expected a live table, got %+v", current)
+	}
+	if current.TableID != 1 || current.Phase != string(PhaseWaiting) {
+		t.Fatalf("unexpected result: %+v", current)
+	}
+}
+
+func TestCurrentTableFromPersistedRow(t *testing.T) {
+	db, svc := newCurrentTableService(t)
+	ctx := context.Background()
+
+	table := model.Table{
+		ID:          3,
+		SceneID:     1,
+		Status:      "playing",
+		SeatCount:   2,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":1013,"chips":1000}}`),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("seed table: %v", err)
+	}
+	match := model.Match{TableID: 3, SceneID: 1}
+	if err := db.Create(&match).Error; err != nil {
+		t.Fatalf("seed match: %v", err)
+	}
+	// An ended table must never be reported, even with the user in it.
+	ended := model.Table{
+		ID:          4,
+		SceneID:     1,
+		Status:      "ended",
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":0}}`),
+	}
+	if err := db.Create(&ended).Error; err != nil {
+		t.Fatalf("seed ended table: %v", err)
+	}
+
+	current, err := svc.CurrentTable(ctx, 101)
+	if err != nil {
+		t.Fatalf("discovery failed: %v", err)
+	}
+	if current == nil || current.Live {
+		t.Fatalf("expected a non-live table, got %+v", current)
+	}
+	if current.TableID != 3 || current.MatchID != match.ID || current.Phase != "playing" {
+		t.Fatalf("unexpected result: %+v", current)
+	}
+
+	// User 101 must not leak onto user 10's probe via the LIKE prefilter.
+	if current, err := svc.CurrentTable(ctx, 10); err != nil || current != nil {
+		t.Fatalf("expected nothing for user 10, got %+v (%v)", current, err)
+	}
+}
+
+func TestCurrentTableFromPendingMatch(t *testing.T) {
+	_, svc := newCurrentTableService(t)
+	svc.SetMatchmaker(&pendingStub{tableID: 7, matchID: 9})
+
+	current, err := svc.CurrentTable(context.Background(), 101)
+	if err != nil {
+		t.Fatalf("discovery failed: %v", err)
+	}
+	if current == nil || current.Live {
+		t.Fatalf("expected pending match result, got %+v", current)
+	}
+	if current.TableID != 7 || current.MatchID != 9 || current.Phase != string(PhaseWaiting) {
+		t.Fatalf("unexpected result: %+v", current)
+	}
+}
+
+func TestCurrentTableNothingActive(t *testing.T) {
+	_, svc := newCurrentTableService(t)
+	svc.SetMatchmaker(&pendingStub{})
+
+	current, err := svc.CurrentTable(context.Background(), 101)
+	if err != nil {
+		t.Fatalf("discovery failed: %v", err)
+	}
+	if current != nil {
+		t.Fatalf("expected no active table, got %+v", current)
+	}
+}
+
+func TestDiscoveryIndexTracksLineupChanges(t *testing.T) {
+	svc := &Service{}
+
+	svc.indexTableUsers(1, []int64{101, 102})
+	if tableID, ok := svc.liveTableFor(101); !ok || tableID != 1 {
+		t.Fatalf("expected 101 indexed at table 1, got %d (%v)", tableID, ok)
+	}
+
+	// 101 leaves; only 102 remains indexed.
+	svc.indexTableUsers(1, []int64{102})
+	if _, ok := svc.liveTableFor(101); ok {
+		t.Fatal("101 still indexed after leaving")
+	}
+	if tableID, ok := svc.liveTableFor(102); !ok || tableID != 1 {
+		t.Fatalf("expected 102 still indexed, got %d (%v)", tableID, ok)
+	}
+
+	// A user reseated elsewhere keeps the newest table even if the old
+	// table's stale lineup is dropped afterwards.
+	svc.indexTableUsers(2, []int64{102})
+	svc.indexTableUsers(1, nil)
+	if tableID, ok := svc.liveTableFor(102); !ok || tableID != 2 {
+		t.Fatalf("expected 102 at table 2, got %d (%v)", tableID, ok)
+	}
+
+	svc.dropTableIndex(2)
+	if _, ok := svc.liveTableFor(102); ok {
+		t.Fatal("102 still indexed after table close")
+	}
+}
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAdminDissolveAbortsLiveHand(t *testing.T) {
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	rt := setupLifetimeRuntime(t, 0, 0)
+	ch := rt.Subscribe(101)
+	collected := make(chan OutgoingMessage, 256)
+	go func() {
+		for msg := range ch {
+			collected <- msg
+		}
+	}()
+
+	if err := rt.HandleAction(101, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if err := rt.HandleAction(102, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+
+	if err := rt.Dissolve(); err != nil {
+		t.Fatalf("dissolve failed: %v", err)
+	}
+
+	sawDissolved := false
+	deadline := time.After(2 * time.Second)
+	for !sawDissolved {
+		select {
+		case msg := <-collected:
+			switch msg.Type {
+			case "table_dissolved":
+				sawDissolved = true
+			case "close":
+				t.Fatal("close arrived before table_dissolved")
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for table_dissolved")
+		}
+	}
+
+	select {
+	case <-rt.quitCh:
+	case <-time.After(2 * time.Second):
+		t.Fatal("runtime kept running after dissolve")
+	}
+
+	if rt.expiredReason != "dissolved" {
+		t.Fatalf("expected dissolved close reason, got %q", rt.expiredReason)
+	}
+	if len(rt.SettlementResults) != 2 {
+		t.Fatalf("expected refund results for both seats, got %d", len(rt.SettlementResults))
+	}
+	for _, res := range rt.SettlementResults {
+		if res.NetPoints != 0 {
+			t.Fatalf("refund result not zero-sum: %+v", res)
+		}
+		if reason, _ := res.Meta["reason"].(string); reason != "admin_dissolve" {
+			t.Fatalf("refund result missing admin_dissolve reason: %+v", res)
+		}
+	}
+
+	// A second dissolve against the stopped runtime must not block.
+	if err := rt.Dissolve(); err != nil {
+		t.Fatalf("dissolve on stopped runtime failed: %v", err)
+	}
+}
+
+func TestAdminDissolveWithoutRuntimeClosesRows(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}, &model.Match{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.Create(&model.Table{ID: 9, SceneID: 1, Status: "playing"}).Error; err != nil {
+		t.Fatalf("seed table: %v", err)
+	}
+	match := model.Match{TableID: 9, SceneID: 1}
+	if err := db.Create(&match).Error; err != nil {
+		t.Fatalf("seed match: %v", err)
+	}
+
+	svc := &Service{db: db}
+	ctx := context.Background()
+	if err := svc.AdminDissolveTable(ctx, 9); err != nil {
+		t.Fatalf("dissolve failed: %v", err)
+	}
+
+	var table model.Table
+	if err := db.First(&table, 9).Error; err != nil {
+		t.Fatalf("table lookup failed: %v", err)
+	}
+	if table.Status != "ended" {
+		t.Fatalf("expected table ended, got %q", table.Status)
+	}
+	var closed model.Match
+	if err := db.First(&closed, match.ID).Error; err != nil {
+		t.Fatalf("match lookup failed: %v", err)
+	}
+	if closed.EndedAt == nil {
+		t.Fatal("open match not closed")
+	}
+
+	if err := svc.AdminDissolveTable(ctx, 404); err == nil {
+		t.Fatal("expected table not found for unknown id")
+	}
+}
@@ -0,0 +1,174 @@
+package game
+
+import "encoding/json"
+
+// Granular events cut the broadcast cost for clients that can apply deltas.
+// A client opts in by sending a "hello" action with the "events" capability
+// right after subscribing; from then on high-frequency transitions — a single
+// turn action, a card deal, a betting-round change — reach it as the typed
+// messages below instead of a full TableState. Full state still goes out on
+// subscribe, rejoin and resync, and for transitions nothing below covers
+// (settlement, admin changes), so an event client can always re-anchor.
+const (
+	// msgTypeAction reports one completed turn action: seat, action, the
+	// seat's bet level, the new pot and whose turn it is now.
+	msgTypeAction = "action"
+	// msgTypeDeal reports a card deal: the public per-seat counts plus,
+	// only on a seated recipient's own copy, the cards they were dealt.
+	// Unlike the other events it goes to every subscriber, capability or
+	// not, so clients can animate the deal instead of diffing state.
+	msgTypeDeal = "deal"
+	// msgTypePhase reports a phase or betting-round transition.
+	msgTypePhase = "phase"
+	// capEvents is the hello capability that enables the above.
+	capEvents = "events"
+)
+
+// handleHelloLocked records the capabilities a client announced after
+// connecting. Unknown capability names are ignored, so newer clients keep
+// working against older servers and vice versa.
+func (rt *TableRuntime) handleHelloLocked(userID int64, data json.RawMessage) error {
+	var payload struct {
+		Capabilities []string `json:"capabilities"`
+	}
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &payload)
+	}
+	for _, name := range payload.Capabilities {
+		if name == capEvents {
+			if rt.eventSubs == nil {
+				rt.eventSubs = make(map[int64]bool)
+			}
+			rt.eventSubs[userID] = true
+		}
+	}
+	return nil
+}
+
+// emitEventLocked fans one granular event out to every subscriber that
+// negotiated events; everyone else is reached by the legacy state broadcast
+// that follows. build, when set, customizes the message per user so private
+// fields never travel on another seat's copy.
+func (rt *TableRuntime) emitEventLocked(msg OutgoingMessage, build func(userID int64) OutgoingMessage) {
+	for uid := range rt.eventSubs {
+		_, local := rt.subscribers[uid]
+		_, remote := rt.remoteSubs[uid]
+		if !local && !remote {
+			continue
+		}
+		out := msg
+		if build != nil {
+			out = build(uid)
+		}
+		// pushMessageLocked stamps the per-user seq and relays remote
+		// subscribers through the bridge via deliverNowLocked.
+		rt.pushMessageLocked(uid, out)
+	}
+}
+
+func (rt *TableRuntime) emitActionEventLocked(seatIdx int, action string, amount int64) {
+	if len(rt.eventSubs) == 0 {
+		return
+	}
+	rt.emitEventLocked(OutgoingMessage{
+		Type: msgTypeAction,
+		Data: ginH{
+			"seat":        seatIdx,
+			"action":      action,
+			"amount":      amount,
+			"newPot":      rt.pot,
+			"newTurnSeat": rt.turnSeat,
+			"countdown":   rt.countdownSecondsLocked(),
+		},
+	}, nil)
+}
+
+func (rt *TableRuntime) emitPhaseEventLocked() {
+	if len(rt.eventSubs) == 0 {
+		return
+	}
+	rt.emitEventLocked(OutgoingMessage{
+		Type: msgTypePhase,
+		Data: ginH{
+			"phase":     rt.phase,
+			"round":     rt.round,
+			"pot":       rt.pot,
+			"turnSeat":  rt.turnSeat,
+			"countdown": rt.countdownSecondsLocked(),
+		},
+	}, nil)
+}
+
+// pushDealMessagesLocked runs right after dealCardsLocked moved cards off
+// the deck, so a seat's freshly dealt cards are the last count entries of
+// its hand. Recipients get the public per-seat counts; a seated recipient's
+// own copy additionally carries the cards they just received, converted to
+// poker codes in chexuan mode like the state view. Spectators and seats not
+// dealt this stage get counts only. Because deals always precede the
+// broadcast that follows them and pushMessageLocked forces any pending
+// coalesced state out first, the deal message is guaranteed to arrive
+// before the next full state.
+func (rt *TableRuntime) pushDealMessagesLocked(count int) {
+	if count <= 0 {
+		return
+	}
+	// The opening deal is part of the hand-start state every client already
+	// renders, so it stays events-only; the mid-hand additional cards are
+	// what clients animate, so those reach every subscriber.
+	toAll := rt.round > 0
+	if !toAll && len(rt.eventSubs) == 0 {
+		return
+	}
+	dealt := make([]ginH, 0, len(rt.seats))
+	for i := range rt.seats {
+		seat := &rt.seats[i]
+		if (seat.Status == "playing" || seat.Status == "allin") && len(seat.cards) >= count {
+			dealt = append(dealt, ginH{"seat": seat.SeatIndex, "count": count})
+		}
+	}
+	public := OutgoingMessage{
+		Type: msgTypeDeal,
+		Data: ginH{"round": rt.round, "count": count, "dealt": dealt},
+	}
+	build := func(userID int64) OutgoingMessage {
+		if rt.spectators[userID] {
+			return public
+		}
+		seatIdx, ok := rt.seatByUser[userID]
+		if !ok {
+			return public
+		}
+		seat := rt.findSeatLocked(seatIdx)
+		if seat == nil || (seat.Status != "playing" && seat.Status != "allin") || len(seat.cards) < count {
+			return public
+		}
+		myCards := append([]string(nil), seat.cards[len(seat.cards)-count:]...)
+		for k, c := range myCards {
+			myCards[k] = rt.variantLocked().DisplayCard(c)
+		}
+		return OutgoingMessage{
+			Type: msgTypeDeal,
+			Data: ginH{
+				"round":   rt.round,
+				"count":   count,
+				"dealt":   dealt,
+				"myCards": myCards,
+			},
+		}
+	}
+	for userID := range rt.subscribers {
+		if !toAll && !rt.eventSubs[userID] {
+			continue
+		}
+		rt.pushMessageLocked(userID, build(userID))
+	}
+	for userID := range rt.remoteSubs {
+		if _, local := rt.subscribers[userID]; local {
+			continue
+		}
+		if !toAll && !rt.eventSubs[userID] {
+			continue
+		}
+		rt.pushMessageLocked(userID, build(userID))
+	}
+}
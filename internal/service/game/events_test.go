@@ -0,0 +1,173 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// eventsRuntime is a bare three-seat hand mid-round with two subscribers:
+// user 101 upgrades to granular events via hello, user 102 stays legacy.
+func eventsRuntime(t *testing.T) (*TableRuntime, chan OutgoingMessage, chan OutgoingMessage) {
+	t.Helper()
+	capable := make(chan OutgoingMessage, 64)
+	legacy := make(chan OutgoingMessage, 64)
+	rt := &TableRuntime{
+		phase:      PhasePlaying,
+		round:      1,
+		turnSeat:   1,
+		bankerSeat: 1,
+		lastRaise:  10,
+		pot:        30,
+		roundActed: map[int]bool{},
+		precommits: map[int]string{},
+		seatByUser: map[int64]int{101: 1, 102: 2, 103: 3},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 990, Bet: 10, TotalContribution: 10, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 990, Bet: 10, TotalContribution: 10, Status: "playing"},
+			{SeatIndex: 3, UserID: 103, Chips: 990, Bet: 10, TotalContribution: 10, Status: "playing"},
+		},
+		subscribers: map[int64]chan OutgoingMessage{101: capable, 102: legacy},
+		spectators:  map[int64]bool{},
+	}
+	if err := rt.handleActionLocked(101, "hello", json.RawMessage(`{"capabilities":["events","someday"]}`)); err != nil {
+		t.Fatalf("hello failed: %v", err)
+	}
+	return rt, capable, legacy
+}
+
+func messageTypes(msgs []OutgoingMessage) map[string]int {
+	types := make(map[string]int)
+	for _, msg := range msgs {
+		types[msg.Type]++
+	}
+	return types
+}
+
+func TestCallEmitsActionEventForCapableClients(t *testing.T) {
+	rt, capable, legacy := eventsRuntime(t)
+
+	if err := rt.handleActionLocked(101, "pass", nil); err != nil {
+		t.Fatalf("pass failed: %v", err)
+	}
+
+	msgs := drainStates(capable)
+	types := messageTypes(msgs)
+	if types[msgTypeAction] != 1 {
+		t.Fatalf("expected one action event, got %v", types)
+	}
+	if types["state"] != 0 {
+		t.Fatalf("capable client must not get full state for a turn action, got %v", types)
+	}
+	var event ginH
+	for _, msg := range msgs {
+		if msg.Type == msgTypeAction {
+			event = msg.Data.(ginH)
+		}
+	}
+	if event["seat"] != 1 || event["action"] != "pass" || event["newTurnSeat"] != 2 {
+		t.Fatalf("unexpected action payload %v", event)
+	}
+	if event["newPot"] != int64(30) {
+		t.Fatalf("unexpected pot in action payload %v", event)
+	}
+
+	// The legacy subscriber still gets the full state and no events.
+	types = messageTypes(drainStates(legacy))
+	if types["state"] != 1 || types[msgTypeAction] != 0 {
+		t.Fatalf("legacy client should get exactly the full state, got %v", types)
+	}
+}
+
+func TestRoundAdvanceEmitsDealAndPhase(t *testing.T) {
+	rt, capable, legacy := eventsRuntime(t)
+	rt.variant = chexuanVariant{}
+	rt.deck = []string{"RQ", "B10", "R2"}
+	rt.roundActed = map[int]bool{2: true, 3: true}
+	for i := range rt.seats {
+		rt.seats[i].cards = []string{"a", "b"}
+	}
+
+	if err := rt.handleActionLocked(101, "pass", nil); err != nil {
+		t.Fatalf("pass failed: %v", err)
+	}
+	if rt.round != 2 {
+		t.Fatalf("expected round 2 after everyone acted, got %d", rt.round)
+	}
+
+	msgs := drainStates(capable)
+	types := messageTypes(msgs)
+	if types[msgTypeDeal] != 1 || types[msgTypeAction] != 1 || types[msgTypePhase] != 1 {
+		t.Fatalf("expected deal+action+phase, got %v", types)
+	}
+	if types["state"] != 0 {
+		t.Fatalf("capable client must not get full state on round advance, got %v", types)
+	}
+	for _, msg := range msgs {
+		if msg.Type != msgTypeDeal {
+			continue
+		}
+		deal := msg.Data.(ginH)
+		cards, _ := deal["myCards"].([]string)
+		// Seat 1 drew RQ; the private copy carries the converted code.
+		if deal["count"] != 1 || len(cards) != 1 || cards[0] != "Qh" {
+			t.Fatalf("unexpected deal payload %v", deal)
+		}
+	}
+
+	// The deal message is not capability-gated: the legacy subscriber gets
+	// its own private copy for the animation, then the full state.
+	msgs = drainStates(legacy)
+	types = messageTypes(msgs)
+	if types["state"] != 1 || types[msgTypeDeal] != 1 {
+		t.Fatalf("legacy client should get deal then state, got %v", types)
+	}
+	if msgs[0].Type != msgTypeDeal {
+		t.Fatalf("deal must precede the state broadcast, got %v first", msgs[0].Type)
+	}
+	deal := msgs[0].Data.(ginH)
+	cards, _ := deal["myCards"].([]string)
+	if len(cards) != 1 || cards[0] != "Ts" {
+		t.Fatalf("expected seat 2's converted card, got %v", deal)
+	}
+	dealt, _ := deal["dealt"].([]ginH)
+	if len(dealt) != 3 {
+		t.Fatalf("expected per-seat counts for all three seats, got %v", deal["dealt"])
+	}
+}
+
+func TestDealMessageGivesSpectatorsCountsOnly(t *testing.T) {
+	rt, _, _ := eventsRuntime(t)
+	watcher := make(chan OutgoingMessage, 64)
+	rt.subscribers[104] = watcher
+	rt.spectators[104] = true
+	for i := range rt.seats {
+		rt.seats[i].cards = []string{"c1"}
+	}
+
+	rt.pushDealMessagesLocked(1)
+
+	msgs := drainStates(watcher)
+	if len(msgs) != 1 || msgs[0].Type != msgTypeDeal {
+		t.Fatalf("expected exactly the deal message, got %v", msgs)
+	}
+	deal := msgs[0].Data.(ginH)
+	if _, ok := deal["myCards"]; ok {
+		t.Fatalf("spectators must never see card values, got %v", deal)
+	}
+	dealt, _ := deal["dealt"].([]ginH)
+	if len(dealt) != 3 || dealt[0]["count"] != 1 {
+		t.Fatalf("expected counts for every dealt seat, got %v", deal["dealt"])
+	}
+}
+
+func TestStateStillBroadcastWithoutEventCoverage(t *testing.T) {
+	rt, capable, _ := eventsRuntime(t)
+
+	// A transition with no granular equivalent reaches everyone as state.
+	rt.broadcastStateLocked()
+
+	types := messageTypes(drainStates(capable))
+	if types["state"] != 1 {
+		t.Fatalf("un-evented transitions must still reach capable clients, got %v", types)
+	}
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Runtime eviction keeps the live map bounded. Without it every table this
+// instance ever hosted would keep its loop goroutine alive forever: a settled
+// runtime that never opens a next match, or a waiting one whose players all
+// left, just sits in the sync.Map. Eviction is purely in-memory — the table
+// row stays untouched and GetRuntime rebuilds the runtime from it if anyone
+// comes back.
+const (
+	// defaultFinishEvictGrace is how long a settled runtime may wait for a
+	// next match before it is reclaimed.
+	defaultFinishEvictGrace = 2 * time.Minute
+	// defaultIdleEvictAfter is how long a waiting runtime may sit with zero
+	// subscribers before the sweeper reclaims it.
+	defaultIdleEvictAfter = 10 * time.Minute
+	// practiceIdleEvictAfter reclaims abandoned practice tables. Their bot
+	// seats stay subscribed, so the subscriber count never reaches zero and
+	// idle time alone decides; nothing is lost, there is no row to resume.
+	practiceIdleEvictAfter = 2 * time.Minute
+)
+
+func (s *Service) finishGrace() time.Duration {
+	if s.finishEvictGrace > 0 {
+		return s.finishEvictGrace
+	}
+	return defaultFinishEvictGrace
+}
+
+func (s *Service) idleAfter() time.Duration {
+	if s.idleEvictAfter > 0 {
+		return s.idleEvictAfter
+	}
+	return defaultIdleEvictAfter
+}
+
+// scheduleFinishEviction arms the post-settle grace timer for a runtime that
+// finished a hand without a follow-up match. When the grace ends and the
+// runtime is still on its result screen, it is evicted; a hand that started
+// after all (phase moved on) leaves it to the idle sweeper.
+func (s *Service) scheduleFinishEviction(rt *TableRuntime) {
+	time.AfterFunc(s.finishGrace(), func() {
+		if snap, ok := rt.Snapshot(); ok && snap.Phase != PhaseEnded {
+			return
+		}
+		s.evictRuntime(rt, "finished")
+	})
+}
+
+// SweepIdleRuntimes evicts runtimes that have sat in PhaseWaiting with zero
+// subscribers past the idle threshold, plus any stale entries whose loop
+// already stopped. Registered as a periodic scheduler job; the runtime map is
+// per-instance state, so the job takes no cross-instance lock.
+func (s *Service) SweepIdleRuntimes(ctx context.Context) error {
+	threshold := s.idleAfter()
+	s.runtimes.Range(func(_, value interface{}) bool {
+		rt := value.(*TableRuntime)
+		snap, ok := rt.Snapshot()
+		if !ok {
+			s.evictRuntime(rt, "stopped")
+			return ctx.Err() == nil
+		}
+		if rt.practice {
+			if time.Duration(snap.IdleMS)*time.Millisecond >= practiceIdleEvictAfter {
+				s.evictRuntime(rt, "practice_idle")
+			}
+			return ctx.Err() == nil
+		}
+		if snap.Phase == PhaseWaiting && snap.Subscribers == 0 &&
+			time.Duration(snap.IdleMS)*time.Millisecond >= threshold {
+			s.evictRuntime(rt, "idle")
+		}
+		return ctx.Err() == nil
+	})
+	return ctx.Err()
+}
+
+// evictRuntime drops one runtime from the live map and stops its loop, which
+// drains every subscriber channel behind a table_expired close frame.
+// CompareAndDelete makes a double eviction — or one racing a GetRuntime that
+// already replaced the entry — a no-op.
+func (s *Service) evictRuntime(rt *TableRuntime, cause string) {
+	if !s.runtimes.CompareAndDelete(rt.tableID, rt) {
+		return
+	}
+	s.dropTableIndex(rt.tableID)
+	rt.stopWithReason(CloseReasonExpired)
+	logger.WithContext(s.lifecycleContext()).Info("evicted table runtime",
+		zap.Int64("tableID", rt.tableID),
+		zap.String("cause", cause),
+	)
+}
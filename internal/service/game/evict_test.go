@@ -0,0 +1,160 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newEvictService builds a service hosting one waiting table with the given
+// player snapshot, migrated widely enough for a full settle to run.
+func newEvictService(t *testing.T, playersJSON string) (*gorm.DB, *Service) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&model.Table{}, &model.Match{}, &model.Scene{}, &model.MatchRoundLog{},
+		&model.Wallet{}, &model.BillingLog{}, &model.AgentRule{},
+		&model.AgentProfitLog{}, &model.MatchRake{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.Create(&model.Scene{ID: 1, Name: "test", SeatCount: 2, BasePi: 10, MinUnitPi: 1}).Error; err != nil {
+		t.Fatalf("seed scene: %v", err)
+	}
+	if err := db.Create(&model.Table{
+		ID: 1, SceneID: 1, SeatCount: 2, Status: "waiting",
+		PlayersJSON: []byte(playersJSON),
+	}).Error; err != nil {
+		t.Fatalf("seed table: %v", err)
+	}
+	return db, &Service{db: db}
+}
+
+// A hand that settles with fewer than two stacked players opens no next
+// match; after the grace window the runtime's loop must exit and the map
+// entry disappear, and GetRuntime must rebuild from the row on demand.
+func TestFinishedRuntimeEvictedAfterGrace(t *testing.T) {
+	db, svc := newEvictService(t, `{"1":{"userId":101,"chips":10},"2":{"userId":102,"chips":10}}`)
+	svc.finishEvictGrace = 50 * time.Millisecond
+	ctx := context.Background()
+
+	match := model.Match{TableID: 1, SceneID: 1}
+	if err := db.Create(&match).Error; err != nil {
+		t.Fatalf("seed match: %v", err)
+	}
+
+	rt, err := svc.GetRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("get runtime: %v", err)
+	}
+	rt.resultGrace = 10 * time.Millisecond
+
+	// Both stacks cover exactly the ante, so whoever folds busts to zero and
+	// the survivor is the lone stacked player: no next match can open.
+	playFoldHand(t, rt)
+
+	select {
+	case <-rt.quitCh:
+	case <-time.After(2 * time.Second):
+		t.Fatal("runtime loop still running after the eviction grace")
+	}
+	if _, ok := svc.runtimes.Load(int64(1)); ok {
+		t.Fatal("evicted runtime still in the map")
+	}
+	if _, ok := rt.Snapshot(); ok {
+		t.Fatal("stopped loop answered a snapshot probe")
+	}
+
+	// The table row survives eviction, so a returning player gets a fresh
+	// runtime.
+	again, err := svc.GetRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("re-create after eviction: %v", err)
+	}
+	if again == rt {
+		t.Fatal("expected a fresh runtime, got the evicted one")
+	}
+	if snap, ok := again.Snapshot(); !ok || !snap.Live {
+		t.Fatal("re-created runtime is not live")
+	}
+	t.Cleanup(again.stop)
+}
+
+// The sweeper leaves watched tables alone and reclaims waiting runtimes once
+// nobody has been subscribed past the idle threshold.
+func TestSweeperEvictsIdleWaitingRuntime(t *testing.T) {
+	_, svc := newEvictService(t, `{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`)
+	svc.idleEvictAfter = time.Millisecond
+	ctx := context.Background()
+
+	rt, err := svc.GetRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("get runtime: %v", err)
+	}
+	ch := rt.Subscribe(101)
+	go func() {
+		for range ch {
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if err := svc.SweepIdleRuntimes(ctx); err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	}
+	if _, ok := svc.runtimes.Load(int64(1)); !ok {
+		t.Fatal("sweeper evicted a table with a live subscriber")
+	}
+
+	rt.Unsubscribe(101)
+	time.Sleep(10 * time.Millisecond)
+	if err := svc.SweepIdleRuntimes(ctx); err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	}
+	if _, ok := svc.runtimes.Load(int64(1)); ok {
+		t.Fatal("idle unwatched runtime survived the sweep")
+	}
+	select {
+	case <-rt.quitCh:
+	case <-time.After(time.Second):
+		t.Fatal("swept runtime's loop did not exit")
+	}
+}
+
+// A stale map entry whose loop already stopped must not be handed out;
+// GetRuntime replaces it with a fresh runtime.
+func TestGetRuntimeReplacesStoppedRuntime(t *testing.T) {
+	_, svc := newEvictService(t, `{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`)
+	ctx := context.Background()
+
+	rt, err := svc.GetRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("get runtime: %v", err)
+	}
+	rt.stop()
+
+	again, err := svc.GetRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("get runtime after stop: %v", err)
+	}
+	if again == rt {
+		t.Fatal("stopped runtime handed out again")
+	}
+	if snap, ok := again.Snapshot(); !ok || !snap.Live {
+		t.Fatal("replacement runtime is not live")
+	}
+	t.Cleanup(again.stop)
+}
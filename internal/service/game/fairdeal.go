@@ -0,0 +1,94 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	mrand "math/rand"
+
+	"dx-service/pkg/utils/random"
+)
+
+// Provably-fair dealing. Before a hand is dealt the runtime draws a random
+// seed, publishes only its SHA-256 commitment, and shuffles the deck with a
+// source derived deterministically from the seed. Once the hand ends the seed
+// is revealed, so anyone can re-run the shuffle and check that both the
+// commitment and the deck order match — proving the deal was fixed before any
+// card was seen and never adjusted mid-hand.
+
+// dealVerifyInstructions ships with the revealed seed so clients can verify
+// without reading the server source.
+const dealVerifyInstructions = "hex-decode nothing: hash the seed string with SHA-256 and compare it to the commitment published at hand start; then shuffle a fresh deck in template order with the seed-derived source to reproduce the deal"
+
+// newDealSeed draws a fresh 32-byte seed and returns it hex-encoded together
+// with its commitment.
+func newDealSeed() (seed, commitment string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	seed = hex.EncodeToString(buf)
+	return seed, commitSeed(seed), nil
+}
+
+// commitSeed is the published commitment: SHA-256 over the seed string.
+func commitSeed(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:])
+}
+
+// seededRNG derives the shuffle source deterministically from the seed.
+func seededRNG(seed string) *mrand.Rand {
+	sum := sha256.Sum256([]byte("deal:" + seed))
+	return mrand.New(mrand.NewSource(int64(binary.BigEndian.Uint64(sum[:8]))))
+}
+
+// shuffleStrings shuffles in place with the provided source, falling back to
+// crypto/rand when rng is nil.
+func shuffleStrings(deck []string, rng *mrand.Rand) {
+	var src random.Source
+	if rng != nil {
+		src = rng
+	}
+	random.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] }, src)
+}
+
+// newPokerDeck returns the 52-card poker deck in template order.
+func newPokerDeck() []string {
+	suits := []string{"s", "h", "d", "c"}
+	ranks := []string{"2", "3", "4", "5", "6", "7", "8", "9", "T", "J", "Q", "K", "A"}
+	deck := make([]string, 0, 52)
+	for _, s := range suits {
+		for _, r := range ranks {
+			deck = append(deck, r+s)
+		}
+	}
+	return deck
+}
+
+// VerifyDeal checks a revealed seed against its published commitment and the
+// deck order it is claimed to have produced. The deck type is inferred from
+// the order's length (32 cards = Chexuan, 52 = poker).
+func VerifyDeal(seed, commitment string, finalDeckOrder []string) error {
+	if commitSeed(seed) != commitment {
+		return fmt.Errorf("seed does not hash to the published commitment")
+	}
+	var expect []string
+	switch len(finalDeckOrder) {
+	case len(chexuanDeckTemplate):
+		expect = newShuffledChexuanDeck(seededRNG(seed))
+	case 52:
+		expect = newPokerDeck()
+		shuffleStrings(expect, seededRNG(seed))
+	default:
+		return fmt.Errorf("unrecognized deck size %d", len(finalDeckOrder))
+	}
+	for i, code := range expect {
+		if finalDeckOrder[i] != code {
+			return fmt.Errorf("deck order diverges at position %d: got %s, want %s", i, finalDeckOrder[i], code)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyDealRoundTrip(t *testing.T) {
+	seed, commitment, err := newDealSeed()
+	if err != nil {
+		t.Fatalf("new deal seed: %v", err)
+	}
+
+	chexuan := newShuffledChexuanDeck(seededRNG(seed))
+	if err := VerifyDeal(seed, commitment, chexuan); err != nil {
+		t.Fatalf("chexuan deal should verify: %v", err)
+	}
+
+	poker := newPokerDeck()
+	shuffleStrings(poker, seededRNG(seed))
+	if err := VerifyDeal(seed, commitment, poker); err != nil {
+		t.Fatalf("poker deal should verify: %v", err)
+	}
+
+	tampered := append([]string(nil), chexuan...)
+	// Most chexuan codes appear twice in the deck, so swapping two fixed
+	// positions can be a no-op; swap position 0 with the first position
+	// holding a different code so the tamper always changes the order.
+	for i := 1; i < len(tampered); i++ {
+		if tampered[i] != tampered[0] {
+			tampered[0], tampered[i] = tampered[i], tampered[0]
+			break
+		}
+	}
+	if err := VerifyDeal(seed, commitment, tampered); err == nil {
+		t.Fatal("a reordered deck must fail verification")
+	}
+	if err := VerifyDeal("not-the-seed", commitment, chexuan); err == nil {
+		t.Fatal("a wrong seed must fail the commitment check")
+	}
+	if err := VerifyDeal(seed, commitment, chexuan[:5]); err == nil {
+		t.Fatal("an unknown deck size must be rejected")
+	}
+}
+
+func TestHandCommitsThenRevealsSeed(t *testing.T) {
+	rt := setupSitOutRuntime(t)
+
+	ch := rt.Subscribe(101)
+	collected := make(chan OutgoingMessage, 256)
+	go func() {
+		for msg := range ch {
+			collected <- msg
+		}
+		close(collected)
+	}()
+
+	for _, uid := range []int64{101, 102, 103} {
+		if err := rt.HandleAction(uid, "ready", nil); err != nil {
+			t.Fatalf("ready failed: %v", err)
+		}
+	}
+	if rt.phase != PhasePlaying {
+		t.Fatalf("expected playing phase, got %s", rt.phase)
+	}
+	if rt.dealSeed == "" || rt.dealCommitment == "" {
+		t.Fatal("a live hand must carry a committed seed")
+	}
+	if commitSeed(rt.dealSeed) != rt.dealCommitment {
+		t.Fatal("commitment does not match the seed")
+	}
+
+	var committed string
+	deadline := time.After(2 * time.Second)
+drain:
+	for {
+		select {
+		case msg := <-collected:
+			if msg.Type == "deal_commitment" {
+				committed, _ = msg.Data.(ginH)["commitment"].(string)
+				break drain
+			}
+		case <-deadline:
+			t.Fatal("no deal_commitment broadcast before the deal")
+		}
+	}
+	if committed != rt.dealCommitment {
+		t.Fatalf("broadcast commitment %q does not match the hand's %q", committed, rt.dealCommitment)
+	}
+
+	// The seed stays hidden until the hand ends.
+	if state := rt.exportStateLocked(101); state.DealSeed != "" || state.DealCommitment != rt.dealCommitment {
+		t.Fatalf("mid-hand state must expose only the commitment, got %+v", state)
+	}
+
+	for rt.phase == PhasePlaying {
+		actAsTurnOwner(t, rt, "fold", nil)
+	}
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected hand settled, got %s", rt.phase)
+	}
+	state := rt.exportStateLocked(101)
+	if state.DealSeed == "" || state.DealVerify == "" {
+		t.Fatal("final state must reveal the seed with instructions")
+	}
+	if commitSeed(state.DealSeed) != state.DealCommitment {
+		t.Fatal("revealed seed does not match the published commitment")
+	}
+}
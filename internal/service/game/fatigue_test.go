@@ -0,0 +1,91 @@
+package game
+
+import "testing"
+
+func fatigueRuntime(limit int) *TableRuntime {
+	return &TableRuntime{
+		basePi:         10,
+		maxHandsStreak: limit,
+		seatHands:      make(map[int]int),
+		restedSeats:    make(map[int]bool),
+		seatByUser:     map[int64]int{101: 1, 102: 2, 103: 3},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 1000, Status: "playing"},
+			{SeatIndex: 3, UserID: 103, Chips: 1000, Status: "playing"},
+		},
+	}
+}
+
+func TestFatigueForcesRestAfterLimit(t *testing.T) {
+	rt := fatigueRuntime(2)
+	rt.seatHands[1] = 2
+
+	rt.applyFatigueLocked()
+
+	if rt.seats[0].Status != "resting" {
+		t.Fatalf("expected seat 1 resting, got %s", rt.seats[0].Status)
+	}
+	if !rt.restedSeats[1] {
+		t.Fatal("expected seat 1 recorded as rested")
+	}
+	if rt.seatHands[2] != 1 || rt.seatHands[3] != 1 {
+		t.Fatalf("expected other seats to accrue a hand, got %v", rt.seatHands)
+	}
+}
+
+func TestFatigueRestoresSeatAfterOneHand(t *testing.T) {
+	rt := fatigueRuntime(2)
+	rt.seatHands[1] = 2
+	rt.restedSeats[1] = true
+
+	// startRoundLocked resets non-eliminated seats to "playing" before the
+	// fatigue pass runs; the rested seat must come back with a fresh count.
+	rt.applyFatigueLocked()
+
+	if rt.seats[0].Status != "playing" {
+		t.Fatalf("expected seat 1 restored, got %s", rt.seats[0].Status)
+	}
+	if rt.seatHands[1] != 1 {
+		t.Fatalf("expected streak restarted at 1, got %d", rt.seatHands[1])
+	}
+	if rt.restedSeats[1] {
+		t.Fatal("expected rested marker cleared")
+	}
+}
+
+func TestFatigueDisabledWhenLimitZero(t *testing.T) {
+	rt := fatigueRuntime(0)
+	rt.seatHands[1] = 99
+
+	rt.applyFatigueLocked()
+
+	for _, seat := range rt.seats {
+		if seat.Status != "playing" {
+			t.Fatalf("expected seat %d playing, got %s", seat.SeatIndex, seat.Status)
+		}
+	}
+}
+
+func TestRestingSeatIgnoredBySettlementMath(t *testing.T) {
+	rt := fatigueRuntime(1)
+	rt.seats[0].Status = "resting"
+
+	rt.applyAntesLocked()
+
+	if rt.seats[0].Chips != 1000 || rt.seats[0].Bet != 0 {
+		t.Fatalf("expected resting seat untouched by antes, got chips %d bet %d",
+			rt.seats[0].Chips, rt.seats[0].Bet)
+	}
+	if rt.pot != 20 {
+		t.Fatalf("expected pot from two playing seats only, got %d", rt.pot)
+	}
+
+	active := rt.activeSeatsLocked()
+	if len(active) != 2 || active[0] != 2 || active[1] != 3 {
+		t.Fatalf("expected resting seat excluded from active seats, got %v", active)
+	}
+	if got := rt.findFirstActiveSeatLocked(); got != 2 {
+		t.Fatalf("expected first active seat 2, got %d", got)
+	}
+}
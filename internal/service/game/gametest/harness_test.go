@@ -0,0 +1,96 @@
+package gametest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"dx-service/internal/service/game"
+)
+
+// Settlement through the harness: a fold win moves the pot but caps the
+// winner's take at what they matched of the folder's contribution.
+func TestHarnessFoldWinSettles(t *testing.T) {
+	scene := NewTestScene()
+	h := NewRuntimeForTest(t, scene,
+		Player{UserID: 101, Chips: 1000},
+		Player{UserID: 102, Chips: 1000},
+	)
+	h.ReadyAll()
+
+	folder := h.TurnSeat()
+	h.MustAct(folder, "fold", 0)
+
+	if phase := h.State(101).Phase; phase != game.PhaseSettling && phase != game.PhaseEnded {
+		t.Fatalf("expected hand settled after fold, phase %s", phase)
+	}
+
+	results := h.RT.SettlementResults
+	if len(results) == 0 {
+		t.Fatal("expected settlement results recorded")
+	}
+	var sum, winnerNet int64
+	folderUser := int64(100 + folder)
+	for _, res := range results {
+		sum += res.NetPoints
+		if res.UserID != folderUser {
+			winnerNet = res.NetPoints
+		}
+	}
+	if sum != 0 {
+		t.Fatalf("net points sum to %d, want 0: %+v", sum, results)
+	}
+	if winnerNet != scene.BasePi {
+		t.Fatalf("fold win net %d, want the matched ante %d", winnerNet, scene.BasePi)
+	}
+
+	state, err := h.LastState(102)
+	if err != nil {
+		t.Fatalf("no state broadcast reached the winner: %v", err)
+	}
+	if state.Phase != game.PhaseSettling && state.Phase != game.PhaseEnded {
+		t.Fatalf("broadcast phase %s, want settled", state.Phase)
+	}
+}
+
+// Timeout behavior through the harness: once the frozen clock passes the
+// turn deadline, the turn owner's action is rejected as expired.
+func TestHarnessExpiredTurnRejectsAction(t *testing.T) {
+	h := NewRuntimeForTest(t, NewTestScene(),
+		Player{UserID: 101, Chips: 1000},
+		Player{UserID: 102, Chips: 1000},
+	)
+	h.ReadyAll()
+
+	turn := h.TurnSeat()
+	h.RT.SetClock(func() time.Time { return time.Now().Add(time.Minute) })
+
+	err := h.Act(turn, "pass", 0)
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("expected expired turn rejected, got %v", err)
+	}
+}
+
+// The capture channels see every player's broadcasts, so cross-seat effects
+// are assertable without poking runtime internals.
+func TestHarnessCapturesBroadcasts(t *testing.T) {
+	h := NewRuntimeForTest(t, NewTestScene(),
+		Player{UserID: 101, Chips: 1000},
+		Player{UserID: 102, Chips: 1000},
+	)
+	h.ReadyAll()
+
+	if _, err := h.LastState(101); err != nil {
+		t.Fatalf("seat 1 captured no state: %v", err)
+	}
+	state, err := h.LastState(102)
+	if err != nil {
+		t.Fatalf("seat 2 captured no state: %v", err)
+	}
+	if state.Phase != game.PhasePlaying {
+		t.Fatalf("expected playing phase broadcast, got %s", state.Phase)
+	}
+	if len(state.Seats) != 2 {
+		t.Fatalf("expected both seats in the broadcast, got %d", len(state.Seats))
+	}
+}
@@ -0,0 +1,209 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newHandoffPair builds two in-process game services that share one sqlite
+// handle and one miniredis, the way two deploy generations share the real
+// database and Redis.
+func newHandoffPair(t *testing.T) (*gorm.DB, *Service, *Service) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	mr := miniredis.RunT(t)
+	rdbA := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	rdbB := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&model.Table{}, &model.Match{}, &model.Scene{}, &model.MatchRoundLog{},
+		&model.Wallet{}, &model.BillingLog{}, &model.AgentRule{},
+		&model.AgentProfitLog{}, &model.MatchRake{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.Create(&model.Scene{ID: 1, Name: "test", SeatCount: 2, BasePi: 10, MinUnitPi: 1}).Error; err != nil {
+		t.Fatalf("seed scene: %v", err)
+	}
+	if err := db.Create(&model.Table{
+		ID: 1, SceneID: 1, SeatCount: 2, Status: "waiting",
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`),
+	}).Error; err != nil {
+		t.Fatalf("seed table: %v", err)
+	}
+	if err := db.Create(&model.Match{ID: 1, TableID: 1, SceneID: 1}).Error; err != nil {
+		t.Fatalf("seed match: %v", err)
+	}
+
+	svcA := &Service{db: db, rdb: rdbA, bridge: newBridge(rdbA)}
+	svcB := &Service{db: db, rdb: rdbB, bridge: newBridge(rdbB)}
+	return db, svcA, svcB
+}
+
+// A deploy drains instance A mid-hand; instance B claims the table, resumes
+// the turn clock from the snapshot and plays the hand to settlement.
+func TestWarmHandoffResumesMidHand(t *testing.T) {
+	db, svcA, svcB := newHandoffPair(t)
+	ctx := context.Background()
+
+	rtA, err := svcA.GetRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("get runtime on A: %v", err)
+	}
+	ch := rtA.Subscribe(101)
+	collected := make(chan OutgoingMessage, 256)
+	go func() {
+		for msg := range ch {
+			collected <- msg
+		}
+		close(collected)
+	}()
+
+	if err := rtA.HandleAction(101, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if err := rtA.HandleAction(102, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if rtA.phase != PhasePlaying {
+		t.Fatalf("expected a hand in flight, got %s", rtA.phase)
+	}
+	wantRound, wantTurn, wantPot := rtA.round, rtA.turnSeat, rtA.pot
+
+	if err := svcA.Stop(ctx); err != nil {
+		t.Fatalf("drain A: %v", err)
+	}
+	if _, ok := svcA.runtimes.Load(int64(1)); ok {
+		t.Fatal("drained instance still hosts the runtime")
+	}
+
+	// Subscribers get the transfer notice, then a reconnect_to close.
+	sawNotice := false
+	deadline := time.After(2 * time.Second)
+loop:
+	for {
+		select {
+		case msg, ok := <-collected:
+			if !ok {
+				break loop
+			}
+			switch msg.Type {
+			case "table_transferring":
+				sawNotice = true
+			case "close":
+				info, ok := msg.Data.(CloseInfo)
+				if !ok || info.Reason != CloseReasonTransferring {
+					t.Fatalf("unexpected close payload: %#v", msg.Data)
+				}
+				if !sawNotice {
+					t.Fatal("close frame arrived before the transfer notice")
+				}
+			}
+		case <-deadline:
+			t.Fatal("subscriber channel never closed")
+		}
+	}
+	if !sawNotice {
+		t.Fatal("no table_transferring notice delivered")
+	}
+
+	var row model.Table
+	if err := db.First(&row, 1).Error; err != nil {
+		t.Fatalf("table lookup failed: %v", err)
+	}
+	if len(row.RuntimeSnapshotJSON) == 0 {
+		t.Fatal("handoff left no snapshot on the table row")
+	}
+
+	// The ownership claim is released so the next instance can take over.
+	waitUntil := time.Now().Add(2 * time.Second)
+	for {
+		if err := svcA.rdb.Get(ctx, buildTableOwnerKey(1)).Err(); err == redis.Nil {
+			break
+		}
+		if time.Now().After(waitUntil) {
+			t.Fatal("ownership key never released")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	rtB, err := svcB.GetRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("get runtime on B: %v", err)
+	}
+	t.Cleanup(rtB.stop)
+	if rtB == rtA {
+		t.Fatal("instance B was handed A's runtime")
+	}
+	if rtB.phase != PhasePlaying {
+		t.Fatalf("hand not resumed on B, phase %s", rtB.phase)
+	}
+	if rtB.round != wantRound || rtB.turnSeat != wantTurn || rtB.pot != wantPot {
+		t.Fatalf("resumed state differs: round %d/%d turnSeat %d/%d pot %d/%d",
+			rtB.round, wantRound, rtB.turnSeat, wantTurn, rtB.pot, wantPot)
+	}
+	if rtB.countdownSecondsLocked() == 0 {
+		t.Fatal("turn clock not re-armed after handoff")
+	}
+
+	// The hand that started on A finishes on B.
+	actAsTurnOwner(t, rtB, "fold", nil)
+	if rtB.phase != PhaseEnded {
+		t.Fatalf("expected hand settled on B, got %s", rtB.phase)
+	}
+	waitUntil = time.Now().Add(2 * time.Second)
+	for {
+		var match model.Match
+		if err := db.First(&match, 1).Error; err != nil {
+			t.Fatalf("match lookup failed: %v", err)
+		}
+		if match.EndedAt != nil {
+			break
+		}
+		if time.Now().After(waitUntil) {
+			t.Fatal("match never settled after the handoff")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// After the transfer snapshot is written nothing may mutate the hand on the
+// draining instance.
+func TestTransferRefusesFurtherActions(t *testing.T) {
+	rt := &TableRuntime{
+		phase:    PhasePlaying,
+		turnSeat: 1,
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Status: "playing", Chips: 990},
+			{SeatIndex: 2, UserID: 102, Status: "playing", Chips: 990},
+		},
+		seatByUser: map[int64]int{101: 1, 102: 2},
+	}
+
+	rt.handleTransferLocked()
+	if !rt.transferring {
+		t.Fatal("transfer did not mark the runtime")
+	}
+	if err := rt.handleActionLocked(101, "fold", nil); !errors.Is(err, appErr.ErrServerBusy) {
+		t.Fatalf("expected server busy after the handoff snapshot, got %v", err)
+	}
+}
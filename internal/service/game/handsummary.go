@@ -0,0 +1,78 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SeatHandSummary carries one seat's last-hand net alongside its running
+// session totals for the between-hands recap.
+type SeatHandSummary struct {
+	SeatIndex   int    `json:"seatIndex"`
+	UserID      int64  `json:"userId,string"`
+	Alias       string `json:"alias"`
+	HandNet     int64  `json:"handNet"`
+	SessionNet  int64  `json:"sessionNet"`
+	HandsPlayed int    `json:"handsPlayed"`
+}
+
+// HandSummary is the `hand_summary` payload broadcast after settlement so
+// players see where they stand before the next hand starts.
+type HandSummary struct {
+	HandNo     int               `json:"handNo"`
+	NextBanker int               `json:"nextBanker"`
+	Seats      []SeatHandSummary `json:"seats"`
+}
+
+// accumulateSessionLocked folds the hand's settlement into each seat's
+// running totals. A refunded hand (net zero everywhere) still counts as
+// played so liuju hands show up in the session history.
+func (rt *TableRuntime) accumulateSessionLocked(results []PlayerResult) {
+	for _, res := range results {
+		seatIdx, ok := rt.seatByUser[res.UserID]
+		if !ok {
+			continue
+		}
+		if seat := rt.findSeatLocked(seatIdx); seat != nil {
+			seat.SessionNet += res.NetPoints
+			seat.HandsPlayed++
+		}
+	}
+}
+
+// broadcastHandSummaryLocked updates the session accumulator from the hand's
+// results, broadcasts the recap, records a one-line version in the table log
+// and persists the totals with the PlayersJSON snapshot.
+func (rt *TableRuntime) broadcastHandSummaryLocked(results []PlayerResult) {
+	rt.accumulateSessionLocked(results)
+
+	handNet := make(map[int64]int64, len(results))
+	for _, res := range results {
+		handNet[res.UserID] += res.NetPoints
+	}
+
+	summary := HandSummary{HandNo: rt.handNo, NextBanker: rt.bankerSeat}
+	parts := make([]string, 0, len(rt.seats))
+	for _, seat := range rt.seats {
+		if seat.UserID == 0 {
+			continue
+		}
+		summary.Seats = append(summary.Seats, SeatHandSummary{
+			SeatIndex:   seat.SeatIndex,
+			UserID:      seat.UserID,
+			Alias:       seat.Alias,
+			HandNet:     handNet[seat.UserID],
+			SessionNet:  seat.SessionNet,
+			HandsPlayed: seat.HandsPlayed,
+		})
+		parts = append(parts, fmt.Sprintf("%s %+d(累计%+d)", seat.Alias, handNet[seat.UserID], seat.SessionNet))
+	}
+
+	rt.appendSystemLogLocked(fmt.Sprintf("第%d手结算 %s", summary.HandNo, strings.Join(parts, " ")))
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "hand_summary",
+		Seq:  rt.nextSeqLocked(),
+		Data: summary,
+	})
+	rt.persistPlayersLocked()
+}
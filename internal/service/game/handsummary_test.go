@@ -0,0 +1,107 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func summaryRuntime() *TableRuntime {
+	return &TableRuntime{
+		phase:      PhaseEnded,
+		bankerSeat: 1,
+		seatByUser: map[int64]int{101: 1, 102: 2},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Alias: "甲", Chips: 1000},
+			{SeatIndex: 2, UserID: 102, Alias: "乙", Chips: 1000},
+		},
+		subscribers: map[int64]chan OutgoingMessage{
+			101: make(chan OutgoingMessage, 8),
+		},
+	}
+}
+
+func lastHandSummary(t *testing.T, ch chan OutgoingMessage) HandSummary {
+	t.Helper()
+	var summary HandSummary
+	found := false
+	for {
+		select {
+		case msg := <-ch:
+			if msg.Type != "hand_summary" {
+				continue
+			}
+			summary = msg.Data.(HandSummary)
+			found = true
+		default:
+			if !found {
+				t.Fatal("no hand_summary message delivered")
+			}
+			return summary
+		}
+	}
+}
+
+func TestHandSummaryAccumulatesAcrossHands(t *testing.T) {
+	rt := summaryRuntime()
+	ch := rt.subscribers[101]
+
+	// Hand 1: seat 1 wins 50 from seat 2.
+	rt.handNo = 1
+	rt.broadcastHandSummaryLocked([]PlayerResult{
+		{UserID: 101, NetPoints: 50},
+		{UserID: 102, NetPoints: -50},
+	})
+	first := lastHandSummary(t, ch)
+	if first.HandNo != 1 || first.NextBanker != 1 {
+		t.Fatalf("unexpected header: %+v", first)
+	}
+	if first.Seats[0].HandNet != 50 || first.Seats[0].SessionNet != 50 {
+		t.Fatalf("unexpected seat 1 totals: %+v", first.Seats[0])
+	}
+
+	// Hand 2: refunded liuju, everyone nets zero but the hand still counts.
+	rt.handNo = 2
+	rt.broadcastHandSummaryLocked([]PlayerResult{
+		{UserID: 101, NetPoints: 0, Meta: map[string]interface{}{"reason": "mango_refund"}},
+		{UserID: 102, NetPoints: 0},
+	})
+	second := lastHandSummary(t, ch)
+	if second.Seats[0].HandNet != 0 || second.Seats[0].SessionNet != 50 || second.Seats[0].HandsPlayed != 2 {
+		t.Fatalf("liuju hand not reflected: %+v", second.Seats[0])
+	}
+
+	// Hand 3: seat 2 wins 80 back.
+	rt.handNo = 3
+	rt.broadcastHandSummaryLocked([]PlayerResult{
+		{UserID: 101, NetPoints: -80},
+		{UserID: 102, NetPoints: 80},
+	})
+	third := lastHandSummary(t, ch)
+	if third.Seats[0].SessionNet != -30 || third.Seats[1].SessionNet != 30 {
+		t.Fatalf("unexpected session nets: %+v", third.Seats)
+	}
+	if third.Seats[1].HandsPlayed != 3 {
+		t.Fatalf("expected 3 hands played, got %d", third.Seats[1].HandsPlayed)
+	}
+
+	if len(rt.logs) != 3 {
+		t.Fatalf("expected 3 summary log lines, got %d", len(rt.logs))
+	}
+}
+
+func TestSessionTotalsSurvivePlayersJSONRoundTrip(t *testing.T) {
+	raw := json.RawMessage(`{
+		"1": {"userId": 101, "alias": "甲", "chips": 970, "sessionNet": -30, "handsPlayed": 3},
+		"2": {"userId": 102, "alias": "乙", "chips": 1030, "sessionNet": 30, "handsPlayed": 3}
+	}`)
+	seats, _, err := parsePlayersJSON(raw)
+	if err != nil {
+		t.Fatalf("parse players: %v", err)
+	}
+	if seats[0].SessionNet != -30 || seats[0].HandsPlayed != 3 {
+		t.Fatalf("seat 1 totals not restored: %+v", seats[0])
+	}
+	if seats[1].SessionNet != 30 || seats[1].HandsPlayed != 3 {
+		t.Fatalf("seat 2 totals not restored: %+v", seats[1])
+	}
+}
@@ -0,0 +1,185 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// RuntimeSnapshot is the operator view of one table: the sanitized observer
+// state (cards hidden) plus loop-side counters no client export carries.
+// Live distinguishes a runtime read from the DB fallback for ended tables.
+type RuntimeSnapshot struct {
+	TableState
+	MatchID     int64 `json:"matchId,string,omitempty"`
+	Subscribers int   `json:"subscribers"`
+	Spectators  int   `json:"spectators"`
+	// IdleMS is how long ago the loop handled its last command; snapshot
+	// probes themselves don't count.
+	IdleMS int64 `json:"idleMs"`
+	Live   bool  `json:"live"`
+}
+
+// Snapshot reads the runtime's current state from inside the loop goroutine,
+// so the export never races in-flight commands. ok is false when the runtime
+// already stopped.
+func (rt *TableRuntime) Snapshot() (RuntimeSnapshot, bool) {
+	ch := make(chan RuntimeSnapshot, 1)
+	select {
+	case rt.cmdCh <- loopCommand{kind: "snapshot", snap: ch}:
+		select {
+		case snap := <-ch:
+			return snap, true
+		case <-rt.quitCh:
+			return RuntimeSnapshot{}, false
+		}
+	case <-rt.quitCh:
+		return RuntimeSnapshot{}, false
+	}
+}
+
+func (rt *TableRuntime) snapshotLocked() RuntimeSnapshot {
+	subs := len(rt.subscribers)
+	for _, n := range rt.remoteSubs {
+		subs += n
+	}
+	idle := rt.timeNow().Sub(rt.lastActiveAt)
+	if idle < 0 {
+		idle = 0
+	}
+	return RuntimeSnapshot{
+		TableState:  rt.exportObserverStateLocked(false),
+		MatchID:     rt.matchID,
+		Subscribers: subs,
+		Spectators:  len(rt.spectators),
+		IdleMS:      idle.Milliseconds(),
+		Live:        true,
+	}
+}
+
+// InspectRuntime is the admin "what is this table doing right now" read: the
+// live loop snapshot when a runtime is hosted here, otherwise the persisted
+// table row reconstructed into the same shape so ended tables stay
+// inspectable.
+func (s *Service) InspectRuntime(ctx context.Context, tableID int64) (*RuntimeSnapshot, error) {
+	if v, ok := s.runtimes.Load(tableID); ok {
+		if snap, ok := v.(*TableRuntime).Snapshot(); ok {
+			return &snap, nil
+		}
+	}
+
+	var table model.Table
+	if err := s.db.WithContext(ctx).First(&table, tableID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErr.ErrTableNotFound
+		}
+		return nil, err
+	}
+	seats, _, err := parsePlayersJSON(json.RawMessage(table.PlayersJSON))
+	if err != nil {
+		return nil, err
+	}
+	return &RuntimeSnapshot{
+		TableState: TableState{
+			TableID: table.ID,
+			Phase:   Phase(table.Status),
+			Seats:   seats,
+		},
+		Live: false,
+	}, nil
+}
+
+// AdminListTablesFilter narrows the admin table listing; zero values mean
+// "no filter".
+type AdminListTablesFilter struct {
+	Page        int
+	Size        int
+	SceneID     int64
+	Status      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+// AdminTableItem is one listing row: the persisted table plus whether a
+// runtime is hosted on this instance right now.
+type AdminTableItem struct {
+	model.Table
+	Live bool `json:"live"`
+}
+
+type AdminListTablesResult struct {
+	Items []AdminTableItem
+	Total int64
+}
+
+func (f *AdminListTablesFilter) sanitize() {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Size <= 0 {
+		f.Size = 20
+	}
+	if f.Size > 100 {
+		f.Size = 100
+	}
+}
+
+func applyAdminTableFilters(db *gorm.DB, filter AdminListTablesFilter) *gorm.DB {
+	if filter.SceneID > 0 {
+		db = db.Where("scene_id = ?", filter.SceneID)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		db = db.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		db = db.Where("created_at < ?", filter.CreatedTo)
+	}
+	return db
+}
+
+// AdminListTables pages through table rows newest first, flagging the ones
+// whose runtime lives on this instance.
+func (s *Service) AdminListTables(ctx context.Context, filter AdminListTablesFilter) (*AdminListTablesResult, error) {
+	filter.sanitize()
+
+	countQuery := applyAdminTableFilters(s.db.WithContext(ctx).Model(&model.Table{}), filter)
+	var total int64
+	if err := countQuery.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	result := &AdminListTablesResult{
+		Items: make([]AdminTableItem, 0),
+		Total: total,
+	}
+	if total == 0 {
+		return result, nil
+	}
+
+	var tables []model.Table
+	dataQuery := applyAdminTableFilters(s.db.WithContext(ctx).Model(&model.Table{}), filter)
+	if err := dataQuery.
+		Order("id DESC").
+		Limit(filter.Size).
+		Offset((filter.Page - 1) * filter.Size).
+		Find(&tables).Error; err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		// The resume blob is internal (and carries encrypted cards); the
+		// listing has no use for it.
+		table.RuntimeSnapshotJSON = nil
+		_, live := s.runtimes.Load(table.ID)
+		result.Items = append(result.Items, AdminTableItem{Table: table, Live: live})
+	}
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+)
+
+func TestInspectRuntimeReadsLiveLoop(t *testing.T) {
+	db, svc := newCurrentTableService(t)
+	ctx := context.Background()
+
+	if err := db.Create(&model.Scene{ID: 1, Name: "test", SeatCount: 2, BasePi: 10, MinUnitPi: 1}).Error; err != nil {
+		t.Fatalf("seed scene: %v", err)
+	}
+	table := model.Table{
+		ID:          1,
+		SceneID:     1,
+		Status:      "waiting",
+		SeatCount:   2,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("seed table: %v", err)
+	}
+
+	rt, err := svc.GetRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("runtime creation failed: %v", err)
+	}
+	t.Cleanup(rt.stop)
+	ch := rt.Subscribe(101)
+	go func() {
+		for range ch {
+		}
+	}()
+
+	snap, err := svc.InspectRuntime(ctx, 1)
+	if err != nil {
+		t.Fatalf("inspect failed: %v", err)
+	}
+	if !snap.Live || snap.Phase != PhaseWaiting {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+	if len(snap.Seats) != 2 || snap.Subscribers != 1 {
+		t.Fatalf("unexpected seats/subscribers: %+v", snap)
+	}
+	for _, seat := range snap.Seats {
+		if len(seat.Cards) != 0 {
+			t.Fatalf("snapshot leaked hole cards: %+v", seat)
+		}
+	}
+}
+
+func TestInspectRuntimeFallsBackToRow(t *testing.T) {
+	db, svc := newCurrentTableService(t)
+	ctx := context.Background()
+
+	table := model.Table{
+		ID:          5,
+		SceneID:     1,
+		Status:      "ended",
+		SeatCount:   2,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":900}}`),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("seed table: %v", err)
+	}
+
+	snap, err := svc.InspectRuntime(ctx, 5)
+	if err != nil {
+		t.Fatalf("inspect failed: %v", err)
+	}
+	if snap.Live || snap.Phase != Phase("ended") || len(snap.Seats) != 1 {
+		t.Fatalf("unexpected fallback snapshot: %+v", snap)
+	}
+
+	if _, err := svc.InspectRuntime(ctx, 404); err == nil {
+		t.Fatal("expected table not found for unknown id")
+	}
+}
+
+func TestAdminListTablesFilters(t *testing.T) {
+	db, svc := newCurrentTableService(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	rows := []model.Table{
+		{ID: 1, SceneID: 1, Status: "playing", CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: 2, SceneID: 1, Status: "ended", CreatedAt: now.Add(-1 * time.Hour)},
+		{ID: 3, SceneID: 2, Status: "playing", CreatedAt: now},
+	}
+	for i := range rows {
+		if err := db.Create(&rows[i]).Error; err != nil {
+			t.Fatalf("seed table: %v", err)
+		}
+	}
+
+	result, err := svc.AdminListTables(ctx, AdminListTablesFilter{SceneID: 1})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if result.Total != 2 || len(result.Items) != 2 {
+		t.Fatalf("scene filter missed: %+v", result)
+	}
+	// Newest first.
+	if result.Items[0].ID != 2 || result.Items[1].ID != 1 {
+		t.Fatalf("unexpected ordering: %+v", result.Items)
+	}
+
+	result, err = svc.AdminListTables(ctx, AdminListTablesFilter{Status: "playing"})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if result.Total != 2 {
+		t.Fatalf("status filter missed: %+v", result)
+	}
+
+	result, err = svc.AdminListTables(ctx, AdminListTablesFilter{
+		CreatedFrom: now.Add(-90 * time.Minute),
+		CreatedTo:   now.Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if result.Total != 1 || result.Items[0].ID != 2 {
+		t.Fatalf("time-range filter missed: %+v", result)
+	}
+}
@@ -0,0 +1,144 @@
+package game
+
+import (
+	"dx-service/internal/config"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// AnomalyReport describes one impossible game state caught by the runtime's
+// invariant checks, for the operator-facing error-reporting hook.
+type AnomalyReport struct {
+	TableID   int64
+	MatchID   int64
+	Phase     Phase
+	Invariant string
+	Stage     string
+	Detail    map[string]interface{}
+	// State is the sanitized observer export (cards hidden) at the moment of
+	// the violation.
+	State TableState
+}
+
+// Invariant names reported by the runtime checks.
+const (
+	invariantNegativeChips    = "negative_chips"
+	invariantPotContribution  = "pot_contribution"
+	invariantTurnSeat         = "turn_seat"
+	invariantSettlementLedger = "settlement_ledger"
+)
+
+// verifyInvariantsLocked runs the cheap state checks at a phase transition.
+// It returns false when a violation froze the table, in which case the
+// caller must not keep driving the hand.
+func (rt *TableRuntime) verifyInvariantsLocked(stage string) bool {
+	for _, seat := range rt.seats {
+		if seat.UserID == 0 {
+			continue
+		}
+		if seat.Chips < 0 || seat.Bet < 0 {
+			rt.raiseAnomalyLocked(invariantNegativeChips, stage, map[string]interface{}{
+				"seat":  seat.SeatIndex,
+				"chips": seat.Chips,
+				"bet":   seat.Bet,
+			})
+			return !rt.frozen
+		}
+	}
+
+	if rt.phase == PhasePlaying || rt.phase == PhaseSettling {
+		var contributions int64
+		for _, seat := range rt.seats {
+			contributions += seat.TotalContribution
+		}
+		if rt.pot != contributions {
+			rt.raiseAnomalyLocked(invariantPotContribution, stage, map[string]interface{}{
+				"pot":           rt.pot,
+				"contributions": contributions,
+			})
+			return !rt.frozen
+		}
+	}
+
+	if rt.phase == PhasePlaying && rt.turnSeat != 0 {
+		seat := rt.findSeatLocked(rt.turnSeat)
+		if seat == nil || seat.UserID == 0 || seat.Status != "playing" {
+			rt.raiseAnomalyLocked(invariantTurnSeat, stage, map[string]interface{}{
+				"turnSeat": rt.turnSeat,
+			})
+			return !rt.frozen
+		}
+	}
+	return true
+}
+
+// verifySettlementLocked checks the ledger of a finishing hand sums to zero
+// before anything is persisted or broadcast. It returns false when freezing
+// stopped the settlement.
+func (rt *TableRuntime) verifySettlementLocked(results []PlayerResult) bool {
+	var sum int64
+	for _, res := range results {
+		sum += res.NetPoints
+	}
+	if sum == 0 {
+		return true
+	}
+	rt.raiseAnomalyLocked(invariantSettlementLedger, "settle", map[string]interface{}{
+		"sum":     sum,
+		"results": len(results),
+	})
+	return !rt.frozen
+}
+
+// raiseAnomalyLocked is the single sink for invariant violations: an Error
+// log with the sanitized state dump, the error-reporting hook, and — behind
+// the config flag — the freeze that parks the table for admin review.
+func (rt *TableRuntime) raiseAnomalyLocked(invariant, stage string, detail map[string]interface{}) {
+	report := AnomalyReport{
+		TableID:   rt.tableID,
+		MatchID:   rt.matchID,
+		Phase:     rt.phase,
+		Invariant: invariant,
+		Stage:     stage,
+		Detail:    detail,
+		State:     rt.exportObserverStateLocked(false),
+	}
+	logger.Log.Error("game state invariant violated",
+		zap.Int64("tableID", rt.tableID),
+		zap.Int64("matchID", rt.matchID),
+		zap.String("invariant", invariant),
+		zap.String("stage", stage),
+		zap.Any("detail", detail),
+		zap.Any("state", report.State),
+	)
+	if rt.onAnomaly != nil {
+		rt.onAnomaly(report)
+	}
+	if rt.freezeOnAnomaly {
+		rt.freezeTableLocked(invariant)
+	}
+}
+
+// freezeTableLocked parks the table: no actions, no timers, no settlement,
+// until an admin reviews it (typically ending in a force-dissolve).
+func (rt *TableRuntime) freezeTableLocked(invariant string) {
+	if rt.frozen {
+		return
+	}
+	rt.frozen = true
+	rt.cancelTimerLocked()
+	rt.appendSystemLogLocked("牌局异常，牌桌已冻结等待处理")
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "table_frozen",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{"invariant": invariant},
+	})
+	rt.broadcastStateLocked()
+}
+
+// freezeOnAnomalyFromConfig reads the flag that turns invariant violations
+// from log-only alerts into a table freeze.
+func freezeOnAnomalyFromConfig() bool {
+	return config.GlobalConfig != nil && config.GlobalConfig.Game.FreezeOnAnomaly
+}
@@ -0,0 +1,152 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// anomalyRuntime is a bare mid-hand runtime for driving the invariant checks
+// directly: two playing seats, level bets, a consistent pot.
+func anomalyRuntime() *TableRuntime {
+	return &TableRuntime{
+		tableID: 7,
+		phase:   PhasePlaying,
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 990, Bet: 10, TotalContribution: 10, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 990, Bet: 10, TotalContribution: 10, Status: "playing"},
+		},
+		seatByUser:  map[int64]int{101: 1, 102: 2},
+		pot:         20,
+		turnSeat:    1,
+		subscribers: map[int64]chan OutgoingMessage{},
+		spectators:  map[int64]bool{},
+	}
+}
+
+func TestInvariantViolationFreezesTable(t *testing.T) {
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	rt := setupLifetimeRuntime(t, 0, 0)
+	rt.freezeOnAnomaly = true
+	reports := make(chan AnomalyReport, 4)
+	rt.onAnomaly = func(r AnomalyReport) { reports <- r }
+
+	ch := rt.Subscribe(101)
+	collected := make(chan OutgoingMessage, 256)
+	go func() {
+		for msg := range ch {
+			collected <- msg
+		}
+	}()
+
+	if err := rt.HandleAction(101, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if err := rt.HandleAction(102, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if rt.phase != PhasePlaying {
+		t.Fatalf("expected playing phase, got %s", rt.phase)
+	}
+
+	// Skew the pot, then let the round play out: the round-advance check
+	// must catch pot != sum of contributions.
+	rt.CorruptPotForTest(7)
+	actAsTurnOwner(t, rt, "pass", nil)
+	actAsTurnOwner(t, rt, "pass", nil)
+
+	select {
+	case report := <-reports:
+		if report.Invariant != invariantPotContribution {
+			t.Fatalf("unexpected invariant %q", report.Invariant)
+		}
+		if report.TableID != 1 || report.Stage != "round_advance" {
+			t.Fatalf("unexpected report %+v", report)
+		}
+		if len(report.State.Seats) == 0 {
+			t.Fatal("report carries no state dump")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no anomaly report fired")
+	}
+
+	if !rt.frozen {
+		t.Fatal("table did not freeze on violation")
+	}
+	if err := rt.HandleAction(101, "pass", nil); !errors.Is(err, appErr.ErrTableFrozen) {
+		t.Fatalf("expected ErrTableFrozen on frozen table, got %v", err)
+	}
+
+	sawFrozen := false
+	deadline := time.After(2 * time.Second)
+	for !sawFrozen {
+		select {
+		case msg := <-collected:
+			if msg.Type == "table_frozen" {
+				sawFrozen = true
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for table_frozen broadcast")
+		}
+	}
+}
+
+func TestSettlementImbalanceFreezesInsteadOfSettling(t *testing.T) {
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	rt := anomalyRuntime()
+	rt.freezeOnAnomaly = true
+	var report *AnomalyReport
+	rt.onAnomaly = func(r AnomalyReport) { report = &r }
+
+	rt.finishWithResultsLocked([]PlayerResult{
+		{UserID: 101, NetPoints: 5},
+		{UserID: 102, NetPoints: -2},
+	})
+
+	if report == nil || report.Invariant != invariantSettlementLedger {
+		t.Fatalf("expected settlement ledger report, got %+v", report)
+	}
+	if !rt.frozen {
+		t.Fatal("table did not freeze on unbalanced ledger")
+	}
+	if rt.phase == PhaseEnded {
+		t.Fatal("hand ended despite the freeze")
+	}
+	if rt.SettlementResults != nil {
+		t.Fatal("unbalanced results were stored for settlement")
+	}
+}
+
+func TestAnomalyWithoutFreezeFlagOnlyReports(t *testing.T) {
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	rt := anomalyRuntime()
+	svc := &Service{}
+	var relayed []AnomalyReport
+	svc.SetAnomalyReporter(func(r AnomalyReport) { relayed = append(relayed, r) })
+	rt.onAnomaly = svc.recordAnomaly
+
+	rt.CorruptPotForTest(3)
+	if !rt.verifyInvariantsLocked("test") {
+		t.Fatal("log-only mode must not stop the caller")
+	}
+	if rt.frozen {
+		t.Fatal("table froze with the freeze flag off")
+	}
+	if svc.AnomalyCount() != 1 {
+		t.Fatalf("expected anomaly metric 1, got %d", svc.AnomalyCount())
+	}
+	if len(relayed) != 1 || relayed[0].Invariant != invariantPotContribution {
+		t.Fatalf("reporter hook saw %+v", relayed)
+	}
+}
@@ -0,0 +1,243 @@
+package game
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// inviteTTL bounds how long a table invitation stays redeemable.
+const inviteTTL = 2 * time.Minute
+
+// Invitation is a short-lived offer of a seat at a waiting table.
+type Invitation struct {
+	Token     string `json:"token"`
+	TableID   int64  `json:"tableId"`
+	SceneID   int64  `json:"sceneId"`
+	InviterID int64  `json:"inviterId"`
+	InviteeID int64  `json:"inviteeId"`
+	ExpiresAt int64  `json:"expiresAt"` // unix milliseconds
+}
+
+// InviteRequest identifies the invitee by userID or phone; phone is only
+// consulted when InviteeID is zero.
+type InviteRequest struct {
+	InviterID int64
+	TableID   int64
+	InviteeID int64
+	Phone     string
+}
+
+// Invite creates an invitation from a seated player to a friend. The table
+// must still be waiting with a free seat; the final seat check happens again
+// inside the runtime loop when the invitee accepts.
+func (s *Service) Invite(ctx context.Context, req InviteRequest) (*Invitation, error) {
+	invitee, err := s.resolveInvitee(ctx, req.InviteeID, req.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	var table model.Table
+	if err := s.db.WithContext(ctx).First(&table, req.TableID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErr.ErrTableNotFound
+		}
+		return nil, err
+	}
+	if table.Status != "waiting" {
+		return nil, appErr.ErrTableMidHand
+	}
+
+	seats, seatByUser, err := parsePlayersJSON(json.RawMessage(table.PlayersJSON))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := seatByUser[req.InviterID]; !ok {
+		return nil, appErr.ErrTableAccessDenied
+	}
+	if _, ok := seatByUser[invitee.ID]; ok {
+		return nil, appErr.ErrAlreadySeated
+	}
+	if len(seats) >= table.SeatCount {
+		return nil, appErr.ErrTableFull
+	}
+
+	token, err := newInviteToken()
+	if err != nil {
+		return nil, err
+	}
+	inv := Invitation{
+		Token:     token,
+		TableID:   table.ID,
+		SceneID:   table.SceneID,
+		InviterID: req.InviterID,
+		InviteeID: invitee.ID,
+		ExpiresAt: time.Now().Add(inviteTTL).UnixMilli(),
+	}
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rdb.Set(ctx, buildInviteKey(token), data, inviteTTL).Err(); err != nil {
+		return nil, err
+	}
+	userKey := buildUserInvitesKey(invitee.ID)
+	s.rdb.SAdd(ctx, userKey, token)
+	s.rdb.Expire(ctx, userKey, inviteTTL)
+
+	return &inv, nil
+}
+
+// PendingInvitations lists a user's unexpired invitations, pruning any tokens
+// whose backing key already expired.
+func (s *Service) PendingInvitations(ctx context.Context, userID int64) ([]Invitation, error) {
+	userKey := buildUserInvitesKey(userID)
+	tokens, err := s.rdb.SMembers(ctx, userKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	invitations := make([]Invitation, 0, len(tokens))
+	for _, token := range tokens {
+		data, getErr := s.rdb.Get(ctx, buildInviteKey(token)).Result()
+		if getErr != nil {
+			s.rdb.SRem(ctx, userKey, token)
+			continue
+		}
+		var inv Invitation
+		if jsonErr := json.Unmarshal([]byte(data), &inv); jsonErr != nil {
+			s.rdb.SRem(ctx, userKey, token)
+			continue
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, nil
+}
+
+// PendingInvitationsForUsers backs the lobby hub's periodic push of open
+// invitations to connected users.
+func (s *Service) PendingInvitationsForUsers(ctx context.Context, userIDs []int64) (map[int64][]Invitation, error) {
+	result := make(map[int64][]Invitation)
+	for _, userID := range userIDs {
+		invitations, err := s.PendingInvitations(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(invitations) > 0 {
+			result[userID] = invitations
+		}
+	}
+	return result, nil
+}
+
+// AcceptInvitation redeems a token and seats the invitee through the runtime
+// loop after the usual balance check.
+func (s *Service) AcceptInvitation(ctx context.Context, userID int64, token string) (*Invitation, error) {
+	data, err := s.rdb.Get(ctx, buildInviteKey(token)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, appErr.ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	var inv Invitation
+	if err := json.Unmarshal([]byte(data), &inv); err != nil {
+		return nil, appErr.ErrInvitationNotFound
+	}
+	if inv.InviteeID != userID {
+		return nil, appErr.ErrInvitationNotFound
+	}
+
+	var scene model.Scene
+	if err := s.db.WithContext(ctx).First(&scene, inv.SceneID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErr.ErrSceneNotFound
+		}
+		return nil, err
+	}
+
+	var wallet model.Wallet
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	if wallet.BalanceAvailable < scene.MinIn {
+		return nil, appErr.ErrInsufficientBalance
+	}
+
+	var user model.User
+	if err := s.db.WithContext(ctx).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErr.ErrUserNotFound
+		}
+		return nil, err
+	}
+	if scene.RequireVerification && user.VerifyStatus != "verified" {
+		return nil, appErr.ErrVerificationRequired
+	}
+
+	rt, err := s.GetRuntime(ctx, inv.TableID)
+	if err != nil {
+		return nil, err
+	}
+	if err := rt.SeatUser(userID, user.Nickname, scene.MinIn); err != nil {
+		return nil, err
+	}
+
+	s.rdb.Del(ctx, buildInviteKey(token))
+	s.rdb.SRem(ctx, buildUserInvitesKey(userID), token)
+	return &inv, nil
+}
+
+func (s *Service) resolveInvitee(ctx context.Context, userID int64, phone string) (*model.User, error) {
+	var user model.User
+	query := s.db.WithContext(ctx)
+	switch {
+	case userID != 0:
+		query = query.Where("id = ?", userID)
+	case phone != "":
+		query = query.Where("phone = ?", phone)
+	default:
+		return nil, appErr.ErrUserNotFound
+	}
+	if err := query.First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErr.ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.Status == "banned" {
+		return nil, appErr.ErrUserBanned
+	}
+	return &user, nil
+}
+
+func newInviteToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func buildInviteKey(token string) string {
+	return "table:invite:" + token
+}
+
+func buildUserInvitesKey(userID int64) string {
+	return fmt.Sprintf("table:invites:user:%d", userID)
+}
@@ -0,0 +1,155 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupLateJoinRuntime seats two players on a persisted 3-seat table so a
+// third can be topped up once the hand is over.
+func setupLateJoinRuntime(t *testing.T) (*gorm.DB, *TableRuntime) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}, &model.Match{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	scene := model.Scene{ID: 1, Name: "test", SeatCount: 3, BasePi: 10, MinUnitPi: 1}
+	table := model.Table{
+		ID:          1,
+		SceneID:     scene.ID,
+		SeatCount:   3,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("failed to create table row: %v", err)
+	}
+
+	rt, err := newTableRuntime(context.Background(), db, table, scene, 0, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(rt.stop)
+	return db, rt
+}
+
+func TestLateJoinerPlaysNextHand(t *testing.T) {
+	db, rt := setupLateJoinRuntime(t)
+
+	for _, userID := range []int64{101, 102} {
+		if err := rt.HandleAction(userID, "ready", nil); err != nil {
+			t.Fatalf("ready failed: %v", err)
+		}
+	}
+	if rt.phase != PhasePlaying {
+		t.Fatalf("expected playing phase, got %s", rt.phase)
+	}
+	// A live hand refuses the insert; the matcher requeues the player.
+	if err := rt.SeatUser(103, "late", 800); !errors.Is(err, appErr.ErrTableMidHand) {
+		t.Fatalf("expected ErrTableMidHand during the hand, got %v", err)
+	}
+
+	settleFixtureHand(t, rt)
+	rt.cmdCh <- loopCommand{kind: "next_match", matchID: 2}
+	for _, userID := range []int64{101, 102} {
+		if err := rt.HandleAction(userID, "ack_result", nil); err != nil {
+			t.Fatalf("ack_result failed: %v", err)
+		}
+	}
+	if rt.phase != PhaseWaiting {
+		t.Fatalf("expected waiting phase between hands, got %s", rt.phase)
+	}
+
+	if err := rt.SeatUser(103, "late", 800); err != nil {
+		t.Fatalf("late join between hands failed: %v", err)
+	}
+	if rt.seatByUser[103] != 3 {
+		t.Fatalf("expected the free seat 3, got %d", rt.seatByUser[103])
+	}
+	if err := rt.SeatUser(104, "overflow", 800); !errors.Is(err, appErr.ErrTableFull) {
+		t.Fatalf("expected ErrTableFull beyond the seat count, got %v", err)
+	}
+	var stored model.Table
+	if err := db.First(&stored, rt.tableID).Error; err != nil {
+		t.Fatalf("failed to reload table: %v", err)
+	}
+	if !strings.Contains(string(stored.PlayersJSON), `"userId":103`) {
+		t.Fatalf("late joiner missing from the persisted lineup: %s", stored.PlayersJSON)
+	}
+
+	// The newcomer readies up and antes into the next hand like everyone else.
+	for _, userID := range []int64{101, 102, 103} {
+		if err := rt.HandleAction(userID, "ready", nil); err != nil {
+			t.Fatalf("ready failed: %v", err)
+		}
+	}
+	if rt.phase != PhasePlaying {
+		t.Fatalf("expected the next hand running, got %s", rt.phase)
+	}
+	if rt.pot != 30 {
+		t.Fatalf("expected three antes of 10 in the pot, got %d", rt.pot)
+	}
+	for _, seat := range rt.seats {
+		if seat.UserID == 103 && (seat.Chips != 790 || seat.Bet != 10) {
+			t.Fatalf("late joiner's ante is off: chips %d bet %d", seat.Chips, seat.Bet)
+		}
+	}
+
+	// Folding the hand down settles zero-sum across all three stacks.
+	for i := 0; rt.phase == PhasePlaying && i < 4; i++ {
+		settleFixtureHandStep(t, rt)
+	}
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected hand ended, got %s", rt.phase)
+	}
+	var total int64
+	for _, seat := range rt.seats {
+		total += seat.Chips
+	}
+	if total != 2800 {
+		t.Fatalf("settlement must conserve chips, got total %d", total)
+	}
+}
+
+func TestSeatUserBroadcastsPlayerJoined(t *testing.T) {
+	ch := make(chan OutgoingMessage, 8)
+	rt := &TableRuntime{
+		phase:      PhaseWaiting,
+		seatCount:  3,
+		seatByUser: map[int64]int{101: 1, 102: 2},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Status: "waiting"},
+			{SeatIndex: 2, UserID: 102, Chips: 1000, Status: "waiting"},
+		},
+		subscribers: map[int64]chan OutgoingMessage{101: ch},
+	}
+
+	if err := rt.handleSeatUserLocked(seatRequest{UserID: 103, Alias: "late", Chips: 800}); err != nil {
+		t.Fatalf("seat user failed: %v", err)
+	}
+	msgs := drainMessages(ch)
+	if len(msgs) == 0 || msgs[0].Type != "player_joined" {
+		t.Fatalf("expected player_joined broadcast, got %+v", msgs)
+	}
+	payload, ok := msgs[0].Data.(ginH)
+	if !ok || payload["seat"] != 3 || payload["userId"] != int64(103) {
+		t.Fatalf("unexpected join payload: %#v", msgs[0].Data)
+	}
+}
@@ -0,0 +1,139 @@
+package game
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// Round logs are the authoritative record in a dispute, so each match keeps a
+// running HMAC over its rows: row i stores HMAC(key, chain[i-1] || actions_i)
+// under a key derived from the match ID. Settlement seals the final value
+// into Match.ResultJSON; editing, dropping or reordering any row afterwards
+// breaks the chain.
+
+// matchLogKey derives the per-match chain key, in the same spirit as the
+// per-user card keys.
+func matchLogKey(matchID int64) []byte {
+	sum := sha256.Sum256([]byte("match-log:" + strconv.FormatInt(matchID, 10)))
+	return sum[:]
+}
+
+// nextChainHMAC advances the chain by one row.
+func nextChainHMAC(matchID int64, prev string, actionsJSON []byte) string {
+	mac := hmac.New(sha256.New, matchLogKey(matchID))
+	mac.Write([]byte(prev))
+	mac.Write(actionsJSON)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// roundLogWriter drains queued rows on a single goroutine so inserts land in
+// chain order; independent per-row goroutines could overtake each other and
+// leave id order disagreeing with the chain.
+type roundLogWriter struct {
+	db *gorm.DB
+
+	mu      sync.Mutex
+	queue   []queuedRoundLog
+	writing bool
+}
+
+type queuedRoundLog struct {
+	ctx context.Context
+	row model.MatchRoundLog
+}
+
+func (w *roundLogWriter) enqueue(ctx context.Context, row model.MatchRoundLog) {
+	w.mu.Lock()
+	w.queue = append(w.queue, queuedRoundLog{ctx: ctx, row: row})
+	if w.writing {
+		w.mu.Unlock()
+		return
+	}
+	w.writing = true
+	w.mu.Unlock()
+	go w.drain()
+}
+
+func (w *roundLogWriter) drain() {
+	for {
+		w.mu.Lock()
+		if len(w.queue) == 0 {
+			w.writing = false
+			w.mu.Unlock()
+			return
+		}
+		item := w.queue[0]
+		w.queue = w.queue[1:]
+		w.mu.Unlock()
+		_ = w.db.WithContext(item.ctx).Create(&item.row).Error
+	}
+}
+
+// LogVerification reports whether a match's round logs still match their
+// HMAC chain and the seal written at settlement.
+type LogVerification struct {
+	MatchID int64 `json:"matchId,string"`
+	Rows    int   `json:"rows"`
+	Valid   bool  `json:"valid"`
+	// DivergedAtRow is the id of the first row whose stored HMAC disagrees
+	// with the recomputed chain; zero when every row checks out.
+	DivergedAtRow int64  `json:"divergedAtRow,omitempty"`
+	ComputedChain string `json:"computedChain,omitempty"`
+	// SealedChain is the value settlement wrote into Match.ResultJSON; empty
+	// for matches settled before chaining existed.
+	SealedChain string `json:"sealedChain,omitempty"`
+}
+
+// VerifyMatchLog recomputes the match's round-log HMAC chain in row order and
+// reports the first divergence, then checks the recomputed tail against the
+// seal in Match.ResultJSON.
+func (s *Service) VerifyMatchLog(ctx context.Context, matchID int64) (*LogVerification, error) {
+	var match model.Match
+	if err := s.db.WithContext(ctx).First(&match, matchID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErr.ErrMatchNotFound
+		}
+		return nil, err
+	}
+	var rows []model.MatchRoundLog
+	if err := s.db.WithContext(ctx).
+		Where("match_id = ?", matchID).
+		Order("id ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	report := &LogVerification{MatchID: matchID, Rows: len(rows), Valid: true}
+	chain := ""
+	for _, row := range rows {
+		chain = nextChainHMAC(matchID, chain, row.ActionsJSON)
+		if row.ChainHMAC != chain {
+			report.Valid = false
+			report.DivergedAtRow = row.ID
+			break
+		}
+	}
+	report.ComputedChain = chain
+
+	if len(match.ResultJSON) > 0 {
+		var env resultEnvelope
+		if json.Unmarshal(match.ResultJSON, &env) == nil {
+			report.SealedChain = env.LogChain
+		}
+	}
+	if report.Valid && report.SealedChain != "" && report.SealedChain != chain {
+		report.Valid = false
+	}
+	return report, nil
+}
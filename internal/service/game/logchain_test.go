@@ -0,0 +1,94 @@
+package game
+
+import (
+	"testing"
+
+	"dx-service/internal/model"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func logChainTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Match{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.Create(&model.Match{ID: 9, TableID: 1, SceneID: 1}).Error; err != nil {
+		t.Fatalf("seed match: %v", err)
+	}
+	return db
+}
+
+func persistThreeEntries(t *testing.T, db *gorm.DB) *TableRuntime {
+	t.Helper()
+	rt := &TableRuntime{db: db, matchID: 9}
+	rt.persistRoundLogLocked(actionEntry{Action: "call", Seat: 1})
+	rt.persistRoundLogLocked(actionEntry{Action: "raise", Seat: 2, Amount: 20})
+	rt.persistRoundLogLocked(actionEntry{Action: "fold", Seat: 3})
+	waitForRoundLogs(t, db, 3)
+	return rt
+}
+
+func TestMatchLogChainVerifies(t *testing.T) {
+	db := logChainTestDB(t)
+	rt := persistThreeEntries(t, db)
+	svc := &Service{db: db}
+
+	report, err := svc.VerifyMatchLog(t.Context(), 9)
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if !report.Valid || report.Rows != 3 || report.DivergedAtRow != 0 {
+		t.Fatalf("intact chain reported invalid: %+v", report)
+	}
+	if report.ComputedChain != rt.logChain {
+		t.Fatalf("computed chain %s differs from the runtime's %s", report.ComputedChain, rt.logChain)
+	}
+
+	// A correct settlement seal keeps the report valid; a wrong one fails it.
+	if err := db.Model(&model.Match{}).Where("id = ?", 9).
+		Update("result_json", mustJSON(resultEnvelope{LogChain: rt.logChain})).Error; err != nil {
+		t.Fatalf("seal match: %v", err)
+	}
+	if report, err = svc.VerifyMatchLog(t.Context(), 9); err != nil || !report.Valid {
+		t.Fatalf("sealed chain should verify: %+v err=%v", report, err)
+	}
+	if err := db.Model(&model.Match{}).Where("id = ?", 9).
+		Update("result_json", mustJSON(resultEnvelope{LogChain: "forged"})).Error; err != nil {
+		t.Fatalf("reseal match: %v", err)
+	}
+	if report, err = svc.VerifyMatchLog(t.Context(), 9); err != nil || report.Valid {
+		t.Fatalf("mismatched seal should fail verification: %+v err=%v", report, err)
+	}
+}
+
+func TestMatchLogTamperDetected(t *testing.T) {
+	db := logChainTestDB(t)
+	persistThreeEntries(t, db)
+
+	var rows []model.MatchRoundLog
+	if err := db.Order("id ASC").Find(&rows).Error; err != nil {
+		t.Fatalf("load rows: %v", err)
+	}
+	// Rewrite the middle row as if the raise had been smaller.
+	if err := db.Model(&model.MatchRoundLog{}).Where("id = ?", rows[1].ID).
+		Update("actions_json", []byte(`[{"action":"raise","seat":2,"amount":5}]`)).Error; err != nil {
+		t.Fatalf("tamper row: %v", err)
+	}
+
+	report, err := (&Service{db: db}).VerifyMatchLog(t.Context(), 9)
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if report.Valid {
+		t.Fatal("tampered log reported valid")
+	}
+	if report.DivergedAtRow != rows[1].ID {
+		t.Fatalf("expected divergence at row %d, got %d", rows[1].ID, report.DivergedAtRow)
+	}
+}
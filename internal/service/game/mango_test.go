@@ -0,0 +1,50 @@
+package game
+
+import "testing"
+
+func TestMangoStreakIncrementRecordsHistory(t *testing.T) {
+	rt := &TableRuntime{basePi: 10, round1Bet: true}
+
+	rt.applyMangoSettlementLocked(nil, false)
+
+	if rt.mangoStreak != 1 {
+		t.Fatalf("expected streak 1, got %d", rt.mangoStreak)
+	}
+	if len(rt.mangoHistory) != 1 {
+		t.Fatalf("expected one history event, got %d", len(rt.mangoHistory))
+	}
+	event := rt.mangoHistory[0]
+	if event.Trigger != "liuju" || event.Streak != 1 || event.Carried != 20 {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
+
+func TestMangoStreakCapsAtThree(t *testing.T) {
+	rt := &TableRuntime{basePi: 10, round1Bet: true, mangoStreak: 3}
+
+	rt.applyMangoSettlementLocked(nil, false)
+
+	if rt.mangoStreak != 3 {
+		t.Fatalf("expected streak capped at 3, got %d", rt.mangoStreak)
+	}
+	if len(rt.mangoHistory) != 0 {
+		t.Fatalf("expected no event when streak unchanged, got %d", len(rt.mangoHistory))
+	}
+}
+
+func TestMangoStreakResetsOnShowdown(t *testing.T) {
+	rt := &TableRuntime{basePi: 10, mangoStreak: 2, handNo: 4}
+
+	rt.applyMangoSettlementLocked(nil, true)
+
+	if rt.mangoStreak != 0 {
+		t.Fatalf("expected streak reset, got %d", rt.mangoStreak)
+	}
+	if len(rt.mangoHistory) != 1 {
+		t.Fatalf("expected one history event, got %d", len(rt.mangoHistory))
+	}
+	event := rt.mangoHistory[0]
+	if event.Trigger != "reset" || event.Streak != 0 || event.Carried != 0 || event.Hand != 4 {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
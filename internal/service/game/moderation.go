@@ -0,0 +1,283 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/utils/sensitive"
+)
+
+// chatFilter masks sensitive words in player-visible chat text before it is
+// delivered. The built-in list covers the usual cheating/abuse vocabulary;
+// a configurable list can replace it without touching the call sites.
+var chatFilter = sensitive.NewTrie([]string{
+	"外挂", "开挂", "代充", "刷分", "赌债", "上分", "下分",
+	"傻逼", "操你", "妈的", "去死",
+})
+
+// filterChatText masks sensitive words in chat text, leaving clean text
+// untouched.
+func filterChatText(text string) string {
+	masked, _ := chatFilter.Mask(text)
+	return masked
+}
+
+// ConfigureChatFilter swaps the built-in banned-word list for the one from
+// config. An empty list keeps the default vocabulary.
+func ConfigureChatFilter(words []string) {
+	if len(words) == 0 {
+		return
+	}
+	chatFilter = sensitive.NewTrie(words)
+}
+
+// Chat limits: maximum message length in runes and the minimum interval
+// between two messages from the same user.
+const (
+	chatMaxRunes = 200
+	chatCooldown = 2 * time.Second
+)
+
+// handleChatLocked broadcasts a player's chat line. Any seated user may chat
+// in any phase — folded players banter too — and nothing settlement-relevant
+// is touched. Admin mutes, the banned-word filter and the per-user rate
+// limit all apply before delivery.
+func (rt *TableRuntime) handleChatLocked(seatIdx int, userID int64, data json.RawMessage) error {
+	if err := rt.chatAllowedLocked(userID); err != nil {
+		return err
+	}
+	var payload struct {
+		Text string `json:"text"`
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("invalid chat payload: %v", err)
+		}
+	}
+	text := strings.TrimSpace(payload.Text)
+	if text == "" {
+		return fmt.Errorf("chat text is required")
+	}
+	if utf8.RuneCountInString(text) > chatMaxRunes {
+		return fmt.Errorf("chat text exceeds %d characters", chatMaxRunes)
+	}
+
+	now := rt.timeNow()
+	if last, ok := rt.lastChatAt[userID]; ok && now.Sub(last) < chatCooldown {
+		return fmt.Errorf("chatting too fast")
+	}
+	if rt.lastChatAt == nil {
+		rt.lastChatAt = make(map[int64]time.Time)
+	}
+	rt.lastChatAt[userID] = now
+
+	alias := ""
+	if seat := rt.findSeatLocked(seatIdx); seat != nil {
+		alias = seat.Alias
+	}
+	rt.deliverChatLocked(userID, OutgoingMessage{
+		Type: "chat",
+		Data: ginH{
+			"seatIndex":   seatIdx,
+			"senderAlias": alias,
+			"userId":      userID,
+			"text":        filterChatText(text),
+			"ts":          now.UnixMilli(),
+		},
+	})
+	return nil
+}
+
+// emoteWhitelist is the canned quick-phrase set clients may send; anything
+// outside it is rejected.
+var emoteWhitelist = map[string]bool{
+	"thumbs_up": true,
+	"hurry_up":  true,
+	"nice_hand": true,
+	"good_luck": true,
+	"laugh":     true,
+	"cry":       true,
+	"angry":     true,
+}
+
+// emoteCooldown is the minimum interval between two emotes from one user.
+const emoteCooldown = 3 * time.Second
+
+// handleEmoteLocked broadcasts a canned emote from a seated player. Purely
+// transient: nothing reaches the round log or settlement state. Admin mutes
+// cover emotes the same as free chat.
+func (rt *TableRuntime) handleEmoteLocked(seatIdx int, userID int64, data json.RawMessage) error {
+	if err := rt.chatAllowedLocked(userID); err != nil {
+		return err
+	}
+	var payload struct {
+		EmoteID string `json:"emoteId"`
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("invalid emote payload: %v", err)
+		}
+	}
+	if !emoteWhitelist[payload.EmoteID] {
+		return fmt.Errorf("unknown emote")
+	}
+
+	now := rt.timeNow()
+	if last, ok := rt.lastEmoteAt[userID]; ok && now.Sub(last) < emoteCooldown {
+		return fmt.Errorf("emoting too fast")
+	}
+	if rt.lastEmoteAt == nil {
+		rt.lastEmoteAt = make(map[int64]time.Time)
+	}
+	rt.lastEmoteAt[userID] = now
+
+	rt.deliverChatLocked(userID, OutgoingMessage{
+		Type: "emote",
+		Data: ginH{
+			"seatIndex": seatIdx,
+			"userId":    userID,
+			"emoteId":   payload.EmoteID,
+			"ts":        now.UnixMilli(),
+		},
+	})
+	return nil
+}
+
+// MuteInfo is one active admin mute in the exported state.
+type MuteInfo struct {
+	UserID int64 `json:"userId,string"`
+	Until  int64 `json:"until"` // unix millis
+}
+
+// muteRequest silences a user's chat at this table until the deadline; a
+// deadline in the past lifts an existing mute.
+type muteRequest struct {
+	UserID int64
+	Until  time.Time
+}
+
+// Mute applies an admin chat mute for the given duration; zero or negative
+// duration lifts the mute.
+func (rt *TableRuntime) Mute(userID int64, duration time.Duration) {
+	rt.cmdCh <- loopCommand{kind: "mute", mute: &muteRequest{UserID: userID, Until: rt.timeNow().Add(duration)}}
+}
+
+func (rt *TableRuntime) handleMuteLocked(req muteRequest) {
+	if rt.mutedUntil == nil {
+		rt.mutedUntil = make(map[int64]time.Time)
+	}
+	if req.Until.After(rt.timeNow()) {
+		rt.mutedUntil[req.UserID] = req.Until
+	} else {
+		delete(rt.mutedUntil, req.UserID)
+	}
+	rt.broadcastStateLocked()
+}
+
+// chatAllowedLocked rejects chat/emote actions from an admin-muted user.
+// Gameplay actions never consult it: a muted player still plays their hand.
+func (rt *TableRuntime) chatAllowedLocked(userID int64) error {
+	until, ok := rt.mutedUntil[userID]
+	if !ok {
+		return nil
+	}
+	if rt.timeNow().Before(until) {
+		return fmt.Errorf("%w: until %s", appErr.ErrMuted, until.Format(time.RFC3339))
+	}
+	delete(rt.mutedUntil, userID)
+	return nil
+}
+
+// handleMutePlayerLocked maintains the requester's personal ignore list. It
+// only affects what this subscriber sees; the muted player's actions and
+// everyone else's view are untouched.
+func (rt *TableRuntime) handleMutePlayerLocked(userID int64, data json.RawMessage) error {
+	var payload struct {
+		UserID int64 `json:"userId"`
+		Muted  *bool `json:"muted"`
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("invalid mute payload: %v", err)
+		}
+	}
+	if payload.UserID == 0 || payload.UserID == userID {
+		return fmt.Errorf("invalid mute target")
+	}
+
+	muted := true
+	if payload.Muted != nil {
+		muted = *payload.Muted
+	}
+	if muted {
+		if rt.localMutes == nil {
+			rt.localMutes = make(map[int64]map[int64]bool)
+		}
+		if rt.localMutes[userID] == nil {
+			rt.localMutes[userID] = make(map[int64]bool)
+		}
+		rt.localMutes[userID][payload.UserID] = true
+	} else if set, ok := rt.localMutes[userID]; ok {
+		delete(set, payload.UserID)
+		if len(set) == 0 {
+			delete(rt.localMutes, userID)
+		}
+	}
+	rt.pushStateLocked(userID)
+	return nil
+}
+
+// deliverChatLocked fans a chat-style message out to every subscriber that
+// has not locally muted the sender.
+func (rt *TableRuntime) deliverChatLocked(fromUserID int64, msg OutgoingMessage) {
+	for uid := range rt.subscribers {
+		if rt.localMutedLocked(uid, fromUserID) {
+			continue
+		}
+		rt.sendLocked(uid, msg)
+	}
+}
+
+func (rt *TableRuntime) localMutedLocked(viewerID, fromUserID int64) bool {
+	set, ok := rt.localMutes[viewerID]
+	return ok && set[fromUserID]
+}
+
+// activeMutesLocked lists admin mutes still in force, expiring stale ones as
+// a side effect so the export never shows a lapsed mute.
+func (rt *TableRuntime) activeMutesLocked() []MuteInfo {
+	if len(rt.mutedUntil) == 0 {
+		return nil
+	}
+	now := rt.timeNow()
+	mutes := make([]MuteInfo, 0, len(rt.mutedUntil))
+	for uid, until := range rt.mutedUntil {
+		if !now.Before(until) {
+			delete(rt.mutedUntil, uid)
+			continue
+		}
+		mutes = append(mutes, MuteInfo{UserID: uid, Until: until.UnixMilli()})
+	}
+	sort.Slice(mutes, func(i, j int) bool { return mutes[i].UserID < mutes[j].UserID })
+	return mutes
+}
+
+// localMutesForLocked lists the users this viewer has muted, for the state
+// export.
+func (rt *TableRuntime) localMutesForLocked(userID int64) []int64 {
+	set := rt.localMutes[userID]
+	if len(set) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(set))
+	for uid := range set {
+		ids = append(ids, uid)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,240 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	appErr "dx-service/pkg/errors"
+)
+
+func moderationRuntime() *TableRuntime {
+	return &TableRuntime{
+		phase:      PhasePlaying,
+		seatByUser: map[int64]int{101: 1, 102: 2},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 1000, Status: "playing"},
+		},
+		subscribers: map[int64]chan OutgoingMessage{
+			101: make(chan OutgoingMessage, 8),
+			102: make(chan OutgoingMessage, 8),
+		},
+	}
+}
+
+func drainMessages(ch chan OutgoingMessage) []OutgoingMessage {
+	var msgs []OutgoingMessage
+	for {
+		select {
+		case msg := <-ch:
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestAdminMuteBlocksChatUntilExpiry(t *testing.T) {
+	rt := moderationRuntime()
+	now := time.Now()
+	rt.now = func() time.Time { return now }
+
+	rt.handleMuteLocked(muteRequest{UserID: 101, Until: now.Add(time.Minute)})
+	if err := rt.chatAllowedLocked(101); !errors.Is(err, appErr.ErrMuted) {
+		t.Fatalf("expected ErrMuted, got %v", err)
+	}
+	if err := rt.chatAllowedLocked(102); err != nil {
+		t.Fatalf("unmuted user blocked: %v", err)
+	}
+
+	// Past the deadline the mute lapses and is cleaned up.
+	now = now.Add(2 * time.Minute)
+	if err := rt.chatAllowedLocked(101); err != nil {
+		t.Fatalf("expired mute still blocks: %v", err)
+	}
+	if _, ok := rt.mutedUntil[101]; ok {
+		t.Fatal("expired mute not removed")
+	}
+}
+
+func TestAdminMuteAppearsInStateExport(t *testing.T) {
+	rt := moderationRuntime()
+	now := time.Now()
+	rt.now = func() time.Time { return now }
+	until := now.Add(time.Minute)
+
+	rt.handleMuteLocked(muteRequest{UserID: 101, Until: until})
+	state := rt.exportStateLocked(102)
+	if len(state.Mutes) != 1 || state.Mutes[0].UserID != 101 || state.Mutes[0].Until != until.UnixMilli() {
+		t.Fatalf("unexpected mutes in export: %+v", state.Mutes)
+	}
+
+	// Lifting the mute clears the export.
+	rt.handleMuteLocked(muteRequest{UserID: 101, Until: now})
+	state = rt.exportStateLocked(102)
+	if len(state.Mutes) != 0 {
+		t.Fatalf("lifted mute still exported: %+v", state.Mutes)
+	}
+}
+
+func TestMutePlayerFiltersChatDelivery(t *testing.T) {
+	rt := moderationRuntime()
+
+	if err := rt.handleMutePlayerLocked(101, json.RawMessage(`{"userId":102}`)); err != nil {
+		t.Fatalf("mute player: %v", err)
+	}
+	drainMessages(rt.subscribers[101]) // state push from the mute itself
+
+	chat := OutgoingMessage{Type: "chat", Data: ginH{"from": int64(102), "text": "hi"}}
+	rt.deliverChatLocked(102, chat)
+
+	if msgs := drainMessages(rt.subscribers[101]); len(msgs) != 0 {
+		t.Fatalf("muted sender still delivered to viewer: %+v", msgs)
+	}
+	msgs := drainMessages(rt.subscribers[102])
+	if len(msgs) != 1 || msgs[0].Type != "chat" {
+		t.Fatalf("other subscriber should receive chat, got %+v", msgs)
+	}
+
+	state := rt.exportStateLocked(101)
+	if len(state.LocalMutes) != 1 || state.LocalMutes[0] != 102 {
+		t.Fatalf("local mute missing from export: %+v", state.LocalMutes)
+	}
+
+	// Unmute restores delivery and clears the export.
+	if err := rt.handleMutePlayerLocked(101, json.RawMessage(`{"userId":102,"muted":false}`)); err != nil {
+		t.Fatalf("unmute player: %v", err)
+	}
+	drainMessages(rt.subscribers[101])
+	rt.deliverChatLocked(102, chat)
+	if msgs := drainMessages(rt.subscribers[101]); len(msgs) != 1 {
+		t.Fatalf("unmuted sender not delivered: %+v", msgs)
+	}
+	if state := rt.exportStateLocked(101); len(state.LocalMutes) != 0 {
+		t.Fatalf("cleared local mute still exported: %+v", state.LocalMutes)
+	}
+}
+
+func TestMutePlayerRejectsSelfAndMissingTarget(t *testing.T) {
+	rt := moderationRuntime()
+
+	if err := rt.handleMutePlayerLocked(101, json.RawMessage(`{"userId":101}`)); err == nil {
+		t.Fatal("expected self-mute to be rejected")
+	}
+	if err := rt.handleMutePlayerLocked(101, nil); err == nil {
+		t.Fatal("expected missing target to be rejected")
+	}
+}
+
+func TestChatActionBroadcastsToSeatedUsers(t *testing.T) {
+	rt := moderationRuntime()
+	now := time.Now()
+	rt.now = func() time.Time { return now }
+	rt.seats[0].Alias = "玩家一"
+	rt.seats[0].Status = "folded"
+	rt.phase = PhaseEnded
+
+	// Folded seats on an ended hand still chat.
+	if err := rt.handleActionLocked(101, "chat", json.RawMessage(`{"text":"好牌"}`)); err != nil {
+		t.Fatalf("chat failed: %v", err)
+	}
+	msgs := drainMessages(rt.subscribers[102])
+	if len(msgs) != 1 || msgs[0].Type != "chat" {
+		t.Fatalf("expected one chat broadcast, got %+v", msgs)
+	}
+	data := msgs[0].Data.(ginH)
+	if data["senderAlias"] != "玩家一" || data["seatIndex"] != 1 || data["text"] != "好牌" {
+		t.Fatalf("unexpected chat payload: %+v", data)
+	}
+
+	// Non-seated users cannot chat.
+	if err := rt.handleActionLocked(999, "chat", json.RawMessage(`{"text":"hi"}`)); err != appErr.ErrTableAccessDenied {
+		t.Fatalf("expected ErrTableAccessDenied for a spectator, got %v", err)
+	}
+}
+
+func TestChatActionRateAndLengthLimits(t *testing.T) {
+	rt := moderationRuntime()
+	now := time.Now()
+	rt.now = func() time.Time { return now }
+
+	if err := rt.handleActionLocked(101, "chat", json.RawMessage(`{"text":"one"}`)); err != nil {
+		t.Fatalf("first chat failed: %v", err)
+	}
+	if err := rt.handleActionLocked(101, "chat", json.RawMessage(`{"text":"two"}`)); err == nil {
+		t.Fatal("expected rate limit inside the cooldown")
+	}
+	now = now.Add(chatCooldown)
+	if err := rt.handleActionLocked(101, "chat", json.RawMessage(`{"text":"two"}`)); err != nil {
+		t.Fatalf("chat after cooldown failed: %v", err)
+	}
+
+	long, _ := json.Marshal(map[string]string{"text": strings.Repeat("字", chatMaxRunes+1)})
+	now = now.Add(chatCooldown)
+	if err := rt.handleActionLocked(101, "chat", long); err == nil {
+		t.Fatal("expected over-long chat rejected")
+	}
+	if err := rt.handleActionLocked(101, "chat", json.RawMessage(`{"text":"  "}`)); err == nil {
+		t.Fatal("expected blank chat rejected")
+	}
+}
+
+func TestEmoteActionBroadcastsWhitelistedID(t *testing.T) {
+	rt := moderationRuntime()
+	now := time.Now()
+	rt.now = func() time.Time { return now }
+
+	if err := rt.handleActionLocked(101, "emote", json.RawMessage(`{"emoteId":"thumbs_up"}`)); err != nil {
+		t.Fatalf("emote failed: %v", err)
+	}
+	msgs := drainMessages(rt.subscribers[102])
+	if len(msgs) != 1 || msgs[0].Type != "emote" {
+		t.Fatalf("expected one emote broadcast, got %+v", msgs)
+	}
+	data := msgs[0].Data.(ginH)
+	if data["emoteId"] != "thumbs_up" || data["seatIndex"] != 1 {
+		t.Fatalf("unexpected emote payload: %+v", data)
+	}
+
+	// Anything off the whitelist is rejected, as are non-seated users.
+	now = now.Add(emoteCooldown)
+	if err := rt.handleActionLocked(101, "emote", json.RawMessage(`{"emoteId":"dance"}`)); err == nil {
+		t.Fatal("expected unknown emote rejected")
+	}
+	if err := rt.handleActionLocked(999, "emote", json.RawMessage(`{"emoteId":"thumbs_up"}`)); err != appErr.ErrTableAccessDenied {
+		t.Fatalf("expected ErrTableAccessDenied for a spectator, got %v", err)
+	}
+}
+
+func TestEmoteActionCooldown(t *testing.T) {
+	rt := moderationRuntime()
+	now := time.Now()
+	rt.now = func() time.Time { return now }
+
+	if err := rt.handleActionLocked(101, "emote", json.RawMessage(`{"emoteId":"hurry_up"}`)); err != nil {
+		t.Fatalf("first emote failed: %v", err)
+	}
+	if err := rt.handleActionLocked(101, "emote", json.RawMessage(`{"emoteId":"hurry_up"}`)); err == nil {
+		t.Fatal("expected cooldown inside the interval")
+	}
+	// The cooldown is per user: another seat emotes freely.
+	if err := rt.handleActionLocked(102, "emote", json.RawMessage(`{"emoteId":"laugh"}`)); err != nil {
+		t.Fatalf("other user's emote failed: %v", err)
+	}
+	now = now.Add(emoteCooldown)
+	if err := rt.handleActionLocked(101, "emote", json.RawMessage(`{"emoteId":"hurry_up"}`)); err != nil {
+		t.Fatalf("emote after cooldown failed: %v", err)
+	}
+}
+
+func TestFilterChatTextMasksSensitiveWords(t *testing.T) {
+	if got := filterChatText("你开挂了"); got != "你**了" {
+		t.Fatalf("unexpected filtered text: %q", got)
+	}
+	if got := filterChatText("好牌"); got != "好牌" {
+		t.Fatalf("clean text changed: %q", got)
+	}
+}
@@ -0,0 +1,262 @@
+package game
+
+import (
+	"context"
+	"testing"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func settleFixtureHand(t *testing.T, rt *TableRuntime) {
+	t.Helper()
+	actor := rt.turnSeat
+	var actorUser int64
+	for _, seat := range rt.seats {
+		if seat.SeatIndex == actor {
+			actorUser = seat.UserID
+		}
+	}
+	if err := rt.HandleAction(actorUser, "fold", nil); err != nil {
+		t.Fatalf("fold failed: %v", err)
+	}
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected hand ended after fold, got %s", rt.phase)
+	}
+}
+
+func TestNextMatchResetsSettledRuntime(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+	startTimeBankHand(t, rt)
+	settleFixtureHand(t, rt)
+	bankerBefore := rt.bankerSeat
+
+	rt.cmdCh <- loopCommand{kind: "next_match", matchID: 42}
+	if err := rt.HandleAction(101, "ping", nil); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected reset held by the closing window, got %s", rt.phase)
+	}
+
+	// Both seats acking the result ends the closing window early and
+	// releases the held next_match.
+	if err := rt.HandleAction(101, "ack_result", nil); err != nil {
+		t.Fatalf("ack_result failed: %v", err)
+	}
+	if err := rt.HandleAction(102, "ack_result", nil); err != nil {
+		t.Fatalf("ack_result failed: %v", err)
+	}
+
+	if rt.phase != PhaseWaiting {
+		t.Fatalf("expected waiting phase for the next hand, got %s", rt.phase)
+	}
+	if rt.matchID != 42 {
+		t.Fatalf("expected runtime on match 42, got %d", rt.matchID)
+	}
+	if rt.bankerSeat == bankerBefore {
+		t.Fatalf("expected banker rotated off seat %d", bankerBefore)
+	}
+	var chips int64
+	for _, seat := range rt.seats {
+		if seat.Ready {
+			t.Fatalf("seat %d still ready from the previous hand", seat.SeatIndex)
+		}
+		chips += seat.Chips
+	}
+	if chips != 2000 {
+		t.Fatalf("chips must carry over, got total %d", chips)
+	}
+
+	// The same players ready up for hand two without re-queueing.
+	startTimeBankHand(t, rt)
+	if rt.handNo != 2 {
+		t.Fatalf("expected hand 2 on the same table, got %d", rt.handNo)
+	}
+}
+
+func TestNextMatchIgnoredMidHand(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+	startTimeBankHand(t, rt)
+
+	rt.cmdCh <- loopCommand{kind: "next_match", matchID: 99}
+	if err := rt.HandleAction(101, "ping", nil); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+
+	if rt.phase != PhasePlaying {
+		t.Fatalf("live hand must keep playing, got %s", rt.phase)
+	}
+	if rt.matchID == 99 {
+		t.Fatal("a live hand must not switch matches")
+	}
+}
+
+func TestNextStackedSeatSkipsBustedSeats(t *testing.T) {
+	rt := &TableRuntime{
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 500, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 0, Status: "playing"},
+			{SeatIndex: 3, UserID: 103, Chips: 500, Status: "folded"},
+		},
+	}
+	if got := rt.nextStackedSeatLocked(1); got != 3 {
+		t.Fatalf("banker should skip the busted seat, got %d", got)
+	}
+
+	rt.seats[2].Status = "eliminated"
+	if got := rt.nextStackedSeatLocked(1); got != 1 {
+		t.Fatalf("with no other stacked seat the banker stays, got %d", got)
+	}
+}
+
+// setupBankerRuntime seats three players on a persisted table row so banker
+// rotation can be checked against both the runtime and the stored column.
+func setupBankerRuntime(t *testing.T) (*gorm.DB, *TableRuntime) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}, &model.Match{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	scene := model.Scene{ID: 1, Name: "test", SeatCount: 3, BasePi: 10, MinUnitPi: 1}
+	table := model.Table{
+		ID:          1,
+		SceneID:     scene.ID,
+		SeatCount:   3,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000},"3":{"userId":103,"chips":1000}}`),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("failed to create table row: %v", err)
+	}
+
+	rt, err := newTableRuntime(context.Background(), db, table, scene, 0, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(rt.stop)
+	return db, rt
+}
+
+// playBankerHand readies all three seats and folds the hand down to a winner.
+func playBankerHand(t *testing.T, rt *TableRuntime) {
+	t.Helper()
+	for _, userID := range []int64{101, 102, 103} {
+		if err := rt.HandleAction(userID, "ready", nil); err != nil {
+			t.Fatalf("ready failed: %v", err)
+		}
+	}
+	if rt.phase != PhasePlaying {
+		t.Fatalf("expected playing phase, got %s", rt.phase)
+	}
+	for i := 0; rt.phase == PhasePlaying && i < 4; i++ {
+		settleFixtureHandStep(t, rt)
+	}
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected hand ended, got %s", rt.phase)
+	}
+}
+
+// settleFixtureHandStep folds whichever seat owns the turn.
+func settleFixtureHandStep(t *testing.T, rt *TableRuntime) {
+	t.Helper()
+	var actorUser int64
+	for _, seat := range rt.seats {
+		if seat.SeatIndex == rt.turnSeat {
+			actorUser = seat.UserID
+		}
+	}
+	if err := rt.HandleAction(actorUser, "fold", nil); err != nil {
+		t.Fatalf("fold failed: %v", err)
+	}
+}
+
+func TestBankerRotationOrderAcrossHands(t *testing.T) {
+	db, rt := setupBankerRuntime(t)
+
+	order := []int{rt.bankerSeat}
+	for hand := 0; hand < 2; hand++ {
+		playBankerHand(t, rt)
+		rt.cmdCh <- loopCommand{kind: "next_match", matchID: int64(hand + 2)}
+		for _, userID := range []int64{101, 102, 103} {
+			if err := rt.HandleAction(userID, "ack_result", nil); err != nil {
+				t.Fatalf("ack_result failed: %v", err)
+			}
+		}
+		if rt.phase != PhaseWaiting {
+			t.Fatalf("expected waiting phase after next_match, got %s", rt.phase)
+		}
+		order = append(order, rt.bankerSeat)
+	}
+	// The third hand plays from the twice-rotated button.
+	playBankerHand(t, rt)
+
+	if order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Fatalf("expected rotation order 1,2,3, got %v", order)
+	}
+	if got := rt.exportStateLocked(101).BankerSeat; got != rt.bankerSeat {
+		t.Fatalf("state must expose the banker seat, got %d want %d", got, rt.bankerSeat)
+	}
+
+	var stored model.Table
+	if err := db.First(&stored, rt.tableID).Error; err != nil {
+		t.Fatalf("failed to reload table: %v", err)
+	}
+	if stored.BankerSeat != rt.bankerSeat {
+		t.Fatalf("expected banker %d persisted, got %d", rt.bankerSeat, stored.BankerSeat)
+	}
+
+	// A rebuilt runtime resumes the rotation from the stored column.
+	rebuilt, err := buildTableRuntime(nil, stored, model.Scene{ID: 1, Name: "test", SeatCount: 3, BasePi: 10, MinUnitPi: 1}, 0, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to rebuild runtime: %v", err)
+	}
+	if rebuilt.bankerSeat != stored.BankerSeat {
+		t.Fatalf("restart must keep the banker, got %d want %d", rebuilt.bankerSeat, stored.BankerSeat)
+	}
+}
+
+func TestClosingWindowReleasesNextMatchOnDeadline(t *testing.T) {
+	ch := make(chan OutgoingMessage, 32)
+	rt := &TableRuntime{
+		phase:      PhaseEnded,
+		seatByUser: map[int64]int{101: 1, 102: 2},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 1000, Status: "playing"},
+		},
+		subscribers: map[int64]chan OutgoingMessage{101: ch},
+	}
+
+	rt.beginClosingWindowLocked()
+	msgs := drainMessages(ch)
+	if len(msgs) == 0 || msgs[0].Type != "table_closing" {
+		t.Fatalf("expected table_closing broadcast, got %+v", msgs)
+	}
+	payload, ok := msgs[0].Data.(ginH)
+	if !ok || payload["countdown"].(int) <= 0 {
+		t.Fatalf("expected positive countdown in payload, got %#v", msgs[0].Data)
+	}
+
+	rt.handleNextMatchLocked(42)
+	if rt.phase != PhaseEnded || rt.pendingMatch != 42 {
+		t.Fatalf("expected next match held by the window, phase %s pending %d", rt.phase, rt.pendingMatch)
+	}
+
+	// The loop routes the timer here once the window deadline passes.
+	rt.handleTurnTimeoutLocked()
+	if rt.phase != PhaseWaiting || rt.matchID != 42 {
+		t.Fatalf("expected held match applied after the window, phase %s match %d", rt.phase, rt.matchID)
+	}
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"context"
+
+	"dx-service/internal/service/features"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// observerSub is a privileged live-view subscription. Observers receive the
+// same broadcast stream as players but are kept out of the subscribers map so
+// allowedActions/MyCards logic never mistakes an admin ID for a seated user.
+type observerSub struct {
+	ch        chan OutgoingMessage
+	showCards bool
+}
+
+// Observe attaches an admin as a privileged observer and returns their
+// message channel, or nil when the runtime has already stopped.
+func (rt *TableRuntime) Observe(adminID int64, showCards bool) chan OutgoingMessage {
+	ch := make(chan OutgoingMessage, 8)
+	resp := make(chan error, 1)
+	rt.cmdCh <- loopCommand{kind: "observe", userID: adminID, subCh: ch, showCards: showCards, resp: resp}
+	if err := <-resp; err != nil {
+		close(ch)
+		return nil
+	}
+	return ch
+}
+
+// Unobserve detaches an admin observer and closes their channel.
+func (rt *TableRuntime) Unobserve(adminID int64) {
+	resp := make(chan error, 1)
+	rt.cmdCh <- loopCommand{kind: "unobserve", userID: adminID, resp: resp}
+	<-resp
+}
+
+func (rt *TableRuntime) handleObserveLocked(adminID int64, ch chan OutgoingMessage, showCards bool) {
+	if old, ok := rt.observers[adminID]; ok {
+		close(old.ch)
+	}
+	rt.observers[adminID] = &observerSub{ch: ch, showCards: showCards}
+	rt.pushObserverLocked(adminID, OutgoingMessage{
+		Type: "state",
+		Seq:  rt.nextSeqLocked(),
+		Data: rt.exportObserverStateLocked(showCards),
+	})
+}
+
+func (rt *TableRuntime) handleUnobserveLocked(adminID int64) {
+	if sub, ok := rt.observers[adminID]; ok {
+		delete(rt.observers, adminID)
+		close(sub.ch)
+	}
+}
+
+// exportObserverStateLocked is the observer variant of exportStateLocked: no
+// seat-relative fields, and with showCards every seat's hole cards exposed.
+func (rt *TableRuntime) exportObserverStateLocked(showCards bool) TableState {
+	state := rt.exportStateLocked(0)
+	if !showCards {
+		return state
+	}
+	for i, s := range rt.seats {
+		if len(s.cards) == 0 {
+			continue
+		}
+		cards := make([]string, len(s.cards))
+		for k, c := range s.cards {
+			cards[k] = rt.variantLocked().DisplayCard(c)
+		}
+		state.Seats[i].Cards = cards
+	}
+	return state
+}
+
+// pushObserverLocked delivers one message to a single observer. Observers are
+// never subject to the spectator delay: support needs the real-time view.
+func (rt *TableRuntime) pushObserverLocked(adminID int64, msg OutgoingMessage) {
+	sub, ok := rt.observers[adminID]
+	if !ok {
+		return
+	}
+	select {
+	case sub.ch <- msg:
+	default:
+		logger.Log.Warn("ws observer channel full", zap.Int64("adminID", adminID), zap.Int64("tableID", rt.tableID))
+	}
+}
+
+// CanViewLiveCards reports whether an admin may see unredacted per-seat cards
+// in the live table view.
+func (s *Service) CanViewLiveCards(ctx context.Context, adminID int64) bool {
+	if s.features == nil {
+		return false
+	}
+	return s.features.Enabled(ctx, features.FlagAdminLiveCards, features.Subject{UserID: adminID})
+}
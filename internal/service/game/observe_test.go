@@ -0,0 +1,56 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestObserverStateExposesCardsOnlyWhenPrivileged(t *testing.T) {
+	rt := potCapRuntime(80, 100)
+	rt.seats[0].cards = []string{"AS", "KD"}
+	rt.seats[1].cards = []string{"2C", "7H"}
+
+	privileged := rt.exportObserverStateLocked(true)
+	if len(privileged.Seats[0].Cards) != 2 || privileged.Seats[0].Cards[0] != "AS" {
+		t.Fatalf("expected unredacted cards for privileged observer, got %v", privileged.Seats[0].Cards)
+	}
+
+	plain := rt.exportObserverStateLocked(false)
+	if len(plain.Seats[0].Cards) != 0 {
+		t.Fatalf("expected no cards without the permission, got %v", plain.Seats[0].Cards)
+	}
+	if len(plain.MyCards) != 0 || len(plain.AllowedActions) != 0 {
+		t.Fatal("observer state must not carry seat-relative fields")
+	}
+
+	// A player's own export must never pick up the observer-only field.
+	player := rt.exportStateLocked(101)
+	for _, seat := range player.Seats {
+		if len(seat.Cards) != 0 {
+			t.Fatalf("player export leaked cards: %v", seat.Cards)
+		}
+	}
+}
+
+func TestObserverReceivesBroadcasts(t *testing.T) {
+	rt := potCapRuntime(80, 100)
+	rt.observers = make(map[int64]*observerSub)
+	ch := make(chan OutgoingMessage, 8)
+	rt.handleObserveLocked(9, ch, false)
+
+	// The subscribe handshake pushes an initial state frame.
+	first := <-ch
+	if first.Type != "state" {
+		t.Fatalf("expected initial state frame, got %q", first.Type)
+	}
+
+	rt.broadcastMessageLocked(OutgoingMessage{Type: "player_action", Seq: rt.nextSeqLocked()})
+	msg := <-ch
+	if msg.Type != "player_action" {
+		t.Fatalf("expected broadcast relayed to observer, got %q", msg.Type)
+	}
+
+	rt.handleUnobserveLocked(9)
+	if _, open := <-ch; open {
+		t.Fatal("expected observer channel closed after unobserve")
+	}
+}
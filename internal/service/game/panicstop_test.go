@@ -0,0 +1,133 @@
+package game
+
+import (
+	"context"
+	"testing"
+
+	"dx-service/internal/model"
+	"dx-service/internal/service/delivery"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type panicFlag struct{ on bool }
+
+func (p *panicFlag) Active(context.Context, int64) bool { return p.on }
+
+func newPanicSettleService(t *testing.T) (*gorm.DB, *Service, *delivery.Service, *panicFlag) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&model.Match{}, &model.Scene{}, &model.Table{}, &model.Wallet{}, &model.BillingLog{},
+		&model.AgentRule{}, &model.AgentProfitLog{}, &model.MatchRake{},
+		&model.DeliveryFailure{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	deliverySvc := delivery.NewService(db)
+	flag := &panicFlag{}
+	svc := &Service{db: db}
+	svc.SetDeadLetter(deliverySvc)
+	svc.SetPanicChecker(flag)
+	deliverySvc.RegisterHandler(delivery.TypeSettlement, svc.ReplaySettlement)
+	return db, svc, deliverySvc, flag
+}
+
+func TestPanicStopDefersSettlementExactlyOnce(t *testing.T) {
+	db, svc, deliverySvc, flag := newPanicSettleService(t)
+	ctx := context.Background()
+
+	if err := db.Create(&model.Scene{ID: 1, Name: "test", SeatCount: 2}).Error; err != nil {
+		t.Fatalf("seed scene: %v", err)
+	}
+	match := model.Match{TableID: 1, SceneID: 1}
+	if err := db.Create(&match).Error; err != nil {
+		t.Fatalf("seed match: %v", err)
+	}
+
+	req := SettlementRequest{
+		MatchID: match.ID,
+		SceneID: 1,
+		Results: []PlayerResult{
+			{UserID: 201, NetPoints: 100},
+			{UserID: 202, NetPoints: -100},
+		},
+	}
+
+	// During the panic the settlement is parked, not applied.
+	flag.on = true
+	if err := svc.SettleMatch(ctx, req); err != nil {
+		t.Fatalf("settle during panic should defer, got %v", err)
+	}
+	var reloaded model.Match
+	if err := db.First(&reloaded, match.ID).Error; err != nil {
+		t.Fatalf("reload match: %v", err)
+	}
+	if reloaded.EndedAt != nil {
+		t.Fatal("deferred settlement must not close the match")
+	}
+	var wallets int64
+	if err := db.Model(&model.Wallet{}).Count(&wallets).Error; err != nil {
+		t.Fatalf("count wallets: %v", err)
+	}
+	if wallets != 0 {
+		t.Fatal("deferred settlement must not touch wallets")
+	}
+	var parked int64
+	if err := db.Model(&model.DeliveryFailure{}).Where("type = ?", delivery.TypeSettlement).Count(&parked).Error; err != nil {
+		t.Fatalf("count deferred: %v", err)
+	}
+	if parked != 1 {
+		t.Fatalf("expected 1 deferred settlement, got %d", parked)
+	}
+
+	// Resume drains the queue and the settlement applies.
+	flag.on = false
+	drained, err := deliverySvc.RetryBatch(ctx, delivery.TypeSettlement, 1)
+	if err != nil {
+		t.Fatalf("drain failed: %v", err)
+	}
+	if drained.Replayed != 1 || drained.Failed != 0 {
+		t.Fatalf("unexpected drain result: %+v", drained)
+	}
+	if err := db.First(&reloaded, match.ID).Error; err != nil {
+		t.Fatalf("reload match: %v", err)
+	}
+	if reloaded.EndedAt == nil {
+		t.Fatal("drained settlement did not close the match")
+	}
+	var winner model.Wallet
+	if err := db.First(&winner, "user_id = ?", int64(201)).Error; err != nil {
+		t.Fatalf("load winner wallet: %v", err)
+	}
+	if winner.BalanceAvailable != 100 {
+		t.Fatalf("expected winner credited 100, got %d", winner.BalanceAvailable)
+	}
+
+	// A second drain finds nothing, and replaying the payload by hand hits
+	// the already-settled guard without double-crediting.
+	drained, err = deliverySvc.RetryBatch(ctx, delivery.TypeSettlement, 1)
+	if err != nil || drained.Replayed != 0 || drained.Failed != 0 {
+		t.Fatalf("second drain should be empty, got %+v err %v", drained, err)
+	}
+	if err := svc.SettleMatch(ctx, req); err == nil {
+		t.Fatal("direct re-settle should hit the already-settled guard")
+	}
+	if err := db.First(&winner, "user_id = ?", int64(201)).Error; err != nil {
+		t.Fatalf("reload winner wallet: %v", err)
+	}
+	if winner.BalanceAvailable != 100 {
+		t.Fatalf("settlement applied more than once: %d", winner.BalanceAvailable)
+	}
+}
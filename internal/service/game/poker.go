@@ -70,7 +70,7 @@ func EvaluateHand(cards []string) int64 {
 	for i, c := range cards {
 		parsed[i] = parseCard(c)
 	}
-	
+
 	// Sort descending by rank
 	sort.Slice(parsed, func(i, j int) bool {
 		return parsed[i].RankValue > parsed[j].RankValue
@@ -94,4 +94,3 @@ func EvaluateHand(cards []string) int64 {
 	}
 	return score
 }
-
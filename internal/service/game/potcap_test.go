@@ -0,0 +1,97 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func potCapRuntime(pot, maxPot int64) *TableRuntime {
+	return &TableRuntime{
+		basePi:     10,
+		minUnitPi:  1,
+		maxPot:     maxPot,
+		phase:      PhasePlaying,
+		round:      1,
+		turnSeat:   1,
+		pot:        pot,
+		lastRaise:  10,
+		roundActed: map[int]bool{2: true},
+		seatByUser: map[int64]int{101: 1, 102: 2},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Bet: 10, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 1000, Bet: 10, Status: "playing"},
+		},
+	}
+}
+
+func raiseData(t *testing.T, amount int64) json.RawMessage {
+	t.Helper()
+	return json.RawMessage(fmt.Sprintf(`{"amount":%d}`, amount))
+}
+
+func TestRaiseExactlyToCapAllowed(t *testing.T) {
+	rt := potCapRuntime(80, 100)
+
+	// Raising to 30 adds exactly the remaining headroom of 20.
+	if err := rt.handleRaiseLocked(1, raiseData(t, 30)); err != nil {
+		t.Fatalf("exact-cap raise rejected: %v", err)
+	}
+	if rt.pot != 100 {
+		t.Fatalf("expected pot at cap, got %d", rt.pot)
+	}
+	if !rt.potCapNotified {
+		t.Fatal("expected pot_capped broadcast marker set")
+	}
+}
+
+func TestRaiseBeyondCapRejectedWithHeadroom(t *testing.T) {
+	rt := potCapRuntime(80, 100)
+
+	err := rt.handleRaiseLocked(1, raiseData(t, 40))
+	var capErr *PotCapError
+	if !errors.As(err, &capErr) {
+		t.Fatalf("expected PotCapError, got %v", err)
+	}
+	if capErr.Headroom != 20 {
+		t.Fatalf("expected headroom 20, got %d", capErr.Headroom)
+	}
+	if rt.pot != 80 {
+		t.Fatalf("expected pot unchanged, got %d", rt.pot)
+	}
+}
+
+func TestCallTrimmedToCap(t *testing.T) {
+	rt := potCapRuntime(95, 100)
+	rt.lastRaise = 40
+
+	if err := rt.handleCallLocked(1); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if rt.pot != 100 {
+		t.Fatalf("expected call trimmed to the cap, pot %d", rt.pot)
+	}
+}
+
+func TestAllowedActionsDropRaiseOnceCapped(t *testing.T) {
+	open := potCapRuntime(80, 100)
+	if !containsAction(open.allowedActionsLocked(101), "raise") {
+		t.Fatal("expected raise offered below the cap")
+	}
+
+	capped := potCapRuntime(100, 100)
+	actions := capped.allowedActionsLocked(101)
+	if containsAction(actions, "raise") || containsAction(actions, "knock_bobo") {
+		t.Fatalf("expected raise/knock_bobo withheld at the cap, got %v", actions)
+	}
+}
+
+func containsAction(actions []string, want string) bool {
+	for _, a := range actions {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
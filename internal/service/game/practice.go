@@ -0,0 +1,225 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+)
+
+// Practice tables are single-player chexuan tutorials: the learner sits with
+// deterministic house bots on a runtime that has no table row, no match rows
+// and no wallet movement. The full WS protocol applies unchanged — bots speak
+// it too — but nothing a practice hand does leaves the process, and the idle
+// sweeper reclaims abandoned tables on a much shorter leash than real ones.
+
+const (
+	practiceSeatCount = 3
+	practiceBasePi    = 10
+	practiceBuyIn     = 1000
+)
+
+// practiceSeq feeds the synthetic IDs practice tables and matches use.
+// They are negative so they can never collide with persisted rows: a stopped
+// practice runtime is simply gone, GetRuntime has nothing to rebuild it from.
+var practiceSeq atomic.Int64
+
+// practiceScene is the fixed rule set practice hands play under; the name
+// keeps the runtime in chexuan mode without a stored scene row.
+func practiceScene() model.Scene {
+	return model.Scene{
+		Name:        "chexuan-practice",
+		SeatCount:   practiceSeatCount,
+		BasePi:      practiceBasePi,
+		MinUnitPi:   1,
+		BoboEnabled: true,
+	}
+}
+
+// StartPractice opens (or returns the learner's existing) practice table and
+// reports the table ID their WS connects to. The runtime goes straight into
+// the live map under its synthetic ID; there is no row for GetRuntime to load.
+func (s *Service) StartPractice(ctx context.Context, userID int64) (int64, error) {
+	// Restarting the tutorial reattaches to the live table instead of
+	// stacking a new runtime per tap.
+	existing := int64(0)
+	s.runtimes.Range(func(_, value interface{}) bool {
+		rt := value.(*TableRuntime)
+		if rt.practice && rt.practiceUserID == userID {
+			select {
+			case <-rt.quitCh:
+			default:
+				existing = rt.tableID
+				return false
+			}
+		}
+		return true
+	})
+	if existing != 0 {
+		return existing, nil
+	}
+
+	if s.AtRuntimeCapacity() {
+		s.runtimeSheds.Add(1)
+		return 0, appErr.ErrServerBusy
+	}
+
+	alias := fmt.Sprintf("玩家%d", userID)
+	if s.db != nil {
+		var user model.User
+		if err := s.db.WithContext(ctx).First(&user, userID).Error; err == nil && user.Nickname != "" {
+			alias = user.Nickname
+		}
+	}
+
+	players := map[string]map[string]interface{}{
+		"1": {"userId": userID, "alias": alias, "chips": int64(practiceBuyIn)},
+	}
+	botIDs := make([]int64, 0, practiceSeatCount-1)
+	for seat := 2; seat <= practiceSeatCount; seat++ {
+		botID := practiceBotID(seat)
+		botIDs = append(botIDs, botID)
+		players[fmt.Sprintf("%d", seat)] = map[string]interface{}{
+			"userId": botID,
+			"alias":  fmt.Sprintf("教学机器人%d", seat-1),
+			"chips":  int64(practiceBuyIn),
+		}
+	}
+	playersJSON, err := json.Marshal(players)
+	if err != nil {
+		return 0, err
+	}
+
+	tableID := -practiceSeq.Add(1)
+	table := model.Table{
+		ID:          tableID,
+		SeatCount:   practiceSeatCount,
+		PlayersJSON: playersJSON,
+	}
+	rt, err := newTableRuntime(s.lifecycleContext(), nil, table, practiceScene(), 0, nil, s.handlePracticeFinish)
+	if err != nil {
+		return 0, err
+	}
+	rt.practice = true
+	rt.practiceUserID = userID
+	rt.onAnomaly = s.recordAnomaly
+	s.runtimes.Store(tableID, rt)
+	for _, botID := range botIDs {
+		go runPracticeBot(rt, botID)
+	}
+	return tableID, nil
+}
+
+// practiceBotID derives a bot's synthetic user ID from its seat. Negative like
+// the table IDs, so a bot can never alias a real account.
+func practiceBotID(seatIdx int) int64 {
+	return int64(-seatIdx)
+}
+
+// handlePracticeFinish is the no-settlement finish path: the hand's ledger
+// moved play chips between the seats and nothing else, so there is no match
+// to close, no wallet to touch and no behavior row to write. The runtime just
+// resets for the next practice hand under a fresh synthetic match ID.
+func (s *Service) handlePracticeFinish(rt *TableRuntime) {
+	rt.beginNextMatch(-practiceSeq.Add(1))
+}
+
+// runPracticeBot drives one house seat over the same protocol a client uses:
+// it subscribes, watches its per-user state exports and answers with actions.
+// The goroutine ends when the runtime closes its channel (eviction, stop).
+func runPracticeBot(rt *TableRuntime, botID int64) {
+	ch := rt.Subscribe(botID)
+	if ch == nil {
+		return
+	}
+	for msg := range ch {
+		state, ok := msg.Data.(TableState)
+		if !ok {
+			continue
+		}
+		if action := practiceBotAction(state, botID); action != "" {
+			practiceBotAct(rt, botID, action)
+		}
+	}
+}
+
+// practiceBotAction is the deterministic house policy: ready while waiting,
+// the cheapest continuing action on its turn — pass over call, fold only when
+// nothing else is legal — and an ack once the result screen is up. The bot
+// never raises or knocks, so the learner alone drives the pot.
+func practiceBotAction(state TableState, botID int64) string {
+	var seat *SeatState
+	for i := range state.Seats {
+		if state.Seats[i].UserID == botID {
+			seat = &state.Seats[i]
+			break
+		}
+	}
+	if seat == nil {
+		return ""
+	}
+	switch state.Phase {
+	case PhaseWaiting:
+		if !seat.Ready {
+			return "ready"
+		}
+	case PhasePlaying:
+		if state.TurnSeat != seat.SeatIndex {
+			return ""
+		}
+		for _, preferred := range []string{"pass", "call", "fold"} {
+			for _, allowed := range state.AllowedActions {
+				if allowed == preferred {
+					return preferred
+				}
+			}
+		}
+	case PhaseEnded:
+		return "ack_result"
+	}
+	return ""
+}
+
+// practiceBotAct mirrors HandleAction but gives up when the runtime stops, so
+// a bot caught mid-message by an eviction never leaks its goroutine.
+func practiceBotAct(rt *TableRuntime, botID int64, action string) {
+	resp := make(chan error, 1)
+	select {
+	case rt.cmdCh <- loopCommand{kind: "action", userID: botID, action: action, resp: resp}:
+	case <-rt.quitCh:
+		return
+	}
+	select {
+	case <-resp:
+	case <-rt.quitCh:
+	}
+}
+
+// pushPracticeHintLocked explains the BestSplit evaluation of the learner's
+// cards once the hand has settled, so the tutorial can show why their
+// head/tail split won or lost instead of just the ledger.
+func (rt *TableRuntime) pushPracticeHintLocked() {
+	seatIdx, ok := rt.seatByUser[rt.practiceUserID]
+	if !ok {
+		return
+	}
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil || len(seat.cards) < 2 {
+		return
+	}
+	head, tail, _, isValid := BestSplit(seat.cards)
+	rt.pushMessageLocked(rt.practiceUserID, OutgoingMessage{
+		Type: "hint",
+		Data: ginH{
+			"cards":     append([]string(nil), seat.cards...),
+			"head":      head,
+			"tail":      tail,
+			"headScore": evaluatePairScore(head),
+			"tailScore": evaluatePairScore(tail),
+			"isDaoba":   !isValid,
+		},
+	})
+}
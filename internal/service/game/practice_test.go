@@ -0,0 +1,136 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newPracticeTestService(t *testing.T) *Service {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.Match{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	s := NewService(db, nil, nil, nil)
+	t.Cleanup(func() { _ = s.Stop(context.Background()) })
+	return s
+}
+
+func TestPracticeBotActionPolicy(t *testing.T) {
+	state := TableState{
+		Phase: PhaseWaiting,
+		Seats: []SeatState{{SeatIndex: 2, UserID: -2}},
+	}
+	if got := practiceBotAction(state, -2); got != "ready" {
+		t.Fatalf("expected ready while waiting, got %q", got)
+	}
+
+	state.Phase = PhasePlaying
+	state.TurnSeat = 2
+	state.AllowedActions = []string{"fold", "call", "knock_bobo"}
+	if got := practiceBotAction(state, -2); got != "call" {
+		t.Fatalf("expected the cheapest continuing action, got %q", got)
+	}
+	state.AllowedActions = []string{"fold", "pass", "raise"}
+	if got := practiceBotAction(state, -2); got != "pass" {
+		t.Fatalf("expected pass preferred over fold, got %q", got)
+	}
+
+	state.TurnSeat = 1
+	if got := practiceBotAction(state, -2); got != "" {
+		t.Fatalf("expected no action off turn, got %q", got)
+	}
+
+	state.Phase = PhaseEnded
+	if got := practiceBotAction(state, -2); got != "ack_result" {
+		t.Fatalf("expected result ack once ended, got %q", got)
+	}
+}
+
+func TestPracticeHandPlaysToHint(t *testing.T) {
+	s := newPracticeTestService(t)
+	ctx := context.Background()
+
+	tableID, err := s.StartPractice(ctx, 7)
+	if err != nil {
+		t.Fatalf("failed to start practice: %v", err)
+	}
+	if tableID >= 0 {
+		t.Fatalf("expected a synthetic negative table ID, got %d", tableID)
+	}
+	// Starting again reattaches instead of stacking runtimes.
+	if again, err := s.StartPractice(ctx, 7); err != nil || again != tableID {
+		t.Fatalf("expected the existing table %d back, got %d err %v", tableID, again, err)
+	}
+
+	v, ok := s.runtimes.Load(tableID)
+	if !ok {
+		t.Fatal("expected the practice runtime in the live map")
+	}
+	rt := v.(*TableRuntime)
+
+	ch := rt.Subscribe(7)
+	if ch == nil {
+		t.Fatal("failed to subscribe the learner")
+	}
+	if err := rt.HandleAction(7, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+
+	// Play the learner's turns with the bot policy until the showdown hint
+	// arrives; the bots drive their own seats in the background.
+	deadline := time.After(10 * time.Second)
+	for {
+		var msg OutgoingMessage
+		select {
+		case msg = <-ch:
+		case <-deadline:
+			t.Fatal("no hint before the deadline")
+		}
+		if msg.Type == "hint" {
+			hint, ok := msg.Data.(ginH)
+			if !ok {
+				t.Fatalf("unexpected hint payload %#v", msg.Data)
+			}
+			head, _ := hint["head"].([]string)
+			tail, _ := hint["tail"].([]string)
+			if len(head) != 2 || len(tail) != 2 {
+				t.Fatalf("expected a 2+2 split explained, got head %v tail %v", head, tail)
+			}
+			break
+		}
+		if state, ok := msg.Data.(TableState); ok && state.Phase == PhasePlaying {
+			if action := practiceBotAction(state, 7); action != "" {
+				_ = rt.HandleAction(7, action, nil)
+			}
+		}
+	}
+
+	// Practice hands leave no trace: no match rows, no round logs.
+	var matches, logs int64
+	if err := s.db.Model(&model.Match{}).Count(&matches).Error; err != nil {
+		t.Fatalf("failed to count matches: %v", err)
+	}
+	if err := s.db.Model(&model.MatchRoundLog{}).Count(&logs).Error; err != nil {
+		t.Fatalf("failed to count round logs: %v", err)
+	}
+	if matches != 0 || logs != 0 {
+		t.Fatalf("practice must not persist, got %d matches %d logs", matches, logs)
+	}
+}
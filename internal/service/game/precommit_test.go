@@ -0,0 +1,135 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// newPrecommitRuntime is a bare three-seat hand mid-round: everyone level
+// at the last raise, seat 1 on turn.
+func newPrecommitRuntime() *TableRuntime {
+	return &TableRuntime{
+		phase:      PhasePlaying,
+		round:      1,
+		turnSeat:   1,
+		lastRaise:  10,
+		pot:        30,
+		roundActed: map[int]bool{},
+		precommits: map[int]string{},
+		seatByUser: map[int64]int{101: 1, 102: 2, 103: 3},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 990, Bet: 10, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 990, Bet: 10, Status: "playing"},
+			{SeatIndex: 3, UserID: 103, Chips: 990, Bet: 10, Status: "playing"},
+		},
+	}
+}
+
+func TestPrecommitAppliesWhenTurnOpens(t *testing.T) {
+	rt := newPrecommitRuntime()
+
+	if err := rt.handleActionLocked(102, "precommit", json.RawMessage(`{"intent":"call_any"}`)); err != nil {
+		t.Fatalf("precommit failed: %v", err)
+	}
+
+	// Seat 1 checks; seat 2's parked call_any auto-checks and the turn
+	// lands on seat 3.
+	if err := rt.handleActionLocked(101, "pass", nil); err != nil {
+		t.Fatalf("pass failed: %v", err)
+	}
+	if rt.turnSeat != 3 {
+		t.Fatalf("expected turn to skip past the precommitted seat, got %d", rt.turnSeat)
+	}
+	if !rt.roundActed[2] {
+		t.Fatal("precommitted seat should have acted")
+	}
+	if _, ok := rt.precommits[2]; ok {
+		t.Fatal("precommit not consumed after use")
+	}
+}
+
+func TestPrecommitCallAnyCallsOutstandingBet(t *testing.T) {
+	rt := newPrecommitRuntime()
+	rt.lastRaise = 30 // seat 2 owes 20 on top of its bet
+
+	if err := rt.handleActionLocked(102, "precommit", json.RawMessage(`{"intent":"call_any"}`)); err != nil {
+		t.Fatalf("precommit failed: %v", err)
+	}
+	rt.seats[0].Bet = 30 // seat 1 already level
+	if err := rt.handleActionLocked(101, "pass", nil); err != nil {
+		t.Fatalf("pass failed: %v", err)
+	}
+
+	seat := rt.findSeatLocked(2)
+	if seat.Bet != 30 || seat.Chips != 970 {
+		t.Fatalf("expected seat 2 auto-called to 30, got bet %d chips %d", seat.Bet, seat.Chips)
+	}
+	if rt.turnSeat != 3 {
+		t.Fatalf("expected turn on seat 3, got %d", rt.turnSeat)
+	}
+}
+
+func TestPrecommitCheckFoldInvalidatedByRaise(t *testing.T) {
+	rt := newPrecommitRuntime()
+
+	if err := rt.handleActionLocked(102, "precommit", json.RawMessage(`{"intent":"check_fold"}`)); err != nil {
+		t.Fatalf("precommit failed: %v", err)
+	}
+
+	// A raise lands before seat 2's turn: checking is no longer legal, so
+	// the precommit is dropped and the seat gets its normal timed turn.
+	rt.lastRaise = 30
+	rt.seats[0].Bet = 30
+	rt.roundActed[1] = true
+	rt.moveToNextTurnLocked()
+
+	if rt.turnSeat != 2 {
+		t.Fatalf("expected seat 2 left on turn, got %d", rt.turnSeat)
+	}
+	if rt.roundActed[2] {
+		t.Fatal("invalidated precommit must not act for the seat")
+	}
+	if _, ok := rt.precommits[2]; ok {
+		t.Fatal("invalidated precommit should be cleared")
+	}
+}
+
+func TestPrecommitClearedByManualAction(t *testing.T) {
+	rt := newPrecommitRuntime()
+
+	if err := rt.handleActionLocked(101, "precommit", json.RawMessage(`{"intent":"call_any"}`)); err != nil {
+		t.Fatalf("precommit failed: %v", err)
+	}
+	if err := rt.handleActionLocked(101, "fold", nil); err != nil {
+		t.Fatalf("fold failed: %v", err)
+	}
+	if _, ok := rt.precommits[1]; ok {
+		t.Fatal("manual action should clear the precommit")
+	}
+
+	// intent "none" clears an existing precommit explicitly.
+	if err := rt.handleActionLocked(102, "precommit", json.RawMessage(`{"intent":"call_any"}`)); err != nil {
+		t.Fatalf("precommit failed: %v", err)
+	}
+	if err := rt.handleActionLocked(102, "precommit", json.RawMessage(`{"intent":"none"}`)); err != nil {
+		t.Fatalf("clearing precommit failed: %v", err)
+	}
+	if _, ok := rt.precommits[2]; ok {
+		t.Fatal("intent none should clear the precommit")
+	}
+}
+
+func TestPrecommitOnlyVisibleToOwner(t *testing.T) {
+	rt := newPrecommitRuntime()
+
+	if err := rt.handleActionLocked(102, "precommit", json.RawMessage(`{"intent":"check_fold"}`)); err != nil {
+		t.Fatalf("precommit failed: %v", err)
+	}
+
+	if state := rt.exportStateLocked(102); state.MyPrecommit != precommitCheckFold {
+		t.Fatalf("owner should see their precommit, got %q", state.MyPrecommit)
+	}
+	if state := rt.exportStateLocked(101); state.MyPrecommit != "" {
+		t.Fatalf("other players must not see the precommit, got %q", state.MyPrecommit)
+	}
+}
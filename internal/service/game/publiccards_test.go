@@ -0,0 +1,108 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// drainSubscriber buffers everything a subscriber channel produces so the
+// 8-slot delivery buffer never drops broadcasts during the hand.
+func drainSubscriber(ch <-chan OutgoingMessage) <-chan OutgoingMessage {
+	collected := make(chan OutgoingMessage, 256)
+	go func() {
+		for msg := range ch {
+			collected <- msg
+		}
+		close(collected)
+	}()
+	return collected
+}
+
+// lastState pulls everything currently buffered and returns the newest state
+// payload seen.
+func lastState(t *testing.T, collected <-chan OutgoingMessage) TableState {
+	t.Helper()
+
+	var last *TableState
+	for {
+		select {
+		case msg := <-collected:
+			if msg.Type != "state" {
+				continue
+			}
+			state, ok := msg.Data.(TableState)
+			if !ok {
+				t.Fatalf("state message carries %#v", msg.Data)
+			}
+			last = &state
+		case <-time.After(200 * time.Millisecond):
+			if last == nil {
+				t.Fatal("no state message received")
+			}
+			return *last
+		}
+	}
+}
+
+// TestShowdownExposesPublicCards drives a two-seat hand to showdown and
+// checks both subscribers receive every active seat's hole cards in the final
+// state, while mid-hand states keep them hidden.
+func TestShowdownExposesPublicCards(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+	c101 := drainSubscriber(rt.Subscribe(101))
+	c102 := drainSubscriber(rt.Subscribe(102))
+
+	startTimeBankHand(t, rt)
+
+	// Mid-hand, nobody's cards are public.
+	midState := lastState(t, c101)
+	for _, seat := range midState.Seats {
+		if len(seat.PublicCards) != 0 {
+			t.Fatalf("seat %d cards exposed mid-hand: %v", seat.SeatIndex, seat.PublicCards)
+		}
+	}
+
+	actAsTurnOwner(t, rt, "raise", json.RawMessage(`{"amount":20}`))
+	actAsTurnOwner(t, rt, "call", nil)
+	actAsTurnOwner(t, rt, "raise", json.RawMessage(`{"amount":40}`))
+	actAsTurnOwner(t, rt, "call", nil)
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected hand settled, got %s", rt.phase)
+	}
+
+	for _, collected := range []<-chan OutgoingMessage{c101, c102} {
+		state := lastState(t, collected)
+		for _, seat := range state.Seats {
+			if len(seat.PublicCards) != 2 {
+				t.Fatalf("seat %d missing showdown cards: %v", seat.SeatIndex, seat.PublicCards)
+			}
+		}
+	}
+}
+
+// TestFoldedSeatStaysHiddenAtShowdown ends the hand by folding and checks the
+// folder's cards never become public while the winner's do.
+func TestFoldedSeatStaysHiddenAtShowdown(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+	collected := drainSubscriber(rt.Subscribe(102))
+
+	startTimeBankHand(t, rt)
+	folder := actAsTurnOwner(t, rt, "fold", nil)
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected fold to end the hand, got %s", rt.phase)
+	}
+
+	state := lastState(t, collected)
+	for _, seat := range state.Seats {
+		if seat.UserID == folder {
+			if len(seat.PublicCards) != 0 {
+				t.Fatalf("folded seat %d cards exposed: %v", seat.SeatIndex, seat.PublicCards)
+			}
+			continue
+		}
+		if len(seat.PublicCards) != 2 {
+			t.Fatalf("active seat %d missing showdown cards: %v", seat.SeatIndex, seat.PublicCards)
+		}
+	}
+}
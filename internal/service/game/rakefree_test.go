@@ -0,0 +1,103 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+)
+
+// allWeekWindows covers every weekday in full, so the settle below is always
+// inside a rake-free window regardless of when the test runs.
+func allWeekWindows() string {
+	entries := make([]string, 0, 7)
+	for day := 0; day < 7; day++ {
+		entries = append(entries, fmt.Sprintf(`{"weekday":%d,"start":"00:00","end":"00:00"}`, day))
+	}
+	return "[" + strings.Join(entries, ",") + "]"
+}
+
+func TestRakeFreeWindowWaivesRake(t *testing.T) {
+	db, svc := newTrialSettleService(t)
+	ctx := context.Background()
+
+	if err := db.Create(&model.RakeRule{ID: 5, Name: "r", Type: "ratio", Status: "enabled", ConfigJSON: []byte(`{"ratio":0.1}`)}).Error; err != nil {
+		t.Fatalf("seed rake rule: %v", err)
+	}
+	if err := db.Create(&model.AgentRule{MaxLevel: 1, LevelRatiosJSON: []byte(`{"L1":0.5}`)}).Error; err != nil {
+		t.Fatalf("seed agent rule: %v", err)
+	}
+	if err := db.Create(&model.User{ID: 201, Phone: "201", AgentPath: "9"}).Error; err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	if err := db.Create(&model.Scene{
+		ID: 1, Name: "happy", SeatCount: 2, RakeRuleID: 5,
+		RakeFreeWindowsJSON: []byte(allWeekWindows()),
+	}).Error; err != nil {
+		t.Fatalf("seed scene: %v", err)
+	}
+	match := model.Match{TableID: 1, SceneID: 1}
+	if err := db.Create(&match).Error; err != nil {
+		t.Fatalf("seed match: %v", err)
+	}
+	wallets := []model.Wallet{
+		{UserID: 201, BalanceTotal: 500, BalanceAvailable: 500},
+		{UserID: 202, BalanceTotal: 500, BalanceAvailable: 500},
+	}
+	if err := db.Create(&wallets).Error; err != nil {
+		t.Fatalf("seed wallets: %v", err)
+	}
+
+	if err := svc.SettleMatch(ctx, SettlementRequest{
+		MatchID: match.ID,
+		SceneID: 1,
+		Results: []PlayerResult{
+			{UserID: 201, NetPoints: 100},
+			{UserID: 202, NetPoints: -100},
+		},
+	}); err != nil {
+		t.Fatalf("settle failed: %v", err)
+	}
+
+	// The winner keeps the gross win: the configured 10% rule never ran.
+	var winner model.Wallet
+	if err := db.First(&winner, "user_id = ?", int64(201)).Error; err != nil {
+		t.Fatalf("load winner: %v", err)
+	}
+	if winner.BalanceAvailable != 600 || winner.TotalRake != 0 {
+		t.Fatalf("expected an unraked win of 100, got %+v", winner)
+	}
+
+	var settled model.Match
+	if err := db.First(&settled, match.ID).Error; err != nil {
+		t.Fatalf("load match: %v", err)
+	}
+	if !strings.Contains(string(settled.RakeJSON), `"rakeFree":true`) {
+		t.Fatalf("RakeJSON missing the rake-free marker: %s", settled.RakeJSON)
+	}
+	if !strings.Contains(string(settled.RakeJSON), `"rakeFreeWindow"`) {
+		t.Fatalf("RakeJSON missing the applied window: %s", settled.RakeJSON)
+	}
+	if settled.EndedAt == nil || time.Since(*settled.EndedAt) > time.Minute {
+		t.Fatalf("match not marked settled: %+v", settled.EndedAt)
+	}
+
+	// No rake means no agent shares and no platform income.
+	var agentLogs int64
+	if err := db.Model(&model.AgentProfitLog{}).Count(&agentLogs).Error; err != nil {
+		t.Fatalf("count agent logs: %v", err)
+	}
+	if agentLogs != 0 {
+		t.Fatalf("rake-free settlement must not distribute agent shares, got %d", agentLogs)
+	}
+	var rake model.MatchRake
+	if err := db.First(&rake, "match_id = ?", match.ID).Error; err != nil {
+		t.Fatalf("load rake row: %v", err)
+	}
+	if rake.TotalRake != 0 || rake.Platform != 0 || rake.AgentTotal != 0 {
+		t.Fatalf("revenue row must be zero for a rake-free match: %+v", rake)
+	}
+}
@@ -0,0 +1,110 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// readyTimeoutRuntime builds a three-seat waiting table without starting its
+// loop so the test can drive the ready deadline synchronously.
+func readyTimeoutRuntime(t *testing.T) *TableRuntime {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	scene := model.Scene{ID: 1, Name: "test", SeatCount: 3, BasePi: 10, MinUnitPi: 1, ReadyTimeoutSeconds: 20}
+	table := model.Table{
+		ID:          1,
+		SceneID:     scene.ID,
+		SeatCount:   3,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000},"3":{"userId":103,"chips":1000}}`),
+	}
+	rt, err := buildTableRuntime(nil, table, scene, 0, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to build runtime: %v", err)
+	}
+	rt.armReadyTimerLocked()
+	return rt
+}
+
+func TestReadyTimeoutStartsWithAbsenteeRefunded(t *testing.T) {
+	rt := readyTimeoutRuntime(t)
+	if rt.countdownSecondsLocked() == 0 {
+		t.Fatal("waiting table should expose the ready countdown")
+	}
+	if err := rt.handleReadyLocked(1, 101, nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if err := rt.handleReadyLocked(2, 102, nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if rt.phase != PhaseWaiting {
+		t.Fatalf("two of three readies must not start the hand, phase %s", rt.phase)
+	}
+
+	rt.handleTurnTimeoutLocked()
+
+	if rt.phase != PhasePlaying {
+		t.Fatalf("expected the deadline to deal without seat 3, phase %s", rt.phase)
+	}
+	if seat := rt.findSeatLocked(3); seat != nil {
+		t.Fatalf("absent seat should be cashed out, still holds user %d", seat.UserID)
+	}
+	if rt.totalCashOuts != 1000 {
+		t.Fatalf("absentee's buy-in should refund, cashed out %d", rt.totalCashOuts)
+	}
+	if !rt.readyDeadline.IsZero() {
+		t.Fatal("ready deadline should clear once the hand starts")
+	}
+}
+
+func TestReadyTimeoutDissolvesUnderTwoReady(t *testing.T) {
+	rt := readyTimeoutRuntime(t)
+	finished := make(chan struct{}, 1)
+	rt.onFinish = func(*TableRuntime) { finished <- struct{}{} }
+	if err := rt.handleReadyLocked(1, 101, nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+
+	rt.handleTurnTimeoutLocked()
+
+	if rt.expiredReason != "dissolved" {
+		t.Fatalf("expected a dissolve close, expiredReason %q", rt.expiredReason)
+	}
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected ended phase, got %s", rt.phase)
+	}
+	if len(rt.SettlementResults) != 3 {
+		t.Fatalf("every seat should settle a zero result, got %d", len(rt.SettlementResults))
+	}
+	for _, res := range rt.SettlementResults {
+		if res.NetPoints != 0 {
+			t.Fatalf("dissolve results must be zero-net, user %d got %d", res.UserID, res.NetPoints)
+		}
+	}
+	select {
+	case <-rt.quitCh:
+	default:
+		t.Fatal("dissolved runtime should have stopped")
+	}
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatal("finish callback never ran")
+	}
+	var logged bool
+	for _, item := range rt.logs {
+		if strings.Contains(item.Content, "解散") {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Fatal("expected a system log explaining the dissolve")
+	}
+}
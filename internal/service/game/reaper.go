@@ -0,0 +1,236 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// defaultReapStaleAfter is how old a waiting/playing table must be before
+// the reaper considers it stuck. Overridable via reaper.staleAfterHours.
+const defaultReapStaleAfter = 24 * time.Hour
+
+// reaperDecision is one entry of ReaperRun.DecisionsJSON: what the reaper
+// did (or, in dry-run, would do) with a single stale table.
+type reaperDecision struct {
+	TableID int64 `json:"tableId"`
+	MatchID int64 `json:"matchId,omitempty"`
+	// Decision is "closed" (zero-net settlement refunded the players),
+	// "closed_no_match" (no unended match, the row was just marked ended)
+	// or "abandoned" (amounts could not be reconstructed).
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
+
+func reapStaleAfterFromConfig() time.Duration {
+	if config.GlobalConfig != nil && config.GlobalConfig.Reaper.StaleAfterHours > 0 {
+		return time.Duration(config.GlobalConfig.Reaper.StaleAfterHours) * time.Hour
+	}
+	return defaultReapStaleAfter
+}
+
+func reapAutoCleanFromConfig() bool {
+	return config.GlobalConfig != nil && config.GlobalConfig.Reaper.AutoClean
+}
+
+// ReapStaleTables closes DB table rows stuck in waiting/playing with no live
+// runtime and no recent round activity — leftovers of crashes and historical
+// bugs that pollute admin listings and block their players via the
+// active-match guard. Qualifying tables with a reconstructible seat list are
+// closed through the normal refund path (a zero-net settlement, which also
+// releases frozen escrow); rows whose players can't be parsed are marked
+// "abandoned" for manual review. Every pass that examines at least one table
+// records a ReaperRun row; until reaper.autoClean is enabled the job runs dry
+// and only records what it would have done. Registered as a periodic
+// scheduler job under a cross-instance lock.
+func (s *Service) ReapStaleTables(ctx context.Context) error {
+	cutoff := time.Now().Add(-reapStaleAfterFromConfig())
+	dryRun := !reapAutoCleanFromConfig()
+
+	var tables []model.Table
+	if err := s.db.WithContext(ctx).
+		Where("status IN ? AND created_at < ?", []string{"waiting", "playing"}, cutoff).
+		Order("id").
+		Find(&tables).Error; err != nil {
+		return err
+	}
+
+	run := model.ReaperRun{DryRun: dryRun}
+	decisions := make([]reaperDecision, 0)
+	for _, table := range tables {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// A live runtime owns the table; the sweeper and finish callbacks
+		// handle it.
+		if _, ok := s.runtimes.Load(table.ID); ok {
+			continue
+		}
+		match, err := s.loadActiveMatch(ctx, table.ID)
+		if err != nil {
+			return err
+		}
+		if match != nil {
+			// Recent round logs mean the hand is (or was very recently)
+			// alive somewhere — likely another instance. Leave it alone.
+			var recent int64
+			if err := s.db.WithContext(ctx).
+				Model(&model.MatchRoundLog{}).
+				Where("match_id = ? AND created_at >= ?", match.ID, cutoff).
+				Count(&recent).Error; err != nil {
+				return err
+			}
+			if recent > 0 {
+				continue
+			}
+		}
+		run.Examined++
+
+		decision := s.reapOneTable(ctx, table, match, dryRun)
+		decisions = append(decisions, decision)
+		if decision.Decision == "abandoned" {
+			run.Abandoned++
+		} else {
+			run.Closed++
+		}
+		logger.WithContext(ctx).Info("stale table reaped",
+			zap.Int64("tableID", decision.TableID),
+			zap.String("decision", decision.Decision),
+			zap.String("reason", decision.Reason),
+			zap.Bool("dryRun", dryRun),
+		)
+	}
+
+	// Nothing examined, nothing to report; at the job's cadence an
+	// unconditional row would drown the runs operators care about.
+	if run.Examined == 0 {
+		return nil
+	}
+	run.DecisionsJSON = mustJSON(decisions)
+	return s.db.WithContext(ctx).Create(&run).Error
+}
+
+// reapOneTable decides and, outside dry-run, executes the cleanup for a
+// single qualifying table.
+func (s *Service) reapOneTable(ctx context.Context, table model.Table, match *model.Match, dryRun bool) reaperDecision {
+	if match == nil {
+		// No unended match means no stakes to unwind; the row just needs
+		// its status corrected.
+		if !dryRun {
+			_ = s.db.WithContext(ctx).
+				Model(&model.Table{}).
+				Where("id = ?", table.ID).
+				Update("status", "ended").Error
+			s.clearReapedPlayers(ctx, table)
+		}
+		return reaperDecision{TableID: table.ID, Decision: "closed_no_match", Reason: "no unended match"}
+	}
+
+	decision := reaperDecision{TableID: table.ID, MatchID: match.ID}
+	_, seatByUser, err := parsePlayersJSON(json.RawMessage(table.PlayersJSON))
+	if err != nil || len(seatByUser) == 0 {
+		decision.Decision = "abandoned"
+		decision.Reason = "players_json unreadable, amounts not reconstructible"
+		if !dryRun {
+			s.abandonReapedTable(ctx, table, match)
+		}
+		return decision
+	}
+
+	if dryRun {
+		decision.Decision = "closed"
+		decision.Reason = "stale, would refund via zero-net settlement"
+		return decision
+	}
+
+	// The zero-net settlement is the same refund path a dissolved runtime
+	// takes: it releases frozen escrow, seals the match and marks the table
+	// ended, so the active-match guard stops seeing it.
+	results := make([]PlayerResult, 0, len(seatByUser))
+	for userID := range seatByUser {
+		results = append(results, PlayerResult{
+			UserID: userID,
+			Meta:   map[string]interface{}{"reason": "stale_reaper"},
+		})
+	}
+	if err := s.SettleMatch(ctx, SettlementRequest{
+		MatchID: match.ID,
+		SceneID: table.SceneID,
+		Results: results,
+	}); err != nil {
+		logger.WithContext(ctx).Warn("stale-table refund settlement failed",
+			zap.Int64("tableID", table.ID),
+			zap.Int64("matchID", match.ID),
+			zap.Error(err),
+		)
+		decision.Decision = "abandoned"
+		decision.Reason = "refund settlement failed: " + err.Error()
+		s.abandonReapedTable(ctx, table, match)
+		return decision
+	}
+	s.clearReapedPlayers(ctx, table)
+	decision.Decision = "closed"
+	decision.Reason = "stale, refunded via zero-net settlement"
+	return decision
+}
+
+// abandonReapedTable parks a table the reaper could not refund: the status
+// flags it for manual review and the match is sealed so the active-match
+// guard stops blocking its players.
+func (s *Service) abandonReapedTable(ctx context.Context, table model.Table, match *model.Match) {
+	_ = s.db.WithContext(ctx).
+		Model(&model.Table{}).
+		Where("id = ?", table.ID).
+		Update("status", "abandoned").Error
+	_ = s.db.WithContext(ctx).
+		Model(&model.Match{}).
+		Where("id = ? AND ended_at IS NULL", match.ID).
+		Update("ended_at", time.Now()).Error
+	s.clearReapedPlayers(ctx, table)
+}
+
+// clearReapedPlayers drops the matched markers of everyone seated at a
+// reaped table so they can queue again immediately.
+func (s *Service) clearReapedPlayers(ctx context.Context, table model.Table) {
+	if s.matchmaker == nil {
+		return
+	}
+	_, seatByUser, err := parsePlayersJSON(json.RawMessage(table.PlayersJSON))
+	if err != nil {
+		return
+	}
+	for userID := range seatByUser {
+		s.matchmaker.ClearMatched(ctx, userID)
+	}
+}
+
+// ListReaperRuns pages the reaper's run reports, newest first.
+func (s *Service) ListReaperRuns(ctx context.Context, page, size int) ([]model.ReaperRun, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 20
+	}
+
+	var total int64
+	if err := s.db.WithContext(ctx).Model(&model.ReaperRun{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	runs := make([]model.ReaperRun, 0)
+	if total == 0 {
+		return runs, 0, nil
+	}
+	err := s.db.WithContext(ctx).
+		Order("id DESC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&runs).Error
+	return runs, total, err
+}
@@ -0,0 +1,244 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newReaperTestService(t *testing.T) (*gorm.DB, *Service) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&model.Match{}, &model.Scene{}, &model.Table{}, &model.Wallet{}, &model.BillingLog{},
+		&model.RakeRule{}, &model.AgentRule{}, &model.AgentProfitLog{}, &model.MatchRake{},
+		&model.User{}, &model.MatchRoundLog{}, &model.ReaperRun{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db, &Service{db: db}
+}
+
+func setReaperAutoClean(t *testing.T) {
+	t.Helper()
+	prev := config.GlobalConfig
+	config.GlobalConfig = &config.Config{Reaper: config.ReaperConfig{AutoClean: true}}
+	t.Cleanup(func() { config.GlobalConfig = prev })
+}
+
+// seedStaleTable plants a waiting table older than the default threshold with
+// an unended match and two seated players, and returns both rows.
+func seedStaleTable(t *testing.T, db *gorm.DB, tableID int64, playersJSON string) (model.Table, model.Match) {
+	t.Helper()
+	table := model.Table{
+		ID:          tableID,
+		SceneID:     1,
+		Status:      "waiting",
+		SeatCount:   2,
+		PlayersJSON: []byte(playersJSON),
+		CreatedAt:   time.Now().Add(-48 * time.Hour),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("seed table: %v", err)
+	}
+	match := model.Match{TableID: tableID, SceneID: 1}
+	if err := db.Create(&match).Error; err != nil {
+		t.Fatalf("seed match: %v", err)
+	}
+	return table, match
+}
+
+func TestReaperDryRunRecordsWithoutTouching(t *testing.T) {
+	db, svc := newReaperTestService(t)
+	ctx := context.Background()
+
+	seedStaleTable(t, db, 1, `{"1":{"userId":201},"2":{"userId":202}}`)
+
+	// No config loaded means dry-run: the default until autoClean is armed.
+	if err := svc.ReapStaleTables(ctx); err != nil {
+		t.Fatalf("reap failed: %v", err)
+	}
+
+	var table model.Table
+	if err := db.First(&table, int64(1)).Error; err != nil {
+		t.Fatalf("load table: %v", err)
+	}
+	if table.Status != "waiting" {
+		t.Fatalf("dry-run must not touch the table, got status %q", table.Status)
+	}
+	var match model.Match
+	if err := db.First(&match, "table_id = ?", int64(1)).Error; err != nil {
+		t.Fatalf("load match: %v", err)
+	}
+	if match.EndedAt != nil {
+		t.Fatal("dry-run must not end the match")
+	}
+
+	var runs []model.ReaperRun
+	if err := db.Find(&runs).Error; err != nil {
+		t.Fatalf("load runs: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("expected one run report, got %d", len(runs))
+	}
+	run := runs[0]
+	if !run.DryRun || run.Examined != 1 || run.Closed != 1 || run.Abandoned != 0 {
+		t.Fatalf("unexpected run counters: %+v", run)
+	}
+	if !strings.Contains(string(run.DecisionsJSON), `"decision":"closed"`) {
+		t.Fatalf("decisions missing the would-close entry: %s", run.DecisionsJSON)
+	}
+}
+
+func TestReaperAutoCleanRefundsStaleTable(t *testing.T) {
+	db, svc := newReaperTestService(t)
+	ctx := context.Background()
+	setReaperAutoClean(t)
+
+	if err := db.Create(&model.Scene{ID: 1, Name: "stale", SeatCount: 2}).Error; err != nil {
+		t.Fatalf("seed scene: %v", err)
+	}
+	wallets := []model.Wallet{
+		{UserID: 201, BalanceTotal: 500, BalanceAvailable: 500},
+		{UserID: 202, BalanceTotal: 500, BalanceAvailable: 500},
+	}
+	if err := db.Create(&wallets).Error; err != nil {
+		t.Fatalf("seed wallets: %v", err)
+	}
+	seedStaleTable(t, db, 1, `{"1":{"userId":201},"2":{"userId":202}}`)
+
+	if err := svc.ReapStaleTables(ctx); err != nil {
+		t.Fatalf("reap failed: %v", err)
+	}
+
+	var table model.Table
+	if err := db.First(&table, int64(1)).Error; err != nil {
+		t.Fatalf("load table: %v", err)
+	}
+	if table.Status != "ended" {
+		t.Fatalf("expected the table closed, got status %q", table.Status)
+	}
+	var match model.Match
+	if err := db.First(&match, "table_id = ?", int64(1)).Error; err != nil {
+		t.Fatalf("load match: %v", err)
+	}
+	if match.EndedAt == nil {
+		t.Fatal("expected the match sealed")
+	}
+
+	// Zero-net: everyone keeps exactly what they had.
+	for _, userID := range []int64{201, 202} {
+		var w model.Wallet
+		if err := db.First(&w, "user_id = ?", userID).Error; err != nil {
+			t.Fatalf("load wallet %d: %v", userID, err)
+		}
+		if w.BalanceAvailable != 500 {
+			t.Fatalf("user %d balance changed to %d", userID, w.BalanceAvailable)
+		}
+	}
+
+	var run model.ReaperRun
+	if err := db.First(&run).Error; err != nil {
+		t.Fatalf("load run: %v", err)
+	}
+	if run.DryRun || run.Closed != 1 {
+		t.Fatalf("unexpected run counters: %+v", run)
+	}
+
+	items, total, err := svc.ListReaperRuns(ctx, 1, 20)
+	if err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if total != 1 || len(items) != 1 {
+		t.Fatalf("expected the run listed, got total=%d items=%d", total, len(items))
+	}
+}
+
+func TestReaperAbandonsUnreadablePlayers(t *testing.T) {
+	db, svc := newReaperTestService(t)
+	ctx := context.Background()
+	setReaperAutoClean(t)
+
+	seedStaleTable(t, db, 1, `[1,2,3]`)
+
+	if err := svc.ReapStaleTables(ctx); err != nil {
+		t.Fatalf("reap failed: %v", err)
+	}
+
+	var table model.Table
+	if err := db.First(&table, int64(1)).Error; err != nil {
+		t.Fatalf("load table: %v", err)
+	}
+	if table.Status != "abandoned" {
+		t.Fatalf("expected the table parked for review, got status %q", table.Status)
+	}
+	var match model.Match
+	if err := db.First(&match, "table_id = ?", int64(1)).Error; err != nil {
+		t.Fatalf("load match: %v", err)
+	}
+	if match.EndedAt == nil {
+		t.Fatal("abandoning must still unblock the players' active-match guard")
+	}
+	var run model.ReaperRun
+	if err := db.First(&run).Error; err != nil {
+		t.Fatalf("load run: %v", err)
+	}
+	if run.Abandoned != 1 || run.Closed != 0 {
+		t.Fatalf("unexpected run counters: %+v", run)
+	}
+}
+
+func TestReaperSkipsLiveAndRecentTables(t *testing.T) {
+	db, svc := newReaperTestService(t)
+	ctx := context.Background()
+	setReaperAutoClean(t)
+
+	// Young table: not past the threshold yet.
+	if err := db.Create(&model.Table{ID: 1, SceneID: 1, Status: "waiting", SeatCount: 2, CreatedAt: time.Now()}).Error; err != nil {
+		t.Fatalf("seed young table: %v", err)
+	}
+
+	// Old table, but its match logged a round recently — another instance
+	// may be playing it.
+	_, match := seedStaleTable(t, db, 2, `{"1":{"userId":201}}`)
+	if err := db.Create(&model.MatchRoundLog{MatchID: match.ID, RoundNo: 1, CreatedAt: time.Now()}).Error; err != nil {
+		t.Fatalf("seed round log: %v", err)
+	}
+
+	if err := svc.ReapStaleTables(ctx); err != nil {
+		t.Fatalf("reap failed: %v", err)
+	}
+
+	for _, id := range []int64{1, 2} {
+		var table model.Table
+		if err := db.First(&table, id).Error; err != nil {
+			t.Fatalf("load table %d: %v", id, err)
+		}
+		if table.Status != "waiting" {
+			t.Fatalf("table %d must be untouched, got status %q", id, table.Status)
+		}
+	}
+	var runs int64
+	if err := db.Model(&model.ReaperRun{}).Count(&runs).Error; err != nil {
+		t.Fatalf("count runs: %v", err)
+	}
+	if runs != 0 {
+		t.Fatalf("a pass that examines nothing must not write a report, got %d", runs)
+	}
+}
@@ -0,0 +1,174 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"dx-service/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// remoteDeliveryRuntime builds a bare runtime whose bridge publishes to a
+// real miniredis, with user 201 registered as a cross-instance subscriber,
+// and returns a channel carrying the envelopes published for the table.
+func remoteDeliveryRuntime(t *testing.T) (*TableRuntime, chan eventEnvelope) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	rt := &TableRuntime{
+		tableID:    1,
+		phase:      PhasePlaying,
+		bridge:     newBridge(rdb),
+		seatByUser: map[int64]int{101: 1, 102: 2},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 1000, Status: "playing"},
+		},
+		subscribers: map[int64]chan OutgoingMessage{},
+		remoteSubs:  map[int64]int{201: 1},
+		spectators:  map[int64]bool{},
+	}
+
+	sub := rdb.Subscribe(context.Background(), buildTableEventsChannel(rt.tableID))
+	if _, err := sub.Receive(context.Background()); err != nil {
+		t.Fatalf("subscribe to events channel: %v", err)
+	}
+	t.Cleanup(func() { sub.Close() })
+
+	events := make(chan eventEnvelope, 64)
+	go func() {
+		for msg := range sub.Channel() {
+			var env eventEnvelope
+			if json.Unmarshal([]byte(msg.Payload), &env) == nil {
+				events <- env
+			}
+		}
+	}()
+	return rt, events
+}
+
+// collectRemoteEnvelopes waits for want envelopes off the bridge channel.
+func collectRemoteEnvelopes(t *testing.T, events chan eventEnvelope, want int) []eventEnvelope {
+	t.Helper()
+	got := make([]eventEnvelope, 0, want)
+	deadline := time.After(2 * time.Second)
+	for len(got) < want {
+		select {
+		case env := <-events:
+			got = append(got, env)
+		case <-deadline:
+			t.Fatalf("expected %d bridge envelopes, got %d", want, len(got))
+		}
+	}
+	return got
+}
+
+// A spectator whose WS landed on a non-owning instance gets the same
+// anti-ghosting treatment as a local one: in a delayed scene broadcast
+// events sit in the buffer until spectatorDelay has passed.
+func TestRemoteSpectatorDelayedBroadcast(t *testing.T) {
+	rt, events := remoteDeliveryRuntime(t)
+	rt.spectatorDelay = 2 * time.Second
+	now := time.Now()
+	rt.now = func() time.Time { return now }
+	rt.spectators[201] = true
+
+	rt.broadcastMessageLocked(OutgoingMessage{Type: "action"})
+
+	select {
+	case env := <-events:
+		t.Fatalf("broadcast reached the remote spectator in real time: %+v", env)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	rt.flushDelayedLocked(now.Add(rt.spectatorDelay))
+	envs := collectRemoteEnvelopes(t, events, 1)
+	if envs[0].UserID != 201 || envs[0].Msg.Type != "action" {
+		t.Fatalf("unexpected delayed delivery %+v", envs[0])
+	}
+	if envs[0].Msg.Seq != 1 {
+		t.Fatalf("expected the delayed message stamped with seq 1, got %d", envs[0].Msg.Seq)
+	}
+}
+
+// State frames and broadcast events reach a cross-instance subscriber on one
+// per-user counter; the producer's table-wide seq never leaks through.
+func TestRemoteSubscriberSeqSingleCounter(t *testing.T) {
+	rt, events := remoteDeliveryRuntime(t)
+
+	rt.pushStateLocked(201)
+	rt.broadcastMessageLocked(OutgoingMessage{Type: "chat", Seq: rt.nextSeqLocked()})
+	rt.broadcastMessageLocked(OutgoingMessage{Type: "hand_summary", Seq: rt.nextSeqLocked()})
+	rt.pushStateLocked(201)
+
+	seen := make(map[int64]string, 4)
+	for _, env := range collectRemoteEnvelopes(t, events, 4) {
+		if env.UserID != 201 {
+			t.Fatalf("envelope for unexpected user %d", env.UserID)
+		}
+		seen[env.Msg.Seq] = env.Msg.Type
+	}
+	for want := int64(1); want <= 4; want++ {
+		if _, ok := seen[want]; !ok {
+			t.Fatalf("missing per-user seq %d in %v", want, seen)
+		}
+	}
+}
+
+// A cross-instance client that reconnects replays the broadcast events it
+// missed: bridged deliveries enter the per-user replay buffer like local ones.
+func TestRemoteRejoinReplaysBroadcastEvents(t *testing.T) {
+	rt, events := remoteDeliveryRuntime(t)
+	for i := 0; i < 3; i++ {
+		rt.broadcastMessageLocked(OutgoingMessage{Type: "action", Data: ginH{"n": i}})
+	}
+	collectRemoteEnvelopes(t, events, 3)
+
+	if err := rt.handleActionLocked(201, "rejoin", json.RawMessage(`{"lastSeq":1}`)); err != nil {
+		t.Fatalf("rejoin failed: %v", err)
+	}
+	actionSeqs := make(map[int64]bool)
+	states := 0
+	for _, env := range collectRemoteEnvelopes(t, events, 3) {
+		switch env.Msg.Type {
+		case "action":
+			actionSeqs[env.Msg.Seq] = true
+		case "state":
+			states++
+		default:
+			t.Fatalf("unexpected replayed message %+v", env.Msg)
+		}
+	}
+	if !actionSeqs[2] || !actionSeqs[3] || len(actionSeqs) != 2 {
+		t.Fatalf("expected actions seq 2 and 3 replayed, got %v", actionSeqs)
+	}
+	if states != 1 {
+		t.Fatalf("expected one trailing fresh state, got %d", states)
+	}
+}
+
+// Back-to-back broadcasts from the runtime loop reach the events channel in
+// emit order: the bridge publishes each table from one ordered queue instead
+// of per-message goroutines.
+func TestBridgePublishesEventsInOrder(t *testing.T) {
+	rt, events := remoteDeliveryRuntime(t)
+	const n = 32
+	for i := 0; i < n; i++ {
+		rt.broadcastMessageLocked(OutgoingMessage{Type: "action", Data: ginH{"n": i}})
+	}
+	for i, env := range collectRemoteEnvelopes(t, events, n) {
+		if env.Msg.Seq != int64(i+1) {
+			t.Fatalf("message %d arrived with seq %d: bridge reordered events", i, env.Msg.Seq)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// ReplayAction is one timeline entry of a stitched match replay.
+type ReplayAction struct {
+	Seq       int64                  `json:"seq"`
+	TS        int64                  `json:"ts"`
+	RoundNo   int                    `json:"roundNo"`
+	Action    string                 `json:"action"`
+	Seat      int                    `json:"seat"`
+	Amount    int64                  `json:"amount,omitempty"`
+	LatencyMS int64                  `json:"latencyMs,omitempty"`
+	Meta      map[string]interface{} `json:"meta,omitempty"`
+}
+
+// ReplaySeat carries a seat's identity for the timeline; Cards is only
+// populated for seats the viewer is allowed to see.
+type ReplaySeat struct {
+	SeatIndex int      `json:"seatIndex"`
+	UserID    int64    `json:"userId,string"`
+	Alias     string   `json:"alias"`
+	Cards     []string `json:"cards,omitempty"`
+}
+
+// MatchReplay is the response of the replay endpoints: the ordered action
+// timeline for a round range plus seat identities and the final results.
+type MatchReplay struct {
+	MatchID int64           `json:"matchId"`
+	TableID int64           `json:"tableId"`
+	Seats   []ReplaySeat    `json:"seats"`
+	Actions []ReplayAction  `json:"actions"`
+	Results json.RawMessage `json:"results,omitempty"`
+	// NextRound is set when rounds beyond the requested range exist, for
+	// the client's next page; nil means the timeline is complete.
+	NextRound *int `json:"nextRound,omitempty"`
+}
+
+// ReplayQuery selects a round range of a match's timeline. FromRound zero
+// starts at the beginning; RoundCount zero or less means no limit. AllSeats
+// is the admin variant: every seat's cards decrypt instead of the viewer's.
+type ReplayQuery struct {
+	ViewerID   int64
+	FromRound  int
+	RoundCount int
+	AllSeats   bool
+}
+
+// MatchReplay stitches a match's persisted round logs into an ordered action
+// timeline. Only participants (or the admin variant) may read it, and card
+// decryption is limited to the viewer's own seat unless AllSeats is set.
+func (s *Service) MatchReplay(ctx context.Context, matchID int64, q ReplayQuery) (*MatchReplay, error) {
+	var match model.Match
+	if err := s.db.WithContext(ctx).First(&match, matchID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErr.ErrMatchNotFound
+		}
+		return nil, err
+	}
+
+	var table model.Table
+	if err := s.db.WithContext(ctx).First(&table, match.TableID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	seats, seatByUser, _ := parsePlayersJSON(json.RawMessage(table.PlayersJSON))
+
+	if !q.AllSeats && !replayParticipant(q.ViewerID, seatByUser, match.ResultJSON) {
+		return nil, appErr.ErrTableAccessDenied
+	}
+
+	var logs []model.MatchRoundLog
+	if err := s.db.WithContext(ctx).
+		Where("match_id = ?", matchID).
+		Order("id ASC").
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	replay := &MatchReplay{
+		MatchID: matchID,
+		TableID: match.TableID,
+		Results: json.RawMessage(match.ResultJSON),
+	}
+
+	lastRound := q.FromRound
+	if q.RoundCount > 0 {
+		lastRound = q.FromRound + q.RoundCount - 1
+	}
+	cardsByUser := make(map[int64][]string)
+	for _, roundLog := range logs {
+		if roundLog.RoundNo < q.FromRound {
+			continue
+		}
+		if q.RoundCount > 0 && roundLog.RoundNo > lastRound {
+			next := roundLog.RoundNo
+			if replay.NextRound == nil || *replay.NextRound > next {
+				replay.NextRound = &next
+			}
+			continue
+		}
+		var actions []actionEntry
+		if err := json.Unmarshal(roundLog.ActionsJSON, &actions); err != nil {
+			continue
+		}
+		for _, entry := range actions {
+			replay.Actions = append(replay.Actions, ReplayAction{
+				Seq:       entry.Seq,
+				TS:        entry.TS,
+				RoundNo:   roundLog.RoundNo,
+				Action:    entry.Action,
+				Seat:      entry.Seat,
+				Amount:    entry.Amount,
+				LatencyMS: entry.LatencyMS,
+				Meta:      entry.Meta,
+			})
+		}
+		decryptLogCards(roundLog.CardsJSON, q, cardsByUser)
+	}
+
+	for _, seat := range seats {
+		replay.Seats = append(replay.Seats, ReplaySeat{
+			SeatIndex: seat.SeatIndex,
+			UserID:    seat.UserID,
+			Alias:     seat.Alias,
+			Cards:     cardsByUser[seat.UserID],
+		})
+	}
+	return replay, nil
+}
+
+// replayParticipant reports whether the viewer took part in the match: still
+// in the table lineup, or present in the settled results after the lineup
+// moved on.
+func replayParticipant(viewerID int64, seatByUser map[int64]int, resultJSON []byte) bool {
+	if _, ok := seatByUser[viewerID]; ok {
+		return true
+	}
+	for _, rec := range parseResultRecords(resultJSON) {
+		if rec.UserID == viewerID {
+			return true
+		}
+	}
+	return false
+}
+
+// decryptLogCards opens the card snapshots a round log carries, limited to
+// the seats the query may see. Later rounds overwrite earlier snapshots so
+// the timeline ends with each seat's final hand.
+func decryptLogCards(raw []byte, q ReplayQuery, out map[int64][]string) {
+	if len(raw) == 0 {
+		return
+	}
+	var encrypted map[string]string
+	if json.Unmarshal(raw, &encrypted) != nil {
+		return
+	}
+	for uidStr, enc := range encrypted {
+		userID, err := strconv.ParseInt(uidStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if !q.AllSeats && userID != q.ViewerID {
+			continue
+		}
+		plain, err := decryptForUser(userID, enc)
+		if err != nil {
+			continue
+		}
+		var cards []string
+		if json.Unmarshal(plain, &cards) == nil {
+			out[userID] = cards
+		}
+	}
+}
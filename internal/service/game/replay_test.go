@@ -0,0 +1,189 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupReplayMatch plays one full fold hand with round logs persisted, and
+// returns the service plus the match it settled.
+func setupReplayMatch(t *testing.T) (*Service, int64) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	// A shared-cache DSN keeps the async round-log writers on the same
+	// in-memory database as the assertions below; naming it per test keeps
+	// fixtures isolated from each other.
+	dsn := fmt.Sprintf("file:replay_%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}, &model.Match{}, &model.Scene{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	scene := model.Scene{ID: 1, Name: "test", SeatCount: 2, BasePi: 10, MinUnitPi: 1}
+	table := model.Table{
+		ID:          1,
+		SceneID:     1,
+		SeatCount:   2,
+		PlayersJSON: []byte(`{"1":{"userId":101,"alias":"甲","chips":1000},"2":{"userId":102,"alias":"乙","chips":1000}}`),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("seed table: %v", err)
+	}
+	match := model.Match{TableID: 1, SceneID: 1}
+	if err := db.Create(&match).Error; err != nil {
+		t.Fatalf("seed match: %v", err)
+	}
+
+	rt, err := newTableRuntime(context.Background(), db, table, scene, match.ID, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(rt.stop)
+
+	if err := rt.HandleAction(101, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if err := rt.HandleAction(102, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	actAsTurnOwner(t, rt, "fold", nil)
+
+	// Round logs are written off the loop; wait for the deal and the fold to
+	// land before reading them back.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var count int64
+		db.Model(&model.MatchRoundLog{}).Where("match_id = ?", match.ID).Count(&count)
+		if count >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("round logs never landed, have %d", count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return &Service{db: db}, match.ID
+}
+
+func TestMatchReplayStitchesTimelineForParticipant(t *testing.T) {
+	svc, matchID := setupReplayMatch(t)
+	ctx := context.Background()
+
+	replay, err := svc.MatchReplay(ctx, matchID, ReplayQuery{ViewerID: 101})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if replay.MatchID != matchID || replay.TableID != 1 {
+		t.Fatalf("unexpected replay header: %+v", replay)
+	}
+	if len(replay.Actions) < 2 {
+		t.Fatalf("expected deal and fold in the timeline, got %d actions", len(replay.Actions))
+	}
+	for i := 1; i < len(replay.Actions); i++ {
+		if replay.Actions[i].Seq <= replay.Actions[i-1].Seq {
+			t.Fatalf("timeline out of order at %d: %+v", i, replay.Actions)
+		}
+	}
+	sawFold := false
+	for _, action := range replay.Actions {
+		if action.Action == "fold" || action.Action == "auto_fold" {
+			sawFold = true
+		}
+	}
+	if !sawFold {
+		t.Fatal("fold missing from the timeline")
+	}
+
+	var ownCards, otherCards []string
+	for _, seat := range replay.Seats {
+		if seat.Alias == "" {
+			t.Fatalf("seat %d lost its alias", seat.SeatIndex)
+		}
+		switch seat.UserID {
+		case 101:
+			ownCards = seat.Cards
+		case 102:
+			otherCards = seat.Cards
+		}
+	}
+	if len(ownCards) == 0 {
+		t.Fatal("viewer's own cards did not decrypt")
+	}
+	if len(otherCards) != 0 {
+		t.Fatalf("opponent cards leaked to a participant: %v", otherCards)
+	}
+}
+
+func TestMatchReplayAdminDecryptsAllSeats(t *testing.T) {
+	svc, matchID := setupReplayMatch(t)
+
+	replay, err := svc.MatchReplay(context.Background(), matchID, ReplayQuery{AllSeats: true})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	for _, seat := range replay.Seats {
+		if len(seat.Cards) == 0 {
+			t.Fatalf("seat %d cards missing in the admin variant", seat.SeatIndex)
+		}
+	}
+}
+
+func TestMatchReplayRejectsNonParticipant(t *testing.T) {
+	svc, matchID := setupReplayMatch(t)
+
+	if _, err := svc.MatchReplay(context.Background(), matchID, ReplayQuery{ViewerID: 999}); !errors.Is(err, appErr.ErrTableAccessDenied) {
+		t.Fatalf("expected access denied, got %v", err)
+	}
+	if _, err := svc.MatchReplay(context.Background(), 424242, ReplayQuery{ViewerID: 101}); !errors.Is(err, appErr.ErrMatchNotFound) {
+		t.Fatalf("expected match not found, got %v", err)
+	}
+}
+
+func TestMatchReplayPaginatesByRound(t *testing.T) {
+	svc, matchID := setupReplayMatch(t)
+	ctx := context.Background()
+
+	// The fold hand only reaches round 1, so a page covering round 0 alone
+	// must point at round 1 as the next page.
+	page, err := svc.MatchReplay(ctx, matchID, ReplayQuery{ViewerID: 101, FromRound: 0, RoundCount: 1})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if page.NextRound == nil || *page.NextRound != 1 {
+		t.Fatalf("expected next page at round 1, got %+v", page.NextRound)
+	}
+	for _, action := range page.Actions {
+		if action.RoundNo > 0 {
+			t.Fatalf("action beyond the page leaked: %+v", action)
+		}
+	}
+
+	rest, err := svc.MatchReplay(ctx, matchID, ReplayQuery{ViewerID: 101, FromRound: 1})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if rest.NextRound != nil {
+		t.Fatalf("unexpected next page: %d", *rest.NextRound)
+	}
+	if len(rest.Actions) == 0 {
+		t.Fatal("round 1 page is empty")
+	}
+}
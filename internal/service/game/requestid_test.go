@@ -0,0 +1,98 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	appErr "dx-service/pkg/errors"
+)
+
+func actionCmd(userID int64, action, requestID string, data json.RawMessage) (loopCommand, chan ActionResult) {
+	result := make(chan ActionResult, 1)
+	return loopCommand{
+		kind:      "action",
+		userID:    userID,
+		action:    action,
+		data:      data,
+		requestID: requestID,
+		result:    result,
+	}, result
+}
+
+func TestRequestIDRetryDoesNotReapplyAction(t *testing.T) {
+	rt := moderationRuntime()
+
+	cmd, res := actionCmd(101, "chat", "r1", json.RawMessage(`{"text":"hi"}`))
+	rt.handleCommand(cmd)
+	if out := <-res; out.Err != nil || out.Duplicate {
+		t.Fatalf("first attempt: expected fresh success, got err %v duplicate %v", out.Err, out.Duplicate)
+	}
+	if got := len(drainMessages(rt.subscribers[102])); got != 1 {
+		t.Fatalf("expected one chat delivered, got %d", got)
+	}
+
+	retry, retryRes := actionCmd(101, "chat", "r1", json.RawMessage(`{"text":"hi"}`))
+	rt.handleCommand(retry)
+	if out := <-retryRes; out.Err != nil || !out.Duplicate {
+		t.Fatalf("retry: expected cached success marked duplicate, got err %v duplicate %v", out.Err, out.Duplicate)
+	}
+	if got := len(drainMessages(rt.subscribers[102])); got != 0 {
+		t.Fatalf("retry must not deliver the chat again, got %d messages", got)
+	}
+}
+
+func TestRequestIDRetryReplaysFirstError(t *testing.T) {
+	rt := moderationRuntime()
+
+	// Burn the chat cooldown so the next distinct request fails.
+	warm, warmRes := actionCmd(101, "chat", "r1", json.RawMessage(`{"text":"hi"}`))
+	rt.handleCommand(warm)
+	if out := <-warmRes; out.Err != nil {
+		t.Fatalf("warm-up chat failed: %v", out.Err)
+	}
+
+	cmd, res := actionCmd(101, "chat", "r2", json.RawMessage(`{"text":"again"}`))
+	rt.handleCommand(cmd)
+	first := <-res
+	if first.Err == nil || first.Duplicate {
+		t.Fatalf("expected fresh cooldown error, got err %v duplicate %v", first.Err, first.Duplicate)
+	}
+
+	retry, retryRes := actionCmd(101, "chat", "r2", json.RawMessage(`{"text":"again"}`))
+	rt.handleCommand(retry)
+	second := <-retryRes
+	if !second.Duplicate {
+		t.Fatal("expected retry answered from the outcome cache")
+	}
+	if !errors.Is(second.Err, first.Err) {
+		t.Fatalf("expected the original error replayed, first %v second %v", first.Err, second.Err)
+	}
+}
+
+func TestRequestIDCacheIsPerUserAndBounded(t *testing.T) {
+	rt := moderationRuntime()
+
+	cmd, res := actionCmd(101, "ping", "shared", nil)
+	rt.handleCommand(cmd)
+	if out := <-res; out.Err != nil || out.Duplicate {
+		t.Fatalf("unexpected outcome for user 101: %+v", out)
+	}
+
+	// Another user reusing the same requestId is a fresh evaluation.
+	other, otherRes := actionCmd(102, "ping", "shared", nil)
+	rt.handleCommand(other)
+	if out := <-otherRes; out.Err != nil || out.Duplicate {
+		t.Fatalf("requestIds must be scoped per user, got %+v", out)
+	}
+
+	for i := 0; i < requestCacheSize; i++ {
+		rt.rememberOutcomeLocked(101, string(rune('a'+i%26))+string(rune('0'+i%10)), appErr.ErrStaleTurn)
+	}
+	if got := len(rt.reqOutcomes[101]); got != requestCacheSize {
+		t.Fatalf("expected cache capped at %d, got %d", requestCacheSize, got)
+	}
+	if _, ok := rt.cachedOutcomeLocked(101, "shared"); ok {
+		t.Fatal("expected the oldest entry evicted once the cache is full")
+	}
+}
@@ -0,0 +1,118 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func resumeRuntime() (*TableRuntime, map[int64]chan OutgoingMessage) {
+	chans := map[int64]chan OutgoingMessage{
+		101: make(chan OutgoingMessage, msgHistorySize*2),
+		102: make(chan OutgoingMessage, msgHistorySize*2),
+	}
+	rt := &TableRuntime{
+		phase:      PhasePlaying,
+		seatByUser: map[int64]int{101: 1, 102: 2},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 1000, Status: "playing"},
+		},
+		subscribers: map[int64]chan OutgoingMessage{
+			101: chans[101],
+			102: chans[102],
+		},
+	}
+	return rt, chans
+}
+
+func TestRejoinReplaysMissedMessages(t *testing.T) {
+	rt, chans := resumeRuntime()
+	for i := 0; i < 5; i++ {
+		rt.broadcastMessageLocked(OutgoingMessage{Type: "action", Data: ginH{"n": i}})
+	}
+	drainMessages(chans[101])
+
+	if err := rt.handleActionLocked(101, "rejoin", json.RawMessage(`{"lastSeq":2}`)); err != nil {
+		t.Fatalf("rejoin failed: %v", err)
+	}
+	msgs := drainMessages(chans[101])
+	if len(msgs) != 4 {
+		t.Fatalf("expected 3 replayed messages plus fresh state, got %d", len(msgs))
+	}
+	for i, want := range []int64{3, 4, 5} {
+		if msgs[i].Type != "action" || msgs[i].Seq != want {
+			t.Fatalf("replay %d: expected action seq %d, got %s seq %d", i, want, msgs[i].Type, msgs[i].Seq)
+		}
+	}
+	last := msgs[3]
+	if last.Type != "state" || last.Seq != 6 || last.Resync {
+		t.Fatalf("expected trailing fresh state seq 6 without resync, got %s seq %d resync %v",
+			last.Type, last.Seq, last.Resync)
+	}
+}
+
+func TestRejoinGapOverflowSendsResync(t *testing.T) {
+	rt, chans := resumeRuntime()
+	for i := 0; i < msgHistorySize+10; i++ {
+		rt.broadcastMessageLocked(OutgoingMessage{Type: "action"})
+	}
+	drainMessages(chans[101])
+
+	if err := rt.handleActionLocked(101, "rejoin", json.RawMessage(`{"lastSeq":1}`)); err != nil {
+		t.Fatalf("rejoin failed: %v", err)
+	}
+	msgs := drainMessages(chans[101])
+	if len(msgs) != 1 {
+		t.Fatalf("expected a single resync state, got %d messages", len(msgs))
+	}
+	if msgs[0].Type != "state" || !msgs[0].Resync {
+		t.Fatalf("expected full state with resync flag, got %s resync %v", msgs[0].Type, msgs[0].Resync)
+	}
+}
+
+func TestRejoinWithoutLastSeqSendsStateOnly(t *testing.T) {
+	rt, chans := resumeRuntime()
+	rt.broadcastMessageLocked(OutgoingMessage{Type: "action"})
+	drainMessages(chans[101])
+
+	if err := rt.handleActionLocked(101, "rejoin", nil); err != nil {
+		t.Fatalf("rejoin failed: %v", err)
+	}
+	msgs := drainMessages(chans[101])
+	if len(msgs) != 1 || msgs[0].Type != "state" || msgs[0].Resync {
+		t.Fatalf("expected a single plain state, got %+v", msgs)
+	}
+}
+
+func TestPerUserSeqMonotonicAcrossDeliveryPaths(t *testing.T) {
+	rt, chans := resumeRuntime()
+	for i := 0; i < 12; i++ {
+		switch i % 4 {
+		case 0:
+			rt.broadcastMessageLocked(OutgoingMessage{Type: "action"})
+		case 1:
+			rt.pushStateLocked(101)
+		case 2:
+			rt.broadcastStateLocked()
+		default:
+			rt.pushMessageLocked(102, OutgoingMessage{Type: "pong"})
+		}
+	}
+
+	counts := map[int64]int{}
+	for uid, ch := range chans {
+		var last int64
+		for i, msg := range drainMessages(ch) {
+			if msg.Seq != last+1 {
+				t.Fatalf("user %d message %d: expected seq %d, got %d", uid, i, last+1, msg.Seq)
+			}
+			last = msg.Seq
+			counts[uid]++
+		}
+	}
+	// 3 broadcasts + 3 broadcast states reach both; 3 targeted states go to
+	// 101 and 3 pongs to 102, so both streams end at seq 9 with no gaps.
+	if counts[101] != 9 || counts[102] != 9 {
+		t.Fatalf("expected 9 deliveries per user, got 101=%d 102=%d", counts[101], counts[102])
+	}
+}
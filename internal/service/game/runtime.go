@@ -16,15 +16,21 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"dx-service/internal/config"
 	"dx-service/internal/model"
+	scenesvc "dx-service/internal/service/scene"
 	appErr "dx-service/pkg/errors"
 	"dx-service/pkg/logger"
 
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Phase string
@@ -39,6 +45,74 @@ const (
 const (
 	defaultTurnSeconds   = 15
 	defaultCountdownUnit = time.Second
+
+	// defaultReadyTimeoutSeconds bounds the waiting phase when the scene
+	// doesn't set its own ReadyTimeoutSeconds; a table never sits in
+	// waiting forever because one player withholds their ready.
+	defaultReadyTimeoutSeconds = 30
+
+	spectatorFlushInterval = 250 * time.Millisecond
+	maxDelayedMessages     = 512
+
+	// topUpGraceSeconds is how long a zero-chip seat may rebuy after a hand
+	// before the next deal eliminates it.
+	topUpGraceSeconds = 30
+
+	// defaultResultGraceSeconds is how long the result screen stays up after
+	// a hand ends before the table moves on; config game.resultGraceSeconds
+	// overrides it.
+	defaultResultGraceSeconds = 30
+
+	// defaultStateFlushMS caps full state broadcasts to one per interval so
+	// auto-run bursts don't emit five states in a few milliseconds; config
+	// game.stateFlushMs overrides it.
+	defaultStateFlushMS = 50
+
+	// defaultTimeoutStrikeLimit is how many consecutive turn timeouts it
+	// takes before a seat is folded for the rest of the hand and benched.
+	defaultTimeoutStrikeLimit = 2
+
+	// disconnectGraceSeconds is how long a seat may stay without any live
+	// subscription mid-hand before the same benching applies.
+	disconnectGraceSeconds = 30
+
+	// shutdownReconnectHintMS is how long the server_shutdown notice asks
+	// clients to wait before reconnecting, giving the replacement process
+	// time to come up.
+	shutdownReconnectHintMS = 3000
+)
+
+// CloseInfo is the payload of the final `close` message pushed before a
+// subscriber channel shuts down.
+type CloseInfo struct {
+	Reason string `json:"reason"`
+}
+
+// Close reasons sent as a final `close` message before a subscriber channel
+// shuts; the WS layer maps them onto application close codes.
+const (
+	CloseReasonShutdown  = "server_shutdown"
+	CloseReasonTakeover  = "session_takeover"
+	CloseReasonKicked    = "kicked"
+	CloseReasonBanned    = "banned"
+	CloseReasonDissolved = "table_dissolved"
+	CloseReasonExpired   = "table_expired"
+	// CloseReasonTransferring tells clients to reconnect immediately: the
+	// table is being handed off to another instance mid-deploy and resumes
+	// there from its persisted snapshot.
+	CloseReasonTransferring = "reconnect_to"
+)
+
+// Turn-timeout auto-action policies a scene can select (Scene.TimeoutPolicy).
+const (
+	// TimeoutPolicyPass passes when legal and folds otherwise (default).
+	TimeoutPolicyPass = "auto_pass"
+	// TimeoutPolicyFold always folds the timed-out seat.
+	TimeoutPolicyFold = "auto_fold"
+	// TimeoutPolicyCall passes when legal, otherwise calls — but only when
+	// the outstanding amount is fully affordable and within one basePi, so
+	// a timeout can never commit more than a base unit.
+	TimeoutPolicyCall = "auto_call"
 )
 
 type SeatState struct {
@@ -48,14 +122,48 @@ type SeatState struct {
 	Chips     int64  `json:"chips"`
 	Bet       int64  `json:"bet"`
 	Avatar    string `json:"avatar,omitempty"`
-	Status    string `json:"status"` // waiting/playing/folded/eliminated
-	Ready     bool   `json:"-"`
-	cards     []string
+
+	// TotalContribution accumulates everything the seat paid into the pot
+	// this hand (ante, calls, raises, knocks). Unlike Bet it survives any
+	// per-round reset or rewrite, so settlement losses are computed from it.
+	TotalContribution int64  `json:"totalContribution"`
+	Status            string `json:"status"` // waiting/playing/folded/eliminated/resting
+	Ready             bool   `json:"-"`
+	cards             []string
+
+	// TimeBankLeft is the seat's remaining auto time-bank seconds, filled at
+	// export time from the runtime's spent ledger; zero when the scene has
+	// no bank.
+	TimeBankLeft int `json:"timeBankLeft,omitempty"`
+
+	// Running session totals, carried across hands and persisted with
+	// PlayersJSON so a rebuilt runtime keeps them.
+	SessionNet  int64 `json:"sessionNet"`
+	HandsPlayed int   `json:"handsPlayed"`
+
+	// Cards is the unredacted hole-card view, set only for privileged admin
+	// observers; player-facing exports leave it empty.
+	Cards []string `json:"cards,omitempty"`
+
+	// PublicCards is the showdown view: once the hand reaches Settling or
+	// Ended every non-folded seat's hole cards are exposed here so clients
+	// can render the comparison. Folded seats stay hidden, and the field is
+	// only populated at export time so a new hand never carries it over.
+	PublicCards []string `json:"publicCards,omitempty"`
 
 	// Chexuan specific split result (exposed during settle/end)
 	Split *SplitView `json:"split,omitempty"`
 }
 
+// MangoEvent records one change of the table's mango streak so players can
+// see how the current carry was built.
+type MangoEvent struct {
+	Hand    int    `json:"hand"`
+	Trigger string `json:"trigger"` // liuju/reset
+	Streak  int    `json:"streak"`
+	Carried int64  `json:"carried"` // value the streak adds to the next pot
+}
+
 type LogItem struct {
 	ID        string `json:"id"`
 	Timestamp int64  `json:"timestamp"`
@@ -63,24 +171,70 @@ type LogItem struct {
 }
 
 type TableState struct {
-	TableID        int64       `json:"tableId,string"`
-	Phase          Phase       `json:"phase"`
-	Round          int         `json:"round"`
-	TurnSeat       int         `json:"turnSeat"`
-	LastRaise      int64       `json:"lastRaise"`
-	Pot            int64       `json:"pot"`
-	MangoStreak    int         `json:"mangoStreak"`
-	Countdown      int         `json:"countdown"`
-	AllowedActions []string    `json:"allowedActions"`
-	Seats          []SeatState `json:"seats"`
-	MyCards        []string    `json:"myCards"`
-	Logs           []LogItem   `json:"logs"`
-	Result         interface{} `json:"result,omitempty"`
+	TableID      int64        `json:"tableId,string"`
+	Phase        Phase        `json:"phase"`
+	Round        int          `json:"round"`
+	TurnSeat     int          `json:"turnSeat"`
+	BankerSeat   int          `json:"bankerSeat"` // dealer button; first actor is the next seat
+	LastRaise    int64        `json:"lastRaise"`
+	Pot          int64        `json:"pot"`
+	MangoStreak  int          `json:"mangoStreak"`
+	MangoCarry   int64        `json:"mangoCarry"` // projected extra value the next pot carries
+	MangoHistory []MangoEvent `json:"mangoHistory,omitempty"`
+	Countdown    int          `json:"countdown"`
+	// ServerTime and TurnDeadline are absolute unix millis so clients can
+	// run their own countdown without the relative Countdown drifting over
+	// network latency. Countdown stays for older clients.
+	ServerTime   int64 `json:"serverTime"`
+	TurnDeadline int64 `json:"turnDeadline,omitempty"`
+	// TurnToken increases every time the turn advances; turn actions echo it
+	// so the server can tell a late retry from an action on the current turn.
+	TurnToken      int64    `json:"turnToken"`
+	AllowedActions []string `json:"allowedActions"`
+
+	// Betting bounds for the requesting user, set only when it is their
+	// turn so clients never re-implement the server's calculations. The
+	// loose fields predate BetInfo and stay for older clients.
+	CallAmount *int64      `json:"callAmount,omitempty"`
+	MinRaise   *int64      `json:"minRaise,omitempty"`
+	MaxRaise   *int64      `json:"maxRaise,omitempty"`
+	BetInfo    *BetInfo    `json:"betInfo,omitempty"`
+	Seats      []SeatState `json:"seats"`
+	MyCards    []string    `json:"myCards"`
+	Logs       []LogItem   `json:"logs"`
+	Result     interface{} `json:"result,omitempty"`
+
+	// Active admin chat mutes, plus the users this viewer muted locally.
+	Mutes      []MuteInfo `json:"mutes,omitempty"`
+	LocalMutes []int64    `json:"localMutes,omitempty"`
+
+	// MyPrecommit is the requesting user's own parked turn intent; other
+	// players' precommits are never exported.
+	MyPrecommit string `json:"myPrecommit,omitempty"`
+
+	// Provably-fair deal: the commitment is visible while the hand plays;
+	// the seed and verification instructions appear once it has ended.
+	DealCommitment string `json:"dealCommitment,omitempty"`
+	DealSeed       string `json:"dealSeed,omitempty"`
+	DealVerify     string `json:"dealVerify,omitempty"`
 
 	// Internal field to pass results to callback
 	SettlementResults []PlayerResult
 }
 
+// BetInfo carries the acting user's betting bounds, computed by the same
+// helpers the action validators run so the client's slider can never
+// disagree with the server about a legal amount.
+type BetInfo struct {
+	CallAmount int64 `json:"callAmount"`
+	MinRaise   int64 `json:"minRaise"`
+	MaxRaise   int64 `json:"maxRaise"`
+	// RaiseConvertsToBobo is set when a raise would be handled as
+	// knock_bobo instead, so the client shows the knock control rather
+	// than the amount slider.
+	RaiseConvertsToBobo bool `json:"raiseConvertsToBobo"`
+}
+
 type SplitView struct {
 	Head     []string `json:"head"`
 	Tail     []string `json:"tail"`
@@ -93,43 +247,194 @@ type OutgoingMessage struct {
 	Type string      `json:"type"`
 	Seq  int64       `json:"seq"`
 	Data interface{} `json:"data"`
+	// Resync marks a full state sent because the reconnect gap outgrew the
+	// replay buffer; the client must drop its local history and start over.
+	Resync bool `json:"resync,omitempty"`
 }
 
-type loopCommand struct {
-	kind   string
+// delayedMessage holds a spectator message until its due time so that
+// spectators cannot relay real-time action to seated players.
+type delayedMessage struct {
+	due    time.Time
 	userID int64
-	action string
-	data   json.RawMessage
-	resp   chan error
-	subCh  chan OutgoingMessage
+	msg    OutgoingMessage
+}
+
+type loopCommand struct {
+	kind string
+	// ctx carries the caller's request context (trace correlation) into the
+	// loop; it does not bound the command itself.
+	ctx        context.Context
+	userID     int64
+	action     string
+	data       json.RawMessage
+	requestID  string            // optional client retry token; see HandleActionID
+	result     chan ActionResult // set instead of resp by the requestId-aware path
+	resp       chan error
+	subCh      chan OutgoingMessage
+	adjust     *AdminAdjustment
+	adjustResp chan adminAdjustResult
+	seat       *seatRequest
+	mute       *muteRequest
+	showCards  bool
+	spectator  bool
+	matchID    int64
+	info       chan runtimeInfo
+	snap       chan RuntimeSnapshot
+}
+
+// seatRequest seats a new player between hands, e.g. an accepted invitation.
+type seatRequest struct {
+	UserID int64
+	Alias  string
+	Chips  int64
+}
+
+// AdminAdjustment is the whitelist of runtime fields an operator may change
+// between hands. Nil fields are left untouched.
+type AdminAdjustment struct {
+	MangoStreak *int
+	TurnSeconds *int
+	Reason      string
+}
+
+type adminAdjustResult struct {
+	before map[string]interface{}
+	after  map[string]interface{}
+	err    error
+}
+
+// runtimeInfo is the loop's answer to a current-table discovery probe.
+type runtimeInfo struct {
+	phase   Phase
+	matchID int64
 }
 
 type TableRuntime struct {
 	tableID     int64
 	matchID     int64
 	sceneID     int64
+	seatCount   int
 	basePi      int64
+	minIn       int64
+	maxIn       int64
 	minUnitPi   int64
+	timeBankSec int
+	turnSeconds int
+	maxPot      int64 // scene pot cap in points, 0 = uncapped
 	boboEnabled bool
-	chexuanMode bool
-	db          *gorm.DB
-	phase       Phase
-	round       int
-	turnSeat    int
-	lastRaise   int64
-	pot         int64
-	mangoStreak int
-	bankerSeat  int
-
-	round1Bet   bool
-	round2Bet   bool
-	round2Knock bool
-	lastAggSeat int
-	tailBigWin  bool
-
-	seats      []SeatState
-	seatByUser map[int64]int
-	roundActed map[int]bool
+	// variant is the mode rule set the scene selected; nil falls back to
+	// defaultVariant via variantLocked for hand-built test runtimes.
+	variant Variant
+	// practice marks a tutorial table: nothing backs it in the DB, settlement
+	// moves play chips only, and the learner (practiceUserID, the one human
+	// seat) gets a hint explaining their hand at showdown.
+	practice       bool
+	practiceUserID int64
+	db             *gorm.DB
+	phase          Phase
+	round          int
+	turnSeat       int
+	lastRaise      int64
+	pot            int64
+	mangoStreak    int
+	mangoHistory   []MangoEvent
+	handNo         int
+	bankerSeat     int
+
+	round1Bet      bool
+	round2Bet      bool
+	round2Knock    bool
+	lastAggSeat    int
+	tailBigWin     bool
+	potCapNotified bool
+
+	seats        []SeatState
+	seatByUser   map[int64]int
+	roundActed   map[int]bool
+	timeBankUsed map[int]bool // seatIdx -> used the time bank this hand
+
+	// Auto time bank: beyond the base turn timer each seat holds a budget
+	// (scene.TimeBankBudgetSeconds) that burns when the base countdown runs
+	// out, instead of auto-acting right away. timeBankSpent ledgers the
+	// seconds each seat has consumed; the burn fields track the one seat
+	// currently eating into its bank so an action mid-burn is charged only
+	// for the time actually used.
+	timeBankBudgetSec int
+	timeBankRefreshes bool // refill timeBankSpent at every hand start
+	timeBankSpent     map[int]int
+	timeBankBurnSeat  int
+	timeBankBurnStart time.Time
+
+	maxHandsStreak int
+	seatHands      map[int]int       // seatIdx -> consecutive hands played
+	restedSeats    map[int]bool      // seats that sat out the previous hand for fatigue
+	topUpDeadline  map[int]time.Time // zero-chip seats must rebuy before this
+
+	// Table lifetime limits. Once either is exceeded the table closes right
+	// after the hand in progress settles, so a table never dies mid-hand and
+	// seated players always get the hand they started.
+	maxHands       int
+	maxLifetime    time.Duration
+	openedAt       time.Time
+	requeueOptIn   map[int64]bool // userID -> re-queue me when this table expires
+	expiredReason  string         // set at close time: "max_hands" / "max_duration" / "dissolved"
+	expiredRequeue []requeueCandidate
+
+	// lastActiveAt is when the loop last handled a command; the idle sweeper
+	// uses it to reclaim waiting runtimes nobody is driving.
+	lastActiveAt time.Time
+
+	// Post-end grace window: after a hand settles the result screen stays up
+	// for resultGrace (table_closing announces the deadline); the next-hand
+	// or close transition waits until the window ends or every active seat
+	// has acked the result.
+	resultGrace  time.Duration
+	closingUntil time.Time
+	resultAcks   map[int64]bool // userID -> acked this hand's result
+	pendingMatch int64          // next match held back by the window, 0 = none
+
+	// timeoutPolicy is the scene's turn-timeout auto-action; empty or
+	// unknown values behave as TimeoutPolicyPass.
+	timeoutPolicy string
+
+	// Absent-player shedding: consecutive turn timeouts per seat, seats
+	// benched ("sitting out") until they ready up again, and the deadline a
+	// vanished subscriber has to resubscribe mid-hand before being benched.
+	strikeLimit        int
+	timeoutStrikes     map[int]int
+	sittingOut         map[int]bool
+	disconnectDeadline map[int64]time.Time
+
+	// Chat moderation: admin mutes by user with expiry, plus each viewer's
+	// personal ignore list applied when chat messages are delivered.
+	mutedUntil  map[int64]time.Time
+	lastChatAt  map[int64]time.Time      // per-user chat rate limit
+	lastEmoteAt map[int64]time.Time      // per-user emote cooldown
+	localMutes  map[int64]map[int64]bool // viewer userID -> muted userIDs
+
+	// precommits holds each seat's pre-selected action for its next turn
+	// (call_any / check_fold), applied the moment the turn opens and cleared
+	// on use, on any manual action, and at every new hand.
+	precommits map[int]string
+
+	// Chip conservation counters: stacks on the table plus cash-outs must
+	// always equal buy-ins plus top-ups (per-hand nets sum to zero).
+	totalBuyIns   int64
+	totalTopUps   int64
+	totalCashOuts int64
+
+	spectatorDelay time.Duration
+	delayed        []delayedMessage // time-indexed spectator delivery queue
+
+	// actionMask limits the optional turn actions a scene permits;
+	// nil means everything is allowed.
+	actionMask map[string]bool
+
+	// rdb backs incremental log persistence; pendingLogs buffers entries
+	// between flushes so each action costs at most one pipelined write.
+	rdb         *redis.Client
+	pendingLogs []LogItem
 
 	firstRaiseDone bool
 	raisedRound1   bool
@@ -138,57 +443,267 @@ type TableRuntime struct {
 	seq            int64
 	deck           []string
 
-	subscribers  map[int64]chan OutgoingMessage
-	timer        *time.Timer
-	timerC       <-chan time.Time
-	turnDeadline time.Time
-	cmdCh        chan loopCommand
-	quitCh       chan struct{}
+	subscribers map[int64]chan OutgoingMessage
+	remoteSubs  map[int64]int  // refcount of subscribers relayed by other instances
+	spectators  map[int64]bool // read-only subscribers: never seated, every action rejected
+	// recent buffers the last msgHistorySize messages delivered to each
+	// subscriber so a rejoin carrying lastSeq can replay what it missed;
+	// recentFloor holds the seq of the newest entry evicted from each buffer.
+	recent      map[int64][]OutgoingMessage
+	recentFloor map[int64]int64
+	// userSeq holds each subscriber's delivery counter; sendLocked stamps
+	// every outgoing message with the next value so each client observes a
+	// strictly increasing seq regardless of how the message was produced.
+	// Counters survive resubscribes so the resume protocol stays coherent.
+	userSeq map[int64]int64
+	// reqOutcomes remembers the last requestCacheSize client requestIds per
+	// user with the outcome their first evaluation produced, so flaky-network
+	// retries never apply an action twice.
+	reqOutcomes   map[int64][]requestOutcome
+	observers     map[int64]*observerSub
+	bridge        *bridge
+	timer         *time.Timer
+	timerC        <-chan time.Time
+	turnDeadline  time.Time
+	turnStartedAt time.Time
+	turnToken     int64 // bumped on every turn advance, echoed by clients
+
+	// Waiting-phase deadline: once readyDeadline passes the hand deals
+	// without the absentees or the table dissolves
+	// (handleReadyTimeoutLocked). Zero readyTimeout means the scene didn't
+	// configure one and defaultReadyTimeoutSeconds applies.
+	readyTimeout  time.Duration
+	readyDeadline time.Time
+	cmdCh         chan loopCommand
+	quitCh        chan struct{}
+	stopOnce      sync.Once
+	stopReason    string // why the loop is going away; sent as a final close frame
+
+	// Coalesced state broadcasts: within stateFlushEvery of the previous
+	// flush, broadcastStateLocked only marks the state dirty and arms the
+	// flush timer; event messages force the pending state out first so
+	// ordering is preserved. Zero (bare test runtimes) disables coalescing.
+	stateFlushEvery time.Duration
+	stateDirty      bool
+	lastStateFlush  time.Time
+	stateFlushTimer *time.Timer
+	stateFlushC     <-chan time.Time
+	// eventSubs holds subscribers that negotiated granular events via the
+	// hello capability; the state flush skips them for transitions they
+	// already received as events. stateForAll marks a pending flush that
+	// nobody may skip (settlement, admin changes, anything un-evented).
+	eventSubs   map[int64]bool
+	stateForAll bool
+
+	// Anomaly handling: frozen parks the table after an invariant violation
+	// (no gameplay actions, no timers, no settlement) until an admin reviews
+	// it; onAnomaly relays the report to the service's error-reporting hook,
+	// nil (bare test runtimes) skips the relay.
+	frozen          bool
+	freezeOnAnomaly bool
+	onAnomaly       func(AnomalyReport)
+
+	// transferring marks a runtime whose handoff snapshot has been written;
+	// gameplay is rejected so nothing can diverge from it before the loop
+	// stops.
+	transferring bool
 
 	onFinish func(*TableRuntime)
 
+	// onSeats is notified with the current seated user IDs whenever the
+	// lineup changes, so the service's userID->tableID discovery index stays
+	// current; nil (bare test runtimes) skips the notification.
+	onSeats func(tableID int64, userIDs []int64)
+
+	// ctx scopes background writes (round logs, settlement callbacks) to the
+	// owning service's lifetime; nil (bare test runtimes) means Background.
+	ctx context.Context
+	// actionCtx is the in-flight command's request context, set by the loop
+	// for the duration of one action so trace correlation recorded at action
+	// time reaches the round log writer.
+	actionCtx context.Context
+
+	// Injectable clock and RNG; nil means wall clock / global RNG. The
+	// simulation harness overrides both for deterministic hands.
+	now       func() time.Time
+	rng       *mrand.Rand
+	fixedDeck []string // when set, initDeckLocked deals this instead of shuffling
+
+	// Provably-fair deal: dealSeed drives the shuffle and dealCommitment is
+	// its SHA-256, published before any card is seen. Both empty when a test
+	// injects rng or fixedDeck.
+	dealSeed       string
+	dealCommitment string
+
+	// Round-log integrity: logChain is the running HMAC over this match's
+	// persisted rows, logWriter serializes their inserts into chain order.
+	logChain  string
+	logWriter *roundLogWriter
+
 	// Result cache for service callback
 	SettlementResults []PlayerResult
+	// SettlementLogChain is the final round-log HMAC at settle time; the
+	// settlement seals it into Match.ResultJSON.
+	SettlementLogChain string
 }
 
-func newTableRuntime(db *gorm.DB, table model.Table, scene model.Scene, matchID int64, onFinish func(*TableRuntime)) (*TableRuntime, error) {
+func newTableRuntime(ctx context.Context, db *gorm.DB, table model.Table, scene model.Scene, matchID int64, b *bridge, onFinish func(*TableRuntime)) (*TableRuntime, error) {
+	rt, err := buildTableRuntime(db, table, scene, matchID, b, onFinish)
+	if err != nil {
+		return nil, err
+	}
+	rt.ctx = ctx
+	// Rehydrate the persisted narrative before the loop starts so the first
+	// exported state already carries the hand's history.
+	rt.restoreLogs(rt.runtimeCtx())
+	// A snapshot left behind by a previous process means a hand was in
+	// flight; resume it rather than resetting players to a waiting table.
+	if rt.restoreSnapshot([]byte(table.RuntimeSnapshotJSON)) {
+		rt.appendSystemLogLocked("服务已恢复，牌局继续")
+	}
+	// A waiting table only holds for readies so long; the deadline either
+	// deals without the absentees or dissolves the table.
+	if rt.phase == PhaseWaiting {
+		rt.armReadyTimerLocked()
+	}
+	rt.startLoop()
+	return rt, nil
+}
+
+// buildTableRuntime assembles a runtime without starting its loop; the
+// simulation harness drives commands synchronously instead.
+func buildTableRuntime(db *gorm.DB, table model.Table, scene model.Scene, matchID int64, b *bridge, onFinish func(*TableRuntime)) (*TableRuntime, error) {
 	seats, seatByUser, err := parsePlayersJSON(json.RawMessage(table.PlayersJSON))
 	if err != nil {
+		return nil, fmt.Errorf("%w: %v", appErr.ErrCorruptTable, err)
+	}
+	if err := validatePlayers(json.RawMessage(table.PlayersJSON), seats, seatByUser, table.SeatCount); err != nil {
 		return nil, err
 	}
-	sceneName := strings.ToLower(scene.Name)
-	chexuanMode := scene.BoboEnabled || scene.MangoEnabled || strings.Contains(sceneName, "扯旋") || strings.Contains(sceneName, "chexuan")
-	bankerSeat := 0
-	if len(seats) > 0 {
+	// Mask errors fail open: a bad admin edit should not strand the table,
+	// and the admin endpoints already validate writes.
+	actionMask, _ := scenesvc.ParseAllowedActions(scene.AllowedActionsJSON)
+	// Resume the persisted button so a restart does not hand the first-actor
+	// advantage back to seat one; fresh tables start at the first seat.
+	bankerSeat := table.BankerSeat
+	if bankerSeat == 0 && len(seats) > 0 {
 		bankerSeat = seats[0].SeatIndex
 	}
 	rt := &TableRuntime{
-		tableID:     table.ID,
-		matchID:     matchID,
-		sceneID:     scene.ID,
-		db:          db,
-		basePi:      scene.BasePi,
-		minUnitPi:   scene.MinUnitPi,
-		boboEnabled: scene.BoboEnabled,
-		chexuanMode: chexuanMode,
-		phase:       PhaseWaiting,
-		round:       0,
-		turnSeat:    0,
-		mangoStreak: table.MangoStreak,
-		seats:       seats,
-		seatByUser:  seatByUser,
-		bankerSeat:  bankerSeat,
-		roundActed:  make(map[int]bool),
-		logs:        []LogItem{},
-		subscribers: make(map[int64]chan OutgoingMessage),
-		cmdCh:       make(chan loopCommand, 16),
-		quitCh:      make(chan struct{}),
-		onFinish:    onFinish,
-	}
-	rt.startLoop()
+		tableID:            table.ID,
+		matchID:            matchID,
+		sceneID:            scene.ID,
+		seatCount:          table.SeatCount,
+		db:                 db,
+		basePi:             scene.BasePi,
+		minIn:              scene.MinIn,
+		maxIn:              scene.MaxIn,
+		minUnitPi:          scene.MinUnitPi,
+		timeBankSec:        scene.TimeBankSeconds,
+		timeBankBudgetSec:  scene.TimeBankBudgetSeconds,
+		timeBankRefreshes:  scene.TimeBankRefreshes,
+		timeBankSpent:      make(map[int]int),
+		turnSeconds:        defaultTurnSeconds,
+		readyTimeout:       time.Duration(scene.ReadyTimeoutSeconds) * time.Second,
+		resultGrace:        resultGraceFromConfig(),
+		stateFlushEvery:    stateFlushFromConfig(),
+		freezeOnAnomaly:    freezeOnAnomalyFromConfig(),
+		maxPot:             int64(scene.MaxPotMultiplier) * scene.BasePi,
+		boboEnabled:        scene.BoboEnabled,
+		variant:            variantForScene(scene),
+		phase:              PhaseWaiting,
+		round:              0,
+		turnSeat:           0,
+		mangoStreak:        table.MangoStreak,
+		mangoHistory:       parseMangoHistory(json.RawMessage(table.MangoHistoryJSON)),
+		seats:              seats,
+		seatByUser:         seatByUser,
+		bankerSeat:         bankerSeat,
+		roundActed:         make(map[int]bool),
+		timeBankUsed:       make(map[int]bool),
+		maxHandsStreak:     scene.MaxConsecutiveHands,
+		maxHands:           scene.MaxHands,
+		maxLifetime:        time.Duration(scene.MaxDurationMinutes) * time.Minute,
+		requeueOptIn:       make(map[int64]bool),
+		strikeLimit:        defaultTimeoutStrikeLimit,
+		timeoutPolicy:      scene.TimeoutPolicy,
+		timeoutStrikes:     make(map[int]int),
+		sittingOut:         make(map[int]bool),
+		disconnectDeadline: make(map[int64]time.Time),
+		seatHands:          make(map[int]int),
+		restedSeats:        make(map[int]bool),
+		topUpDeadline:      make(map[int]time.Time),
+		mutedUntil:         make(map[int64]time.Time),
+		lastChatAt:         make(map[int64]time.Time),
+		lastEmoteAt:        make(map[int64]time.Time),
+		precommits:         make(map[int]string),
+		localMutes:         make(map[int64]map[int64]bool),
+		spectatorDelay:     time.Duration(scene.SpectatorDelaySeconds) * time.Second,
+		actionMask:         actionMask,
+		logs:               []LogItem{},
+		subscribers:        make(map[int64]chan OutgoingMessage),
+		observers:          make(map[int64]*observerSub),
+		remoteSubs:         make(map[int64]int),
+		spectators:         make(map[int64]bool),
+		recent:             make(map[int64][]OutgoingMessage),
+		recentFloor:        make(map[int64]int64),
+		userSeq:            make(map[int64]int64),
+		reqOutcomes:        make(map[int64][]requestOutcome),
+		bridge:             b,
+		cmdCh:              make(chan loopCommand, 16),
+		quitCh:             make(chan struct{}),
+		onFinish:           onFinish,
+	}
+	if b != nil {
+		rt.rdb = b.rdb
+	}
+	for _, seat := range seats {
+		rt.totalBuyIns += seat.Chips
+	}
+	rt.openedAt = rt.timeNow()
+	rt.lastActiveAt = rt.openedAt
 	return rt, nil
 }
 
+// validatePlayers rejects PlayersJSON that parsePlayersJSON would quietly
+// repair. Lenient parsing is fine for listings and invites, but a runtime
+// seated from partial data would deal hands in a broken state, so a corrupted
+// row must surface as ErrCorruptTable before play starts.
+func validatePlayers(raw json.RawMessage, seats []SeatState, seatByUser map[int64]int, seatCount int) error {
+	var payload map[string]json.RawMessage
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("%w: %v", appErr.ErrCorruptTable, err)
+		}
+	}
+	if len(payload) == 0 && len(seats) == 0 {
+		// A table not yet seated; nothing to validate.
+		return nil
+	}
+	if len(seats) != len(payload) {
+		return fmt.Errorf("%w: only %d of %d seat entries parseable", appErr.ErrCorruptTable, len(seats), len(payload))
+	}
+	// Fewer seats than the scene allows is a legal partial fill — the top-up
+	// path seats late joiners into the gaps — but overflow never is.
+	if len(seats) > seatCount {
+		return fmt.Errorf("%w: %d seats for seat count %d", appErr.ErrCorruptTable, len(seats), seatCount)
+	}
+	if len(seatByUser) != len(seats) {
+		return fmt.Errorf("%w: duplicate user across seats", appErr.ErrCorruptTable)
+	}
+	seen := make(map[int]bool, len(seats))
+	for _, seat := range seats {
+		if seat.SeatIndex < 1 || seat.SeatIndex > seatCount {
+			return fmt.Errorf("%w: seat index %d out of range 1..%d", appErr.ErrCorruptTable, seat.SeatIndex, seatCount)
+		}
+		if seen[seat.SeatIndex] {
+			return fmt.Errorf("%w: duplicate seat index %d", appErr.ErrCorruptTable, seat.SeatIndex)
+		}
+		seen[seat.SeatIndex] = true
+	}
+	return nil
+}
+
 func parsePlayersJSON(raw json.RawMessage) ([]SeatState, map[int64]int, error) {
 	seats := make([]SeatState, 0)
 	seatByUser := make(map[int64]int)
@@ -226,16 +741,26 @@ func parsePlayersJSON(raw json.RawMessage) ([]SeatState, map[int64]int, error) {
 		if v, ok := data["chips"]; ok {
 			chips, _ = toInt64(v)
 		}
+		sessionNet := int64(0)
+		if v, ok := data["sessionNet"]; ok {
+			sessionNet, _ = toInt64(v)
+		}
+		handsPlayed := int64(0)
+		if v, ok := data["handsPlayed"]; ok {
+			handsPlayed, _ = toInt64(v)
+		}
 		// If chips are 0 in PlayersJSON, it might be missed during creation.
 		// However, MatchService now populates it from BuyIn.
 
 		seat := SeatState{
-			SeatIndex: seatIdx,
-			UserID:    userID,
-			Alias:     alias,
-			Avatar:    avatar,
-			Chips:     chips,
-			Status:    "waiting",
+			SeatIndex:   seatIdx,
+			UserID:      userID,
+			Alias:       alias,
+			Avatar:      avatar,
+			Chips:       chips,
+			Status:      "waiting",
+			SessionNet:  sessionNet,
+			HandsPlayed: int(handsPlayed),
 		}
 		seats = append(seats, seat)
 		seatByUser[userID] = seatIdx
@@ -262,6 +787,18 @@ func toInt64(v interface{}) (int64, error) {
 }
 
 func (rt *TableRuntime) startLoop() {
+	var tickC <-chan time.Time
+	var ticker *time.Ticker
+	if rt.spectatorDelay > 0 {
+		ticker = time.NewTicker(spectatorFlushInterval)
+		tickC = ticker.C
+	}
+	var snapC <-chan time.Time
+	var snapTicker *time.Ticker
+	if rt.db != nil {
+		snapTicker = time.NewTicker(snapshotInterval)
+		snapC = snapTicker.C
+	}
 	go func() {
 		for {
 			select {
@@ -269,7 +806,44 @@ func (rt *TableRuntime) startLoop() {
 				rt.handleCommand(cmd)
 			case <-rt.timerC:
 				rt.handleTurnTimeoutLocked()
+			case <-tickC:
+				rt.flushDelayedLocked(rt.timeNow())
+			case <-snapC:
+				if rt.phase == PhasePlaying {
+					rt.persistRuntimeSnapshotLocked()
+				}
+			case <-rt.stateFlushC:
+				rt.stateFlushTimer = nil
+				rt.stateFlushC = nil
+				rt.flushPendingStateLocked()
 			case <-rt.quitCh:
+				if ticker != nil {
+					ticker.Stop()
+				}
+				if rt.stateFlushTimer != nil {
+					rt.stateFlushTimer.Stop()
+				}
+				if snapTicker != nil {
+					snapTicker.Stop()
+				}
+				rt.delayed = nil
+				reason := rt.stopReason
+				if reason == "" {
+					reason = CloseReasonShutdown
+				}
+				closeMsg := OutgoingMessage{
+					Type: "close",
+					Data: CloseInfo{Reason: reason},
+				}
+				for uid, ch := range rt.subscribers {
+					rt.sendLocked(uid, closeMsg)
+					delete(rt.subscribers, uid)
+					close(ch)
+				}
+				for adminID, sub := range rt.observers {
+					delete(rt.observers, adminID)
+					close(sub.ch)
+				}
 				return
 			}
 		}
@@ -277,9 +851,40 @@ func (rt *TableRuntime) startLoop() {
 }
 
 func (rt *TableRuntime) handleCommand(cmd loopCommand) {
+	if cmd.kind != "snapshot" {
+		// Snapshot probes (admin inspection, the idle sweeper) are not
+		// activity; counting them would keep an idle table alive forever.
+		rt.lastActiveAt = rt.timeNow()
+	}
 	switch cmd.kind {
 	case "subscribe":
+		// Seated players always subscribe as players even when the flag is
+		// set; the spectator role would reject their own turn actions.
+		spectator := cmd.spectator
+		if _, seated := rt.seatByUser[cmd.userID]; seated {
+			spectator = false
+		}
+		if spectator && !rt.spectators[cmd.userID] && len(rt.spectators) >= maxSpectators {
+			if cmd.resp != nil {
+				cmd.resp <- appErr.ErrServerBusy
+			}
+			return
+		}
+		if prev, ok := rt.subscribers[cmd.userID]; ok {
+			// A newer connection takes over; tell the old one not to retry.
+			rt.sendLocked(cmd.userID, OutgoingMessage{
+				Type: "close",
+				Data: CloseInfo{Reason: CloseReasonTakeover},
+			})
+			close(prev)
+		}
+		if spectator {
+			rt.spectators[cmd.userID] = true
+		} else {
+			delete(rt.spectators, cmd.userID)
+		}
 		rt.subscribers[cmd.userID] = cmd.subCh
+		delete(rt.disconnectDeadline, cmd.userID)
 		rt.pushStateLocked(cmd.userID)
 		if cmd.resp != nil {
 			cmd.resp <- nil
@@ -287,20 +892,133 @@ func (rt *TableRuntime) handleCommand(cmd loopCommand) {
 	case "unsubscribe":
 		if ch, ok := rt.subscribers[cmd.userID]; ok {
 			delete(rt.subscribers, cmd.userID)
+			delete(rt.spectators, cmd.userID)
+			// Capabilities are per connection; the next one renegotiates.
+			delete(rt.eventSubs, cmd.userID)
 			close(ch)
+			rt.armDisconnectGraceLocked(cmd.userID)
+		}
+		if cmd.resp != nil {
+			cmd.resp <- nil
 		}
+	case "disconnect_check":
+		rt.handleDisconnectCheckLocked(cmd.userID)
+	case "observe":
+		rt.handleObserveLocked(cmd.userID, cmd.subCh, cmd.showCards)
 		if cmd.resp != nil {
 			cmd.resp <- nil
 		}
+	case "unobserve":
+		rt.handleUnobserveLocked(cmd.userID)
+		if cmd.resp != nil {
+			cmd.resp <- nil
+		}
+	case "remote_subscribe":
+		if cmd.spectator {
+			if _, seated := rt.seatByUser[cmd.userID]; !seated {
+				if !rt.spectators[cmd.userID] && len(rt.spectators) >= maxSpectators {
+					// Over the cap: answer with a close frame via the bridge
+					// instead of joining the fan-out.
+					rt.bridge.publishEvent(rt.tableID, cmd.userID, OutgoingMessage{
+						Type: "close",
+						Seq:  rt.nextSeqLocked(),
+						Data: CloseInfo{Reason: CloseReasonShutdown},
+					})
+					return
+				}
+				rt.spectators[cmd.userID] = true
+			}
+		}
+		rt.remoteSubs[cmd.userID]++
+		delete(rt.disconnectDeadline, cmd.userID)
+		rt.pushStateLocked(cmd.userID)
+	case "remote_unsubscribe":
+		if rt.remoteSubs[cmd.userID] > 1 {
+			rt.remoteSubs[cmd.userID]--
+		} else {
+			delete(rt.remoteSubs, cmd.userID)
+			delete(rt.spectators, cmd.userID)
+			delete(rt.eventSubs, cmd.userID)
+		}
 	case "action":
+		if cmd.requestID != "" {
+			if err, ok := rt.cachedOutcomeLocked(cmd.userID, cmd.requestID); ok {
+				// A retry of an action we already evaluated: hand back the
+				// original outcome without touching the hand.
+				if cmd.result != nil {
+					cmd.result <- ActionResult{Err: err, Duplicate: true}
+				}
+				return
+			}
+		}
+		if cmd.ctx != nil {
+			rt.actionCtx = cmd.ctx
+		}
 		err := rt.handleActionLocked(cmd.userID, cmd.action, cmd.data)
+		rt.actionCtx = nil
+		if cmd.requestID != "" {
+			rt.rememberOutcomeLocked(cmd.userID, cmd.requestID, err)
+		}
+		if cmd.result != nil {
+			cmd.result <- ActionResult{Err: err}
+		}
+		if cmd.resp != nil {
+			cmd.resp <- err
+		}
+	case "admin_adjust":
+		result := rt.handleAdminAdjustLocked(cmd.adjust)
+		if cmd.adjustResp != nil {
+			cmd.adjustResp <- result
+		}
+	case "seat_user":
+		err := rt.handleSeatUserLocked(*cmd.seat)
 		if cmd.resp != nil {
 			cmd.resp <- err
 		}
+	case "mute":
+		rt.handleMuteLocked(*cmd.mute)
+	case "next_match":
+		rt.handleNextMatchLocked(cmd.matchID)
+	case "current_info":
+		cmd.info <- runtimeInfo{phase: rt.phase, matchID: rt.matchID}
+	case "snapshot":
+		cmd.snap <- rt.snapshotLocked()
+	case "dissolve":
+		rt.handleAdminDissolveLocked()
+		if cmd.resp != nil {
+			cmd.resp <- nil
+		}
+	case "shutdown":
+		rt.handleShutdownLocked()
+		if cmd.resp != nil {
+			cmd.resp <- nil
+		}
+	case "transfer":
+		rt.handleTransferLocked()
+		if cmd.resp != nil {
+			cmd.resp <- nil
+		}
 	}
 }
 
 func (rt *TableRuntime) handleActionLocked(userID int64, action string, data json.RawMessage) error {
+	if rt.spectators[userID] {
+		return appErr.ErrSpectatorCannotAct
+	}
+	if rt.frozen {
+		// A frozen table takes no gameplay; only passive traffic passes so
+		// clients stay connected while an admin sorts the state out.
+		switch action {
+		case "ping", "rejoin", "chat", "mute_player", "hello":
+		default:
+			return appErr.ErrTableFrozen
+		}
+	}
+	if rt.transferring {
+		// Nothing may mutate state after the handoff snapshot was written;
+		// the retry lands on the instance that picks the table up.
+		return appErr.ErrServerBusy
+	}
 	seatIdx, ok := rt.seatByUser[userID]
 	if !ok && action != "rejoin" {
 		return appErr.ErrTableAccessDenied
@@ -308,20 +1026,598 @@ func (rt *TableRuntime) handleActionLocked(userID int64, action string, data jso
 
 	switch action {
 	case "ready":
-		return rt.handleReadyLocked(seatIdx, userID)
-	case "pass", "call", "raise", "fold", "knock_bobo":
+		return rt.handleReadyLocked(seatIdx, userID, data)
+	case "pass", "call", "raise", "fold", "knock_bobo", "allin":
 		return rt.handleTurnActionLocked(action, seatIdx, data)
+	case "request_time":
+		return rt.handleRequestTimeLocked(seatIdx, userID)
+	case "precommit":
+		return rt.handlePrecommitLocked(seatIdx, userID, data)
+	case "topup":
+		return rt.handleTopUpLocked(seatIdx, userID, data)
+	case "leave", "leave_table":
+		return rt.handleCashOutLocked(seatIdx, userID)
 	case "rejoin":
-		rt.pushStateLocked(userID)
+		rt.handleRejoinLocked(userID, data)
 		return nil
+	case "ack_result":
+		return rt.handleAckResultLocked(seatIdx, userID)
+	case "chat":
+		return rt.handleChatLocked(seatIdx, userID, data)
+	case "emote":
+		return rt.handleEmoteLocked(seatIdx, userID, data)
+	case "mute_player":
+		return rt.handleMutePlayerLocked(userID, data)
 	case "ping":
-		rt.pushMessageLocked(userID, OutgoingMessage{Type: "pong", Seq: rt.nextSeqLocked(), Data: ginH{"message": "pong"}})
+		rt.pushMessageLocked(userID, OutgoingMessage{Type: "pong", Data: ginH{"message": "pong", "serverTime": rt.timeNow().UnixMilli()}})
 		return nil
+	case "hello":
+		return rt.handleHelloLocked(userID, data)
 	default:
 		return fmt.Errorf("unsupported action")
 	}
 }
 
+// AdminAdjust applies an operator correction through the command channel and
+// returns the before/after snapshots for the audit trail.
+func (rt *TableRuntime) AdminAdjust(adj AdminAdjustment) (before, after map[string]interface{}, err error) {
+	resp := make(chan adminAdjustResult, 1)
+	rt.cmdCh <- loopCommand{kind: "admin_adjust", adjust: &adj, adjustResp: resp}
+	result := <-resp
+	return result.before, result.after, result.err
+}
+
+func (rt *TableRuntime) handleAdminAdjustLocked(adj *AdminAdjustment) adminAdjustResult {
+	if rt.phase == PhasePlaying {
+		return adminAdjustResult{err: appErr.ErrTableMidHand}
+	}
+	if adj.MangoStreak != nil && (*adj.MangoStreak < 0 || *adj.MangoStreak > 3) {
+		return adminAdjustResult{err: appErr.ErrInvalidAdjustment}
+	}
+	if adj.TurnSeconds != nil && (*adj.TurnSeconds < 5 || *adj.TurnSeconds > 120) {
+		return adminAdjustResult{err: appErr.ErrInvalidAdjustment}
+	}
+	if adj.MangoStreak == nil && adj.TurnSeconds == nil {
+		return adminAdjustResult{err: appErr.ErrInvalidAdjustment}
+	}
+
+	before := map[string]interface{}{
+		"mangoStreak": rt.mangoStreak,
+		"turnSeconds": rt.turnSeconds,
+	}
+	if adj.MangoStreak != nil {
+		rt.mangoStreak = *adj.MangoStreak
+		if rt.db != nil {
+			_ = rt.db.Model(&model.Table{}).
+				Where("id = ?", rt.tableID).
+				Update("mango_streak", rt.mangoStreak).Error
+		}
+	}
+	if adj.TurnSeconds != nil {
+		rt.turnSeconds = *adj.TurnSeconds
+	}
+	after := map[string]interface{}{
+		"mangoStreak": rt.mangoStreak,
+		"turnSeconds": rt.turnSeconds,
+	}
+
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "admin_adjust",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{
+			"reason":  adj.Reason,
+			"changes": after,
+		},
+	})
+	rt.broadcastStateLocked()
+	return adminAdjustResult{before: before, after: after}
+}
+
+// SeatUser adds a player to a free seat while the table is waiting.
+func (rt *TableRuntime) SeatUser(userID int64, alias string, chips int64) error {
+	resp := make(chan error, 1)
+	rt.cmdCh <- loopCommand{kind: "seat_user", seat: &seatRequest{UserID: userID, Alias: alias, Chips: chips}, resp: resp}
+	return <-resp
+}
+
+func (rt *TableRuntime) handleSeatUserLocked(req seatRequest) error {
+	if rt.phase != PhaseWaiting {
+		return appErr.ErrTableMidHand
+	}
+	if _, ok := rt.seatByUser[req.UserID]; ok {
+		return appErr.ErrAlreadySeated
+	}
+	seatIdx := rt.freeSeatLocked()
+	if seatIdx == 0 {
+		return appErr.ErrTableFull
+	}
+
+	alias := req.Alias
+	if alias == "" {
+		alias = fmt.Sprintf("玩家%d", seatIdx)
+	}
+	rt.seats = append(rt.seats, SeatState{
+		SeatIndex: seatIdx,
+		UserID:    req.UserID,
+		Alias:     alias,
+		Chips:     req.Chips,
+		Status:    "waiting",
+	})
+	sort.Slice(rt.seats, func(i, j int) bool {
+		return rt.seats[i].SeatIndex < rt.seats[j].SeatIndex
+	})
+	rt.seatByUser[req.UserID] = seatIdx
+	rt.totalBuyIns += req.Chips
+	rt.persistPlayersLocked()
+
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "player_joined",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{
+			"seat":   seatIdx,
+			"userId": req.UserID,
+			"alias":  alias,
+		},
+	})
+	rt.broadcastStateLocked()
+	return nil
+}
+
+// freeSeatLocked returns the lowest unoccupied seat index, or 0 when full.
+func (rt *TableRuntime) freeSeatLocked() int {
+	if rt.seatCount <= 0 || len(rt.seats) >= rt.seatCount {
+		return 0
+	}
+	used := make(map[int]bool, len(rt.seats))
+	for _, seat := range rt.seats {
+		used[seat.SeatIndex] = true
+	}
+	for idx := 1; idx <= rt.seatCount; idx++ {
+		if !used[idx] {
+			return idx
+		}
+	}
+	return 0
+}
+
+// persistPlayersLocked rewrites the table's PlayersJSON from the live seats so
+// a restarted instance rebuilds the same lineup.
+func (rt *TableRuntime) persistPlayersLocked() {
+	if rt.onSeats != nil {
+		rt.onSeats(rt.tableID, rt.playersSnapshot())
+	}
+	if rt.db == nil {
+		return
+	}
+	playerMap := make(map[string]map[string]interface{}, len(rt.seats))
+	for _, seat := range rt.seats {
+		if seat.UserID == 0 {
+			continue
+		}
+		playerMap[strconv.Itoa(seat.SeatIndex)] = map[string]interface{}{
+			"userId":      seat.UserID,
+			"alias":       seat.Alias,
+			"avatar":      seat.Avatar,
+			"status":      "waiting",
+			"chips":       seat.Chips,
+			"sessionNet":  seat.SessionNet,
+			"handsPlayed": seat.HandsPlayed,
+		}
+	}
+	data, err := json.Marshal(playerMap)
+	if err != nil {
+		return
+	}
+	_ = rt.db.Model(&model.Table{}).
+		Where("id = ?", rt.tableID).
+		Update("players_json", datatypes.JSON(data)).Error
+}
+
+// handleTopUpLocked rebuys chips from the wallet between hands. The amount is
+// frozen on the wallet (released again at settlement) and the resulting stack
+// must stay within the scene's MinIn..MaxIn bounds.
+func (rt *TableRuntime) handleTopUpLocked(seatIdx int, userID int64, data json.RawMessage) error {
+	if rt.phase == PhasePlaying || rt.phase == PhaseSettling {
+		return appErr.ErrTableMidHand
+	}
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil || seat.Status == "eliminated" {
+		return appErr.ErrTableAccessDenied
+	}
+	if deadline, ok := rt.topUpDeadline[seatIdx]; ok && rt.timeNow().After(deadline) {
+		rt.eliminateSeatLocked(seatIdx, "topup_timeout")
+		return appErr.ErrTableAccessDenied
+	}
+
+	var payload struct {
+		Amount int64 `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return appErr.ErrInvalidBuyIn
+	}
+	amount := payload.Amount
+	if amount <= 0 || (rt.minUnitPi > 0 && amount%rt.minUnitPi != 0) {
+		return appErr.ErrInvalidBuyIn
+	}
+	newStack := seat.Chips + amount
+	if (rt.minIn > 0 && newStack < rt.minIn) || (rt.maxIn > 0 && newStack > rt.maxIn) {
+		return appErr.ErrInvalidBuyIn
+	}
+
+	if err := rt.freezeTopUpLocked(userID, amount, newStack); err != nil {
+		return err
+	}
+
+	seat.Chips = newStack
+	rt.totalTopUps += amount
+	delete(rt.topUpDeadline, seatIdx)
+	rt.persistPlayersLocked()
+	rt.appendLogLocked("topup", userID)
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "seat_topup",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{"seat": seatIdx, "amount": amount, "chips": newStack},
+	})
+	rt.broadcastStateLocked()
+	return nil
+}
+
+// freezeTopUpLocked moves the rebuy amount from available to frozen balance
+// and records a buyin_topup billing log; SettleMatch releases it again.
+func (rt *TableRuntime) freezeTopUpLocked(userID, amount, newStack int64) error {
+	if rt.db == nil {
+		return nil
+	}
+	now := rt.timeNow()
+	return rt.db.Transaction(func(tx *gorm.DB) error {
+		var wallet model.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			First(&wallet).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErr.ErrInsufficientBalance
+			}
+			return err
+		}
+		if wallet.BalanceAvailable < amount {
+			return appErr.ErrInsufficientBalance
+		}
+		wallet.BalanceAvailable -= amount
+		wallet.BalanceFrozen += amount
+		wallet.UpdatedAt = now
+		if err := tx.Save(&wallet).Error; err != nil {
+			return err
+		}
+
+		log := model.BillingLog{
+			UserID:       userID,
+			Type:         "buyin_topup",
+			Delta:        -amount,
+			BalanceAfter: wallet.BalanceAvailable,
+			MetaJSON: mustJSON(map[string]interface{}{
+				"tableId":  rt.tableID,
+				"newStack": newStack,
+			}),
+			CreatedAt: now,
+		}
+		if rt.matchID != 0 {
+			matchID := rt.matchID
+			log.MatchID = &matchID
+		}
+		return tx.Create(&log).Error
+	})
+}
+
+func (rt *TableRuntime) eliminateSeatLocked(seatIdx int, reason string) {
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil || seat.Status == "eliminated" {
+		return
+	}
+	seat.Status = "eliminated"
+	delete(rt.topUpDeadline, seatIdx)
+	rt.appendLogLocked("eliminated", seat.UserID)
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "seat_eliminated",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{"seat": seatIdx, "reason": reason},
+	})
+}
+
+// armTopUpCountdownLocked gives busted seats a grace window to rebuy before
+// the next deal eliminates them.
+func (rt *TableRuntime) armTopUpCountdownLocked() {
+	deadline := rt.timeNow().Add(topUpGraceSeconds * time.Second)
+	for _, seat := range rt.seats {
+		if seat.UserID == 0 || seat.Chips > 0 || seat.Status == "eliminated" {
+			continue
+		}
+		rt.topUpDeadline[seat.SeatIndex] = deadline
+		rt.broadcastMessageLocked(OutgoingMessage{
+			Type: "topup_required",
+			Seq:  rt.nextSeqLocked(),
+			Data: ginH{"seat": seat.SeatIndex, "deadline": deadline.UnixMilli()},
+		})
+	}
+}
+
+// handleCashOutLocked removes a seat between hands, releasing the player's
+// outstanding escrow. Per-hand results already flowed through SettleMatch, so
+// the chips themselves carry no extra wallet delta.
+func (rt *TableRuntime) handleCashOutLocked(seatIdx int, userID int64) error {
+	if rt.phase == PhasePlaying || rt.phase == PhaseSettling {
+		return appErr.ErrTableMidHand
+	}
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil {
+		return appErr.ErrTableAccessDenied
+	}
+	chips := seat.Chips
+
+	if err := rt.releaseEscrowLocked(userID, chips); err != nil {
+		return err
+	}
+
+	rt.appendLogLocked("leave", userID)
+	rt.removeSeatLocked(seatIdx, userID)
+	rt.totalCashOuts += chips
+	rt.persistPlayersLocked()
+	rt.checkChipInvariantLocked()
+
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "player_left",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{"seat": seatIdx, "userId": userID, "chips": chips},
+	})
+	rt.broadcastStateLocked()
+
+	// A leave that strands the table without an opponent cannot be followed
+	// by another hand; dissolve and refund everyone still seated.
+	if rt.occupiedSeatsLocked() < 2 {
+		rt.dissolveUnderSeatedLocked()
+	}
+	return nil
+}
+
+func (rt *TableRuntime) occupiedSeatsLocked() int {
+	n := 0
+	for _, seat := range rt.seats {
+		if seat.UserID != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// dissolveUnderSeatedLocked closes a table a leave left below two players:
+// every remaining seat is cashed out the same way the leaver was, then the
+// loop stops with a dissolve close frame. The finish callback sees the
+// expired reason and handles the service-side teardown (table row, matched
+// markers) like any other lifetime close.
+func (rt *TableRuntime) dissolveUnderSeatedLocked() {
+	rt.expiredReason = "dissolved"
+
+	seats := make([]SeatState, len(rt.seats))
+	copy(seats, rt.seats)
+	for _, seat := range seats {
+		if seat.UserID == 0 {
+			continue
+		}
+		if err := rt.releaseEscrowLocked(seat.UserID, seat.Chips); err != nil {
+			logger.Log.Warn("dissolve cash-out failed",
+				zap.Int64("tableID", rt.tableID),
+				zap.Int64("userID", seat.UserID),
+				zap.Error(err),
+			)
+			continue
+		}
+		rt.appendLogLocked("leave", seat.UserID)
+		rt.totalCashOuts += seat.Chips
+		rt.removeSeatLocked(seat.SeatIndex, seat.UserID)
+	}
+	rt.persistPlayersLocked()
+	rt.checkChipInvariantLocked()
+
+	rt.appendSystemLogLocked("人数不足，牌桌已解散")
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "table_dissolved",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{"reason": "not_enough_players"},
+	})
+	rt.stopWithReason(CloseReasonDissolved)
+	if rt.onFinish != nil {
+		go rt.onFinish(rt)
+	}
+}
+
+// Dissolve aborts whatever hand is in flight on an operator's order. The
+// loop broadcasts table_dissolved and stops; the finish callback settles the
+// zero-sum refund results and tears the table down like any lifetime close.
+// A runtime that already stopped has nothing left to abort.
+func (rt *TableRuntime) Dissolve() error {
+	resp := make(chan error, 1)
+	select {
+	case rt.cmdCh <- loopCommand{kind: "dissolve", resp: resp}:
+		// The loop may stop (this dissolve, or a concurrent close) without
+		// answering a command it accepted; don't wait on a dead loop.
+		select {
+		case err := <-resp:
+			return err
+		case <-rt.quitCh:
+			return nil
+		}
+	case <-rt.quitCh:
+		return nil
+	}
+}
+
+// handleAdminDissolveLocked is the forced variant of
+// dissolveUnderSeatedLocked: instead of cashing seats out one by one, every
+// seated player gets a zero-net refund result so the settlement releases
+// their escrow in a single zero-sum pass.
+func (rt *TableRuntime) handleAdminDissolveLocked() {
+	rt.expiredReason = "dissolved"
+	rt.cancelTimerLocked()
+
+	results := make([]PlayerResult, 0, len(rt.seats))
+	for _, seat := range rt.seats {
+		if seat.UserID == 0 {
+			continue
+		}
+		results = append(results, PlayerResult{
+			UserID:    seat.UserID,
+			NetPoints: 0,
+			Meta: map[string]interface{}{
+				"reason":       "admin_dissolve",
+				"contribution": seat.TotalContribution,
+			},
+		})
+	}
+	rt.SettlementResults = results
+	rt.phase = PhaseEnded
+
+	rt.appendSystemLogLocked("管理员已解散牌桌")
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "table_dissolved",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{"reason": "admin_dissolve"},
+	})
+	rt.stopWithReason(CloseReasonDissolved)
+	if rt.onFinish != nil {
+		go rt.onFinish(rt)
+	}
+}
+
+// releaseEscrowLocked returns a leaver's still-frozen top-ups to the
+// available balance and records the cash-out billing log.
+func (rt *TableRuntime) releaseEscrowLocked(userID, chips int64) error {
+	if rt.db == nil {
+		return nil
+	}
+	now := rt.timeNow()
+	return rt.db.Transaction(func(tx *gorm.DB) error {
+		frozen, err := outstandingTopUps(tx, rt.matchID, userID)
+		if err != nil {
+			return err
+		}
+
+		var wallet model.Wallet
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			First(&wallet).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			wallet = model.Wallet{UserID: userID}
+		}
+		exists := err == nil
+
+		wallet.BalanceFrozen -= frozen
+		wallet.BalanceAvailable += frozen
+		wallet.UpdatedAt = now
+		if exists {
+			if err := tx.Save(&wallet).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Create(&wallet).Error; err != nil {
+			return err
+		}
+
+		log := model.BillingLog{
+			UserID:       userID,
+			Type:         "cashout",
+			Delta:        frozen,
+			BalanceAfter: wallet.BalanceAvailable,
+			MetaJSON: mustJSON(map[string]interface{}{
+				"tableId":  rt.tableID,
+				"chips":    chips,
+				"released": frozen,
+			}),
+			CreatedAt: now,
+		}
+		if rt.matchID != 0 {
+			matchID := rt.matchID
+			log.MatchID = &matchID
+		}
+		return tx.Create(&log).Error
+	})
+}
+
+// outstandingTopUps sums a user's frozen top-ups for a match minus anything
+// already released by settlement or an earlier cash-out.
+func outstandingTopUps(tx *gorm.DB, matchID, userID int64) (int64, error) {
+	if matchID == 0 {
+		return 0, nil
+	}
+	var logs []model.BillingLog
+	err := tx.Where("match_id = ? AND user_id = ? AND type IN ?",
+		matchID, userID, []string{"buyin_topup", "topup_release", "cashout"}).
+		Find(&logs).Error
+	if err != nil {
+		return 0, err
+	}
+	var frozen int64
+	for _, log := range logs {
+		if log.Type == "buyin_topup" {
+			frozen += -log.Delta
+		} else {
+			frozen -= log.Delta
+		}
+	}
+	if frozen < 0 {
+		frozen = 0
+	}
+	return frozen, nil
+}
+
+func (rt *TableRuntime) removeSeatLocked(seatIdx int, userID int64) {
+	for i := range rt.seats {
+		if rt.seats[i].SeatIndex == seatIdx {
+			rt.seats = append(rt.seats[:i], rt.seats[i+1:]...)
+			break
+		}
+	}
+	delete(rt.seatByUser, userID)
+	delete(rt.seatHands, seatIdx)
+	delete(rt.restedSeats, seatIdx)
+	delete(rt.topUpDeadline, seatIdx)
+	delete(rt.timeBankUsed, seatIdx)
+}
+
+// checkChipInvariantLocked asserts chip conservation. A mismatch points at a
+// settlement bug, so it is logged loudly rather than silently corrected.
+func (rt *TableRuntime) checkChipInvariantLocked() {
+	var onTable int64
+	for _, seat := range rt.seats {
+		onTable += seat.Chips + seat.Bet
+	}
+	onTable += rt.pot
+	expected := rt.totalBuyIns + rt.totalTopUps - rt.totalCashOuts
+	if onTable != expected {
+		logger.Log.Error("table chip invariant violated",
+			zap.Int64("tableID", rt.tableID),
+			zap.Int64("onTable", onTable),
+			zap.Int64("expected", expected),
+			zap.Int64("buyIns", rt.totalBuyIns),
+			zap.Int64("topUps", rt.totalTopUps),
+			zap.Int64("cashOuts", rt.totalCashOuts),
+		)
+	}
+}
+
+// sweepTopUpLocked eliminates seats that busted last hand and never rebought.
+func (rt *TableRuntime) sweepTopUpLocked() {
+	for _, seat := range rt.seats {
+		if seat.UserID == 0 || seat.Status == "eliminated" {
+			continue
+		}
+		if seat.Chips <= 0 {
+			rt.eliminateSeatLocked(seat.SeatIndex, "no_topup")
+		}
+	}
+	rt.topUpDeadline = make(map[int]time.Time)
+}
+
 func (rt *TableRuntime) Subscribe(userID int64) chan OutgoingMessage {
 	ch := make(chan OutgoingMessage, 8)
 	resp := make(chan error, 1)
@@ -333,23 +1629,135 @@ func (rt *TableRuntime) Subscribe(userID int64) chan OutgoingMessage {
 	return ch
 }
 
-func (rt *TableRuntime) Unsubscribe(userID int64) {
+// maxSpectators bounds the read-only audience per table so spectators can
+// never dominate the broadcast fan-out.
+const maxSpectators = 20
+
+// SubscribeSpectator registers a read-only subscriber: they receive the
+// broadcast stream but never hold a seat and every action is rejected.
+// Returns nil when the table is at its spectator cap.
+func (rt *TableRuntime) SubscribeSpectator(userID int64) chan OutgoingMessage {
+	ch := make(chan OutgoingMessage, 8)
+	resp := make(chan error, 1)
+	rt.cmdCh <- loopCommand{kind: "subscribe", userID: userID, subCh: ch, spectator: true, resp: resp}
+	if err := <-resp; err != nil {
+		close(ch)
+		return nil
+	}
+	return ch
+}
+
+func (rt *TableRuntime) Unsubscribe(userID int64) {
+	resp := make(chan error, 1)
+	rt.cmdCh <- loopCommand{kind: "unsubscribe", userID: userID, resp: resp}
+	<-resp
+}
+
+func (rt *TableRuntime) HandleAction(userID int64, action string, data json.RawMessage) error {
+	resp := make(chan error, 1)
+	rt.cmdCh <- loopCommand{kind: "action", userID: userID, action: action, data: data, resp: resp}
+	return <-resp
+}
+
+// ActionResult is the structured outcome of a requestId-aware action:
+// Duplicate marks a retry answered from the outcome cache.
+type ActionResult struct {
+	Err       error
+	Duplicate bool
+}
+
+// requestCacheSize bounds how many recent requestIds are remembered per user.
+const requestCacheSize = 32
+
+// requestOutcome pairs a client requestId with the result its first
+// evaluation produced, errors included, so retries replay it verbatim.
+type requestOutcome struct {
+	id  string
+	err error
+}
+
+// HandleActionID is HandleAction with a client-provided retry token: the
+// same requestId from the same user returns the first evaluation's outcome
+// instead of reapplying the action. An empty requestId disables caching.
+func (rt *TableRuntime) HandleActionID(userID int64, action string, data json.RawMessage, requestID string) ActionResult {
+	result := make(chan ActionResult, 1)
+	rt.cmdCh <- loopCommand{kind: "action", userID: userID, action: action, data: data, requestID: requestID, result: result}
+	return <-result
+}
+
+func (rt *TableRuntime) cachedOutcomeLocked(userID int64, requestID string) (error, bool) {
+	for _, out := range rt.reqOutcomes[userID] {
+		if out.id == requestID {
+			return out.err, true
+		}
+	}
+	return nil, false
+}
+
+func (rt *TableRuntime) rememberOutcomeLocked(userID int64, requestID string, err error) {
+	if rt.reqOutcomes == nil {
+		rt.reqOutcomes = make(map[int64][]requestOutcome)
+	}
+	outcomes := rt.reqOutcomes[userID]
+	if len(outcomes) >= requestCacheSize {
+		outcomes = outcomes[1:]
+	}
+	rt.reqOutcomes[userID] = append(outcomes, requestOutcome{id: requestID, err: err})
+}
+
+// HandleActionContext is HandleAction with the caller's request context
+// attached, so trace/span IDs active at action time flow into the persisted
+// round log entries.
+func (rt *TableRuntime) HandleActionContext(ctx context.Context, userID int64, action string, data json.RawMessage) error {
 	resp := make(chan error, 1)
-	rt.cmdCh <- loopCommand{kind: "unsubscribe", userID: userID, resp: resp}
-	<-resp
+	rt.cmdCh <- loopCommand{kind: "action", ctx: ctx, userID: userID, action: action, data: data, resp: resp}
+	return <-resp
 }
 
-func (rt *TableRuntime) HandleAction(userID int64, action string, data json.RawMessage) error {
-	resp := make(chan error, 1)
-	rt.cmdCh <- loopCommand{kind: "action", userID: userID, action: action, data: data, resp: resp}
-	return <-resp
+// subscribeRemote registers a subscriber whose WS connection lives on another
+// instance; their messages are delivered through the Redis bridge.
+func (rt *TableRuntime) subscribeRemote(userID int64) {
+	rt.cmdCh <- loopCommand{kind: "remote_subscribe", userID: userID}
 }
 
-func (rt *TableRuntime) handleReadyLocked(seatIdx int, userID int64) error {
+func (rt *TableRuntime) unsubscribeRemote(userID int64) {
+	rt.cmdCh <- loopCommand{kind: "remote_unsubscribe", userID: userID}
+}
+
+// spectateRemote registers a read-only subscriber relayed by another
+// instance.
+func (rt *TableRuntime) spectateRemote(userID int64) {
+	rt.cmdCh <- loopCommand{kind: "remote_subscribe", userID: userID, spectator: true}
+}
+
+func (rt *TableRuntime) handleReadyLocked(seatIdx int, userID int64, data json.RawMessage) error {
 	if rt.phase != PhaseWaiting && rt.phase != PhasePlaying {
 		return fmt.Errorf("invalid phase")
 	}
 
+	// Ready optionally carries the lifetime-limit opt-in: players who set
+	// autoRequeue are put straight back into the scene's queue when the
+	// table expires. Omitting the flag keeps the previous choice.
+	var payload struct {
+		AutoRequeue *bool `json:"autoRequeue"`
+	}
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &payload)
+	}
+	if payload.AutoRequeue != nil {
+		if rt.requeueOptIn == nil {
+			rt.requeueOptIn = make(map[int64]bool)
+		}
+		rt.requeueOptIn[userID] = *payload.AutoRequeue
+	}
+
+	// Readying up ends a sit-out: the seat rejoins the next deal with a
+	// clean timeout record.
+	if rt.sittingOut[seatIdx] {
+		delete(rt.sittingOut, seatIdx)
+		delete(rt.timeoutStrikes, seatIdx)
+	}
+
 	for i := range rt.seats {
 		if rt.seats[i].SeatIndex == seatIdx {
 			if rt.seats[i].Ready {
@@ -368,6 +1776,29 @@ func (rt *TableRuntime) handleReadyLocked(seatIdx int, userID int64) error {
 	return nil
 }
 
+// checkTurnTokenLocked rejects actions that echo the token of a previous
+// turn so a delayed retry resyncs instead of acting on the wrong turn.
+// Clients that omit the token keep the old behavior until the
+// requireTurnToken deprecation window closes.
+func (rt *TableRuntime) checkTurnTokenLocked(data json.RawMessage) error {
+	var payload struct {
+		TurnToken int64 `json:"turnToken"`
+	}
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &payload)
+	}
+	if payload.TurnToken == 0 {
+		if config.GlobalConfig != nil && config.GlobalConfig.Features.RequireTurnToken {
+			return fmt.Errorf("%w: turnToken required", appErr.ErrStaleTurn)
+		}
+		return nil
+	}
+	if payload.TurnToken != rt.turnToken {
+		return fmt.Errorf("%w: got %d, current is %d", appErr.ErrStaleTurn, payload.TurnToken, rt.turnToken)
+	}
+	return nil
+}
+
 func (rt *TableRuntime) handleTurnActionLocked(action string, seatIdx int, data json.RawMessage) error {
 	if rt.phase != PhasePlaying {
 		return fmt.Errorf("not in playing phase")
@@ -378,30 +1809,45 @@ func (rt *TableRuntime) handleTurnActionLocked(action string, seatIdx int, data
 	if rt.isTurnExpiredLocked() {
 		return fmt.Errorf("turn timeout")
 	}
+	if err := rt.checkTurnTokenLocked(data); err != nil {
+		return err
+	}
 
 	seat := rt.findSeatLocked(seatIdx)
-	if seat == nil || seat.Status == "folded" || seat.Status == "eliminated" {
+	if seat == nil || seat.Status == "folded" || seat.Status == "eliminated" || seat.Status == "resting" || seat.Status == "allin" {
 		return fmt.Errorf("invalid seat status")
 	}
 
+	if !rt.actionAllowedLocked(action) {
+		return fmt.Errorf("%w: %s", appErr.ErrActionDisabled, action)
+	}
+
+	// Any live action attempt proves the player is back, so the timeout
+	// strike count starts over. A manual action also supersedes whatever the
+	// player pre-committed while waiting.
+	delete(rt.timeoutStrikes, seatIdx)
+	delete(rt.precommits, seatIdx)
+
+	latency := rt.decisionLatencyMSLocked()
+
 	switch action {
 	case "fold":
 		rt.markSeatStatusLocked(seatIdx, "folded")
 		rt.markActedLocked(seatIdx)
 		rt.appendLogLocked("fold", seat.UserID)
-		rt.persistRoundLogLocked(actionEntry{Action: "fold", Seat: seatIdx})
+		rt.persistRoundLogLocked(actionEntry{Action: "fold", Seat: seatIdx, LatencyMS: latency})
 	case "pass":
 		if !rt.canPassLocked(seatIdx) {
 			return fmt.Errorf("cannot pass, must call or fold")
 		}
 		rt.markActedLocked(seatIdx)
 		rt.appendLogLocked("pass", seat.UserID)
-		rt.persistRoundLogLocked(actionEntry{Action: "pass", Seat: seatIdx})
+		rt.persistRoundLogLocked(actionEntry{Action: "pass", Seat: seatIdx, LatencyMS: latency})
 	case "call":
 		if err := rt.handleCallLocked(seatIdx); err != nil {
 			return err
 		}
-		rt.persistRoundLogLocked(actionEntry{Action: "call", Seat: seatIdx})
+		rt.persistRoundLogLocked(actionEntry{Action: "call", Seat: seatIdx, LatencyMS: latency})
 	case "raise":
 		if err := rt.handleRaiseLocked(seatIdx, data); err != nil {
 			return err
@@ -410,13 +1856,22 @@ func (rt *TableRuntime) handleTurnActionLocked(action string, seatIdx int, data
 			Amount int64 `json:"amount"`
 		}
 		_ = json.Unmarshal(data, &payload)
-		rt.persistRoundLogLocked(actionEntry{Action: "raise", Seat: seatIdx, Amount: payload.Amount})
+		rt.persistRoundLogLocked(actionEntry{Action: "raise", Seat: seatIdx, Amount: payload.Amount, LatencyMS: latency})
+	case "allin":
+		if err := rt.handleAllInLocked(seatIdx); err != nil {
+			return err
+		}
+		rt.persistRoundLogLocked(actionEntry{Action: "allin", Seat: seatIdx, Amount: seat.Bet, LatencyMS: latency})
 	case "knock_bobo":
 		return rt.handleKnockBoboLocked(seatIdx, "manual_knock")
 	default:
 		return fmt.Errorf("unsupported action")
 	}
 
+	// The seat's bet level after the action; event clients reconcile it
+	// against the seat row instead of summing deltas.
+	amount := seat.Bet
+
 	if rt.shouldSettleLocked() {
 		if rt.round == 2 && rt.round2Bet {
 			rt.tailBigWin = true
@@ -429,14 +1884,17 @@ func (rt *TableRuntime) handleTurnActionLocked(action string, seatIdx int, data
 	if rt.shouldAdvanceRoundLocked() {
 		rt.advanceRoundLocked()
 		if rt.phase != PhaseSettling {
-			rt.broadcastStateLocked()
+			rt.emitActionEventLocked(seatIdx, action, amount)
+			rt.emitPhaseEventLocked()
+			rt.broadcastStateAfterEventsLocked()
 		}
 		return nil
 	}
 
 	rt.moveToNextTurnLocked()
 	if rt.phase == PhasePlaying {
-		rt.broadcastStateLocked()
+		rt.emitActionEventLocked(seatIdx, action, amount)
+		rt.broadcastStateAfterEventsLocked()
 	}
 	return nil
 }
@@ -445,35 +1903,249 @@ func (rt *TableRuntime) pushStateLocked(userID int64) {
 	state := rt.exportStateLocked(userID)
 	rt.pushMessageLocked(userID, OutgoingMessage{
 		Type: "state",
-		Seq:  rt.nextSeqLocked(),
 		Data: state,
 	})
 }
 
+// broadcastStateLocked schedules a full state for every subscriber. Within
+// stateFlushEvery of the previous flush it only marks the state dirty and
+// arms the flush timer, so auto-run bursts collapse into one broadcast that
+// carries the latest data.
 func (rt *TableRuntime) broadcastStateLocked() {
+	rt.stateForAll = true
+	rt.scheduleStateLocked()
+}
+
+// broadcastStateAfterEventsLocked schedules the same broadcast for a
+// transition that event-capable subscribers already received as granular
+// messages, so the flush reaches only legacy clients.
+func (rt *TableRuntime) broadcastStateAfterEventsLocked() {
+	rt.scheduleStateLocked()
+}
+
+func (rt *TableRuntime) scheduleStateLocked() {
+	if rt.stateFlushEvery <= 0 {
+		rt.flushStateLocked()
+		return
+	}
+	elapsed := rt.timeNow().Sub(rt.lastStateFlush)
+	if elapsed >= rt.stateFlushEvery {
+		rt.flushStateLocked()
+		return
+	}
+	rt.stateDirty = true
+	if rt.stateFlushTimer == nil {
+		rt.stateFlushTimer = time.NewTimer(rt.stateFlushEvery - elapsed)
+		rt.stateFlushC = rt.stateFlushTimer.C
+	}
+}
+
+// flushPendingStateLocked delivers a deferred state broadcast before an event
+// message so clients never apply an event on top of stale state.
+func (rt *TableRuntime) flushPendingStateLocked() {
+	if rt.stateDirty {
+		rt.flushStateLocked()
+	}
+}
+
+func (rt *TableRuntime) flushStateLocked() {
+	forAll := rt.stateForAll || len(rt.eventSubs) == 0
+	rt.stateForAll = false
+	rt.stateDirty = false
+	rt.lastStateFlush = rt.timeNow()
+	if rt.stateFlushTimer != nil {
+		rt.stateFlushTimer.Stop()
+		rt.stateFlushTimer = nil
+		rt.stateFlushC = nil
+	}
+	rt.flushLogsLocked()
+	// Subscriber seqs are stamped per user in sendLocked; the table-wide
+	// counter only sequences the admin observer stream.
+	for uid := range rt.subscribers {
+		if !forAll && rt.eventSubs[uid] {
+			continue
+		}
+		rt.pushMessageLocked(uid, OutgoingMessage{
+			Type: "state",
+			Data: rt.exportStateLocked(uid),
+		})
+	}
+	for uid := range rt.remoteSubs {
+		if _, ok := rt.subscribers[uid]; ok {
+			continue
+		}
+		if !forAll && rt.eventSubs[uid] {
+			continue
+		}
+		rt.pushMessageLocked(uid, OutgoingMessage{
+			Type: "state",
+			Data: rt.exportStateLocked(uid),
+		})
+	}
 	stateSeq := rt.nextSeqLocked()
-	for uid, ch := range rt.subscribers {
-		state := rt.exportStateLocked(uid)
-		msg := OutgoingMessage{
+	for adminID, sub := range rt.observers {
+		rt.pushObserverLocked(adminID, OutgoingMessage{
 			Type: "state",
 			Seq:  stateSeq,
-			Data: state,
-		}
-		select {
-		case ch <- msg:
-		default:
-			logger.Log.Warn("ws subscriber channel full", zap.Int64("userID", uid), zap.Int64("tableID", rt.tableID))
+			Data: rt.exportObserverStateLocked(sub.showCards),
+		})
+	}
+}
+
+// broadcastMessageLocked delivers one message to every subscriber, local or
+// relayed through the bridge.
+func (rt *TableRuntime) broadcastMessageLocked(msg OutgoingMessage) {
+	if msg.Type != "state" {
+		rt.flushPendingStateLocked()
+	}
+	for uid := range rt.subscribers {
+		rt.pushMessageLocked(uid, msg)
+	}
+	// Remote subscribers take the same per-user path as local ones so their
+	// seqs stay monotonic and the spectator delay and replay buffer apply;
+	// deliverNowLocked relays through the bridge at the end of it.
+	for uid := range rt.remoteSubs {
+		if _, ok := rt.subscribers[uid]; !ok {
+			rt.pushMessageLocked(uid, msg)
 		}
 	}
+	for adminID := range rt.observers {
+		rt.pushObserverLocked(adminID, msg)
+	}
 }
 
 func (rt *TableRuntime) pushMessageLocked(userID int64, msg OutgoingMessage) {
+	if msg.Type != "state" {
+		// Event messages bypass coalescing but must not overtake it.
+		rt.flushPendingStateLocked()
+	}
+	if rt.spectatorDelay > 0 {
+		if _, seated := rt.seatByUser[userID]; !seated {
+			// Delayed messages are buffered unstamped; they get the user's
+			// next seq when the flush delivers them.
+			rt.enqueueDelayedLocked(userID, msg)
+			return
+		}
+	}
+	rt.sendLocked(userID, msg)
+}
+
+// msgHistorySize bounds the per-subscriber replay buffer used by rejoin.
+const msgHistorySize = 128
+
+// recordRecentLocked appends a delivered message to the user's replay buffer.
+// Replayed messages re-enter with seqs already recorded and are skipped, so
+// the buffer stays strictly increasing.
+func (rt *TableRuntime) recordRecentLocked(userID int64, msg OutgoingMessage) {
+	if rt.recent == nil {
+		rt.recent = make(map[int64][]OutgoingMessage)
+	}
+	buf := rt.recent[userID]
+	if len(buf) > 0 && msg.Seq <= buf[len(buf)-1].Seq {
+		return
+	}
+	if len(buf) >= msgHistorySize {
+		if rt.recentFloor == nil {
+			rt.recentFloor = make(map[int64]int64)
+		}
+		rt.recentFloor[userID] = buf[0].Seq
+		buf = buf[1:]
+	}
+	rt.recent[userID] = append(buf, msg)
+}
+
+// handleRejoinLocked resyncs a reconnecting client. With a lastSeq the missed
+// messages are replayed from the buffer; when the gap has already been
+// evicted a full state flagged Resync is sent instead, since replaying would
+// silently skip messages.
+func (rt *TableRuntime) handleRejoinLocked(userID int64, data json.RawMessage) {
+	var req struct {
+		LastSeq int64 `json:"lastSeq"`
+	}
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &req)
+	}
+	if req.LastSeq <= 0 {
+		rt.pushStateLocked(userID)
+		return
+	}
+	if req.LastSeq < rt.recentFloor[userID] {
+		rt.pushMessageLocked(userID, OutgoingMessage{
+			Type:   "state",
+			Data:   rt.exportStateLocked(userID),
+			Resync: true,
+		})
+		return
+	}
+	for _, msg := range rt.recent[userID] {
+		if msg.Seq > req.LastSeq {
+			rt.deliverNowLocked(userID, msg)
+		}
+	}
+	rt.pushStateLocked(userID)
+}
+
+// nextUserSeqLocked advances the per-subscriber delivery counter.
+func (rt *TableRuntime) nextUserSeqLocked(userID int64) int64 {
+	if rt.userSeq == nil {
+		rt.userSeq = make(map[int64]int64)
+	}
+	rt.userSeq[userID]++
+	return rt.userSeq[userID]
+}
+
+// sendLocked is the single stamping point for subscriber deliveries: the
+// message gets the user's next seq and enters their replay buffer before it
+// is written out. Producers don't allocate subscriber seqs; whatever they set
+// is overwritten here. Replays bypass this and go through deliverNowLocked so
+// buffered messages keep their original numbers.
+func (rt *TableRuntime) sendLocked(userID int64, msg OutgoingMessage) {
+	msg.Seq = rt.nextUserSeqLocked(userID)
+	rt.recordRecentLocked(userID, msg)
+	rt.deliverNowLocked(userID, msg)
+}
+
+func (rt *TableRuntime) deliverNowLocked(userID int64, msg OutgoingMessage) {
 	if ch, ok := rt.subscribers[userID]; ok {
 		select {
 		case ch <- msg:
 		default:
 			logger.Log.Warn("ws subscriber channel full", zap.Int64("userID", userID), zap.Int64("tableID", rt.tableID))
 		}
+		return
+	}
+	if rt.remoteSubs[userID] > 0 {
+		rt.bridge.publishEvent(rt.tableID, userID, msg)
+	}
+}
+
+// enqueueDelayedLocked buffers a spectator message until spectatorDelay has
+// elapsed. The buffer is bounded: when full the oldest entry is dropped so a
+// stalled flush cannot grow memory without limit.
+func (rt *TableRuntime) enqueueDelayedLocked(userID int64, msg OutgoingMessage) {
+	if len(rt.delayed) >= maxDelayedMessages {
+		rt.delayed = rt.delayed[1:]
+	}
+	rt.delayed = append(rt.delayed, delayedMessage{
+		due:    rt.timeNow().Add(rt.spectatorDelay),
+		userID: userID,
+		msg:    msg,
+	})
+}
+
+// flushDelayedLocked delivers every buffered spectator message whose due time
+// has passed. Entries are appended in order with a fixed delay, so the queue
+// stays sorted and we only need to trim the head.
+func (rt *TableRuntime) flushDelayedLocked(now time.Time) {
+	i := 0
+	for ; i < len(rt.delayed); i++ {
+		if rt.delayed[i].due.After(now) {
+			break
+		}
+		rt.sendLocked(rt.delayed[i].userID, rt.delayed[i].msg)
+	}
+	if i > 0 {
+		rt.delayed = rt.delayed[i:]
 	}
 }
 
@@ -482,56 +2154,83 @@ func (rt *TableRuntime) nextSeqLocked() int64 {
 	return rt.seq
 }
 
+// timeNow returns the runtime clock, defaulting to the wall clock so
+// hand-built runtimes in tests work without wiring.
+func (rt *TableRuntime) timeNow() time.Time {
+	if rt.now != nil {
+		return rt.now()
+	}
+	return time.Now()
+}
+
+// runtimeCtx returns the runtime's lifecycle context, defaulting to
+// Background for hand-built runtimes.
+func (rt *TableRuntime) runtimeCtx() context.Context {
+	if rt.ctx != nil {
+		return rt.ctx
+	}
+	return context.Background()
+}
+
+// actionContextLocked is what background writes should derive from: the
+// in-flight command's request context when one was attached, otherwise the
+// runtime's own lifecycle context.
+func (rt *TableRuntime) actionContextLocked() context.Context {
+	if rt.actionCtx != nil {
+		return rt.actionCtx
+	}
+	return rt.runtimeCtx()
+}
+
 func (rt *TableRuntime) exportStateLocked(userID int64) TableState {
 	allowed := rt.allowedActionsLocked(userID)
 	countdown := rt.countdownSecondsLocked()
+	variant := rt.variantLocked()
 
 	myCards := []string{}
 	// Show my cards always if I have them
 	if seatIdx, ok := rt.seatByUser[userID]; ok {
 		for _, s := range rt.seats {
 			if s.SeatIndex == seatIdx {
-				if rt.chexuanMode {
-					myCards = make([]string, len(s.cards))
-					for k, c := range s.cards {
-						myCards[k] = ToPokerCode(c)
-					}
-				} else {
-					myCards = s.cards
+				myCards = make([]string, len(s.cards))
+				for k, c := range s.cards {
+					myCards[k] = variant.DisplayCard(c)
 				}
 				break
 			}
 		}
 	}
 
-	// In Settling/Ended phase, expose ALL cards?
-	// For now, let's keep private unless we implement Showdown event explicitly.
-	// Better: If PhaseEnded, we can populate all seats' cards in the Seats array?
-	// The current SeatState struct has 'cards' as private. We need to expose them if needed.
-	// Let's stick to 'MyCards' for now.
+	// Once the hand is decided the comparison is public: expose every
+	// non-folded seat's cards so clients can render the showdown. The copy
+	// below keeps rt.seats untouched, so the next hand starts clean.
+	showdown := rt.phase == PhaseSettling || rt.phase == PhaseEnded
 
 	// Create a copy of seats to potentially expose cards
 	displaySeats := make([]SeatState, len(rt.seats))
 	for i, s := range rt.seats {
 		ds := s
-		// If ended, we could theoretically expose cards here if we added a PublicCards field.
-		// For now, we rely on 'MyCards' only.
-
-		// In Chexuan mode, during Settle/Ended, we expose split details if available
-		// Need to store split details in seat state or compute them here?
-		// Better to store them in rt.seats during settle.
-		// Since rt.seats[i].Split is a pointer, we just copy it.
-		// We'll populate it in settleChexuanLocked.
+		if rt.timeBankBudgetSec > 0 {
+			ds.TimeBankLeft = rt.timeBankLeftLocked(s.SeatIndex)
+		}
+		if showdown && s.Status != "folded" && len(s.cards) > 0 {
+			ds.PublicCards = make([]string, len(s.cards))
+			for k, c := range s.cards {
+				ds.PublicCards[k] = variant.DisplayCard(c)
+			}
+		}
 
-		if s.Split != nil && rt.chexuanMode {
+		// Split details (populated by settleChexuanLocked) are exposed during
+		// Settle/Ended; the copy converts card codes without touching rt.seats.
+		if s.Split != nil {
 			newSplit := *s.Split
 			newSplit.Head = make([]string, len(s.Split.Head))
 			for k, c := range s.Split.Head {
-				newSplit.Head[k] = ToPokerCode(c)
+				newSplit.Head[k] = variant.DisplayCard(c)
 			}
 			newSplit.Tail = make([]string, len(s.Split.Tail))
 			for k, c := range s.Split.Tail {
-				newSplit.Tail[k] = ToPokerCode(c)
+				newSplit.Tail[k] = variant.DisplayCard(c)
 			}
 			ds.Split = &newSplit
 		}
@@ -539,27 +2238,114 @@ func (rt *TableRuntime) exportStateLocked(userID int64) TableState {
 		displaySeats[i] = ds
 	}
 
+	// Spectators get the public view only: no split details, even at
+	// showdown when the comparison is shown to seated players.
+	if rt.spectators[userID] {
+		for i := range displaySeats {
+			displaySeats[i].Split = nil
+		}
+	}
+
 	state := TableState{
 		TableID:        rt.tableID,
 		Phase:          rt.phase,
 		Round:          rt.round,
 		TurnSeat:       rt.turnSeat,
+		BankerSeat:     rt.bankerSeat,
 		LastRaise:      rt.lastRaise,
 		Pot:            rt.pot,
 		MangoStreak:    rt.mangoStreak,
+		MangoCarry:     int64(rt.mangoStreak) * 2 * rt.basePi,
+		MangoHistory:   rt.recentMangoEventsLocked(5),
 		Countdown:      countdown,
+		ServerTime:     rt.timeNow().UnixMilli(),
+		TurnToken:      rt.turnToken,
 		AllowedActions: allowed,
 		Seats:          displaySeats,
 		MyCards:        myCards,
 		Logs:           append([]LogItem(nil), rt.logs...),
+		Mutes:          rt.activeMutesLocked(),
+		LocalMutes:     rt.localMutesForLocked(userID),
+	}
+	if !rt.turnDeadline.IsZero() {
+		state.TurnDeadline = rt.turnDeadline.UnixMilli()
 	}
 	if rt.phase == PhaseEnded && len(rt.SettlementResults) > 0 {
 		state.Result = rt.SettlementResults
 	}
+	if rt.dealCommitment != "" {
+		state.DealCommitment = rt.dealCommitment
+		if rt.phase == PhaseEnded {
+			state.DealSeed = rt.dealSeed
+			state.DealVerify = dealVerifyInstructions
+		}
+	}
+	if seatIdx, ok := rt.seatByUser[userID]; ok {
+		state.MyPrecommit = rt.precommits[seatIdx]
+	}
+	if seatIdx, ok := rt.seatByUser[userID]; ok && rt.phase == PhasePlaying && rt.turnSeat == seatIdx {
+		call := rt.callAmountLocked(seatIdx)
+		minRaise, maxRaise := rt.raiseBoundsLocked(seatIdx)
+		state.CallAmount = &call
+		state.MinRaise = &minRaise
+		state.MaxRaise = &maxRaise
+		state.BetInfo = &BetInfo{
+			CallAmount: call,
+			MinRaise:   minRaise,
+			MaxRaise:   maxRaise,
+			// Mirrors the redirect at the top of handleRaiseLocked.
+			RaiseConvertsToBobo: rt.round == 2 && rt.boboEnabled && !rt.round2Knock,
+		}
+	}
 	return state
 }
 
+// actionAllowedLocked consults the scene's optional-action mask. Only the
+// optional actions can be switched off; fold, call and the rest always pass.
+func (rt *TableRuntime) actionAllowedLocked(action string) bool {
+	if rt.actionMask == nil {
+		return true
+	}
+	switch action {
+	case "raise", "knock_bobo", "pass":
+		return rt.actionMask[action]
+	default:
+		return true
+	}
+}
+
+// filterMaskedLocked drops masked optional actions from an allowed-actions
+// list. When pass is masked off the player still needs a way to stay in the
+// hand, so call takes its place.
+func (rt *TableRuntime) filterMaskedLocked(actions []string) []string {
+	if rt.actionMask == nil {
+		return actions
+	}
+	filtered := make([]string, 0, len(actions))
+	hasCall := false
+	droppedPass := false
+	for _, action := range actions {
+		if !rt.actionAllowedLocked(action) {
+			if action == "pass" {
+				droppedPass = true
+			}
+			continue
+		}
+		if action == "call" {
+			hasCall = true
+		}
+		filtered = append(filtered, action)
+	}
+	if droppedPass && !hasCall {
+		filtered = append(filtered, "call")
+	}
+	return filtered
+}
+
 func (rt *TableRuntime) allowedActionsLocked(userID int64) []string {
+	if rt.spectators[userID] {
+		return nil
+	}
 	seatIdx, ok := rt.seatByUser[userID]
 	if !ok {
 		return nil
@@ -576,7 +2362,7 @@ func (rt *TableRuntime) allowedActionsLocked(userID int64) []string {
 			return nil
 		}
 		seat := rt.findSeatLocked(seatIdx)
-		if seat == nil || seat.Status == "folded" || seat.Status == "eliminated" {
+		if seat == nil || seat.Status == "folded" || seat.Status == "eliminated" || seat.Status == "resting" || seat.Status == "allin" {
 			return nil
 		}
 		if rt.round >= 3 {
@@ -593,20 +2379,31 @@ func (rt *TableRuntime) allowedActionsLocked(userID int64) []string {
 			actions = append(actions, "call")
 		}
 
-		firstActor := rt.round == 1 && len(rt.roundActed) == 0 && seatIdx == rt.firstActorSeatLocked()
-		if rt.round == 1 && seat.Chips > 0 && !firstActor {
-			actions = append(actions, "raise")
-		}
-		if rt.round == 2 {
-			if rt.boboEnabled {
-				actions = append(actions, "knock_bobo")
-			} else if seat.Chips > 0 {
+		// Once the pot hits the scene cap, only pass/call/fold remain.
+		if !rt.potCappedLocked() {
+			firstActor := rt.round == 1 && len(rt.roundActed) == 0 && seatIdx == rt.firstActorSeatLocked()
+			if rt.round == 1 && seat.Chips > 0 && !firstActor {
 				actions = append(actions, "raise")
 			}
-		} else if rt.round == 1 && rt.boboEnabled {
-			actions = append(actions, "knock_bobo")
+			if rt.round == 2 {
+				if rt.boboEnabled && rt.actionAllowedLocked("knock_bobo") {
+					actions = append(actions, "knock_bobo")
+				} else if seat.Chips > 0 {
+					actions = append(actions, "raise")
+				}
+			} else if rt.round == 1 && rt.boboEnabled && rt.actionAllowedLocked("knock_bobo") {
+				actions = append(actions, "knock_bobo")
+			}
+			// A live stack can always shove, even below the raise floor; in
+			// round-2 bobo scenes knock_bobo already is the shove.
+			if seat.Chips > 0 && !(rt.round == 2 && rt.boboEnabled) {
+				actions = append(actions, "allin")
+			}
 		}
-		return actions
+		if rt.timeBankSec > 0 && !rt.timeBankUsed[seatIdx] {
+			actions = append(actions, "request_time")
+		}
+		return rt.filterMaskedLocked(actions)
 	case PhaseSettling, PhaseEnded:
 		return nil
 	default:
@@ -627,20 +2424,36 @@ func (rt *TableRuntime) allReadyLocked() bool {
 	if len(rt.seats) == 0 {
 		return false
 	}
+	participants := 0
 	for _, seat := range rt.seats {
+		// Sitting-out and eliminated seats don't hold up the deal; the
+		// former rejoin by readying, the latter never play again.
+		if rt.sittingOut[seat.SeatIndex] || seat.Status == "eliminated" {
+			continue
+		}
+		participants++
 		if !seat.Ready {
 			return false
 		}
 	}
-	return true
+	return participants > 0
 }
 
 func (rt *TableRuntime) startRoundLocked() {
 	rt.phase = PhasePlaying
+	rt.readyDeadline = time.Time{}
+	rt.handNo++
+	rt.precommits = make(map[int]string)
 	rt.round = 0
 	rt.pot = 0
 	rt.lastRaise = 0
 	rt.roundActed = make(map[int]bool)
+	rt.timeBankUsed = make(map[int]bool)
+	if rt.timeBankRefreshes {
+		rt.timeBankSpent = make(map[int]int)
+	}
+	rt.timeBankBurnSeat = 0
+	rt.timeBankBurnStart = time.Time{}
 	rt.firstRaiseDone = false
 	rt.raisedRound1 = false
 	rt.raisedRound2 = false
@@ -649,13 +2462,29 @@ func (rt *TableRuntime) startRoundLocked() {
 	rt.round2Knock = false
 	rt.lastAggSeat = 0
 	rt.tailBigWin = false
+	rt.potCapNotified = false
+	rt.sweepTopUpLocked()
 	for i := range rt.seats {
 		rt.seats[i].Bet = 0
+		rt.seats[i].TotalContribution = 0
 		if rt.seats[i].Status != "eliminated" {
 			rt.seats[i].Status = "playing"
 		}
+		if rt.sittingOut[rt.seats[i].SeatIndex] && rt.seats[i].Status == "playing" {
+			rt.seats[i].Status = "resting"
+		}
 	}
+	rt.applyFatigueLocked()
 	rt.initDeckLocked()
+	if rt.dealCommitment != "" {
+		// Published before any card leaves the deck, so the reveal after the
+		// hand can be checked against it.
+		rt.broadcastMessageLocked(OutgoingMessage{
+			Type: "deal_commitment",
+			Seq:  rt.nextSeqLocked(),
+			Data: ginH{"handNo": rt.handNo, "commitment": rt.dealCommitment},
+		})
+	}
 	rt.applyAntesLocked()
 	if rt.bankerSeat == 0 {
 		rt.bankerSeat = rt.findFirstActiveSeatLocked()
@@ -664,12 +2493,47 @@ func (rt *TableRuntime) startRoundLocked() {
 	rt.advanceRoundLocked()
 }
 
+// applyFatigueLocked enforces the scene's consecutive-hands limit: a seat
+// that has just completed maxHandsStreak hands in a row sits out this hand
+// as "resting" (no ante, no cards, no turn) and is restored the hand after.
+func (rt *TableRuntime) applyFatigueLocked() {
+	if rt.maxHandsStreak <= 0 {
+		return
+	}
+	for i := range rt.seats {
+		seat := &rt.seats[i]
+		if seat.UserID == 0 || seat.Status != "playing" {
+			continue
+		}
+		if rt.restedSeats[seat.SeatIndex] {
+			delete(rt.restedSeats, seat.SeatIndex)
+			rt.seatHands[seat.SeatIndex] = 0
+		}
+		if rt.seatHands[seat.SeatIndex] >= rt.maxHandsStreak {
+			seat.Status = "resting"
+			rt.restedSeats[seat.SeatIndex] = true
+			rt.appendLogLocked("resting", seat.UserID)
+			rt.broadcastMessageLocked(OutgoingMessage{
+				Type: "seat_resting",
+				Seq:  rt.nextSeqLocked(),
+				Data: ginH{
+					"seat":   seat.SeatIndex,
+					"reason": "fatigue",
+					"hands":  rt.seatHands[seat.SeatIndex],
+				},
+			})
+			continue
+		}
+		rt.seatHands[seat.SeatIndex]++
+	}
+}
+
 func (rt *TableRuntime) applyAntesLocked() {
 	if rt.basePi <= 0 {
 		return
 	}
 	for i := range rt.seats {
-		if rt.seats[i].Status == "folded" || rt.seats[i].Status == "eliminated" {
+		if rt.seats[i].Status == "folded" || rt.seats[i].Status == "eliminated" || rt.seats[i].Status == "resting" {
 			continue
 		}
 		ante := rt.basePi
@@ -681,6 +2545,7 @@ func (rt *TableRuntime) applyAntesLocked() {
 		}
 		rt.seats[i].Chips -= ante
 		rt.seats[i].Bet += ante
+		rt.seats[i].TotalContribution += ante
 		rt.pot += ante
 	}
 	if rt.lastRaise < rt.basePi {
@@ -698,28 +2563,10 @@ func (rt *TableRuntime) advanceRoundLocked() {
 		return
 	}
 
-	// Chexuan "Mango" / "LiuJu" detection logic
-	if rt.chexuanMode {
-		// Round 1 -> Round 2 transition
-		// We already track rt.round1Bet in handleCall/Raise.
-
-		// Round 2 -> Round 3 transition
-		if rt.round == 3 {
-			// Rule: If R1 had action (Bet/Raise), but R2 was all checks (no Raise) -> Mango (LiuJu).
-			// Rule: If R2 had action (Raise) but no one called (except folder?) -> Tail big eats skin (handled in shouldSettle?).
-			// Actually, if R2 had Raise and everyone else Folded, shouldSettleLocked() returns true before we get here.
-			// So if we are here, it means either:
-			// A) R2 was all PASS/CHECK (no bets added in R2).
-			// B) R2 had bets but multiple players are still active (Callers).
-
-			if rt.round1Bet && !rt.round2Bet {
-				// Condition A: R1 active, R2 quiet -> Mango.
-				// No showdown.
-				rt.phase = PhaseSettling
-				rt.settleChexuanMangoLocked()
-				return
-			}
-		}
+	// Variants can end the hand at a round boundary without a showdown
+	// (chexuan's mango draw when round 2 stayed quiet).
+	if rt.variantLocked().SettleEarlyLocked(rt) {
+		return
 	}
 
 	if rt.round >= 3 {
@@ -734,21 +2581,23 @@ func (rt *TableRuntime) advanceRoundLocked() {
 		rt.determineWinnersAndSettleLocked()
 		return
 	}
+	if !rt.verifyInvariantsLocked("round_advance") {
+		return
+	}
 	if rt.round == 1 && rt.lastRaise == 0 && rt.basePi > 0 {
 		rt.lastRaise = rt.basePi
 	}
-	rt.persistRoundLogLocked(actionEntry{Action: fmt.Sprintf("round%d_start", rt.round), Seat: rt.turnSeat}, true)
+	startEntry := actionEntry{Action: fmt.Sprintf("round%d_start", rt.round), Seat: rt.turnSeat}
+	if rt.round == 1 && rt.dealCommitment != "" {
+		startEntry.Meta = map[string]interface{}{"dealCommitment": rt.dealCommitment}
+	}
+	rt.persistRoundLogLocked(startEntry, true)
 	rt.resetTurnTimerLocked()
+	rt.maybeApplyPrecommitLocked()
 }
 
 func (rt *TableRuntime) shouldDealThisStageLocked() bool {
-	if rt.round == 0 {
-		return true
-	}
-	if rt.chexuanMode && (rt.round == 1 || rt.round == 2) {
-		return true
-	}
-	return false
+	return rt.variantLocked().CardsPerStage(rt.round) > 0
 }
 
 func (rt *TableRuntime) firstActorSeatLocked() int {
@@ -764,47 +2613,51 @@ func (rt *TableRuntime) nextActiveAfterLocked(seatIdx int) int {
 	if len(active) == 0 {
 		return 0
 	}
-	if seatIdx == 0 {
-		return active[0]
-	}
+	start := 0
 	for i, s := range active {
 		if s == seatIdx {
-			return active[(i+1)%len(active)]
+			start = i + 1
+			break
+		}
+	}
+	// All-in seats stay active for the showdown but never take another
+	// turn, so rotation walks past them.
+	for i := 0; i < len(active); i++ {
+		idx := active[(start+i)%len(active)]
+		if seat := rt.findSeatLocked(idx); seat != nil && seat.Status != "allin" {
+			return idx
 		}
 	}
-	return active[0]
+	return 0
 }
 
 func (rt *TableRuntime) initDeckLocked() {
-	if rt.chexuanMode {
-		rt.deck = NewChexuanDeck()
+	rt.dealSeed, rt.dealCommitment = "", ""
+	if len(rt.fixedDeck) > 0 {
+		rt.deck = append([]string(nil), rt.fixedDeck...)
 		return
 	}
-	suits := []string{"s", "h", "d", "c"}
-	ranks := []string{"2", "3", "4", "5", "6", "7", "8", "9", "T", "J", "Q", "K", "A"}
-	rt.deck = make([]string, 0, 52)
-	for _, s := range suits {
-		for _, r := range ranks {
-			rt.deck = append(rt.deck, r+s)
+	// Tests and the simulation harness inject rt.rng for reproducible hands;
+	// committed hands derive their source from a fresh crypto seed instead.
+	rng := rt.rng
+	if rng == nil {
+		if seed, commitment, err := newDealSeed(); err == nil {
+			rt.dealSeed, rt.dealCommitment = seed, commitment
+			rng = seededRNG(seed)
 		}
 	}
-	mrand.Shuffle(len(rt.deck), func(i, j int) {
-		rt.deck[i], rt.deck[j] = rt.deck[j], rt.deck[i]
-	})
+	rt.deck = rt.variantLocked().NewDeck(rng)
 }
 
 func (rt *TableRuntime) dealCardsLocked() {
-	count := 0
+	count := rt.variantLocked().CardsPerStage(rt.round)
+	if count == 0 {
+		return
+	}
 	if rt.round == 0 {
-		count = 2
 		for i := range rt.seats {
 			rt.seats[i].cards = nil
 		}
-	} else if rt.chexuanMode && (rt.round == 1 || rt.round == 2) {
-		count = 1
-	}
-	if count == 0 {
-		return
 	}
 	cardsPerPlayer := count
 	activeSeats := rt.activeSeatsLocked()
@@ -827,9 +2680,13 @@ func (rt *TableRuntime) dealCardsLocked() {
 			}
 		}
 	}
+
+	rt.pushDealMessagesLocked(cardsPerPlayer)
 }
 
 func (rt *TableRuntime) markActedLocked(seatIdx int) {
+	// Acting resolves the turn; charge the seat for any bank time it spent.
+	rt.settleTimeBankBurnLocked(seatIdx)
 	if rt.roundActed == nil {
 		rt.roundActed = make(map[int]bool)
 	}
@@ -883,10 +2740,43 @@ func (rt *TableRuntime) minRaiseAmountLocked() int64 {
 	return minAmount
 }
 
-func (rt *TableRuntime) handleCallLocked(seatIdx int) error {
+// PotCapError reports a raise rejected by the scene pot cap, carrying how
+// much can still legally go into the pot.
+type PotCapError struct {
+	Headroom int64
+}
+
+func (e *PotCapError) Error() string {
+	return fmt.Sprintf("pot cap reached, headroom %d", e.Headroom)
+}
+
+func (rt *TableRuntime) potCappedLocked() bool {
+	return rt.maxPot > 0 && rt.pot >= rt.maxPot
+}
+
+// maybeBroadcastPotCapLocked announces the cap once per hand when the pot
+// first reaches it.
+func (rt *TableRuntime) maybeBroadcastPotCapLocked() {
+	if !rt.potCappedLocked() || rt.potCapNotified {
+		return
+	}
+	rt.potCapNotified = true
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "pot_capped",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{
+			"pot":    rt.pot,
+			"maxPot": rt.maxPot,
+		},
+	})
+}
+
+// callAmountLocked returns the chips the seat must still add to call, after
+// the stack and pot-cap clamps; handleCallLocked charges exactly this amount.
+func (rt *TableRuntime) callAmountLocked(seatIdx int) int64 {
 	seat := rt.findSeatLocked(seatIdx)
 	if seat == nil {
-		return fmt.Errorf("seat not found")
+		return 0
 	}
 	target := rt.requiredCallAmountLocked(seatIdx)
 	if target < rt.lastRaise {
@@ -899,6 +2789,44 @@ func (rt *TableRuntime) handleCallLocked(seatIdx int) error {
 	if diff > seat.Chips {
 		diff = seat.Chips
 	}
+	if rt.maxPot > 0 && rt.pot+diff > rt.maxPot {
+		diff = rt.maxPot - rt.pot
+	}
+	return diff
+}
+
+// raiseBoundsLocked returns the lowest and highest bet level a raise may
+// target, matching the validation in handleRaiseLocked.
+func (rt *TableRuntime) raiseBoundsLocked(seatIdx int) (int64, int64) {
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil {
+		return 0, 0
+	}
+	minAmount := rt.minRaiseAmountLocked()
+	maxAmount := seat.Chips + seat.Bet
+	if rt.maxPot > 0 {
+		if capped := seat.Bet + rt.maxPot - rt.pot; capped < maxAmount {
+			maxAmount = capped
+		}
+	}
+	return minAmount, maxAmount
+}
+
+func (rt *TableRuntime) handleCallLocked(seatIdx int) error {
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil {
+		return fmt.Errorf("seat not found")
+	}
+	rt.commitCallLocked(seat)
+	rt.appendLogLocked("call", seat.UserID)
+	rt.maybeBroadcastPotCapLocked()
+	return nil
+}
+
+// commitCallLocked moves the seat's outstanding call into the pot; shared by
+// the player action and the timeout auto-call.
+func (rt *TableRuntime) commitCallLocked(seat *SeatState) int64 {
+	diff := rt.callAmountLocked(seat.SeatIndex)
 	if diff > 0 {
 		if rt.round == 1 {
 			rt.round1Bet = true
@@ -908,13 +2836,13 @@ func (rt *TableRuntime) handleCallLocked(seatIdx int) error {
 	}
 	seat.Chips -= diff
 	seat.Bet += diff
+	seat.TotalContribution += diff
 	rt.pot += diff
 	if seat.Bet > rt.lastRaise {
 		rt.lastRaise = seat.Bet
-	}
-	rt.markActedLocked(seatIdx)
-	rt.appendLogLocked("call", seat.UserID)
-	return nil
+	}
+	rt.markActedLocked(seat.SeatIndex)
+	return diff
 }
 
 func (rt *TableRuntime) handleRaiseLocked(seatIdx int, data json.RawMessage) error {
@@ -933,6 +2861,16 @@ func (rt *TableRuntime) handleRaiseLocked(seatIdx int, data json.RawMessage) err
 		}
 		return fmt.Errorf("amount required")
 	}
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil {
+		return fmt.Errorf("seat not found")
+	}
+	// A raise to exactly the whole stack is an all-in and skips the minimum
+	// checks below: it is the only way a short stack can keep contesting
+	// when the raise floor exceeds what it has left.
+	if payload.Amount == seat.Bet+seat.Chips {
+		return rt.handleAllInLocked(seatIdx)
+	}
 	if rt.round == 1 {
 		threshold := rt.minUnitPi * 5
 		if threshold == 0 {
@@ -955,10 +2893,6 @@ func (rt *TableRuntime) handleRaiseLocked(seatIdx int, data json.RawMessage) err
 		}
 		return fmt.Errorf("amount too low")
 	}
-	seat := rt.findSeatLocked(seatIdx)
-	if seat == nil {
-		return fmt.Errorf("seat not found")
-	}
 	diff := payload.Amount - seat.Bet
 	if diff <= 0 {
 		if rt.boboEnabled {
@@ -969,8 +2903,12 @@ func (rt *TableRuntime) handleRaiseLocked(seatIdx int, data json.RawMessage) err
 	if seat.Chips < diff {
 		return fmt.Errorf("insufficient chips")
 	}
+	if rt.maxPot > 0 && rt.pot+diff > rt.maxPot {
+		return &PotCapError{Headroom: rt.maxPot - rt.pot}
+	}
 	seat.Chips -= diff
 	seat.Bet = payload.Amount
+	seat.TotalContribution += diff
 	rt.pot += diff
 	rt.lastRaise = payload.Amount
 	rt.lastAggSeat = seatIdx
@@ -985,6 +2923,60 @@ func (rt *TableRuntime) handleRaiseLocked(seatIdx int, data json.RawMessage) err
 	}
 	rt.resetRoundActedLocked(seatIdx)
 	rt.appendLogLocked("raise", seat.UserID)
+	rt.maybeBroadcastPotCapLocked()
+	return nil
+}
+
+// handleAllInLocked commits the seat's whole remaining stack, which is why it
+// is the one bet allowed below the raise minimum. The seat keeps its showdown
+// claim — settlement caps what it can win at what it matched — but takes no
+// further turns this hand.
+func (rt *TableRuntime) handleAllInLocked(seatIdx int) error {
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil {
+		return fmt.Errorf("seat not found")
+	}
+	if seat.Chips <= 0 {
+		return fmt.Errorf("no chips to commit")
+	}
+	diff := seat.Chips
+	if rt.maxPot > 0 && rt.pot+diff > rt.maxPot {
+		diff = rt.maxPot - rt.pot
+	}
+	if diff < 0 {
+		diff = 0
+	}
+	seat.Chips -= diff
+	seat.Bet += diff
+	seat.TotalContribution += diff
+	rt.pot += diff
+	seat.Status = "allin"
+	if diff > 0 {
+		if rt.round == 1 {
+			rt.round1Bet = true
+		} else if rt.round == 2 {
+			rt.round2Bet = true
+		}
+	}
+	if seat.Bet > rt.lastRaise {
+		// The shove raises the table; everyone still able to act gets a
+		// fresh decision.
+		rt.lastRaise = seat.Bet
+		rt.lastAggSeat = seatIdx
+		rt.firstRaiseDone = true
+		if rt.round == 1 {
+			rt.raisedRound1 = true
+		} else if rt.round == 2 {
+			rt.raisedRound2 = true
+		}
+		rt.resetRoundActedLocked(seatIdx)
+	} else {
+		// An all-in for less is a call: it closes the seat's action without
+		// reopening anyone else's.
+		rt.markActedLocked(seatIdx)
+	}
+	rt.appendLogLocked("allin", seat.UserID)
+	rt.maybeBroadcastPotCapLocked()
 	return nil
 }
 
@@ -1005,6 +2997,7 @@ func (rt *TableRuntime) handleKnockBoboLocked(seatIdx int, reason string) error
 	if diff > 0 {
 		seat.Chips -= diff
 		seat.Bet += diff
+		seat.TotalContribution += diff
 		rt.pot += diff
 	}
 	rt.lastRaise = seat.Bet
@@ -1047,7 +3040,7 @@ func (rt *TableRuntime) findSeatLocked(seatIdx int) *SeatState {
 
 func (rt *TableRuntime) findFirstActiveSeatLocked() int {
 	for _, seat := range rt.seats {
-		if seat.Status != "folded" && seat.Status != "eliminated" {
+		if seat.Status != "folded" && seat.Status != "eliminated" && seat.Status != "resting" {
 			return seat.SeatIndex
 		}
 	}
@@ -1061,12 +3054,115 @@ func (rt *TableRuntime) moveToNextTurnLocked() {
 	}
 	rt.turnSeat = next
 	rt.resetTurnTimerLocked()
+	rt.maybeApplyPrecommitLocked()
+}
+
+// Precommit intents a player may park while waiting for their turn.
+const (
+	precommitCallAny   = "call_any"
+	precommitCheckFold = "check_fold"
+)
+
+// handlePrecommitLocked stores (or clears, intent "none") the seat's
+// pre-selected action for its next turn. Only the owner ever sees it in the
+// state export; nothing settlement-relevant changes until the turn opens.
+func (rt *TableRuntime) handlePrecommitLocked(seatIdx int, userID int64, data json.RawMessage) error {
+	if rt.phase != PhasePlaying {
+		return fmt.Errorf("not in playing phase")
+	}
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil || seat.Status != "playing" {
+		return fmt.Errorf("invalid seat status")
+	}
+	var payload struct {
+		Intent string `json:"intent"`
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("invalid precommit payload: %v", err)
+		}
+	}
+	switch payload.Intent {
+	case precommitCallAny, precommitCheckFold:
+		if rt.precommits == nil {
+			rt.precommits = make(map[int]string)
+		}
+		rt.precommits[seatIdx] = payload.Intent
+	case "none", "":
+		delete(rt.precommits, seatIdx)
+	default:
+		return fmt.Errorf("unsupported precommit intent")
+	}
+	rt.pushStateLocked(userID)
+	return nil
+}
+
+// maybeApplyPrecommitLocked plays the turn owner's stored precommit the
+// moment their turn opens. call_any calls whatever is owed (or checks when
+// nothing is); check_fold only fires while checking is still legal — a raise
+// that arrived after the precommit invalidates it and the seat falls back to
+// its normal timed turn rather than being folded on the player's behalf. The
+// precommit is consumed either way.
+func (rt *TableRuntime) maybeApplyPrecommitLocked() {
+	if rt.phase != PhasePlaying || rt.turnSeat == 0 {
+		return
+	}
+	seatIdx := rt.turnSeat
+	intent, ok := rt.precommits[seatIdx]
+	if !ok {
+		return
+	}
+	delete(rt.precommits, seatIdx)
+
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil || seat.Status != "playing" {
+		return
+	}
+
+	switch intent {
+	case precommitCallAny:
+		if rt.canPassLocked(seatIdx) {
+			rt.markActedLocked(seatIdx)
+			rt.appendLogLocked("auto_pass", seat.UserID)
+			rt.persistRoundLogLocked(actionEntry{Action: "auto_pass", Seat: seatIdx, Meta: map[string]interface{}{"reason": "precommit"}})
+		} else {
+			if err := rt.handleCallLocked(seatIdx); err != nil {
+				return
+			}
+			rt.persistRoundLogLocked(actionEntry{Action: "call", Seat: seatIdx, Meta: map[string]interface{}{"reason": "precommit"}})
+		}
+	case precommitCheckFold:
+		if !rt.canPassLocked(seatIdx) {
+			return
+		}
+		rt.markActedLocked(seatIdx)
+		rt.appendLogLocked("auto_pass", seat.UserID)
+		rt.persistRoundLogLocked(actionEntry{Action: "auto_pass", Seat: seatIdx, Meta: map[string]interface{}{"reason": "precommit"}})
+	default:
+		return
+	}
+
+	if rt.shouldSettleLocked() {
+		if rt.round == 2 && rt.round2Bet {
+			rt.tailBigWin = true
+		}
+		rt.phase = PhaseSettling
+		rt.determineWinnersAndSettleLocked()
+		return
+	}
+
+	if rt.shouldAdvanceRoundLocked() {
+		rt.advanceRoundLocked()
+		return
+	}
+
+	rt.moveToNextTurnLocked()
 }
 
 func (rt *TableRuntime) activeSeatsLocked() []int {
 	result := make([]int, 0)
 	for _, seat := range rt.seats {
-		if seat.Status != "folded" && seat.Status != "eliminated" {
+		if seat.Status != "folded" && seat.Status != "eliminated" && seat.Status != "resting" {
 			result = append(result, seat.SeatIndex)
 		}
 	}
@@ -1087,7 +3183,7 @@ func (rt *TableRuntime) shouldAdvanceRoundLocked() bool {
 	}
 	for _, seatIdx := range active {
 		seat := rt.findSeatLocked(seatIdx)
-		if seat == nil || seat.Status == "folded" || seat.Status == "eliminated" {
+		if seat == nil || seat.Status == "folded" || seat.Status == "eliminated" || seat.Status == "allin" {
 			continue
 		}
 		if seat.Bet < rt.lastRaise && seat.Chips > 0 {
@@ -1105,10 +3201,12 @@ func (rt *TableRuntime) shouldSettleLocked() bool {
 }
 
 func (rt *TableRuntime) determineWinnersAndSettleLocked() {
-	if rt.chexuanMode {
-		rt.settleChexuanLocked()
-		return
-	}
+	rt.variantLocked().SettleLocked(rt)
+}
+
+// settleDefaultLocked is the generic settlement: fold-wins, then a showdown
+// scored by the variant's evaluator with layered side pots.
+func (rt *TableRuntime) settleDefaultLocked() {
 	activeSeats := rt.activeSeatsLocked()
 	if len(activeSeats) == 0 {
 		rt.finishLocked()
@@ -1124,36 +3222,17 @@ func (rt *TableRuntime) determineWinnersAndSettleLocked() {
 
 		results := make([]PlayerResult, 0)
 
-		// Winner gets Pot - their own Contribution?
-		// Actually Pot includes everyone's bets.
-		// NetPoints for winner = Pot - their_bets_this_round (already in Pot) + returned_bets...
-		// Simplified: NetPoints = Pot - TotalBet
-		// But SettleMatch expects NetPoints sum to 0.
-		// So Winner gets +X, Losers get -Y.
-
-		// We need to track how much each player put in to calculate net win/loss correctly?
-		// SeatState has `Bet` which is CURRENT round bet.
-		// Real poker needs cumulative pot tracking per player for side pots.
-		// Simplified Model:
-		// Losers lose what they bet. Winner wins the rest.
-
-		// Calculate losers first
+		// Each loser loses what they paid into the pot this hand
+		// (TotalContribution: ante plus every call/raise/knock, across all
+		// rounds), capped at what the winner matched: an all-in winner
+		// cannot collect contributions it never covered, the excess stays
+		// with its owner. The ledger still nets to zero.
 		winAmount := int64(0)
 		for _, seat := range rt.seats {
 			if seat.SeatIndex == winnerIdx {
 				continue
 			}
-			// Assuming `Bet` is what they put in THIS round/hand total?
-			// rt.pot should be sum of all seat.Bet if we reset Bet each round?
-			// Wait, rt.pot accumulates. seat.Bet is usually per-street.
-			// If we simplify: seat.Bet is total contribution this hand.
-			// We need to persist total contribution if we clear seat.Bet between rounds.
-			// Current implementation: startRound clears Bet. call/raise adds to Bet and Pot.
-			// So seat.Bet is valid for this round.
-			// If multiple rounds, we need cumulative.
-			// Let's assume single round for "Mango" / "Bobo".
-
-			loss := seat.Bet
+			loss := minInt64(seat.TotalContribution, winnerSeat.TotalContribution)
 			if loss > 0 {
 				results = append(results, PlayerResult{
 					UserID:    seat.UserID,
@@ -1170,6 +3249,7 @@ func (rt *TableRuntime) determineWinnersAndSettleLocked() {
 		})
 
 		rt.applyMangoSettlementLocked(&results, showdown)
+		rt.applyChipUpdatesLocked(results)
 		rt.finishWithResultsLocked(results)
 		return
 	}
@@ -1177,21 +3257,21 @@ func (rt *TableRuntime) determineWinnersAndSettleLocked() {
 	// 2. Showdown: Compare cards
 	// Evaluate hands
 	type contender struct {
-		SeatIdx int
-		UserID  int64
-		Score   int64
-		Bet     int64
+		SeatIdx      int
+		UserID       int64
+		Score        int64
+		Contribution int64
 	}
 	candidates := make([]contender, 0)
 
 	for _, idx := range activeSeats {
 		seat := rt.findSeatLocked(idx)
-		score := EvaluateHand(seat.cards)
+		score := rt.variantLocked().Evaluate(seat.cards)
 		candidates = append(candidates, contender{
-			SeatIdx: idx,
-			UserID:  seat.UserID,
-			Score:   score,
-			Bet:     seat.Bet,
+			SeatIdx:      idx,
+			UserID:       seat.UserID,
+			Score:        score,
+			Contribution: seat.TotalContribution,
 		})
 	}
 
@@ -1200,43 +3280,72 @@ func (rt *TableRuntime) determineWinnersAndSettleLocked() {
 		return candidates[i].Score > candidates[j].Score
 	})
 
-	winner := candidates[0]
-	// Handle split pot? MVP: Single winner
+	// Pay out layer by layer in hand order, side pots included: each hand
+	// takes at most its own contribution from every worse hand and every
+	// folded stake (min(loser, winner) per pair, like the chexuan ledger),
+	// so an all-in winner only collects the portion it matched and the
+	// leftover falls through to the next-best hand. Anything no contender
+	// matched stays with its owner via the chip update below.
+	nets := make([]int64, len(candidates))
+	remaining := make([]int64, len(candidates))
+	for i, c := range candidates {
+		remaining[i] = c.Contribution
+	}
 
-	results := make([]PlayerResult, 0)
-	winAmount := int64(0)
+	type foldedStake struct {
+		userID    int64
+		remaining int64
+		net       int64
+	}
+	folded := make([]foldedStake, 0)
+	for _, seat := range rt.seats {
+		if seat.Status == "folded" && seat.TotalContribution > 0 {
+			folded = append(folded, foldedStake{userID: seat.UserID, remaining: seat.TotalContribution})
+		}
+	}
 
-	for _, c := range candidates {
-		if c.SeatIdx == winner.SeatIdx {
-			continue
+	for i, w := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			take := minInt64(remaining[j], w.Contribution)
+			if take <= 0 {
+				continue
+			}
+			remaining[j] -= take
+			nets[j] -= take
+			nets[i] += take
+		}
+		for f := range folded {
+			take := minInt64(folded[f].remaining, w.Contribution)
+			if take <= 0 {
+				continue
+			}
+			folded[f].remaining -= take
+			folded[f].net -= take
+			nets[i] += take
+		}
+	}
+
+	results := make([]PlayerResult, 0, len(candidates)+len(folded))
+	for i, c := range candidates {
+		meta := map[string]interface{}{"score": c.Score}
+		if i == 0 {
+			meta["winType"] = "showdown"
 		}
-		loss := c.Bet
 		results = append(results, PlayerResult{
 			UserID:    c.UserID,
-			NetPoints: -loss,
-			Meta:      map[string]interface{}{"score": c.Score},
+			NetPoints: nets[i],
+			Meta:      meta,
 		})
-		winAmount += loss
 	}
-
-	// Add folded players losses
-	for _, seat := range rt.seats {
-		if seat.Status == "folded" && seat.Bet > 0 {
-			results = append(results, PlayerResult{
-				UserID:    seat.UserID,
-				NetPoints: -seat.Bet,
-			})
-			winAmount += seat.Bet
-		}
+	for _, stake := range folded {
+		results = append(results, PlayerResult{
+			UserID:    stake.userID,
+			NetPoints: stake.net,
+		})
 	}
 
-	results = append(results, PlayerResult{
-		UserID:    winner.UserID,
-		NetPoints: winAmount,
-		Meta:      map[string]interface{}{"score": winner.Score, "winType": "showdown"},
-	})
-
 	rt.applyMangoSettlementLocked(&results, showdown)
+	rt.applyChipUpdatesLocked(results)
 	rt.finishWithResultsLocked(results)
 }
 
@@ -1251,9 +3360,8 @@ func (rt *TableRuntime) settleChexuanMangoLocked() {
 			results = append(results, PlayerResult{
 				UserID:    seat.UserID,
 				NetPoints: 0, // Refund: No profit/loss recorded in DB (or strictly 0).
-				// But we rely on applyChipUpdatesLocked to return the bet to seat.Chips.
-				// applyChipUpdatesLocked: returned = seat.Bet (100) + NetPoints (0) = 100.
-				// seat.Chips += 100. (Restore balance). Correct.
+				// applyChipUpdatesLocked returns the stake to seat.Chips:
+				// returned = contribution (100) + NetPoints (0) = 100. Correct.
 				Meta: map[string]interface{}{"reason": "mango_refund"},
 			})
 		} else {
@@ -1270,6 +3378,7 @@ func (rt *TableRuntime) settleChexuanMangoLocked() {
 	} else {
 		rt.mangoStreak = 3
 	}
+	rt.recordMangoEventLocked("liuju")
 
 	// Attach Mango info to first result for logging
 	if len(results) > 0 {
@@ -1301,7 +3410,7 @@ type chexuanPlayer struct {
 func (rt *TableRuntime) settleChexuanLocked() {
 	participants := make([]chexuanPlayer, 0, len(rt.seats))
 	for i, seat := range rt.seats {
-		if seat.Status == "eliminated" || seat.UserID == 0 {
+		if seat.Status == "eliminated" || seat.Status == "resting" || seat.UserID == 0 {
 			continue
 		}
 		p := chexuanPlayer{
@@ -1347,27 +3456,7 @@ func (rt *TableRuntime) settleChexuanLocked() {
 	// Note: SanHua players are valid but scores are irrelevant as they always tie.
 	// We can keep them in the list.
 	sort.Slice(participants, func(i, j int) bool {
-		// Folded always last
-		if participants[i].Folded != participants[j].Folded {
-			return !participants[i].Folded
-		}
-		if participants[i].Folded {
-			return false
-		}
-
-		// SanHua treatment in sort? Doesn't matter much as they tie.
-		// Let's sort normally for others.
-
-		if participants[i].Invalid != participants[j].Invalid {
-			return !participants[i].Invalid
-		}
-		if participants[i].HeadScore != participants[j].HeadScore {
-			return participants[i].HeadScore > participants[j].HeadScore
-		}
-		if participants[i].HeadMax != participants[j].HeadMax {
-			return participants[i].HeadMax > participants[j].HeadMax
-		}
-		return participants[i].TailScore > participants[j].TailScore
+		return rt.chexuanRankLess(participants[i], participants[j])
 	})
 
 	ledger := make(map[int64]int64, len(participants))
@@ -1380,7 +3469,9 @@ func (rt *TableRuntime) settleChexuanLocked() {
 	// Logic: If tailBigWin is true, we skip comparison.
 	if rt.tailBigWin && rt.lastAggSeat != 0 {
 		winner := rt.findSeatLocked(rt.lastAggSeat)
-		if winner != nil {
+		// The aggressor may have folded after betting; a folded seat can
+		// never take the pot, so fall through to the pairwise settle.
+		if winner != nil && winner.Status != "folded" {
 			winTotal := int64(0)
 			for _, seat := range rt.seats {
 				if seat.UserID == winner.UserID || seat.UserID == 0 {
@@ -1390,11 +3481,11 @@ func (rt *TableRuntime) settleChexuanLocked() {
 				winTotal += seat.Bet
 			}
 			ledger[winner.UserID] = winTotal
+			res := buildResultsFromLedger(ledger)
+			rt.applyMangoSettlementLocked(res, len(participants) > 1)
+			rt.finishWithResultsLocked(*res)
+			return
 		}
-		res := buildResultsFromLedger(ledger)
-		rt.applyMangoSettlementLocked(res, len(participants) > 1)
-		rt.finishWithResultsLocked(*res)
-		return
 	}
 
 	// Pairwise settle
@@ -1425,8 +3516,10 @@ func (rt *TableRuntime) settleChexuanLocked() {
 	// Only the top player (by sort order) gets protection?
 	// Document says "Head Big (Largest Head Card) player".
 	// Our sort puts largest HeadScore first. So participants[0] is Head Big.
+	// Exact head ties were already broken deterministically by the sort
+	// (closest seat after the banker), so the protection always lands on
+	// the same player for the same deal.
 	top := participants[0]
-	// Check if top really is Head Big (could be tied with others).
 	// Protection applies if they lost more than cap.
 	if !top.Folded && !top.Invalid {
 		net := ledger[top.UserID]
@@ -1445,6 +3538,52 @@ func (rt *TableRuntime) settleChexuanLocked() {
 	rt.finishWithResultsLocked(*results)
 }
 
+// chexuanRankLess orders settlement participants: unfolded before folded,
+// valid before daoba, then HeadScore, HeadMax and TailScore descending. On an
+// exact tie the seat closest after the banker ranks first, so the head-big
+// spot (and the loss-cap protection attached to it) lands deterministically
+// instead of following the input slice order.
+func (rt *TableRuntime) chexuanRankLess(a, b chexuanPlayer) bool {
+	// Folded always last
+	if a.Folded != b.Folded {
+		return !a.Folded
+	}
+	if a.Folded {
+		return false
+	}
+
+	// SanHua treatment in sort? Doesn't matter much as they tie.
+	// Let's sort normally for others.
+
+	if a.Invalid != b.Invalid {
+		return !a.Invalid
+	}
+	if a.HeadScore != b.HeadScore {
+		return a.HeadScore > b.HeadScore
+	}
+	if a.HeadMax != b.HeadMax {
+		return a.HeadMax > b.HeadMax
+	}
+	if a.TailScore != b.TailScore {
+		return a.TailScore > b.TailScore
+	}
+	return rt.seatOrderFromBankerLocked(a.SeatIdx) < rt.seatOrderFromBankerLocked(b.SeatIdx)
+}
+
+// seatOrderFromBankerLocked ranks a seat by clockwise distance from the seat
+// just after the banker; the banker itself ranks last. Used as the final
+// tie-break when chexuan participants are otherwise exactly equal.
+func (rt *TableRuntime) seatOrderFromBankerLocked(seatIdx int) int {
+	n := rt.seatCount
+	if n <= 0 {
+		n = len(rt.seats)
+	}
+	if n <= 0 {
+		return seatIdx
+	}
+	return ((seatIdx-rt.bankerSeat-1)%n + n) % n
+}
+
 func (rt *TableRuntime) applyChipUpdatesLocked(results []PlayerResult) {
 	for _, res := range results {
 		if res.UserID == 0 {
@@ -1454,16 +3593,16 @@ func (rt *TableRuntime) applyChipUpdatesLocked(results []PlayerResult) {
 		if !ok {
 			continue
 		}
-		seat := &rt.seats[seatIdx-1]
-		// For winners, we add back their bet + net profit.
-		// For losers (net < 0), we add back (bet - loss).
-		// Since net = win - bet (usually), or net is pure profit/loss.
-		// Let's assume NetPoints is change in wealth relative to start of hand.
-		// If I bet 100 and win pot of 300 (my 100 + opp 100 + opp 100). Net is +200.
-		// Returned = 100 (my bet) + 200 (net) = 300. Correct.
-		// If I bet 100 and lose. Net is -100.
-		// Returned = 100 + (-100) = 0. Correct.
-		returned := seat.Bet + res.NetPoints
+		seat := rt.findSeatLocked(seatIdx)
+		if seat == nil {
+			continue
+		}
+		// The seat's whole contribution was already moved into the pot at
+		// action time, so its stack change for the hand should equal
+		// NetPoints. Returning contribution + net achieves that: a winner
+		// gets its stake back plus the profit, a full loser gets zero, a
+		// refunded seat (net 0) gets exactly its stake back.
+		returned := seat.TotalContribution + res.NetPoints
 		if returned > 0 {
 			seat.Chips += returned
 		}
@@ -1643,25 +3782,340 @@ func (rt *TableRuntime) applyMangoSettlementLocked(results *[]PlayerResult, show
 			}
 			(*results)[winIdx].NetPoints += mangoValue
 		}
-		if (*results)[winIdx].Meta == nil {
-			(*results)[winIdx].Meta = make(map[string]interface{})
+		if (*results)[winIdx].Meta == nil {
+			(*results)[winIdx].Meta = make(map[string]interface{})
+		}
+		(*results)[winIdx].Meta["mangoValue"] = mangoValue
+		(*results)[winIdx].Meta["mangoStreak"] = newStreak
+	}
+
+	if newStreak > rt.mangoStreak {
+		rt.mangoStreak = newStreak
+		rt.recordMangoEventLocked("liuju")
+	} else if newStreak == 0 && rt.mangoStreak > 0 {
+		rt.mangoStreak = 0
+		rt.recordMangoEventLocked("reset")
+	} else {
+		rt.mangoStreak = newStreak
+	}
+}
+
+const mangoHistoryLimit = 20
+
+func (rt *TableRuntime) recentMangoEventsLocked(n int) []MangoEvent {
+	if len(rt.mangoHistory) == 0 {
+		return nil
+	}
+	if len(rt.mangoHistory) > n {
+		return append([]MangoEvent(nil), rt.mangoHistory[len(rt.mangoHistory)-n:]...)
+	}
+	return append([]MangoEvent(nil), rt.mangoHistory...)
+}
+
+func parseMangoHistory(raw json.RawMessage) []MangoEvent {
+	if len(raw) == 0 {
+		return nil
+	}
+	var events []MangoEvent
+	if err := json.Unmarshal(raw, &events); err != nil {
+		return nil
+	}
+	return events
+}
+
+// recordMangoEventLocked appends the streak change to the table's history and
+// persists it so the carry survives a restart alongside mango_streak.
+func (rt *TableRuntime) recordMangoEventLocked(trigger string) {
+	event := MangoEvent{
+		Hand:    rt.handNo,
+		Trigger: trigger,
+		Streak:  rt.mangoStreak,
+		Carried: int64(rt.mangoStreak) * 2 * rt.basePi,
+	}
+	rt.mangoHistory = append(rt.mangoHistory, event)
+	if len(rt.mangoHistory) > mangoHistoryLimit {
+		rt.mangoHistory = rt.mangoHistory[len(rt.mangoHistory)-mangoHistoryLimit:]
+	}
+	rt.persistMangoHistoryLocked()
+}
+
+func (rt *TableRuntime) persistMangoHistoryLocked() {
+	if rt.db == nil {
+		return
+	}
+	data, err := json.Marshal(rt.mangoHistory)
+	if err != nil {
+		return
+	}
+	tableID := rt.tableID
+	db := rt.db
+	go func() {
+		if err := db.Model(&model.Table{}).
+			Where("id = ?", tableID).
+			Update("mango_history_json", datatypes.JSON(data)).Error; err != nil {
+			logger.Log.Warn("failed to persist mango history", zap.Int64("tableID", tableID), zap.Error(err))
+		}
+	}()
+}
+
+func (rt *TableRuntime) finishWithResultsLocked(results []PlayerResult) {
+	if !rt.verifySettlementLocked(results) {
+		// Ledger doesn't balance: the table froze instead of settling
+		// garbage; chips stay where they are pending admin review.
+		return
+	}
+	rt.phase = PhaseEnded
+	rt.turnSeat = 0
+	rt.cancelTimerLocked()
+	rt.persistRuntimeSnapshotLocked() // hand over: clear the resume point
+	rt.SettlementResults = results    // Store for callback
+	if rt.dealSeed != "" {
+		// The reveal: replay readers verify the seed against the commitment
+		// persisted with the round1_start entry.
+		rt.persistRoundLogLocked(actionEntry{Action: "deal_reveal", Meta: map[string]interface{}{
+			"dealSeed":       rt.dealSeed,
+			"dealCommitment": rt.dealCommitment,
+		}})
+	}
+	rt.SettlementLogChain = rt.logChain
+	rt.armTopUpCountdownLocked()
+	if rt.practice {
+		rt.pushPracticeHintLocked()
+	}
+	rt.broadcastHandSummaryLocked(results)
+	rt.broadcastStateLocked()
+	if reason := rt.tableExpiredReasonLocked(); reason != "" {
+		rt.expireTableLocked(reason)
+	}
+	rt.beginClosingWindowLocked()
+
+	if rt.onFinish != nil {
+		go rt.onFinish(rt)
+	}
+}
+
+// resultGraceFromConfig reads the configured result-screen duration, falling
+// back to the built-in default.
+func resultGraceFromConfig() time.Duration {
+	if config.GlobalConfig != nil && config.GlobalConfig.Game.ResultGraceSeconds > 0 {
+		return time.Duration(config.GlobalConfig.Game.ResultGraceSeconds) * time.Second
+	}
+	return defaultResultGraceSeconds * time.Second
+}
+
+// stateFlushFromConfig reads the configured state-broadcast coalescing
+// interval, falling back to the built-in default.
+func stateFlushFromConfig() time.Duration {
+	if config.GlobalConfig != nil && config.GlobalConfig.Game.StateFlushMS > 0 {
+		return time.Duration(config.GlobalConfig.Game.StateFlushMS) * time.Millisecond
+	}
+	return defaultStateFlushMS * time.Millisecond
+}
+
+// beginClosingWindowLocked opens the post-end grace window: clients get a
+// table_closing frame with the countdown so they can play out the result
+// animation, and the runtime holds the next-hand or close transition until
+// the window ends or every active seat acks the result.
+func (rt *TableRuntime) beginClosingWindowLocked() {
+	grace := rt.resultGrace
+	if grace <= 0 {
+		grace = defaultResultGraceSeconds * time.Second
+	}
+	rt.closingUntil = rt.timeNow().Add(grace)
+	rt.resultAcks = make(map[int64]bool)
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "table_closing",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{
+			"countdown": int(grace / time.Second),
+			"deadline":  rt.closingUntil.UnixMilli(),
+			"reason":    rt.expiredReason, // "" while the table plays on
+		},
+	})
+	rt.timer = time.NewTimer(grace)
+	rt.timerC = rt.timer.C
+}
+
+// endClosingWindowLocked applies whatever transition the window held back:
+// the deferred expiry close, or the next match that arrived mid-window.
+func (rt *TableRuntime) endClosingWindowLocked() {
+	rt.closingUntil = time.Time{}
+	rt.cancelTimerLocked()
+	if rt.expiredReason != "" {
+		rt.stopWithReason(CloseReasonExpired)
+		return
+	}
+	if rt.pendingMatch != 0 {
+		matchID := rt.pendingMatch
+		rt.pendingMatch = 0
+		rt.handleNextMatchLocked(matchID)
+	}
+}
+
+// handleAckResultLocked records that a seat has seen the result screen; the
+// latency feeds analytics, and once every active seat has acked the closing
+// window ends early.
+func (rt *TableRuntime) handleAckResultLocked(seatIdx int, userID int64) error {
+	if rt.phase != PhaseEnded || rt.closingUntil.IsZero() {
+		return fmt.Errorf("no result to acknowledge")
+	}
+	if rt.resultAcks == nil {
+		rt.resultAcks = make(map[int64]bool)
+	}
+	if rt.resultAcks[userID] {
+		return nil
+	}
+	rt.resultAcks[userID] = true
+	logger.Log.Info("result acked",
+		zap.Int64("tableID", rt.tableID),
+		zap.Int64("userID", userID),
+		zap.Int("seat", seatIdx),
+		zap.Int64("matchID", rt.matchID),
+	)
+	for _, seat := range rt.seats {
+		if seat.UserID != 0 && seat.Status != "eliminated" && !rt.resultAcks[seat.UserID] {
+			return nil
+		}
+	}
+	rt.endClosingWindowLocked()
+	return nil
+}
+
+// beginNextMatch hands a fresh match ID to a settled runtime so the next
+// hand plays on the same table.
+func (rt *TableRuntime) beginNextMatch(matchID int64) {
+	select {
+	case rt.cmdCh <- loopCommand{kind: "next_match", matchID: matchID}:
+	case <-rt.quitCh:
+	}
+}
+
+// handleNextMatchLocked resets a settled runtime for a fresh match: chips
+// and the mango streak carry over, the banker rotates to the next stacked
+// seat, ready flags clear and the phase returns to waiting so players opt
+// into the next hand. Seats without chips are left to the top-up and
+// elimination flow and are not dealt in.
+func (rt *TableRuntime) handleNextMatchLocked(matchID int64) {
+	if rt.phase != PhaseEnded {
+		return
+	}
+	if !rt.closingUntil.IsZero() {
+		// The result screen is still up; hold the reset until the closing
+		// window ends or every seat acks.
+		rt.pendingMatch = matchID
+		return
+	}
+	rt.matchID = matchID
+	rt.SettlementResults = nil
+	rt.SettlementLogChain = ""
+	rt.logChain = "" // the chain is per match; the new match starts fresh
+	rt.bankerSeat = rt.nextStackedSeatLocked(rt.bankerSeat)
+	rt.persistBankerLocked()
+	for i := range rt.seats {
+		rt.seats[i].Ready = false
+	}
+	rt.phase = PhaseWaiting
+	rt.appendSystemLogLocked("新一局已开，请准备")
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "next_match",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{"matchId": matchID, "banker": rt.bankerSeat},
+	})
+	rt.broadcastStateLocked()
+}
+
+// persistBankerLocked writes the rotated button to the table row so a restart
+// resumes the rotation instead of resetting it to the first seat.
+func (rt *TableRuntime) persistBankerLocked() {
+	if rt.db == nil {
+		return
+	}
+	_ = rt.db.Model(&model.Table{}).
+		Where("id = ?", rt.tableID).
+		Update("banker_seat", rt.bankerSeat).Error
+}
+
+// nextStackedSeatLocked rotates from the given seat to the next one able to
+// bank a hand: seated, not eliminated and still holding chips.
+func (rt *TableRuntime) nextStackedSeatLocked(from int) int {
+	if len(rt.seats) == 0 {
+		return from
+	}
+	start := rt.findSeatIdxLocked(from)
+	for off := 1; off <= len(rt.seats); off++ {
+		seat := rt.seats[(start+off+len(rt.seats))%len(rt.seats)]
+		if seat.UserID != 0 && seat.Status != "eliminated" && seat.Chips > 0 {
+			return seat.SeatIndex
+		}
+	}
+	return from
+}
+
+// stackedSeatsSnapshot counts seats that can play another hand; like the
+// other snapshots it is read by the finish callback while the loop runs.
+func (rt *TableRuntime) stackedSeatsSnapshot() int {
+	n := 0
+	for _, seat := range rt.seats {
+		if seat.UserID != 0 && seat.Status != "eliminated" && seat.Chips > 0 {
+			n++
 		}
-		(*results)[winIdx].Meta["mangoValue"] = mangoValue
-		(*results)[winIdx].Meta["mangoStreak"] = newStreak
 	}
+	return n
+}
 
-	rt.mangoStreak = newStreak
+// requeueCandidate is one expired-table player who opted into an automatic
+// re-queue, captured with the stack they would buy back in with.
+type requeueCandidate struct {
+	UserID int64
+	BuyIn  int64
 }
 
-func (rt *TableRuntime) finishWithResultsLocked(results []PlayerResult) {
-	rt.phase = PhaseEnded
-	rt.turnSeat = 0
-	rt.cancelTimerLocked()
-	rt.SettlementResults = results // Store for callback
-	rt.broadcastStateLocked()
+// tableExpiredReasonLocked reports why the table's lifetime is over, or ""
+// while it may host another hand. Limits are only evaluated after a hand
+// settles, which both keeps mid-hand state out of the close flow and
+// guarantees every table delivers at least one full hand before the duration
+// limit can re-queue anyone.
+func (rt *TableRuntime) tableExpiredReasonLocked() string {
+	if rt.handNo == 0 {
+		return ""
+	}
+	if rt.maxHands > 0 && rt.handNo >= rt.maxHands {
+		return "max_hands"
+	}
+	if rt.maxLifetime > 0 && rt.timeNow().Sub(rt.openedAt) >= rt.maxLifetime {
+		return "max_duration"
+	}
+	return ""
+}
 
-	if rt.onFinish != nil {
-		go rt.onFinish(rt)
+// expireTableLocked closes a table that ran past its scene lifetime limits.
+// Players hear about it first (table_expired), re-queue opt-ins are captured
+// for the service callback, then the loop stops with a dissolve-style close
+// frame. Wallet settlement already happened in the normal finish path; the
+// service side clears matched markers and performs the re-queues.
+func (rt *TableRuntime) expireTableLocked(reason string) {
+	rt.expiredReason = reason
+	for _, seat := range rt.seats {
+		if seat.UserID == 0 || !rt.requeueOptIn[seat.UserID] {
+			continue
+		}
+		rt.expiredRequeue = append(rt.expiredRequeue, requeueCandidate{UserID: seat.UserID, BuyIn: seat.Chips})
+	}
+	rt.appendSystemLogLocked(fmt.Sprintf("牌桌已到期（第%d手结束），自动散桌", rt.handNo))
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "table_expired",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{
+			"reason":   reason,
+			"hands":    rt.handNo,
+			"requeued": len(rt.expiredRequeue),
+		},
+	})
+	// The stop itself waits for the post-end closing window so players see
+	// the final result before their sockets die; forced dissolves outside a
+	// finished hand have no window and stop immediately.
+	if rt.closingUntil.IsZero() && rt.phase != PhaseEnded {
+		rt.stopWithReason(CloseReasonExpired)
 	}
 }
 
@@ -1671,12 +4125,22 @@ func (rt *TableRuntime) onFinishWithResults(results []PlayerResult) {
 }
 
 type actionEntry struct {
-	Seq    int64                  `json:"seq"`
-	TS     int64                  `json:"ts"`
-	Action string                 `json:"action"`
-	Seat   int                    `json:"seat"`
-	Amount int64                  `json:"amount,omitempty"`
-	Meta   map[string]interface{} `json:"meta,omitempty"`
+	Seq       int64                  `json:"seq"`
+	TS        int64                  `json:"ts"`
+	Action    string                 `json:"action"`
+	Seat      int                    `json:"seat"`
+	Amount    int64                  `json:"amount,omitempty"`
+	LatencyMS int64                  `json:"latencyMs,omitempty"` // time from turn start to the decision
+	Meta      map[string]interface{} `json:"meta,omitempty"`
+}
+
+// decisionLatencyMSLocked measures how long the current turn has been open,
+// feeding the per-player behavior statistics.
+func (rt *TableRuntime) decisionLatencyMSLocked() int64 {
+	if rt.turnStartedAt.IsZero() {
+		return 0
+	}
+	return rt.timeNow().Sub(rt.turnStartedAt).Milliseconds()
 }
 
 func (rt *TableRuntime) persistRoundLogLocked(entry actionEntry, includeCards ...bool) {
@@ -1684,7 +4148,18 @@ func (rt *TableRuntime) persistRoundLogLocked(entry actionEntry, includeCards ..
 		return
 	}
 	entry.Seq = rt.nextSeqLocked()
-	entry.TS = time.Now().UnixMilli()
+	entry.TS = rt.timeNow().UnixMilli()
+
+	// Trace correlation attached at action time rides along in the entry
+	// metadata so a persisted line can be tied back to its request.
+	if sc := trace.SpanContextFromContext(rt.actionContextLocked()); sc.IsValid() {
+		if entry.Meta == nil {
+			entry.Meta = make(map[string]interface{})
+		}
+		entry.Meta["traceId"] = sc.TraceID().String()
+		entry.Meta["spanId"] = sc.SpanID().String()
+	}
+
 	actions := []actionEntry{entry}
 
 	payload := struct {
@@ -1716,12 +4191,21 @@ func (rt *TableRuntime) persistRoundLogLocked(entry actionEntry, includeCards ..
 		RoundNo:     rt.round,
 		ActionsJSON: actionsRaw,
 		CardsJSON:   cardsRaw,
-		CreatedAt:   time.Now(),
+		CreatedAt:   rt.timeNow(),
 	}
+	// The chain advances under the loop, so order is well-defined here; the
+	// writer then inserts rows one at a time to keep id order matching it.
+	log.ChainHMAC = nextChainHMAC(rt.matchID, rt.logChain, actionsRaw)
+	rt.logChain = log.ChainHMAC
 
-	go func(l model.MatchRoundLog) {
-		_ = rt.db.Create(&l).Error
-	}(log)
+	// Derive from the action context for correlation but drop cancellation:
+	// a cancelled request or a shutting-down service flushes the write, it
+	// never abandons it.
+	writeCtx := context.WithoutCancel(rt.actionContextLocked())
+	if rt.logWriter == nil {
+		rt.logWriter = &roundLogWriter{db: rt.db}
+	}
+	rt.logWriter.enqueue(writeCtx, log)
 }
 
 func (rt *TableRuntime) encryptCardsForLogLocked() map[string]string {
@@ -1760,6 +4244,16 @@ func encryptForUser(userID int64, data []byte) (string, error) {
 	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
 }
 
+func (rt *TableRuntime) seatUsersSnapshot() map[int]int64 {
+	users := make(map[int]int64, len(rt.seats))
+	for _, seat := range rt.seats {
+		if seat.UserID != 0 {
+			users[seat.SeatIndex] = seat.UserID
+		}
+	}
+	return users
+}
+
 func (rt *TableRuntime) playersSnapshot() []int64 {
 	ids := make([]int64, 0, len(rt.seats))
 	for _, seat := range rt.seats {
@@ -1774,6 +4268,7 @@ func (rt *TableRuntime) finishLocked() {
 	rt.phase = PhaseEnded
 	rt.turnSeat = 0
 	rt.cancelTimerLocked()
+	rt.persistRuntimeSnapshotLocked()
 	rt.broadcastStateLocked()
 	if rt.onFinish != nil {
 		go rt.onFinish(rt)
@@ -1790,11 +4285,25 @@ func (rt *TableRuntime) appendLogLocked(action string, userID int64) {
 		}
 	}
 	content := fmt.Sprintf("%s %s", alias, rt.describeActionForLog(action, seatPtr))
-	rt.logs = append(rt.logs, LogItem{
-		ID:        fmt.Sprintf("%d-%d", time.Now().UnixNano(), len(rt.logs)+1),
-		Timestamp: time.Now().UnixMilli(),
+	item := LogItem{
+		ID:        fmt.Sprintf("%d-%d", rt.timeNow().UnixNano(), len(rt.logs)+1),
+		Timestamp: rt.timeNow().UnixMilli(),
 		Content:   content,
-	})
+	}
+	rt.logs = append(rt.logs, item)
+	rt.pendingLogs = append(rt.pendingLogs, item)
+}
+
+// appendSystemLogLocked records a pre-formatted line that is not tied to a
+// single player's action, e.g. the between-hands settlement recap.
+func (rt *TableRuntime) appendSystemLogLocked(content string) {
+	item := LogItem{
+		ID:        fmt.Sprintf("%d-%d", rt.timeNow().UnixNano(), len(rt.logs)+1),
+		Timestamp: rt.timeNow().UnixMilli(),
+		Content:   content,
+	}
+	rt.logs = append(rt.logs, item)
+	rt.pendingLogs = append(rt.pendingLogs, item)
 }
 
 func (rt *TableRuntime) describeActionForLog(action string, seat *SeatState) string {
@@ -1803,6 +4312,8 @@ func (rt *TableRuntime) describeActionForLog(action string, seat *SeatState) str
 		return "超时自动过牌"
 	case strings.HasPrefix(action, "auto_fold"):
 		return "超时自动弃牌"
+	case strings.HasPrefix(action, "auto_call"):
+		return "超时自动跟注"
 	case strings.HasPrefix(action, "knock_bobo"):
 		return "敲波波"
 	case action == "fold":
@@ -1826,29 +4337,133 @@ func (rt *TableRuntime) describeActionForLog(action string, seat *SeatState) str
 	}
 }
 
+// handleRequestTimeLocked spends the seat's once-per-hand time bank to extend
+// the current turn deadline by the scene's TimeBankSeconds.
+func (rt *TableRuntime) handleRequestTimeLocked(seatIdx int, userID int64) error {
+	if rt.timeBankSec <= 0 {
+		return fmt.Errorf("time bank disabled")
+	}
+	if rt.phase != PhasePlaying {
+		return fmt.Errorf("not in playing phase")
+	}
+	if rt.turnSeat != seatIdx {
+		return fmt.Errorf("not your turn")
+	}
+	if rt.timeBankUsed[seatIdx] {
+		return fmt.Errorf("time bank already used this hand")
+	}
+	if rt.isTurnExpiredLocked() {
+		return fmt.Errorf("turn timeout")
+	}
+
+	rt.timeBankUsed[seatIdx] = true
+	rt.extendTurnTimerLocked(time.Duration(rt.timeBankSec) * time.Second)
+	rt.appendLogLocked("request_time", userID)
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "time_extended",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{
+			"seat":       seatIdx,
+			"extendedBy": rt.timeBankSec,
+			"countdown":  rt.countdownSecondsLocked(),
+		},
+	})
+	return nil
+}
+
+func (rt *TableRuntime) extendTurnTimerLocked(extra time.Duration) {
+	rt.cancelTimerLocked()
+	rt.turnDeadline = rt.turnDeadline.Add(extra)
+	rt.timer = time.NewTimer(time.Until(rt.turnDeadline))
+	rt.timerC = rt.timer.C
+}
+
+// armReadyTimerLocked starts the waiting-phase deadline on the loop timer.
+// The waiting table has no turn, so the timer is free; once a hand starts the
+// usual turn arming takes the timer back over.
+func (rt *TableRuntime) armReadyTimerLocked() {
+	rt.cancelTimerLocked()
+	wait := rt.readyTimeout
+	if wait <= 0 {
+		wait = defaultReadyTimeoutSeconds * time.Second
+	}
+	rt.readyDeadline = rt.timeNow().Add(wait)
+	rt.timer = time.NewTimer(time.Until(rt.readyDeadline))
+	rt.timerC = rt.timer.C
+}
+
 func (rt *TableRuntime) resetTurnTimerLocked() {
 	rt.cancelTimerLocked()
-	rt.turnDeadline = time.Now().Add(defaultTurnSeconds * time.Second)
-	rt.timer = time.NewTimer(defaultTurnSeconds * time.Second)
+	rt.turnToken++
+	turn := time.Duration(rt.turnSeconds) * time.Second
+	rt.turnStartedAt = rt.timeNow()
+	rt.turnDeadline = rt.turnStartedAt.Add(turn)
+	rt.timer = time.NewTimer(turn)
 	rt.timerC = rt.timer.C
 }
 
 func (rt *TableRuntime) handleTurnTimeoutLocked() {
+	if rt.frozen {
+		return
+	}
+	if rt.phase == PhaseEnded && !rt.closingUntil.IsZero() {
+		// Not a turn: the post-end closing window just ran out.
+		rt.endClosingWindowLocked()
+		return
+	}
+	if rt.phase == PhaseWaiting {
+		if !rt.readyDeadline.IsZero() {
+			rt.handleReadyTimeoutLocked()
+		}
+		return
+	}
 	if rt.phase != PhasePlaying {
 		return
 	}
 
+	seatIdx := rt.turnSeat
+	// An expired base countdown first burns the seat's time bank; only once
+	// that is gone does the auto-action below fire.
+	if rt.beginTimeBankBurnLocked(seatIdx) {
+		return
+	}
+	rt.settleTimeBankBurnLocked(seatIdx)
+
 	logger.Log.Warn("turn timeout auto-action",
 		zap.Int64("tableID", rt.tableID),
 		zap.Int("seat", rt.turnSeat),
 	)
-	if rt.canPassLocked(rt.turnSeat) {
-		rt.markActedLocked(rt.turnSeat)
+	if rt.timeoutStrikes == nil {
+		rt.timeoutStrikes = make(map[int]int)
+	}
+	rt.timeoutStrikes[seatIdx]++
+
+	latency := rt.decisionLatencyMSLocked()
+	policy := rt.timeoutPolicyLocked()
+	switch {
+	case rt.timeoutStrikes[seatIdx] >= rt.strikeLimitLocked():
+		// Enough consecutive timeouts: the player is gone, stop dragging
+		// the hand out on their account.
+		rt.markSeatStatusLocked(seatIdx, "folded")
+		rt.markActedLocked(seatIdx)
+		rt.appendLogLocked("auto_fold", 0)
+		rt.persistRoundLogLocked(actionEntry{Action: "auto_fold", Seat: seatIdx, LatencyMS: latency, Meta: map[string]interface{}{"reason": "timeout_strikes"}})
+		rt.benchSeatLocked(seatIdx, "timeout_strikes")
+	case policy != TimeoutPolicyFold && rt.canPassLocked(seatIdx):
+		rt.markActedLocked(seatIdx)
 		rt.appendLogLocked("auto_pass", 0)
-	} else {
-		rt.markSeatStatusLocked(rt.turnSeat, "folded")
-		rt.markActedLocked(rt.turnSeat)
+		rt.persistRoundLogLocked(actionEntry{Action: "auto_pass", Seat: seatIdx, LatencyMS: latency, Meta: map[string]interface{}{"reason": "timeout", "policy": policy}})
+	case policy == TimeoutPolicyCall && rt.autoCallAllowedLocked(seatIdx):
+		seat := rt.findSeatLocked(seatIdx)
+		diff := rt.commitCallLocked(seat)
+		rt.appendLogLocked("auto_call", 0)
+		rt.maybeBroadcastPotCapLocked()
+		rt.persistRoundLogLocked(actionEntry{Action: "auto_call", Seat: seatIdx, LatencyMS: latency, Meta: map[string]interface{}{"reason": "timeout", "policy": policy, "amount": diff}})
+	default:
+		rt.markSeatStatusLocked(seatIdx, "folded")
+		rt.markActedLocked(seatIdx)
 		rt.appendLogLocked("auto_fold", 0)
+		rt.persistRoundLogLocked(actionEntry{Action: "auto_fold", Seat: seatIdx, LatencyMS: latency, Meta: map[string]interface{}{"reason": "timeout", "policy": policy}})
 	}
 
 	if rt.shouldSettleLocked() {
@@ -1872,6 +4487,303 @@ func (rt *TableRuntime) handleTurnTimeoutLocked() {
 	rt.broadcastStateLocked()
 }
 
+// handleReadyTimeoutLocked resolves a waiting phase that outlived the ready
+// deadline. With at least two readied players the hand deals anyway: every
+// absent seat is cashed out like a leave, so its reservation refunds instead
+// of holding the table hostage. Below two no hand can follow, so the table
+// dissolves the admin-dissolve way — zero-net results release every escrow in
+// one settlement pass and the finish callback clears the players' matched
+// markers so they can re-queue.
+func (rt *TableRuntime) handleReadyTimeoutLocked() {
+	rt.readyDeadline = time.Time{}
+	rt.cancelTimerLocked()
+
+	ready := 0
+	for _, seat := range rt.seats {
+		if seat.UserID != 0 && seat.Ready {
+			ready++
+		}
+	}
+	if ready < 2 {
+		rt.expiredReason = "dissolved"
+		results := make([]PlayerResult, 0, len(rt.seats))
+		for _, seat := range rt.seats {
+			if seat.UserID == 0 {
+				continue
+			}
+			results = append(results, PlayerResult{
+				UserID:    seat.UserID,
+				NetPoints: 0,
+				Meta: map[string]interface{}{
+					"reason": "ready_timeout",
+				},
+			})
+		}
+		rt.SettlementResults = results
+		rt.phase = PhaseEnded
+		rt.appendSystemLogLocked("准备超时，人数不足，牌桌已解散")
+		rt.broadcastMessageLocked(OutgoingMessage{
+			Type: "table_dissolved",
+			Seq:  rt.nextSeqLocked(),
+			Data: ginH{"reason": "ready_timeout"},
+		})
+		rt.stopWithReason(CloseReasonDissolved)
+		if rt.onFinish != nil {
+			go rt.onFinish(rt)
+		}
+		return
+	}
+
+	seats := make([]SeatState, len(rt.seats))
+	copy(seats, rt.seats)
+	for _, seat := range seats {
+		if seat.UserID == 0 || seat.Ready {
+			continue
+		}
+		if err := rt.releaseEscrowLocked(seat.UserID, seat.Chips); err != nil {
+			logger.Log.Warn("ready-timeout cash-out failed",
+				zap.Int64("tableID", rt.tableID),
+				zap.Int64("userID", seat.UserID),
+				zap.Error(err),
+			)
+			continue
+		}
+		rt.appendLogLocked("leave", seat.UserID)
+		rt.totalCashOuts += seat.Chips
+		rt.removeSeatLocked(seat.SeatIndex, seat.UserID)
+		rt.appendSystemLogLocked(fmt.Sprintf("%d号位准备超时，已退还买入", seat.SeatIndex))
+	}
+	rt.persistPlayersLocked()
+	rt.checkChipInvariantLocked()
+
+	rt.startRoundLocked()
+	rt.broadcastStateLocked()
+}
+
+func (rt *TableRuntime) strikeLimitLocked() int {
+	if rt.strikeLimit > 0 {
+		return rt.strikeLimit
+	}
+	return defaultTimeoutStrikeLimit
+}
+
+// timeoutPolicyLocked normalizes the scene's timeout policy; empty or unknown
+// values behave as the historical pass-else-fold default.
+func (rt *TableRuntime) timeoutPolicyLocked() string {
+	switch rt.timeoutPolicy {
+	case TimeoutPolicyFold, TimeoutPolicyCall:
+		return rt.timeoutPolicy
+	default:
+		return TimeoutPolicyPass
+	}
+}
+
+// autoCallAllowedLocked reports whether the auto_call policy may call for the
+// timed-out seat. The call must cost something, cost at most basePi, be fully
+// affordable (a timeout never puts a player all-in) and not land in the
+// showdown round.
+func (rt *TableRuntime) autoCallAllowedLocked(seatIdx int) bool {
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil || rt.round >= 3 {
+		return false
+	}
+	target := rt.requiredCallAmountLocked(seatIdx)
+	if target < rt.lastRaise {
+		target = rt.lastRaise
+	}
+	diff := target - seat.Bet
+	return diff > 0 && diff <= rt.basePi && diff <= seat.Chips
+}
+
+// benchSeatLocked flags a seat as sitting out: the ready check stops waiting
+// on it and the next deal marks it "resting" until the player acts again.
+func (rt *TableRuntime) benchSeatLocked(seatIdx int, reason string) {
+	if rt.sittingOut == nil {
+		rt.sittingOut = make(map[int]bool)
+	}
+	if rt.sittingOut[seatIdx] {
+		return
+	}
+	rt.sittingOut[seatIdx] = true
+	if seat := rt.findSeatLocked(seatIdx); seat != nil {
+		delete(rt.disconnectDeadline, seat.UserID)
+	}
+	switch reason {
+	case "disconnected":
+		rt.appendSystemLogLocked(fmt.Sprintf("%d号位断线未归，自动暂离", seatIdx))
+	default:
+		rt.appendSystemLogLocked(fmt.Sprintf("%d号位连续超时，自动暂离", seatIdx))
+	}
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "seat_sitting_out",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{"seat": seatIdx, "reason": reason},
+	})
+}
+
+// armDisconnectGraceLocked starts the resubscribe grace window when a seated
+// player drops their last local connection mid-hand. If they are still gone
+// when the timer fires the disconnect_check command benches them.
+func (rt *TableRuntime) armDisconnectGraceLocked(userID int64) {
+	seatIdx, ok := rt.seatByUser[userID]
+	if !ok || rt.phase != PhasePlaying {
+		return
+	}
+	if rt.remoteSubs[userID] > 0 || rt.sittingOut[seatIdx] {
+		return
+	}
+	if rt.disconnectDeadline == nil {
+		rt.disconnectDeadline = make(map[int64]time.Time)
+	}
+	grace := disconnectGraceSeconds * time.Second
+	rt.disconnectDeadline[userID] = rt.timeNow().Add(grace)
+	time.AfterFunc(grace, func() {
+		select {
+		case rt.cmdCh <- loopCommand{kind: "disconnect_check", userID: userID}:
+		case <-rt.quitCh:
+		}
+	})
+}
+
+func (rt *TableRuntime) handleDisconnectCheckLocked(userID int64) {
+	deadline, ok := rt.disconnectDeadline[userID]
+	if !ok || rt.timeNow().Before(deadline) {
+		// Resubscribed (marker cleared) or a later grace window superseded
+		// this timer.
+		return
+	}
+	delete(rt.disconnectDeadline, userID)
+	if _, back := rt.subscribers[userID]; back || rt.remoteSubs[userID] > 0 {
+		return
+	}
+	seatIdx, seated := rt.seatByUser[userID]
+	if !seated {
+		return
+	}
+	seat := rt.findSeatLocked(seatIdx)
+	if seat == nil || seat.Status == "eliminated" {
+		return
+	}
+
+	rt.benchSeatLocked(seatIdx, "disconnected")
+
+	if rt.phase == PhasePlaying && seat.Status == "playing" {
+		rt.markSeatStatusLocked(seatIdx, "folded")
+		rt.markActedLocked(seatIdx)
+		rt.appendLogLocked("auto_fold", userID)
+		rt.persistRoundLogLocked(actionEntry{Action: "auto_fold", Seat: seatIdx, Meta: map[string]interface{}{"reason": "disconnected"}})
+
+		if rt.shouldSettleLocked() {
+			if rt.round == 2 && rt.round2Bet {
+				rt.tailBigWin = true
+			}
+			rt.phase = PhaseSettling
+			rt.determineWinnersAndSettleLocked()
+			return
+		}
+		if rt.turnSeat == seatIdx {
+			if rt.shouldAdvanceRoundLocked() {
+				rt.advanceRoundLocked()
+				if rt.phase != PhaseSettling {
+					rt.broadcastStateLocked()
+				}
+				return
+			}
+			rt.moveToNextTurnLocked()
+		}
+	}
+	rt.broadcastStateLocked()
+}
+
+// stop terminates the runtime loop and closes subscriber channels. Safe to
+// call more than once.
+func (rt *TableRuntime) stop() {
+	rt.stopWithReason(CloseReasonShutdown)
+}
+
+// shutdown drains a runtime for process exit: an in-flight hand is
+// snapshotted so the restarted server resumes it, subscribers get a
+// server_shutdown notice with a reconnect hint, then the loop closes their
+// channels with the usual close frame.
+func (rt *TableRuntime) shutdown() {
+	resp := make(chan error, 1)
+	select {
+	case rt.cmdCh <- loopCommand{kind: "shutdown", resp: resp}:
+		<-resp
+	case <-rt.quitCh:
+	}
+	rt.stop()
+}
+
+// transfer drains a runtime for a warm handoff to another instance: the
+// in-flight hand is snapshotted, further actions are refused, subscribers get
+// a table_transferring notice and then a reconnect_to close so they reattach
+// immediately. Closing quitCh also releases the Redis ownership claim, which
+// is what lets the next instance's GetRuntime pick the table up.
+func (rt *TableRuntime) transfer() {
+	resp := make(chan error, 1)
+	select {
+	case rt.cmdCh <- loopCommand{kind: "transfer", resp: resp}:
+		<-resp
+	case <-rt.quitCh:
+	}
+	rt.stopWithReason(CloseReasonTransferring)
+}
+
+// CurrentInfo reports the phase and active match as the loop sees them, for
+// current-table discovery. ok is false when the runtime already stopped.
+func (rt *TableRuntime) CurrentInfo() (phase Phase, matchID int64, ok bool) {
+	ch := make(chan runtimeInfo, 1)
+	select {
+	case rt.cmdCh <- loopCommand{kind: "current_info", info: ch}:
+		select {
+		case info := <-ch:
+			return info.phase, info.matchID, true
+		case <-rt.quitCh:
+			return "", 0, false
+		}
+	case <-rt.quitCh:
+		return "", 0, false
+	}
+}
+
+func (rt *TableRuntime) handleShutdownLocked() {
+	if rt.phase == PhasePlaying {
+		rt.persistRuntimeSnapshotLocked()
+	}
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "server_shutdown",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{"reconnectAfterMs": shutdownReconnectHintMS},
+	})
+}
+
+// handleTransferLocked freezes the runtime behind its final snapshot and
+// tells clients the table moves instances: unlike a shutdown there is no
+// backoff hint — the replacement host is already up, so reconnecting at once
+// resumes the hand with minimal dead air.
+func (rt *TableRuntime) handleTransferLocked() {
+	rt.transferring = true
+	rt.cancelTimerLocked()
+	if rt.phase == PhasePlaying {
+		rt.persistRuntimeSnapshotLocked()
+	}
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "table_transferring",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{"reconnectAfterMs": 0},
+	})
+}
+
+// stopWithReason records why the runtime is going away before the loop closes
+// the subscriber channels, so the WS layer can pick a matching close code.
+func (rt *TableRuntime) stopWithReason(reason string) {
+	rt.stopOnce.Do(func() {
+		rt.stopReason = reason
+		close(rt.quitCh)
+	})
+}
+
 func (rt *TableRuntime) cancelTimerLocked() {
 	if rt.timer != nil {
 		rt.timer.Stop()
@@ -1881,10 +4793,15 @@ func (rt *TableRuntime) cancelTimerLocked() {
 }
 
 func (rt *TableRuntime) countdownSecondsLocked() int {
-	if rt.turnDeadline.IsZero() {
+	deadline := rt.turnDeadline
+	if rt.phase == PhaseWaiting && !rt.readyDeadline.IsZero() {
+		// Waiting tables count down to the ready deadline instead of a turn.
+		deadline = rt.readyDeadline
+	}
+	if deadline.IsZero() {
 		return 0
 	}
-	diff := time.Until(rt.turnDeadline)
+	diff := time.Until(deadline)
 	if diff <= 0 {
 		return 0
 	}
@@ -1895,13 +4812,31 @@ func (rt *TableRuntime) isTurnExpiredLocked() bool {
 	if rt.turnDeadline.IsZero() {
 		return false
 	}
-	return time.Now().After(rt.turnDeadline)
+	return rt.timeNow().After(rt.turnDeadline)
 }
 
 // Service manages per-table runtimes.
 func (s *Service) GetRuntime(ctx context.Context, tableID int64) (*TableRuntime, error) {
 	if v, ok := s.runtimes.Load(tableID); ok {
-		return v.(*TableRuntime), nil
+		rt := v.(*TableRuntime)
+		select {
+		case <-rt.quitCh:
+			// The runtime stopped (eviction, dissolve) between being looked
+			// up and used; clear the stale entry and rebuild from the table
+			// row below.
+			s.runtimes.CompareAndDelete(tableID, v)
+		default:
+			return rt, nil
+		}
+	}
+
+	if s.AtRuntimeCapacity() {
+		s.runtimeSheds.Add(1)
+		logger.WithContext(ctx).Warn("shedding table runtime: instance at capacity",
+			zap.Int64("tableID", tableID),
+			zap.Int("runtimes", s.RuntimeCount()),
+		)
+		return nil, appErr.ErrServerBusy
 	}
 
 	var table model.Table
@@ -1926,13 +4861,80 @@ func (s *Service) GetRuntime(ctx context.Context, tableID int64) (*TableRuntime,
 		return nil, err
 	}
 
-	rt, err := newTableRuntime(s.db, table, scene, matchID, s.handleRuntimeFinish)
+	rt, err := newTableRuntime(s.lifecycleContext(), s.db, table, scene, matchID, s.bridge, s.handleRuntimeFinish)
 	if err != nil {
+		if errors.Is(err, appErr.ErrCorruptTable) {
+			s.dissolveCorruptTable(ctx, table, matchID, err)
+		}
 		return nil, err
 	}
-	s.runtimes.Store(tableID, rt)
+	rt.onSeats = s.indexTableUsers
+	rt.onAnomaly = s.recordAnomaly
+	if existing, loaded := s.runtimes.LoadOrStore(tableID, rt); loaded {
+		// A concurrent caller built the runtime first; discard ours so two
+		// loops never serve the same table.
+		rt.stop()
+		return existing.(*TableRuntime), nil
+	}
+	s.indexTableUsers(tableID, rt.playersSnapshot())
+	s.bridge.claimOwnership(tableID, rt.quitCh)
+	s.bridge.consumeActions(rt)
 	return rt, nil
 }
 
+// dissolveCorruptTable closes a table whose stored state cannot back a
+// runtime. The active match (if any) settles at zero for every readable
+// player so buy-ins come back untouched, and the table is marked ended so
+// nothing tries to load it again.
+func (s *Service) dissolveCorruptTable(ctx context.Context, table model.Table, matchID int64, cause error) {
+	logger.WithContext(ctx).Error("dissolving corrupt table",
+		zap.Int64("tableID", table.ID),
+		zap.Error(cause),
+	)
+
+	if matchID != 0 {
+		seats, _, err := parsePlayersJSON(json.RawMessage(table.PlayersJSON))
+		if err == nil && len(seats) > 0 {
+			results := make([]PlayerResult, 0, len(seats))
+			for _, seat := range seats {
+				results = append(results, PlayerResult{
+					UserID:    seat.UserID,
+					NetPoints: 0,
+					Meta:      map[string]interface{}{"reason": "corrupt_table_refund"},
+				})
+			}
+			if err := s.SettleMatch(ctx, SettlementRequest{
+				MatchID: matchID,
+				SceneID: table.SceneID,
+				Results: results,
+			}); err == nil {
+				// SettleMatch already marked the table ended.
+				return
+			}
+			logger.WithContext(ctx).Warn("corrupt table refund settlement failed",
+				zap.Int64("matchID", matchID),
+			)
+		}
+	}
+
+	_ = s.db.WithContext(ctx).
+		Model(&model.Table{}).
+		Where("id = ?", table.ID).
+		Update("status", "ended").Error
+}
+
+// GetSession resolves the table for a WS client: the local runtime when this
+// instance hosts (or can host) it, or a remote relay when another instance
+// already owns it.
+func (s *Service) GetSession(ctx context.Context, tableID int64) (TableSession, error) {
+	if v, ok := s.runtimes.Load(tableID); ok {
+		return v.(*TableRuntime), nil
+	}
+	if owner := s.bridge.remoteOwner(ctx, tableID); owner != "" {
+		return newRemoteSession(tableID, s.bridge), nil
+	}
+	return s.GetRuntime(ctx, tableID)
+}
+
 // ginH is a tiny helper to avoid importing gin in this file.
 type ginH map[string]interface{}
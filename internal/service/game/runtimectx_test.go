@@ -0,0 +1,98 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func roundLogTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func waitForRoundLogs(t *testing.T, db *gorm.DB, want int64) []model.MatchRoundLog {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var logs []model.MatchRoundLog
+		if err := db.Find(&logs).Error; err != nil {
+			t.Fatalf("query round logs: %v", err)
+		}
+		if int64(len(logs)) == want {
+			return logs
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d round logs, got %d", want, len(logs))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRoundLogFlushesAfterShutdownCancel(t *testing.T) {
+	db := roundLogTestDB(t)
+
+	// The runtime's lifecycle context is already cancelled, as it would be
+	// mid-shutdown; the write must flush anyway instead of being abandoned.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rt := &TableRuntime{db: db, matchID: 5, ctx: ctx}
+
+	rt.persistRoundLogLocked(actionEntry{Action: "raise", Seat: 1, Amount: 20})
+	waitForRoundLogs(t, db, 1)
+}
+
+func TestRoundLogCarriesActionTraceIDs(t *testing.T) {
+	db := roundLogTestDB(t)
+	rt := &TableRuntime{db: db, matchID: 6}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+		SpanID:  trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+	})
+	rt.actionCtx = trace.ContextWithSpanContext(context.Background(), sc)
+
+	rt.persistRoundLogLocked(actionEntry{Action: "call", Seat: 2})
+	logs := waitForRoundLogs(t, db, 1)
+
+	var actions []actionEntry
+	if err := json.Unmarshal(logs[0].ActionsJSON, &actions); err != nil {
+		t.Fatalf("decode actions: %v", err)
+	}
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action, got %d", len(actions))
+	}
+	if got := actions[0].Meta["traceId"]; got != sc.TraceID().String() {
+		t.Fatalf("expected traceId %s, got %v", sc.TraceID().String(), got)
+	}
+	if got := actions[0].Meta["spanId"]; got != sc.SpanID().String() {
+		t.Fatalf("expected spanId %s, got %v", sc.SpanID().String(), got)
+	}
+}
+
+func TestStopCancelsLifecycleContext(t *testing.T) {
+	s := NewService(nil, nil, nil, nil)
+	if s.lifeCtx.Err() != nil {
+		t.Fatal("lifecycle context cancelled before Stop")
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if s.lifeCtx.Err() == nil {
+		t.Fatal("expected Stop to cancel the lifecycle context")
+	}
+}
@@ -3,21 +3,280 @@ package game
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"dx-service/internal/config"
 	"dx-service/internal/model"
+	"dx-service/internal/service/features"
+	"dx-service/internal/service/notify"
 	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
 
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // Service encapsulates game-specific workflows such as settlement and live table runtime.
 type Service struct {
 	db       *gorm.DB
+	rdb      *redis.Client
+	bridge   *bridge
+	features *features.Service
+	notify   *notify.Service
 	runtimes sync.Map // tableID -> *TableRuntime
+
+	// userTables/tableUsers form the discovery index answering "which live
+	// table is this user seated at". Runtimes push lineup changes through
+	// indexTableUsers; lazily initialized so bare test services work.
+	indexMu    sync.Mutex
+	userTables map[int64]int64
+	tableUsers map[int64]map[int64]bool
+
+	// runtimeSheds counts runtime creations refused at the capacity ceiling.
+	runtimeSheds atomic.Int64
+
+	// anomalies counts game-state invariant violations raised by hosted
+	// runtimes; anomalyReporter forwards each report to an external error
+	// reporter when one is wired, nil keeps them log-only.
+	anomalies       atomic.Int64
+	anomalyReporter func(AnomalyReport)
+
+	// finishEvictGrace/idleEvictAfter override the eviction thresholds in
+	// evict.go; zero keeps the package defaults. Set by tests only.
+	finishEvictGrace time.Duration
+	idleEvictAfter   time.Duration
+
+	// matchmaker recycles players when a table's lifetime limits close it;
+	// nil (bare test services) skips marker cleanup and re-queues.
+	matchmaker Matchmaker
+
+	// deadletter captures async deliveries that permanently failed so an
+	// admin can replay them; nil skips the capture.
+	deadletter DeadLetter
+
+	// panic is the operator kill switch: while engaged, settlements defer
+	// through the dead-letter queue instead of applying. Nil never panics.
+	panic PanicChecker
+
+	// lifeCtx spans the service's lifetime. Runtime-scoped contexts derive
+	// from it so background writers learn about shutdown instead of running
+	// on a detached context.Background.
+	lifeCtx    context.Context
+	lifeCancel context.CancelFunc
+}
+
+func NewService(db *gorm.DB, rdb *redis.Client, featuresSvc *features.Service, notifySvc *notify.Service) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{db: db, rdb: rdb, bridge: newBridge(rdb), features: featuresSvc, notify: notifySvc, lifeCtx: ctx, lifeCancel: cancel}
+}
+
+// Matchmaker is the narrow slice of the matchmaking service the game service
+// needs: dropping a user's matched markers so their status returns to idle,
+// putting opt-ins straight back into a scene's queue, and reading the
+// pending-match notification for current-table discovery.
+type Matchmaker interface {
+	ClearMatched(ctx context.Context, userID int64)
+	Requeue(ctx context.Context, userID, sceneID, buyIn int64) error
+	PendingMatch(ctx context.Context, userID int64) (tableID, matchID int64, ok bool)
+}
+
+// SetMatchmaker wires the matchmaking service in after construction.
+func (s *Service) SetMatchmaker(m Matchmaker) {
+	s.matchmaker = m
+}
+
+// DeadLetter is where settlement hands off deliveries it has given up on,
+// keeping the payload around for an admin-triggered replay.
+type DeadLetter interface {
+	Record(ctx context.Context, deliveryType string, payload interface{}, attempts int, deliveryErrs []string) error
+}
+
+// SetDeadLetter wires the dead-letter queue in after construction.
+func (s *Service) SetDeadLetter(d DeadLetter) {
+	s.deadletter = d
+}
+
+// PanicChecker is the narrow slice of the panic-stop service settlement
+// needs: whether money movement is frozen globally or for one scene.
+type PanicChecker interface {
+	Active(ctx context.Context, sceneID int64) bool
+}
+
+// SetPanicChecker wires the panic-stop switch in after construction.
+func (s *Service) SetPanicChecker(p PanicChecker) {
+	s.panic = p
+}
+
+// RuntimeCount is the gauge of live table runtimes hosted by this instance.
+func (s *Service) RuntimeCount() int {
+	n := 0
+	s.runtimes.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// AtRuntimeCapacity reports whether the configured runtime ceiling is
+// reached. The matcher consults it before composing new tables so queued
+// users aren't seated at tables nobody can host.
+func (s *Service) AtRuntimeCapacity() bool {
+	if config.GlobalConfig == nil {
+		return false
+	}
+	limit := config.GlobalConfig.Capacity.MaxRuntimes
+	return limit > 0 && s.RuntimeCount() >= limit
+}
+
+// RuntimeSheds reports how many runtime creations were refused because the
+// instance was at its ceiling.
+func (s *Service) RuntimeSheds() int64 {
+	return s.runtimeSheds.Load()
+}
+
+// SetAnomalyReporter wires the error-reporting hook that receives runtime
+// anomaly reports in after construction.
+func (s *Service) SetAnomalyReporter(fn func(AnomalyReport)) {
+	s.anomalyReporter = fn
+}
+
+// AnomalyCount is the gauge of invariant violations raised by runtimes on
+// this instance.
+func (s *Service) AnomalyCount() int64 {
+	return s.anomalies.Load()
+}
+
+// recordAnomaly is every runtime's onAnomaly sink: bump the metric and relay
+// the report.
+func (s *Service) recordAnomaly(report AnomalyReport) {
+	s.anomalies.Add(1)
+	if s.anomalyReporter != nil {
+		s.anomalyReporter(report)
+	}
+}
+
+// lifecycleContext is the service-wide parent for runtime background work.
+// Zero-value Services (bare test fixtures) fall back to Background.
+func (s *Service) lifecycleContext() context.Context {
+	if s.lifeCtx != nil {
+		return s.lifeCtx
+	}
+	return context.Background()
+}
+
+// Stop drains every live table runtime. In-flight hands are snapshotted to
+// their table rows first, so the match resumes instead of leaving a dangling
+// un-ended row. With the Redis bridge present the drain is a warm handoff:
+// ownership keys are released and clients are told to reconnect at once,
+// landing on whichever instance claims the table next. Without the bridge
+// (single instance) clients get the usual shutdown backoff hint. The context
+// only bounds how long we wait for in-flight loop commands.
+func (s *Service) Stop(ctx context.Context) error {
+	s.runtimes.Range(func(key, value interface{}) bool {
+		rt := value.(*TableRuntime)
+		if s.bridge != nil {
+			rt.transfer()
+		} else {
+			rt.shutdown()
+		}
+		s.runtimes.Delete(key)
+		s.dropTableIndex(key.(int64))
+		return ctx.Err() == nil
+	})
+	// Signal background writers that the service is going away. Writes
+	// derived from the lifecycle context flush (they strip cancellation)
+	// rather than starting new work against a closing process.
+	if s.lifeCancel != nil {
+		s.lifeCancel()
+	}
+	return ctx.Err()
+}
+
+// AdminAdjustRuntime corrects a live table's runtime state between hands and
+// returns before/after snapshots for the audit trail.
+func (s *Service) AdminAdjustRuntime(ctx context.Context, tableID int64, adj AdminAdjustment) (before, after map[string]interface{}, err error) {
+	rt, err := s.GetRuntime(ctx, tableID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rt.AdminAdjust(adj)
+}
+
+// AdminDissolveTable force-closes a stuck table. With a live runtime the
+// loop aborts the hand, refunds every seat through a zero-sum settlement and
+// stops; without one the table row is marked ended and the open match closed
+// so nothing loads it as active again.
+func (s *Service) AdminDissolveTable(ctx context.Context, tableID int64) error {
+	if v, ok := s.runtimes.Load(tableID); ok {
+		// The finish callback settles the refund results and removes the
+		// runtime from the map via finalizeExpiredTable.
+		return v.(*TableRuntime).Dissolve()
+	}
+
+	res := s.db.WithContext(ctx).
+		Model(&model.Table{}).
+		Where("id = ?", tableID).
+		Update("status", "ended")
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return appErr.ErrTableNotFound
+	}
+	return s.db.WithContext(ctx).
+		Model(&model.Match{}).
+		Where("table_id = ? AND ended_at IS NULL", tableID).
+		Update("ended_at", time.Now()).Error
+}
+
+// CashOut removes a player's seat between hands, releasing any escrow still
+// frozen on their wallet. Used by the leave action, kicks and table teardown.
+func (s *Service) CashOut(ctx context.Context, tableID, userID int64) error {
+	rt, err := s.GetRuntime(ctx, tableID)
+	if err != nil {
+		return err
+	}
+	return rt.HandleActionContext(ctx, userID, "leave", nil)
+}
+
+// SeatLatePlayer seats a queued user into an already-composed table that
+// still has a free seat. The runtime enforces the real guards — it rejects
+// the insert mid-hand or once every seat is taken — so the matcher can offer
+// players optimistically and requeue whoever it refuses. The active match ID
+// comes back for the matched notification payload.
+func (s *Service) SeatLatePlayer(ctx context.Context, tableID, userID, chips int64) (int64, error) {
+	rt, err := s.GetRuntime(ctx, tableID)
+	if err != nil {
+		return 0, err
+	}
+
+	alias := ""
+	var user model.User
+	if err := s.db.WithContext(ctx).First(&user, userID).Error; err == nil {
+		alias = user.Nickname
+	}
+	if err := rt.SeatUser(userID, alias, chips); err != nil {
+		return 0, err
+	}
+
+	match, _ := s.loadActiveMatch(ctx, tableID)
+	if match == nil {
+		return 0, nil
+	}
+	return match.ID, nil
 }
 
-func NewService(db *gorm.DB) *Service {
-	return &Service{db: db}
+// MuteUser applies an admin chat mute on a live table; zero or negative
+// duration lifts an existing mute. Gameplay actions are unaffected.
+func (s *Service) MuteUser(ctx context.Context, tableID, userID int64, duration time.Duration) error {
+	rt, err := s.GetRuntime(ctx, tableID)
+	if err != nil {
+		return err
+	}
+	rt.Mute(userID, duration)
+	return nil
 }
 
 // FinalizeMatch is a helper to settle by matchID and update DB/table status.
@@ -30,7 +289,23 @@ func (s *Service) FinalizeMatch(ctx context.Context, matchID int64, results Sett
 }
 
 func (s *Service) handleRuntimeFinish(rt *TableRuntime) {
-	ctx := context.Background()
+	// Settlement derives from the lifecycle context for correlation but
+	// strips cancellation: once a hand has ended, its writes must flush
+	// even while the service is shutting down.
+	ctx := context.WithoutCancel(s.lifecycleContext())
+
+	// Lifetime-limit closes decided at settle time finish here regardless of
+	// whether an active match row remains (later hands settle locally only).
+	defer s.finalizeExpiredTable(ctx, rt)
+
+	// A runtime that settles without opening a next match has nothing left
+	// to do; reclaim it after the grace window unless a hand started anyway.
+	nextStarted := false
+	defer func() {
+		if rt.expiredReason == "" && !nextStarted {
+			s.scheduleFinishEviction(rt)
+		}
+	}()
 
 	match, err := s.loadActiveMatch(ctx, rt.tableID)
 	if err != nil || match == nil {
@@ -55,19 +330,97 @@ func (s *Service) handleRuntimeFinish(rt *TableRuntime) {
 		}
 	}
 
+	if len(results) == 0 {
+		// A dissolved table has nobody left to settle; just close the
+		// match row so it cannot be loaded as active again.
+		_ = s.db.WithContext(ctx).Model(&model.Match{}).
+			Where("id = ?", match.ID).
+			Update("ended_at", time.Now()).Error
+		return
+	}
+
 	req := SettlementRequest{
-		MatchID: match.ID,
-		SceneID: match.SceneID,
-		Results: results,
+		MatchID:  match.ID,
+		SceneID:  match.SceneID,
+		Results:  results,
+		LogChain: rt.SettlementLogChain,
 	}
 	if err := s.SettleMatch(ctx, req); err != nil {
 		return
 	}
+	// Behavior aggregation replays round logs; run it off the finish path so
+	// the table-ended broadcast is never delayed.
+	go s.recordMatchBehavior(ctx, match.ID, rt.seatUsersSnapshot())
 	// Update table streak for next match
 	_ = s.db.WithContext(ctx).
 		Model(&model.Table{}).
 		Where("id = ?", rt.tableID).
 		Update("mango_streak", rt.mangoStreak).Error
+
+	// Unless the table's lifetime ended with this settle, keep it playing:
+	// the same players ready up for the next hand instead of re-queueing.
+	if rt.expiredReason == "" {
+		nextStarted = s.startNextMatch(ctx, rt)
+	}
+}
+
+// startNextMatch opens a new match row on a settled table and resets the
+// runtime to waiting for the next hand, reporting whether it did. Tables left
+// without two stacked players stay ended.
+func (s *Service) startNextMatch(ctx context.Context, rt *TableRuntime) bool {
+	if rt.stackedSeatsSnapshot() < 2 {
+		return false
+	}
+	match := model.Match{TableID: rt.tableID, SceneID: rt.sceneID}
+	if err := s.db.WithContext(ctx).Create(&match).Error; err != nil {
+		logger.WithContext(ctx).Warn("failed to open next match",
+			zap.Int64("tableID", rt.tableID),
+			zap.Error(err),
+		)
+		return false
+	}
+	if err := s.db.WithContext(ctx).Model(&model.Table{}).
+		Where("id = ?", rt.tableID).
+		Update("status", "waiting").Error; err != nil {
+		logger.WithContext(ctx).Warn("failed to reopen settled table",
+			zap.Int64("tableID", rt.tableID),
+			zap.Error(err),
+		)
+	}
+	rt.beginNextMatch(match.ID)
+	return true
+}
+
+// finalizeExpiredTable completes a close the runtime decided when the last
+// hand settled past the scene's MaxHands/MaxDurationMinutes: the runtime is
+// already stopping with a table_expired close, so drop it from the live map,
+// mark the table row ended, clear every player's matched markers and re-queue
+// the ones who opted in via their ready action.
+func (s *Service) finalizeExpiredTable(ctx context.Context, rt *TableRuntime) {
+	if rt.expiredReason == "" {
+		return
+	}
+	s.runtimes.Delete(rt.tableID)
+	s.dropTableIndex(rt.tableID)
+	_ = s.db.WithContext(ctx).
+		Model(&model.Table{}).
+		Where("id = ?", rt.tableID).
+		Update("status", "ended").Error
+	if s.matchmaker == nil {
+		return
+	}
+	for _, userID := range rt.playersSnapshot() {
+		s.matchmaker.ClearMatched(ctx, userID)
+	}
+	for _, cand := range rt.expiredRequeue {
+		if err := s.matchmaker.Requeue(ctx, cand.UserID, rt.sceneID, cand.BuyIn); err != nil {
+			logger.WithContext(ctx).Warn("expired table re-queue failed",
+				zap.Int64("tableID", rt.tableID),
+				zap.Int64("userID", cand.UserID),
+				zap.Error(err),
+			)
+		}
+	}
 }
 
 func (s *Service) loadActiveMatch(ctx context.Context, tableID int64) (*model.Match, error) {
@@ -10,8 +10,17 @@ import (
 	"time"
 
 	"dx-service/internal/model"
+	"dx-service/internal/service/delivery"
+	"dx-service/internal/service/notify"
+	scenesvc "dx-service/internal/service/scene"
+	"dx-service/internal/service/wallet"
+	"dx-service/internal/tracing"
 	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -21,6 +30,9 @@ type SettlementRequest struct {
 	MatchID int64
 	SceneID int64
 	Results []PlayerResult
+	// LogChain is the final round-log HMAC of the hand; sealed into
+	// Match.ResultJSON so later log edits are detectable.
+	LogChain string
 }
 
 type PlayerResult struct {
@@ -36,6 +48,26 @@ type playerResultRecord struct {
 	Meta      map[string]interface{} `json:"meta,omitempty"`
 }
 
+// resultEnvelope is the settled shape of Match.ResultJSON: the per-player
+// records plus the finalized round-log HMAC chain. Rows written before
+// chaining hold the bare record array; parseResultRecords reads both.
+type resultEnvelope struct {
+	Results  []playerResultRecord `json:"results"`
+	LogChain string               `json:"logChain,omitempty"`
+}
+
+func parseResultRecords(raw []byte) []playerResultRecord {
+	var env resultEnvelope
+	if err := json.Unmarshal(raw, &env); err == nil && env.Results != nil {
+		return env.Results
+	}
+	var records []playerResultRecord
+	if json.Unmarshal(raw, &records) == nil {
+		return records
+	}
+	return nil
+}
+
 type agentShareRecord struct {
 	AgentID int64 `json:"agentId"`
 	Level   int   `json:"level"`
@@ -46,9 +78,21 @@ type rakeSummary struct {
 	Total    int64              `json:"total"`
 	Platform int64              `json:"platform"`
 	Agents   []agentShareRecord `json:"agents"`
+	// RakeFree marks a happy-hour match: no rake was charged, and Window
+	// records the schedule entry that applied at settlement time.
+	RakeFree bool                 `json:"rakeFree,omitempty"`
+	Window   *scenesvc.OpenWindow `json:"rakeFreeWindow,omitempty"`
 }
 
 func (s *Service) SettleMatch(ctx context.Context, req SettlementRequest) error {
+	ctx, span := tracing.Tracer().Start(ctx, "game.SettleMatch",
+		trace.WithAttributes(
+			attribute.Int64("match.id", req.MatchID),
+			attribute.Int64("scene.id", req.SceneID),
+		),
+	)
+	defer span.End()
+
 	if req.MatchID == 0 || len(req.Results) == 0 {
 		return appErr.ErrSettlementValidation
 	}
@@ -64,185 +108,367 @@ func (s *Service) SettleMatch(ctx context.Context, req SettlementRequest) error
 		return fmt.Errorf("%w: net points must sum to zero", appErr.ErrSettlementValidation)
 	}
 
+	// A panic stop defers the whole settlement through the dead-letter queue
+	// instead of applying it; the payload replays through ReplaySettlement
+	// when the operator resumes, and the already-settled guard below keeps a
+	// double replay harmless.
+	if s.panic != nil && s.deadletter != nil && s.panic.Active(ctx, req.SceneID) {
+		logger.WithContext(ctx).Warn("settlement deferred by panic stop",
+			zap.Int64("matchID", req.MatchID),
+			zap.Int64("sceneID", req.SceneID),
+		)
+		return s.deadletter.Record(ctx, delivery.TypeSettlement, req, 0, []string{"panic stop engaged"})
+	}
+
 	now := time.Now()
 
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var match model.Match
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&match, req.MatchID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return appErr.ErrMatchNotFound
-			}
-			return err
-		}
+	// Hold every participant's wallet mutex around the transaction so admin
+	// adjustments and other entry points order consistently against
+	// settlement writes.
+	settleUsers := make([]int64, 0, len(req.Results))
+	for _, r := range req.Results {
+		settleUsers = append(settleUsers, r.UserID)
+	}
 
-		if match.EndedAt != nil {
-			return appErr.ErrMatchAlreadySettled
-		}
-		if req.SceneID != 0 && match.SceneID != req.SceneID {
-			return fmt.Errorf("scene mismatch: %w", appErr.ErrSceneNotFound)
-		}
+	var releasedCommissions []model.AgentProfitLog
+	err := wallet.WithUserLocks(ctx, s.rdb, settleUsers, func(ctx context.Context) error {
+		return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var match model.Match
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&match, req.MatchID).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return appErr.ErrMatchNotFound
+				}
+				return err
+			}
 
-		var scene model.Scene
-		if err := tx.First(&scene, match.SceneID).Error; err != nil {
-			return err
-		}
+			if match.EndedAt != nil {
+				return appErr.ErrMatchAlreadySettled
+			}
+			if req.SceneID != 0 && match.SceneID != req.SceneID {
+				return fmt.Errorf("scene mismatch: %w", appErr.ErrSceneNotFound)
+			}
 
-		var rakeRule *model.RakeRule
-		if scene.RakeRuleID != 0 {
-			var rule model.RakeRule
-			if err := tx.First(&rule, scene.RakeRuleID).Error; err != nil {
+			var scene model.Scene
+			if err := tx.First(&scene, match.SceneID).Error; err != nil {
 				return err
 			}
-			rakeRule = &rule
-		}
-
-		agentRule, err := s.loadAgentRule(tx)
-		if err != nil {
-			return err
-		}
 
-		wallets := newWalletBook(tx)
-		billingLogs := make([]model.BillingLog, 0, len(req.Results)*3)
-		agentLogs := make([]model.AgentProfitLog, 0)
-		resultRecords := make([]playerResultRecord, 0, len(req.Results))
-		agentShareRecords := make([]agentShareRecord, 0)
+			// Trial scenes settle in play money: no rake is charged, no
+			// agent shares are distributed, and wallet movement stays on the
+			// trial balance so real points and revenue are untouched.
+			trial := scene.TrialMode
+
+			// A rake-free happy-hour window covering the match's end waives
+			// the rake the same way: with nothing raked there is nothing to
+			// share, so agent and platform income are naturally zero.
+			var rakeFreeWindow *scenesvc.OpenWindow
+			if !trial {
+				rakeFreeWindow, _ = scenesvc.RakeFreeState(scene, now)
+			}
 
-		var totalRake int64
-		var platformIncome int64
+			var rakeRule *model.RakeRule
+			if !trial && rakeFreeWindow == nil && scene.RakeRuleID != 0 {
+				var rule model.RakeRule
+				if err := tx.First(&rule, scene.RakeRuleID).Error; err != nil {
+					return err
+				}
+				rakeRule = &rule
+			}
 
-		for _, res := range req.Results {
-			wallet, err := wallets.Ensure(res.UserID)
-			if err != nil {
-				return err
+			var agentRule *model.AgentRule
+			if !trial {
+				rule, err := s.loadAgentRule(tx)
+				if err != nil {
+					return err
+				}
+				agentRule = rule
 			}
 
-			if res.NetPoints > 0 {
-				rake := calculateRake(rakeRule, res.NetPoints)
-				totalRake += rake
-				netWin := res.NetPoints - rake
+			wallets := newWalletBook(tx)
+			billingLogs := make([]model.BillingLog, 0, len(req.Results)*3)
+			agentLogs := make([]model.AgentProfitLog, 0)
+			resultRecords := make([]playerResultRecord, 0, len(req.Results))
+			agentShareRecords := make([]agentShareRecord, 0)
 
-				wallet.BalanceAvailable += netWin
-				wallet.BalanceTotal += netWin
-				wallet.TotalWin += netWin
-				wallet.TotalRake += rake
+			var totalRake int64
+			var platformIncome int64
+			var totalPot int64
 
-				winMeta := map[string]interface{}{
-					"matchId": match.ID,
-					"sceneId": scene.ID,
-					"rawWin":  res.NetPoints,
+			for _, res := range req.Results {
+				wallet, err := wallets.Ensure(res.UserID)
+				if err != nil {
+					return err
 				}
-				billingLogs = append(billingLogs, model.BillingLog{
-					UserID:       res.UserID,
-					Type:         "win",
-					Delta:        netWin,
-					BalanceAfter: wallet.BalanceAvailable,
-					MatchID:      &match.ID,
-					MetaJSON:     mustJSON(winMeta),
-					CreatedAt:    now,
-				})
-				if rake > 0 {
+
+				if res.NetPoints > 0 {
+					totalPot += res.NetPoints
+					rake := calculateRake(rakeRule, res.NetPoints)
+					totalRake += rake
+					netWin := res.NetPoints - rake
+
+					balanceAfter := int64(0)
+					if trial {
+						wallet.TrialBalance += netWin
+						balanceAfter = wallet.TrialBalance
+					} else {
+						wallet.BalanceAvailable += netWin
+						wallet.BalanceTotal += netWin
+						wallet.TotalWin += netWin
+						wallet.TotalRake += rake
+						balanceAfter = wallet.BalanceAvailable
+					}
+
+					winMeta := map[string]interface{}{
+						"matchId": match.ID,
+						"sceneId": scene.ID,
+						"rawWin":  res.NetPoints,
+					}
+					if trial {
+						// The marker keeps play-money rows out of revenue
+						// reports and reconciliation.
+						winMeta["trial"] = true
+					}
 					billingLogs = append(billingLogs, model.BillingLog{
 						UserID:       res.UserID,
-						Type:         "rake",
-						Delta:        -rake,
-						BalanceAfter: wallet.BalanceAvailable,
+						Type:         "win",
+						Delta:        netWin,
+						BalanceAfter: balanceAfter,
 						MatchID:      &match.ID,
 						MetaJSON:     mustJSON(winMeta),
 						CreatedAt:    now,
 					})
-
-					shareResult, shareLogs, profitLogs, platformShare, err := s.distributeAgentShare(tx, wallets, res.UserID, rake, agentRule, match, scene, now)
-					if err != nil {
-						return err
-					}
-					agentShareRecords = append(agentShareRecords, shareResult...)
-					billingLogs = append(billingLogs, shareLogs...)
-					agentLogs = append(agentLogs, profitLogs...)
-					if platformShare > 0 {
-						platformIncome += platformShare
+					if rake > 0 {
 						billingLogs = append(billingLogs, model.BillingLog{
-							UserID:       0,
-							Type:         "platform_income",
-							Delta:        platformShare,
-							BalanceAfter: 0,
+							UserID:       res.UserID,
+							Type:         "rake",
+							Delta:        -rake,
+							BalanceAfter: wallet.BalanceAvailable,
 							MatchID:      &match.ID,
 							MetaJSON:     mustJSON(winMeta),
 							CreatedAt:    now,
 						})
+
+						shareResult, shareLogs, profitLogs, platformShare, err := s.distributeAgentShare(tx, wallets, res.UserID, rake, agentRule, match, scene, now)
+						if err != nil {
+							return err
+						}
+						agentShareRecords = append(agentShareRecords, shareResult...)
+						billingLogs = append(billingLogs, shareLogs...)
+						agentLogs = append(agentLogs, profitLogs...)
+						if platformShare > 0 {
+							platformIncome += platformShare
+							billingLogs = append(billingLogs, model.BillingLog{
+								UserID:       0,
+								Type:         "platform_income",
+								Delta:        platformShare,
+								BalanceAfter: 0,
+								MatchID:      &match.ID,
+								MetaJSON:     mustJSON(winMeta),
+								CreatedAt:    now,
+							})
+						}
+					}
+
+					resultRecords = append(resultRecords, playerResultRecord{
+						UserID:    res.UserID,
+						NetPoints: netWin,
+						Rake:      rake,
+						Meta:      res.Meta,
+					})
+				} else {
+					loss := res.NetPoints
+					balanceAfter := int64(0)
+					if trial {
+						wallet.TrialBalance += loss
+						balanceAfter = wallet.TrialBalance
+					} else {
+						wallet.BalanceAvailable += loss
+						wallet.BalanceTotal += loss
+						wallet.TotalConsume += -loss
+						balanceAfter = wallet.BalanceAvailable
+					}
+
+					lossMeta := map[string]interface{}{
+						"matchId": match.ID,
+						"sceneId": scene.ID,
+					}
+					if trial {
+						lossMeta["trial"] = true
 					}
+					billingLogs = append(billingLogs, model.BillingLog{
+						UserID:       res.UserID,
+						Type:         "lose",
+						Delta:        loss,
+						BalanceAfter: balanceAfter,
+						MatchID:      &match.ID,
+						MetaJSON:     mustJSON(lossMeta),
+						CreatedAt:    now,
+					})
+
+					resultRecords = append(resultRecords, playerResultRecord{
+						UserID:    res.UserID,
+						NetPoints: loss,
+						Rake:      0,
+						Meta:      res.Meta,
+					})
 				}
+			}
 
-				resultRecords = append(resultRecords, playerResultRecord{
-					UserID:    res.UserID,
-					NetPoints: netWin,
-					Rake:      rake,
-					Meta:      res.Meta,
-				})
-			} else {
-				loss := res.NetPoints
-				wallet.BalanceAvailable += loss
-				wallet.BalanceTotal += loss
-				wallet.TotalConsume += -loss
-
-				lossMeta := map[string]interface{}{
-					"matchId": match.ID,
-					"sceneId": scene.ID,
+			// Release wallet funds frozen by mid-session top-ups now the match
+			// is settled; the chip movement itself is already in NetPoints.
+			var topUps []model.BillingLog
+			if err := tx.Where("match_id = ? AND type IN ?",
+				match.ID, []string{"buyin_topup", "topup_release", "cashout"}).
+				Find(&topUps).Error; err != nil {
+				return err
+			}
+			frozenByUser := make(map[int64]int64)
+			for _, log := range topUps {
+				if log.Type == "buyin_topup" {
+					frozenByUser[log.UserID] += -log.Delta
+				} else {
+					frozenByUser[log.UserID] -= log.Delta
+				}
+			}
+			for userID, amount := range frozenByUser {
+				if amount <= 0 {
+					continue
 				}
+				wallet, err := wallets.Ensure(userID)
+				if err != nil {
+					return err
+				}
+				wallet.BalanceFrozen -= amount
+				wallet.BalanceAvailable += amount
 				billingLogs = append(billingLogs, model.BillingLog{
-					UserID:       res.UserID,
-					Type:         "lose",
-					Delta:        loss,
+					UserID:       userID,
+					Type:         "topup_release",
+					Delta:        amount,
 					BalanceAfter: wallet.BalanceAvailable,
 					MatchID:      &match.ID,
-					MetaJSON:     mustJSON(lossMeta),
-					CreatedAt:    now,
+					MetaJSON: mustJSON(map[string]interface{}{
+						"matchId": match.ID,
+						"sceneId": scene.ID,
+					}),
+					CreatedAt: now,
 				})
+			}
 
-				resultRecords = append(resultRecords, playerResultRecord{
-					UserID:    res.UserID,
-					NetPoints: loss,
-					Rake:      0,
-					Meta:      res.Meta,
-				})
+			if err := wallets.SaveAll(now); err != nil {
+				return err
 			}
-		}
 
-		if err := wallets.SaveAll(now); err != nil {
-			return err
-		}
+			if len(billingLogs) > 0 {
+				if err := tx.Create(&billingLogs).Error; err != nil {
+					return err
+				}
+			}
 
-		if len(billingLogs) > 0 {
-			if err := tx.Create(&billingLogs).Error; err != nil {
-				return err
+			if len(agentLogs) > 0 {
+				if err := tx.Create(&agentLogs).Error; err != nil {
+					return err
+				}
+				releasedCommissions = agentLogs
 			}
-		}
 
-		if len(agentLogs) > 0 {
-			if err := tx.Create(&agentLogs).Error; err != nil {
+			match.ResultJSON = mustJSON(resultEnvelope{Results: resultRecords, LogChain: req.LogChain})
+			match.RakeJSON = mustJSON(rakeSummary{
+				Total:    totalRake,
+				Platform: platformIncome,
+				Agents:   agentShareRecords,
+				RakeFree: rakeFreeWindow != nil,
+				Window:   rakeFreeWindow,
+			})
+			match.EndedAt = &now
+
+			if err := tx.Save(&match).Error; err != nil {
 				return err
 			}
-		}
 
-		match.ResultJSON = mustJSON(resultRecords)
-		match.RakeJSON = mustJSON(rakeSummary{
-			Total:    totalRake,
-			Platform: platformIncome,
-			Agents:   agentShareRecords,
-		})
-		match.EndedAt = &now
+			// Play-money matches never feed the revenue tables.
+			if trial {
+				return tx.Model(&model.Table{}).
+					Where("id = ?", match.TableID).
+					Update("status", "ended").Error
+			}
 
-		if err := tx.Save(&match).Error; err != nil {
-			return err
-		}
+			var agentTotal int64
+			for _, share := range agentShareRecords {
+				agentTotal += share.Amount
+			}
+			if err := tx.Create(&model.MatchRake{
+				MatchID:    match.ID,
+				SceneID:    scene.ID,
+				RuleID:     scene.RakeRuleID,
+				TotalRake:  totalRake,
+				Platform:   platformIncome,
+				AgentTotal: agentTotal,
+				TotalPot:   totalPot,
+				CreatedAt:  now,
+			}).Error; err != nil {
+				return err
+			}
 
-		if err := tx.Model(&model.Table{}).
-			Where("id = ?", match.TableID).
-			Update("status", "ended").Error; err != nil {
-			return err
-		}
+			if err := tx.Model(&model.Table{}).
+				Where("id = ?", match.TableID).
+				Update("status", "ended").Error; err != nil {
+				return err
+			}
 
-		return nil
+			return nil
+		})
 	})
+	if err != nil {
+		return err
+	}
+
+	// Commission notifications go out after the commit so a rollback never
+	// leaves an inbox entry for money that was not released.
+	if s.notify != nil {
+		for _, commission := range releasedCommissions {
+			if notifyErr := s.notify.Notify(ctx, commission.AgentID, "commission_released", map[string]interface{}{
+				"matchId": commission.MatchID,
+				"amount":  commission.ProfitAmount,
+			}); notifyErr != nil {
+				logger.WithContext(ctx).Warn("commission notification failed",
+					zap.Int64("agentID", commission.AgentID),
+					zap.Error(notifyErr),
+				)
+				// The push is single-shot, so a failure here is final: park
+				// the payload in the dead-letter queue for an admin replay.
+				if s.deadletter != nil {
+					if dlErr := s.deadletter.Record(ctx, delivery.TypeNotification, notify.PushPayload{
+						UserID: commission.AgentID,
+						Type:   "commission_released",
+						Payload: map[string]interface{}{
+							"matchId": commission.MatchID,
+							"amount":  commission.ProfitAmount,
+						},
+					}, 1, []string{notifyErr.Error()}); dlErr != nil {
+						logger.WithContext(ctx).Warn("dead-letter record failed",
+							zap.Int64("agentID", commission.AgentID),
+							zap.Error(dlErr),
+						)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ReplaySettlement is the dead-letter replay path for settlements deferred
+// by a panic stop. A replay that finds its match already settled counts as
+// success, so draining the queue twice applies each settlement exactly once.
+func (s *Service) ReplaySettlement(ctx context.Context, payload json.RawMessage) error {
+	var req SettlementRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return err
+	}
+	if err := s.SettleMatch(ctx, req); err != nil && err != appErr.ErrMatchAlreadySettled {
+		return err
+	}
+	return nil
 }
 
 func (s *Service) loadAgentRule(tx *gorm.DB) (*model.AgentRule, error) {
@@ -0,0 +1,133 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// sidePotRuntime seats three players mid-settlement: seat 1 is all-in for 30,
+// seats 2 and 3 are deep and contributed 100 each. Chips already moved into
+// the pot at action time.
+func sidePotRuntime() *TableRuntime {
+	return &TableRuntime{
+		phase:         PhaseSettling,
+		handNo:        1,
+		bankerSeat:    1,
+		pot:           230,
+		seatByUser:    map[int64]int{101: 1, 102: 2, 103: 3},
+		topUpDeadline: make(map[int]time.Time),
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 0, TotalContribution: 30, Status: "playing", cards: []string{"9s", "9d"}},
+			{SeatIndex: 2, UserID: 102, Chips: 900, TotalContribution: 100, Status: "playing", cards: []string{"8s", "8d"}},
+			{SeatIndex: 3, UserID: 103, Chips: 900, TotalContribution: 100, Status: "playing", cards: []string{"2s", "3d"}},
+		},
+	}
+}
+
+func resultsByUser(results []PlayerResult) map[int64]PlayerResult {
+	byUser := make(map[int64]PlayerResult, len(results))
+	for _, res := range results {
+		byUser[res.UserID] = res
+	}
+	return byUser
+}
+
+// The all-in best hand only wins the 30 it matched from each opponent; the
+// unmatched layer falls through to the second-best hand.
+func TestShowdownSidePotCapsAllInWinner(t *testing.T) {
+	rt := sidePotRuntime()
+	rt.determineWinnersAndSettleLocked()
+
+	byUser := resultsByUser(rt.SettlementResults)
+	if got := byUser[101].NetPoints; got != 60 {
+		t.Fatalf("all-in winner net %d, want 60 (30 matched from each)", got)
+	}
+	if byUser[101].Meta["winType"] != "showdown" {
+		t.Fatalf("best hand missing winType: %+v", byUser[101].Meta)
+	}
+	if got := byUser[102].NetPoints; got != 40 {
+		t.Fatalf("side-pot winner net %d, want 40 (-30 to best, +70 from worst)", got)
+	}
+	if got := byUser[103].NetPoints; got != -100 {
+		t.Fatalf("losing deep stack net %d, want -100", got)
+	}
+
+	var sum int64
+	for _, res := range rt.SettlementResults {
+		sum += res.NetPoints
+	}
+	if sum != 0 {
+		t.Fatalf("net points sum to %d, want 0: %+v", sum, rt.SettlementResults)
+	}
+
+	// Chip updates must mirror the nets: stack = contribution + net returned.
+	if rt.seats[0].Chips != 90 {
+		t.Fatalf("all-in winner stack %d, want 90", rt.seats[0].Chips)
+	}
+	if rt.seats[1].Chips != 1040 {
+		t.Fatalf("side-pot winner stack %d, want 1040", rt.seats[1].Chips)
+	}
+	if rt.seats[2].Chips != 900 {
+		t.Fatalf("losing stack %d, want 900 (full loss)", rt.seats[2].Chips)
+	}
+}
+
+// Seat indices survive departures sparse (1,3,5 after the middle seats leave),
+// so chip updates must resolve seats by index, not by slice position.
+func TestApplyChipUpdatesSparseSeatIndices(t *testing.T) {
+	rt := &TableRuntime{
+		seatByUser: map[int64]int{101: 1, 103: 3, 105: 5},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 0, TotalContribution: 50},
+			{SeatIndex: 3, UserID: 103, Chips: 0, TotalContribution: 50},
+			{SeatIndex: 5, UserID: 105, Chips: 0, TotalContribution: 50},
+		},
+	}
+	rt.applyChipUpdatesLocked([]PlayerResult{
+		{UserID: 101, NetPoints: 100},
+		{UserID: 103, NetPoints: -50},
+		{UserID: 105, NetPoints: -50},
+	})
+
+	if rt.seats[0].Chips != 150 {
+		t.Fatalf("seat 1 stack %d, want 150", rt.seats[0].Chips)
+	}
+	if rt.seats[1].Chips != 0 {
+		t.Fatalf("seat 3 stack %d, want 0", rt.seats[1].Chips)
+	}
+	if rt.seats[2].Chips != 0 {
+		t.Fatalf("seat 5 stack %d, want 0", rt.seats[2].Chips)
+	}
+}
+
+// A fold win is capped the same way: the all-in winner collects only what it
+// matched of the folder's contribution, the excess returns to the folder.
+func TestFoldWinCappedAtWinnerContribution(t *testing.T) {
+	rt := &TableRuntime{
+		phase:         PhaseSettling,
+		handNo:        1,
+		bankerSeat:    1,
+		pot:           130,
+		seatByUser:    map[int64]int{101: 1, 102: 2},
+		topUpDeadline: make(map[int]time.Time),
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 0, TotalContribution: 30, Status: "playing", cards: []string{"9s", "9d"}},
+			{SeatIndex: 2, UserID: 102, Chips: 900, TotalContribution: 100, Status: "folded"},
+		},
+	}
+	rt.determineWinnersAndSettleLocked()
+
+	byUser := resultsByUser(rt.SettlementResults)
+	if got := byUser[101].NetPoints; got != 30 {
+		t.Fatalf("fold winner net %d, want 30 (capped at own contribution)", got)
+	}
+	if got := byUser[102].NetPoints; got != -30 {
+		t.Fatalf("folder net %d, want -30", got)
+	}
+	if rt.seats[0].Chips != 60 {
+		t.Fatalf("winner stack %d, want 60", rt.seats[0].Chips)
+	}
+	if rt.seats[1].Chips != 970 {
+		t.Fatalf("folder stack %d, want 970 (unmatched 70 returned)", rt.seats[1].Chips)
+	}
+}
@@ -0,0 +1,141 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	mrand "math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"dx-service/internal/model"
+)
+
+// SimulatedPlayer seeds one seat of a simulated hand; seats are assigned in
+// slice order starting at 1.
+type SimulatedPlayer struct {
+	UserID int64
+	Chips  int64
+}
+
+// SimulatedAction is one scripted turn action for a seat. Amount is only used
+// by raise.
+type SimulatedAction struct {
+	Action string
+	Amount int64
+}
+
+// SimulationConfig drives one deterministic hand through the real runtime
+// code: same dealing, betting, knock and settlement paths as production, but
+// with the clock frozen and the RNG seeded.
+type SimulationConfig struct {
+	Scene   model.Scene
+	Players []SimulatedPlayer
+	Seed    int64
+	Deck    []string                  // optional fixed deck, overrides the seeded shuffle
+	Scripts map[int][]SimulatedAction // seatIdx -> actions consumed in turn order
+}
+
+// SimulationResult is the settled ledger of a simulated hand plus the final
+// seat states for invariant checks.
+type SimulationResult struct {
+	Results []PlayerResult
+	Seats   []SeatState
+	Pot     int64
+}
+
+// maxSimulationSteps bounds a single hand; scripts that never settle are an
+// error rather than a hang.
+const maxSimulationSteps = 256
+
+// Simulate runs one full hand synchronously. The runtime loop is never
+// started: commands are applied inline, so timers are armed but never fire
+// and the frozen clock means no turn ever expires. A seat whose script is
+// exhausted (or whose scripted action is illegal) folds.
+func Simulate(cfg SimulationConfig) (*SimulationResult, error) {
+	if len(cfg.Players) < 2 {
+		return nil, fmt.Errorf("simulation requires at least two players")
+	}
+
+	playerMap := make(map[string]map[string]interface{}, len(cfg.Players))
+	for i, p := range cfg.Players {
+		playerMap[strconv.Itoa(i+1)] = map[string]interface{}{
+			"userId": p.UserID,
+			"chips":  p.Chips,
+		}
+	}
+	playerBytes, err := json.Marshal(playerMap)
+	if err != nil {
+		return nil, err
+	}
+	table := model.Table{
+		ID:          1,
+		SceneID:     cfg.Scene.ID,
+		SeatCount:   len(cfg.Players),
+		PlayersJSON: playerBytes,
+	}
+
+	rt, err := buildTableRuntime(nil, table, cfg.Scene, 0, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	rt.rng = mrand.New(mrand.NewSource(cfg.Seed))
+	frozen := time.Unix(1700000000, 0)
+	rt.now = func() time.Time { return frozen }
+	if len(cfg.Deck) > 0 {
+		rt.fixedDeck = cfg.Deck
+	}
+	defer rt.cancelTimerLocked()
+
+	for _, p := range cfg.Players {
+		if err := rt.handleActionLocked(p.UserID, "ready", nil); err != nil {
+			return nil, fmt.Errorf("ready user %d: %w", p.UserID, err)
+		}
+	}
+	if rt.phase != PhasePlaying {
+		return nil, fmt.Errorf("hand did not start, phase %s", rt.phase)
+	}
+
+	scripts := make(map[int][]SimulatedAction, len(cfg.Scripts))
+	for seatIdx, actions := range cfg.Scripts {
+		scripts[seatIdx] = append([]SimulatedAction(nil), actions...)
+	}
+
+	for steps := 0; rt.phase == PhasePlaying; steps++ {
+		if steps >= maxSimulationSteps {
+			return nil, fmt.Errorf("hand did not settle within %d steps", maxSimulationSteps)
+		}
+		seatIdx := rt.turnSeat
+		seat := rt.findSeatLocked(seatIdx)
+		if seat == nil {
+			return nil, fmt.Errorf("turn on unknown seat %d", seatIdx)
+		}
+
+		act := SimulatedAction{Action: "fold"}
+		if queue := scripts[seatIdx]; len(queue) > 0 {
+			act = queue[0]
+			scripts[seatIdx] = queue[1:]
+		}
+		var data json.RawMessage
+		if act.Amount > 0 {
+			data, _ = json.Marshal(map[string]int64{"amount": act.Amount})
+		}
+		if err := rt.handleActionLocked(seat.UserID, act.Action, data); err != nil {
+			// An illegal scripted action degrades to the always-legal fold.
+			if foldErr := rt.handleActionLocked(seat.UserID, "fold", nil); foldErr != nil {
+				return nil, fmt.Errorf("seat %d stuck: %v then %v", seatIdx, err, foldErr)
+			}
+		}
+	}
+
+	seats := make([]SeatState, len(rt.seats))
+	copy(seats, rt.seats)
+	// The ledger is built from a map; sort so equal seeds compare equal.
+	results := append([]PlayerResult(nil), rt.SettlementResults...)
+	sort.Slice(results, func(i, j int) bool { return results[i].UserID < results[j].UserID })
+	return &SimulationResult{
+		Results: results,
+		Seats:   seats,
+		Pot:     rt.pot,
+	}, nil
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"fmt"
+	mrand "math/rand"
+	"testing"
+
+	"dx-service/internal/model"
+)
+
+func randomSimulationConfig(seed int64) SimulationConfig {
+	rng := mrand.New(mrand.NewSource(seed))
+
+	seatCount := 3 + rng.Intn(2)
+	players := make([]SimulatedPlayer, 0, seatCount)
+	for i := 0; i < seatCount; i++ {
+		players = append(players, SimulatedPlayer{
+			UserID: int64(100 + i),
+			Chips:  500 + int64(rng.Intn(1000)),
+		})
+	}
+
+	scripts := make(map[int][]SimulatedAction, seatCount)
+	for seatIdx := 1; seatIdx <= seatCount; seatIdx++ {
+		actions := make([]SimulatedAction, 0, 8)
+		for i := 0; i < 8; i++ {
+			switch roll := rng.Intn(100); {
+			case roll < 40:
+				actions = append(actions, SimulatedAction{Action: "call"})
+			case roll < 65:
+				actions = append(actions, SimulatedAction{Action: "pass"})
+			case roll < 90:
+				actions = append(actions, SimulatedAction{Action: "raise", Amount: int64(2 + rng.Intn(60))})
+			default:
+				actions = append(actions, SimulatedAction{Action: "fold"})
+			}
+		}
+		scripts[seatIdx] = actions
+	}
+
+	return SimulationConfig{
+		Scene: model.Scene{
+			ID:           1,
+			Name:         "chexuan-sim",
+			SeatCount:    seatCount,
+			BasePi:       10,
+			MinUnitPi:    1,
+			MangoEnabled: seed%2 == 0,
+			BoboEnabled:  seed%3 == 0,
+		},
+		Players: players,
+		Seed:    seed,
+		Scripts: scripts,
+	}
+}
+
+func assertLedgerInvariants(t *testing.T, seed int64, cfg SimulationConfig, result *SimulationResult) {
+	t.Helper()
+
+	seatByUser := make(map[int64]SeatState, len(result.Seats))
+	for _, seat := range result.Seats {
+		seatByUser[seat.UserID] = seat
+	}
+
+	var sum int64
+	for _, r := range result.Results {
+		sum += r.NetPoints
+
+		seat, ok := seatByUser[r.UserID]
+		if !ok {
+			t.Fatalf("seed %d: result for unknown user %d", seed, r.UserID)
+		}
+		// The chexuan ledger settles pairwise: a seat stakes its hand bet
+		// against every settling opponent, so its exposure is bet * opponents.
+		exposure := seat.Bet * int64(len(result.Results)-1)
+		if r.NetPoints < 0 && -r.NetPoints > exposure {
+			t.Fatalf("seed %d: user %d loses %d beyond exposure %d (bet %d)",
+				seed, r.UserID, -r.NetPoints, exposure, seat.Bet)
+		}
+		if r.NetPoints > 0 && seat.Status == "folded" {
+			t.Fatalf("seed %d: folded user %d won %d", seed, r.UserID, r.NetPoints)
+		}
+	}
+	if sum != 0 {
+		t.Fatalf("seed %d: net points sum to %d, ledger %+v", seed, sum, result.Results)
+	}
+}
+
+func TestSimulationLedgerInvariants(t *testing.T) {
+	const seeds = 2000
+	for seed := int64(0); seed < seeds; seed++ {
+		cfg := randomSimulationConfig(seed)
+		result, err := Simulate(cfg)
+		if err != nil {
+			t.Fatalf("seed %d: simulation failed: %v", seed, err)
+		}
+		assertLedgerInvariants(t, seed, cfg, result)
+	}
+}
+
+func TestSimulationDeterministicWithFixedSeed(t *testing.T) {
+	cfg := randomSimulationConfig(42)
+
+	first, err := Simulate(cfg)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	second, err := Simulate(cfg)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+
+	if fmt.Sprintf("%+v", first.Results) != fmt.Sprintf("%+v", second.Results) {
+		t.Fatalf("expected identical ledgers for the same seed:\n%+v\n%+v", first.Results, second.Results)
+	}
+}
@@ -0,0 +1,185 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// strikeRuntime seats three players mid-hand with the turn on seat 1.
+func strikeRuntime() *TableRuntime {
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	return &TableRuntime{
+		phase:       PhasePlaying,
+		handNo:      1,
+		round:       1,
+		turnSeat:    1,
+		lastRaise:   20,
+		strikeLimit: 2,
+		seatByUser:  map[int64]int{101: 1, 102: 2, 103: 3},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 500, Bet: 0, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 500, Bet: 20, Status: "playing"},
+			{SeatIndex: 3, UserID: 103, Chips: 500, Bet: 20, Status: "playing"},
+		},
+	}
+}
+
+func TestFirstTimeoutDoesNotBench(t *testing.T) {
+	rt := strikeRuntime()
+	rt.handleTurnTimeoutLocked()
+
+	if rt.seats[0].Status != "folded" {
+		t.Fatalf("seat 1 should auto-fold facing a raise, got %s", rt.seats[0].Status)
+	}
+	if rt.sittingOut[1] {
+		t.Fatal("one timeout must not bench the seat")
+	}
+	if rt.timeoutStrikes[1] != 1 {
+		t.Fatalf("expected 1 strike recorded, got %d", rt.timeoutStrikes[1])
+	}
+}
+
+func TestTimeoutStrikesBenchSeat(t *testing.T) {
+	rt := strikeRuntime()
+	rt.timeoutStrikes = map[int]int{1: 1}
+	rt.handleTurnTimeoutLocked()
+
+	if rt.seats[0].Status != "folded" {
+		t.Fatalf("struck seat should fold, got %s", rt.seats[0].Status)
+	}
+	if !rt.sittingOut[1] {
+		t.Fatal("expected seat 1 benched after reaching the strike limit")
+	}
+	if rt.turnSeat == 1 {
+		t.Fatal("turn should have moved off the benched seat")
+	}
+	var logged bool
+	for _, item := range rt.logs {
+		if strings.Contains(item.Content, "连续超时") {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Fatal("expected a system log explaining the sit-out")
+	}
+}
+
+func setupSitOutRuntime(t *testing.T) *TableRuntime {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}, &model.Match{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	scene := model.Scene{ID: 1, Name: "test", SeatCount: 3, BasePi: 10, MinUnitPi: 1}
+	table := model.Table{
+		ID:          1,
+		SceneID:     scene.ID,
+		SeatCount:   3,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000},"3":{"userId":103,"chips":1000}}`),
+	}
+
+	rt, err := newTableRuntime(context.Background(), db, table, scene, 0, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(rt.stop)
+	return rt
+}
+
+func TestSittingOutSeatSkippedByReadyCheck(t *testing.T) {
+	rt := setupSitOutRuntime(t)
+	rt.sittingOut = map[int]bool{3: true}
+
+	if err := rt.HandleAction(101, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if err := rt.HandleAction(102, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+
+	if rt.phase != PhasePlaying {
+		t.Fatalf("hand should start without seat 3's ready, phase %s", rt.phase)
+	}
+	seat := rt.findSeatLocked(3)
+	if seat.Status != "resting" {
+		t.Fatalf("benched seat should rest, got %s", seat.Status)
+	}
+	if seat.TotalContribution != 0 {
+		t.Fatalf("resting seat must not ante, contributed %d", seat.TotalContribution)
+	}
+}
+
+func TestDisconnectGraceBenchesAbsentPlayer(t *testing.T) {
+	rt := setupSitOutRuntime(t)
+
+	ch := rt.Subscribe(103)
+	drainSubscriber(ch)
+	for _, uid := range []int64{101, 102, 103} {
+		if err := rt.HandleAction(uid, "ready", nil); err != nil {
+			t.Fatalf("ready failed: %v", err)
+		}
+	}
+	if rt.phase != PhasePlaying {
+		t.Fatalf("expected playing phase, got %s", rt.phase)
+	}
+
+	rt.Unsubscribe(103)
+	rt.now = func() time.Time { return time.Now().Add(disconnectGraceSeconds*time.Second + time.Second) }
+	rt.cmdCh <- loopCommand{kind: "disconnect_check", userID: 103}
+	// A synchronous action flushes the async check before we inspect state.
+	if err := rt.HandleAction(101, "ping", nil); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+
+	if !rt.sittingOut[3] {
+		t.Fatal("expected the disconnected seat benched after the grace window")
+	}
+	if seat := rt.findSeatLocked(3); seat.Status != "folded" {
+		t.Fatalf("disconnected seat should fold out of the hand, got %s", seat.Status)
+	}
+}
+
+func TestResubscribeCancelsDisconnectGrace(t *testing.T) {
+	rt := setupSitOutRuntime(t)
+
+	drainSubscriber(rt.Subscribe(103))
+	for _, uid := range []int64{101, 102, 103} {
+		if err := rt.HandleAction(uid, "ready", nil); err != nil {
+			t.Fatalf("ready failed: %v", err)
+		}
+	}
+
+	rt.Unsubscribe(103)
+	drainSubscriber(rt.Subscribe(103))
+	rt.now = func() time.Time { return time.Now().Add(disconnectGraceSeconds*time.Second + time.Second) }
+	rt.cmdCh <- loopCommand{kind: "disconnect_check", userID: 103}
+	if err := rt.HandleAction(101, "ping", nil); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+
+	if rt.sittingOut[3] {
+		t.Fatal("resubscribing should cancel the grace window")
+	}
+	if seat := rt.findSeatLocked(3); seat.Status != "playing" {
+		t.Fatalf("seat should keep playing after resubscribe, got %s", seat.Status)
+	}
+}
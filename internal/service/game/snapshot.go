@@ -0,0 +1,310 @@
+package game
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dx-service/internal/model"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	// snapshotInterval is how often a live runtime writes its resumable
+	// state; a crash loses at most one interval of progress.
+	snapshotInterval = 5 * time.Second
+	// snapshotResumeGrace replaces a turn deadline that lapsed while the
+	// process was down, so the actor is not timed out the instant play
+	// resumes.
+	snapshotResumeGrace = 5 * time.Second
+)
+
+// runtimeSnapshot is the resumable mid-hand state persisted to
+// Table.RuntimeSnapshotJSON. Hole cards and the undealt deck are sealed with
+// the same per-user AES-GCM scheme the round logs use, so a database dump
+// never exposes a live hand in the clear.
+type runtimeSnapshot struct {
+	Phase          Phase          `json:"phase"`
+	Round          int            `json:"round"`
+	TurnSeat       int            `json:"turnSeat"`
+	LastRaise      int64          `json:"lastRaise"`
+	Pot            int64          `json:"pot"`
+	HandNo         int            `json:"handNo"`
+	BankerSeat     int            `json:"bankerSeat"`
+	TurnToken      int64          `json:"turnToken"`
+	TurnDeadline   int64          `json:"turnDeadline,omitempty"` // unix millis
+	Round1Bet      bool           `json:"round1Bet,omitempty"`
+	Round2Bet      bool           `json:"round2Bet,omitempty"`
+	Round2Knock    bool           `json:"round2Knock,omitempty"`
+	FirstRaiseDone bool           `json:"firstRaiseDone,omitempty"`
+	RaisedRound1   bool           `json:"raisedRound1,omitempty"`
+	RaisedRound2   bool           `json:"raisedRound2,omitempty"`
+	LastAggSeat    int            `json:"lastAggSeat,omitempty"`
+	TailBigWin     bool           `json:"tailBigWin,omitempty"`
+	RoundActed     []int          `json:"roundActed,omitempty"`
+	TimeBankUsed   []int          `json:"timeBankUsed,omitempty"`
+	Seats          []snapshotSeat `json:"seats"`
+	DeckEnc        string         `json:"deckEnc,omitempty"` // undealt remainder, sealed under the table key
+	// The fairness commitment is public; the seed would expose the whole
+	// deck, so it is sealed like the deck until the hand's reveal.
+	DealCommitment string `json:"dealCommitment,omitempty"`
+	DealSeedEnc    string `json:"dealSeedEnc,omitempty"`
+	SavedAt        int64  `json:"savedAt"`
+}
+
+type snapshotSeat struct {
+	SeatIndex    int    `json:"seatIndex"`
+	Chips        int64  `json:"chips"`
+	Bet          int64  `json:"bet"`
+	Contribution int64  `json:"contribution,omitempty"`
+	Status       string `json:"status"`
+	CardsEnc     string `json:"cardsEnc,omitempty"` // hole cards, sealed under the seat owner's key
+}
+
+// snapshotDeckKey derives the key id for the undealt deck. The deck has no
+// owner, so it is sealed under the negated table ID — a key space no real
+// user occupies.
+func snapshotDeckKey(tableID int64) int64 {
+	return -tableID
+}
+
+// persistRuntimeSnapshotLocked writes the resumable state of the in-flight
+// hand onto the table row. Outside PhasePlaying the column is cleared
+// instead: a waiting or ended table rebuilds cleanly from PlayersJSON alone.
+func (rt *TableRuntime) persistRuntimeSnapshotLocked() {
+	if rt.db == nil {
+		return
+	}
+	var value interface{}
+	if rt.phase == PhasePlaying {
+		raw, err := rt.buildRuntimeSnapshotLocked()
+		if err != nil {
+			return
+		}
+		value = datatypes.JSON(raw)
+	}
+	_ = rt.db.Model(&model.Table{}).
+		Where("id = ?", rt.tableID).
+		Update("runtime_snapshot_json", value).Error
+}
+
+func (rt *TableRuntime) buildRuntimeSnapshotLocked() ([]byte, error) {
+	snap := runtimeSnapshot{
+		Phase:          rt.phase,
+		Round:          rt.round,
+		TurnSeat:       rt.turnSeat,
+		LastRaise:      rt.lastRaise,
+		Pot:            rt.pot,
+		HandNo:         rt.handNo,
+		BankerSeat:     rt.bankerSeat,
+		TurnToken:      rt.turnToken,
+		Round1Bet:      rt.round1Bet,
+		Round2Bet:      rt.round2Bet,
+		Round2Knock:    rt.round2Knock,
+		FirstRaiseDone: rt.firstRaiseDone,
+		RaisedRound1:   rt.raisedRound1,
+		RaisedRound2:   rt.raisedRound2,
+		LastAggSeat:    rt.lastAggSeat,
+		TailBigWin:     rt.tailBigWin,
+		SavedAt:        rt.timeNow().UnixMilli(),
+	}
+	if !rt.turnDeadline.IsZero() {
+		snap.TurnDeadline = rt.turnDeadline.UnixMilli()
+	}
+	for seatIdx := range rt.roundActed {
+		snap.RoundActed = append(snap.RoundActed, seatIdx)
+	}
+	sort.Ints(snap.RoundActed)
+	for seatIdx := range rt.timeBankUsed {
+		snap.TimeBankUsed = append(snap.TimeBankUsed, seatIdx)
+	}
+	sort.Ints(snap.TimeBankUsed)
+
+	for _, seat := range rt.seats {
+		ss := snapshotSeat{
+			SeatIndex:    seat.SeatIndex,
+			Chips:        seat.Chips,
+			Bet:          seat.Bet,
+			Contribution: seat.TotalContribution,
+			Status:       seat.Status,
+		}
+		if len(seat.cards) > 0 && seat.UserID != 0 {
+			plain, err := json.Marshal(seat.cards)
+			if err != nil {
+				return nil, err
+			}
+			enc, err := encryptForUser(seat.UserID, plain)
+			if err != nil {
+				return nil, err
+			}
+			ss.CardsEnc = enc
+		}
+		snap.Seats = append(snap.Seats, ss)
+	}
+	if len(rt.deck) > 0 {
+		plain, err := json.Marshal(rt.deck)
+		if err != nil {
+			return nil, err
+		}
+		enc, err := encryptForUser(snapshotDeckKey(rt.tableID), plain)
+		if err != nil {
+			return nil, err
+		}
+		snap.DeckEnc = enc
+	}
+	if rt.dealSeed != "" {
+		enc, err := encryptForUser(snapshotDeckKey(rt.tableID), []byte(rt.dealSeed))
+		if err != nil {
+			return nil, err
+		}
+		snap.DealCommitment = rt.dealCommitment
+		snap.DealSeedEnc = enc
+	}
+	return json.Marshal(snap)
+}
+
+// restoreSnapshot resumes a mid-hand state captured by
+// persistRuntimeSnapshotLocked. It runs before the loop starts, so no
+// synchronization is needed. Everything is decrypted and validated up front:
+// a stale or mismatched snapshot is discarded whole, never half-applied, and
+// the table simply comes back as a fresh waiting table.
+func (rt *TableRuntime) restoreSnapshot(raw []byte) bool {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" || trimmed == "null" {
+		return false
+	}
+	var snap runtimeSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return false
+	}
+	if snap.Phase != PhasePlaying {
+		return false
+	}
+
+	type restoredSeat struct {
+		seat  *SeatState
+		snap  snapshotSeat
+		cards []string
+	}
+	restored := make([]restoredSeat, 0, len(snap.Seats))
+	for _, ss := range snap.Seats {
+		seat := rt.findSeatLocked(ss.SeatIndex)
+		if seat == nil {
+			return false
+		}
+		var cards []string
+		if ss.CardsEnc != "" {
+			plain, err := decryptForUser(seat.UserID, ss.CardsEnc)
+			if err != nil {
+				return false
+			}
+			if err := json.Unmarshal(plain, &cards); err != nil {
+				return false
+			}
+		}
+		restored = append(restored, restoredSeat{seat: seat, snap: ss, cards: cards})
+	}
+	var deck []string
+	if snap.DeckEnc != "" {
+		plain, err := decryptForUser(snapshotDeckKey(rt.tableID), snap.DeckEnc)
+		if err != nil {
+			return false
+		}
+		if err := json.Unmarshal(plain, &deck); err != nil {
+			return false
+		}
+	}
+	var dealSeed string
+	if snap.DealSeedEnc != "" {
+		plain, err := decryptForUser(snapshotDeckKey(rt.tableID), snap.DealSeedEnc)
+		if err != nil {
+			return false
+		}
+		dealSeed = string(plain)
+	}
+
+	rt.phase = PhasePlaying
+	rt.round = snap.Round
+	rt.turnSeat = snap.TurnSeat
+	rt.lastRaise = snap.LastRaise
+	rt.pot = snap.Pot
+	rt.handNo = snap.HandNo
+	if snap.BankerSeat != 0 {
+		rt.bankerSeat = snap.BankerSeat
+	}
+	rt.turnToken = snap.TurnToken
+	rt.round1Bet = snap.Round1Bet
+	rt.round2Bet = snap.Round2Bet
+	rt.round2Knock = snap.Round2Knock
+	rt.firstRaiseDone = snap.FirstRaiseDone
+	rt.raisedRound1 = snap.RaisedRound1
+	rt.raisedRound2 = snap.RaisedRound2
+	rt.lastAggSeat = snap.LastAggSeat
+	rt.tailBigWin = snap.TailBigWin
+	rt.roundActed = make(map[int]bool, len(snap.RoundActed))
+	for _, seatIdx := range snap.RoundActed {
+		rt.roundActed[seatIdx] = true
+	}
+	rt.timeBankUsed = make(map[int]bool, len(snap.TimeBankUsed))
+	for _, seatIdx := range snap.TimeBankUsed {
+		rt.timeBankUsed[seatIdx] = true
+	}
+	for _, r := range restored {
+		r.seat.Chips = r.snap.Chips
+		r.seat.Bet = r.snap.Bet
+		r.seat.TotalContribution = r.snap.Contribution
+		r.seat.Status = r.snap.Status
+		r.seat.Ready = true
+		r.seat.cards = r.cards
+	}
+	rt.deck = deck
+	rt.dealSeed = dealSeed
+	rt.dealCommitment = snap.DealCommitment
+
+	// Re-arm the turn clock where it left off; a deadline that lapsed while
+	// the process was down gets a short grace instead of an instant timeout.
+	if snap.TurnDeadline > 0 {
+		deadline := time.UnixMilli(snap.TurnDeadline)
+		now := rt.timeNow()
+		if remaining := deadline.Sub(now); remaining > 0 {
+			rt.turnDeadline = deadline
+			rt.turnStartedAt = deadline.Add(-time.Duration(rt.turnSeconds) * time.Second)
+			rt.timer = time.NewTimer(remaining)
+		} else {
+			rt.turnDeadline = now.Add(snapshotResumeGrace)
+			rt.turnStartedAt = now
+			rt.timer = time.NewTimer(snapshotResumeGrace)
+		}
+		rt.timerC = rt.timer.C
+	}
+	return true
+}
+
+// decryptForUser reverses encryptForUser for snapshot resumption.
+func decryptForUser(userID int64, enc string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return nil, err
+	}
+	keyMaterial := sha256.Sum256([]byte(strconv.FormatInt(userID, 10)))
+	block, err := aes.NewCipher(keyMaterial[:])
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext shorter than nonce")
+	}
+	return gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+}
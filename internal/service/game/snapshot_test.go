@@ -0,0 +1,256 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupSnapshotRuntime(t *testing.T) (*TableRuntime, *gorm.DB, model.Scene) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}, &model.Match{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	scene := model.Scene{
+		ID:        1,
+		Name:      "test",
+		SeatCount: 2,
+		BasePi:    10,
+		MinUnitPi: 1,
+	}
+	table := model.Table{
+		ID:          1,
+		SceneID:     scene.ID,
+		SeatCount:   2,
+		Status:      "playing",
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`),
+	}
+	if err := db.Create(&table).Error; err != nil {
+		t.Fatalf("failed to seed table: %v", err)
+	}
+
+	rt, err := newTableRuntime(context.Background(), db, table, scene, 0, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(rt.stop)
+	return rt, db, scene
+}
+
+func TestSnapshotResumesMidHand(t *testing.T) {
+	rt, db, scene := setupSnapshotRuntime(t)
+	actor := startTimeBankHand(t, rt)
+
+	wantActions := rt.allowedActionsLocked(actor)
+	wantCountdown := rt.countdownSecondsLocked()
+	if wantCountdown == 0 {
+		t.Fatal("expected a running countdown mid-hand")
+	}
+
+	// Kill the runtime, then write the snapshot the periodic ticker would
+	// have produced just before the crash.
+	rt.stop()
+	rt.persistRuntimeSnapshotLocked()
+
+	var row model.Table
+	if err := db.First(&row, rt.tableID).Error; err != nil {
+		t.Fatalf("table lookup failed: %v", err)
+	}
+	if len(row.RuntimeSnapshotJSON) == 0 {
+		t.Fatal("expected snapshot persisted on table row")
+	}
+	// Hole cards and the deck remainder must be sealed, not stored plain.
+	var stored runtimeSnapshot
+	if err := json.Unmarshal(row.RuntimeSnapshotJSON, &stored); err != nil {
+		t.Fatalf("snapshot json invalid: %v", err)
+	}
+	for i, seat := range rt.seats {
+		if len(seat.cards) == 0 {
+			continue
+		}
+		enc := stored.Seats[i].CardsEnc
+		if enc == "" {
+			t.Fatalf("seat %d cards missing from snapshot", seat.SeatIndex)
+		}
+		if _, err := decryptForUser(seat.UserID+1, enc); err == nil {
+			t.Fatalf("seat %d cards decryptable with the wrong key", seat.SeatIndex)
+		}
+	}
+	if len(rt.deck) > 0 && stored.DeckEnc == "" {
+		t.Fatal("deck remainder missing from snapshot")
+	}
+
+	rebuilt, err := newTableRuntime(context.Background(), db, row, scene, 0, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to rebuild runtime: %v", err)
+	}
+	t.Cleanup(rebuilt.stop)
+
+	if rebuilt.phase != PhasePlaying {
+		t.Fatalf("expected resumed playing phase, got %s", rebuilt.phase)
+	}
+	if rebuilt.round != rt.round || rebuilt.turnSeat != rt.turnSeat || rebuilt.pot != rt.pot || rebuilt.lastRaise != rt.lastRaise {
+		t.Fatalf("resumed state differs: round %d/%d turnSeat %d/%d pot %d/%d lastRaise %d/%d",
+			rebuilt.round, rt.round, rebuilt.turnSeat, rt.turnSeat, rebuilt.pot, rt.pot, rebuilt.lastRaise, rt.lastRaise)
+	}
+	for i, seat := range rt.seats {
+		got := rebuilt.seats[i]
+		if got.Chips != seat.Chips || got.Bet != seat.Bet || got.Status != seat.Status {
+			t.Fatalf("seat %d differs after resume: %+v vs %+v", seat.SeatIndex, got, seat)
+		}
+		if !reflect.DeepEqual(got.cards, seat.cards) {
+			t.Fatalf("seat %d cards differ after resume: %v vs %v", seat.SeatIndex, got.cards, seat.cards)
+		}
+	}
+	if !reflect.DeepEqual(rebuilt.deck, rt.deck) {
+		t.Fatalf("deck remainder differs after resume: %v vs %v", rebuilt.deck, rt.deck)
+	}
+
+	if got := rebuilt.allowedActionsLocked(actor); !reflect.DeepEqual(got, wantActions) {
+		t.Fatalf("allowed actions differ after resume: %v vs %v", got, wantActions)
+	}
+	if got := rebuilt.countdownSecondsLocked(); got == 0 || got > wantCountdown {
+		t.Fatalf("countdown not resumed: got %d, want at most %d and non-zero", got, wantCountdown)
+	}
+}
+
+func TestShutdownSnapshotsHandAndNotifiesSubscribers(t *testing.T) {
+	rt, db, _ := setupSnapshotRuntime(t)
+	startTimeBankHand(t, rt)
+
+	ch := rt.Subscribe(101)
+	rt.shutdown()
+
+	sawNotice := false
+	deadline := time.After(2 * time.Second)
+loop:
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				break loop
+			}
+			switch msg.Type {
+			case "server_shutdown":
+				sawNotice = true
+				data, ok := msg.Data.(ginH)
+				if !ok || data["reconnectAfterMs"] != shutdownReconnectHintMS {
+					t.Fatalf("unexpected shutdown payload: %#v", msg.Data)
+				}
+			case "close":
+				if !sawNotice {
+					t.Fatal("close frame arrived before the shutdown notice")
+				}
+			}
+		case <-deadline:
+			t.Fatal("subscriber channel never closed")
+		}
+	}
+	if !sawNotice {
+		t.Fatal("no server_shutdown notice delivered")
+	}
+
+	var row model.Table
+	if err := db.First(&row, rt.tableID).Error; err != nil {
+		t.Fatalf("table lookup failed: %v", err)
+	}
+	if len(row.RuntimeSnapshotJSON) == 0 {
+		t.Fatal("expected in-flight hand snapshotted on shutdown")
+	}
+}
+
+func TestSnapshotClearedOutsidePlaying(t *testing.T) {
+	rt, db, _ := setupSnapshotRuntime(t)
+	rt.stop()
+
+	// Simulate a leftover snapshot on the row; a waiting runtime clears it.
+	if err := db.Model(&model.Table{}).Where("id = ?", rt.tableID).
+		Update("runtime_snapshot_json", []byte(`{"phase":"playing"}`)).Error; err != nil {
+		t.Fatalf("failed to seed snapshot: %v", err)
+	}
+	rt.persistRuntimeSnapshotLocked()
+
+	var row model.Table
+	if err := db.First(&row, rt.tableID).Error; err != nil {
+		t.Fatalf("table lookup failed: %v", err)
+	}
+	if len(row.RuntimeSnapshotJSON) != 0 && string(row.RuntimeSnapshotJSON) != "null" {
+		t.Fatalf("expected cleared snapshot, got %s", row.RuntimeSnapshotJSON)
+	}
+}
+
+func TestRestoreSnapshotRejectsTamperedCards(t *testing.T) {
+	rt, _, _ := setupSnapshotRuntime(t)
+	startTimeBankHand(t, rt)
+	rt.stop()
+
+	raw, err := rt.buildRuntimeSnapshotLocked()
+	if err != nil {
+		t.Fatalf("failed to build snapshot: %v", err)
+	}
+	var snap runtimeSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		t.Fatalf("snapshot json invalid: %v", err)
+	}
+	snap.Seats[0].CardsEnc = "dGFtcGVyZWQ="
+	tampered, _ := json.Marshal(snap)
+
+	fresh := &TableRuntime{
+		phase:      PhaseWaiting,
+		seats:      append([]SeatState(nil), rt.seats...),
+		seatByUser: map[int64]int{101: 1, 102: 2},
+	}
+	if fresh.restoreSnapshot(tampered) {
+		t.Fatal("expected tampered snapshot to be rejected")
+	}
+	if fresh.phase != PhaseWaiting {
+		t.Fatalf("rejected snapshot mutated phase to %s", fresh.phase)
+	}
+}
+
+func TestRestoreSnapshotLapsedDeadlineGetsGrace(t *testing.T) {
+	snap := runtimeSnapshot{
+		Phase:        PhasePlaying,
+		Round:        1,
+		TurnSeat:     1,
+		TurnDeadline: time.Now().Add(-time.Minute).UnixMilli(),
+		Seats: []snapshotSeat{
+			{SeatIndex: 1, Chips: 990, Status: "playing"},
+			{SeatIndex: 2, Chips: 990, Status: "playing"},
+		},
+	}
+	raw, _ := json.Marshal(snap)
+
+	rt := &TableRuntime{
+		phase:       PhaseWaiting,
+		turnSeconds: defaultTurnSeconds,
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000},
+			{SeatIndex: 2, UserID: 102, Chips: 1000},
+		},
+		seatByUser: map[int64]int{101: 1, 102: 2},
+	}
+	if !rt.restoreSnapshot(raw) {
+		t.Fatal("expected snapshot to be restored")
+	}
+	remaining := time.Until(rt.turnDeadline)
+	if remaining <= 0 || remaining > snapshotResumeGrace {
+		t.Fatalf("expected grace deadline within %v, got %v", snapshotResumeGrace, remaining)
+	}
+	if rt.timerC == nil {
+		t.Fatal("expected turn timer re-armed")
+	}
+}
@@ -0,0 +1,199 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	appErr "dx-service/pkg/errors"
+)
+
+func spectatorRuntime(delay time.Duration) (*TableRuntime, chan OutgoingMessage, chan OutgoingMessage) {
+	playerCh := make(chan OutgoingMessage, 8)
+	spectatorCh := make(chan OutgoingMessage, 8)
+	rt := &TableRuntime{
+		spectatorDelay: delay,
+		seatByUser:     map[int64]int{101: 1},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Status: "playing"},
+		},
+		subscribers: map[int64]chan OutgoingMessage{
+			101: playerCh,
+			999: spectatorCh,
+		},
+	}
+	return rt, playerCh, spectatorCh
+}
+
+func TestSpectatorSeesRaiseOnlyAfterDelay(t *testing.T) {
+	rt, playerCh, spectatorCh := spectatorRuntime(2 * time.Second)
+	msg := OutgoingMessage{Type: "action", Seq: 1, Data: ginH{"action": "raise"}}
+
+	rt.broadcastMessageLocked(msg)
+
+	if len(playerCh) != 1 {
+		t.Fatalf("expected seated player to receive the raise immediately, got %d messages", len(playerCh))
+	}
+	if len(spectatorCh) != 0 {
+		t.Fatalf("expected spectator delivery buffered, got %d messages", len(spectatorCh))
+	}
+	if len(rt.delayed) != 1 {
+		t.Fatalf("expected one buffered spectator message, got %d", len(rt.delayed))
+	}
+
+	rt.flushDelayedLocked(time.Now().Add(time.Second))
+	if len(spectatorCh) != 0 {
+		t.Fatal("expected spectator message still held before the delay elapses")
+	}
+
+	rt.flushDelayedLocked(time.Now().Add(3 * time.Second))
+	if len(spectatorCh) != 1 {
+		t.Fatalf("expected spectator message delivered after the delay, got %d", len(spectatorCh))
+	}
+	got := <-spectatorCh
+	if got.Type != "action" {
+		t.Fatalf("unexpected message type %s", got.Type)
+	}
+	if len(rt.delayed) != 0 {
+		t.Fatalf("expected delay buffer drained, got %d", len(rt.delayed))
+	}
+}
+
+func TestSpectatorDelayZeroIsRealTime(t *testing.T) {
+	rt, playerCh, spectatorCh := spectatorRuntime(0)
+
+	rt.broadcastMessageLocked(OutgoingMessage{Type: "action", Seq: 1})
+
+	if len(playerCh) != 1 || len(spectatorCh) != 1 {
+		t.Fatalf("expected real-time delivery to both, got player %d spectator %d",
+			len(playerCh), len(spectatorCh))
+	}
+}
+
+func TestSpectatorDelayBufferBounded(t *testing.T) {
+	rt, _, _ := spectatorRuntime(time.Minute)
+
+	for i := 0; i < maxDelayedMessages+10; i++ {
+		rt.enqueueDelayedLocked(999, OutgoingMessage{Seq: int64(i)})
+	}
+
+	if len(rt.delayed) != maxDelayedMessages {
+		t.Fatalf("expected buffer capped at %d, got %d", maxDelayedMessages, len(rt.delayed))
+	}
+	if rt.delayed[0].msg.Seq != 10 {
+		t.Fatalf("expected oldest entries dropped, head seq %d", rt.delayed[0].msg.Seq)
+	}
+}
+
+func spectatorRoleRuntime() *TableRuntime {
+	return &TableRuntime{
+		phase:      PhasePlaying,
+		seatByUser: map[int64]int{101: 1},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 1000, Status: "playing"},
+		},
+		subscribers: map[int64]chan OutgoingMessage{},
+		spectators:  map[int64]bool{},
+	}
+}
+
+func subscribeCmd(userID int64, spectator bool) (loopCommand, chan error) {
+	resp := make(chan error, 1)
+	return loopCommand{
+		kind:      "subscribe",
+		userID:    userID,
+		subCh:     make(chan OutgoingMessage, 8),
+		resp:      resp,
+		spectator: spectator,
+	}, resp
+}
+
+func TestSpectatorSubscribeIsReadOnly(t *testing.T) {
+	rt := spectatorRoleRuntime()
+
+	cmd, resp := subscribeCmd(999, true)
+	rt.handleCommand(cmd)
+	if err := <-resp; err != nil {
+		t.Fatalf("spectator subscribe failed: %v", err)
+	}
+	if !rt.spectators[999] {
+		t.Fatal("expected user 999 tracked as spectator")
+	}
+
+	if err := rt.handleActionLocked(999, "fold", nil); err != appErr.ErrSpectatorCannotAct {
+		t.Fatalf("expected ErrSpectatorCannotAct, got %v", err)
+	}
+	if actions := rt.allowedActionsLocked(999); actions != nil {
+		t.Fatalf("expected no allowed actions for spectator, got %v", actions)
+	}
+
+	cmd2, _ := subscribeCmd(999, false)
+	rt.handleCommand(cmd2)
+	if rt.spectators[999] {
+		t.Fatal("expected player re-subscribe to clear the spectator role")
+	}
+}
+
+func TestSpectatorExportHidesSplitDetails(t *testing.T) {
+	rt := spectatorRoleRuntime()
+	rt.phase = PhaseSettling
+	rt.variant = chexuanVariant{}
+	rt.seats[0].Split = &SplitView{Head: []string{"m1"}, Tail: []string{"m2", "m3"}}
+	rt.spectators[999] = true
+
+	player := rt.exportStateLocked(101)
+	if player.Seats[0].Split == nil {
+		t.Fatal("expected seated player to see the split at showdown")
+	}
+
+	spectator := rt.exportStateLocked(999)
+	if spectator.Seats[0].Split != nil {
+		t.Fatal("expected split details hidden from spectators")
+	}
+	if len(spectator.MyCards) != 0 {
+		t.Fatalf("expected no hole cards for spectator, got %v", spectator.MyCards)
+	}
+	if spectator.AllowedActions != nil {
+		t.Fatalf("expected no allowed actions for spectator, got %v", spectator.AllowedActions)
+	}
+}
+
+func TestSpectatorCapRejectsOverflow(t *testing.T) {
+	rt := spectatorRoleRuntime()
+	for i := 0; i < maxSpectators; i++ {
+		uid := int64(2000 + i)
+		rt.spectators[uid] = true
+		rt.subscribers[uid] = make(chan OutgoingMessage, 8)
+	}
+
+	cmd, resp := subscribeCmd(999, true)
+	rt.handleCommand(cmd)
+	if err := <-resp; err != appErr.ErrServerBusy {
+		t.Fatalf("expected ErrServerBusy at the spectator cap, got %v", err)
+	}
+	if _, ok := rt.subscribers[999]; ok {
+		t.Fatal("expected over-cap spectator not subscribed")
+	}
+
+	// An already-admitted spectator may reconnect past the cap.
+	cmd2, resp2 := subscribeCmd(2000, true)
+	rt.handleCommand(cmd2)
+	if err := <-resp2; err != nil {
+		t.Fatalf("expected admitted spectator to reconnect, got %v", err)
+	}
+}
+
+func TestSeatedUserSpectateFlagIgnored(t *testing.T) {
+	rt := spectatorRoleRuntime()
+
+	cmd, resp := subscribeCmd(101, true)
+	rt.handleCommand(cmd)
+	if err := <-resp; err != nil {
+		t.Fatalf("seated subscribe failed: %v", err)
+	}
+	if rt.spectators[101] {
+		t.Fatal("expected seated user subscribed as player despite the flag")
+	}
+	if err := rt.handleActionLocked(101, "fold", nil); err == appErr.ErrSpectatorCannotAct {
+		t.Fatal("expected seated user actions not rejected as spectator")
+	}
+}
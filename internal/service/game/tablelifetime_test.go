@@ -0,0 +1,231 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupLifetimeRuntime(t *testing.T, maxHands, maxDurationMinutes int) *TableRuntime {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}, &model.Match{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	scene := model.Scene{
+		ID:                 1,
+		Name:               "test",
+		SeatCount:          2,
+		BasePi:             10,
+		MinUnitPi:          1,
+		MaxHands:           maxHands,
+		MaxDurationMinutes: maxDurationMinutes,
+	}
+	table := model.Table{
+		ID:          1,
+		SceneID:     scene.ID,
+		SeatCount:   2,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`),
+	}
+
+	rt, err := newTableRuntime(context.Background(), db, table, scene, 0, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(rt.stop)
+	return rt
+}
+
+// playFoldHand readies both seats (101 opting into the auto re-queue) and has
+// the turn owner fold immediately, settling the hand as a fold win.
+func playFoldHand(t *testing.T, rt *TableRuntime) {
+	t.Helper()
+
+	if err := rt.HandleAction(101, "ready", json.RawMessage(`{"autoRequeue":true}`)); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if err := rt.HandleAction(102, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if rt.phase != PhasePlaying {
+		t.Fatalf("expected playing phase, got %s", rt.phase)
+	}
+	actAsTurnOwner(t, rt, "fold", nil)
+	if rt.phase != PhaseEnded {
+		t.Fatalf("expected hand settled, got %s", rt.phase)
+	}
+}
+
+func TestTableExpiresAfterMaxHands(t *testing.T) {
+	rt := setupLifetimeRuntime(t, 1, 0)
+	rt.resultGrace = 50 * time.Millisecond // keep the post-end window short
+	ch := rt.Subscribe(101)
+	// Drain into a roomy buffer so broadcasts during the hand are never
+	// dropped on the 8-slot subscriber channel.
+	collected := make(chan OutgoingMessage, 256)
+	go func() {
+		for msg := range ch {
+			collected <- msg
+		}
+		close(collected)
+	}()
+
+	playFoldHand(t, rt)
+
+	if rt.expiredReason != "max_hands" {
+		t.Fatalf("expected max_hands expiry, got %q", rt.expiredReason)
+	}
+	if len(rt.expiredRequeue) != 1 || rt.expiredRequeue[0].UserID != 101 {
+		t.Fatalf("expected opt-in 101 captured for re-queue, got %+v", rt.expiredRequeue)
+	}
+
+	sawExpired := false
+	sawClosing := false
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case msg, ok := <-collected:
+			if !ok {
+				if !sawExpired {
+					t.Fatal("channel closed without a table_expired message")
+				}
+				return
+			}
+			switch msg.Type {
+			case "table_expired":
+				payload, ok := msg.Data.(ginH)
+				if !ok || payload["reason"] != "max_hands" {
+					t.Fatalf("unexpected table_expired payload: %#v", msg.Data)
+				}
+				sawExpired = true
+			case "table_closing":
+				if !sawExpired {
+					t.Fatal("table_closing arrived before table_expired")
+				}
+				sawClosing = true
+			case "close":
+				info, ok := msg.Data.(CloseInfo)
+				if !ok || info.Reason != CloseReasonExpired {
+					t.Fatalf("unexpected close payload: %#v", msg.Data)
+				}
+				if !sawClosing {
+					t.Fatal("close frame arrived before the closing window was announced")
+				}
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for expiry messages")
+		}
+	}
+}
+
+func TestTableExpiresAfterMaxDuration(t *testing.T) {
+	rt := setupLifetimeRuntime(t, 0, 1)
+	rt.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	playFoldHand(t, rt)
+
+	if rt.expiredReason != "max_duration" {
+		t.Fatalf("expected max_duration expiry, got %q", rt.expiredReason)
+	}
+}
+
+// A table under its hand limit keeps running with the settled chips carried
+// over, so players are not re-queued between every hand.
+func TestTableSurvivesUnderMaxHands(t *testing.T) {
+	rt := setupLifetimeRuntime(t, 2, 0)
+
+	playFoldHand(t, rt)
+
+	if rt.expiredReason != "" {
+		t.Fatalf("table expired early: %q", rt.expiredReason)
+	}
+	select {
+	case <-rt.quitCh:
+		t.Fatal("runtime stopped although the hand limit was not reached")
+	default:
+	}
+
+	var total int64
+	for _, seat := range rt.seats {
+		total += seat.Chips
+	}
+	if total != 2000 {
+		t.Fatalf("chips not conserved across the hand: %d", total)
+	}
+}
+
+type fakeMatchmaker struct {
+	cleared  []int64
+	requeued map[int64]int64
+}
+
+func (f *fakeMatchmaker) ClearMatched(_ context.Context, userID int64) {
+	f.cleared = append(f.cleared, userID)
+}
+
+func (f *fakeMatchmaker) Requeue(_ context.Context, userID, _ int64, buyIn int64) error {
+	f.requeued[userID] = buyIn
+	return nil
+}
+
+func (f *fakeMatchmaker) PendingMatch(_ context.Context, _ int64) (int64, int64, bool) {
+	return 0, 0, false
+}
+
+func TestFinalizeExpiredTableClearsMarkersAndRequeues(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.Create(&model.Table{ID: 1, SceneID: 5, Status: "playing"}).Error; err != nil {
+		t.Fatalf("failed to seed table: %v", err)
+	}
+
+	fake := &fakeMatchmaker{requeued: make(map[int64]int64)}
+	svc := &Service{db: db}
+	svc.SetMatchmaker(fake)
+
+	rt := &TableRuntime{
+		tableID:       1,
+		sceneID:       5,
+		expiredReason: "max_hands",
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 950},
+			{SeatIndex: 2, UserID: 102, Chips: 1050},
+		},
+		expiredRequeue: []requeueCandidate{{UserID: 101, BuyIn: 950}},
+	}
+
+	svc.finalizeExpiredTable(context.Background(), rt)
+
+	var table model.Table
+	if err := db.First(&table, 1).Error; err != nil {
+		t.Fatalf("table lookup failed: %v", err)
+	}
+	if table.Status != "ended" {
+		t.Fatalf("expected table ended, got %q", table.Status)
+	}
+	if len(fake.cleared) != 2 {
+		t.Fatalf("expected matched markers cleared for both players, got %v", fake.cleared)
+	}
+	if buyIn, ok := fake.requeued[101]; !ok || buyIn != 950 {
+		t.Fatalf("expected 101 re-queued with buy-in 950, got %v", fake.requeued)
+	}
+	if _, ok := fake.requeued[102]; ok {
+		t.Fatal("102 did not opt in but was re-queued")
+	}
+}
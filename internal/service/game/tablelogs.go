@@ -0,0 +1,98 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// tableLogsCap bounds the persisted narrative per table; older entries
+	// fall off the head of the list.
+	tableLogsCap = 200
+	tableLogsTTL = 24 * time.Hour
+)
+
+func buildTableLogsKey(tableID int64) string {
+	return fmt.Sprintf("table:%d:logs", tableID)
+}
+
+// flushLogsLocked ships buffered log items to Redis in one pipeline. The
+// write runs off the loop goroutine so the hot path never waits on a network
+// round-trip; losing a batch on failure only costs reconnect narrative.
+func (rt *TableRuntime) flushLogsLocked() {
+	if rt.rdb == nil || len(rt.pendingLogs) == 0 {
+		return
+	}
+	batch := rt.pendingLogs
+	rt.pendingLogs = nil
+
+	key := buildTableLogsKey(rt.tableID)
+	rdb := rt.rdb
+	go func() {
+		ctx := context.Background()
+		pipe := rdb.Pipeline()
+		for _, item := range batch {
+			data, err := json.Marshal(item)
+			if err != nil {
+				continue
+			}
+			pipe.RPush(ctx, key, data)
+		}
+		pipe.LTrim(ctx, key, -tableLogsCap, -1)
+		pipe.Expire(ctx, key, tableLogsTTL)
+		_, _ = pipe.Exec(ctx)
+	}()
+}
+
+// restoreLogs loads the persisted narrative back into a freshly built
+// runtime so reconnecting players keep the hand's history after a restore.
+func (rt *TableRuntime) restoreLogs(ctx context.Context) {
+	if rt.rdb == nil || len(rt.logs) > 0 {
+		return
+	}
+	entries, err := rt.rdb.LRange(ctx, buildTableLogsKey(rt.tableID), -tableLogsCap, -1).Result()
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		var item LogItem
+		if err := json.Unmarshal([]byte(entry), &item); err != nil {
+			continue
+		}
+		rt.logs = append(rt.logs, item)
+	}
+}
+
+// TableLogs pages through the persisted table log, oldest first, for rejoin
+// clients that need entries older than the ones the state export carries.
+func (s *Service) TableLogs(ctx context.Context, tableID int64, offset, limit int64) ([]LogItem, error) {
+	if s.rdb == nil {
+		return nil, nil
+	}
+	if limit <= 0 || limit > tableLogsCap {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	entries, err := s.rdb.LRange(ctx, buildTableLogsKey(tableID), offset, offset+limit-1).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	items := make([]LogItem, 0, len(entries))
+	for _, entry := range entries {
+		var item LogItem
+		if err := json.Unmarshal([]byte(entry), &item); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newLogsTestClient(t *testing.T) *redis.Client {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	return redis.NewClient(&redis.Options{Addr: mr.Addr()})
+}
+
+func waitForLogLen(t *testing.T, rdb *redis.Client, tableID int64, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		n, err := rdb.LLen(context.Background(), buildTableLogsKey(tableID)).Result()
+		if err == nil && n == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("persisted log never reached %d entries", want)
+}
+
+func TestFlushAndRestoreLogs(t *testing.T) {
+	rdb := newLogsTestClient(t)
+	rt := &TableRuntime{tableID: 4, rdb: rdb}
+
+	for i := 0; i < 3; i++ {
+		rt.pendingLogs = append(rt.pendingLogs, LogItem{
+			ID:        fmt.Sprintf("id-%d", i),
+			Timestamp: int64(1000 + i),
+			Content:   fmt.Sprintf("entry %d", i),
+		})
+	}
+	rt.flushLogsLocked()
+	if len(rt.pendingLogs) != 0 {
+		t.Fatal("expected pending buffer drained after flush")
+	}
+	waitForLogLen(t, rdb, 4, 3)
+
+	restored := &TableRuntime{tableID: 4, rdb: rdb}
+	restored.restoreLogs(context.Background())
+	if len(restored.logs) != 3 {
+		t.Fatalf("expected 3 restored entries, got %d", len(restored.logs))
+	}
+	if restored.logs[0].Content != "entry 0" || restored.logs[2].Content != "entry 2" {
+		t.Fatalf("restored entries out of order: %+v", restored.logs)
+	}
+}
+
+func TestFlushTrimsToCap(t *testing.T) {
+	rdb := newLogsTestClient(t)
+	rt := &TableRuntime{tableID: 4, rdb: rdb}
+
+	for i := 0; i < tableLogsCap+25; i++ {
+		rt.pendingLogs = append(rt.pendingLogs, LogItem{
+			ID:      fmt.Sprintf("id-%d", i),
+			Content: fmt.Sprintf("entry %d", i),
+		})
+	}
+	rt.flushLogsLocked()
+	waitForLogLen(t, rdb, 4, tableLogsCap)
+
+	// The oldest entries fall off the head.
+	svc := &Service{rdb: rdb}
+	items, err := svc.TableLogs(context.Background(), 4, 0, 1)
+	if err != nil {
+		t.Fatalf("table logs: %v", err)
+	}
+	if len(items) != 1 || items[0].Content != "entry 25" {
+		t.Fatalf("expected oldest surviving entry 25, got %+v", items)
+	}
+}
+
+func TestTableLogsPaging(t *testing.T) {
+	rdb := newLogsTestClient(t)
+	rt := &TableRuntime{tableID: 4, rdb: rdb}
+	for i := 0; i < 10; i++ {
+		rt.pendingLogs = append(rt.pendingLogs, LogItem{ID: fmt.Sprintf("id-%d", i), Content: fmt.Sprintf("entry %d", i)})
+	}
+	rt.flushLogsLocked()
+	waitForLogLen(t, rdb, 4, 10)
+
+	svc := &Service{rdb: rdb}
+	page, err := svc.TableLogs(context.Background(), 4, 4, 3)
+	if err != nil {
+		t.Fatalf("table logs: %v", err)
+	}
+	if len(page) != 3 || page[0].Content != "entry 4" || page[2].Content != "entry 6" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+}
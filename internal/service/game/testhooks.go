@@ -0,0 +1,60 @@
+package game
+
+import (
+	"context"
+	mrand "math/rand"
+	"time"
+
+	"dx-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Test hooks for the gametest harness. These are plain exported accessors —
+// no build tags — so harness code in other packages can drive a runtime
+// without reaching into unexported state. Production code has no reason to
+// call them.
+
+// NewRuntimeForTest assembles a live runtime outside the service registry.
+// A nil db is fine: every persistence path already degrades to a no-op, the
+// same way bare in-package test fixtures run.
+func NewRuntimeForTest(ctx context.Context, db *gorm.DB, table model.Table, scene model.Scene) (*TableRuntime, error) {
+	return newTableRuntime(ctx, db, table, scene, 0, nil, func(*TableRuntime) {})
+}
+
+// SetClock overrides the runtime's clock. Like every direct field access in
+// tests, call it only while no loop command is in flight.
+func (rt *TableRuntime) SetClock(now func() time.Time) {
+	rt.now = now
+}
+
+// SetSeed replaces the global RNG with a seeded one so dealt hands are
+// reproducible across runs.
+func (rt *TableRuntime) SetSeed(seed int64) {
+	rt.rng = mrand.New(mrand.NewSource(seed))
+}
+
+// SetFixedDeck makes the next deals use this exact card order instead of
+// shuffling.
+func (rt *TableRuntime) SetFixedDeck(cards []string) {
+	rt.fixedDeck = cards
+}
+
+// Stop tears the runtime down; the usual t.Cleanup target.
+func (rt *TableRuntime) Stop() {
+	rt.stop()
+}
+
+// CorruptPotForTest skews the pot by delta so invariant-check tests can
+// manufacture an impossible state. Only safe while no loop command is in
+// flight.
+func (rt *TableRuntime) CorruptPotForTest(delta int64) {
+	rt.pot += delta
+}
+
+// StateForTest exports the table state as userID sees it, bypassing the
+// loop. Only safe while no loop command is in flight, which is how harness
+// tests operate between synchronous actions.
+func (rt *TableRuntime) StateForTest(userID int64) TableState {
+	return rt.exportStateLocked(userID)
+}
@@ -0,0 +1,75 @@
+package game
+
+import (
+	"math"
+	"time"
+)
+
+// The auto time bank backs slow players past the base turn timer: when the
+// countdown hits zero the turn keeps running on the seat's remaining bank
+// (scene.TimeBankBudgetSeconds) and the usual auto-action fires only once
+// the bank is dry. It is independent of the manual request_time extension,
+// which stays a once-per-hand button.
+
+// timeBankLeftLocked reports how many bank seconds a seat still has.
+func (rt *TableRuntime) timeBankLeftLocked(seatIdx int) int {
+	left := rt.timeBankBudgetSec - rt.timeBankSpent[seatIdx]
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// beginTimeBankBurnLocked switches an expired base countdown over to the
+// seat's remaining bank, re-arming the turn timer for that long, and reports
+// whether it did. A second expiry for the same seat means the bank ran dry,
+// so the caller falls through to the auto-action.
+func (rt *TableRuntime) beginTimeBankBurnLocked(seatIdx int) bool {
+	if rt.timeBankBudgetSec <= 0 {
+		return false
+	}
+	if rt.timeBankBurnSeat == seatIdx && !rt.timeBankBurnStart.IsZero() {
+		return false
+	}
+	left := rt.timeBankLeftLocked(seatIdx)
+	if left <= 0 {
+		return false
+	}
+	rt.timeBankBurnSeat = seatIdx
+	rt.timeBankBurnStart = rt.timeNow()
+	rt.extendTurnTimerLocked(time.Duration(left) * time.Second)
+	rt.appendLogLocked("time_bank", 0)
+	rt.broadcastMessageLocked(OutgoingMessage{
+		Type: "time_bank",
+		Seq:  rt.nextSeqLocked(),
+		Data: ginH{
+			"seat":      seatIdx,
+			"remaining": left,
+			"countdown": rt.countdownSecondsLocked(),
+		},
+	})
+	return true
+}
+
+// settleTimeBankBurnLocked charges the burning seat for the bank time it
+// actually used once its turn resolves, rounding up so a dip never costs
+// zero. Safe to call from any turn-ending path: it is a no-op unless seatIdx
+// is the seat currently burning.
+func (rt *TableRuntime) settleTimeBankBurnLocked(seatIdx int) {
+	if rt.timeBankBurnSeat != seatIdx || rt.timeBankBurnStart.IsZero() {
+		return
+	}
+	elapsed := int(math.Ceil(rt.timeNow().Sub(rt.timeBankBurnStart).Seconds()))
+	if elapsed < 1 {
+		elapsed = 1
+	}
+	if left := rt.timeBankLeftLocked(seatIdx); elapsed > left {
+		elapsed = left
+	}
+	if rt.timeBankSpent == nil {
+		rt.timeBankSpent = make(map[int]int)
+	}
+	rt.timeBankSpent[seatIdx] += elapsed
+	rt.timeBankBurnSeat = 0
+	rt.timeBankBurnStart = time.Time{}
+}
@@ -0,0 +1,243 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTimeBankRuntime(t *testing.T, timeBankSeconds int) *TableRuntime {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Table{}, &model.Match{}, &model.MatchRoundLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	scene := model.Scene{
+		ID:              1,
+		Name:            "test",
+		SeatCount:       2,
+		BasePi:          10,
+		MinUnitPi:       1,
+		TimeBankSeconds: timeBankSeconds,
+	}
+	table := model.Table{
+		ID:          1,
+		SceneID:     scene.ID,
+		SeatCount:   2,
+		PlayersJSON: []byte(`{"1":{"userId":101,"chips":1000},"2":{"userId":102,"chips":1000}}`),
+	}
+
+	rt, err := newTableRuntime(context.Background(), db, table, scene, 0, nil, func(*TableRuntime) {})
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(rt.stop)
+	return rt
+}
+
+func startTimeBankHand(t *testing.T, rt *TableRuntime) int64 {
+	t.Helper()
+
+	if err := rt.HandleAction(101, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if err := rt.HandleAction(102, "ready", nil); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if rt.phase != PhasePlaying {
+		t.Fatalf("expected playing phase, got %s", rt.phase)
+	}
+
+	for _, seat := range rt.seats {
+		if seat.SeatIndex == rt.turnSeat {
+			return seat.UserID
+		}
+	}
+	t.Fatalf("no seat owns the turn %d", rt.turnSeat)
+	return 0
+}
+
+func TestRequestTimeExtendsDeadline(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 5)
+	actor := startTimeBankHand(t, rt)
+
+	before := rt.turnDeadline
+	if err := rt.HandleAction(actor, "request_time", nil); err != nil {
+		t.Fatalf("request_time failed: %v", err)
+	}
+
+	extended := rt.turnDeadline.Sub(before)
+	if extended != 5*time.Second {
+		t.Fatalf("expected deadline extended by 5s, got %v", extended)
+	}
+	if rt.timer == nil {
+		t.Fatal("expected turn timer rearmed at the extended deadline")
+	}
+	if remaining := time.Until(rt.turnDeadline); remaining <= defaultTurnSeconds*time.Second {
+		t.Fatalf("expected timer past the default deadline, remaining %v", remaining)
+	}
+
+	state := rt.exportStateLocked(actor)
+	if state.TurnDeadline != rt.turnDeadline.UnixMilli() {
+		t.Fatalf("expected exported deadline %d, got %d", rt.turnDeadline.UnixMilli(), state.TurnDeadline)
+	}
+	if state.ServerTime == 0 {
+		t.Fatal("expected serverTime set in exported state")
+	}
+}
+
+func TestRequestTimeDoubleUseRejected(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 5)
+	actor := startTimeBankHand(t, rt)
+
+	if err := rt.HandleAction(actor, "request_time", nil); err != nil {
+		t.Fatalf("first request_time failed: %v", err)
+	}
+	if err := rt.HandleAction(actor, "request_time", nil); err == nil {
+		t.Fatal("expected second request_time to be rejected")
+	}
+}
+
+func TestRequestTimeDisabled(t *testing.T) {
+	rt := setupTimeBankRuntime(t, 0)
+	actor := startTimeBankHand(t, rt)
+
+	if err := rt.HandleAction(actor, "request_time", nil); err == nil {
+		t.Fatal("expected request_time to fail when the scene has no time bank")
+	}
+}
+
+// autoBankRuntime seats two players mid-round-2 with the turn on seat 1
+// facing a free pass, and the auto time bank set to budget seconds.
+func autoBankRuntime(budget int) *TableRuntime {
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	return &TableRuntime{
+		phase:             PhasePlaying,
+		handNo:            1,
+		round:             2,
+		turnSeat:          1,
+		basePi:            10,
+		lastRaise:         20,
+		strikeLimit:       5,
+		timeBankBudgetSec: budget,
+		timeBankSpent:     make(map[int]int),
+		seatByUser:        map[int64]int{101: 1, 102: 2},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 500, Bet: 20, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 500, Bet: 20, Status: "playing"},
+		},
+	}
+}
+
+// The heart of the feature: a slow turn eats only the bank time it actually
+// used, the next slow turn re-arms with what is left, and the auto-action
+// fires only once the bank is empty.
+func TestTimeBankPartialConsumptionAcrossTurns(t *testing.T) {
+	rt := autoBankRuntime(30)
+	now := time.Now()
+	rt.now = func() time.Time { return now }
+	rt.turnDeadline = now
+
+	// Turn 1: the base countdown expires and the bank takes over.
+	rt.handleTurnTimeoutLocked()
+	if rt.roundActed[1] {
+		t.Fatal("bank burn must not auto-act")
+	}
+	if rt.timeBankBurnSeat != 1 {
+		t.Fatalf("expected seat 1 burning its bank, got %d", rt.timeBankBurnSeat)
+	}
+	if got := rt.turnDeadline.Sub(now); got != 30*time.Second {
+		t.Fatalf("expected the timer re-armed for the full bank, got %v", got)
+	}
+
+	// The player acts 10 seconds in; only that much is charged.
+	now = now.Add(10 * time.Second)
+	if err := rt.handleTurnActionLocked("pass", 1, nil); err != nil {
+		t.Fatalf("pass failed: %v", err)
+	}
+	if left := rt.timeBankLeftLocked(1); left != 20 {
+		t.Fatalf("expected 20s of bank left after a 10s dip, got %d", left)
+	}
+	rt.cancelTimerLocked()
+
+	// Turn 2: the shrunken bank re-arms for what remains.
+	rt.turnSeat = 1
+	rt.roundActed = map[int]bool{}
+	rt.turnDeadline = now
+	rt.handleTurnTimeoutLocked()
+	if got := rt.turnDeadline.Sub(now); got != 20*time.Second {
+		t.Fatalf("expected the timer re-armed for the remaining 20s, got %v", got)
+	}
+
+	// Once it runs dry the usual auto-action finally fires.
+	now = now.Add(20 * time.Second)
+	rt.handleTurnTimeoutLocked()
+	if left := rt.timeBankLeftLocked(1); left != 0 {
+		t.Fatalf("expected the bank drained, got %d", left)
+	}
+	if !rt.roundActed[1] {
+		t.Fatal("expected the auto-action after the bank drained")
+	}
+	rt.cancelTimerLocked()
+}
+
+func TestTimeBankDisabledTimesOutImmediately(t *testing.T) {
+	rt := autoBankRuntime(0)
+	rt.turnDeadline = time.Now()
+
+	rt.handleTurnTimeoutLocked()
+	if !rt.roundActed[1] {
+		t.Fatal("without a bank the timeout must auto-act at once")
+	}
+	rt.cancelTimerLocked()
+}
+
+func TestTimeBankRemainingExportedPerSeat(t *testing.T) {
+	rt := autoBankRuntime(30)
+	rt.timeBankSpent[1] = 12
+
+	state := rt.exportStateLocked(101)
+	for _, seat := range state.Seats {
+		want := 30
+		if seat.SeatIndex == 1 {
+			want = 18
+		}
+		if seat.TimeBankLeft != want {
+			t.Fatalf("seat %d: expected %ds of bank exported, got %d", seat.SeatIndex, want, seat.TimeBankLeft)
+		}
+	}
+}
+
+func TestTimeBankRefreshPerHand(t *testing.T) {
+	rt := autoBankRuntime(30)
+	rt.timeBankSpent[1] = 25
+
+	// Session-scoped bank: the next hand keeps the dent.
+	rt.startRoundLocked()
+	rt.cancelTimerLocked()
+	if left := rt.timeBankLeftLocked(1); left != 5 {
+		t.Fatalf("expected the spent bank carried across hands, got %d", left)
+	}
+
+	// Per-hand refresh: the next hand starts with the full budget.
+	rt.timeBankRefreshes = true
+	rt.startRoundLocked()
+	rt.cancelTimerLocked()
+	if left := rt.timeBankLeftLocked(1); left != 30 {
+		t.Fatalf("expected a refilled bank at hand start, got %d", left)
+	}
+}
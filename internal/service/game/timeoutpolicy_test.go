@@ -0,0 +1,148 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// policyRuntime seats three players mid-hand with the turn on seat 1, which
+// faces an outstanding bet of lastRaise-bet. Round 2 keeps the first-actor
+// minimum out of the call math.
+func policyRuntime(policy string, lastRaise, bet int64) *TableRuntime {
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	return &TableRuntime{
+		phase:         PhasePlaying,
+		handNo:        1,
+		round:         2,
+		turnSeat:      1,
+		basePi:        10,
+		lastRaise:     lastRaise,
+		strikeLimit:   5,
+		timeoutPolicy: policy,
+		seatByUser:    map[int64]int{101: 1, 102: 2, 103: 3},
+		seats: []SeatState{
+			{SeatIndex: 1, UserID: 101, Chips: 500, Bet: bet, Status: "playing"},
+			{SeatIndex: 2, UserID: 102, Chips: 500, Bet: lastRaise, Status: "playing"},
+			{SeatIndex: 3, UserID: 103, Chips: 500, Bet: lastRaise, Status: "playing"},
+		},
+	}
+}
+
+func timeoutEntry(t *testing.T, rt *TableRuntime) actionEntry {
+	t.Helper()
+	logs := waitForRoundLogs(t, rt.db, 1)
+	var actions []actionEntry
+	if err := json.Unmarshal(logs[0].ActionsJSON, &actions); err != nil {
+		t.Fatalf("decode actions: %v", err)
+	}
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action, got %d", len(actions))
+	}
+	return actions[0]
+}
+
+func TestTimeoutPolicyDefaultPassesWhenLegal(t *testing.T) {
+	rt := policyRuntime("", 20, 20)
+	rt.db, rt.matchID = roundLogTestDB(t), 1
+	rt.handleTurnTimeoutLocked()
+
+	if rt.seats[0].Status != "playing" {
+		t.Fatalf("default policy should pass a free turn, got %s", rt.seats[0].Status)
+	}
+	entry := timeoutEntry(t, rt)
+	if entry.Action != "auto_pass" {
+		t.Fatalf("expected auto_pass entry, got %s", entry.Action)
+	}
+	if got := entry.Meta["policy"]; got != TimeoutPolicyPass {
+		t.Fatalf("expected policy %s in meta, got %v", TimeoutPolicyPass, got)
+	}
+}
+
+func TestTimeoutPolicyFoldIgnoresLegalPass(t *testing.T) {
+	rt := policyRuntime(TimeoutPolicyFold, 20, 20)
+	rt.db, rt.matchID = roundLogTestDB(t), 1
+	rt.handleTurnTimeoutLocked()
+
+	if rt.seats[0].Status != "folded" {
+		t.Fatalf("fold policy must fold even when a pass is legal, got %s", rt.seats[0].Status)
+	}
+	entry := timeoutEntry(t, rt)
+	if entry.Action != "auto_fold" {
+		t.Fatalf("expected auto_fold entry, got %s", entry.Action)
+	}
+	if got := entry.Meta["policy"]; got != TimeoutPolicyFold {
+		t.Fatalf("expected policy %s in meta, got %v", TimeoutPolicyFold, got)
+	}
+}
+
+func TestTimeoutPolicyCallPrefersFreePass(t *testing.T) {
+	rt := policyRuntime(TimeoutPolicyCall, 20, 20)
+	rt.handleTurnTimeoutLocked()
+
+	if rt.seats[0].Status != "playing" {
+		t.Fatalf("call policy should still pass for free, got %s", rt.seats[0].Status)
+	}
+	if rt.seats[0].Chips != 500 {
+		t.Fatalf("a free pass must not spend chips, stack %d", rt.seats[0].Chips)
+	}
+}
+
+func TestTimeoutPolicyCallCallsSmallBet(t *testing.T) {
+	rt := policyRuntime(TimeoutPolicyCall, 20, 10)
+	rt.db, rt.matchID = roundLogTestDB(t), 1
+	rt.handleTurnTimeoutLocked()
+
+	seat := rt.findSeatLocked(1)
+	if seat.Status != "playing" {
+		t.Fatalf("small bet should be called, got %s", seat.Status)
+	}
+	if seat.Chips != 490 || seat.Bet != 20 {
+		t.Fatalf("expected 10 committed, stack %d bet %d", seat.Chips, seat.Bet)
+	}
+	if rt.pot != 10 {
+		t.Fatalf("expected pot 10, got %d", rt.pot)
+	}
+	entry := timeoutEntry(t, rt)
+	if entry.Action != "auto_call" {
+		t.Fatalf("expected auto_call entry, got %s", entry.Action)
+	}
+	if got := entry.Meta["policy"]; got != TimeoutPolicyCall {
+		t.Fatalf("expected policy %s in meta, got %v", TimeoutPolicyCall, got)
+	}
+	if got, ok := entry.Meta["amount"].(float64); !ok || int64(got) != 10 {
+		t.Fatalf("expected amount 10 in meta, got %v", entry.Meta["amount"])
+	}
+}
+
+func TestTimeoutPolicyCallFoldsWhenBetExceedsBasePi(t *testing.T) {
+	rt := policyRuntime(TimeoutPolicyCall, 50, 10)
+	rt.handleTurnTimeoutLocked()
+
+	seat := rt.findSeatLocked(1)
+	if seat.Status != "folded" {
+		t.Fatalf("a call above basePi must fold instead, got %s", seat.Status)
+	}
+	if seat.Chips != 500 {
+		t.Fatalf("folding must not spend chips, stack %d", seat.Chips)
+	}
+}
+
+func TestTimeoutPolicyCallFoldsWhenSeatCannotAfford(t *testing.T) {
+	rt := policyRuntime(TimeoutPolicyCall, 20, 10)
+	rt.seats[0].Chips = 5
+	rt.handleTurnTimeoutLocked()
+
+	seat := rt.findSeatLocked(1)
+	if seat.Status != "folded" {
+		t.Fatalf("an unaffordable call must fold, not go all-in, got %s", seat.Status)
+	}
+	if seat.Chips != 5 {
+		t.Fatalf("folding must not spend chips, stack %d", seat.Chips)
+	}
+}
@@ -0,0 +1,121 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTrialSettleService(t *testing.T) (*gorm.DB, *Service) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&model.Match{}, &model.Scene{}, &model.Table{}, &model.Wallet{}, &model.BillingLog{},
+		&model.RakeRule{}, &model.AgentRule{}, &model.AgentProfitLog{}, &model.MatchRake{},
+		&model.User{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db, &Service{db: db}
+}
+
+func TestTrialSettlementTouchesOnlyTrialBalance(t *testing.T) {
+	db, svc := newTrialSettleService(t)
+	ctx := context.Background()
+
+	// A configured rake rule and a bound agent chain must both be ignored
+	// when the scene runs in trial mode.
+	if err := db.Create(&model.RakeRule{ID: 5, Name: "r", Type: "ratio", Status: "enabled", ConfigJSON: []byte(`{"ratio":0.1}`)}).Error; err != nil {
+		t.Fatalf("seed rake rule: %v", err)
+	}
+	if err := db.Create(&model.AgentRule{MaxLevel: 1, LevelRatiosJSON: []byte(`{"L1":0.5}`)}).Error; err != nil {
+		t.Fatalf("seed agent rule: %v", err)
+	}
+	if err := db.Create(&model.User{ID: 201, Phone: "201", AgentPath: "9"}).Error; err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	if err := db.Create(&model.Scene{ID: 1, Name: "trial", SeatCount: 2, TrialMode: true, RakeRuleID: 5}).Error; err != nil {
+		t.Fatalf("seed scene: %v", err)
+	}
+	match := model.Match{TableID: 1, SceneID: 1}
+	if err := db.Create(&match).Error; err != nil {
+		t.Fatalf("seed match: %v", err)
+	}
+	wallets := []model.Wallet{
+		{UserID: 201, BalanceTotal: 500, BalanceAvailable: 500, TrialBalance: 1000},
+		{UserID: 202, BalanceTotal: 500, BalanceAvailable: 500, TrialBalance: 1000},
+	}
+	if err := db.Create(&wallets).Error; err != nil {
+		t.Fatalf("seed wallets: %v", err)
+	}
+
+	if err := svc.SettleMatch(ctx, SettlementRequest{
+		MatchID: match.ID,
+		SceneID: 1,
+		Results: []PlayerResult{
+			{UserID: 201, NetPoints: 100},
+			{UserID: 202, NetPoints: -100},
+		},
+	}); err != nil {
+		t.Fatalf("settle failed: %v", err)
+	}
+
+	var winner, loser model.Wallet
+	if err := db.First(&winner, "user_id = ?", int64(201)).Error; err != nil {
+		t.Fatalf("load winner: %v", err)
+	}
+	if err := db.First(&loser, "user_id = ?", int64(202)).Error; err != nil {
+		t.Fatalf("load loser: %v", err)
+	}
+	if winner.TrialBalance != 1100 || loser.TrialBalance != 900 {
+		t.Fatalf("expected trial balances 1100/900, got %d/%d", winner.TrialBalance, loser.TrialBalance)
+	}
+	if winner.BalanceAvailable != 500 || winner.BalanceTotal != 500 || winner.TotalWin != 0 || winner.TotalRake != 0 {
+		t.Fatalf("trial win leaked into the real wallet: %+v", winner)
+	}
+	if loser.BalanceAvailable != 500 || loser.BalanceTotal != 500 || loser.TotalConsume != 0 {
+		t.Fatalf("trial loss leaked into the real wallet: %+v", loser)
+	}
+
+	var agentLogs int64
+	if err := db.Model(&model.AgentProfitLog{}).Count(&agentLogs).Error; err != nil {
+		t.Fatalf("count agent logs: %v", err)
+	}
+	if agentLogs != 0 {
+		t.Fatalf("trial settlement must not distribute agent shares, got %d logs", agentLogs)
+	}
+	var rakeRows int64
+	if err := db.Model(&model.MatchRake{}).Count(&rakeRows).Error; err != nil {
+		t.Fatalf("count rake rows: %v", err)
+	}
+	if rakeRows != 0 {
+		t.Fatalf("trial settlement must not write revenue rows, got %d", rakeRows)
+	}
+
+	var logs []model.BillingLog
+	if err := db.Find(&logs).Error; err != nil {
+		t.Fatalf("load billing logs: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("expected only win/lose logs, got %d", len(logs))
+	}
+	for _, log := range logs {
+		if !strings.Contains(string(log.MetaJSON), `"trial":true`) {
+			t.Fatalf("billing log missing trial marker: %s", log.MetaJSON)
+		}
+	}
+}
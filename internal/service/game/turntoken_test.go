@@ -0,0 +1,76 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"dx-service/internal/config"
+	appErr "dx-service/pkg/errors"
+)
+
+func tokenRuntime() *TableRuntime {
+	rt := maskRuntime(nil, false, 1)
+	rt.turnToken = 3
+	return rt
+}
+
+func tokenData(t *testing.T, token int64) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(map[string]int64{"turnToken": token})
+	if err != nil {
+		t.Fatalf("marshal token payload: %v", err)
+	}
+	return data
+}
+
+func TestTurnTokenMatchAccepted(t *testing.T) {
+	rt := tokenRuntime()
+	if err := rt.handleTurnActionLocked("pass", 1, tokenData(t, 3)); err != nil {
+		t.Fatalf("expected matching token accepted, got %v", err)
+	}
+}
+
+func TestTurnTokenMismatchRejected(t *testing.T) {
+	rt := tokenRuntime()
+	err := rt.handleTurnActionLocked("pass", 1, tokenData(t, 2))
+	if !errors.Is(err, appErr.ErrStaleTurn) {
+		t.Fatalf("expected ErrStaleTurn, got %v", err)
+	}
+}
+
+func TestTurnTokenOmittedKeepsOldBehavior(t *testing.T) {
+	rt := tokenRuntime()
+	if err := rt.handleTurnActionLocked("pass", 1, nil); err != nil {
+		t.Fatalf("expected tokenless action accepted during deprecation window, got %v", err)
+	}
+}
+
+func TestTurnTokenOmittedRejectedWhenRequired(t *testing.T) {
+	prev := config.GlobalConfig
+	config.GlobalConfig = &config.Config{Features: config.FeatureConfig{RequireTurnToken: true}}
+	defer func() { config.GlobalConfig = prev }()
+
+	rt := tokenRuntime()
+	err := rt.handleTurnActionLocked("pass", 1, nil)
+	if !errors.Is(err, appErr.ErrStaleTurn) {
+		t.Fatalf("expected ErrStaleTurn for missing token, got %v", err)
+	}
+}
+
+func TestTurnTokenAdvancesWithTurnTimer(t *testing.T) {
+	rt := tokenRuntime()
+	rt.turnSeconds = 15
+
+	before := rt.turnToken
+	rt.resetTurnTimerLocked()
+	rt.cancelTimerLocked()
+	if rt.turnToken != before+1 {
+		t.Fatalf("expected token %d after reset, got %d", before+1, rt.turnToken)
+	}
+
+	state := rt.exportStateLocked(101)
+	if state.TurnToken != rt.turnToken {
+		t.Fatalf("expected exported token %d, got %d", rt.turnToken, state.TurnToken)
+	}
+}
@@ -0,0 +1,142 @@
+package game
+
+import (
+	mrand "math/rand"
+	"strings"
+
+	"dx-service/internal/model"
+)
+
+// A Variant bundles the rules that differ between table modes: how the deck
+// is built, how many cards each betting round deals, how hands score, and how
+// a finished hand settles. The runtime calls through its variant instead of
+// branching on mode flags, so adding a mode means writing an implementation
+// here and teaching variantForScene to pick it.
+type Variant interface {
+	// NewDeck builds the shuffled deck for a fresh hand. rng may be nil, in
+	// which case the shuffle falls back to crypto randomness.
+	NewDeck(rng *mrand.Rand) []string
+	// CardsPerStage reports how many cards each active seat is dealt when
+	// the given betting round opens; zero means no deal that round.
+	CardsPerStage(round int) int
+	// DisplayCard converts an internal card code to the client-facing code.
+	DisplayCard(code string) string
+	// Evaluate scores a hand for the generic showdown; higher wins. Variants
+	// that replace the showdown wholesale (chexuan) never reach it.
+	Evaluate(cards []string) int64
+	// SettleEarlyLocked lets the variant end the hand when a betting round
+	// closes without reaching the normal showdown, reporting whether it
+	// settled. Runs on the runtime loop.
+	SettleEarlyLocked(rt *TableRuntime) bool
+	// SettleLocked resolves the finished hand: winners, ledger, payout.
+	// Runs on the runtime loop.
+	SettleLocked(rt *TableRuntime)
+}
+
+// variantForScene picks the rule set a scene plays.
+func variantForScene(scene model.Scene) Variant {
+	name := strings.ToLower(scene.Name)
+	switch {
+	case scene.BoboEnabled || scene.MangoEnabled || strings.Contains(name, "扯旋") || strings.Contains(name, "chexuan"):
+		return chexuanVariant{}
+	case strings.Contains(name, "highcard"):
+		return highCardVariant{}
+	default:
+		return defaultVariant{}
+	}
+}
+
+// variantLocked returns the table's rule set, defaulting so hand-built
+// runtimes in tests work without wiring.
+func (rt *TableRuntime) variantLocked() Variant {
+	if rt.variant == nil {
+		return defaultVariant{}
+	}
+	return rt.variant
+}
+
+// defaultVariant is the simplified two-card game: one deal of two cards,
+// pair-or-high-card scoring, and the layered side-pot showdown.
+type defaultVariant struct{}
+
+func (defaultVariant) NewDeck(rng *mrand.Rand) []string {
+	deck := newPokerDeck()
+	shuffleStrings(deck, rng)
+	return deck
+}
+
+func (defaultVariant) CardsPerStage(round int) int {
+	if round == 0 {
+		return 2
+	}
+	return 0
+}
+
+func (defaultVariant) DisplayCard(code string) string { return code }
+
+func (defaultVariant) Evaluate(cards []string) int64 { return EvaluateHand(cards) }
+
+func (defaultVariant) SettleEarlyLocked(*TableRuntime) bool { return false }
+
+func (defaultVariant) SettleLocked(rt *TableRuntime) { rt.settleDefaultLocked() }
+
+// chexuanVariant plays the 32-card chexuan rules: single-card deals on rounds
+// one and two, head/tail split comparison at showdown, and the mango draw.
+type chexuanVariant struct{}
+
+func (chexuanVariant) NewDeck(rng *mrand.Rand) []string { return newShuffledChexuanDeck(rng) }
+
+func (chexuanVariant) CardsPerStage(round int) int {
+	switch round {
+	case 0:
+		return 2
+	case 1, 2:
+		return 1
+	}
+	return 0
+}
+
+func (chexuanVariant) DisplayCard(code string) string { return ToPokerCode(code) }
+
+// Evaluate only backs the generic showdown, which chexuan replaces in
+// SettleLocked; score as a pair so any stray caller still ranks sanely.
+func (chexuanVariant) Evaluate(cards []string) int64 { return evaluatePairScore(cards) }
+
+func (chexuanVariant) SettleEarlyLocked(rt *TableRuntime) bool {
+	// Round 1 saw betting but round 2 was all checks: the hand ends as a
+	// mango ("liuju") draw with no showdown. A raised-and-abandoned round 2
+	// never reaches here — shouldSettleLocked ends those hands first.
+	if rt.round != 3 || !rt.round1Bet || rt.round2Bet {
+		return false
+	}
+	rt.phase = PhaseSettling
+	rt.settleChexuanMangoLocked()
+	return true
+}
+
+func (chexuanVariant) SettleLocked(rt *TableRuntime) { rt.settleChexuanLocked() }
+
+// highCardVariant is a deliberately tiny demonstration of the extension
+// point: default deal and settlement, but only the single highest card
+// counts, so a pair of twos loses to a lone ace.
+type highCardVariant struct{}
+
+func (highCardVariant) NewDeck(rng *mrand.Rand) []string { return defaultVariant{}.NewDeck(rng) }
+
+func (highCardVariant) CardsPerStage(round int) int { return defaultVariant{}.CardsPerStage(round) }
+
+func (highCardVariant) DisplayCard(code string) string { return code }
+
+func (highCardVariant) Evaluate(cards []string) int64 {
+	best := int64(0)
+	for _, c := range cards {
+		if v := int64(parseCard(c).RankValue); v > best {
+			best = v
+		}
+	}
+	return best
+}
+
+func (highCardVariant) SettleEarlyLocked(*TableRuntime) bool { return false }
+
+func (highCardVariant) SettleLocked(rt *TableRuntime) { rt.settleDefaultLocked() }
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"testing"
+
+	"dx-service/internal/model"
+)
+
+func TestVariantForScene(t *testing.T) {
+	cases := []struct {
+		name  string
+		scene model.Scene
+		want  Variant
+	}{
+		{"plain scene", model.Scene{Name: "普通场"}, defaultVariant{}},
+		{"bobo flag", model.Scene{Name: "普通场", BoboEnabled: true}, chexuanVariant{}},
+		{"mango flag", model.Scene{Name: "普通场", MangoEnabled: true}, chexuanVariant{}},
+		{"chexuan by name", model.Scene{Name: "扯旋高级场"}, chexuanVariant{}},
+		{"chexuan by ascii name", model.Scene{Name: "Chexuan VIP"}, chexuanVariant{}},
+		{"highcard demo by name", model.Scene{Name: "highcard demo"}, highCardVariant{}},
+	}
+	for _, tc := range cases {
+		if got := variantForScene(tc.scene); got != tc.want {
+			t.Errorf("%s: got %T, want %T", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestVariantLockedDefaultsForBareRuntime(t *testing.T) {
+	rt := &TableRuntime{}
+	if _, ok := rt.variantLocked().(defaultVariant); !ok {
+		t.Fatalf("expected defaultVariant for a bare runtime, got %T", rt.variantLocked())
+	}
+}
+
+func TestHighCardEvaluateIgnoresPairs(t *testing.T) {
+	v := highCardVariant{}
+	pair := v.Evaluate([]string{"2s", "2d"})
+	aceHigh := v.Evaluate([]string{"As", "7h"})
+	if pair >= aceHigh {
+		t.Fatalf("expected the lone ace to beat the pair of twos, got %d >= %d", pair, aceHigh)
+	}
+	// The default evaluator ranks the same hands the other way around.
+	if EvaluateHand([]string{"2s", "2d"}) <= EvaluateHand([]string{"As", "7h"}) {
+		t.Fatal("expected the default evaluator to rank the pair above the ace")
+	}
+}
+
+// The same deal settles differently under the two rule sets, and the scene
+// name alone selects the demo variant: the extension point end to end.
+func TestHighCardVariantFlipsShowdown(t *testing.T) {
+	run := func(sceneName string) *SimulationResult {
+		t.Helper()
+		result, err := Simulate(SimulationConfig{
+			Scene: model.Scene{ID: 1, Name: sceneName, SeatCount: 2, BasePi: 10, MinUnitPi: 1},
+			Players: []SimulatedPlayer{
+				{UserID: 101, Chips: 500},
+				{UserID: 102, Chips: 500},
+			},
+			Seed: 1,
+			// Deal order alternates seats: seat 1 gets 2s+2d (a pair of
+			// twos), seat 2 gets As+7h (ace high).
+			Deck: []string{"2s", "As", "2d", "7h"},
+			Scripts: map[int][]SimulatedAction{
+				1: {{Action: "pass"}, {Action: "pass"}, {Action: "pass"}},
+				2: {{Action: "pass"}, {Action: "pass"}, {Action: "pass"}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("%s: simulation failed: %v", sceneName, err)
+		}
+		return result
+	}
+
+	netByUser := func(result *SimulationResult) map[int64]int64 {
+		nets := make(map[int64]int64, len(result.Results))
+		for _, r := range result.Results {
+			nets[r.UserID] = r.NetPoints
+		}
+		return nets
+	}
+
+	plain := netByUser(run("普通场"))
+	if plain[101] <= 0 || plain[102] >= 0 {
+		t.Fatalf("default rules: expected the pair to win, got %v", plain)
+	}
+
+	demo := netByUser(run("highcard demo"))
+	if demo[102] <= 0 || demo[101] >= 0 {
+		t.Fatalf("high-card rules: expected the ace to win, got %v", demo)
+	}
+	if demo[101]+demo[102] != 0 {
+		t.Fatalf("high-card rules: ledger does not net to zero: %v", demo)
+	}
+}
@@ -0,0 +1,171 @@
+// Package geoip resolves client IPs to country codes and applies the
+// compliance region blocklist. Lookups are cached per IP for a few minutes
+// and never block the request path: when the data source is unavailable the
+// check fails open and counts the miss for monitoring.
+package geoip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// cacheTTL bounds how long a resolved country is reused for the same IP.
+const cacheTTL = 5 * time.Minute
+
+// Resolver maps an IP address to an ISO 3166-1 alpha-2 country code.
+type Resolver interface {
+	Country(ip string) (string, error)
+}
+
+// FileResolver resolves from a JSON object of IP (or IP prefix) to country
+// code loaded once at startup. It stands in for a MaxMind database reader —
+// anything implementing Resolver plugs into the same config path.
+type FileResolver struct {
+	entries map[string]string
+}
+
+// NewFileResolver loads the mapping from path.
+func NewFileResolver(path string) (*FileResolver, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: read %s: %w", path, err)
+	}
+	entries := make(map[string]string)
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("geoip: parse %s: %w", path, err)
+	}
+	return &FileResolver{entries: entries}, nil
+}
+
+// Country returns the exact-IP entry if present, otherwise the longest
+// matching prefix entry.
+func (r *FileResolver) Country(ip string) (string, error) {
+	if country, ok := r.entries[ip]; ok {
+		return country, nil
+	}
+	best, bestLen := "", 0
+	for prefix, country := range r.entries {
+		if len(prefix) > bestLen && strings.HasPrefix(ip, prefix) {
+			best, bestLen = country, len(prefix)
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("geoip: no entry for %s", ip)
+	}
+	return best, nil
+}
+
+type cacheEntry struct {
+	country   string
+	expiresAt time.Time
+}
+
+// Service is the region gate consulted at login and queue join. A nil
+// resolver (no data source configured) disables lookups entirely.
+type Service struct {
+	resolver Resolver
+	blocked  map[string]struct{}
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	now   func() time.Time
+
+	// failOpens counts lookups that failed and were allowed through.
+	failOpens atomic.Int64
+}
+
+func NewService(resolver Resolver, blockedCountries []string) *Service {
+	blocked := make(map[string]struct{}, len(blockedCountries))
+	for _, code := range blockedCountries {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code != "" {
+			blocked[code] = struct{}{}
+		}
+	}
+	return &Service{
+		resolver: resolver,
+		blocked:  blocked,
+		cache:    make(map[string]cacheEntry),
+		now:      time.Now,
+	}
+}
+
+// NewFromConfig builds the service from the global config. A missing or
+// unreadable database file logs a warning and leaves lookups disabled rather
+// than failing startup.
+func NewFromConfig() *Service {
+	if config.GlobalConfig == nil {
+		return NewService(nil, nil)
+	}
+	geoCfg := config.GlobalConfig.Geo
+	var resolver Resolver
+	if geoCfg.DBPath != "" {
+		fileResolver, err := NewFileResolver(geoCfg.DBPath)
+		if err != nil {
+			logger.Log.Warn("geoip database unavailable, region checks disabled",
+				zap.String("path", geoCfg.DBPath),
+				zap.Error(err),
+			)
+		} else {
+			resolver = fileResolver
+		}
+	}
+	return NewService(resolver, geoCfg.BlockedCountries)
+}
+
+// Check resolves the country for ip and reports whether the blocklist bars
+// it. Resolver failures fail open: an unreachable data source must never
+// lock users out of the whole product.
+func (s *Service) Check(ctx context.Context, ip string) (country string, blocked bool) {
+	if s.resolver == nil || strings.TrimSpace(ip) == "" {
+		return "", false
+	}
+	country, err := s.lookup(ip)
+	if err != nil {
+		s.failOpens.Add(1)
+		logger.WithContext(ctx).Warn("geoip lookup failed, failing open",
+			zap.String("ip", ip),
+			zap.Error(err),
+		)
+		return "", false
+	}
+	_, blocked = s.blocked[country]
+	return country, blocked
+}
+
+// FailOpens reports how many lookups failed and were allowed through.
+func (s *Service) FailOpens() int64 {
+	return s.failOpens.Load()
+}
+
+func (s *Service) lookup(ip string) (string, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[ip]
+	if ok && s.now().Before(entry.expiresAt) {
+		s.mu.Unlock()
+		return entry.country, nil
+	}
+	s.mu.Unlock()
+
+	country, err := s.resolver.Country(ip)
+	if err != nil {
+		return "", err
+	}
+	country = strings.ToUpper(strings.TrimSpace(country))
+
+	s.mu.Lock()
+	s.cache[ip] = cacheEntry{country: country, expiresAt: s.now().Add(cacheTTL)}
+	s.mu.Unlock()
+	return country, nil
+}
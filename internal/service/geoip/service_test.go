@@ -0,0 +1,122 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+type fakeResolver struct {
+	countries map[string]string
+	err       error
+	calls     int
+}
+
+func (r *fakeResolver) Country(ip string) (string, error) {
+	r.calls++
+	if r.err != nil {
+		return "", r.err
+	}
+	country, ok := r.countries[ip]
+	if !ok {
+		return "", errors.New("no entry")
+	}
+	return country, nil
+}
+
+func newGeoTestService(t *testing.T, resolver Resolver, blocked ...string) *Service {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	return NewService(resolver, blocked)
+}
+
+func TestCheckBlockedCountry(t *testing.T) {
+	resolver := &fakeResolver{countries: map[string]string{"1.2.3.4": "XX"}}
+	s := newGeoTestService(t, resolver, "xx")
+
+	country, blocked := s.Check(context.Background(), "1.2.3.4")
+	if country != "XX" || !blocked {
+		t.Fatalf("expected XX blocked, got %q blocked=%v", country, blocked)
+	}
+}
+
+func TestCheckAllowedCountry(t *testing.T) {
+	resolver := &fakeResolver{countries: map[string]string{"1.2.3.4": "US"}}
+	s := newGeoTestService(t, resolver, "XX")
+
+	country, blocked := s.Check(context.Background(), "1.2.3.4")
+	if country != "US" || blocked {
+		t.Fatalf("expected US allowed, got %q blocked=%v", country, blocked)
+	}
+}
+
+func TestCheckFailsOpenOnLookupError(t *testing.T) {
+	resolver := &fakeResolver{err: errors.New("database offline")}
+	s := newGeoTestService(t, resolver, "XX")
+
+	country, blocked := s.Check(context.Background(), "1.2.3.4")
+	if country != "" || blocked {
+		t.Fatalf("lookup failure must fail open, got %q blocked=%v", country, blocked)
+	}
+	if s.FailOpens() != 1 {
+		t.Fatalf("expected one fail-open counted, got %d", s.FailOpens())
+	}
+}
+
+func TestCheckCachesLookupsPerIP(t *testing.T) {
+	resolver := &fakeResolver{countries: map[string]string{"1.2.3.4": "US"}}
+	s := newGeoTestService(t, resolver)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.Check(context.Background(), "1.2.3.4")
+	s.Check(context.Background(), "1.2.3.4")
+	if resolver.calls != 1 {
+		t.Fatalf("expected cached second lookup, resolver called %d times", resolver.calls)
+	}
+
+	now = now.Add(cacheTTL + time.Second)
+	s.Check(context.Background(), "1.2.3.4")
+	if resolver.calls != 2 {
+		t.Fatalf("expected fresh lookup past the TTL, resolver called %d times", resolver.calls)
+	}
+}
+
+func TestCheckWithoutResolverIsDisabled(t *testing.T) {
+	s := newGeoTestService(t, nil, "XX")
+	if country, blocked := s.Check(context.Background(), "1.2.3.4"); country != "" || blocked {
+		t.Fatalf("nil resolver must disable the gate, got %q blocked=%v", country, blocked)
+	}
+	if s.FailOpens() != 0 {
+		t.Fatalf("a disabled gate is not a fail-open, counted %d", s.FailOpens())
+	}
+}
+
+func TestFileResolverPrefixMatch(t *testing.T) {
+	path := t.TempDir() + "/geo.json"
+	if err := os.WriteFile(path, []byte(`{"1.2.3.4": "US", "10.0.": "XX"}`), 0o600); err != nil {
+		t.Fatalf("write mapping: %v", err)
+	}
+	resolver, err := NewFileResolver(path)
+	if err != nil {
+		t.Fatalf("load resolver: %v", err)
+	}
+
+	if country, err := resolver.Country("1.2.3.4"); err != nil || country != "US" {
+		t.Fatalf("exact match failed: %q %v", country, err)
+	}
+	if country, err := resolver.Country("10.0.0.7"); err != nil || country != "XX" {
+		t.Fatalf("prefix match failed: %q %v", country, err)
+	}
+	if _, err := resolver.Country("192.168.1.1"); err == nil {
+		t.Fatal("expected error for an unmapped IP")
+	}
+}
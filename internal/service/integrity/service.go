@@ -0,0 +1,274 @@
+package integrity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"dx-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Finding severities. Errors are conditions that corrupt settlement or
+// payouts; warnings are suspicious but survivable.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ratioEpsilon absorbs float noise when summing commission ratios.
+const ratioEpsilon = 1e-9
+
+// Finding is one integrity violation, pointing at the offending row.
+type Finding struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Target   string `json:"target"` // e.g. "scene:3"
+	Detail   string `json:"detail"`
+}
+
+// Report groups findings by severity. A run that finds nothing returns a
+// report whose Clean method is true.
+type Report struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	Errors    []Finding `json:"errors"`
+	Warnings  []Finding `json:"warnings"`
+}
+
+// Clean reports whether the run found no errors (warnings do not count).
+func (r *Report) Clean() bool {
+	return len(r.Errors) == 0
+}
+
+func (r *Report) add(findings ...Finding) {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			r.Errors = append(r.Errors, f)
+		} else {
+			r.Warnings = append(r.Warnings, f)
+		}
+	}
+}
+
+// Service runs read-only validations over the rules reference data and
+// wallet invariants. It never mutates anything.
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Run executes every check battery and returns the grouped findings.
+func (s *Service) Run(ctx context.Context) (*Report, error) {
+	report := &Report{CheckedAt: time.Now()}
+
+	var rakeRules []model.RakeRule
+	if err := s.db.WithContext(ctx).Find(&rakeRules).Error; err != nil {
+		return nil, err
+	}
+	rakeRuleIDs := make(map[int64]bool, len(rakeRules))
+	for _, rule := range rakeRules {
+		rakeRuleIDs[rule.ID] = true
+		report.add(CheckRakeRule(rule)...)
+	}
+
+	var scenes []model.Scene
+	if err := s.db.WithContext(ctx).Find(&scenes).Error; err != nil {
+		return nil, err
+	}
+	for _, scene := range scenes {
+		report.add(CheckScene(scene)...)
+		if scene.RakeRuleID != 0 && !rakeRuleIDs[scene.RakeRuleID] {
+			report.add(Finding{
+				Severity: SeverityError,
+				Check:    "scene_rake_rule",
+				Target:   target("scene", scene.ID),
+				Detail:   fmt.Sprintf("references missing rake rule %d", scene.RakeRuleID),
+			})
+		}
+	}
+
+	var agentRules []model.AgentRule
+	if err := s.db.WithContext(ctx).Find(&agentRules).Error; err != nil {
+		return nil, err
+	}
+	for _, rule := range agentRules {
+		report.add(CheckAgentRule(rule)...)
+	}
+
+	var wallets []model.Wallet
+	if err := s.db.WithContext(ctx).Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	for _, wallet := range wallets {
+		report.add(CheckWallet(wallet)...)
+	}
+
+	return report, nil
+}
+
+// CheckScene validates one scene's buy-in bounds and stake configuration.
+func CheckScene(scene model.Scene) []Finding {
+	var findings []Finding
+	tgt := target("scene", scene.ID)
+	if scene.MinIn > scene.MaxIn {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "scene_buyin_bounds",
+			Target:   tgt,
+			Detail:   fmt.Sprintf("minIn %d exceeds maxIn %d", scene.MinIn, scene.MaxIn),
+		})
+	}
+	if scene.BasePi <= 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "scene_base_pi",
+			Target:   tgt,
+			Detail:   fmt.Sprintf("basePi %d must be positive", scene.BasePi),
+		})
+	}
+	if scene.SeatCount < 2 {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "scene_seat_count",
+			Target:   tgt,
+			Detail:   fmt.Sprintf("seat count %d below minimum of 2", scene.SeatCount),
+		})
+	}
+	if scene.MinUnitPi <= 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Check:    "scene_min_unit_pi",
+			Target:   tgt,
+			Detail:   fmt.Sprintf("minUnitPi %d should be positive", scene.MinUnitPi),
+		})
+	}
+	if scene.RakeRuleID == 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Check:    "scene_rake_rule",
+			Target:   tgt,
+			Detail:   "no rake rule assigned",
+		})
+	}
+	return findings
+}
+
+// CheckRakeRule validates a rake rule's type and config payload.
+func CheckRakeRule(rule model.RakeRule) []Finding {
+	var findings []Finding
+	tgt := target("rake_rule", rule.ID)
+	switch rule.Type {
+	case "ratio", "fixed", "ladder":
+	default:
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "rake_rule_type",
+			Target:   tgt,
+			Detail:   fmt.Sprintf("unknown type %q", rule.Type),
+		})
+	}
+	if len(rule.ConfigJSON) == 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "rake_rule_config",
+			Target:   tgt,
+			Detail:   "empty config",
+		})
+	} else if !json.Valid(rule.ConfigJSON) {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "rake_rule_config",
+			Target:   tgt,
+			Detail:   "config is not valid JSON",
+		})
+	}
+	return findings
+}
+
+// CheckAgentRule validates the commission split: every ratio in [0,1] and
+// the level ratios plus the platform's own share never exceeding the whole.
+func CheckAgentRule(rule model.AgentRule) []Finding {
+	var findings []Finding
+	tgt := target("agent_rule", rule.ID)
+	if rule.MaxLevel <= 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "agent_rule_levels",
+			Target:   tgt,
+			Detail:   fmt.Sprintf("maxLevel %d must be positive", rule.MaxLevel),
+		})
+	}
+	if rule.BasePlatformRatio < 0 || rule.BasePlatformRatio > 1 {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "agent_rule_ratios",
+			Target:   tgt,
+			Detail:   fmt.Sprintf("basePlatformRatio %g outside [0,1]", rule.BasePlatformRatio),
+		})
+	}
+	var ratios map[string]float64
+	if err := json.Unmarshal(rule.LevelRatiosJSON, &ratios); err != nil {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "agent_rule_ratios",
+			Target:   tgt,
+			Detail:   fmt.Sprintf("levelRatiosJson unparseable: %v", err),
+		})
+		return findings
+	}
+	sum := rule.BasePlatformRatio
+	for level, ratio := range ratios {
+		if ratio < 0 || ratio > 1 {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Check:    "agent_rule_ratios",
+				Target:   tgt,
+				Detail:   fmt.Sprintf("level %s ratio %g outside [0,1]", level, ratio),
+			})
+		}
+		sum += ratio
+	}
+	if sum > 1+ratioEpsilon {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "agent_rule_ratios",
+			Target:   tgt,
+			Detail:   fmt.Sprintf("ratios sum to %g, exceeding the whole", sum),
+		})
+	}
+	return findings
+}
+
+// CheckWallet validates the balance invariants settlement depends on.
+func CheckWallet(wallet model.Wallet) []Finding {
+	var findings []Finding
+	tgt := target("wallet", wallet.UserID)
+	if wallet.BalanceTotal < 0 || wallet.BalanceAvailable < 0 || wallet.BalanceFrozen < 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "wallet_negative_balance",
+			Target:   tgt,
+			Detail: fmt.Sprintf("total %d available %d frozen %d",
+				wallet.BalanceTotal, wallet.BalanceAvailable, wallet.BalanceFrozen),
+		})
+	}
+	if wallet.BalanceTotal != wallet.BalanceAvailable+wallet.BalanceFrozen {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "wallet_balance_sum",
+			Target:   tgt,
+			Detail: fmt.Sprintf("total %d != available %d + frozen %d",
+				wallet.BalanceTotal, wallet.BalanceAvailable, wallet.BalanceFrozen),
+		})
+	}
+	return findings
+}
+
+func target(kind string, id int64) string {
+	return fmt.Sprintf("%s:%d", kind, id)
+}
@@ -0,0 +1,121 @@
+package integrity
+
+import (
+	"context"
+	"testing"
+
+	"dx-service/internal/model"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupIntegrityDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&model.Scene{}, &model.RakeRule{}, &model.AgentRule{}, &model.Wallet{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func findingCount(findings []Finding, check string) int {
+	n := 0
+	for _, f := range findings {
+		if f.Check == check {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRunReportsBrokenReferenceData(t *testing.T) {
+	db := setupIntegrityDB(t)
+
+	rule := model.RakeRule{Name: "standard", Type: "ratio", ConfigJSON: []byte(`{"ratio":0.05}`)}
+	if err := db.Create(&rule).Error; err != nil {
+		t.Fatalf("failed to seed rake rule: %v", err)
+	}
+	scenes := []model.Scene{
+		{Name: "ok", SeatCount: 4, MinIn: 100, MaxIn: 5000, BasePi: 10, MinUnitPi: 1, RakeRuleID: rule.ID},
+		{Name: "inverted", SeatCount: 4, MinIn: 5000, MaxIn: 100, BasePi: 10, MinUnitPi: 1, RakeRuleID: rule.ID},
+		{Name: "dangling", SeatCount: 4, MinIn: 100, MaxIn: 5000, BasePi: 10, MinUnitPi: 1, RakeRuleID: 999},
+	}
+	for i := range scenes {
+		if err := db.Create(&scenes[i]).Error; err != nil {
+			t.Fatalf("failed to seed scene: %v", err)
+		}
+	}
+	agentRule := model.AgentRule{
+		MaxLevel:          3,
+		LevelRatiosJSON:   []byte(`{"L1":0.4,"L2":0.3}`),
+		BasePlatformRatio: 0.6,
+	}
+	if err := db.Create(&agentRule).Error; err != nil {
+		t.Fatalf("failed to seed agent rule: %v", err)
+	}
+	wallet := model.Wallet{UserID: 7, BalanceTotal: 100, BalanceAvailable: 80, BalanceFrozen: 30}
+	if err := db.Create(&wallet).Error; err != nil {
+		t.Fatalf("failed to seed wallet: %v", err)
+	}
+
+	report, err := NewService(db).Run(context.Background())
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if report.Clean() {
+		t.Fatal("expected errors on broken reference data")
+	}
+	if got := findingCount(report.Errors, "scene_buyin_bounds"); got != 1 {
+		t.Fatalf("expected 1 buy-in bounds error, got %d", got)
+	}
+	if got := findingCount(report.Errors, "scene_rake_rule"); got != 1 {
+		t.Fatalf("expected 1 dangling rake rule error, got %d", got)
+	}
+	if got := findingCount(report.Errors, "agent_rule_ratios"); got != 1 {
+		t.Fatalf("expected 1 agent ratio error, got %d", got)
+	}
+	if got := findingCount(report.Errors, "wallet_balance_sum"); got != 1 {
+		t.Fatalf("expected 1 wallet sum error, got %d", got)
+	}
+}
+
+func TestRunCleanDataset(t *testing.T) {
+	db := setupIntegrityDB(t)
+
+	rule := model.RakeRule{Name: "standard", Type: "fixed", ConfigJSON: []byte(`{"amount":2}`)}
+	if err := db.Create(&rule).Error; err != nil {
+		t.Fatalf("failed to seed rake rule: %v", err)
+	}
+	scene := model.Scene{Name: "ok", SeatCount: 4, MinIn: 100, MaxIn: 5000, BasePi: 10, MinUnitPi: 1, RakeRuleID: rule.ID}
+	if err := db.Create(&scene).Error; err != nil {
+		t.Fatalf("failed to seed scene: %v", err)
+	}
+	wallet := model.Wallet{UserID: 7, BalanceTotal: 100, BalanceAvailable: 70, BalanceFrozen: 30}
+	if err := db.Create(&wallet).Error; err != nil {
+		t.Fatalf("failed to seed wallet: %v", err)
+	}
+
+	report, err := NewService(db).Run(context.Background())
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !report.Clean() {
+		t.Fatalf("expected clean report, got errors %+v", report.Errors)
+	}
+	if len(report.Warnings) != 0 {
+		t.Fatalf("expected no warnings, got %+v", report.Warnings)
+	}
+}
+
+func TestCheckWalletFlagsNegativeBalances(t *testing.T) {
+	findings := CheckWallet(model.Wallet{UserID: 9, BalanceTotal: -5, BalanceAvailable: -5})
+	if findingCount(findings, "wallet_negative_balance") != 1 {
+		t.Fatalf("expected negative balance finding, got %+v", findings)
+	}
+}
@@ -0,0 +1,171 @@
+package match
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newBatchTestService(t *testing.T) *Service {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Wallet{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return &Service{db: db, rdb: client, cfg: defaultConfig()}
+}
+
+func batchCandidate(userID, snapshot int64) queueMember {
+	return queueMember{
+		UserID:          userID,
+		SceneID:         7,
+		BuyIn:           100,
+		BalanceSnapshot: snapshot,
+		JoinedAt:        time.Now(),
+	}
+}
+
+func TestLoadQueueMembersBatchMatchesSequential(t *testing.T) {
+	s := newBatchTestService(t)
+	ctx := context.Background()
+
+	userIDs := make([]int64, 0, 5)
+	for id := int64(1); id <= 5; id++ {
+		if err := s.saveQueueMember(ctx, batchCandidate(id, 1000)); err != nil {
+			t.Fatalf("save member: %v", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+	// One expired member key must simply be skipped by both paths.
+	userIDs = append(userIDs, 99)
+
+	batched, err := s.loadQueueMembers(ctx, 7, userIDs)
+	if err != nil {
+		t.Fatalf("batched load: %v", err)
+	}
+
+	sequential := make([]queueMember, 0, len(userIDs))
+	for _, userID := range userIDs {
+		member, err := s.loadQueueMember(ctx, 7, userID)
+		if err == errQueueMemberNotFound {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("sequential load: %v", err)
+		}
+		sequential = append(sequential, member)
+	}
+
+	if len(batched) != len(sequential) {
+		t.Fatalf("batched %d members, sequential %d", len(batched), len(sequential))
+	}
+	for i := range batched {
+		if batched[i].UserID != sequential[i].UserID {
+			t.Fatalf("order mismatch at %d: %d vs %d", i, batched[i].UserID, sequential[i].UserID)
+		}
+	}
+}
+
+func TestSelectPlayersUsesBatchedBalances(t *testing.T) {
+	s := newBatchTestService(t)
+	ctx := context.Background()
+
+	scene := model.Scene{ID: 7, SeatCount: 2, MinIn: 500}
+	candidates := []queueMember{
+		batchCandidate(1, 1000),
+		batchCandidate(2, 1000),
+		batchCandidate(3, 1000),
+	}
+	wallets := []model.Wallet{
+		{UserID: 1, BalanceAvailable: 1000},
+		{UserID: 2, BalanceAvailable: 100}, // spent down since joining
+		{UserID: 3, BalanceAvailable: 800},
+	}
+	if err := s.db.Create(&wallets).Error; err != nil {
+		t.Fatalf("seed wallets: %v", err)
+	}
+
+	balances, err := s.loadCandidateWallets(ctx, scene, candidates)
+	if err != nil {
+		t.Fatalf("load wallets: %v", err)
+	}
+	if len(balances) != 3 {
+		t.Fatalf("expected 3 balances, got %d", len(balances))
+	}
+
+	selected := s.selectPlayers(ctx, scene, candidates, balances)
+	if len(selected) != 2 || selected[0].UserID != 1 || selected[1].UserID != 3 {
+		t.Fatalf("expected users 1 and 3 selected, got %+v", selected)
+	}
+
+	// With no wallet map the snapshot path selects the first two, matching
+	// the behavior before the batched re-check.
+	fallback := s.selectPlayers(ctx, scene, candidates, nil)
+	if len(fallback) != 2 || fallback[0].UserID != 1 || fallback[1].UserID != 2 {
+		t.Fatalf("expected snapshot fallback to select users 1 and 2, got %+v", fallback)
+	}
+}
+
+func newBenchService(b *testing.B) *Service {
+	b.Helper()
+	mr := miniredis.RunT(b)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return &Service{rdb: client, cfg: defaultConfig()}
+}
+
+func seedBenchMembers(b *testing.B, s *Service, n int) []int64 {
+	b.Helper()
+	ctx := context.Background()
+	userIDs := make([]int64, 0, n)
+	for id := int64(1); id <= int64(n); id++ {
+		if err := s.saveQueueMember(ctx, batchCandidate(id, 1000)); err != nil {
+			b.Fatalf("save member: %v", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs
+}
+
+// The pair below contrasts the per-candidate GET loop tryCompose used to run
+// every tick with the single MGET that replaced it.
+func BenchmarkLoadQueueMembersSequential(b *testing.B) {
+	s := newBenchService(b)
+	userIDs := seedBenchMembers(b, s, 50)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, userID := range userIDs {
+			if _, err := s.loadQueueMember(ctx, 7, userID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkLoadQueueMembersBatched(b *testing.B) {
+	s := newBenchService(b)
+	userIDs := seedBenchMembers(b, s, 50)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.loadQueueMembers(ctx, 7, userIDs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
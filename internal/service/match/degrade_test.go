@@ -0,0 +1,115 @@
+package match
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/breaker"
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newDegradeTestService keeps a handle on miniredis so tests can flip it into
+// a failing state and back.
+func newDegradeTestService(t *testing.T) (*Service, *miniredis.Miniredis) {
+	t.Helper()
+
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Scene{}, &model.Table{}, &model.Match{}, &model.Wallet{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	s := &Service{db: db, rdb: client, cfg: defaultConfig()}
+	s.SetBreaker(breaker.New("queueing", breaker.Config{
+		MinCalls:     1,
+		FailureRatio: 0.5,
+		Cooldown:     50 * time.Millisecond,
+	}))
+	return s, mr
+}
+
+func TestQueueingDegradesAndRecoversAcrossRedisOutage(t *testing.T) {
+	s, mr := newDegradeTestService(t)
+	ctx := context.Background()
+
+	scene := queueTestScene(t, s, 1)
+	wallet := model.Wallet{UserID: 1, BalanceTotal: 10000, BalanceAvailable: 10000}
+	if err := s.db.Create(&wallet).Error; err != nil {
+		t.Fatalf("failed to seed wallet: %v", err)
+	}
+	// User 2 sits at a live table so the degraded status has a DB fact to
+	// report.
+	table := model.Table{
+		SceneID:     scene.ID,
+		Status:      "playing",
+		SeatCount:   2,
+		PlayersJSON: []byte(`{"1":{"userId":2},"2":{"userId":3}}`),
+	}
+	if err := s.db.Create(&table).Error; err != nil {
+		t.Fatalf("failed to seed table: %v", err)
+	}
+	match := model.Match{TableID: table.ID, SceneID: scene.ID}
+	if err := s.db.Create(&match).Error; err != nil {
+		t.Fatalf("failed to seed match: %v", err)
+	}
+
+	// The first failing call surfaces raw and opens the breaker.
+	mr.SetError("boom")
+	if _, err := s.JoinQueue(ctx, JoinQueueRequest{UserID: 1, SceneID: scene.ID, BuyIn: 100}); err == nil {
+		t.Fatal("expected the Redis failure surfaced")
+	}
+	if got := s.breaker.State(); got != breaker.StateOpen {
+		t.Fatalf("expected the breaker open, got %s", got)
+	}
+
+	// Open breaker: joins shed with the business error, status answers from
+	// the DB.
+	if _, err := s.JoinQueue(ctx, JoinQueueRequest{UserID: 1, SceneID: scene.ID, BuyIn: 100}); err != appErr.ErrMatchmakingUnavailable {
+		t.Fatalf("expected ErrMatchmakingUnavailable while open, got %v", err)
+	}
+	status, err := s.GetStatus(ctx, 2, 0)
+	if err != nil {
+		t.Fatalf("degraded status failed: %v", err)
+	}
+	if !status.Degraded || status.Status != QueueStatusMatched {
+		t.Fatalf("expected degraded matched status, got %+v", status)
+	}
+	if status.TableID == nil || *status.TableID != table.ID || status.MatchID == nil || *status.MatchID != match.ID {
+		t.Fatalf("expected the live table and match reported, got %+v", status)
+	}
+	idle, err := s.GetStatus(ctx, 1, scene.ID)
+	if err != nil || !idle.Degraded || idle.Status != QueueStatusIdle {
+		t.Fatalf("expected degraded idle status, got %+v err %v", idle, err)
+	}
+
+	// Redis recovers; the half-open probe closes the breaker and joins work
+	// again.
+	mr.SetError("")
+	time.Sleep(60 * time.Millisecond)
+	if _, err := s.GetStatus(ctx, 1, scene.ID); err != nil {
+		t.Fatalf("probe status failed: %v", err)
+	}
+	if got := s.breaker.State(); got != breaker.StateClosed {
+		t.Fatalf("expected the breaker closed after recovery, got %s", got)
+	}
+	if _, err := s.JoinQueue(ctx, JoinQueueRequest{UserID: 1, SceneID: scene.ID, BuyIn: 100}); err != nil {
+		t.Fatalf("join after recovery failed: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package match
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"dx-service/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newDriftTestService(t *testing.T) *Service {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	return newQueueTestService(t)
+}
+
+func TestSweepCancelsGhostZSetEntries(t *testing.T) {
+	s := newDriftTestService(t)
+	ctx := context.Background()
+
+	// A ZSET entry without a member key: the member hash TTL expired.
+	if err := s.rdb.ZAdd(ctx, buildQueueKey(7), redis.Z{
+		Score:  float64(time.Now().UnixMilli()),
+		Member: "1",
+	}).Err(); err != nil {
+		t.Fatalf("zadd ghost: %v", err)
+	}
+	s.rdb.SAdd(ctx, buildUserQueuesKey(1), "7")
+
+	// A healthy entry must survive the sweep.
+	healthy := queueMember{UserID: 2, SceneID: 7, Score: float64(time.Now().UnixMilli())}
+	if err := s.saveQueueMember(ctx, healthy); err != nil {
+		t.Fatalf("save healthy member: %v", err)
+	}
+	if err := s.rdb.ZAdd(ctx, buildQueueKey(7), redis.Z{
+		Score:  healthy.effectiveScore(),
+		Member: "2",
+	}).Err(); err != nil {
+		t.Fatalf("zadd healthy: %v", err)
+	}
+
+	if err := s.sweepQueueDrift(ctx, 7); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+
+	if _, err := s.rdb.ZScore(ctx, buildQueueKey(7), "1").Result(); err != redis.Nil {
+		t.Fatalf("expected ghost entry removed, got err=%v", err)
+	}
+	if _, err := s.rdb.ZScore(ctx, buildQueueKey(7), "2").Result(); err != nil {
+		t.Fatalf("expected healthy entry untouched: %v", err)
+	}
+	// GetStatus must reflect reality: the ghost user is idle again.
+	if members, _ := s.rdb.SMembers(ctx, buildUserQueuesKey(1)).Result(); len(members) != 0 {
+		t.Fatalf("expected reverse index cleared for ghost, got %v", members)
+	}
+}
+
+func TestSweepDeletesOrphanMemberKeys(t *testing.T) {
+	s := newDriftTestService(t)
+	ctx := context.Background()
+
+	// A member key with no ZSET entry: the leftover of a failed ZRem.
+	orphan := queueMember{UserID: 3, SceneID: 7, Score: float64(time.Now().UnixMilli())}
+	if err := s.saveQueueMember(ctx, orphan); err != nil {
+		t.Fatalf("save orphan member: %v", err)
+	}
+
+	if err := s.sweepQueueDrift(ctx, 7); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+
+	exists, err := s.rdb.Exists(ctx, buildQueueMemberKey(7, 3)).Result()
+	if err != nil {
+		t.Fatalf("exists: %v", err)
+	}
+	if exists != 0 {
+		t.Fatal("expected orphan member key deleted")
+	}
+}
+
+func TestCleanupExpiredQueueRunsDriftSweep(t *testing.T) {
+	s := newDriftTestService(t)
+	ctx := context.Background()
+
+	if err := s.rdb.ZAdd(ctx, buildQueueKey(7), redis.Z{
+		Score:  float64(time.Now().UnixMilli()),
+		Member: strconv.FormatInt(9, 10),
+	}).Err(); err != nil {
+		t.Fatalf("zadd: %v", err)
+	}
+
+	if err := s.cleanupExpiredQueue(ctx, 7); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if _, err := s.rdb.ZScore(ctx, buildQueueKey(7), "9").Result(); err != redis.Nil {
+		t.Fatalf("expected ghost removed by cleanup, got err=%v", err)
+	}
+}
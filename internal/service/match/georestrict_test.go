@@ -0,0 +1,61 @@
+package match
+
+import (
+	"context"
+	"testing"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+)
+
+// blockAllChecker flags every IP as coming from a blocked region.
+type blockAllChecker struct{}
+
+func (blockAllChecker) Check(ctx context.Context, ip string) (string, bool) {
+	return "XX", true
+}
+
+func newGeoRestrictTestService(t *testing.T) *Service {
+	t.Helper()
+	s := newMultiQueueTestService(t)
+	if err := s.db.AutoMigrate(&model.Wallet{}, &model.User{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	s.geo = blockAllChecker{}
+	return s
+}
+
+func TestJoinQueueBlockedRegion(t *testing.T) {
+	s := newGeoRestrictTestService(t)
+	queueTestScene(t, s, 1)
+	user := model.User{ID: 1, Phone: "13800000001", Status: "normal"}
+	if err := s.db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+
+	_, err := s.JoinQueue(context.Background(), JoinQueueRequest{
+		UserID: 1, SceneID: 1, BuyIn: 100, IP: "1.2.3.4",
+	})
+	if err != appErr.ErrRegionBlocked {
+		t.Fatalf("expected ErrRegionBlocked, got %v", err)
+	}
+}
+
+func TestJoinQueueGeoBypassOverridesBlock(t *testing.T) {
+	s := newGeoRestrictTestService(t)
+	queueTestScene(t, s, 1)
+	user := model.User{ID: 1, Phone: "13800000001", Status: "normal", GeoBypass: true}
+	if err := s.db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+	wallet := model.Wallet{UserID: 1, BalanceTotal: 10000, BalanceAvailable: 10000}
+	if err := s.db.Create(&wallet).Error; err != nil {
+		t.Fatalf("failed to seed wallet: %v", err)
+	}
+
+	if _, err := s.JoinQueue(context.Background(), JoinQueueRequest{
+		UserID: 1, SceneID: 1, BuyIn: 100, IP: "1.2.3.4",
+	}); err != nil {
+		t.Fatalf("admin bypass must override the region block: %v", err)
+	}
+}
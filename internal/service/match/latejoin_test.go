@@ -0,0 +1,127 @@
+package match
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type seatCall struct {
+	tableID int64
+	userID  int64
+	chips   int64
+}
+
+type stubSeater struct {
+	matchID int64
+	err     error
+	calls   []seatCall
+}
+
+func (s *stubSeater) SeatLatePlayer(_ context.Context, tableID, userID, chips int64) (int64, error) {
+	s.calls = append(s.calls, seatCall{tableID: tableID, userID: userID, chips: chips})
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.matchID, nil
+}
+
+// One member waits in a 3-seat scene that cannot fill a fresh table. The
+// tick must hand them to the waiting table with a free seat, skipping the
+// full one, and leave the usual matched markers behind.
+func TestTryComposeTopsUpWaitingTable(t *testing.T) {
+	s := newMultiQueueTestService(t)
+	ctx := context.Background()
+
+	scene := model.Scene{ID: 7, Name: "late", SeatCount: 3}
+	if err := s.db.Create(&scene).Error; err != nil {
+		t.Fatalf("failed to seed scene: %v", err)
+	}
+	full := model.Table{
+		SceneID:     scene.ID,
+		Status:      "waiting",
+		SeatCount:   3,
+		PlayersJSON: []byte(`{"1":{"userId":101},"2":{"userId":102},"3":{"userId":103}}`),
+	}
+	if err := s.db.Create(&full).Error; err != nil {
+		t.Fatalf("failed to seed full table: %v", err)
+	}
+	open := model.Table{
+		SceneID:     scene.ID,
+		Status:      "waiting",
+		SeatCount:   3,
+		PlayersJSON: []byte(`{"1":{"userId":201},"2":{"userId":202}}`),
+	}
+	if err := s.db.Create(&open).Error; err != nil {
+		t.Fatalf("failed to seed open table: %v", err)
+	}
+
+	enqueueMember(t, s, scene.ID, 9)
+	seater := &stubSeater{matchID: 55}
+	s.SetTableSeater(seater)
+
+	if err := s.tryCompose(ctx, scene); err != nil {
+		t.Fatalf("tryCompose failed: %v", err)
+	}
+
+	if len(seater.calls) != 1 {
+		t.Fatalf("expected one seat call, got %d", len(seater.calls))
+	}
+	call := seater.calls[0]
+	if call.tableID != open.ID || call.userID != 9 || call.chips != 100 {
+		t.Fatalf("unexpected seat call: %+v", call)
+	}
+	if _, err := s.rdb.ZScore(ctx, buildQueueKey(scene.ID), "9").Result(); err != redis.Nil {
+		t.Fatalf("expected seated user removed from queue, got err=%v", err)
+	}
+	if _, err := s.rdb.Get(ctx, buildMatchedLockKey(9)).Result(); err != nil {
+		t.Fatalf("expected matched lock held: %v", err)
+	}
+	notify, err := s.rdb.Get(ctx, buildMatchNotifyKey(9)).Result()
+	if err != nil {
+		t.Fatalf("expected matched notification: %v", err)
+	}
+	if !strings.Contains(notify, `"matchId":55`) {
+		t.Fatalf("notification missing the live match, got %s", notify)
+	}
+}
+
+// A runtime refusal (hand already dealing, seat raced away) must put the
+// player back in the queue with their lock released so the next tick retries.
+func TestTopUpRefusalRequeuesPlayer(t *testing.T) {
+	s := newMultiQueueTestService(t)
+	ctx := context.Background()
+
+	scene := model.Scene{ID: 7, Name: "late", SeatCount: 3}
+	if err := s.db.Create(&scene).Error; err != nil {
+		t.Fatalf("failed to seed scene: %v", err)
+	}
+	table := model.Table{
+		SceneID:     scene.ID,
+		Status:      "playing",
+		SeatCount:   3,
+		PlayersJSON: []byte(`{"1":{"userId":201},"2":{"userId":202}}`),
+	}
+	if err := s.db.Create(&table).Error; err != nil {
+		t.Fatalf("failed to seed table: %v", err)
+	}
+
+	enqueueMember(t, s, scene.ID, 9)
+	s.SetTableSeater(&stubSeater{err: appErr.ErrTableMidHand})
+
+	if err := s.tryCompose(ctx, scene); err != nil {
+		t.Fatalf("tryCompose must swallow the refusal, got %v", err)
+	}
+
+	if _, err := s.rdb.ZScore(ctx, buildQueueKey(scene.ID), "9").Result(); err != nil {
+		t.Fatalf("expected refused user requeued: %v", err)
+	}
+	if _, err := s.rdb.Get(ctx, buildMatchedLockKey(9)).Result(); err != redis.Nil {
+		t.Fatalf("expected matched lock released, got err=%v", err)
+	}
+}
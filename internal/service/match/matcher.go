@@ -9,10 +9,17 @@ import (
 
 	"dx-service/internal/config"
 	"dx-service/internal/model"
+	"dx-service/internal/service/features"
+	scenesvc "dx-service/internal/service/scene"
+	"dx-service/internal/tracing"
 	"dx-service/pkg/logger"
 	"dx-service/pkg/utils/geo"
 	netutil "dx-service/pkg/utils/net"
 
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
@@ -34,22 +41,40 @@ func (s *Service) runMatcher(ctx context.Context, scene model.Scene) {
 			logger.Log.Info("matcher stopped", zap.Int64("sceneID", scene.ID))
 			return
 		case <-ticker.C:
-			if err := s.tryCompose(ctx, scene); err != nil {
-				logger.Log.Warn("matcher compose error",
+			// Each tick is its own root span: there is no inbound request to parent it.
+			tickCtx, span := tracing.Tracer().Start(ctx, "matcher.tick",
+				trace.WithNewRoot(),
+				trace.WithAttributes(attribute.Int64("scene.id", scene.ID)),
+			)
+			if err := s.tryCompose(tickCtx, scene); err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				logger.WithContext(tickCtx).Warn("matcher compose error",
 					zap.Int64("sceneID", scene.ID),
 					zap.Error(err),
 				)
 			}
+			span.End()
 		}
 	}
 }
 
 func (s *Service) tryCompose(ctx context.Context, scene model.Scene) error {
-	if err := s.cleanupExpiredQueue(ctx, scene.ID); err != nil {
-		logger.Log.Warn("queue cleanup error",
-			zap.Int64("sceneID", scene.ID),
-			zap.Error(err),
-		)
+	ctx, span := tracing.Tracer().Start(ctx, "match.tryCompose",
+		trace.WithAttributes(attribute.Int64("scene.id", scene.ID)),
+	)
+	defer span.End()
+
+	// Outside open hours no tables are composed; anyone still waiting is
+	// drained. Live tables are untouched and finish on their own.
+	if open, _ := scenesvc.OpenState(scene, time.Now()); !open {
+		return s.drainQueue(ctx, scene.ID, "scene_closed")
+	}
+
+	// Seating players at a table no runtime can host only strands them;
+	// leave them queued until capacity frees up.
+	if s.capacity != nil && s.capacity.AtRuntimeCapacity() {
+		return nil
 	}
 
 	queueKey := buildQueueKey(scene.ID)
@@ -61,35 +86,61 @@ func (s *Service) tryCompose(ctx context.Context, scene model.Scene) error {
 	if err != nil {
 		return err
 	}
-	if len(members) < scene.SeatCount {
+	if len(members) == 0 {
 		return nil
 	}
 
-	candidates := make([]queueMember, 0, len(members))
+	userIDs := make([]int64, 0, len(members))
 	for _, member := range members {
 		userID, err := strconv.ParseInt(member, 10, 64)
 		if err != nil {
 			continue
 		}
-		qm, err := s.loadQueueMember(ctx, scene.ID, userID)
-		if err != nil {
-			if err == errQueueMemberNotFound {
-				continue
-			}
-			return err
-		}
-		candidates = append(candidates, qm)
+		userIDs = append(userIDs, userID)
+	}
+	candidates, err := s.loadQueueMembers(ctx, scene.ID, userIDs)
+	if err != nil {
+		return err
 	}
 
-	selected := s.selectPlayers(scene, candidates)
-	if len(selected) < scene.SeatCount {
-		return nil
+	balances, err := s.loadCandidateWallets(ctx, scene, candidates)
+	if err != nil {
+		// Selection falls back to the join-time snapshots; a degraded
+		// balance check beats skipping the tick.
+		logger.WithContext(ctx).Warn("candidate wallet batch load failed",
+			zap.Int64("sceneID", scene.ID),
+			zap.Error(err),
+		)
+		balances = nil
+	}
+
+	selected := s.selectPlayers(ctx, scene, candidates, balances)
+	if len(selected) >= scene.SeatCount {
+		return s.composeTable(ctx, scene, selected)
 	}
 
-	return s.composeTable(ctx, scene, selected)
+	// Too few for a fresh table; offer the leftovers to tables that still
+	// have free seats instead of leaving them to wait for a full complement.
+	return s.topUpTables(ctx, scene, selected)
 }
 
-func (s *Service) selectPlayers(scene model.Scene, candidates []queueMember) []queueMember {
+// Skip reasons recorded on queue members when selectPlayers passes them over.
+const (
+	skipReasonLowBalance = "low_balance"
+	skipReasonNoLocation = "no_location"
+	skipReasonTooClose   = "too_close"
+	skipReasonSubnet     = "subnet_conflict"
+
+	// skipReasonWriteInterval rate-limits the best-effort member writes;
+	// the matcher ticks far more often than the reason usefully changes.
+	skipReasonWriteInterval = 15 * time.Second
+)
+
+// selectPlayers picks the first seatCount candidates passing every filter.
+// Balances come from the pre-fetched wallet map; candidates missing from it
+// fall back to their join-time snapshot. Skipped candidates get the reason
+// recorded on their member hash so GetStatus can explain the wait.
+func (s *Service) selectPlayers(ctx context.Context, scene model.Scene, candidates []queueMember, balances map[int64]int64) []queueMember {
 	required := scene.SeatCount
 	selected := make([]queueMember, 0, required)
 
@@ -97,16 +148,23 @@ func (s *Service) selectPlayers(scene model.Scene, candidates []queueMember) []q
 		if len(selected) >= required {
 			break
 		}
-		if candidate.BalanceSnapshot < scene.MinIn {
-			continue
+		balance, ok := balances[candidate.UserID]
+		if !ok {
+			balance = candidate.BalanceSnapshot
 		}
-		if s.shouldEnforceLocation(scene) && !hasValidLocation(candidate) {
-			continue
+		reason := ""
+		switch {
+		case balance < scene.MinIn:
+			reason = skipReasonLowBalance
+		case s.shouldEnforceLocation(scene) && !hasValidLocation(candidate):
+			reason = skipReasonNoLocation
+		case !s.passesDistance(scene, selected, candidate):
+			reason = skipReasonTooClose
+		case !passesNetwork(selected, candidate):
+			reason = skipReasonSubnet
 		}
-		if !s.passesDistance(scene, selected, candidate) {
-			continue
-		}
-		if !passesNetwork(selected, candidate) {
+		if reason != "" {
+			s.recordSkipReason(ctx, candidate, reason)
 			continue
 		}
 		selected = append(selected, candidate)
@@ -114,6 +172,25 @@ func (s *Service) selectPlayers(scene model.Scene, candidates []queueMember) []q
 	return selected
 }
 
+// recordSkipReason stores the latest skip reason on the member hash. The
+// write is best-effort and suppressed while the reason is unchanged within
+// skipReasonWriteInterval to avoid Redis churn every tick.
+func (s *Service) recordSkipReason(ctx context.Context, member queueMember, reason string) {
+	now := time.Now().Unix()
+	if member.LastSkipReason == reason && now-member.LastSkipAt < int64(skipReasonWriteInterval/time.Second) {
+		return
+	}
+	member.LastSkipReason = reason
+	member.LastSkipAt = now
+	if err := s.saveQueueMember(ctx, member); err != nil {
+		logger.WithContext(ctx).Debug("skip reason write failed",
+			zap.Int64("userID", member.UserID),
+			zap.Int64("sceneID", member.SceneID),
+			zap.Error(err),
+		)
+	}
+}
+
 func hasValidLocation(member queueMember) bool {
 	return member.GPSLat != 0 && member.GPSLng != 0
 }
@@ -150,6 +227,10 @@ func (s *Service) shouldEnforceLocation(scene model.Scene) bool {
 	if scene.DistanceThresholdM <= 0 {
 		return false
 	}
+	if s.features != nil {
+		// The flag's config fallback covers features.skipLocationValidation.
+		return !s.features.Enabled(context.Background(), features.FlagSkipLocationValidation, features.Subject{SceneID: scene.ID})
+	}
 	if config.GlobalConfig != nil && config.GlobalConfig.Features.SkipLocationValidation {
 		return false
 	}
@@ -157,25 +238,67 @@ func (s *Service) shouldEnforceLocation(scene model.Scene) bool {
 }
 
 func (s *Service) composeTable(ctx context.Context, scene model.Scene, players []queueMember) error {
+	ctx, span := tracing.Tracer().Start(ctx, "match.composeTable",
+		trace.WithAttributes(
+			attribute.Int64("scene.id", scene.ID),
+			attribute.Int("players", len(players)),
+		),
+	)
+	defer span.End()
+
+	// First-match-wins: claim every player with a SetNX matched lock before
+	// touching the queues, so two scene matchers can never seat the same
+	// user in the same tick.
+	lockedUsers := make([]int64, 0, len(players))
+	releaseLocks := func() {
+		for _, userID := range lockedUsers {
+			s.rdb.Del(ctx, buildMatchedLockKey(userID))
+		}
+	}
+	for _, player := range players {
+		gotLock, err := s.rdb.SetNX(ctx, buildMatchedLockKey(player.UserID), scene.ID, s.cfg.MatchedLockTTL).Result()
+		if err != nil {
+			releaseLocks()
+			return err
+		}
+		if !gotLock {
+			// Another scene is seating this user right now.
+			releaseLocks()
+			return nil
+		}
+		lockedUsers = append(lockedUsers, player.UserID)
+	}
+
 	queueKey := buildQueueKey(scene.ID)
+	removedPlayers := make([]queueMember, 0, len(players))
 	for _, player := range players {
 		memberID := strconv.FormatInt(player.UserID, 10)
 		removed, err := s.rdb.ZRem(ctx, queueKey, memberID).Result()
 		if err != nil {
+			s.requeueMembers(ctx, scene.ID, removedPlayers)
+			releaseLocks()
 			return err
 		}
 		if removed == 0 {
+			s.requeueMembers(ctx, scene.ID, removedPlayers)
+			releaseLocks()
 			return nil
 		}
+		removedPlayers = append(removedPlayers, player)
 		s.removeQueueMember(ctx, scene.ID, player.UserID)
-		s.rdb.Set(ctx, buildQueueLockKey(player.UserID), scene.ID, s.cfg.MatchedLockTTL)
 	}
 
 	tableID, matchID, err := s.createTableAndMatch(ctx, scene, players)
 	if err != nil {
+		s.requeueMembers(ctx, scene.ID, removedPlayers)
+		releaseLocks()
 		return err
 	}
 
+	for _, player := range players {
+		s.cancelOtherQueues(ctx, player.UserID, scene.ID)
+	}
+
 	payload := matchNotifyPayload{
 		SceneID: scene.ID,
 		TableID: tableID,
@@ -186,7 +309,7 @@ func (s *Service) composeTable(ctx context.Context, scene model.Scene, players [
 		s.rdb.Set(ctx, buildMatchNotifyKey(player.UserID), data, s.cfg.MatchedNotifyTTL)
 	}
 
-	logger.Log.Info("match composed",
+	logger.WithContext(ctx).Info("match composed",
 		zap.Int64("sceneID", scene.ID),
 		zap.Int64("tableID", tableID),
 		zap.Int64("matchID", matchID),
@@ -195,6 +318,201 @@ func (s *Service) composeTable(ctx context.Context, scene model.Scene, players [
 	return nil
 }
 
+// topUpTables seats leftover queue members into scene tables that still have
+// free seats: waiting tables, and playing tables between hands. The runtime
+// owns the real guard — it rejects the insert mid-hand or once full — so a
+// refused player just goes back to the queue for the next tick. The pairwise
+// distance and subnet filters cannot rerun against occupants who left the
+// queue long ago; they only apply among this tick's joiners.
+func (s *Service) topUpTables(ctx context.Context, scene model.Scene, players []queueMember) error {
+	if s.seater == nil || len(players) == 0 {
+		return nil
+	}
+
+	var tables []model.Table
+	if err := s.db.WithContext(ctx).
+		Where("scene_id = ? AND status IN ?", scene.ID, []string{"waiting", "playing"}).
+		Order("id").
+		Find(&tables).Error; err != nil {
+		return err
+	}
+
+	next := 0
+	for _, table := range tables {
+		free := freeSeats(table)
+		for free > 0 && next < len(players) {
+			seated, err := s.seatLatePlayer(ctx, scene, table.ID, players[next])
+			if err != nil {
+				// Mid-hand or full right now; retry against this table once
+				// the runtime's state has moved on.
+				break
+			}
+			next++
+			if seated {
+				free--
+			}
+		}
+		if next >= len(players) {
+			break
+		}
+	}
+	return nil
+}
+
+// freeSeats counts the open seats on a table row; an unreadable players
+// document counts as full so the top-up never touches it.
+func freeSeats(table model.Table) int {
+	var seats map[string]json.RawMessage
+	if err := json.Unmarshal(table.PlayersJSON, &seats); err != nil {
+		return 0
+	}
+	free := table.SeatCount - len(seats)
+	if free < 0 {
+		return 0
+	}
+	return free
+}
+
+// seatLatePlayer claims one queued player and hands them to the live table.
+// It mirrors composeTable's ordering — matched lock, queue removal, then the
+// irreversible step — so any failure rolls the player back into the queue.
+// A false return without error means the player was skipped (another scene
+// holds their matched lock), not that the table refused them.
+func (s *Service) seatLatePlayer(ctx context.Context, scene model.Scene, tableID int64, player queueMember) (bool, error) {
+	lockKey := buildMatchedLockKey(player.UserID)
+	gotLock, err := s.rdb.SetNX(ctx, lockKey, scene.ID, s.cfg.MatchedLockTTL).Result()
+	if err != nil {
+		return false, err
+	}
+	if !gotLock {
+		// Another scene is seating this user right now.
+		return false, nil
+	}
+
+	memberID := strconv.FormatInt(player.UserID, 10)
+	removed, err := s.rdb.ZRem(ctx, buildQueueKey(scene.ID), memberID).Result()
+	if err != nil || removed == 0 {
+		s.rdb.Del(ctx, lockKey)
+		return false, err
+	}
+	s.removeQueueMember(ctx, scene.ID, player.UserID)
+
+	matchID, err := s.seater.SeatLatePlayer(ctx, tableID, player.UserID, player.BuyIn)
+	if err != nil {
+		s.requeueMembers(ctx, scene.ID, []queueMember{player})
+		s.rdb.Del(ctx, lockKey)
+		return false, err
+	}
+
+	s.cancelOtherQueues(ctx, player.UserID, scene.ID)
+	payload := matchNotifyPayload{
+		SceneID: scene.ID,
+		TableID: tableID,
+		MatchID: matchID,
+	}
+	data, _ := json.Marshal(payload)
+	s.rdb.Set(ctx, buildMatchNotifyKey(player.UserID), data, s.cfg.MatchedNotifyTTL)
+
+	logger.WithContext(ctx).Info("late joiner seated",
+		zap.Int64("sceneID", scene.ID),
+		zap.Int64("tableID", tableID),
+		zap.Int64("userID", player.UserID),
+	)
+	return true, nil
+}
+
+// queueCompensationOffsetMS is subtracted from a member's effective score each
+// time a compose rollback sends them back to the queue, so they beat anyone
+// who joined at the same instant on the next pass.
+const queueCompensationOffsetMS = 5
+
+// requeueMembers puts players removed by a failed compose back into the queue
+// at their original score rather than the back of the ZSET, with a small
+// compensation offset so they are picked first next time.
+func (s *Service) requeueMembers(ctx context.Context, sceneID int64, members []queueMember) {
+	queueKey := buildQueueKey(sceneID)
+	for _, member := range members {
+		member.PriorityOffsetMS += queueCompensationOffsetMS
+		if err := s.saveQueueMember(ctx, member); err != nil {
+			logger.WithContext(ctx).Warn("requeue member save failed",
+				zap.Int64("userID", member.UserID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if err := s.rdb.ZAdd(ctx, queueKey, redis.Z{
+			Score:  member.effectiveScore(),
+			Member: strconv.FormatInt(member.UserID, 10),
+		}).Err(); err != nil {
+			logger.WithContext(ctx).Warn("requeue member zadd failed",
+				zap.Int64("userID", member.UserID),
+				zap.Error(err),
+			)
+			continue
+		}
+	}
+}
+
+// cancelOtherQueues drops a freshly seated user from every other scene queue
+// they were waiting in; first match wins, the rest are cancelled.
+func (s *Service) cancelOtherQueues(ctx context.Context, userID, wonSceneID int64) {
+	sceneIDs, err := s.rdb.SMembers(ctx, buildUserQueuesKey(userID)).Result()
+	if err != nil && err != redis.Nil {
+		logger.WithContext(ctx).Warn("cancel other queues lookup failed",
+			zap.Int64("userID", userID),
+			zap.Error(err),
+		)
+		return
+	}
+	for _, raw := range sceneIDs {
+		sceneID, parseErr := strconv.ParseInt(raw, 10, 64)
+		if parseErr != nil || sceneID == wonSceneID {
+			continue
+		}
+		if err := s.CancelQueue(ctx, CancelQueueRequest{
+			UserID:  userID,
+			SceneID: sceneID,
+			Reason:  "matched_elsewhere",
+		}); err != nil {
+			logger.WithContext(ctx).Warn("matched-elsewhere cancel failed",
+				zap.Int64("userID", userID),
+				zap.Int64("sceneID", sceneID),
+				zap.Error(err),
+			)
+		}
+	}
+	s.rdb.Del(ctx, buildUserQueuesKey(userID))
+}
+
+// loadCandidateWallets reads every candidate's playable balance (trial play
+// money for trial-mode scenes, available points otherwise) in a single query
+// so the selection pass never hits the DB per member.
+func (s *Service) loadCandidateWallets(ctx context.Context, scene model.Scene, candidates []queueMember) (map[int64]int64, error) {
+	if s.db == nil || len(candidates) == 0 {
+		return nil, nil
+	}
+	userIDs := make([]int64, 0, len(candidates))
+	for _, candidate := range candidates {
+		userIDs = append(userIDs, candidate.UserID)
+	}
+	var wallets []model.Wallet
+	if err := s.db.WithContext(ctx).
+		Select("user_id", "balance_available", "trial_balance").
+		Where("user_id IN ?", userIDs).
+		Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	balances := make(map[int64]int64, len(wallets))
+	for _, wallet := range wallets {
+		if scene.TrialMode {
+			balances[wallet.UserID] = wallet.TrialBalance
+		} else {
+			balances[wallet.UserID] = wallet.BalanceAvailable
+		}
+	}
+	return balances, nil
+}
+
 func (s *Service) createTableAndMatch(ctx context.Context, scene model.Scene, players []queueMember) (int64, int64, error) {
 	var (
 		tableID int64
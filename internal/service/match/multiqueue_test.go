@@ -0,0 +1,187 @@
+package match
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"dx-service/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newMultiQueueTestService(t *testing.T) *Service {
+	t.Helper()
+
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Scene{}, &model.Table{}, &model.Match{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return &Service{db: db, rdb: client, cfg: defaultConfig()}
+}
+
+func queueTestScene(t *testing.T, s *Service, id int64) model.Scene {
+	t.Helper()
+	scene := model.Scene{ID: id, Name: "scene" + strconv.FormatInt(id, 10), SeatCount: 2, MinIn: 0}
+	if err := s.db.Create(&scene).Error; err != nil {
+		t.Fatalf("failed to seed scene: %v", err)
+	}
+	return scene
+}
+
+func enqueueMember(t *testing.T, s *Service, sceneID, userID int64) queueMember {
+	t.Helper()
+	ctx := context.Background()
+	member := queueMember{
+		UserID:          userID,
+		SceneID:         sceneID,
+		BuyIn:           100,
+		BalanceSnapshot: 1000,
+		JoinedAt:        time.Now(),
+		Score:           float64(time.Now().UnixMilli()),
+	}
+	if err := s.saveQueueMember(ctx, member); err != nil {
+		t.Fatalf("save member: %v", err)
+	}
+	if err := s.rdb.ZAdd(ctx, buildQueueKey(sceneID), redis.Z{
+		Score:  member.effectiveScore(),
+		Member: strconv.FormatInt(userID, 10),
+	}).Err(); err != nil {
+		t.Fatalf("zadd member: %v", err)
+	}
+	s.rdb.SAdd(ctx, buildUserQueuesKey(userID), strconv.FormatInt(sceneID, 10))
+	return member
+}
+
+// User 1 waits in scenes 7 and 8; both matchers try to seat them in the same
+// tick. The first compose wins, the second must roll back without creating a
+// table and requeue its other player.
+func TestComposeRaceFirstMatchWins(t *testing.T) {
+	s := newMultiQueueTestService(t)
+	ctx := context.Background()
+
+	sceneA := queueTestScene(t, s, 7)
+	sceneB := queueTestScene(t, s, 8)
+
+	shared := enqueueMember(t, s, sceneA.ID, 1)
+	sharedB := enqueueMember(t, s, sceneB.ID, 1)
+	fillerA := enqueueMember(t, s, sceneA.ID, 2)
+	fillerB := enqueueMember(t, s, sceneB.ID, 3)
+
+	if err := s.composeTable(ctx, sceneA, []queueMember{shared, fillerA}); err != nil {
+		t.Fatalf("first compose failed: %v", err)
+	}
+	if err := s.composeTable(ctx, sceneB, []queueMember{sharedB, fillerB}); err != nil {
+		t.Fatalf("losing compose should roll back cleanly, got: %v", err)
+	}
+
+	var tables int64
+	if err := s.db.Model(&model.Table{}).Count(&tables).Error; err != nil {
+		t.Fatalf("count tables: %v", err)
+	}
+	if tables != 1 {
+		t.Fatalf("expected exactly one table, got %d", tables)
+	}
+
+	// The shared user must be gone from the losing scene's queue.
+	if _, err := s.rdb.ZScore(ctx, buildQueueKey(sceneB.ID), "1").Result(); err != redis.Nil {
+		t.Fatalf("expected shared user removed from losing queue, got err=%v", err)
+	}
+	// The losing scene's filler goes back into its queue.
+	if _, err := s.rdb.ZScore(ctx, buildQueueKey(sceneB.ID), "3").Result(); err != nil {
+		t.Fatalf("expected filler requeued in losing scene: %v", err)
+	}
+	// The winner keeps their matched notification.
+	if _, err := s.rdb.Get(ctx, buildMatchNotifyKey(1)).Result(); err != nil {
+		t.Fatalf("expected matched notification for shared user: %v", err)
+	}
+}
+
+func TestComposeCancelsOtherQueues(t *testing.T) {
+	s := newMultiQueueTestService(t)
+	ctx := context.Background()
+
+	sceneA := queueTestScene(t, s, 7)
+	queueTestScene(t, s, 8)
+
+	shared := enqueueMember(t, s, sceneA.ID, 1)
+	enqueueMember(t, s, 8, 1)
+	filler := enqueueMember(t, s, sceneA.ID, 2)
+
+	if err := s.composeTable(ctx, sceneA, []queueMember{shared, filler}); err != nil {
+		t.Fatalf("compose failed: %v", err)
+	}
+
+	if _, err := s.rdb.ZScore(ctx, buildQueueKey(8), "1").Result(); err != redis.Nil {
+		t.Fatalf("expected matched user cancelled from other queue, got err=%v", err)
+	}
+	if _, err := s.rdb.Get(ctx, buildMatchNotifyKey(1)).Result(); err != nil {
+		t.Fatalf("matched-elsewhere cancel must not delete the notify key: %v", err)
+	}
+}
+
+func TestJoinQueueConcurrentLimit(t *testing.T) {
+	s := newMultiQueueTestService(t)
+	if err := s.db.AutoMigrate(&model.Wallet{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	wallet := model.Wallet{UserID: 1, BalanceTotal: 10000, BalanceAvailable: 10000}
+	if err := s.db.Create(&wallet).Error; err != nil {
+		t.Fatalf("failed to seed wallet: %v", err)
+	}
+	ctx := context.Background()
+
+	for i := int64(1); i <= int64(s.cfg.MaxConcurrentQueues); i++ {
+		queueTestScene(t, s, i)
+		if _, err := s.JoinQueue(ctx, JoinQueueRequest{UserID: 1, SceneID: i, BuyIn: 100}); err != nil {
+			t.Fatalf("join scene %d: %v", i, err)
+		}
+	}
+
+	over := int64(s.cfg.MaxConcurrentQueues) + 1
+	queueTestScene(t, s, over)
+	if _, err := s.JoinQueue(ctx, JoinQueueRequest{UserID: 1, SceneID: over, BuyIn: 100}); err != appErr.ErrTooManyQueues {
+		t.Fatalf("expected ErrTooManyQueues beyond the limit, got %v", err)
+	}
+}
+
+func TestJoinQueueShedsWhenSceneQueueFull(t *testing.T) {
+	s := newMultiQueueTestService(t)
+	s.cfg.MaxQueuedPerScene = 1
+	if err := s.db.AutoMigrate(&model.Wallet{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	for _, userID := range []int64{1, 2} {
+		wallet := model.Wallet{UserID: userID, BalanceTotal: 10000, BalanceAvailable: 10000}
+		if err := s.db.Create(&wallet).Error; err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	queueTestScene(t, s, 1)
+	if _, err := s.JoinQueue(ctx, JoinQueueRequest{UserID: 1, SceneID: 1, BuyIn: 100}); err != nil {
+		t.Fatalf("first join should fit under the ceiling: %v", err)
+	}
+	if _, err := s.JoinQueue(ctx, JoinQueueRequest{UserID: 2, SceneID: 1, BuyIn: 100}); err != appErr.ErrServerBusy {
+		t.Fatalf("expected ErrServerBusy with the scene queue full, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package match
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newQueueTestService(t *testing.T) *Service {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return &Service{rdb: client, cfg: defaultConfig()}
+}
+
+func TestRequeuePreservesOriginalScore(t *testing.T) {
+	s := newQueueTestService(t)
+	ctx := context.Background()
+
+	joined := time.Now().Add(-time.Minute)
+	bumped := queueMember{UserID: 1, SceneID: 7, Score: float64(joined.UnixMilli())}
+
+	s.requeueMembers(ctx, 7, []queueMember{bumped})
+
+	stored, err := s.loadQueueMember(ctx, 7, 1)
+	if err != nil {
+		t.Fatalf("load requeued member: %v", err)
+	}
+	if stored.Score != bumped.Score {
+		t.Fatalf("expected original score %f preserved, got %f", bumped.Score, stored.Score)
+	}
+	if stored.PriorityOffsetMS != queueCompensationOffsetMS {
+		t.Fatalf("expected compensation offset %d, got %d", queueCompensationOffsetMS, stored.PriorityOffsetMS)
+	}
+
+	score, err := s.rdb.ZScore(ctx, buildQueueKey(7), "1").Result()
+	if err != nil {
+		t.Fatalf("zscore: %v", err)
+	}
+	if score != bumped.Score-queueCompensationOffsetMS {
+		t.Fatalf("expected effective score %f, got %f", bumped.Score-queueCompensationOffsetMS, score)
+	}
+}
+
+func TestRequeueClampsBuyInAndClearsMarkers(t *testing.T) {
+	s := newMultiQueueTestService(t)
+	ctx := context.Background()
+	if err := s.db.AutoMigrate(&model.Wallet{}); err != nil {
+		t.Fatalf("failed to migrate wallet: %v", err)
+	}
+
+	scene := model.Scene{ID: 7, Name: "expired", SeatCount: 2, MinIn: 200, MaxIn: 500}
+	if err := s.db.Create(&scene).Error; err != nil {
+		t.Fatalf("failed to seed scene: %v", err)
+	}
+	if err := s.db.Create(&model.Wallet{UserID: 1, BalanceTotal: 1000, BalanceAvailable: 1000}).Error; err != nil {
+		t.Fatalf("failed to seed wallet: %v", err)
+	}
+
+	// Stale matched markers from the closed table must not block the join.
+	s.rdb.Set(ctx, buildMatchNotifyKey(1), "stale", time.Minute)
+	s.rdb.Set(ctx, buildMatchedLockKey(1), "stale", time.Minute)
+	s.ClearMatched(ctx, 1)
+
+	// A 50-chip stack carried off the table clamps up to the scene minimum.
+	if err := s.Requeue(ctx, 1, 7, 50); err != nil {
+		t.Fatalf("requeue failed: %v", err)
+	}
+
+	member, err := s.loadQueueMember(ctx, 7, 1)
+	if err != nil {
+		t.Fatalf("load requeued member: %v", err)
+	}
+	if member.BuyIn != 200 {
+		t.Fatalf("expected buy-in clamped to 200, got %d", member.BuyIn)
+	}
+	if _, err := s.rdb.Get(ctx, buildMatchNotifyKey(1)).Result(); err != redis.Nil {
+		t.Fatalf("expected matched notify cleared, got %v", err)
+	}
+}
+
+func TestRequeuedMemberBeatsNewJoinerForLastSeat(t *testing.T) {
+	s := newQueueTestService(t)
+	ctx := context.Background()
+	queueKey := buildQueueKey(7)
+
+	now := time.Now()
+	rolledBack := queueMember{UserID: 1, SceneID: 7, Score: float64(now.UnixMilli())}
+	s.requeueMembers(ctx, 7, []queueMember{rolledBack})
+
+	// A fresh joiner arriving at the same millisecond must still queue
+	// behind the compensated member.
+	fresh := queueMember{UserID: 2, SceneID: 7, Score: float64(now.UnixMilli())}
+	if err := s.saveQueueMember(ctx, fresh); err != nil {
+		t.Fatalf("save fresh member: %v", err)
+	}
+	if err := s.rdb.ZAdd(ctx, queueKey, redis.Z{
+		Score:  fresh.effectiveScore(),
+		Member: "2",
+	}).Err(); err != nil {
+		t.Fatalf("zadd fresh member: %v", err)
+	}
+
+	order, err := s.rdb.ZRange(ctx, queueKey, 0, -1).Result()
+	if err != nil {
+		t.Fatalf("zrange: %v", err)
+	}
+	if len(order) != 2 || order[0] != "1" || order[1] != "2" {
+		t.Fatalf("expected rolled-back member picked first, got %v", order)
+	}
+}
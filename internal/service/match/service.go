@@ -6,10 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"dx-service/internal/model"
+	"dx-service/internal/service/features"
+	scenesvc "dx-service/internal/service/scene"
+	"dx-service/pkg/breaker"
 	appErr "dx-service/pkg/errors"
 	"dx-service/pkg/logger"
 
@@ -28,6 +32,10 @@ type Config struct {
 	MatchedNotifyTTL    time.Duration
 	MatcherInterval     time.Duration
 	CandidateMultiplier int
+	MaxConcurrentQueues int
+	// MaxQueuedPerScene caps a scene's waiting queue; joins beyond it are
+	// shed with a server-busy error. Zero disables the ceiling.
+	MaxQueuedPerScene int
 }
 
 func defaultConfig() Config {
@@ -39,26 +47,98 @@ func defaultConfig() Config {
 		MatchedNotifyTTL:    5 * time.Minute,
 		MatcherInterval:     500 * time.Millisecond,
 		CandidateMultiplier: 3,
+		MaxConcurrentQueues: 3,
+		MaxQueuedPerScene:   500,
 	}
 }
 
 type Service struct {
-	db  *gorm.DB
-	rdb *redis.Client
-	cfg Config
+	db       *gorm.DB
+	rdb      *redis.Client
+	features *features.Service
+	cfg      Config
+
+	// capacity reports the host's runtime ceiling; nil (bare test services)
+	// never holds composes back.
+	capacity HostCapacity
+
+	// geo gates queue joins by the client's resolved region; nil skips the
+	// check.
+	geo GeoChecker
+
+	// seater hands leftover queue members to live tables with free seats;
+	// nil keeps the matcher composing full tables only.
+	seater TableSeater
+
+	// breaker guards the queueing operation group against Redis outages;
+	// nil leaves the paths unguarded.
+	breaker *breaker.Breaker
 
 	startOnce sync.Once
 	startErr  error
+	stop      context.CancelFunc
+	wg        sync.WaitGroup
 }
 
-func NewService(db *gorm.DB, rdb *redis.Client) *Service {
+func NewService(db *gorm.DB, rdb *redis.Client, featuresSvc *features.Service) *Service {
 	return &Service{
-		db:  db,
-		rdb: rdb,
-		cfg: defaultConfig(),
+		db:       db,
+		rdb:      rdb,
+		features: featuresSvc,
+		cfg:      defaultConfig(),
 	}
 }
 
+// HostCapacity is the slice of the game service the matcher needs: whether
+// this instance can still host new table runtimes.
+type HostCapacity interface {
+	AtRuntimeCapacity() bool
+}
+
+// SetHostCapacity wires the runtime capacity probe in after construction.
+func (s *Service) SetHostCapacity(h HostCapacity) {
+	s.capacity = h
+}
+
+// TableSeater is the slice of the game service the top-up path needs:
+// seating a queued player into a live table that still has a free seat. The
+// returned match ID feeds the matched notification payload.
+type TableSeater interface {
+	SeatLatePlayer(ctx context.Context, tableID, userID, chips int64) (int64, error)
+}
+
+// SetTableSeater wires the late-join path in after construction.
+func (s *Service) SetTableSeater(t TableSeater) {
+	s.seater = t
+}
+
+// SetBreaker wires the queueing circuit breaker in after construction. While
+// it is open JoinQueue sheds with a clear business error and GetStatus falls
+// back to DB-derived facts.
+func (s *Service) SetBreaker(b *breaker.Breaker) {
+	s.breaker = b
+}
+
+// recordRedis feeds a Redis outcome to the queueing breaker. Only
+// infrastructure errors pass through here; business rejections are decided
+// after the Redis call succeeded.
+func (s *Service) recordRedis(err error) {
+	if s.breaker != nil {
+		s.breaker.Record(err)
+	}
+}
+
+// GeoChecker resolves a client IP's country and whether the compliance
+// blocklist bars it.
+type GeoChecker interface {
+	Check(ctx context.Context, ip string) (country string, blocked bool)
+}
+
+// SetGeoChecker wires the region gate in after construction.
+func (s *Service) SetGeoChecker(g GeoChecker) {
+	s.geo = g
+}
+
 func (s *Service) Start(ctx context.Context) error {
 	s.startOnce.Do(func() {
 		var scenes []model.Scene
@@ -67,15 +147,46 @@ func (s *Service) Start(ctx context.Context) error {
 			s.startErr = err
 			return
 		}
+		matcherCtx, cancel := context.WithCancel(ctx)
+		s.stop = cancel
 		for _, scene := range scenes {
 			sceneCopy := scene
-			go s.runMatcher(ctx, sceneCopy)
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.runMatcher(matcherCtx, sceneCopy)
+			}()
 		}
 	})
 	return s.startErr
 }
 
+// Stop signals every matcher goroutine and waits for them to exit, or until
+// the context expires.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.stop != nil {
+		s.stop()
+	}
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("matchers did not stop in time: %w", ctx.Err())
+	}
+}
+
 func (s *Service) JoinQueue(ctx context.Context, req JoinQueueRequest) (string, error) {
+	// An open breaker means Redis cannot be trusted to hold the queue;
+	// shed with a clear reason instead of a raw infrastructure error.
+	if s.breaker != nil && !s.breaker.Allow() {
+		return "", appErr.ErrMatchmakingUnavailable
+	}
+
 	scene, err := s.loadScene(ctx, req.SceneID)
 	if err != nil {
 		return "", err
@@ -84,11 +195,28 @@ func (s *Service) JoinQueue(ctx context.Context, req JoinQueueRequest) (string,
 		return "", appErr.ErrSceneNotFound
 	}
 
+	if open, nextOpenAt := scenesvc.OpenState(*scene, time.Now()); !open {
+		if nextOpenAt != nil {
+			return "", fmt.Errorf("%w: next open at %s", appErr.ErrSceneClosed, nextOpenAt.Format(time.RFC3339))
+		}
+		return "", appErr.ErrSceneClosed
+	}
+
 	if req.BuyIn < scene.MinIn || (scene.MaxIn > 0 && req.BuyIn > scene.MaxIn) {
 		return "", appErr.ErrInvalidBuyIn
 	}
 
-	walletBalance, err := s.loadWalletBalance(ctx, req.UserID)
+	if s.geo != nil && req.IP != "" {
+		if _, blocked := s.geo.Check(ctx, req.IP); blocked && !s.userGeoBypass(ctx, req.UserID) {
+			return "", appErr.ErrRegionBlocked
+		}
+	}
+
+	if scene.RequireVerification && !s.userVerified(ctx, req.UserID) {
+		return "", appErr.ErrVerificationRequired
+	}
+
+	walletBalance, err := s.loadWalletBalance(ctx, req.UserID, scene.TrialMode)
 	if err != nil {
 		return "", err
 	}
@@ -102,12 +230,48 @@ func (s *Service) JoinQueue(ctx context.Context, req JoinQueueRequest) (string,
 	if _, err := s.rdb.ZScore(ctx, queueKey, memberID).Result(); err == nil {
 		return "", appErr.ErrAlreadyInQueue
 	} else if err != redis.Nil {
+		s.recordRedis(err)
+		return "", err
+	}
+
+	// A user may wait in several scenes at once, first match wins; block
+	// joins while a compose is seating them elsewhere.
+	if exists, err := s.rdb.Exists(ctx, buildMatchedLockKey(req.UserID)).Result(); err != nil {
+		s.recordRedis(err)
 		return "", err
+	} else if exists > 0 {
+		return "", appErr.ErrQueueProcessing
+	}
+	if s.cfg.MaxConcurrentQueues > 0 {
+		queued, err := s.rdb.SCard(ctx, buildUserQueuesKey(req.UserID)).Result()
+		if err != nil && err != redis.Nil {
+			s.recordRedis(err)
+			return "", err
+		}
+		if queued >= int64(s.cfg.MaxConcurrentQueues) {
+			return "", appErr.ErrTooManyQueues
+		}
+	}
+	if s.cfg.MaxQueuedPerScene > 0 {
+		depth, err := s.rdb.ZCard(ctx, queueKey).Result()
+		if err != nil && err != redis.Nil {
+			s.recordRedis(err)
+			return "", err
+		}
+		if depth >= int64(s.cfg.MaxQueuedPerScene) {
+			logger.WithContext(ctx).Warn("shedding queue join: scene queue full",
+				zap.Int64("sceneID", scene.ID),
+				zap.Int64("userID", req.UserID),
+				zap.Int64("depth", depth),
+			)
+			return "", appErr.ErrServerBusy
+		}
 	}
 
-	lockKey := buildQueueLockKey(req.UserID)
+	lockKey := buildQueueLockKey(scene.ID, req.UserID)
 	gotLock, err := s.rdb.SetNX(ctx, lockKey, scene.ID, s.cfg.QueueLockTTL).Result()
 	if err != nil {
+		s.recordRedis(err)
 		return "", err
 	}
 	if !gotLock {
@@ -115,6 +279,7 @@ func (s *Service) JoinQueue(ctx context.Context, req JoinQueueRequest) (string,
 	}
 	defer s.rdb.Del(ctx, lockKey)
 
+	now := time.Now()
 	member := queueMember{
 		UserID:          req.UserID,
 		SceneID:         req.SceneID,
@@ -123,21 +288,29 @@ func (s *Service) JoinQueue(ctx context.Context, req JoinQueueRequest) (string,
 		GPSLng:          req.GPSLng,
 		IP:              req.IP,
 		BalanceSnapshot: walletBalance,
-		JoinedAt:        time.Now(),
+		JoinedAt:        now,
+		Score:           float64(now.UnixMilli()),
 	}
 
 	if err := s.saveQueueMember(ctx, member); err != nil {
+		s.recordRedis(err)
 		return "", err
 	}
 
-	score := float64(time.Now().UnixMilli())
+	score := member.effectiveScore()
 	if err := s.rdb.ZAdd(ctx, queueKey, redis.Z{
 		Score:  score,
 		Member: memberID,
 	}).Err(); err != nil {
 		s.removeQueueMember(ctx, member.SceneID, member.UserID)
+		s.recordRedis(err)
 		return "", err
 	}
+	s.recordRedis(nil)
+
+	userQueuesKey := buildUserQueuesKey(req.UserID)
+	s.rdb.SAdd(ctx, userQueuesKey, strconv.FormatInt(scene.ID, 10))
+	s.rdb.Expire(ctx, userQueuesKey, s.cfg.QueueMemberTTL)
 
 	logger.Log.Info("user joined queue",
 		zap.Int64("userID", req.UserID),
@@ -157,12 +330,17 @@ func (s *Service) CancelQueue(ctx context.Context, req CancelQueueRequest) error
 	}
 
 	s.removeQueueMember(ctx, req.SceneID, req.UserID)
-	s.rdb.Del(ctx, buildMatchNotifyKey(req.UserID))
+	s.rdb.SRem(ctx, buildUserQueuesKey(req.UserID), strconv.FormatInt(req.SceneID, 10))
 
 	reason := req.Reason
 	if reason == "" {
 		reason = "user"
 	}
+	// A matched-elsewhere cancel must not clobber the winning scene's
+	// matched notification.
+	if reason != "matched_elsewhere" {
+		s.rdb.Del(ctx, buildMatchNotifyKey(req.UserID))
+	}
 	logger.Log.Info("queue cancelled",
 		zap.Int64("userID", req.UserID),
 		zap.Int64("sceneID", req.SceneID),
@@ -171,7 +349,110 @@ func (s *Service) CancelQueue(ctx context.Context, req CancelQueueRequest) error
 	return nil
 }
 
+// ClearMatched drops a user's matched markers once their table is gone, so
+// GetStatus returns to idle (and JoinQueue stops refusing them) immediately
+// instead of waiting out the notify/lock TTLs. Called by table teardown.
+func (s *Service) ClearMatched(ctx context.Context, userID int64) {
+	s.rdb.Del(ctx, buildMatchNotifyKey(userID), buildMatchedLockKey(userID))
+}
+
+// Requeue puts a player straight back into a scene's queue after their table
+// closed. The buy-in carried over from the table is clamped into the scene's
+// range before the normal join validation runs.
+func (s *Service) Requeue(ctx context.Context, userID, sceneID, buyIn int64) error {
+	scene, err := s.loadScene(ctx, sceneID)
+	if err != nil {
+		return err
+	}
+	if scene == nil {
+		return appErr.ErrSceneNotFound
+	}
+	if buyIn < scene.MinIn {
+		buyIn = scene.MinIn
+	}
+	if scene.MaxIn > 0 && buyIn > scene.MaxIn {
+		buyIn = scene.MaxIn
+	}
+	_, err = s.JoinQueue(ctx, JoinQueueRequest{
+		UserID:  userID,
+		SceneID: sceneID,
+		BuyIn:   buyIn,
+	})
+	return err
+}
+
+// PendingMatch reports the table a user was matched into while their notify
+// key is still alive. The game service consults it as the last current-table
+// discovery source after an app restart.
+func (s *Service) PendingMatch(ctx context.Context, userID int64) (tableID, matchID int64, ok bool) {
+	payloadStr, err := s.rdb.Get(ctx, buildMatchNotifyKey(userID)).Result()
+	if err != nil {
+		return 0, 0, false
+	}
+	var payload matchNotifyPayload
+	if json.Unmarshal([]byte(payloadStr), &payload) != nil {
+		return 0, 0, false
+	}
+	return payload.TableID, payload.MatchID, true
+}
+
 func (s *Service) GetStatus(ctx context.Context, userID, sceneID int64) (*StatusResult, error) {
+	if s.breaker != nil && !s.breaker.Allow() {
+		return s.statusFromDB(ctx, userID, sceneID)
+	}
+	status, err := s.liveStatus(ctx, userID, sceneID)
+	s.recordRedis(err)
+	if err != nil && s.breaker != nil {
+		// A single failing call already degrades to the DB answer; the
+		// breaker decides when to stop asking Redis at all.
+		return s.statusFromDB(ctx, userID, sceneID)
+	}
+	return status, err
+}
+
+// statusFromDB derives a degraded status from the database alone: matched
+// when a live table seats the user, idle otherwise. Queue membership lives
+// only in Redis, so waiting users read as idle until it recovers.
+func (s *Service) statusFromDB(ctx context.Context, userID, sceneID int64) (*StatusResult, error) {
+	var tables []model.Table
+	// PlayersJSON always serializes userId as its last object key, so the
+	// closing-brace pattern cannot match a longer ID by prefix.
+	if err := s.db.WithContext(ctx).
+		Where("status IN ?", []string{"waiting", "playing"}).
+		Where("players_json LIKE ? OR players_json LIKE ?",
+			fmt.Sprintf(`%%"userId":%d}%%`, userID),
+			fmt.Sprintf(`%%"userId":%d,%%`, userID)).
+		Order("id DESC").
+		Limit(1).
+		Find(&tables).Error; err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return &StatusResult{
+			Status:   QueueStatusIdle,
+			SceneID:  sceneID,
+			Degraded: true,
+		}, nil
+	}
+
+	table := tables[0]
+	result := &StatusResult{
+		Status:   QueueStatusMatched,
+		SceneID:  table.SceneID,
+		TableID:  &table.ID,
+		Degraded: true,
+	}
+	var match model.Match
+	if err := s.db.WithContext(ctx).
+		Where("table_id = ? AND ended_at IS NULL", table.ID).
+		Order("id DESC").
+		First(&match).Error; err == nil {
+		result.MatchID = &match.ID
+	}
+	return result, nil
+}
+
+func (s *Service) liveStatus(ctx context.Context, userID, sceneID int64) (*StatusResult, error) {
 	notifyKey := buildMatchNotifyKey(userID)
 	payloadStr, err := s.rdb.Get(ctx, notifyKey).Result()
 	if err == nil {
@@ -188,18 +469,25 @@ func (s *Service) GetStatus(ctx context.Context, userID, sceneID int64) (*Status
 		return nil, err
 	}
 
+	if sceneID == 0 {
+		return s.statusAcrossQueues(ctx, userID)
+	}
+
 	queueKey := buildQueueKey(sceneID)
 	memberID := strconv.FormatInt(userID, 10)
 	if _, err := s.rdb.ZScore(ctx, queueKey, memberID).Result(); err == nil {
 		var joinedAt *time.Time
+		skipReason := ""
 		if member, err := s.loadQueueMember(ctx, sceneID, userID); err == nil {
 			joined := member.JoinedAt
 			joinedAt = &joined
+			skipReason = member.LastSkipReason
 		}
 		return &StatusResult{
-			Status:   QueueStatusQueued,
-			SceneID:  sceneID,
-			JoinedAt: joinedAt,
+			Status:     QueueStatusQueued,
+			SceneID:    sceneID,
+			JoinedAt:   joinedAt,
+			SkipReason: skipReason,
 		}, nil
 	} else if err != redis.Nil {
 		return nil, err
@@ -211,6 +499,142 @@ func (s *Service) GetStatus(ctx context.Context, userID, sceneID int64) (*Status
 	}, nil
 }
 
+// statusAcrossQueues reports every scene the user is still queued in, using
+// the reverse index maintained by JoinQueue/CancelQueue.
+func (s *Service) statusAcrossQueues(ctx context.Context, userID int64) (*StatusResult, error) {
+	sceneIDs, err := s.rdb.SMembers(ctx, buildUserQueuesKey(userID)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	memberID := strconv.FormatInt(userID, 10)
+	queues := make([]QueuedScene, 0, len(sceneIDs))
+	for _, raw := range sceneIDs {
+		sceneID, parseErr := strconv.ParseInt(raw, 10, 64)
+		if parseErr != nil {
+			continue
+		}
+		if _, err := s.rdb.ZScore(ctx, buildQueueKey(sceneID), memberID).Result(); err != nil {
+			if err == redis.Nil {
+				s.rdb.SRem(ctx, buildUserQueuesKey(userID), raw)
+				continue
+			}
+			return nil, err
+		}
+		entry := QueuedScene{SceneID: sceneID}
+		if member, err := s.loadQueueMember(ctx, sceneID, userID); err == nil {
+			joined := member.JoinedAt
+			entry.JoinedAt = &joined
+		}
+		queues = append(queues, entry)
+	}
+
+	if len(queues) == 0 {
+		return &StatusResult{Status: QueueStatusIdle}, nil
+	}
+	return &StatusResult{
+		Status: QueueStatusQueued,
+		Queues: queues,
+	}, nil
+}
+
+// LobbySummary aggregates per-scene queue depth and active table counts for
+// the lobby WebSocket broadcast. It is called from a single aggregator
+// goroutine, never per connection.
+func (s *Service) LobbySummary(ctx context.Context) ([]SceneSummary, error) {
+	var scenes []model.Scene
+	if err := s.db.WithContext(ctx).Find(&scenes).Error; err != nil {
+		return nil, err
+	}
+
+	type tableCount struct {
+		SceneID int64
+		Count   int64
+	}
+	var counts []tableCount
+	if err := s.db.WithContext(ctx).
+		Model(&model.Table{}).
+		Select("scene_id, COUNT(*) AS count").
+		Where("status IN ?", []string{"waiting", "playing"}).
+		Group("scene_id").
+		Find(&counts).Error; err != nil {
+		return nil, err
+	}
+	activeTables := make(map[int64]int64, len(counts))
+	for _, c := range counts {
+		activeTables[c.SceneID] = c.Count
+	}
+
+	summaries := make([]SceneSummary, 0, len(scenes))
+	for _, scene := range scenes {
+		queued, err := s.rdb.ZCard(ctx, buildQueueKey(scene.ID)).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		summaries = append(summaries, SceneSummary{
+			SceneID:      scene.ID,
+			Name:         scene.Name,
+			Queued:       queued,
+			ActiveTables: activeTables[scene.ID],
+		})
+	}
+	return summaries, nil
+}
+
+// SceneActivity reports how busy a scene is right now: tables still waiting
+// or playing plus users queued for it. Admin flows consult it before
+// disabling a scene.
+func (s *Service) SceneActivity(ctx context.Context, sceneID int64) (activeTables, queued int64, err error) {
+	if err := s.db.WithContext(ctx).
+		Model(&model.Table{}).
+		Where("scene_id = ? AND status IN ?", sceneID, []string{"waiting", "playing"}).
+		Count(&activeTables).Error; err != nil {
+		return 0, 0, err
+	}
+	queued, err = s.rdb.ZCard(ctx, buildQueueKey(sceneID)).Result()
+	if err != nil && err != redis.Nil {
+		return 0, 0, err
+	}
+	return activeTables, queued, nil
+}
+
+// PendingMatches looks up matched notifications for a batch of users in one
+// MGET so the lobby hub can fan out "matched" pushes cheaply.
+func (s *Service) PendingMatches(ctx context.Context, userIDs []int64) (map[int64]MatchedNotice, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		keys = append(keys, buildMatchNotifyKey(id))
+	}
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	result := make(map[int64]MatchedNotice)
+	for i, raw := range values {
+		str, ok := raw.(string)
+		if !ok || str == "" {
+			continue
+		}
+		var payload matchNotifyPayload
+		if jsonErr := json.Unmarshal([]byte(str), &payload); jsonErr != nil {
+			continue
+		}
+		result[userIDs[i]] = MatchedNotice{
+			SceneID: payload.SceneID,
+			TableID: payload.TableID,
+			MatchID: payload.MatchID,
+		}
+	}
+	return result, nil
+}
+
 func (s *Service) saveQueueMember(ctx context.Context, member queueMember) error {
 	data, err := json.Marshal(member)
 	if err != nil {
@@ -220,6 +644,35 @@ func (s *Service) saveQueueMember(ctx context.Context, member queueMember) error
 	return s.rdb.Set(ctx, key, data, s.cfg.QueueMemberTTL).Err()
 }
 
+// loadQueueMembers fetches a batch of member hashes with a single MGET,
+// skipping entries whose key expired or whose payload is unreadable.
+func (s *Service) loadQueueMembers(ctx context.Context, sceneID int64, userIDs []int64) ([]queueMember, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = buildQueueMemberKey(sceneID, userID)
+	}
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	members := make([]queueMember, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var member queueMember
+		if err := json.Unmarshal([]byte(data), &member); err != nil {
+			continue
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
 func (s *Service) loadQueueMember(ctx context.Context, sceneID, userID int64) (queueMember, error) {
 	var member queueMember
 	key := buildQueueMemberKey(sceneID, userID)
@@ -241,9 +694,25 @@ func (s *Service) removeQueueMember(ctx context.Context, sceneID, userID int64)
 	s.rdb.Del(ctx, key)
 }
 
+// CleanupExpiredQueues sweeps every scene's queue for members past the queue
+// timeout. It backs the scheduler's queue_cleanup job.
+func (s *Service) CleanupExpiredQueues(ctx context.Context) error {
+	var scenes []model.Scene
+	if err := s.db.WithContext(ctx).Find(&scenes).Error; err != nil {
+		return err
+	}
+	var firstErr error
+	for _, scene := range scenes {
+		if err := s.cleanupExpiredQueue(ctx, scene.ID); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 func (s *Service) cleanupExpiredQueue(ctx context.Context, sceneID int64) error {
 	if s.cfg.QueueTimeout <= 0 {
-		return nil
+		return s.sweepQueueDrift(ctx, sceneID)
 	}
 	queueKey := buildQueueKey(sceneID)
 	deadline := time.Now().Add(-s.cfg.QueueTimeout).UnixMilli()
@@ -283,6 +752,109 @@ func (s *Service) cleanupExpiredQueue(ctx context.Context, sceneID int64) error
 		)
 	}
 
+	return s.sweepQueueDrift(ctx, sceneID)
+}
+
+// sweepQueueDrift reconciles the queue ZSET with the member keys. The two
+// have independent TTLs, so they drift: a ZSET entry whose member key expired
+// is a ghost the matcher skips forever, and a member key whose ZSET entry is
+// gone after a failed ZRem just lingers. Ghosts are cancelled with reason
+// "state_lost" so GetStatus reflects reality; orphan keys are deleted.
+func (s *Service) sweepQueueDrift(ctx context.Context, sceneID int64) error {
+	queueKey := buildQueueKey(sceneID)
+	members, err := s.rdb.ZRange(ctx, queueKey, 0, -1).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	var ghostEntries, orphanKeys int
+	for _, member := range members {
+		userID, parseErr := strconv.ParseInt(member, 10, 64)
+		if parseErr != nil {
+			continue
+		}
+		if _, err := s.loadQueueMember(ctx, sceneID, userID); err != nil {
+			if err != errQueueMemberNotFound {
+				return err
+			}
+			if cancelErr := s.CancelQueue(ctx, CancelQueueRequest{
+				UserID:  userID,
+				SceneID: sceneID,
+				Reason:  "state_lost",
+			}); cancelErr != nil {
+				logger.Log.Warn("ghost entry cancel failed",
+					zap.Int64("userID", userID),
+					zap.Int64("sceneID", sceneID),
+					zap.Error(cancelErr),
+				)
+				continue
+			}
+			ghostEntries++
+		}
+	}
+
+	pattern := fmt.Sprintf("queue:member:%d:*", sceneID)
+	var cursor uint64
+	for {
+		keys, next, err := s.rdb.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			userStr := key[strings.LastIndex(key, ":")+1:]
+			if _, parseErr := strconv.ParseInt(userStr, 10, 64); parseErr != nil {
+				continue
+			}
+			if _, err := s.rdb.ZScore(ctx, queueKey, userStr).Result(); err == redis.Nil {
+				s.rdb.Del(ctx, key)
+				orphanKeys++
+			} else if err != nil {
+				return err
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	if ghostEntries > 0 || orphanKeys > 0 {
+		logger.Log.Info("queue drift repaired",
+			zap.Int64("sceneID", sceneID),
+			zap.Int("ghostEntries", ghostEntries),
+			zap.Int("orphanKeys", orphanKeys),
+		)
+	}
+	return nil
+}
+
+// drainQueue cancels every member of a scene's queue with the given reason.
+func (s *Service) drainQueue(ctx context.Context, sceneID int64, reason string) error {
+	members, err := s.rdb.ZRange(ctx, buildQueueKey(sceneID), 0, -1).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil
+		}
+		return err
+	}
+	for _, member := range members {
+		userID, parseErr := strconv.ParseInt(member, 10, 64)
+		if parseErr != nil {
+			continue
+		}
+		if err := s.CancelQueue(ctx, CancelQueueRequest{
+			UserID:  userID,
+			SceneID: sceneID,
+			Reason:  reason,
+		}); err != nil {
+			logger.Log.Warn("queue drain cancel failed",
+				zap.Int64("userID", userID),
+				zap.Int64("sceneID", sceneID),
+				zap.String("reason", reason),
+				zap.Error(err),
+			)
+		}
+	}
 	return nil
 }
 
@@ -305,15 +877,44 @@ func (s *Service) loadScene(ctx context.Context, sceneID int64) (*model.Scene, e
 	return &scene, nil
 }
 
-func (s *Service) loadWalletBalance(ctx context.Context, userID int64) (int64, error) {
+// userGeoBypass reports the per-user compliance override. It is only
+// consulted once a lookup already said blocked, keeping the extra read off
+// the common path.
+func (s *Service) userGeoBypass(ctx context.Context, userID int64) bool {
+	var user model.User
+	if err := s.db.WithContext(ctx).Select("geo_bypass").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return user.GeoBypass
+}
+
+// userVerified reports whether the user cleared real-name verification, for
+// scenes that require it before real-money play.
+func (s *Service) userVerified(ctx context.Context, userID int64) bool {
+	var user model.User
+	if err := s.db.WithContext(ctx).Select("verify_status").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return user.VerifyStatus == "verified"
+}
+
+// loadWalletBalance returns the balance the scene plays for: the trial
+// play-money balance for trial-mode scenes, points otherwise.
+func (s *Service) loadWalletBalance(ctx context.Context, userID int64, trial bool) (int64, error) {
 	var wallet model.Wallet
 	err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return 0, nil
+			// A missing row means the user never had a balance; surface
+			// that instead of a generic insufficient-balance error so
+			// onboarding can point at the recharge flow.
+			return 0, appErr.ErrWalletNotInitialized
 		}
 		return 0, err
 	}
+	if trial {
+		return wallet.TrialBalance, nil
+	}
 	return wallet.BalanceAvailable, nil
 }
 
@@ -359,8 +960,19 @@ func buildQueueMemberKey(sceneID, userID int64) string {
 	return fmt.Sprintf("queue:member:%d:%d", sceneID, userID)
 }
 
-func buildQueueLockKey(userID int64) string {
-	return fmt.Sprintf("queue:lock:%d", userID)
+func buildQueueLockKey(sceneID, userID int64) string {
+	return fmt.Sprintf("queue:lock:%d:%d", sceneID, userID)
+}
+
+// buildMatchedLockKey guards a user while a compose seats them; SetNX on it is
+// what makes first-match-wins atomic across scene matchers.
+func buildMatchedLockKey(userID int64) string {
+	return fmt.Sprintf("queue:matched:%d", userID)
+}
+
+// buildUserQueuesKey is the reverse index of scene queues a user waits in.
+func buildUserQueuesKey(userID int64) string {
+	return fmt.Sprintf("user:queues:%d", userID)
 }
 
 func buildMatchNotifyKey(userID int64) string {
@@ -0,0 +1,92 @@
+package match
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+)
+
+func TestSelectPlayersRecordsSkipReason(t *testing.T) {
+	s := newWalletInitTestService(t)
+	ctx := context.Background()
+
+	scene := model.Scene{ID: 7, SeatCount: 2, MinIn: 500}
+	broke := batchCandidate(1, 100)
+	if err := s.saveQueueMember(ctx, broke); err != nil {
+		t.Fatalf("save member: %v", err)
+	}
+
+	s.selectPlayers(ctx, scene, []queueMember{broke}, nil)
+
+	member, err := s.loadQueueMember(ctx, 7, 1)
+	if err != nil {
+		t.Fatalf("load member: %v", err)
+	}
+	if member.LastSkipReason != skipReasonLowBalance {
+		t.Fatalf("expected reason %q, got %q", skipReasonLowBalance, member.LastSkipReason)
+	}
+	if member.LastSkipAt == 0 {
+		t.Fatal("expected lastSkipAt set")
+	}
+}
+
+func TestRecordSkipReasonRateLimited(t *testing.T) {
+	s := newWalletInitTestService(t)
+	ctx := context.Background()
+
+	// The stored member carries a marker buy-in; a suppressed write leaves it.
+	stored := batchCandidate(1, 100)
+	stored.BuyIn = 999
+	if err := s.saveQueueMember(ctx, stored); err != nil {
+		t.Fatalf("save member: %v", err)
+	}
+
+	fresh := batchCandidate(1, 100)
+	fresh.LastSkipReason = skipReasonLowBalance
+	fresh.LastSkipAt = time.Now().Unix()
+	s.recordSkipReason(ctx, fresh, skipReasonLowBalance)
+
+	member, err := s.loadQueueMember(ctx, 7, 1)
+	if err != nil {
+		t.Fatalf("load member: %v", err)
+	}
+	if member.BuyIn != 999 {
+		t.Fatal("expected repeat write within the interval to be suppressed")
+	}
+
+	// A stale timestamp or a changed reason writes through immediately.
+	fresh.LastSkipAt = time.Now().Add(-time.Minute).Unix()
+	s.recordSkipReason(ctx, fresh, skipReasonLowBalance)
+	member, err = s.loadQueueMember(ctx, 7, 1)
+	if err != nil {
+		t.Fatalf("reload member: %v", err)
+	}
+	if member.BuyIn != 100 {
+		t.Fatal("expected stale reason to be rewritten")
+	}
+}
+
+func TestGetStatusSurfacesSkipReason(t *testing.T) {
+	s := newWalletInitTestService(t)
+	ctx := context.Background()
+
+	member := enqueueMember(t, s, 7, 1)
+	member.LastSkipReason = skipReasonNoLocation
+	member.LastSkipAt = time.Now().Unix()
+	if err := s.saveQueueMember(ctx, member); err != nil {
+		t.Fatalf("save member: %v", err)
+	}
+
+	status, err := s.GetStatus(ctx, 1, 7)
+	if err != nil {
+		t.Fatalf("get status: %v", err)
+	}
+	if status.Status != QueueStatusQueued {
+		t.Fatalf("expected queued status, got %s", status.Status)
+	}
+	if status.SkipReason != skipReasonNoLocation {
+		t.Fatalf("expected skip reason %q, got %q", skipReasonNoLocation, status.SkipReason)
+	}
+}
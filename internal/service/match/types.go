@@ -31,17 +31,64 @@ type StatusResult struct {
 	TableID  *int64      `json:"tableId,omitempty"`
 	MatchID  *int64      `json:"matchId,omitempty"`
 	JoinedAt *time.Time  `json:"joinedAt,omitempty"`
+
+	// SkipReason tells a queued user why the matcher last passed them over
+	// (e.g. no_location -> "enable location services").
+	SkipReason string `json:"skipReason,omitempty"`
+
+	// Queues lists every scene the user is waiting in; populated when the
+	// status request names no particular scene.
+	Queues []QueuedScene `json:"queues,omitempty"`
+
+	// Degraded marks an answer derived from the database while Redis is
+	// unavailable; queue membership is unknown in that mode.
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// QueuedScene is one entry of a multi-scene queue status.
+type QueuedScene struct {
+	SceneID  int64      `json:"sceneId"`
+	JoinedAt *time.Time `json:"joinedAt,omitempty"`
 }
 
 type queueMember struct {
-	UserID          int64     `json:"userId"`
-	SceneID         int64     `json:"sceneId"`
-	BuyIn           int64     `json:"buyIn"`
-	GPSLat          float64   `json:"gpsLat"`
-	GPSLng          float64   `json:"gpsLng"`
-	IP              string    `json:"ip"`
-	BalanceSnapshot int64     `json:"balanceSnapshot"`
-	JoinedAt        time.Time `json:"joinedAt"`
+	UserID           int64     `json:"userId"`
+	SceneID          int64     `json:"sceneId"`
+	BuyIn            int64     `json:"buyIn"`
+	GPSLat           float64   `json:"gpsLat"`
+	GPSLng           float64   `json:"gpsLng"`
+	IP               string    `json:"ip"`
+	BalanceSnapshot  int64     `json:"balanceSnapshot"`
+	JoinedAt         time.Time `json:"joinedAt"`
+	Score            float64   `json:"score"`            // original ZSET score, preserved across requeues
+	PriorityOffsetMS int64     `json:"priorityOffsetMs"` // compensation subtracted from the effective score
+
+	// Last reason the matcher passed this member over, surfaced through
+	// GetStatus so clients can prompt corrective action.
+	LastSkipReason string `json:"lastSkipReason,omitempty"`
+	LastSkipAt     int64  `json:"lastSkipAt,omitempty"` // unix seconds
+}
+
+// effectiveScore is the ZSET score the member actually queues at: the original
+// join score minus any compensation earned from a rolled-back compose.
+func (m queueMember) effectiveScore() float64 {
+	return m.Score - float64(m.PriorityOffsetMS)
+}
+
+// SceneSummary is one scene's entry in the periodic lobby broadcast.
+type SceneSummary struct {
+	SceneID      int64  `json:"sceneId"`
+	Name         string `json:"name"`
+	Queued       int64  `json:"queued"`
+	ActiveTables int64  `json:"activeTables"`
+}
+
+// MatchedNotice is the exported form of the matched notification delivered to
+// lobby WebSocket subscribers.
+type MatchedNotice struct {
+	SceneID int64 `json:"sceneId"`
+	TableID int64 `json:"tableId"`
+	MatchID int64 `json:"matchId"`
 }
 
 type matchNotifyPayload struct {
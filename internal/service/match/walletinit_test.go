@@ -0,0 +1,64 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newWalletInitTestService(t *testing.T) *Service {
+	t.Helper()
+
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Scene{}, &model.Wallet{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return &Service{db: db, rdb: client, cfg: defaultConfig()}
+}
+
+func TestJoinQueueFirstTimeUserWithoutWallet(t *testing.T) {
+	s := newWalletInitTestService(t)
+	ctx := context.Background()
+	queueTestScene(t, s, 7)
+
+	_, err := s.JoinQueue(ctx, JoinQueueRequest{UserID: 1, SceneID: 7, BuyIn: 100})
+	if !errors.Is(err, appErr.ErrWalletNotInitialized) {
+		t.Fatalf("expected ErrWalletNotInitialized, got %v", err)
+	}
+
+	// Once the wallet row exists the normal balance check takes over.
+	if err := s.db.Create(&model.Wallet{UserID: 1, BalanceAvailable: 50}).Error; err != nil {
+		t.Fatalf("seed wallet: %v", err)
+	}
+	_, err = s.JoinQueue(ctx, JoinQueueRequest{UserID: 1, SceneID: 7, BuyIn: 100})
+	if !errors.Is(err, appErr.ErrInsufficientBalance) {
+		t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+	}
+
+	if err := s.db.Model(&model.Wallet{}).Where("user_id = ?", int64(1)).Update("balance_available", 1000).Error; err != nil {
+		t.Fatalf("top up wallet: %v", err)
+	}
+	if _, err := s.JoinQueue(ctx, JoinQueueRequest{UserID: 1, SceneID: 7, BuyIn: 100}); err != nil {
+		t.Fatalf("expected join to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/pkg/breaker"
+	appErr "dx-service/pkg/errors"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	// pendingPushTTL bounds how long a staged push waits for a live lobby
+	// connection before the inbox row becomes the only delivery channel.
+	pendingPushTTL = 5 * time.Minute
+
+	// readRetention is how long read notifications are kept before the
+	// trim job deletes them.
+	readRetention = 90 * 24 * time.Hour
+)
+
+// Service is the per-user notification inbox for off-table events: rows are
+// the durable record, Redis staging feeds the lobby WebSocket for users who
+// happen to be online when the event fires.
+type Service struct {
+	db  *gorm.DB
+	rdb *redis.Client
+
+	// breaker guards the Redis staging path; while it is open pushes are
+	// skipped and the inbox row stays the only delivery channel.
+	breaker *breaker.Breaker
+}
+
+func NewService(db *gorm.DB, rdb *redis.Client) *Service {
+	return &Service{db: db, rdb: rdb}
+}
+
+// SetBreaker wires the notifications circuit breaker in after construction.
+func (s *Service) SetBreaker(b *breaker.Breaker) {
+	s.breaker = b
+}
+
+// Message is the wire form of a notification pushed over the lobby WebSocket.
+type Message struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type ListFilter struct {
+	UnreadOnly bool
+	Page       int
+	Size       int
+}
+
+type ListResult struct {
+	Items []model.Notification
+	Total int64
+}
+
+// Notify persists one notification and stages it for immediate WebSocket
+// delivery if the user has a live lobby connection.
+func (s *Service) Notify(ctx context.Context, userID int64, notifType string, payload map[string]interface{}) error {
+	var payloadJSON datatypes.JSON
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		payloadJSON = datatypes.JSON(data)
+	}
+
+	notification := model.Notification{
+		UserID:      userID,
+		Type:        notifType,
+		PayloadJSON: payloadJSON,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(&notification).Error; err != nil {
+		return err
+	}
+
+	if s.rdb != nil && (s.breaker == nil || s.breaker.Allow()) {
+		msg := Message{
+			ID:        notification.ID,
+			Type:      notification.Type,
+			Payload:   json.RawMessage(payloadJSON),
+			CreatedAt: notification.CreatedAt,
+		}
+		if data, err := json.Marshal(msg); err == nil {
+			key := buildPendingKey(userID)
+			pushErr := s.rdb.RPush(ctx, key, data).Err()
+			if s.breaker != nil {
+				s.breaker.Record(pushErr)
+			}
+			if pushErr == nil {
+				s.rdb.Expire(ctx, key, pendingPushTTL)
+			}
+		}
+	}
+	return nil
+}
+
+// PushPayload is the dead-letter form of one failed Notify call, complete
+// enough to repeat the call verbatim on an admin replay.
+type PushPayload struct {
+	UserID  int64                  `json:"userId"`
+	Type    string                 `json:"type"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+// Replay pushes a dead-lettered notification back through Notify; it is the
+// delivery service's replay handler for the notification type.
+func (s *Service) Replay(ctx context.Context, raw json.RawMessage) error {
+	var p PushPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return err
+	}
+	return s.Notify(ctx, p.UserID, p.Type, p.Payload)
+}
+
+func (s *Service) List(ctx context.Context, userID int64, filter ListFilter) (*ListResult, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Size <= 0 {
+		filter.Size = defaultPageSize
+	}
+	if filter.Size > maxPageSize {
+		filter.Size = maxPageSize
+	}
+
+	query := s.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ?", userID)
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	result := &ListResult{
+		Items: make([]model.Notification, 0),
+		Total: total,
+	}
+	if total == 0 {
+		return result, nil
+	}
+
+	if err := query.
+		Order("id DESC").
+		Limit(filter.Size).
+		Offset((filter.Page - 1) * filter.Size).
+		Find(&result.Items).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MarkRead stamps a notification as read; re-reading an already read
+// notification is a no-op, not an error.
+func (s *Service) MarkRead(ctx context.Context, userID, notificationID int64) error {
+	res := s.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Where("read_at IS NULL").
+		Update("read_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&model.Notification{}).
+			Where("id = ? AND user_id = ?", notificationID, userID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return appErr.ErrNotificationNotFound
+		}
+	}
+	return nil
+}
+
+// UnreadCount backs the profile badge.
+func (s *Service) UnreadCount(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// PendingForUsers drains staged pushes for connected lobby users. Draining is
+// destructive: anything returned here is considered delivered over the socket,
+// the inbox row remains the durable copy.
+func (s *Service) PendingForUsers(ctx context.Context, userIDs []int64) (map[int64][]Message, error) {
+	result := make(map[int64][]Message)
+	for _, userID := range userIDs {
+		key := buildPendingKey(userID)
+		values, err := s.rdb.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			if err == redis.Nil {
+				continue
+			}
+			return nil, err
+		}
+		if len(values) == 0 {
+			continue
+		}
+		s.rdb.Del(ctx, key)
+		for _, raw := range values {
+			var msg Message
+			if jsonErr := json.Unmarshal([]byte(raw), &msg); jsonErr != nil {
+				continue
+			}
+			result[userID] = append(result[userID], msg)
+		}
+	}
+	return result, nil
+}
+
+// TrimRead deletes read notifications older than the retention window. It
+// backs the scheduler's notification_trim job.
+func (s *Service) TrimRead(ctx context.Context) error {
+	cutoff := time.Now().Add(-readRetention)
+	return s.db.WithContext(ctx).
+		Where("read_at IS NOT NULL AND read_at < ?", cutoff).
+		Delete(&model.Notification{}).Error
+}
+
+func buildPendingKey(userID int64) string {
+	return fmt.Sprintf("notify:pending:%d", userID)
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Notification{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return NewService(db, client)
+}
+
+func TestNotifyListAndMarkRead(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	if err := s.Notify(ctx, 1, "withdrawal_approved", map[string]interface{}{"amount": 500}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if err := s.Notify(ctx, 1, "commission_released", map[string]interface{}{"amount": 20}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if err := s.Notify(ctx, 2, "withdrawal_approved", nil); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	unread, err := s.List(ctx, 1, ListFilter{UnreadOnly: true})
+	if err != nil {
+		t.Fatalf("list unread: %v", err)
+	}
+	if unread.Total != 2 {
+		t.Fatalf("expected 2 unread for user 1, got %d", unread.Total)
+	}
+
+	if err := s.MarkRead(ctx, 1, unread.Items[0].ID); err != nil {
+		t.Fatalf("mark read: %v", err)
+	}
+	count, err := s.UnreadCount(ctx, 1)
+	if err != nil {
+		t.Fatalf("unread count: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected badge count 1 after reading, got %d", count)
+	}
+
+	// Reading a notification that belongs to someone else must not succeed.
+	if err := s.MarkRead(ctx, 2, unread.Items[1].ID); err != appErr.ErrNotificationNotFound {
+		t.Fatalf("expected ErrNotificationNotFound for foreign notification, got %v", err)
+	}
+}
+
+func TestPendingForUsersDrainsStagedPushes(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	if err := s.Notify(ctx, 1, "commission_released", map[string]interface{}{"amount": 20}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	pending, err := s.PendingForUsers(ctx, []int64{1, 2})
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if len(pending[1]) != 1 || pending[1][0].Type != "commission_released" {
+		t.Fatalf("expected one staged push for user 1, got %v", pending)
+	}
+
+	// A second drain returns nothing: the push was delivered.
+	pending, err = s.PendingForUsers(ctx, []int64{1})
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if len(pending[1]) != 0 {
+		t.Fatalf("expected drained queue, got %v", pending[1])
+	}
+}
+
+func TestTrimReadKeepsRecentAndUnread(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	old := time.Now().Add(-readRetention - time.Hour)
+	rows := []model.Notification{
+		{UserID: 1, Type: "withdrawal_approved", ReadAt: &old, CreatedAt: old},
+		{UserID: 1, Type: "commission_released", CreatedAt: old},
+	}
+	if err := s.db.Create(&rows).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	if err := s.TrimRead(ctx); err != nil {
+		t.Fatalf("trim: %v", err)
+	}
+
+	var remaining int64
+	if err := s.db.Model(&model.Notification{}).Count(&remaining).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if remaining != 1 {
+		t.Fatalf("expected only the unread notification to survive, got %d", remaining)
+	}
+}
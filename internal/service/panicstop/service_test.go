@@ -0,0 +1,77 @@
+package panicstop
+
+import (
+	"context"
+	"testing"
+
+	"dx-service/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newPanicTestService(t *testing.T) *Service {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	mr := miniredis.RunT(t)
+	return NewService(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+}
+
+func TestGlobalPanicCoversEveryScene(t *testing.T) {
+	s := newPanicTestService(t)
+	ctx := context.Background()
+
+	if err := s.Engage(ctx, 0, "suspected exploit"); err != nil {
+		t.Fatalf("engage failed: %v", err)
+	}
+	if !s.Active(ctx, 0) || !s.Active(ctx, 7) {
+		t.Fatal("global panic should cover every scene")
+	}
+
+	if err := s.Resume(ctx, 0); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if s.Active(ctx, 0) || s.Active(ctx, 7) {
+		t.Fatal("resumed panic still reported active")
+	}
+}
+
+func TestScenePanicIsScoped(t *testing.T) {
+	s := newPanicTestService(t)
+	ctx := context.Background()
+
+	if err := s.Engage(ctx, 7, "bad scene"); err != nil {
+		t.Fatalf("engage failed: %v", err)
+	}
+	if !s.Active(ctx, 7) {
+		t.Fatal("scene panic not reported for its scene")
+	}
+	if s.Active(ctx, 8) || s.Active(ctx, 0) {
+		t.Fatal("scene panic leaked beyond its scene")
+	}
+
+	if err := s.Resume(ctx, 7); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if s.Active(ctx, 7) {
+		t.Fatal("resumed scene panic still reported active")
+	}
+}
+
+func TestNilClientNeverPanics(t *testing.T) {
+	s := NewService(nil)
+	ctx := context.Background()
+
+	if s.Active(ctx, 0) {
+		t.Fatal("nil client should never report a panic")
+	}
+	if err := s.Engage(ctx, 0, "x"); err == nil {
+		t.Fatal("engaging without redis should fail")
+	}
+	if err := s.Resume(ctx, 0); err != nil {
+		t.Fatalf("resume without redis should be a no-op, got %v", err)
+	}
+}
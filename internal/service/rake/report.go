@@ -0,0 +1,214 @@
+package rake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"dx-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// GroupByScene and GroupByRule are the report dimensions.
+	GroupByScene = "scene"
+	GroupByRule  = "rule"
+
+	backfillBatchSize = 500
+)
+
+type ReportFilter struct {
+	From    time.Time
+	To      time.Time
+	GroupBy string // GroupByScene or GroupByRule
+	Page    int
+	Size    int
+}
+
+// ReportGroup is one aggregated row; GroupID is a scene or rule ID depending
+// on the requested dimension.
+type ReportGroup struct {
+	GroupID      int64   `json:"groupId"`
+	MatchCount   int64   `json:"matchCount"`
+	TotalRake    int64   `json:"totalRake"`
+	Platform     int64   `json:"platform"`
+	AgentTotal   int64   `json:"agentTotal"`
+	TotalPot     int64   `json:"totalPot"`
+	AvgRake      float64 `json:"avgRake"`      // rake per settled match
+	RakePotRatio float64 `json:"rakePotRatio"` // rake as a fraction of gross pots
+}
+
+type ReportResult struct {
+	Items []ReportGroup
+	Total int64
+}
+
+// Report aggregates the normalized match_rakes table over a time range,
+// grouped by scene or rule. Groups are paginated so an unbounded range never
+// materializes the whole table.
+func (s *Service) Report(ctx context.Context, filter ReportFilter) (*ReportResult, error) {
+	var groupCol string
+	switch filter.GroupBy {
+	case GroupByScene, "":
+		groupCol = "scene_id"
+	case GroupByRule:
+		groupCol = "rule_id"
+	default:
+		return nil, fmt.Errorf("unsupported groupBy %q", filter.GroupBy)
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Size <= 0 {
+		filter.Size = 20
+	}
+	if filter.Size > 100 {
+		filter.Size = 100
+	}
+
+	base := s.db.WithContext(ctx).Model(&model.MatchRake{})
+	if !filter.From.IsZero() {
+		base = base.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		base = base.Where("created_at < ?", filter.To)
+	}
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Distinct(groupCol).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]ReportGroup, 0, filter.Size)
+	if total > 0 {
+		offset := (filter.Page - 1) * filter.Size
+		err := base.Session(&gorm.Session{}).
+			Select(groupCol+" AS group_id",
+				"COUNT(*) AS match_count",
+				"SUM(total_rake) AS total_rake",
+				"SUM(platform) AS platform",
+				"SUM(agent_total) AS agent_total",
+				"SUM(total_pot) AS total_pot").
+			Group(groupCol).
+			Order("total_rake DESC").
+			Limit(filter.Size).
+			Offset(offset).
+			Scan(&items).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range items {
+		if items[i].MatchCount > 0 {
+			items[i].AvgRake = float64(items[i].TotalRake) / float64(items[i].MatchCount)
+		}
+		if items[i].TotalPot > 0 {
+			items[i].RakePotRatio = float64(items[i].TotalRake) / float64(items[i].TotalPot)
+		}
+	}
+	return &ReportResult{Items: items, Total: total}, nil
+}
+
+// rakeSummaryRecord mirrors the shape game settlement writes into
+// Match.RakeJSON; only the fields the backfill needs are decoded.
+type rakeSummaryRecord struct {
+	Total    int64 `json:"total"`
+	Platform int64 `json:"platform"`
+	Agents   []struct {
+		Amount int64 `json:"amount"`
+	} `json:"agents"`
+}
+
+type resultRecord struct {
+	NetPoints int64 `json:"netPoints"`
+	Rake      int64 `json:"rake"`
+}
+
+// BackfillMatchRake creates match_rakes rows for settled matches that predate
+// the normalized table, reading the legacy RakeJSON/ResultJSON blobs. It is
+// idempotent and returns the number of rows created.
+func (s *Service) BackfillMatchRake(ctx context.Context) (int64, error) {
+	var created int64
+	lastID := int64(0)
+	for {
+		var matches []model.Match
+		err := s.db.WithContext(ctx).
+			Where("id > ? AND ended_at IS NOT NULL", lastID).
+			Where("id NOT IN (?)", s.db.Model(&model.MatchRake{}).Select("match_id")).
+			Order("id ASC").
+			Limit(backfillBatchSize).
+			Find(&matches).Error
+		if err != nil {
+			return created, err
+		}
+		if len(matches) == 0 {
+			return created, nil
+		}
+
+		sceneRules := make(map[int64]int64)
+		for _, match := range matches {
+			lastID = match.ID
+
+			var summary rakeSummaryRecord
+			if len(match.RakeJSON) > 0 {
+				if err := json.Unmarshal(match.RakeJSON, &summary); err != nil {
+					continue // unreadable legacy blob, skip rather than abort
+				}
+			}
+			var agentTotal int64
+			for _, agent := range summary.Agents {
+				agentTotal += agent.Amount
+			}
+
+			// Gross pot = net win + rake summed over winners.
+			var totalPot int64
+			if len(match.ResultJSON) > 0 {
+				var results []resultRecord
+				if json.Unmarshal(match.ResultJSON, &results) != nil {
+					// Post-chaining matches wrap the records in an envelope.
+					var env struct {
+						Results []resultRecord `json:"results"`
+					}
+					if json.Unmarshal(match.ResultJSON, &env) == nil {
+						results = env.Results
+					}
+				}
+				for _, r := range results {
+					if r.NetPoints > 0 {
+						totalPot += r.NetPoints + r.Rake
+					}
+				}
+			}
+
+			ruleID, ok := sceneRules[match.SceneID]
+			if !ok {
+				var scene model.Scene
+				if err := s.db.WithContext(ctx).First(&scene, match.SceneID).Error; err == nil {
+					ruleID = scene.RakeRuleID
+				}
+				sceneRules[match.SceneID] = ruleID
+			}
+
+			createdAt := match.CreatedAt
+			if match.EndedAt != nil {
+				createdAt = *match.EndedAt
+			}
+			if err := s.db.WithContext(ctx).Create(&model.MatchRake{
+				MatchID:    match.ID,
+				SceneID:    match.SceneID,
+				RuleID:     ruleID,
+				TotalRake:  summary.Total,
+				Platform:   summary.Platform,
+				AgentTotal: agentTotal,
+				TotalPot:   totalPot,
+				CreatedAt:  createdAt,
+			}).Error; err != nil {
+				return created, err
+			}
+			created++
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package rake_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+	"dx-service/internal/service/rake"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newReportService(t *testing.T) (*gorm.DB, *rake.Service) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Scene{}, &model.Match{}, &model.MatchRake{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db, rake.NewService(db)
+}
+
+func TestRakeReportGroupsByScene(t *testing.T) {
+	ctx := context.Background()
+	db, svc := newReportService(t)
+
+	now := time.Now()
+	rows := []model.MatchRake{
+		{MatchID: 1, SceneID: 1, RuleID: 10, TotalRake: 100, Platform: 60, AgentTotal: 40, TotalPot: 1000, CreatedAt: now},
+		{MatchID: 2, SceneID: 1, RuleID: 10, TotalRake: 50, Platform: 30, AgentTotal: 20, TotalPot: 500, CreatedAt: now},
+		{MatchID: 3, SceneID: 2, RuleID: 11, TotalRake: 20, Platform: 20, AgentTotal: 0, TotalPot: 400, CreatedAt: now},
+		{MatchID: 4, SceneID: 3, RuleID: 10, TotalRake: 5, Platform: 5, AgentTotal: 0, TotalPot: 100, CreatedAt: now.Add(-48 * time.Hour)},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("failed to seed match rakes: %v", err)
+	}
+
+	result, err := svc.Report(ctx, rake.ReportFilter{
+		From:    now.Add(-time.Hour),
+		GroupBy: rake.GroupByScene,
+	})
+	if err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if result.Total != 2 {
+		t.Fatalf("expected 2 groups in range, got %d", result.Total)
+	}
+	top := result.Items[0]
+	if top.GroupID != 1 || top.MatchCount != 2 || top.TotalRake != 150 {
+		t.Fatalf("unexpected top group: %+v", top)
+	}
+	if top.AvgRake != 75 {
+		t.Fatalf("expected avg rake 75, got %f", top.AvgRake)
+	}
+	if top.RakePotRatio != 0.1 {
+		t.Fatalf("expected rake/pot ratio 0.1, got %f", top.RakePotRatio)
+	}
+
+	byRule, err := svc.Report(ctx, rake.ReportFilter{GroupBy: rake.GroupByRule})
+	if err != nil {
+		t.Fatalf("report by rule failed: %v", err)
+	}
+	if byRule.Total != 2 {
+		t.Fatalf("expected 2 rule groups, got %d", byRule.Total)
+	}
+
+	if _, err := svc.Report(ctx, rake.ReportFilter{GroupBy: "nope"}); err == nil {
+		t.Fatal("expected error for unsupported groupBy")
+	}
+}
+
+func TestBackfillMatchRake(t *testing.T) {
+	ctx := context.Background()
+	db, svc := newReportService(t)
+
+	ended := time.Now().Add(-time.Hour)
+	scene := model.Scene{ID: 5, Name: "legacy", RakeRuleID: 42}
+	if err := db.Create(&scene).Error; err != nil {
+		t.Fatalf("failed to seed scene: %v", err)
+	}
+	legacy := model.Match{
+		SceneID:    5,
+		ResultJSON: []byte(`[{"userId":1,"netPoints":90,"rake":10},{"userId":2,"netPoints":-100,"rake":0}]`),
+		RakeJSON:   []byte(`{"total":10,"platform":6,"agents":[{"agentId":9,"amount":4}]}`),
+		EndedAt:    &ended,
+	}
+	open := model.Match{SceneID: 5}
+	if err := db.Create(&legacy).Error; err != nil {
+		t.Fatalf("failed to seed match: %v", err)
+	}
+	if err := db.Create(&open).Error; err != nil {
+		t.Fatalf("failed to seed open match: %v", err)
+	}
+
+	created, err := svc.BackfillMatchRake(ctx)
+	if err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+	if created != 1 {
+		t.Fatalf("expected 1 row created, got %d", created)
+	}
+
+	var row model.MatchRake
+	if err := db.First(&row, "match_id = ?", legacy.ID).Error; err != nil {
+		t.Fatalf("backfilled row missing: %v", err)
+	}
+	if row.SceneID != 5 || row.RuleID != 42 || row.TotalRake != 10 || row.Platform != 6 || row.AgentTotal != 4 {
+		t.Fatalf("unexpected backfilled row: %+v", row)
+	}
+	if row.TotalPot != 100 {
+		t.Fatalf("expected gross pot 100, got %d", row.TotalPot)
+	}
+
+	// Re-running must not duplicate rows.
+	again, err := svc.BackfillMatchRake(ctx)
+	if err != nil {
+		t.Fatalf("second backfill failed: %v", err)
+	}
+	if again != 0 {
+		t.Fatalf("expected idempotent backfill, created %d", again)
+	}
+}
@@ -0,0 +1,38 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// OptionalActions are the turn actions a scene may switch off; everything
+// else (fold, call, ready, request_time) is always available.
+var OptionalActions = []string{"raise", "knock_bobo", "pass"}
+
+// ParseAllowedActions decodes Scene.AllowedActionsJSON into a permission set.
+// An empty value returns nil, meaning every optional action stays enabled —
+// the behavior existing scenes rely on.
+func ParseAllowedActions(raw []byte) (map[string]bool, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var actions []string
+	if err := json.Unmarshal(raw, &actions); err != nil {
+		return nil, err
+	}
+	mask := make(map[string]bool, len(actions))
+	for _, action := range actions {
+		known := false
+		for _, opt := range OptionalActions {
+			if action == opt {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("allowed actions: unknown action %q", action)
+		}
+		mask[action] = true
+	}
+	return mask, nil
+}
@@ -0,0 +1,142 @@
+package scene_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"dx-service/internal/model"
+	"dx-service/internal/service/scene"
+	appErr "dx-service/pkg/errors"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newIsolatedSceneService opens a private in-memory db so bulk/ordering tests
+// never see rows created by other tests.
+func newIsolatedSceneService(t *testing.T) (*gorm.DB, *scene.Service) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Scene{}); err != nil {
+		t.Fatalf("failed to migrate scene model: %v", err)
+	}
+
+	return db, scene.NewService(db)
+}
+
+func TestListScenesRespectsSortOrder(t *testing.T) {
+	ctx := context.Background()
+	db, svc := newIsolatedSceneService(t)
+
+	scenes := []model.Scene{
+		{Name: "last", SeatCount: 6, MinIn: 100, BasePi: 10, MinUnitPi: 2, SortOrder: 9},
+		{Name: "first", SeatCount: 6, MinIn: 100, BasePi: 10, MinUnitPi: 2, SortOrder: 1},
+		{Name: "middle", SeatCount: 6, MinIn: 100, BasePi: 10, MinUnitPi: 2, SortOrder: 5},
+	}
+	for i := range scenes {
+		if err := db.Create(&scenes[i]).Error; err != nil {
+			t.Fatalf("seed scene: %v", err)
+		}
+	}
+
+	listed, err := svc.ListScenes(ctx)
+	if err != nil {
+		t.Fatalf("list scenes: %v", err)
+	}
+	if len(listed) != 3 {
+		t.Fatalf("expected 3 scenes, got %d", len(listed))
+	}
+	for i, want := range []string{"first", "middle", "last"} {
+		if listed[i].Name != want {
+			t.Fatalf("position %d: expected %q, got %q", i, want, listed[i].Name)
+		}
+	}
+}
+
+func TestBulkSetStatusPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	db, svc := newIsolatedSceneService(t)
+
+	idle := model.Scene{Name: "idle", SeatCount: 6, MinIn: 100, BasePi: 10, MinUnitPi: 2, Status: "enabled"}
+	busy := model.Scene{Name: "busy", SeatCount: 6, MinIn: 100, BasePi: 10, MinUnitPi: 2, Status: "enabled"}
+	for _, sc := range []*model.Scene{&idle, &busy} {
+		if err := db.Create(sc).Error; err != nil {
+			t.Fatalf("seed scene: %v", err)
+		}
+	}
+
+	guard := func(_ context.Context, sceneID int64) error {
+		if sceneID == busy.ID {
+			return fmt.Errorf("%w: 2 active tables, 0 queued", appErr.ErrSceneBusy)
+		}
+		return nil
+	}
+
+	missing := busy.ID + 100
+	results, err := svc.BulkSetStatus(ctx, []int64{idle.ID, busy.ID, missing}, "disabled", guard)
+	if err != nil {
+		t.Fatalf("bulk set status: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(results))
+	}
+	if !results[0].OK || results[0].Error != "" {
+		t.Fatalf("idle scene should disable cleanly: %+v", results[0])
+	}
+	if results[1].OK || results[1].Error == "" {
+		t.Fatalf("busy scene should be refused: %+v", results[1])
+	}
+	if results[2].OK || results[2].Error != appErr.ErrSceneNotFound.Error() {
+		t.Fatalf("missing scene should report not found: %+v", results[2])
+	}
+
+	var gotIdle, gotBusy model.Scene
+	if err := db.First(&gotIdle, idle.ID).Error; err != nil {
+		t.Fatalf("reload idle scene: %v", err)
+	}
+	if gotIdle.Status != "disabled" {
+		t.Fatalf("idle scene not disabled, status %q", gotIdle.Status)
+	}
+	if err := db.First(&gotBusy, busy.ID).Error; err != nil {
+		t.Fatalf("reload busy scene: %v", err)
+	}
+	if gotBusy.Status != "enabled" {
+		t.Fatalf("busy scene should stay enabled, status %q", gotBusy.Status)
+	}
+}
+
+func TestBulkSetStatusRejectsUnknownStatus(t *testing.T) {
+	ctx := context.Background()
+	_, svc := newIsolatedSceneService(t)
+
+	if _, err := svc.BulkSetStatus(ctx, []int64{1}, "archived", nil); err == nil {
+		t.Fatal("expected an error for an unknown status")
+	}
+}
+
+func TestBulkSetStatusEnableSkipsGuard(t *testing.T) {
+	ctx := context.Background()
+	db, svc := newIsolatedSceneService(t)
+
+	sc := model.Scene{Name: "off", SeatCount: 6, MinIn: 100, BasePi: 10, MinUnitPi: 2, Status: "disabled"}
+	if err := db.Create(&sc).Error; err != nil {
+		t.Fatalf("seed scene: %v", err)
+	}
+
+	guard := func(context.Context, int64) error {
+		t.Fatal("guard must not run when enabling")
+		return nil
+	}
+	results, err := svc.BulkSetStatus(ctx, []int64{sc.ID}, "enabled", guard)
+	if err != nil {
+		t.Fatalf("bulk set status: %v", err)
+	}
+	if len(results) != 1 || !results[0].OK {
+		t.Fatalf("expected a clean enable, got %+v", results)
+	}
+}
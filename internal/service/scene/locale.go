@@ -0,0 +1,74 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"dx-service/internal/model"
+)
+
+// DefaultLocale is what every localized lookup falls back to; the base Name
+// column is authored in it.
+const DefaultLocale = "zh"
+
+// supportedLocales is the whitelist admin payloads may key translations by.
+var supportedLocales = map[string]bool{
+	"zh": true,
+	"en": true,
+}
+
+// ParseNameI18n validates a locale->name map: every key must be a supported
+// locale and every value non-empty.
+func ParseNameI18n(raw []byte) (map[string]string, error) {
+	var names map[string]string
+	if err := json.Unmarshal(raw, &names); err != nil {
+		return nil, fmt.Errorf("nameI18n must be an object of locale to name: %w", err)
+	}
+	for locale, name := range names {
+		if !supportedLocales[strings.ToLower(locale)] {
+			return nil, fmt.Errorf("unsupported locale %q", locale)
+		}
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("empty name for locale %q", locale)
+		}
+	}
+	return names, nil
+}
+
+// LocalizedName resolves a scene's display name for the given locale,
+// falling back to the base Name when no translation exists.
+func LocalizedName(sc model.Scene, locale string) string {
+	if len(sc.NameI18nJSON) == 0 {
+		return sc.Name
+	}
+	var names map[string]string
+	if err := json.Unmarshal(sc.NameI18nJSON, &names); err != nil {
+		return sc.Name
+	}
+	if name := strings.TrimSpace(names[strings.ToLower(locale)]); name != "" {
+		return name
+	}
+	return sc.Name
+}
+
+// ResolveLocale picks the first supported locale from an Accept-Language
+// header (quality weights are ignored: order of appearance wins). Anything
+// unrecognized resolves to the default.
+func ResolveLocale(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		lang := strings.TrimSpace(part)
+		if i := strings.Index(lang, ";"); i >= 0 {
+			lang = lang[:i]
+		}
+		// "en-US" matches the "en" catalog.
+		if i := strings.Index(lang, "-"); i >= 0 {
+			lang = lang[:i]
+		}
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if supportedLocales[lang] {
+			return lang
+		}
+	}
+	return DefaultLocale
+}
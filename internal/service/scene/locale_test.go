@@ -0,0 +1,86 @@
+package scene_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"dx-service/internal/model"
+	"dx-service/internal/service/scene"
+
+	"gorm.io/datatypes"
+)
+
+func TestResolveLocale(t *testing.T) {
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"en-US,en;q=0.9,zh;q=0.8", "en"},
+		{"zh-CN,zh;q=0.9", "zh"},
+		{"fr-FR,de;q=0.9", "zh"},
+		{"", "zh"},
+		{"EN", "en"},
+	}
+	for _, tc := range cases {
+		if got := scene.ResolveLocale(tc.header); got != tc.want {
+			t.Errorf("ResolveLocale(%q): expected %q, got %q", tc.header, tc.want, got)
+		}
+	}
+}
+
+func TestLocalizedNameFallsBackToBaseName(t *testing.T) {
+	sc := model.Scene{
+		Name:         "初级场",
+		NameI18nJSON: datatypes.JSON(`{"en":"Beginner Room"}`),
+	}
+	if got := scene.LocalizedName(sc, "en"); got != "Beginner Room" {
+		t.Fatalf("expected English name, got %q", got)
+	}
+	if got := scene.LocalizedName(sc, "zh"); got != "初级场" {
+		t.Fatalf("expected fallback to base name, got %q", got)
+	}
+
+	sc.NameI18nJSON = datatypes.JSON(`not json`)
+	if got := scene.LocalizedName(sc, "en"); got != "初级场" {
+		t.Fatalf("corrupt i18n must fall back, got %q", got)
+	}
+}
+
+func TestCreateSceneRejectsUnsupportedLocale(t *testing.T) {
+	ctx := context.Background()
+	// A private db: the scene created below must not leak into the list
+	// counts of tests running against the shared fixture.
+	_, svc := newIsolatedSceneService(t)
+
+	_, err := svc.CreateScene(ctx, scene.SceneMutationParams{
+		Name:         "测试场",
+		SeatCount:    6,
+		MinIn:        1000,
+		MaxIn:        5000,
+		BasePi:       100,
+		MinUnitPi:    20,
+		RakeRuleID:   1,
+		NameI18nJSON: []byte(`{"fr":"Salle"}`),
+	})
+	if err == nil || !strings.Contains(err.Error(), "unsupported locale") {
+		t.Fatalf("expected unsupported locale error, got %v", err)
+	}
+
+	created, err := svc.CreateScene(ctx, scene.SceneMutationParams{
+		Name:         "测试场",
+		SeatCount:    6,
+		MinIn:        1000,
+		MaxIn:        5000,
+		BasePi:       100,
+		MinUnitPi:    20,
+		RakeRuleID:   1,
+		NameI18nJSON: []byte(`{"en":"Test Room"}`),
+	})
+	if err != nil {
+		t.Fatalf("create with supported locale failed: %v", err)
+	}
+	if got := scene.LocalizedName(*created, "en"); got != "Test Room" {
+		t.Fatalf("expected stored translation resolved, got %q", got)
+	}
+}
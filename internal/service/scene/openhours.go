@@ -0,0 +1,184 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/internal/model"
+)
+
+// OpenWindow is one weekly open range. Times are "HH:MM" in the configured
+// server timezone; an End at or before Start means the window crosses
+// midnight into the next day (e.g. 20:00–02:00).
+type OpenWindow struct {
+	Weekday int    `json:"weekday"` // 0=Sunday .. 6=Saturday, matching time.Weekday
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+// ParseOpenHours decodes Scene.OpenHoursJSON. An empty value means the scene
+// is always open.
+func ParseOpenHours(raw []byte) ([]OpenWindow, error) {
+	return parseWindowList(raw, "open hours")
+}
+
+// parseWindowList is the shared decoder behind open hours and rake-free
+// windows; label names the feature in validation errors.
+func parseWindowList(raw []byte, label string) ([]OpenWindow, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var windows []OpenWindow
+	if err := json.Unmarshal(raw, &windows); err != nil {
+		return nil, err
+	}
+	for _, w := range windows {
+		if w.Weekday < 0 || w.Weekday > 6 {
+			return nil, fmt.Errorf("%s: weekday %d out of range", label, w.Weekday)
+		}
+		if _, err := parseMinutes(w.Start, label); err != nil {
+			return nil, err
+		}
+		if _, err := parseMinutes(w.End, label); err != nil {
+			return nil, err
+		}
+	}
+	return windows, nil
+}
+
+// OpenState reports whether the scene is open at now and, when closed, the
+// next time a window opens. Malformed open hours fail open so a bad admin
+// edit cannot lock everyone out.
+func OpenState(scene model.Scene, now time.Time) (bool, *time.Time) {
+	windows, err := ParseOpenHours(scene.OpenHoursJSON)
+	if err != nil || len(windows) == 0 {
+		return true, nil
+	}
+	local := now.In(serverLocation())
+	if isOpenAt(windows, local) {
+		return true, nil
+	}
+	next := nextOpenAt(windows, local)
+	return false, next
+}
+
+func isOpenAt(windows []OpenWindow, at time.Time) bool {
+	return windowCovering(windows, at) != nil
+}
+
+// windowCovering returns the first window containing at, or nil.
+func windowCovering(windows []OpenWindow, at time.Time) *OpenWindow {
+	day := int(at.Weekday())
+	minute := at.Hour()*60 + at.Minute()
+	for i, w := range windows {
+		start, _ := parseMinutes(w.Start, "")
+		end, _ := parseMinutes(w.End, "")
+		if end > start {
+			if day == w.Weekday && minute >= start && minute < end {
+				return &windows[i]
+			}
+			continue
+		}
+		// Midnight-crossing: [start, 24:00) on the window's day plus
+		// [00:00, end) on the following day.
+		if day == w.Weekday && minute >= start {
+			return &windows[i]
+		}
+		if day == (w.Weekday+1)%7 && minute < end {
+			return &windows[i]
+		}
+	}
+	return nil
+}
+
+// nextOpenAt finds the earliest window start strictly after at, looking at
+// most one week ahead.
+func nextOpenAt(windows []OpenWindow, at time.Time) *time.Time {
+	var best *time.Time
+	midnight := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	for _, w := range windows {
+		start, _ := parseMinutes(w.Start, "")
+		daysAhead := (w.Weekday - int(at.Weekday()) + 7) % 7
+		candidate := midnight.AddDate(0, 0, daysAhead).Add(time.Duration(start) * time.Minute)
+		if !candidate.After(at) {
+			candidate = candidate.AddDate(0, 0, 7)
+		}
+		if best == nil || candidate.Before(*best) {
+			c := candidate
+			best = &c
+		}
+	}
+	return best
+}
+
+func parseMinutes(hhmm, label string) (int, error) {
+	parts := strings.SplitN(hhmm, ":", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("%s: invalid time %q", label, hhmm)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, fmt.Errorf("%s: invalid time %q", label, hhmm)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("%s: invalid time %q", label, hhmm)
+	}
+	return hour*60 + minute, nil
+}
+
+// serverLocation resolves the configured timezone: an IANA name, a DST-free
+// fixed offset like "+08:00", or the host zone when unset.
+func serverLocation() *time.Location {
+	name := ""
+	if config.GlobalConfig != nil {
+		name = strings.TrimSpace(config.GlobalConfig.Server.Timezone)
+	}
+	if name == "" {
+		return time.Local
+	}
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
+	}
+	if offset, ok := parseFixedOffset(name); ok {
+		return time.FixedZone(name, offset)
+	}
+	return time.Local
+}
+
+// parseFixedOffset accepts "+08:00", "-05:30" and the compact "+0800" form,
+// returning the offset in seconds.
+func parseFixedOffset(name string) (int, bool) {
+	if len(name) < 2 || (name[0] != '+' && name[0] != '-') {
+		return 0, false
+	}
+	body := strings.ReplaceAll(name[1:], ":", "")
+	switch len(body) {
+	case 1:
+		body = "0" + body + "00"
+	case 2:
+		body += "00"
+	case 3:
+		body = "0" + body
+	}
+	if len(body) != 4 {
+		return 0, false
+	}
+	hours, err := strconv.Atoi(body[:2])
+	if err != nil || hours > 23 {
+		return 0, false
+	}
+	minutes, err := strconv.Atoi(body[2:])
+	if err != nil || minutes > 59 {
+		return 0, false
+	}
+	offset := hours*3600 + minutes*60
+	if name[0] == '-' {
+		offset = -offset
+	}
+	return offset, true
+}
@@ -0,0 +1,102 @@
+package scene
+
+import (
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+
+	"gorm.io/datatypes"
+)
+
+// at builds a time on a known weekday: 2026-08-31 is a Monday.
+func at(t *testing.T, weekdayOffset int, hhmm string, loc *time.Location) time.Time {
+	t.Helper()
+	parsed, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		t.Fatalf("bad test time %q: %v", hhmm, err)
+	}
+	return time.Date(2026, 8, 31+weekdayOffset, parsed.Hour(), parsed.Minute(), 0, 0, loc)
+}
+
+func TestIsOpenAtMidnightCrossingWindow(t *testing.T) {
+	// Monday 20:00 – Tuesday 02:00.
+	windows := []OpenWindow{{Weekday: 1, Start: "20:00", End: "02:00"}}
+	loc := time.UTC
+
+	cases := []struct {
+		name string
+		when time.Time
+		want bool
+	}{
+		{"before opening", at(t, 0, "19:59", loc), false},
+		{"at opening", at(t, 0, "20:00", loc), true},
+		{"just before midnight", at(t, 0, "23:59", loc), true},
+		{"after midnight next day", at(t, 1, "01:59", loc), true},
+		{"at close", at(t, 1, "02:00", loc), false},
+		{"wrong day same hours", at(t, 2, "21:00", loc), false},
+	}
+	for _, tc := range cases {
+		if got := isOpenAt(windows, tc.when); got != tc.want {
+			t.Errorf("%s: isOpenAt=%v want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNextOpenAtPicksEarliestStart(t *testing.T) {
+	windows := []OpenWindow{
+		{Weekday: 1, Start: "20:00", End: "02:00"}, // Monday night
+		{Weekday: 3, Start: "10:00", End: "12:00"}, // Wednesday morning
+	}
+	now := at(t, 1, "03:00", time.UTC) // Tuesday, both windows closed
+
+	next := nextOpenAt(windows, now)
+	if next == nil {
+		t.Fatal("expected a next opening time")
+	}
+	want := at(t, 2, "10:00", time.UTC)
+	if !next.Equal(want) {
+		t.Fatalf("expected next open %v, got %v", want, next)
+	}
+
+	// From Thursday the earliest is the following Monday.
+	next = nextOpenAt(windows, at(t, 3, "12:00", time.UTC))
+	want = at(t, 7, "20:00", time.UTC)
+	if next == nil || !next.Equal(want) {
+		t.Fatalf("expected next open %v, got %v", want, next)
+	}
+}
+
+func TestParseFixedOffset(t *testing.T) {
+	cases := []struct {
+		name   string
+		offset int
+		ok     bool
+	}{
+		{"+08:00", 8 * 3600, true},
+		{"-05:30", -(5*3600 + 30*60), true},
+		{"+0800", 8 * 3600, true},
+		{"+8", 8 * 3600, true},
+		{"UTC", 0, false},
+		{"+25:00", 0, false},
+	}
+	for _, tc := range cases {
+		offset, ok := parseFixedOffset(tc.name)
+		if ok != tc.ok || offset != tc.offset {
+			t.Errorf("parseFixedOffset(%q) = (%d, %v), want (%d, %v)", tc.name, offset, ok, tc.offset, tc.ok)
+		}
+	}
+}
+
+func TestOpenStateFailsOpenOnMalformedHours(t *testing.T) {
+	scene := model.Scene{OpenHoursJSON: datatypes.JSON(`{"not":"a list"}`)}
+	open, next := OpenState(scene, time.Now())
+	if !open || next != nil {
+		t.Fatal("malformed open hours must fail open")
+	}
+
+	scene = model.Scene{}
+	if open, _ := OpenState(scene, time.Now()); !open {
+		t.Fatal("empty open hours means always open")
+	}
+}
@@ -0,0 +1,33 @@
+package scene
+
+import (
+	"time"
+
+	"dx-service/internal/model"
+)
+
+// Rake-free "happy hour" windows reuse the open-hours shape and evaluation:
+// the same weekday/HH:MM ranges, the same midnight-crossing rule and the same
+// server timezone. While a window covers a match's settlement time no rake is
+// charged, so agent shares and platform income for that match are zero.
+
+// ParseRakeFreeWindows decodes Scene.RakeFreeWindowsJSON. An empty value
+// means rake always applies.
+func ParseRakeFreeWindows(raw []byte) ([]OpenWindow, error) {
+	return parseWindowList(raw, "rake-free windows")
+}
+
+// RakeFreeState returns the window covering now, or, when none does, the
+// start of the next one. Unlike open hours, malformed windows fail closed —
+// rake keeps being charged — so a bad admin edit cannot zero out revenue.
+func RakeFreeState(scene model.Scene, now time.Time) (*OpenWindow, *time.Time) {
+	windows, err := ParseRakeFreeWindows(scene.RakeFreeWindowsJSON)
+	if err != nil || len(windows) == 0 {
+		return nil, nil
+	}
+	local := now.In(serverLocation())
+	if w := windowCovering(windows, local); w != nil {
+		return w, nil
+	}
+	return nil, nextOpenAt(windows, local)
+}
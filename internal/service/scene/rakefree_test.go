@@ -0,0 +1,59 @@
+package scene
+
+import (
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+
+	"gorm.io/datatypes"
+)
+
+func TestRakeFreeStateBoundaries(t *testing.T) {
+	// Monday 18:00–20:00 plus a midnight-crossing Friday 22:00–01:00.
+	scene := model.Scene{RakeFreeWindowsJSON: datatypes.JSON(
+		`[{"weekday":1,"start":"18:00","end":"20:00"},{"weekday":5,"start":"22:00","end":"01:00"}]`,
+	)}
+
+	cases := []struct {
+		name string
+		when time.Time
+		free bool
+	}{
+		{"before start", at(t, 0, "17:59", time.Local), false},
+		{"at start", at(t, 0, "18:00", time.Local), true},
+		{"just before end", at(t, 0, "19:59", time.Local), true},
+		{"at end", at(t, 0, "20:00", time.Local), false},
+		{"friday into saturday", at(t, 5, "00:59", time.Local), true},
+		{"saturday after close", at(t, 5, "01:00", time.Local), false},
+	}
+	for _, tc := range cases {
+		window, _ := RakeFreeState(scene, tc.when)
+		if (window != nil) != tc.free {
+			t.Errorf("%s: rake-free=%v want %v", tc.name, window != nil, tc.free)
+		}
+	}
+
+	// Outside every window the next start is reported for the lobby banner.
+	window, next := RakeFreeState(scene, at(t, 1, "12:00", time.Local))
+	if window != nil {
+		t.Fatal("tuesday noon must not be rake-free")
+	}
+	want := at(t, 4, "22:00", time.Local)
+	if next == nil || !next.Equal(want) {
+		t.Fatalf("expected next window at %v, got %v", want, next)
+	}
+}
+
+func TestRakeFreeStateFailsClosed(t *testing.T) {
+	// Opposite of open hours: a malformed schedule must keep charging rake.
+	scene := model.Scene{RakeFreeWindowsJSON: datatypes.JSON(`{"not":"a list"}`)}
+	if window, next := RakeFreeState(scene, time.Now()); window != nil || next != nil {
+		t.Fatal("malformed rake-free windows must fail closed")
+	}
+
+	scene = model.Scene{}
+	if window, _ := RakeFreeState(scene, time.Now()); window != nil {
+		t.Fatal("empty rake-free windows means rake always applies")
+	}
+}
@@ -2,12 +2,15 @@ package scene
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"dx-service/internal/model"
 	appErr "dx-service/pkg/errors"
 	"dx-service/pkg/logger"
 
 	"go.uber.org/zap"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -25,27 +28,114 @@ type SceneListResult struct {
 }
 
 type SceneMutationParams struct {
-	Name               string
-	SeatCount          int
-	MinIn              int64
-	MaxIn              int64
-	BasePi             int64
-	MinUnitPi          int64
-	MangoEnabled       bool
-	BoboEnabled        bool
-	DistanceThresholdM int
-	Status             string
-	RakeRuleID         int64
+	Name                  string
+	NameI18nJSON          []byte
+	SeatCount             int
+	MinIn                 int64
+	MaxIn                 int64
+	BasePi                int64
+	MinUnitPi             int64
+	MangoEnabled          bool
+	BoboEnabled           bool
+	DistanceThresholdM    int
+	TimeBankSeconds       int
+	TimeBankBudgetSeconds int
+	TimeBankRefreshes     bool
+	ReadyTimeoutSeconds   int
+	MaxConsecutiveHands   int
+	SpectatorDelaySeconds int
+	MaxPotMultiplier      int
+	Status                string
+	SortOrder             int
+	TimeoutPolicy         string
+	RequireVerification   bool
+	TrialMode             bool
+	RakeRuleID            int64
+	OpenHoursJSON         []byte
+	RakeFreeWindowsJSON   []byte
+	AllowedActionsJSON    []byte
+}
+
+// SceneWithSchedule decorates a scene with its current open-hours state for
+// the player-facing listing.
+type SceneWithSchedule struct {
+	model.Scene
+	Open       bool       `json:"open"`
+	NextOpenAt *time.Time `json:"nextOpenAt,omitempty"`
+	// RakeFree flags a currently running happy hour; NextRakeFreeAt is the
+	// start of the next window when none is active, so the client can
+	// advertise it ahead of time.
+	RakeFree       bool       `json:"rakeFree"`
+	NextRakeFreeAt *time.Time `json:"nextRakeFreeAt,omitempty"`
+	// DisplayName is Name resolved for the requesting user's locale.
+	DisplayName string      `json:"displayName"`
+	RuleSummary RuleSummary `json:"ruleSummary"`
 }
 
 func (s *Service) ListScenes(ctx context.Context) ([]model.Scene, error) {
 	var scenes []model.Scene
-	if err := s.db.WithContext(ctx).Find(&scenes).Error; err != nil {
+	if err := s.db.WithContext(ctx).
+		Order("sort_order ASC, id ASC").
+		Find(&scenes).Error; err != nil {
 		return nil, err
 	}
 	return scenes, nil
 }
 
+// ListScenesWithSchedule is ListScenes plus the open/closed flag, the next
+// opening time and the display name resolved for the caller's locale.
+func (s *Service) ListScenesWithSchedule(ctx context.Context, locale string) ([]SceneWithSchedule, error) {
+	scenes, err := s.ListScenes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.loadRakeRules(ctx, scenes)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := make([]SceneWithSchedule, 0, len(scenes))
+	for _, sc := range scenes {
+		open, nextOpenAt := OpenState(sc, now)
+		rakeFreeWindow, nextRakeFreeAt := RakeFreeState(sc, now)
+		result = append(result, SceneWithSchedule{
+			Scene:          sc,
+			Open:           open,
+			NextOpenAt:     nextOpenAt,
+			RakeFree:       rakeFreeWindow != nil,
+			NextRakeFreeAt: nextRakeFreeAt,
+			DisplayName:    LocalizedName(sc, locale),
+			RuleSummary:    BuildRuleSummary(sc, rules[sc.RakeRuleID]),
+		})
+	}
+	return result, nil
+}
+
+// loadRakeRules fetches the rake rules the given scenes reference, keyed by
+// rule ID, in a single query.
+func (s *Service) loadRakeRules(ctx context.Context, scenes []model.Scene) (map[int64]*model.RakeRule, error) {
+	ids := make([]int64, 0, len(scenes))
+	seen := make(map[int64]bool)
+	for _, sc := range scenes {
+		if sc.RakeRuleID != 0 && !seen[sc.RakeRuleID] {
+			seen[sc.RakeRuleID] = true
+			ids = append(ids, sc.RakeRuleID)
+		}
+	}
+	rules := make(map[int64]*model.RakeRule, len(ids))
+	if len(ids) == 0 {
+		return rules, nil
+	}
+	var rows []model.RakeRule
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rules[rows[i].ID] = &rows[i]
+	}
+	return rules, nil
+}
+
 func (s *Service) AdminListScenes(ctx context.Context, page, size int) (*SceneListResult, error) {
 	if page < 1 {
 		page = 1
@@ -84,18 +174,53 @@ func (s *Service) AdminListScenes(ctx context.Context, page, size int) (*SceneLi
 }
 
 func (s *Service) CreateScene(ctx context.Context, params SceneMutationParams) (*model.Scene, error) {
+	if len(params.OpenHoursJSON) > 0 {
+		if _, err := ParseOpenHours(params.OpenHoursJSON); err != nil {
+			return nil, fmt.Errorf("invalid scene payload: %w", err)
+		}
+	}
+	if len(params.RakeFreeWindowsJSON) > 0 {
+		if _, err := ParseRakeFreeWindows(params.RakeFreeWindowsJSON); err != nil {
+			return nil, fmt.Errorf("invalid scene payload: %w", err)
+		}
+	}
+	if len(params.NameI18nJSON) > 0 {
+		if _, err := ParseNameI18n(params.NameI18nJSON); err != nil {
+			return nil, fmt.Errorf("invalid scene payload: %w", err)
+		}
+	}
+	if len(params.AllowedActionsJSON) > 0 {
+		if _, err := ParseAllowedActions(params.AllowedActionsJSON); err != nil {
+			return nil, fmt.Errorf("invalid scene payload: %w", err)
+		}
+	}
 	scene := model.Scene{
-		Name:               params.Name,
-		SeatCount:          params.SeatCount,
-		MinIn:              params.MinIn,
-		MaxIn:              params.MaxIn,
-		BasePi:             params.BasePi,
-		MinUnitPi:          params.MinUnitPi,
-		MangoEnabled:       params.MangoEnabled,
-		BoboEnabled:        params.BoboEnabled,
-		DistanceThresholdM: params.DistanceThresholdM,
-		Status:             params.Status,
-		RakeRuleID:         params.RakeRuleID,
+		Name:                  params.Name,
+		NameI18nJSON:          datatypes.JSON(params.NameI18nJSON),
+		SeatCount:             params.SeatCount,
+		MinIn:                 params.MinIn,
+		MaxIn:                 params.MaxIn,
+		BasePi:                params.BasePi,
+		MinUnitPi:             params.MinUnitPi,
+		MangoEnabled:          params.MangoEnabled,
+		BoboEnabled:           params.BoboEnabled,
+		DistanceThresholdM:    params.DistanceThresholdM,
+		TimeBankSeconds:       params.TimeBankSeconds,
+		TimeBankBudgetSeconds: params.TimeBankBudgetSeconds,
+		TimeBankRefreshes:     params.TimeBankRefreshes,
+		ReadyTimeoutSeconds:   params.ReadyTimeoutSeconds,
+		MaxConsecutiveHands:   params.MaxConsecutiveHands,
+		SpectatorDelaySeconds: params.SpectatorDelaySeconds,
+		MaxPotMultiplier:      params.MaxPotMultiplier,
+		Status:                params.Status,
+		SortOrder:             params.SortOrder,
+		TimeoutPolicy:         params.TimeoutPolicy,
+		RequireVerification:   params.RequireVerification,
+		TrialMode:             params.TrialMode,
+		RakeRuleID:            params.RakeRuleID,
+		OpenHoursJSON:         datatypes.JSON(params.OpenHoursJSON),
+		RakeFreeWindowsJSON:   datatypes.JSON(params.RakeFreeWindowsJSON),
+		AllowedActionsJSON:    datatypes.JSON(params.AllowedActionsJSON),
 	}
 	if err := s.db.WithContext(ctx).Create(&scene).Error; err != nil {
 		return nil, err
@@ -104,18 +229,53 @@ func (s *Service) CreateScene(ctx context.Context, params SceneMutationParams) (
 }
 
 func (s *Service) UpdateScene(ctx context.Context, id int64, params SceneMutationParams) (*model.Scene, error) {
+	if len(params.OpenHoursJSON) > 0 {
+		if _, err := ParseOpenHours(params.OpenHoursJSON); err != nil {
+			return nil, fmt.Errorf("invalid scene payload: %w", err)
+		}
+	}
+	if len(params.RakeFreeWindowsJSON) > 0 {
+		if _, err := ParseRakeFreeWindows(params.RakeFreeWindowsJSON); err != nil {
+			return nil, fmt.Errorf("invalid scene payload: %w", err)
+		}
+	}
+	if len(params.NameI18nJSON) > 0 {
+		if _, err := ParseNameI18n(params.NameI18nJSON); err != nil {
+			return nil, fmt.Errorf("invalid scene payload: %w", err)
+		}
+	}
+	if len(params.AllowedActionsJSON) > 0 {
+		if _, err := ParseAllowedActions(params.AllowedActionsJSON); err != nil {
+			return nil, fmt.Errorf("invalid scene payload: %w", err)
+		}
+	}
 	updates := map[string]interface{}{
-		"name":                 params.Name,
-		"seat_count":           params.SeatCount,
-		"min_in":               params.MinIn,
-		"max_in":               params.MaxIn,
-		"base_pi":              params.BasePi,
-		"min_unit_pi":          params.MinUnitPi,
-		"mango_enabled":        params.MangoEnabled,
-		"bobo_enabled":         params.BoboEnabled,
-		"distance_threshold_m": params.DistanceThresholdM,
-		"status":               params.Status,
-		"rake_rule_id":         params.RakeRuleID,
+		"name":                     params.Name,
+		"name_i18n_json":           datatypes.JSON(params.NameI18nJSON),
+		"seat_count":               params.SeatCount,
+		"min_in":                   params.MinIn,
+		"max_in":                   params.MaxIn,
+		"base_pi":                  params.BasePi,
+		"min_unit_pi":              params.MinUnitPi,
+		"mango_enabled":            params.MangoEnabled,
+		"bobo_enabled":             params.BoboEnabled,
+		"distance_threshold_m":     params.DistanceThresholdM,
+		"time_bank_seconds":        params.TimeBankSeconds,
+		"time_bank_budget_seconds": params.TimeBankBudgetSeconds,
+		"time_bank_refreshes":      params.TimeBankRefreshes,
+		"ready_timeout_seconds":    params.ReadyTimeoutSeconds,
+		"max_consecutive_hands":    params.MaxConsecutiveHands,
+		"spectator_delay_seconds":  params.SpectatorDelaySeconds,
+		"max_pot_multiplier":       params.MaxPotMultiplier,
+		"status":                   params.Status,
+		"sort_order":               params.SortOrder,
+		"timeout_policy":           params.TimeoutPolicy,
+		"require_verification":     params.RequireVerification,
+		"trial_mode":               params.TrialMode,
+		"rake_rule_id":             params.RakeRuleID,
+		"open_hours_json":          datatypes.JSON(params.OpenHoursJSON),
+		"rake_free_windows_json":   datatypes.JSON(params.RakeFreeWindowsJSON),
+		"allowed_actions_json":     datatypes.JSON(params.AllowedActionsJSON),
 	}
 
 	result := s.db.WithContext(ctx).
@@ -136,6 +296,49 @@ func (s *Service) UpdateScene(ctx context.Context, id int64, params SceneMutatio
 	return &scene, nil
 }
 
+// BulkStatusEntry reports one scene's outcome from a bulk status change.
+type BulkStatusEntry struct {
+	SceneID int64  `json:"sceneId"`
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkSetStatus flips the status of many scenes at once. guard (nil = no
+// check) runs before each disable so callers can refuse scenes that still
+// have live tables or queued players; a refused or missing scene is reported
+// in its entry instead of aborting the rest of the batch.
+func (s *Service) BulkSetStatus(ctx context.Context, sceneIDs []int64, status string, guard func(context.Context, int64) error) ([]BulkStatusEntry, error) {
+	if status != "enabled" && status != "disabled" {
+		return nil, fmt.Errorf("invalid scene payload: status must be enabled or disabled")
+	}
+
+	results := make([]BulkStatusEntry, 0, len(sceneIDs))
+	for _, id := range sceneIDs {
+		entry := BulkStatusEntry{SceneID: id}
+		if status == "disabled" && guard != nil {
+			if err := guard(ctx, id); err != nil {
+				entry.Error = err.Error()
+				results = append(results, entry)
+				continue
+			}
+		}
+		res := s.db.WithContext(ctx).
+			Model(&model.Scene{}).
+			Where("id = ?", id).
+			Update("status", status)
+		switch {
+		case res.Error != nil:
+			entry.Error = res.Error.Error()
+		case res.RowsAffected == 0:
+			entry.Error = appErr.ErrSceneNotFound.Error()
+		default:
+			entry.OK = true
+		}
+		results = append(results, entry)
+	}
+	return results, nil
+}
+
 func (s *Service) GetScene(ctx context.Context, id int64) (*model.Scene, error) {
 	var scene model.Scene
 	if err := s.db.WithContext(ctx).First(&scene, id).Error; err != nil {
@@ -0,0 +1,121 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"dx-service/internal/model"
+)
+
+// RuleSummary is the player-facing explanation of a scene's variant,
+// assembled server-side so clients never re-implement the derivations the
+// runtime uses.
+type RuleSummary struct {
+	FirstBet     int64  `json:"firstBet"` // opening bet: 2x basePi
+	MinRaise     int64  `json:"minRaise"` // smallest raise step: 5x minUnitPi
+	BoboEnabled  bool   `json:"boboEnabled"`
+	MangoEnabled bool   `json:"mangoEnabled"`
+	TurnSeconds  int    `json:"turnSeconds"`
+	Rake         string `json:"rake"`
+	Trial        bool   `json:"trial"` // play-money scene, no points at stake
+}
+
+// summaryTurnSeconds mirrors the runtime's default turn length; per-table
+// admin adjustments are runtime-only and not reflected in the listing.
+const summaryTurnSeconds = 15
+
+// BuildRuleSummary derives the summary from a scene and its referenced rake
+// rule; rule may be nil when the scene has none configured.
+func BuildRuleSummary(sc model.Scene, rule *model.RakeRule) RuleSummary {
+	if sc.TrialMode {
+		// Trial settlement ignores any configured rake rule, so the listing
+		// must not advertise one.
+		rule = nil
+	}
+	return RuleSummary{
+		FirstBet:     2 * sc.BasePi,
+		MinRaise:     5 * sc.MinUnitPi,
+		BoboEnabled:  sc.BoboEnabled,
+		MangoEnabled: sc.MangoEnabled,
+		TurnSeconds:  summaryTurnSeconds,
+		Rake:         RakeDescription(rule),
+		Trial:        sc.TrialMode,
+	}
+}
+
+// RakeDescription renders a rake rule as one plain-language sentence, e.g.
+// "5% of winnings, capped at 1000". Unknown types and unparseable configs
+// read as no rake, matching what settlement would actually charge.
+func RakeDescription(rule *model.RakeRule) string {
+	if rule == nil {
+		return "no rake"
+	}
+	switch strings.ToLower(rule.Type) {
+	case "ratio":
+		var cfg struct {
+			Ratio float64 `json:"ratio"`
+			Cap   int64   `json:"cap"`
+		}
+		if err := json.Unmarshal(rule.ConfigJSON, &cfg); err != nil || cfg.Ratio <= 0 {
+			return "no rake"
+		}
+		desc := fmt.Sprintf("%s of winnings", formatPercent(cfg.Ratio))
+		if cfg.Cap > 0 {
+			desc += fmt.Sprintf(", capped at %d", cfg.Cap)
+		}
+		return desc
+	case "fixed":
+		var cfg struct {
+			Amount int64 `json:"amount"`
+		}
+		if err := json.Unmarshal(rule.ConfigJSON, &cfg); err != nil || cfg.Amount <= 0 {
+			return "no rake"
+		}
+		return fmt.Sprintf("fixed %d per winning hand", cfg.Amount)
+	case "ladder":
+		var steps []struct {
+			Min   int64   `json:"min"`
+			Max   int64   `json:"max"`
+			Ratio float64 `json:"ratio"`
+			Value int64   `json:"value"`
+		}
+		if err := json.Unmarshal(rule.ConfigJSON, &steps); err != nil || len(steps) == 0 {
+			return "no rake"
+		}
+		parts := make([]string, 0, len(steps))
+		for _, step := range steps {
+			var charge string
+			switch {
+			case step.Ratio > 0:
+				charge = formatPercent(step.Ratio)
+			case step.Value > 0:
+				charge = fmt.Sprintf("fixed %d", step.Value)
+			default:
+				continue
+			}
+			switch {
+			case step.Min > 0 && step.Max > 0:
+				parts = append(parts, fmt.Sprintf("wins %d-%d: %s", step.Min, step.Max, charge))
+			case step.Min > 0:
+				parts = append(parts, fmt.Sprintf("wins %d and above: %s", step.Min, charge))
+			case step.Max > 0:
+				parts = append(parts, fmt.Sprintf("wins up to %d: %s", step.Max, charge))
+			default:
+				parts = append(parts, fmt.Sprintf("all wins: %s", charge))
+			}
+		}
+		if len(parts) == 0 {
+			return "no rake"
+		}
+		return strings.Join(parts, "; ")
+	}
+	return "no rake"
+}
+
+// formatPercent renders a ratio as a percentage without trailing zeros, so
+// 0.05 reads "5%" and 0.025 reads "2.5%".
+func formatPercent(ratio float64) string {
+	return strconv.FormatFloat(ratio*100, 'f', -1, 64) + "%"
+}
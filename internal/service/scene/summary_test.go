@@ -0,0 +1,84 @@
+package scene_test
+
+import (
+	"testing"
+
+	"dx-service/internal/model"
+	"dx-service/internal/service/scene"
+
+	"gorm.io/datatypes"
+)
+
+func TestBuildRuleSummaryDerivesBettingValues(t *testing.T) {
+	sc := model.Scene{
+		BasePi:       100,
+		MinUnitPi:    20,
+		BoboEnabled:  true,
+		MangoEnabled: false,
+	}
+
+	summary := scene.BuildRuleSummary(sc, nil)
+	if summary.FirstBet != 200 {
+		t.Fatalf("expected firstBet 2x basePi = 200, got %d", summary.FirstBet)
+	}
+	if summary.MinRaise != 100 {
+		t.Fatalf("expected minRaise 5x minUnitPi = 100, got %d", summary.MinRaise)
+	}
+	if !summary.BoboEnabled || summary.MangoEnabled {
+		t.Fatalf("expected feature flags carried through, got bobo %v mango %v",
+			summary.BoboEnabled, summary.MangoEnabled)
+	}
+	if summary.Rake != "no rake" {
+		t.Fatalf("expected no rake without a rule, got %q", summary.Rake)
+	}
+}
+
+func TestRakeDescriptionCoversAllRuleTypes(t *testing.T) {
+	cases := []struct {
+		name string
+		rule *model.RakeRule
+		want string
+	}{
+		{
+			name: "ratio with cap",
+			rule: &model.RakeRule{Type: "ratio", ConfigJSON: datatypes.JSON(`{"ratio":0.05,"cap":1000}`)},
+			want: "5% of winnings, capped at 1000",
+		},
+		{
+			name: "ratio without cap",
+			rule: &model.RakeRule{Type: "ratio", ConfigJSON: datatypes.JSON(`{"ratio":0.025}`)},
+			want: "2.5% of winnings",
+		},
+		{
+			name: "fixed",
+			rule: &model.RakeRule{Type: "fixed", ConfigJSON: datatypes.JSON(`{"amount":200}`)},
+			want: "fixed 200 per winning hand",
+		},
+		{
+			name: "ladder",
+			rule: &model.RakeRule{Type: "ladder", ConfigJSON: datatypes.JSON(
+				`[{"max":500,"value":10},{"min":501,"max":2000,"ratio":0.03},{"min":2001,"ratio":0.05}]`)},
+			want: "wins up to 500: fixed 10; wins 501-2000: 3%; wins 2001 and above: 5%",
+		},
+		{
+			name: "unknown type",
+			rule: &model.RakeRule{Type: "progressive", ConfigJSON: datatypes.JSON(`{}`)},
+			want: "no rake",
+		},
+		{
+			name: "corrupt config",
+			rule: &model.RakeRule{Type: "ratio", ConfigJSON: datatypes.JSON(`not json`)},
+			want: "no rake",
+		},
+		{
+			name: "nil rule",
+			rule: nil,
+			want: "no rake",
+		},
+	}
+	for _, tc := range cases {
+		if got := scene.RakeDescription(tc.rule); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
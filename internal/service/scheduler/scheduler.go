@@ -0,0 +1,252 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const defaultJobTimeout = time.Minute
+
+// JobFunc is the body of a periodic job. The context carries the per-job
+// timeout; long jobs should honor it.
+type JobFunc func(ctx context.Context) error
+
+// Job describes a periodic task. Jobs with NeedsLock take a Redis SET NX lock
+// before running so only one instance executes them per interval.
+type Job struct {
+	Name      string
+	Interval  time.Duration
+	Timeout   time.Duration // defaults to one minute
+	NeedsLock bool
+	Run       JobFunc
+}
+
+// JobStatus is the read model exposed via GET /admin/jobs.
+type JobStatus struct {
+	Name         string     `json:"name"`
+	Interval     string     `json:"interval"`
+	NeedsLock    bool       `json:"needsLock"`
+	Running      bool       `json:"running"`
+	LastRunAt    *time.Time `json:"lastRunAt,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+	LastDuration string     `json:"lastDuration,omitempty"`
+	NextRunAt    *time.Time `json:"nextRunAt,omitempty"`
+}
+
+type jobState struct {
+	job          Job
+	running      bool
+	lastRunAt    *time.Time
+	lastError    string
+	lastDuration time.Duration
+	nextRunAt    *time.Time
+}
+
+// Scheduler runs registered jobs on their intervals with panic recovery,
+// per-job timeouts and optional cross-instance locking.
+type Scheduler struct {
+	rdb *redis.Client
+
+	mu      sync.Mutex
+	jobs    map[string]*jobState
+	order   []string
+	started bool
+}
+
+func NewScheduler(rdb *redis.Client) *Scheduler {
+	return &Scheduler{
+		rdb:  rdb,
+		jobs: make(map[string]*jobState),
+	}
+}
+
+// Register adds a job; it must be called before Start.
+func (s *Scheduler) Register(job Job) {
+	if job.Timeout <= 0 {
+		job.Timeout = defaultJobTimeout
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.Name]; ok {
+		return
+	}
+	s.jobs[job.Name] = &jobState{job: job}
+	s.order = append(s.order, job.Name)
+}
+
+// Start launches one ticker goroutine per registered job. It is idempotent.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = true
+	names := append([]string(nil), s.order...)
+	s.mu.Unlock()
+
+	for _, name := range names {
+		go s.runLoop(ctx, name)
+	}
+}
+
+func (s *Scheduler) runLoop(ctx context.Context, name string) {
+	s.mu.Lock()
+	state := s.jobs[name]
+	interval := state.job.Interval
+	next := time.Now().Add(interval)
+	state.nextRunAt = &next
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.execute(name, false)
+			s.mu.Lock()
+			next := time.Now().Add(interval)
+			s.jobs[name].nextRunAt = &next
+			s.mu.Unlock()
+		}
+	}
+}
+
+// RunNow triggers a job outside its schedule (POST /admin/jobs/:name/run).
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.Lock()
+	_, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return appErr.ErrJobNotFound
+	}
+	return s.execute(name, true)
+}
+
+func (s *Scheduler) execute(name string, manual bool) error {
+	s.mu.Lock()
+	state := s.jobs[name]
+	if state.running {
+		s.mu.Unlock()
+		return appErr.ErrJobAlreadyRunning
+	}
+	state.running = true
+	job := state.job
+	s.mu.Unlock()
+
+	started := time.Now()
+	err := s.runGuarded(job, manual)
+	duration := time.Since(started)
+
+	s.mu.Lock()
+	state.running = false
+	state.lastRunAt = &started
+	state.lastDuration = duration
+	if err != nil {
+		state.lastError = err.Error()
+	} else {
+		state.lastError = ""
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		logger.Log.Warn("job failed",
+			zap.String("job", name),
+			zap.Duration("duration", duration),
+			zap.Error(err),
+		)
+	}
+	return err
+}
+
+func (s *Scheduler) runGuarded(job Job, manual bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+			logger.Log.Error("job panic",
+				zap.String("job", job.Name),
+				zap.Any("panic", r),
+				zap.ByteString("stack", debug.Stack()),
+			)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), job.Timeout)
+	defer cancel()
+
+	if job.NeedsLock && !manual {
+		acquired, release, lockErr := s.acquireLock(ctx, job)
+		if lockErr != nil {
+			return lockErr
+		}
+		if !acquired {
+			// Another instance holds the lock this interval; not a failure.
+			return nil
+		}
+		defer release()
+	}
+
+	return job.Run(ctx)
+}
+
+func buildJobLockKey(name string) string {
+	return fmt.Sprintf("job:lock:%s", name)
+}
+
+func (s *Scheduler) acquireLock(ctx context.Context, job Job) (bool, func(), error) {
+	if s.rdb == nil {
+		return true, func() {}, nil
+	}
+	key := buildJobLockKey(job.Name)
+	ttl := job.Interval
+	if job.Timeout > ttl {
+		ttl = job.Timeout
+	}
+	ok, err := s.rdb.SetNX(ctx, key, 1, ttl).Result()
+	if err != nil {
+		return false, nil, err
+	}
+	if !ok {
+		return false, nil, nil
+	}
+	return true, func() {
+		s.rdb.Del(context.Background(), key)
+	}, nil
+}
+
+// Jobs returns statuses in registration order.
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(s.order))
+	for _, name := range s.order {
+		state := s.jobs[name]
+		status := JobStatus{
+			Name:      name,
+			Interval:  state.job.Interval.String(),
+			NeedsLock: state.job.NeedsLock,
+			Running:   state.running,
+			LastRunAt: state.lastRunAt,
+			LastError: state.lastError,
+			NextRunAt: state.nextRunAt,
+		}
+		if state.lastDuration > 0 {
+			status.LastDuration = state.lastDuration.String()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
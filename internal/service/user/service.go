@@ -20,6 +20,10 @@ const (
 
 type Service struct {
 	db *gorm.DB
+
+	// verifier checks real-name submissions; nil falls back to the stub so
+	// bare test services and dev setups never block on a vendor.
+	verifier VerificationProvider
 }
 
 type UpdateProfileRequest struct {
@@ -187,3 +191,29 @@ func (s *Service) AdminUpdateUserStatus(ctx context.Context, userID int64, statu
 
 	return s.AdminGetUser(ctx, userID)
 }
+
+// AdminSetGeoBypass toggles the per-user exemption from the region
+// blocklist, for accounts compliance has cleared individually.
+func (s *Service) AdminSetGeoBypass(ctx context.Context, userID int64, allow bool, reason string) (*model.User, error) {
+	reason = strings.TrimSpace(reason)
+
+	res := s.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"geo_bypass": allow,
+			"updated_at": time.Now(),
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, appErr.ErrUserNotFound
+	}
+
+	logger.Log.Info("admin updated user geo bypass",
+		zap.Int64("userID", userID),
+		zap.Bool("allow", allow),
+		zap.String("reason", reason))
+
+	return s.AdminGetUser(ctx, userID)
+}
@@ -0,0 +1,345 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Verification statuses carried by User.VerifyStatus and
+// VerificationRequest.Status.
+const (
+	VerifyStatusUnverified = "unverified"
+	VerifyStatusPending    = "pending"
+	VerifyStatusVerified   = "verified"
+	VerifyStatusFailed     = "failed"
+	VerifyStatusRejected   = "rejected"
+)
+
+// VerificationProvider checks a submitted name + ID number pair against an
+// identity source. ok=false with a nil error is a definitive mismatch; an
+// error means the provider could not decide and the submission goes to the
+// admin review queue.
+type VerificationProvider interface {
+	Verify(ctx context.Context, name, idNumber string) (ok bool, message string, err error)
+}
+
+// StubProvider accepts every structurally valid submission. It is the
+// default for development and for deployments without a verification vendor.
+type StubProvider struct{}
+
+func (StubProvider) Verify(context.Context, string, string) (bool, string, error) {
+	return true, "stub accepted", nil
+}
+
+// HTTPProvider posts submissions to a verification vendor endpoint and
+// expects {"verified": bool, "message": string} back.
+type HTTPProvider struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+func (p *HTTPProvider) Verify(ctx context.Context, name, idNumber string) (bool, string, error) {
+	payload, err := json.Marshal(map[string]string{"name": name, "idNumber": idNumber})
+	if err != nil {
+		return false, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("verification provider returned %d", resp.StatusCode)
+	}
+	var body struct {
+		Verified bool   `json:"verified"`
+		Message  string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, "", err
+	}
+	return body.Verified, body.Message, nil
+}
+
+// ProviderFromConfig picks the verification provider the deployment
+// configured; anything unrecognized (or nothing) is the stub.
+func ProviderFromConfig() VerificationProvider {
+	if config.GlobalConfig == nil {
+		return StubProvider{}
+	}
+	cfg := config.GlobalConfig.Verify
+	if cfg.Provider == "http" && cfg.Endpoint != "" {
+		timeout := time.Duration(cfg.TimeoutMS) * time.Millisecond
+		if timeout <= 0 {
+			timeout = 5 * time.Second
+		}
+		return &HTTPProvider{Endpoint: cfg.Endpoint, Client: &http.Client{Timeout: timeout}}
+	}
+	return StubProvider{}
+}
+
+// SetVerificationProvider wires the identity provider in after construction.
+func (s *Service) SetVerificationProvider(p VerificationProvider) {
+	s.verifier = p
+}
+
+// VerificationResult is what a submission returns to the client: the user's
+// new status plus the provider's message when the check did not pass.
+type VerificationResult struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// SubmitVerification runs the real-name check for a user. A provider pass
+// verifies immediately; a mismatch or provider outage parks the user as
+// pending with the request queued for admin review.
+func (s *Service) SubmitVerification(ctx context.Context, userID int64, name, idNumber string) (*VerificationResult, error) {
+	name = strings.TrimSpace(name)
+	idNumber = strings.TrimSpace(idNumber)
+	if name == "" || len(idNumber) < 6 || len(idNumber) > 32 {
+		return nil, appErr.ErrInvalidVerification
+	}
+
+	var user model.User
+	if err := s.db.WithContext(ctx).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErr.ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.VerifyStatus == VerifyStatusVerified {
+		return nil, appErr.ErrAlreadyVerified
+	}
+
+	encrypted, err := encryptIDNumber(idNumber)
+	if err != nil {
+		return nil, err
+	}
+	masked := maskIDNumber(idNumber)
+
+	request := model.VerificationRequest{
+		UserID:         userID,
+		Name:           name,
+		IDNumberEnc:    encrypted,
+		IDNumberMasked: masked,
+		Status:         VerifyStatusPending,
+	}
+
+	ok, message, provErr := s.verifyWithProvider(ctx, name, idNumber)
+	switch {
+	case provErr != nil:
+		// Provider could not decide; keep the submission reviewable instead
+		// of bouncing the user into a retry loop.
+		request.Status = VerifyStatusFailed
+		request.ProviderMsg = provErr.Error()
+		logger.WithContext(ctx).Warn("verification provider error",
+			zap.Int64("userID", userID),
+			zap.Error(provErr),
+		)
+	case ok:
+		request.Status = VerifyStatusVerified
+		request.ProviderMsg = message
+	default:
+		request.Status = VerifyStatusFailed
+		request.ProviderMsg = message
+	}
+
+	if err := s.db.WithContext(ctx).Create(&request).Error; err != nil {
+		return nil, err
+	}
+
+	userStatus := VerifyStatusPending
+	if request.Status == VerifyStatusVerified {
+		userStatus = VerifyStatusVerified
+	}
+	updates := map[string]interface{}{
+		"verify_status":    userStatus,
+		"verified_name":    name,
+		"id_number_enc":    encrypted,
+		"id_number_masked": masked,
+	}
+	if err := s.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	result := &VerificationResult{Status: userStatus}
+	if userStatus != VerifyStatusVerified {
+		result.Message = request.ProviderMsg
+	}
+	return result, nil
+}
+
+func (s *Service) verifyWithProvider(ctx context.Context, name, idNumber string) (bool, string, error) {
+	if s.verifier == nil {
+		return StubProvider{}.Verify(ctx, name, idNumber)
+	}
+	return s.verifier.Verify(ctx, name, idNumber)
+}
+
+// AdminListVerifications pages the review queue, newest first. An empty
+// status lists everything; operators normally filter to "failed".
+func (s *Service) AdminListVerifications(ctx context.Context, status string, page, size int) ([]model.VerificationRequest, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size <= 0 {
+		size = defaultAdminUserPageSize
+	}
+	if size > maxAdminUserPageSize {
+		size = maxAdminUserPageSize
+	}
+
+	query := s.db.WithContext(ctx).Model(&model.VerificationRequest{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	items := make([]model.VerificationRequest, 0)
+	if total == 0 {
+		return items, 0, nil
+	}
+	if err := query.
+		Order("id DESC").
+		Limit(size).
+		Offset((page - 1) * size).
+		Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// AdminReviewVerification settles a queued submission by hand: approve marks
+// the user verified, reject sends them back to unverified so they can
+// resubmit.
+func (s *Service) AdminReviewVerification(ctx context.Context, requestID, adminID int64, approve bool) (*model.VerificationRequest, error) {
+	var request model.VerificationRequest
+	if err := s.db.WithContext(ctx).First(&request, requestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErr.ErrVerificationNotFound
+		}
+		return nil, err
+	}
+	if request.Status == VerifyStatusVerified || request.Status == VerifyStatusRejected {
+		return nil, appErr.ErrVerificationNotFound
+	}
+
+	now := time.Now()
+	request.Status = VerifyStatusRejected
+	userStatus := VerifyStatusUnverified
+	if approve {
+		request.Status = VerifyStatusVerified
+		userStatus = VerifyStatusVerified
+	}
+	request.ReviewedBy = adminID
+	request.ReviewedAt = &now
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&request).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).
+			Where("id = ?", request.UserID).
+			Update("verify_status", userStatus).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// maskIDNumber keeps the leading and trailing characters so support can match
+// a document without the full number ever leaving storage.
+func maskIDNumber(id string) string {
+	runes := []rune(id)
+	if len(runes) <= 7 {
+		return strings.Repeat("*", len(runes))
+	}
+	masked := make([]rune, len(runes))
+	for i := range runes {
+		if i < 3 || i >= len(runes)-4 {
+			masked[i] = runes[i]
+		} else {
+			masked[i] = '*'
+		}
+	}
+	return string(masked)
+}
+
+// idNumberKey derives the AES key for stored ID numbers from the configured
+// secret; without one a static development key keeps the flow working.
+func idNumberKey() [32]byte {
+	secret := "dx-service-verify-dev"
+	if config.GlobalConfig != nil && config.GlobalConfig.Verify.IDSecret != "" {
+		secret = config.GlobalConfig.Verify.IDSecret
+	}
+	return sha256.Sum256([]byte(secret))
+}
+
+func encryptIDNumber(id string) (string, error) {
+	key := idNumberKey()
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(id), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptIDNumber(encoded string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	key := idNumberKey()
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,161 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// scriptedProvider returns a fixed verdict so tests can drive both branches.
+type scriptedProvider struct {
+	ok      bool
+	message string
+	err     error
+}
+
+func (p *scriptedProvider) Verify(context.Context, string, string) (bool, string, error) {
+	return p.ok, p.message, p.err
+}
+
+func newVerifyService(t *testing.T) (*gorm.DB, *Service) {
+	t.Helper()
+
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.VerificationRequest{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.Create(&model.User{ID: 1, Phone: "13800000001", VerifyStatus: VerifyStatusUnverified}).Error; err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	return db, &Service{db: db}
+}
+
+func TestSubmitVerificationVerifiesOnProviderPass(t *testing.T) {
+	db, svc := newVerifyService(t)
+	svc.SetVerificationProvider(&scriptedProvider{ok: true})
+	ctx := context.Background()
+
+	idNumber := "110101199003078888"
+	result, err := svc.SubmitVerification(ctx, 1, "张三", idNumber)
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if result.Status != VerifyStatusVerified {
+		t.Fatalf("expected verified, got %+v", result)
+	}
+
+	var user model.User
+	if err := db.First(&user, 1).Error; err != nil {
+		t.Fatalf("user lookup failed: %v", err)
+	}
+	if user.VerifyStatus != VerifyStatusVerified || user.VerifiedName != "张三" {
+		t.Fatalf("user not updated: %+v", user)
+	}
+	if user.IDNumberMasked != "110***********8888" {
+		t.Fatalf("unexpected mask: %q", user.IDNumberMasked)
+	}
+	if strings.Contains(user.IDNumberMasked, idNumber[3:14]) {
+		t.Fatal("mask leaks the ID number")
+	}
+	plain, err := decryptIDNumber(user.IDNumberEnc)
+	if err != nil || plain != idNumber {
+		t.Fatalf("stored ciphertext does not round-trip: %q %v", plain, err)
+	}
+
+	// A second submission for a verified user is refused.
+	if _, err := svc.SubmitVerification(ctx, 1, "张三", idNumber); !errors.Is(err, appErr.ErrAlreadyVerified) {
+		t.Fatalf("expected already verified, got %v", err)
+	}
+}
+
+func TestSubmitVerificationFailureQueuesForReview(t *testing.T) {
+	db, svc := newVerifyService(t)
+	svc.SetVerificationProvider(&scriptedProvider{ok: false, message: "name mismatch"})
+	ctx := context.Background()
+
+	result, err := svc.SubmitVerification(ctx, 1, "李四", "110101199003078888")
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if result.Status != VerifyStatusPending || result.Message != "name mismatch" {
+		t.Fatalf("expected pending with provider message, got %+v", result)
+	}
+
+	items, total, err := svc.AdminListVerifications(ctx, VerifyStatusFailed, 1, 20)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if total != 1 || len(items) != 1 || items[0].UserID != 1 {
+		t.Fatalf("failed submission missing from review queue: %+v", items)
+	}
+
+	// Manual approval clears the user.
+	request, err := svc.AdminReviewVerification(ctx, items[0].ID, 9, true)
+	if err != nil {
+		t.Fatalf("review failed: %v", err)
+	}
+	if request.Status != VerifyStatusVerified || request.ReviewedBy != 9 || request.ReviewedAt == nil {
+		t.Fatalf("review not recorded: %+v", request)
+	}
+	var user model.User
+	if err := db.First(&user, 1).Error; err != nil {
+		t.Fatalf("user lookup failed: %v", err)
+	}
+	if user.VerifyStatus != VerifyStatusVerified {
+		t.Fatalf("approval did not verify the user: %+v", user)
+	}
+
+	// A settled request cannot be reviewed twice.
+	if _, err := svc.AdminReviewVerification(ctx, items[0].ID, 9, false); !errors.Is(err, appErr.ErrVerificationNotFound) {
+		t.Fatalf("expected not found on double review, got %v", err)
+	}
+}
+
+func TestSubmitVerificationProviderOutageStaysReviewable(t *testing.T) {
+	db, svc := newVerifyService(t)
+	svc.SetVerificationProvider(&scriptedProvider{err: errors.New("vendor timeout")})
+	ctx := context.Background()
+
+	result, err := svc.SubmitVerification(ctx, 1, "王五", "110101199003078888")
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if result.Status != VerifyStatusPending {
+		t.Fatalf("expected pending on provider outage, got %+v", result)
+	}
+	var user model.User
+	if err := db.First(&user, 1).Error; err != nil {
+		t.Fatalf("user lookup failed: %v", err)
+	}
+	if user.VerifyStatus != VerifyStatusPending {
+		t.Fatalf("expected pending user, got %q", user.VerifyStatus)
+	}
+}
+
+func TestSubmitVerificationRejectsBadPayload(t *testing.T) {
+	_, svc := newVerifyService(t)
+	ctx := context.Background()
+
+	if _, err := svc.SubmitVerification(ctx, 1, "", "110101199003078888"); !errors.Is(err, appErr.ErrInvalidVerification) {
+		t.Fatalf("expected invalid payload for empty name, got %v", err)
+	}
+	if _, err := svc.SubmitVerification(ctx, 1, "张三", "123"); !errors.Is(err, appErr.ErrInvalidVerification) {
+		t.Fatalf("expected invalid payload for short id, got %v", err)
+	}
+}
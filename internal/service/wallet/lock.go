@@ -0,0 +1,147 @@
+package wallet
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	appErr "dx-service/pkg/errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// userLockTTL bounds how long a crashed holder can block a wallet. It
+	// sits well above the worst-case multi-user settlement transaction so a
+	// live holder never outlives its own lock.
+	userLockTTL = 15 * time.Second
+	// userLockWait is the total time an acquirer retries before giving up.
+	userLockWait = 2 * time.Second
+)
+
+// releaseUserLockScript deletes the mutex only while it still carries the
+// releasing holder's token. A holder that somehow outlived the TTL finds
+// someone else's token (or nothing) and leaves the lock alone instead of
+// breaking the successor's mutual exclusion.
+var releaseUserLockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// newLockToken draws the per-acquisition token that ties a release to the
+// acquisition that created the lock.
+func newLockToken() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Lock contention counters, the operational metric for spotting hot wallets:
+// waits counts acquisitions that lost the first attempt, timeouts counts
+// acquisitions that gave up entirely.
+var (
+	lockWaits    atomic.Int64
+	lockTimeouts atomic.Int64
+)
+
+// LockContention returns the cumulative wait and timeout counts.
+func LockContention() (waits, timeouts int64) {
+	return lockWaits.Load(), lockTimeouts.Load()
+}
+
+func buildUserLockKey(userID int64) string {
+	return fmt.Sprintf("wallet:mutex:%d", userID)
+}
+
+// WithUserLock serializes wallet mutations for one user across every entry
+// point (REST, WS, settlement) routed through it. A nil client degrades to
+// running fn unlocked so bare test services keep working.
+func WithUserLock(ctx context.Context, rdb *redis.Client, userID int64, fn func(context.Context) error) error {
+	return WithUserLocks(ctx, rdb, []int64{userID}, fn)
+}
+
+// WithUserLocks is the multi-wallet variant used by settlement: the per-user
+// mutexes are acquired in ascending ID order so two concurrent multi-user
+// holders can never deadlock against each other.
+func WithUserLocks(ctx context.Context, rdb *redis.Client, userIDs []int64, fn func(context.Context) error) error {
+	if rdb == nil || len(userIDs) == 0 {
+		return fn(ctx)
+	}
+
+	ids := append([]int64(nil), userIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	type heldLock struct {
+		key   string
+		token string
+	}
+	held := make([]heldLock, 0, len(ids))
+	release := func() {
+		// Release on a fresh context so a cancelled request still unlocks;
+		// the token compare keeps an expired holder from deleting a lock a
+		// second holder has since acquired.
+		for _, l := range held {
+			releaseUserLockScript.Run(context.Background(), rdb, []string{l.key}, l.token)
+		}
+	}
+
+	var prev int64
+	for i, id := range ids {
+		if i > 0 && id == prev {
+			continue
+		}
+		prev = id
+		token, err := acquireUserLock(ctx, rdb, id)
+		if err != nil {
+			release()
+			return err
+		}
+		held = append(held, heldLock{key: buildUserLockKey(id), token: token})
+	}
+	defer release()
+
+	return fn(ctx)
+}
+
+// acquireUserLock takes the SET NX mutex with exponential backoff until
+// userLockWait elapses, returning the token the release must present.
+func acquireUserLock(ctx context.Context, rdb *redis.Client, userID int64) (string, error) {
+	key := buildUserLockKey(userID)
+	token := newLockToken()
+	deadline := time.Now().Add(userLockWait)
+	delay := 10 * time.Millisecond
+	contended := false
+
+	for {
+		ok, err := rdb.SetNX(ctx, key, token, userLockTTL).Result()
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return token, nil
+		}
+		if !contended {
+			contended = true
+			lockWaits.Add(1)
+		}
+		if time.Now().After(deadline) {
+			lockTimeouts.Add(1)
+			return "", fmt.Errorf("%w: user %d", appErr.ErrWalletBusy, userID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		if delay < 100*time.Millisecond {
+			delay *= 2
+		}
+	}
+}
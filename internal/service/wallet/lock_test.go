@@ -0,0 +1,157 @@
+package wallet
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"dx-service/internal/model"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newLockedTestService(t *testing.T) *Service {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Wallet{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return NewService(db, client)
+}
+
+// TestWithUserLockSerializesMixedMutations hammers one wallet with mixed
+// credit/debit read-modify-write cycles from many goroutines; with the mutex
+// the final balance must equal the sum of the applied deltas.
+func TestWithUserLockSerializesMixedMutations(t *testing.T) {
+	s := newLockedTestService(t)
+	ctx := context.Background()
+
+	if err := s.db.Create(&model.Wallet{UserID: 1, BalanceTotal: 1000, BalanceAvailable: 1000}).Error; err != nil {
+		t.Fatalf("seed wallet: %v", err)
+	}
+
+	const (
+		workers    = 8
+		iterations = 20
+	)
+	// Alternate credits and debits so the mix exercises both directions.
+	deltas := []int64{5, -3}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		delta := deltas[w%len(deltas)]
+		wg.Add(1)
+		go func(delta int64) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				err := WithUserLock(ctx, s.rdb, 1, func(ctx context.Context) error {
+					var wallet model.Wallet
+					if err := s.db.WithContext(ctx).Where("user_id = ?", int64(1)).First(&wallet).Error; err != nil {
+						return err
+					}
+					wallet.BalanceAvailable += delta
+					wallet.BalanceTotal = wallet.BalanceAvailable + wallet.BalanceFrozen
+					return s.db.WithContext(ctx).Save(&wallet).Error
+				})
+				if err != nil {
+					errCh <- err
+					return
+				}
+			}
+		}(delta)
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		t.Fatalf("locked mutation failed: %v", err)
+	}
+
+	var wallet model.Wallet
+	if err := s.db.Where("user_id = ?", int64(1)).First(&wallet).Error; err != nil {
+		t.Fatalf("load wallet: %v", err)
+	}
+	// 4 workers credit 5 and 4 debit 3, 20 iterations each.
+	want := int64(1000 + 4*iterations*5 - 4*iterations*3)
+	if wallet.BalanceAvailable != want {
+		t.Fatalf("balance %d, want %d (lost update under contention)", wallet.BalanceAvailable, want)
+	}
+}
+
+// TestWithUserLockWaitsForHolder checks a second acquirer blocks until the
+// holder releases and that the contention metric records the wait.
+func TestWithUserLockWaitsForHolder(t *testing.T) {
+	s := newLockedTestService(t)
+	ctx := context.Background()
+
+	if err := s.rdb.SetNX(ctx, buildUserLockKey(7), 1, userLockTTL).Err(); err != nil {
+		t.Fatalf("pre-hold lock: %v", err)
+	}
+	waitsBefore, _ := LockContention()
+
+	ran := make(chan struct{})
+	go func() {
+		_ = WithUserLock(ctx, s.rdb, 7, func(context.Context) error {
+			close(ran)
+			return nil
+		})
+	}()
+
+	select {
+	case <-ran:
+		t.Fatal("fn ran while the lock was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.rdb.Del(ctx, buildUserLockKey(7))
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("fn never ran after the lock was released")
+	}
+
+	if waitsAfter, _ := LockContention(); waitsAfter <= waitsBefore {
+		t.Fatalf("contention wait not recorded: before=%d after=%d", waitsBefore, waitsAfter)
+	}
+
+	// The released mutex is gone, so the next acquisition is immediate.
+	if err := WithUserLock(ctx, s.rdb, 7, func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("follow-up lock failed: %v", err)
+	}
+}
+
+// TestStaleHolderCannotReleaseSuccessorLock simulates a holder outliving the
+// TTL: the mutex expires mid-fn and a second holder acquires it. The first
+// holder's release must leave the successor's lock in place.
+func TestStaleHolderCannotReleaseSuccessorLock(t *testing.T) {
+	s := newLockedTestService(t)
+	ctx := context.Background()
+	key := buildUserLockKey(9)
+
+	err := WithUserLock(ctx, s.rdb, 9, func(context.Context) error {
+		s.rdb.Del(ctx, key)
+		if ok, err := s.rdb.SetNX(ctx, key, "successor-token", userLockTTL).Result(); err != nil || !ok {
+			t.Fatalf("successor could not acquire the expired lock: ok=%v err=%v", ok, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("locked fn failed: %v", err)
+	}
+
+	val, err := s.rdb.Get(ctx, key).Result()
+	if err != nil || val != "successor-token" {
+		t.Fatalf("stale holder released the successor's lock: val=%q err=%v", val, err)
+	}
+}
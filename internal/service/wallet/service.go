@@ -5,14 +5,36 @@ import (
 	"fmt"
 	"time"
 
+	"dx-service/internal/config"
 	"dx-service/internal/model"
 	appErr "dx-service/pkg/errors"
 
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
+// defaultTrialBalanceCap is the play money a trial-mode scene player holds
+// when the trial config leaves the cap unset.
+const defaultTrialBalanceCap = 10000
+
 type Service struct {
-	db *gorm.DB
+	db  *gorm.DB
+	rdb *redis.Client
+
+	// panic is the operator kill switch; while engaged, balance mutations
+	// are rejected outright. Nil never panics.
+	panic PanicChecker
+}
+
+// PanicChecker is the narrow slice of the panic-stop service wallet
+// mutations need: whether money movement is frozen globally.
+type PanicChecker interface {
+	Active(ctx context.Context, sceneID int64) bool
+}
+
+// SetPanicChecker wires the panic-stop switch in after construction.
+func (s *Service) SetPanicChecker(p PanicChecker) {
+	s.panic = p
 }
 
 type AdminSetWalletRequest struct {
@@ -20,48 +42,92 @@ type AdminSetWalletRequest struct {
 	BalanceFrozen    *int64
 }
 
-func NewService(db *gorm.DB) *Service {
-	return &Service{db: db}
+func NewService(db *gorm.DB, rdb *redis.Client) *Service {
+	return &Service{db: db, rdb: rdb}
+}
+
+// TrialBalanceCap returns the configured play-money ceiling for trial-mode
+// scenes.
+func TrialBalanceCap() int64 {
+	if config.GlobalConfig != nil && config.GlobalConfig.Trial.BalanceCap > 0 {
+		return config.GlobalConfig.Trial.BalanceCap
+	}
+	return defaultTrialBalanceCap
+}
+
+// GrantTrialBalance tops one user's trial balance up to the cap, creating the
+// wallet row when needed; called on first login so new users can try trial
+// scenes before recharging.
+func (s *Service) GrantTrialBalance(ctx context.Context, userID int64) error {
+	cap := TrialBalanceCap()
+	return WithUserLock(ctx, s.rdb, userID, func(ctx context.Context) error {
+		var wallet model.Wallet
+		if err := s.db.WithContext(ctx).Where("user_id = ?", userID).FirstOrCreate(&wallet, model.Wallet{UserID: userID}).Error; err != nil {
+			return err
+		}
+		if wallet.TrialBalance >= cap {
+			return nil
+		}
+		return s.db.WithContext(ctx).Model(&model.Wallet{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"trial_balance": cap, "updated_at": time.Now()}).Error
+	})
+}
+
+// RefillTrialBalances restores every wallet's trial balance to the cap; the
+// scheduler runs it daily so trial losses never lock a user out for good.
+func (s *Service) RefillTrialBalances(ctx context.Context) error {
+	cap := TrialBalanceCap()
+	return s.db.WithContext(ctx).Model(&model.Wallet{}).
+		Where("trial_balance < ?", cap).
+		Updates(map[string]interface{}{"trial_balance": cap, "updated_at": time.Now()}).Error
 }
 
 func (s *Service) GetWallet(ctx context.Context, userID int64) (*model.Wallet, error) {
+	// Auto-create the row so a user who has seen their wallet is past the
+	// "wallet not initialized" stage everywhere else.
 	var wallet model.Wallet
-	err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return &model.Wallet{UserID: userID}, nil
-		}
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).FirstOrCreate(&wallet, model.Wallet{UserID: userID}).Error; err != nil {
 		return nil, err
 	}
 	return &wallet, nil
 }
 
 func (s *Service) AdminSetWallet(ctx context.Context, userID int64, req AdminSetWalletRequest) (*model.Wallet, error) {
+	// Adjustments are not scene-scoped, so only the global panic flag
+	// applies here.
+	if s.panic != nil && s.panic.Active(ctx, 0) {
+		return nil, appErr.ErrPanicStop
+	}
 	if req.BalanceAvailable == nil && req.BalanceFrozen == nil {
 		return nil, fmt.Errorf("%w: balanceAvailable or balanceFrozen is required", appErr.ErrInvalidWalletPayload)
 	}
 
-	var wallet model.Wallet
-	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).FirstOrCreate(&wallet, model.Wallet{UserID: userID}).Error; err != nil {
-		return nil, err
+	if req.BalanceAvailable != nil && *req.BalanceAvailable < 0 {
+		return nil, fmt.Errorf("%w: balanceAvailable must be >= 0", appErr.ErrInvalidWalletPayload)
+	}
+	if req.BalanceFrozen != nil && *req.BalanceFrozen < 0 {
+		return nil, fmt.Errorf("%w: balanceFrozen must be >= 0", appErr.ErrInvalidWalletPayload)
 	}
 
-	if req.BalanceAvailable != nil {
-		if *req.BalanceAvailable < 0 {
-			return nil, fmt.Errorf("%w: balanceAvailable must be >= 0", appErr.ErrInvalidWalletPayload)
+	var wallet model.Wallet
+	err := WithUserLock(ctx, s.rdb, userID, func(ctx context.Context) error {
+		if err := s.db.WithContext(ctx).Where("user_id = ?", userID).FirstOrCreate(&wallet, model.Wallet{UserID: userID}).Error; err != nil {
+			return err
 		}
-		wallet.BalanceAvailable = *req.BalanceAvailable
-	}
-	if req.BalanceFrozen != nil {
-		if *req.BalanceFrozen < 0 {
-			return nil, fmt.Errorf("%w: balanceFrozen must be >= 0", appErr.ErrInvalidWalletPayload)
+
+		if req.BalanceAvailable != nil {
+			wallet.BalanceAvailable = *req.BalanceAvailable
 		}
-		wallet.BalanceFrozen = *req.BalanceFrozen
-	}
-	wallet.BalanceTotal = wallet.BalanceAvailable + wallet.BalanceFrozen
-	wallet.UpdatedAt = time.Now()
+		if req.BalanceFrozen != nil {
+			wallet.BalanceFrozen = *req.BalanceFrozen
+		}
+		wallet.BalanceTotal = wallet.BalanceAvailable + wallet.BalanceFrozen
+		wallet.UpdatedAt = time.Now()
 
-	if err := s.db.WithContext(ctx).Save(&wallet).Error; err != nil {
+		return s.db.WithContext(ctx).Save(&wallet).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &wallet, nil
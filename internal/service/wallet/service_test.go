@@ -0,0 +1,126 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"dx-service/internal/model"
+	appErr "dx-service/pkg/errors"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newWalletTestService(t *testing.T) *Service {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Wallet{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return NewService(db, nil)
+}
+
+func TestGetWalletAutoCreatesRow(t *testing.T) {
+	s := newWalletTestService(t)
+	ctx := context.Background()
+
+	wallet, err := s.GetWallet(ctx, 1)
+	if err != nil {
+		t.Fatalf("get wallet: %v", err)
+	}
+	if wallet.UserID != 1 || wallet.BalanceAvailable != 0 {
+		t.Fatalf("unexpected wallet: %+v", wallet)
+	}
+
+	var count int64
+	if err := s.db.Model(&model.Wallet{}).Where("user_id = ?", int64(1)).Count(&count).Error; err != nil {
+		t.Fatalf("count wallets: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 persisted row, got %d", count)
+	}
+
+	// A second fetch reuses the same row instead of creating another.
+	again, err := s.GetWallet(ctx, 1)
+	if err != nil {
+		t.Fatalf("get wallet again: %v", err)
+	}
+	if again.UserID != 1 {
+		t.Fatalf("unexpected wallet on refetch: %+v", again)
+	}
+	if err := s.db.Model(&model.Wallet{}).Where("user_id = ?", int64(1)).Count(&count).Error; err != nil {
+		t.Fatalf("recount wallets: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected row count to stay 1, got %d", count)
+	}
+}
+
+func TestTrialBalanceGrantAndRefill(t *testing.T) {
+	s := newWalletTestService(t)
+	ctx := context.Background()
+
+	if err := s.GrantTrialBalance(ctx, 1); err != nil {
+		t.Fatalf("grant failed: %v", err)
+	}
+	wallet, err := s.GetWallet(ctx, 1)
+	if err != nil {
+		t.Fatalf("get wallet: %v", err)
+	}
+	cap := TrialBalanceCap()
+	if wallet.TrialBalance != cap {
+		t.Fatalf("expected trial balance %d granted, got %d", cap, wallet.TrialBalance)
+	}
+
+	// The refill restores spent-down balances to the cap and leaves fuller
+	// ones alone.
+	if err := s.db.Model(&model.Wallet{}).Where("user_id = ?", int64(1)).Update("trial_balance", 10).Error; err != nil {
+		t.Fatalf("spend down: %v", err)
+	}
+	if err := s.db.Create(&model.Wallet{UserID: 2, TrialBalance: cap + 500}).Error; err != nil {
+		t.Fatalf("seed wallet: %v", err)
+	}
+	if err := s.RefillTrialBalances(ctx); err != nil {
+		t.Fatalf("refill failed: %v", err)
+	}
+	var wallets []model.Wallet
+	if err := s.db.Order("user_id").Find(&wallets).Error; err != nil {
+		t.Fatalf("load wallets: %v", err)
+	}
+	if wallets[0].TrialBalance != cap {
+		t.Fatalf("expected wallet 1 refilled to %d, got %d", cap, wallets[0].TrialBalance)
+	}
+	if wallets[1].TrialBalance != cap+500 {
+		t.Fatalf("refill must not reduce balances above the cap, got %d", wallets[1].TrialBalance)
+	}
+}
+
+type stubPanic struct{ on bool }
+
+func (p *stubPanic) Active(context.Context, int64) bool { return p.on }
+
+func TestAdminSetWalletRejectedDuringPanic(t *testing.T) {
+	s := newWalletTestService(t)
+	ctx := context.Background()
+	flag := &stubPanic{on: true}
+	s.SetPanicChecker(flag)
+
+	bal := int64(100)
+	if _, err := s.AdminSetWallet(ctx, 1, AdminSetWalletRequest{BalanceAvailable: &bal}); !errors.Is(err, appErr.ErrPanicStop) {
+		t.Fatalf("expected ErrPanicStop, got %v", err)
+	}
+
+	// Resume restores the adjust path.
+	flag.on = false
+	wallet, err := s.AdminSetWallet(ctx, 1, AdminSetWalletRequest{BalanceAvailable: &bal})
+	if err != nil {
+		t.Fatalf("adjust after resume failed: %v", err)
+	}
+	if wallet.BalanceAvailable != 100 {
+		t.Fatalf("unexpected balance: %+v", wallet)
+	}
+}
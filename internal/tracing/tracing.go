@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/pkg/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+const ServiceName = "dx-service"
+
+var tracerProvider trace.TracerProvider = noop.NewTracerProvider()
+
+// Tracer returns the tracer used for manual spans (matcher, settlement, ...).
+// It is a no-op tracer unless InitTracing configured an exporter.
+func Tracer() trace.Tracer {
+	return tracerProvider.Tracer(ServiceName)
+}
+
+// InitTracing wires the global OpenTelemetry tracer provider from config.
+// With no OTLP endpoint configured everything stays a no-op, so instrumented
+// code paths cost nothing in environments without a collector.
+// The returned shutdown func flushes pending spans; callers should invoke it
+// on process exit.
+func InitTracing(ctx context.Context) (func(context.Context) error, error) {
+	cfg := config.GlobalConfig.Tracing
+	if !cfg.Enabled || cfg.OTLPEndpoint == "" {
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{}, propagation.Baggage{},
+		))
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpoint(cfg.OTLPEndpoint),
+		otlptracehttp.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(5*time.Second)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(res),
+	)
+	tracerProvider = tp
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	logger.Log.Info("tracing enabled",
+		zap.String("endpoint", cfg.OTLPEndpoint),
+		zap.Float64("sampleRatio", ratio),
+	)
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,154 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dx-service/internal/service/admin"
+	"dx-service/internal/service/game"
+	pkgAuth "dx-service/pkg/auth"
+	appErr "dx-service/pkg/errors"
+	"dx-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// HandleAdminTableWS streams a live table to customer support. The admin gets
+// the same broadcast stream players see; unredacted per-seat cards require the
+// admin_live_cards flag on top of a valid admin token. Every connection is
+// recorded in the audit trail with its duration.
+func (h *Handler) HandleAdminTableWS(c *gin.Context) {
+	tableIDStr := c.Param("tableId")
+	tableID, err := strconv.ParseInt(tableIDStr, 10, 64)
+	if err != nil || tableID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid table id"})
+		return
+	}
+
+	token, err := getTokenFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	claims, err := pkgAuth.ParseAdminToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	adminID := claims.SubjectID
+
+	showCards := h.gameSvc.CanViewLiveCards(c.Request.Context(), adminID)
+
+	rt, err := h.gameSvc.GetRuntime(c.Request.Context(), tableID)
+	if err != nil {
+		if errors.Is(err, appErr.ErrTableNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load table"})
+		return
+	}
+
+	outbound := rt.Observe(adminID, showCards)
+	if outbound == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "table is closed"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		rt.Unobserve(adminID)
+		logger.Log.Error("Failed to upgrade admin table websocket", zap.Error(err))
+		return
+	}
+
+	logger.Log.Info("New admin live-view connection",
+		zap.Int64("tableID", tableID),
+		zap.Int64("adminID", adminID),
+		zap.Bool("showCards", showCards),
+	)
+
+	startedAt := time.Now()
+	defer func() {
+		rt.Unobserve(adminID)
+		if auditErr := h.adminSvc.RecordAudit(context.Background(), admin.AuditEntry{
+			AdminID:    adminID,
+			Action:     "table_live_view",
+			TargetType: "table",
+			TargetID:   tableID,
+			After: map[string]interface{}{
+				"durationMs": time.Since(startedAt).Milliseconds(),
+				"showCards":  showCards,
+			},
+		}); auditErr != nil {
+			logger.Log.Warn("live-view audit failed", zap.Int64("adminID", adminID), zap.Error(auditErr))
+		}
+	}()
+
+	observer := &adminObserver{
+		conn:     conn,
+		outbound: outbound,
+		done:     make(chan struct{}),
+	}
+	go observer.writePump()
+	observer.readPump()
+}
+
+// adminObserver is the push-only connection for a live-view admin; inbound
+// frames only refresh liveness, mirroring the lobby socket.
+type adminObserver struct {
+	conn     *websocket.Conn
+	outbound chan game.OutgoingMessage
+	done     chan struct{}
+}
+
+func (o *adminObserver) readPump() {
+	defer func() {
+		close(o.done)
+		o.conn.Close()
+	}()
+
+	o.conn.SetReadLimit(4 << 10)
+	o.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	o.conn.SetPongHandler(func(string) error {
+		o.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	for {
+		if _, _, err := o.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (o *adminObserver) writePump() {
+	ticker := time.NewTicker(25 * time.Second)
+	defer func() {
+		ticker.Stop()
+		o.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-o.outbound:
+			if !ok {
+				return
+			}
+			if err := o.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := o.conn.WriteControl(websocket.PingMessage, []byte("ping"), time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		case <-o.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package ws
+
+import (
+	"testing"
+
+	"dx-service/internal/config"
+)
+
+func TestAtConnectionCapacity(t *testing.T) {
+	prev := config.GlobalConfig
+	config.GlobalConfig = &config.Config{Capacity: config.CapacityConfig{MaxConnections: 1}}
+	t.Cleanup(func() { config.GlobalConfig = prev })
+
+	r := NewRegistry(nil)
+	if atConnectionCapacity(r) {
+		t.Fatal("empty registry must not be at capacity")
+	}
+
+	conn := r.register(7, connKindTable, func([]byte) {})
+	if !atConnectionCapacity(r) {
+		t.Fatal("expected capacity reached at the configured limit")
+	}
+
+	r.unregister(conn)
+	if atConnectionCapacity(r) {
+		t.Fatal("expected capacity freed after unregister")
+	}
+
+	// A zero limit disables the ceiling entirely.
+	config.GlobalConfig = &config.Config{}
+	r.register(7, connKindTable, func([]byte) {})
+	if atConnectionCapacity(r) {
+		t.Fatal("zero limit must never shed")
+	}
+}
@@ -0,0 +1,65 @@
+package ws
+
+import "dx-service/internal/service/game"
+
+// Application close codes in the RFC 6455 private range (4000-4099). Clients
+// use these to decide what to do after a disconnect: retry on retryable codes
+// (with backoff), show a message and stop on the rest. The reason text in the
+// close frame mirrors the runtime's close reason.
+const (
+	// CloseAuthFailed means the token was rejected after the connection was
+	// established; the client should obtain a fresh token before retrying.
+	CloseAuthFailed = 4000
+	// CloseSessionTakeover means a newer connection for the same user took
+	// over this subscription; the old client must not auto-reconnect.
+	CloseSessionTakeover = 4001
+	// CloseKicked means an operator removed the player; do not reconnect.
+	CloseKicked = 4002
+	// CloseBanned means the account was disabled; do not reconnect.
+	CloseBanned = 4003
+	// CloseTableDissolved means the table no longer exists; the client
+	// should return to the lobby instead of retrying.
+	CloseTableDissolved = 4004
+	// CloseServerShutdown means this instance is draining; reconnecting
+	// after a short delay will land on a healthy instance.
+	CloseServerShutdown = 4005
+	// CloseReconnectTo means the table was handed off to another instance
+	// mid-deploy; reconnect immediately (no backoff) and resume via lastSeq.
+	CloseReconnectTo = 4006
+	// CloseUsageLimit means the connection was dropped by the usage
+	// enforcement gate; do not auto-reconnect.
+	CloseUsageLimit = 4007
+)
+
+// closeCodeForReason maps the runtime's close reasons onto application codes.
+// Unknown reasons fall back to the retryable shutdown code so a new reason
+// never strands clients.
+func closeCodeForReason(reason string) int {
+	switch reason {
+	case game.CloseReasonTakeover:
+		return CloseSessionTakeover
+	case game.CloseReasonKicked:
+		return CloseKicked
+	case game.CloseReasonBanned:
+		return CloseBanned
+	case game.CloseReasonDissolved, game.CloseReasonExpired:
+		return CloseTableDissolved
+	case game.CloseReasonShutdown:
+		return CloseServerShutdown
+	case game.CloseReasonTransferring:
+		return CloseReconnectTo
+	default:
+		return CloseServerShutdown
+	}
+}
+
+// retryableCloseCode reports whether a client seeing this code should attempt
+// to reconnect automatically.
+func retryableCloseCode(code int) bool {
+	switch code {
+	case CloseSessionTakeover, CloseKicked, CloseBanned, CloseTableDissolved, CloseUsageLimit:
+		return false
+	default:
+		return true
+	}
+}
@@ -7,26 +7,80 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"dx-service/internal/config"
+	"dx-service/internal/service/admin"
 	"dx-service/internal/service/game"
 	"dx-service/internal/service/match"
+	"dx-service/internal/service/notify"
+	sceneSvc "dx-service/internal/service/scene"
 	pkgAuth "dx-service/pkg/auth"
 	appErr "dx-service/pkg/errors"
 	"dx-service/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
 type Handler struct {
 	matchSvc *match.Service
 	gameSvc  *game.Service
+	adminSvc *admin.Service
+	lobbyHub *LobbyHub
+	registry *Registry
 }
 
-func NewHandler(matchSvc *match.Service, gameSvc *game.Service) *Handler {
-	return &Handler{matchSvc: matchSvc, gameSvc: gameSvc}
+func NewHandler(matchSvc *match.Service, gameSvc *game.Service, notifySvc *notify.Service, adminSvc *admin.Service, rdb *redis.Client) *Handler {
+	h := &Handler{
+		matchSvc: matchSvc,
+		gameSvc:  gameSvc,
+		adminSvc: adminSvc,
+		lobbyHub: NewLobbyHub(matchSvc, gameSvc, notifySvc),
+		registry: NewRegistry(rdb),
+	}
+	// Usage thresholds flag through the admin service's risk-flag table.
+	if adminSvc != nil {
+		h.registry.SetRiskFlagger(adminSvc)
+	}
+	return h
+}
+
+// Registry exposes the connection registry for targeted pushes from other
+// layers (ban eject, wallet updates, announcements).
+func (h *Handler) Registry() *Registry {
+	return h.registry
+}
+
+// connectionSheds counts upgrades refused at the connection ceiling.
+var connectionSheds atomic.Int64
+
+// ConnectionSheds reports how many WS upgrades this instance refused because
+// it was at its configured connection ceiling.
+func ConnectionSheds() int64 {
+	return connectionSheds.Load()
+}
+
+// atConnectionCapacity reports whether this instance already carries the
+// configured WS connection ceiling; zero or missing config never sheds.
+func atConnectionCapacity(registry *Registry) bool {
+	if registry == nil || config.GlobalConfig == nil {
+		return false
+	}
+	limit := config.GlobalConfig.Capacity.MaxConnections
+	return limit > 0 && registry.Connections() >= int64(limit)
+}
+
+// shedConnection rejects an upgrade request before any work is done on it.
+func shedConnection(c *gin.Context, kind string) {
+	connectionSheds.Add(1)
+	logger.Log.Warn("shedding websocket: instance at connection capacity",
+		zap.String("kind", kind),
+	)
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server busy"})
 }
 
 var upgrader = websocket.Upgrader{
@@ -38,6 +92,11 @@ var upgrader = websocket.Upgrader{
 }
 
 func (h *Handler) HandleTableWS(c *gin.Context) {
+	if atConnectionCapacity(h.registry) {
+		shedConnection(c, connKindTable)
+		return
+	}
+
 	tableIDStr := c.Param("tableId")
 	tableID, err := strconv.ParseInt(tableIDStr, 10, 64)
 	if err != nil || tableID <= 0 {
@@ -57,27 +116,38 @@ func (h *Handler) HandleTableWS(c *gin.Context) {
 	}
 	userID := claims.SubjectID
 
-	if err := h.matchSvc.ValidateTableAccess(c.Request.Context(), userID, tableID); err != nil {
-		switch {
-		case errors.Is(err, appErr.ErrUnauthorized):
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		case errors.Is(err, appErr.ErrTableNotFound):
-			c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
-		case errors.Is(err, appErr.ErrTableAccessDenied):
-			c.JSON(http.StatusForbidden, gin.H{"error": "table access denied"})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate table access"})
+	// Spectators skip the player-access check: anyone authenticated may
+	// watch read-only, capped per table by the runtime.
+	spectate := c.Query("spectate") == "1"
+	// Locale travels as a query param so localized pushes (announcements,
+	// localized logs) can be rendered per subscriber without a profile read.
+	locale := sceneSvc.ResolveLocale(c.Query("locale"))
+	if !spectate {
+		if err := h.matchSvc.ValidateTableAccess(c.Request.Context(), userID, tableID); err != nil {
+			switch {
+			case errors.Is(err, appErr.ErrUnauthorized):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			case errors.Is(err, appErr.ErrTableNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+			case errors.Is(err, appErr.ErrTableAccessDenied):
+				c.JSON(http.StatusForbidden, gin.H{"error": "table access denied"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate table access"})
+			}
+			return
 		}
-		return
 	}
 
-	rt, err := h.gameSvc.GetRuntime(c.Request.Context(), tableID)
+	sess, err := h.gameSvc.GetSession(c.Request.Context(), tableID)
 	if err != nil {
-		if errors.Is(err, appErr.ErrTableNotFound) {
+		switch {
+		case errors.Is(err, appErr.ErrTableNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
-			return
+		case errors.Is(err, appErr.ErrServerBusy):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server busy"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load table"})
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load table"})
 		return
 	}
 
@@ -92,7 +162,20 @@ func (h *Handler) HandleTableWS(c *gin.Context) {
 		zap.Int64("userID", userID),
 	)
 
-	client := newClient(conn, userID, tableID, rt)
+	client := newClient(conn, userID, tableID, sess, h.registry, spectate)
+	if client != nil {
+		client.locale = locale
+	}
+	if client == nil {
+		// Spectator cap reached: tell the watcher and drop the connection.
+		_ = conn.WriteJSON(game.OutgoingMessage{
+			Type: "error",
+			Seq:  0,
+			Data: gin.H{"message": "spectator capacity reached"},
+		})
+		conn.Close()
+		return
+	}
 	client.run()
 }
 
@@ -118,27 +201,74 @@ type client struct {
 	conn      *websocket.Conn
 	userID    int64
 	tableID   int64
-	rt        *game.TableRuntime
+	locale    string // recorded at connect for localized pushes
+	sess      game.TableSession
 	outbound  <-chan game.OutgoingMessage
 	done      chan struct{}
 	pingEvery time.Duration
+	registry  *Registry
+	regConn   *registeredConn
+	pushes    chan []byte // pre-serialized targeted pushes from the registry
+
+	// takenOver flips when a newer connection replaced this subscription;
+	// the read pump must then leave the (new) subscription alone.
+	takenOver atomic.Bool
 }
 
-func newClient(conn *websocket.Conn, userID, tableID int64, rt *game.TableRuntime) *client {
+func newClient(conn *websocket.Conn, userID, tableID int64, sess game.TableSession, registry *Registry, spectator bool) *client {
 	conn.SetReadLimit(1 << 20)
 	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	conn.SetPongHandler(func(string) error {
 		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		return nil
 	})
-	return &client{
+	var outbound chan game.OutgoingMessage
+	if spectator {
+		if outbound = sess.SubscribeSpectator(userID); outbound == nil {
+			return nil
+		}
+	} else {
+		outbound = sess.Subscribe(userID)
+	}
+	c := &client{
 		conn:      conn,
 		userID:    userID,
 		tableID:   tableID,
-		rt:        rt,
-		outbound:  rt.Subscribe(userID),
+		sess:      sess,
+		outbound:  outbound,
 		done:      make(chan struct{}),
 		pingEvery: 25 * time.Second,
+		registry:  registry,
+		pushes:    make(chan []byte, 8),
+	}
+	if registry != nil {
+		c.regConn = registry.register(userID, connKindTable, c.enqueuePush)
+		// Enforcement hook for usage flags. WriteControl is safe alongside
+		// the write pump; the blunt close after it unblocks the read pump.
+		c.regConn.closeConn = func() {
+			deadline := time.Now().Add(time.Second)
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(CloseUsageLimit, "usage_limit"), deadline)
+			conn.Close()
+		}
+	}
+	return c
+}
+
+// enqueuePush queues a registry push without blocking; when the buffer is
+// full the oldest frame is dropped in favor of the new one.
+func (c *client) enqueuePush(payload []byte) {
+	select {
+	case c.pushes <- payload:
+	default:
+		select {
+		case <-c.pushes:
+		default:
+		}
+		select {
+		case c.pushes <- payload:
+		default:
+		}
 	}
 }
 
@@ -150,7 +280,12 @@ func (c *client) run() {
 func (c *client) readPump() {
 	defer func() {
 		close(c.done)
-		c.rt.Unsubscribe(c.userID)
+		if c.registry != nil {
+			c.registry.unregister(c.regConn)
+		}
+		if !c.takenOver.Load() {
+			c.sess.Unsubscribe(c.userID)
+		}
 		c.conn.Close()
 	}()
 
@@ -163,10 +298,14 @@ func (c *client) readPump() {
 		if mt != websocket.TextMessage && mt != websocket.BinaryMessage {
 			continue
 		}
+		if c.registry != nil {
+			c.registry.recordInbound(c.userID, len(message))
+		}
 
 		var incoming struct {
-			Type string          `json:"type"`
-			Data json.RawMessage `json:"data"`
+			Type      string          `json:"type"`
+			Data      json.RawMessage `json:"data"`
+			RequestID string          `json:"requestId"`
 		}
 		if err := json.Unmarshal(message, &incoming); err != nil {
 			c.safeWrite(game.OutgoingMessage{
@@ -180,13 +319,33 @@ func (c *client) readPump() {
 			continue
 		}
 
-		if err := c.rt.HandleAction(c.userID, incoming.Type, incoming.Data); err != nil {
-			c.safeWrite(game.OutgoingMessage{
-				Type: "error",
-				Seq:  0,
-				Data: gin.H{"message": fmt.Sprintf("action failed: %v", err)},
-			})
+		if incoming.RequestID == "" {
+			// Older clients without retry tokens keep the plain error-only
+			// contract.
+			if err := c.sess.HandleAction(c.userID, incoming.Type, incoming.Data); err != nil {
+				c.safeWrite(game.OutgoingMessage{
+					Type: "error",
+					Seq:  0,
+					Data: gin.H{"message": fmt.Sprintf("action failed: %v", err)},
+				})
+			}
+			continue
 		}
+
+		res := c.sess.HandleActionID(c.userID, incoming.Type, incoming.Data, incoming.RequestID)
+		ack := gin.H{
+			"requestId": incoming.RequestID,
+			"ok":        res.Err == nil,
+			"duplicate": res.Duplicate,
+		}
+		if res.Err != nil {
+			ack["message"] = fmt.Sprintf("action failed: %v", res.Err)
+		}
+		c.safeWrite(game.OutgoingMessage{
+			Type: "ack",
+			Seq:  0,
+			Data: ack,
+		})
 	}
 }
 
@@ -201,9 +360,40 @@ func (c *client) writePump() {
 		select {
 		case msg, ok := <-c.outbound:
 			if !ok {
+				// Channel closed without a reason frame: treat it like a
+				// shutdown so the client retries against a live instance.
+				c.Close(CloseServerShutdown, game.CloseReasonShutdown)
+				return
+			}
+			if msg.Type == "close" {
+				reason := game.CloseReasonShutdown
+				switch data := msg.Data.(type) {
+				case game.CloseInfo:
+					if data.Reason != "" {
+						reason = data.Reason
+					}
+				case map[string]interface{}:
+					// Bridge-relayed messages arrive JSON-decoded.
+					if r, ok := data["reason"].(string); ok && r != "" {
+						reason = r
+					}
+				}
+				code := closeCodeForReason(reason)
+				if code == CloseSessionTakeover {
+					c.takenOver.Store(true)
+				}
+				c.Close(code, reason)
 				return
 			}
-			if err := c.conn.WriteJSON(msg); err != nil {
+			if err := c.writeCounted(msg); err != nil {
+				logger.Log.Info("WS write error", zap.Error(err), zap.Int64("userID", c.userID), zap.Int64("tableID", c.tableID))
+				return
+			}
+		case payload := <-c.pushes:
+			if c.registry != nil {
+				c.registry.recordOutbound(c.userID, len(payload))
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
 				logger.Log.Info("WS write error", zap.Error(err), zap.Int64("userID", c.userID), zap.Int64("tableID", c.tableID))
 				return
 			}
@@ -217,8 +407,35 @@ func (c *client) writePump() {
 	}
 }
 
+// Close sends a final JSON frame describing the disconnect, follows it with a
+// proper close frame carrying the application code, then tears the connection
+// down. The JSON frame reaches clients whose WS libraries hide close frames.
+func (c *client) Close(code int, reason string) {
+	c.safeWrite(game.OutgoingMessage{
+		Type: "close",
+		Seq:  0,
+		Data: gin.H{"code": code, "reason": reason, "retryable": retryableCloseCode(code)},
+	})
+	deadline := time.Now().Add(5 * time.Second)
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+	c.conn.Close()
+}
+
+// writeCounted serializes and writes one frame, counting it against the
+// user's usage.
+func (c *client) writeCounted(msg game.OutgoingMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if c.registry != nil {
+		c.registry.recordOutbound(c.userID, len(payload))
+	}
+	return c.conn.WriteMessage(websocket.TextMessage, payload)
+}
+
 func (c *client) safeWrite(msg game.OutgoingMessage) {
-	if err := c.conn.WriteJSON(msg); err != nil {
+	if err := c.writeCounted(msg); err != nil {
 		logger.Log.Info("WS write error", zap.Error(err), zap.Int64("userID", c.userID), zap.Int64("tableID", c.tableID))
 	}
 }
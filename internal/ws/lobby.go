@@ -0,0 +1,289 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"dx-service/internal/service/game"
+	"dx-service/internal/service/match"
+	"dx-service/internal/service/notify"
+	pkgAuth "dx-service/pkg/auth"
+	"dx-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	lobbySummaryInterval = 3 * time.Second
+	lobbySendBuffer      = 8
+	lobbyPingEvery       = 25 * time.Second
+	lobbyReadDeadline    = 60 * time.Second
+)
+
+type lobbyMessage struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// LobbyHub fans out periodic scene summaries and matched notifications to all
+// lobby WebSocket connections. One aggregator goroutine computes and
+// serializes each summary once; delivery per connection is non-blocking with
+// drop-oldest semantics so thousands of idle connections stay cheap.
+type LobbyHub struct {
+	matchSvc  *match.Service
+	gameSvc   *game.Service
+	notifySvc *notify.Service
+
+	mu      sync.Mutex
+	clients map[*lobbyClient]struct{}
+}
+
+func NewLobbyHub(matchSvc *match.Service, gameSvc *game.Service, notifySvc *notify.Service) *LobbyHub {
+	hub := &LobbyHub{
+		matchSvc:  matchSvc,
+		gameSvc:   gameSvc,
+		notifySvc: notifySvc,
+		clients:   make(map[*lobbyClient]struct{}),
+	}
+	go hub.run()
+	return hub
+}
+
+func (h *LobbyHub) register(c *lobbyClient) {
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+}
+
+func (h *LobbyHub) unregister(c *lobbyClient) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+func (h *LobbyHub) run() {
+	ticker := time.NewTicker(lobbySummaryInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		h.tick()
+	}
+}
+
+func (h *LobbyHub) tick() {
+	h.mu.Lock()
+	clients := make([]*lobbyClient, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
+
+	if len(clients) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lobbySummaryInterval)
+	defer cancel()
+
+	summaries, err := h.matchSvc.LobbySummary(ctx)
+	if err != nil {
+		logger.Log.Warn("lobby summary failed", zap.Error(err))
+	} else {
+		payload, marshalErr := json.Marshal(lobbyMessage{
+			Type: "lobby_summary",
+			Data: gin.H{"scenes": summaries, "serverTime": time.Now().UnixMilli()},
+		})
+		if marshalErr == nil {
+			for _, c := range clients {
+				c.send(payload)
+			}
+		}
+	}
+
+	userIDs := make([]int64, 0, len(clients))
+	for _, c := range clients {
+		userIDs = append(userIDs, c.userID)
+	}
+	matched, err := h.matchSvc.PendingMatches(ctx, userIDs)
+	if err != nil {
+		logger.Log.Warn("lobby matched lookup failed", zap.Error(err))
+		return
+	}
+	for _, c := range clients {
+		notice, ok := matched[c.userID]
+		if !ok {
+			continue
+		}
+		payload, marshalErr := json.Marshal(lobbyMessage{Type: "matched", Data: notice})
+		if marshalErr != nil {
+			continue
+		}
+		c.send(payload)
+	}
+
+	invitations, err := h.gameSvc.PendingInvitationsForUsers(ctx, userIDs)
+	if err != nil {
+		logger.Log.Warn("lobby invitation lookup failed", zap.Error(err))
+		return
+	}
+	for _, c := range clients {
+		for _, inv := range invitations[c.userID] {
+			payload, marshalErr := json.Marshal(lobbyMessage{Type: "table_invite", Data: inv})
+			if marshalErr != nil {
+				continue
+			}
+			c.send(payload)
+		}
+	}
+
+	notifications, err := h.notifySvc.PendingForUsers(ctx, userIDs)
+	if err != nil {
+		logger.Log.Warn("lobby notification lookup failed", zap.Error(err))
+		return
+	}
+	for _, c := range clients {
+		for _, msg := range notifications[c.userID] {
+			payload, marshalErr := json.Marshal(lobbyMessage{Type: "notification", Data: msg})
+			if marshalErr != nil {
+				continue
+			}
+			c.send(payload)
+		}
+	}
+}
+
+type lobbyClient struct {
+	conn     *websocket.Conn
+	userID   int64
+	outCh    chan []byte
+	done     chan struct{}
+	closeMu  sync.Once
+	registry *Registry
+	regConn  *registeredConn
+}
+
+// send enqueues a pre-serialized frame without blocking the aggregator; when
+// the buffer is full the oldest frame is dropped in favor of the new one.
+func (c *lobbyClient) send(payload []byte) {
+	select {
+	case c.outCh <- payload:
+	default:
+		select {
+		case <-c.outCh:
+		default:
+		}
+		select {
+		case c.outCh <- payload:
+		default:
+		}
+	}
+}
+
+func (c *lobbyClient) close() {
+	c.closeMu.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func (h *Handler) HandleLobbyWS(c *gin.Context) {
+	if atConnectionCapacity(h.registry) {
+		shedConnection(c, connKindLobby)
+		return
+	}
+
+	token, err := getTokenFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	claims, err := pkgAuth.ParseUserToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.Log.Error("Failed to upgrade lobby websocket", zap.Error(err))
+		return
+	}
+
+	client := &lobbyClient{
+		conn:   conn,
+		userID: claims.SubjectID,
+		outCh:  make(chan []byte, lobbySendBuffer),
+		done:   make(chan struct{}),
+	}
+	h.lobbyHub.register(client)
+	if h.registry != nil {
+		client.regConn = h.registry.register(client.userID, connKindLobby, client.send)
+		client.regConn.closeConn = client.close
+		client.registry = h.registry
+	}
+
+	logger.Log.Info("New lobby WebSocket connection", zap.Int64("userID", client.userID))
+
+	go client.writePump()
+	client.readPump(h.lobbyHub)
+}
+
+func (c *lobbyClient) readPump(hub *LobbyHub) {
+	defer func() {
+		hub.unregister(c)
+		if c.registry != nil {
+			c.registry.unregister(c.regConn)
+		}
+		c.close()
+	}()
+
+	c.conn.SetReadLimit(4 << 10)
+	c.conn.SetReadDeadline(time.Now().Add(lobbyReadDeadline))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(lobbyReadDeadline))
+		return nil
+	})
+
+	for {
+		// The lobby socket is push-only; inbound frames only refresh liveness
+		// (and count against the sender's usage).
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if c.registry != nil {
+			c.registry.recordInbound(c.userID, len(message))
+		}
+	}
+}
+
+func (c *lobbyClient) writePump() {
+	ticker := time.NewTicker(lobbyPingEvery)
+	defer func() {
+		ticker.Stop()
+		c.close()
+	}()
+
+	for {
+		select {
+		case payload := <-c.outCh:
+			if c.registry != nil {
+				c.registry.recordOutbound(c.userID, len(payload))
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, []byte("ping"), time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
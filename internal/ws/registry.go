@@ -0,0 +1,291 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dx-service/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	connKindTable = "table"
+	connKindLobby = "lobby"
+
+	// registryKey is a hash of "<userID>:<instance>" -> connection count so
+	// any instance can discover where a user's live connections are.
+	registryKey       = "ws:conn_registry"
+	instanceKeyPrefix = "ws:instance:"
+	pushChannelPrefix = "ws:push:"
+	instanceTTL       = 60 * time.Second
+	heartbeatInterval = 20 * time.Second
+)
+
+// pushEnvelope carries a targeted push between instances.
+type pushEnvelope struct {
+	UserID int64           `json:"userId"`
+	Type   string          `json:"type"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// registeredConn is the registry's view of one live connection. deliver must
+// not block; both client types already queue or drop under pressure.
+type registeredConn struct {
+	userID  int64
+	kind    string
+	deliver func(payload []byte)
+	// closeConn tears the underlying socket down; nil for owners that don't
+	// provide the enforcement hook (tests, admin views).
+	closeConn func()
+}
+
+// Registry tracks live WS connections: an in-process map for local delivery
+// plus a Redis hash for cross-instance discovery. Each instance holds an
+// epoch key refreshed while it is alive; on startup entries belonging to
+// instances whose epoch key has expired are swept away.
+type Registry struct {
+	rdb      *redis.Client
+	instance string
+
+	mu    sync.Mutex
+	local map[int64]map[*registeredConn]struct{}
+	usage map[int64]*userUsage
+
+	// risk persists usage flags into the risk-flag table; until wired
+	// (SetRiskFlagger) a tripped threshold is only logged.
+	risk riskFlagger
+
+	connections atomic.Int64
+}
+
+func NewRegistry(rdb *redis.Client) *Registry {
+	host, _ := os.Hostname()
+	r := &Registry{
+		rdb:      rdb,
+		instance: fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().Unix()),
+		local:    make(map[int64]map[*registeredConn]struct{}),
+		usage:    make(map[int64]*userUsage),
+	}
+	if rdb != nil {
+		ctx := context.Background()
+		r.cleanupStale(ctx)
+		r.announce(ctx)
+		go r.heartbeat()
+		go r.listen()
+		go r.usageFlusher()
+	}
+	return r
+}
+
+// Connections is the gauge of connections registered on this instance.
+func (r *Registry) Connections() int64 {
+	return r.connections.Load()
+}
+
+func (r *Registry) register(userID int64, kind string, deliver func([]byte)) *registeredConn {
+	conn := &registeredConn{userID: userID, kind: kind, deliver: deliver}
+
+	r.mu.Lock()
+	if r.local[userID] == nil {
+		r.local[userID] = make(map[*registeredConn]struct{})
+	}
+	r.local[userID][conn] = struct{}{}
+	usage := r.usageFor(userID)
+	usage.connsOpened++
+	usage.deltaConns++
+	flag := usage.noteConn(time.Now(), len(r.local[userID]), usageLimits().MaxConcurrentConns)
+	r.mu.Unlock()
+	r.connections.Add(1)
+	if flag != nil {
+		r.raiseUsageFlag(userID, flag)
+	}
+
+	if r.rdb != nil {
+		if err := r.rdb.HIncrBy(context.Background(), registryKey, r.fieldFor(userID), 1).Err(); err != nil {
+			logger.Log.Warn("ws registry register failed", zap.Int64("userID", userID), zap.Error(err))
+		}
+	}
+	return conn
+}
+
+func (r *Registry) unregister(conn *registeredConn) {
+	if conn == nil {
+		return
+	}
+	r.mu.Lock()
+	if conns, ok := r.local[conn.userID]; ok {
+		if _, present := conns[conn]; present {
+			delete(conns, conn)
+			if len(conns) == 0 {
+				delete(r.local, conn.userID)
+			}
+			r.connections.Add(-1)
+		}
+	}
+	r.mu.Unlock()
+
+	if r.rdb != nil {
+		ctx := context.Background()
+		field := r.fieldFor(conn.userID)
+		if n, err := r.rdb.HIncrBy(ctx, registryKey, field, -1).Result(); err == nil && n <= 0 {
+			r.rdb.HDel(ctx, registryKey, field)
+		}
+	}
+}
+
+// PushToUser delivers a message to every live connection of the user: local
+// ones directly, remote ones via the owning instance's push channel.
+func (r *Registry) PushToUser(ctx context.Context, userID int64, msgType string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(lobbyMessage{Type: msgType, Data: json.RawMessage(raw)})
+	if err != nil {
+		return err
+	}
+	r.deliverLocal(userID, payload)
+
+	if r.rdb == nil {
+		return nil
+	}
+	instances, err := r.instancesFor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	envelope, err := json.Marshal(pushEnvelope{UserID: userID, Type: msgType, Data: raw})
+	if err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		if instance == r.instance {
+			continue
+		}
+		if err := r.rdb.Publish(ctx, pushChannelPrefix+instance, envelope).Err(); err != nil {
+			logger.Log.Warn("ws registry push publish failed",
+				zap.Int64("userID", userID),
+				zap.String("instance", instance),
+				zap.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) deliverLocal(userID int64, payload []byte) {
+	r.mu.Lock()
+	conns := make([]*registeredConn, 0, len(r.local[userID]))
+	for conn := range r.local[userID] {
+		conns = append(conns, conn)
+	}
+	r.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.deliver(payload)
+	}
+}
+
+func (r *Registry) fieldFor(userID int64) string {
+	return fmt.Sprintf("%d:%s", userID, r.instance)
+}
+
+// instancesFor scans the registry hash for the user's entries and returns the
+// owning instances.
+func (r *Registry) instancesFor(ctx context.Context, userID int64) ([]string, error) {
+	instances := make([]string, 0, 2)
+	var cursor uint64
+	match := fmt.Sprintf("%d:*", userID)
+	for {
+		fields, next, err := r.rdb.HScan(ctx, registryKey, cursor, match, 100).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+1 < len(fields); i += 2 {
+			if _, instance, ok := strings.Cut(fields[i], ":"); ok {
+				instances = append(instances, instance)
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return instances, nil
+		}
+	}
+}
+
+// cleanupStale removes registry entries left behind by instances that are no
+// longer heartbeating, so a crashed process does not leave ghost connections.
+func (r *Registry) cleanupStale(ctx context.Context) {
+	alive := make(map[string]bool)
+	var cursor uint64
+	for {
+		fields, next, err := r.rdb.HScan(ctx, registryKey, cursor, "*", 100).Result()
+		if err != nil {
+			return
+		}
+		stale := make([]string, 0)
+		for i := 0; i+1 < len(fields); i += 2 {
+			_, instance, ok := strings.Cut(fields[i], ":")
+			if !ok {
+				stale = append(stale, fields[i])
+				continue
+			}
+			live, checked := alive[instance]
+			if !checked {
+				n, err := r.rdb.Exists(ctx, instanceKeyPrefix+instance).Result()
+				if err != nil {
+					continue
+				}
+				live = n > 0
+				alive[instance] = live
+			}
+			if !live {
+				stale = append(stale, fields[i])
+			}
+		}
+		if len(stale) > 0 {
+			r.rdb.HDel(ctx, registryKey, stale...)
+		}
+		cursor = next
+		if cursor == 0 {
+			return
+		}
+	}
+}
+
+func (r *Registry) announce(ctx context.Context) {
+	if err := r.rdb.Set(ctx, instanceKeyPrefix+r.instance, time.Now().Unix(), instanceTTL).Err(); err != nil {
+		logger.Log.Warn("ws registry announce failed", zap.String("instance", r.instance), zap.Error(err))
+	}
+}
+
+func (r *Registry) heartbeat() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		r.announce(context.Background())
+	}
+}
+
+// listen delivers cross-instance pushes addressed to this instance.
+func (r *Registry) listen() {
+	pubsub := r.rdb.Subscribe(context.Background(), pushChannelPrefix+r.instance)
+	for msg := range pubsub.Channel() {
+		var envelope pushEnvelope
+		if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+			continue
+		}
+		payload, err := json.Marshal(lobbyMessage{Type: envelope.Type, Data: envelope.Data})
+		if err != nil {
+			continue
+		}
+		r.deliverLocal(envelope.UserID, payload)
+	}
+}
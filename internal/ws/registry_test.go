@@ -0,0 +1,96 @@
+package ws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dx-service/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newRegistryTestClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	mr := miniredis.RunT(t)
+	return mr, redis.NewClient(&redis.Options{Addr: mr.Addr()})
+}
+
+func TestRegistryRegisterUnregister(t *testing.T) {
+	_, rdb := newRegistryTestClient(t)
+	r := NewRegistry(rdb)
+	ctx := context.Background()
+
+	conn := r.register(7, connKindTable, func([]byte) {})
+	if r.Connections() != 1 {
+		t.Fatalf("expected gauge 1, got %d", r.Connections())
+	}
+	n, err := rdb.HGet(ctx, registryKey, r.fieldFor(7)).Int64()
+	if err != nil || n != 1 {
+		t.Fatalf("expected redis count 1, got %d (%v)", n, err)
+	}
+
+	r.unregister(conn)
+	if r.Connections() != 0 {
+		t.Fatalf("expected gauge 0, got %d", r.Connections())
+	}
+	if exists, _ := rdb.HExists(ctx, registryKey, r.fieldFor(7)).Result(); exists {
+		t.Fatal("expected redis entry removed after last unregister")
+	}
+}
+
+func TestRegistryPushToUserDeliversLocally(t *testing.T) {
+	_, rdb := newRegistryTestClient(t)
+	r := NewRegistry(rdb)
+
+	got := make(chan []byte, 1)
+	conn := r.register(7, connKindLobby, func(payload []byte) { got <- payload })
+	defer r.unregister(conn)
+
+	if err := r.PushToUser(context.Background(), 7, "announcement", map[string]string{"text": "hi"}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	select {
+	case payload := <-got:
+		if string(payload) == "" {
+			t.Fatal("empty payload delivered")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("local push never delivered")
+	}
+}
+
+func TestRegistryCleansStaleInstances(t *testing.T) {
+	mr, rdb := newRegistryTestClient(t)
+	ctx := context.Background()
+
+	// A crashed instance left an entry behind and its epoch key expired.
+	mr.HSet(registryKey, "7:dead-instance-1", "2")
+
+	r := NewRegistry(rdb)
+	if exists, _ := rdb.HExists(ctx, registryKey, "7:dead-instance-1").Result(); exists {
+		t.Fatal("expected stale entry swept on startup")
+	}
+	// The new instance announced itself.
+	if exists, _ := rdb.Exists(ctx, instanceKeyPrefix+r.instance).Result(); exists != 1 {
+		t.Fatal("expected instance epoch key set")
+	}
+}
+
+func TestRegistryKeepsLiveInstances(t *testing.T) {
+	mr, rdb := newRegistryTestClient(t)
+	ctx := context.Background()
+
+	mr.HSet(registryKey, "7:live-instance-1", "1")
+	mr.Set(instanceKeyPrefix+"live-instance-1", "1")
+
+	NewRegistry(rdb)
+	if exists, _ := rdb.HExists(ctx, registryKey, "7:live-instance-1").Result(); !exists {
+		t.Fatal("expected live instance entry kept")
+	}
+}
@@ -0,0 +1,328 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dx-service/internal/config"
+	"dx-service/pkg/logger"
+	"dx-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Per-user WS usage accounting. Scrapers look like players with dozens of
+// sockets and inhuman message rates, so every connection's traffic is counted
+// against its user, aggregated into hourly Redis buckets, and checked against
+// the configured thresholds. A tripped threshold raises a risk flag for
+// review; actual disconnection stays behind the wsUsage.disconnectOnFlag gate.
+const (
+	// usageKeyPrefix + "<userID>:<UTC hour>" is a hash of hourly counters.
+	usageKeyPrefix     = "ws:usage:"
+	usageHourFormat    = "2006010215"
+	usageBucketTTL     = 48 * time.Hour
+	usageFlushInterval = time.Minute
+
+	// wsUsageReportHours is how far back the admin usage report reads.
+	wsUsageReportHours = 24
+
+	riskKindWSUsage = "ws_usage"
+)
+
+// riskFlagger is the slice of the admin service the registry needs to persist
+// usage flags.
+type riskFlagger interface {
+	RaiseRiskFlag(ctx context.Context, userID int64, kind string, detail map[string]interface{}) error
+}
+
+// SetRiskFlagger wires the risk-flag sink in after construction.
+func (r *Registry) SetRiskFlagger(f riskFlagger) {
+	r.mu.Lock()
+	r.risk = f
+	r.mu.Unlock()
+}
+
+// userUsage accumulates one user's websocket footprint on this instance. The
+// plain totals run for the process lifetime; the delta* fields are drained by
+// the hourly Redis aggregation; hourMsgs resets each wall-clock hour and
+// feeds the message-rate threshold.
+type userUsage struct {
+	connsOpened int64
+	msgsIn      int64
+	msgsOut     int64
+	bytesIn     int64
+	bytesOut    int64
+
+	deltaConns    int64
+	deltaMsgsIn   int64
+	deltaMsgsOut  int64
+	deltaBytesIn  int64
+	deltaBytesOut int64
+
+	hour     int64 // unix hour hourMsgs covers
+	hourMsgs int64
+	// flaggedHour dedupes flags: one ws_usage flag per user per hour no
+	// matter how many thresholds keep tripping.
+	flaggedHour int64
+}
+
+// usageLimits reads the configured thresholds; missing config disables every
+// check.
+func usageLimits() config.WSUsageConfig {
+	if config.GlobalConfig == nil {
+		return config.WSUsageConfig{}
+	}
+	return config.GlobalConfig.WSUsage
+}
+
+// usageFor returns the user's counters, creating them on first use. Caller
+// holds r.mu.
+func (r *Registry) usageFor(userID int64) *userUsage {
+	if r.usage == nil {
+		r.usage = make(map[int64]*userUsage)
+	}
+	u := r.usage[userID]
+	if u == nil {
+		u = &userUsage{}
+		r.usage[userID] = u
+	}
+	return u
+}
+
+// noteConn checks the concurrent-connection ceiling against the count the
+// caller just observed, returning flag detail when it trips. Caller holds
+// r.mu.
+func (u *userUsage) noteConn(now time.Time, concurrent, limit int) map[string]interface{} {
+	hour := now.Unix() / 3600
+	if limit <= 0 || concurrent <= limit || u.flaggedHour == hour {
+		return nil
+	}
+	u.flaggedHour = hour
+	return map[string]interface{}{
+		"reason":      "concurrent_connections",
+		"connections": concurrent,
+		"limit":       limit,
+	}
+}
+
+// noteMessage rolls the hourly frame counter and checks the message-rate
+// ceiling, returning flag detail when it trips. Caller holds r.mu.
+func (u *userUsage) noteMessage(now time.Time, limit int) map[string]interface{} {
+	hour := now.Unix() / 3600
+	if hour != u.hour {
+		u.hour = hour
+		u.hourMsgs = 0
+	}
+	u.hourMsgs++
+	if limit <= 0 || u.hourMsgs <= int64(limit) || u.flaggedHour == hour {
+		return nil
+	}
+	u.flaggedHour = hour
+	return map[string]interface{}{
+		"reason":   "message_rate",
+		"messages": u.hourMsgs,
+		"limit":    limit,
+	}
+}
+
+// recordInbound counts one client frame against its user.
+func (r *Registry) recordInbound(userID int64, bytes int) {
+	r.mu.Lock()
+	u := r.usageFor(userID)
+	u.msgsIn++
+	u.bytesIn += int64(bytes)
+	u.deltaMsgsIn++
+	u.deltaBytesIn += int64(bytes)
+	flag := u.noteMessage(time.Now(), usageLimits().MaxMessagesPerHour)
+	r.mu.Unlock()
+	if flag != nil {
+		r.raiseUsageFlag(userID, flag)
+	}
+}
+
+// recordOutbound counts one frame written to the user's socket.
+func (r *Registry) recordOutbound(userID int64, bytes int) {
+	r.mu.Lock()
+	u := r.usageFor(userID)
+	u.msgsOut++
+	u.bytesOut += int64(bytes)
+	u.deltaMsgsOut++
+	u.deltaBytesOut += int64(bytes)
+	r.mu.Unlock()
+}
+
+// raiseUsageFlag records the tripped threshold for review and, only when the
+// enforcement gate is on, drops the user's live connections.
+func (r *Registry) raiseUsageFlag(userID int64, detail map[string]interface{}) {
+	logger.Log.Warn("ws usage threshold exceeded",
+		zap.Int64("userID", userID),
+		zap.Any("detail", detail),
+	)
+	r.mu.Lock()
+	sink := r.risk
+	r.mu.Unlock()
+	if sink != nil {
+		if err := sink.RaiseRiskFlag(context.Background(), userID, riskKindWSUsage, detail); err != nil {
+			logger.Log.Warn("ws usage flag persist failed", zap.Int64("userID", userID), zap.Error(err))
+		}
+	}
+	if usageLimits().DisconnectOnFlag {
+		r.disconnectUser(userID)
+	}
+}
+
+// disconnectUser closes every local connection of the user that provided a
+// close hook.
+func (r *Registry) disconnectUser(userID int64) {
+	r.mu.Lock()
+	conns := make([]*registeredConn, 0, len(r.local[userID]))
+	for conn := range r.local[userID] {
+		conns = append(conns, conn)
+	}
+	r.mu.Unlock()
+	for _, conn := range conns {
+		if conn.closeConn != nil {
+			conn.closeConn()
+		}
+	}
+}
+
+func (r *Registry) usageFlusher() {
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		r.flushUsage(context.Background(), time.Now())
+	}
+}
+
+// flushUsage drains the accumulated deltas into the current hour's Redis
+// bucket so any instance can serve the admin usage report.
+func (r *Registry) flushUsage(ctx context.Context, now time.Time) {
+	if r.rdb == nil {
+		return
+	}
+	type pendingUsage struct {
+		userID                                    int64
+		conns, msgsIn, msgsOut, bytesIn, bytesOut int64
+	}
+	r.mu.Lock()
+	pending := make([]pendingUsage, 0, len(r.usage))
+	for userID, u := range r.usage {
+		if u.deltaConns == 0 && u.deltaMsgsIn == 0 && u.deltaMsgsOut == 0 && u.deltaBytesIn == 0 && u.deltaBytesOut == 0 {
+			continue
+		}
+		pending = append(pending, pendingUsage{
+			userID:   userID,
+			conns:    u.deltaConns,
+			msgsIn:   u.deltaMsgsIn,
+			msgsOut:  u.deltaMsgsOut,
+			bytesIn:  u.deltaBytesIn,
+			bytesOut: u.deltaBytesOut,
+		})
+		u.deltaConns, u.deltaMsgsIn, u.deltaMsgsOut, u.deltaBytesIn, u.deltaBytesOut = 0, 0, 0, 0, 0
+	}
+	r.mu.Unlock()
+
+	for _, p := range pending {
+		key := fmt.Sprintf("%s%d:%s", usageKeyPrefix, p.userID, now.UTC().Format(usageHourFormat))
+		pipe := r.rdb.Pipeline()
+		if p.conns != 0 {
+			pipe.HIncrBy(ctx, key, "conns", p.conns)
+		}
+		if p.msgsIn != 0 {
+			pipe.HIncrBy(ctx, key, "msgsIn", p.msgsIn)
+		}
+		if p.msgsOut != 0 {
+			pipe.HIncrBy(ctx, key, "msgsOut", p.msgsOut)
+		}
+		if p.bytesIn != 0 {
+			pipe.HIncrBy(ctx, key, "bytesIn", p.bytesIn)
+		}
+		if p.bytesOut != 0 {
+			pipe.HIncrBy(ctx, key, "bytesOut", p.bytesOut)
+		}
+		pipe.Expire(ctx, key, usageBucketTTL)
+		if _, err := pipe.Exec(ctx); err != nil {
+			logger.Log.Warn("ws usage flush failed", zap.Int64("userID", p.userID), zap.Error(err))
+		}
+	}
+}
+
+// WSUsageBucket is one hourly aggregate from Redis.
+type WSUsageBucket struct {
+	Hour        string `json:"hour"` // UTC, YYYYMMDDHH
+	Conns       int64  `json:"conns"`
+	MessagesIn  int64  `json:"messagesIn"`
+	MessagesOut int64  `json:"messagesOut"`
+	BytesIn     int64  `json:"bytesIn"`
+	BytesOut    int64  `json:"bytesOut"`
+}
+
+// WSUsageReport is the admin view of one user's websocket footprint: the live
+// counters on this instance plus the recent cross-instance hourly aggregates.
+type WSUsageReport struct {
+	UserID      int64           `json:"userId"`
+	LiveConns   int             `json:"liveConns"`
+	ConnsOpened int64           `json:"connsOpened"`
+	MessagesIn  int64           `json:"messagesIn"`
+	MessagesOut int64           `json:"messagesOut"`
+	BytesIn     int64           `json:"bytesIn"`
+	BytesOut    int64           `json:"bytesOut"`
+	Hourly      []WSUsageBucket `json:"hourly,omitempty"`
+}
+
+// UsageReport assembles the user's usage report over the last hours buckets.
+func (r *Registry) UsageReport(ctx context.Context, userID int64, hours int) (*WSUsageReport, error) {
+	r.mu.Lock()
+	report := &WSUsageReport{UserID: userID, LiveConns: len(r.local[userID])}
+	if u := r.usage[userID]; u != nil {
+		report.ConnsOpened = u.connsOpened
+		report.MessagesIn = u.msgsIn
+		report.MessagesOut = u.msgsOut
+		report.BytesIn = u.bytesIn
+		report.BytesOut = u.bytesOut
+	}
+	r.mu.Unlock()
+
+	if r.rdb == nil {
+		return report, nil
+	}
+	now := time.Now().UTC()
+	for i := 0; i < hours; i++ {
+		hour := now.Add(-time.Duration(i) * time.Hour).Format(usageHourFormat)
+		fields, err := r.rdb.HGetAll(ctx, fmt.Sprintf("%s%d:%s", usageKeyPrefix, userID, hour)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		bucket := WSUsageBucket{Hour: hour}
+		bucket.Conns, _ = strconv.ParseInt(fields["conns"], 10, 64)
+		bucket.MessagesIn, _ = strconv.ParseInt(fields["msgsIn"], 10, 64)
+		bucket.MessagesOut, _ = strconv.ParseInt(fields["msgsOut"], 10, 64)
+		bucket.BytesIn, _ = strconv.ParseInt(fields["bytesIn"], 10, 64)
+		bucket.BytesOut, _ = strconv.ParseInt(fields["bytesOut"], 10, 64)
+		report.Hourly = append(report.Hourly, bucket)
+	}
+	return report, nil
+}
+
+// AdminWSUsage serves GET /admin/users/:id/ws_usage.
+func (h *Handler) AdminWSUsage(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	report, err := h.registry.UsageReport(c.Request.Context(), userID, wsUsageReportHours)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load usage"})
+		return
+	}
+	response.Success(c, report)
+}
@@ -0,0 +1,167 @@
+package ws
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"dx-service/internal/config"
+)
+
+// fakeRiskFlagger captures raised flags in place of the admin service.
+type fakeRiskFlagger struct {
+	mu    sync.Mutex
+	flags []map[string]interface{}
+}
+
+func (f *fakeRiskFlagger) RaiseRiskFlag(_ context.Context, _ int64, _ string, detail map[string]interface{}) error {
+	f.mu.Lock()
+	f.flags = append(f.flags, detail)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeRiskFlagger) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.flags)
+}
+
+func withUsageLimits(t *testing.T, limits config.WSUsageConfig) {
+	t.Helper()
+	prev := config.GlobalConfig
+	config.GlobalConfig = &config.Config{WSUsage: limits}
+	t.Cleanup(func() { config.GlobalConfig = prev })
+}
+
+func TestConcurrentConnThresholdFlags(t *testing.T) {
+	withUsageLimits(t, config.WSUsageConfig{MaxConcurrentConns: 2})
+	r := NewRegistry(nil)
+	flagger := &fakeRiskFlagger{}
+	r.SetRiskFlagger(flagger)
+
+	r.register(7, connKindTable, func([]byte) {})
+	r.register(7, connKindTable, func([]byte) {})
+	if flagger.count() != 0 {
+		t.Fatalf("connections at the limit must not flag, got %d", flagger.count())
+	}
+
+	r.register(7, connKindTable, func([]byte) {})
+	if flagger.count() != 1 {
+		t.Fatalf("expected one flag past the limit, got %d", flagger.count())
+	}
+	if flagger.flags[0]["reason"] != "concurrent_connections" {
+		t.Fatalf("unexpected flag detail: %+v", flagger.flags[0])
+	}
+
+	// Still over the limit, but the hour is already flagged.
+	r.register(7, connKindTable, func([]byte) {})
+	if flagger.count() != 1 {
+		t.Fatalf("expected the flag deduped per hour, got %d", flagger.count())
+	}
+}
+
+func TestMessageRateThresholdFlags(t *testing.T) {
+	withUsageLimits(t, config.WSUsageConfig{MaxMessagesPerHour: 5})
+	r := NewRegistry(nil)
+	flagger := &fakeRiskFlagger{}
+	r.SetRiskFlagger(flagger)
+
+	for i := 0; i < 5; i++ {
+		r.recordInbound(7, 64)
+	}
+	if flagger.count() != 0 {
+		t.Fatalf("rates at the limit must not flag, got %d", flagger.count())
+	}
+
+	r.recordInbound(7, 64)
+	if flagger.count() != 1 {
+		t.Fatalf("expected one flag past the rate limit, got %d", flagger.count())
+	}
+	if flagger.flags[0]["reason"] != "message_rate" {
+		t.Fatalf("unexpected flag detail: %+v", flagger.flags[0])
+	}
+
+	r.recordInbound(7, 64)
+	if flagger.count() != 1 {
+		t.Fatalf("expected the flag deduped per hour, got %d", flagger.count())
+	}
+}
+
+func TestFlagGatedDisconnect(t *testing.T) {
+	withUsageLimits(t, config.WSUsageConfig{MaxConcurrentConns: 1, DisconnectOnFlag: true})
+	r := NewRegistry(nil)
+	r.SetRiskFlagger(&fakeRiskFlagger{})
+
+	closed := make(chan struct{}, 4)
+	first := r.register(7, connKindTable, func([]byte) {})
+	first.closeConn = func() { closed <- struct{}{} }
+
+	r.register(7, connKindTable, func([]byte) {})
+	select {
+	case <-closed:
+	default:
+		t.Fatal("expected the enforcement gate to close the user's connections")
+	}
+}
+
+func TestDisconnectStaysOffByDefault(t *testing.T) {
+	withUsageLimits(t, config.WSUsageConfig{MaxConcurrentConns: 1})
+	r := NewRegistry(nil)
+	r.SetRiskFlagger(&fakeRiskFlagger{})
+
+	var closedFirst bool
+	first := r.register(7, connKindTable, func([]byte) {})
+	first.closeConn = func() { closedFirst = true }
+
+	r.register(7, connKindTable, func([]byte) {})
+	if closedFirst {
+		t.Fatal("flags must not disconnect unless the gate is on")
+	}
+}
+
+func TestUsageFlushAggregatesHourly(t *testing.T) {
+	withUsageLimits(t, config.WSUsageConfig{})
+	_, rdb := newRegistryTestClient(t)
+	r := NewRegistry(rdb)
+
+	conn := r.register(7, connKindTable, func([]byte) {})
+	r.recordInbound(7, 100)
+	r.recordInbound(7, 50)
+	r.recordOutbound(7, 400)
+	r.unregister(conn)
+
+	now := time.Now()
+	r.flushUsage(context.Background(), now)
+
+	report, err := r.UsageReport(context.Background(), 7, 1)
+	if err != nil {
+		t.Fatalf("usage report: %v", err)
+	}
+	if report.ConnsOpened != 1 || report.MessagesIn != 2 || report.BytesIn != 150 ||
+		report.MessagesOut != 1 || report.BytesOut != 400 {
+		t.Fatalf("unexpected live counters: %+v", report)
+	}
+	if len(report.Hourly) != 1 {
+		t.Fatalf("expected one hourly bucket, got %d", len(report.Hourly))
+	}
+	bucket := report.Hourly[0]
+	if bucket.Hour != now.UTC().Format(usageHourFormat) {
+		t.Fatalf("bucket landed in the wrong hour: %s", bucket.Hour)
+	}
+	if bucket.Conns != 1 || bucket.MessagesIn != 2 || bucket.BytesIn != 150 ||
+		bucket.MessagesOut != 1 || bucket.BytesOut != 400 {
+		t.Fatalf("unexpected bucket: %+v", bucket)
+	}
+
+	// A second flush with no new traffic must not double-count.
+	r.flushUsage(context.Background(), now)
+	report, err = r.UsageReport(context.Background(), 7, 1)
+	if err != nil {
+		t.Fatalf("usage report: %v", err)
+	}
+	if report.Hourly[0].MessagesIn != 2 {
+		t.Fatalf("deltas flushed twice: %+v", report.Hourly[0])
+	}
+}
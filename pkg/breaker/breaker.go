@@ -0,0 +1,219 @@
+// Package breaker implements a lightweight error-rate circuit breaker. The
+// service groups its Redis-dependent operations (queueing, auth codes,
+// notifications) behind one breaker each, so an outage degrades into clear
+// business errors and fallbacks instead of raw 500s and log floods.
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the breaker's lifecycle position.
+type State string
+
+const (
+	// StateClosed passes every call through while sampling the error rate.
+	StateClosed State = "closed"
+	// StateOpen refuses calls until the cooldown elapses.
+	StateOpen State = "open"
+	// StateHalfOpen lets a single probe through to test recovery.
+	StateHalfOpen State = "half_open"
+)
+
+// Config tunes when a breaker opens and how it recovers. Zero fields keep
+// the built-in defaults.
+type Config struct {
+	// Window is the sampling window for the error rate; counters reset
+	// when it rolls over.
+	Window time.Duration
+	// MinCalls is how many calls the window needs before the ratio is
+	// trusted, so a single early failure cannot open the breaker.
+	MinCalls int
+	// FailureRatio is the windowed error rate at which the breaker opens.
+	FailureRatio float64
+	// Cooldown is how long an open breaker waits before the half-open
+	// probe.
+	Cooldown time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.Window <= 0 {
+		c.Window = 30 * time.Second
+	}
+	if c.MinCalls <= 0 {
+		c.MinCalls = 10
+	}
+	if c.FailureRatio <= 0 {
+		c.FailureRatio = 0.5
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 15 * time.Second
+	}
+	return c
+}
+
+// Breaker tracks one operation group. The zero value is not usable; use New.
+type Breaker struct {
+	name string
+	cfg  Config
+	now  func() time.Time
+
+	mu          sync.Mutex
+	state       State
+	windowStart time.Time
+	calls       int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	opens       int64
+}
+
+// New returns a closed breaker for one operation group.
+func New(name string, cfg Config) *Breaker {
+	return &Breaker{
+		name:  name,
+		cfg:   cfg.withDefaults(),
+		now:   time.Now,
+		state: StateClosed,
+	}
+}
+
+// Allow reports whether a call may proceed. An open breaker refuses until
+// the cooldown elapses, then admits exactly one half-open probe; its Record
+// outcome decides between closing and reopening.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.Cooldown {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.probing = false
+		fallthrough
+	case StateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Record feeds a call outcome back. Callers must only pass infrastructure
+// errors (or nil); business rejections are not failures and belong outside
+// the breaker.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case StateHalfOpen:
+		b.probing = false
+		if err != nil {
+			b.openLocked(now)
+			return
+		}
+		b.state = StateClosed
+		b.resetWindowLocked(now)
+	case StateClosed:
+		if b.windowStart.IsZero() || now.Sub(b.windowStart) > b.cfg.Window {
+			b.resetWindowLocked(now)
+		}
+		b.calls++
+		if err != nil {
+			b.failures++
+		}
+		if b.calls >= b.cfg.MinCalls &&
+			float64(b.failures)/float64(b.calls) >= b.cfg.FailureRatio {
+			b.openLocked(now)
+		}
+	default:
+		// Late results for calls admitted before the breaker opened carry
+		// no new information.
+	}
+}
+
+func (b *Breaker) openLocked(now time.Time) {
+	b.state = StateOpen
+	b.openedAt = now
+	b.opens++
+}
+
+func (b *Breaker) resetWindowLocked(now time.Time) {
+	b.windowStart = now
+	b.calls = 0
+	b.failures = 0
+}
+
+// State returns the current lifecycle position.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Snapshot is one breaker's exported metrics.
+type Snapshot struct {
+	Name     string `json:"name"`
+	State    State  `json:"state"`
+	Calls    int    `json:"calls"`    // calls in the current window
+	Failures int    `json:"failures"` // failures in the current window
+	Opens    int64  `json:"opens"`    // lifetime open transitions
+	// RetryAt is when an open breaker admits its probe; zero otherwise.
+	RetryAt int64 `json:"retryAt,omitempty"`
+}
+
+// Snapshot exports the breaker's counters for metrics and the admin
+// dashboard.
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	snap := Snapshot{
+		Name:     b.name,
+		State:    b.state,
+		Calls:    b.calls,
+		Failures: b.failures,
+		Opens:    b.opens,
+	}
+	if b.state == StateOpen {
+		snap.RetryAt = b.openedAt.Add(b.cfg.Cooldown).UnixMilli()
+	}
+	return snap
+}
+
+// Registry collects the process's breakers for the dashboard export.
+type Registry struct {
+	mu       sync.Mutex
+	breakers []*Breaker
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Add registers a breaker and returns it for inline wiring.
+func (r *Registry) Add(b *Breaker) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.breakers = append(r.breakers, b)
+	return b
+}
+
+// Snapshots exports every registered breaker in registration order.
+func (r *Registry) Snapshots() []Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snaps := make([]Snapshot, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		snaps = append(snaps, b.Snapshot())
+	}
+	return snaps
+}
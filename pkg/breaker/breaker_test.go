@@ -0,0 +1,110 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errBoom = errors.New("boom")
+
+func testBreaker(cfg Config) (*Breaker, *time.Time) {
+	b := New("test", cfg)
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreakerOpensAtFailureRate(t *testing.T) {
+	b, _ := testBreaker(Config{MinCalls: 4, FailureRatio: 0.5})
+
+	// Below MinCalls even a 100% failure rate stays closed.
+	for i := 0; i < 3; i++ {
+		if !b.Allow() {
+			t.Fatal("closed breaker must admit calls")
+		}
+		b.Record(errBoom)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("expected closed under MinCalls, got %s", b.State())
+	}
+
+	b.Record(errBoom)
+	if b.State() != StateOpen {
+		t.Fatalf("expected open at the failure rate, got %s", b.State())
+	}
+	if b.Allow() {
+		t.Fatal("open breaker must refuse calls")
+	}
+}
+
+func TestBreakerHalfOpenProbeRecovers(t *testing.T) {
+	b, now := testBreaker(Config{MinCalls: 2, FailureRatio: 0.5, Cooldown: 10 * time.Second})
+	b.Record(errBoom)
+	b.Record(errBoom)
+	if b.State() != StateOpen {
+		t.Fatalf("expected open, got %s", b.State())
+	}
+
+	// A failed probe after the cooldown reopens for another full cooldown.
+	*now = now.Add(11 * time.Second)
+	if !b.Allow() {
+		t.Fatal("expected the half-open probe admitted")
+	}
+	if b.Allow() {
+		t.Fatal("only one probe may be in flight")
+	}
+	b.Record(errBoom)
+	if b.State() != StateOpen || b.Allow() {
+		t.Fatalf("failed probe must reopen, got %s", b.State())
+	}
+
+	// A successful probe closes the breaker with fresh counters.
+	*now = now.Add(11 * time.Second)
+	if !b.Allow() {
+		t.Fatal("expected a second probe after the cooldown")
+	}
+	b.Record(nil)
+	if b.State() != StateClosed {
+		t.Fatalf("successful probe must close, got %s", b.State())
+	}
+	if snap := b.Snapshot(); snap.Calls != 0 || snap.Failures != 0 || snap.Opens != 2 {
+		t.Fatalf("expected fresh window after recovery, got %+v", snap)
+	}
+}
+
+func TestBreakerWindowRollsOver(t *testing.T) {
+	b, now := testBreaker(Config{Window: 30 * time.Second, MinCalls: 4, FailureRatio: 0.5})
+	b.Record(errBoom)
+	b.Record(errBoom)
+	b.Record(errBoom)
+
+	// The stale failures roll out of the window, so the next one cannot
+	// combine with them to open the breaker.
+	*now = now.Add(time.Minute)
+	b.Record(errBoom)
+	if b.State() != StateClosed {
+		t.Fatalf("expected rolled window to stay closed, got %s", b.State())
+	}
+	if snap := b.Snapshot(); snap.Calls != 1 || snap.Failures != 1 {
+		t.Fatalf("expected only the fresh call counted, got %+v", snap)
+	}
+}
+
+func TestRegistrySnapshots(t *testing.T) {
+	r := NewRegistry()
+	a := r.Add(New("queueing", Config{}))
+	r.Add(New("auth_codes", Config{}))
+
+	a.Record(errBoom)
+	snaps := r.Snapshots()
+	if len(snaps) != 2 {
+		t.Fatalf("expected both breakers exported, got %d", len(snaps))
+	}
+	if snaps[0].Name != "queueing" || snaps[0].Failures != 1 {
+		t.Fatalf("unexpected first snapshot: %+v", snaps[0])
+	}
+	if snaps[1].Name != "auth_codes" || snaps[1].State != StateClosed {
+		t.Fatalf("unexpected second snapshot: %+v", snaps[1])
+	}
+}
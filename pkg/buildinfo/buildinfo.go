@@ -0,0 +1,43 @@
+package buildinfo
+
+import (
+	"runtime"
+	"time"
+)
+
+// Set via -ldflags at release build time, e.g.
+//
+//	go build -ldflags "-X dx-service/pkg/buildinfo.Version=v1.4.0 \
+//	  -X dx-service/pkg/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X dx-service/pkg/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Local builds without ldflags report "dev".
+var (
+	Version   = "dev"
+	Commit    = "dev"
+	BuildTime = "dev"
+)
+
+var startTime = time.Now()
+
+// Info is the build/runtime block returned by GET /version.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+	StartTime string `json:"startTime"`
+	Uptime    string `json:"uptime"`
+}
+
+// Summary snapshots the build info plus process start time and uptime.
+func Summary() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		StartTime: startTime.UTC().Format(time.RFC3339),
+		Uptime:    time.Since(startTime).Round(time.Second).String(),
+	}
+}
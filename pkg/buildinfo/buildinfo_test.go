@@ -0,0 +1,29 @@
+package buildinfo_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"dx-service/pkg/buildinfo"
+)
+
+func TestSummaryShape(t *testing.T) {
+	data, err := json.Marshal(buildinfo.Summary())
+	if err != nil {
+		t.Fatalf("failed to marshal summary: %v", err)
+	}
+
+	var decoded map[string]string
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal summary: %v", err)
+	}
+
+	for _, field := range []string{"version", "commit", "buildTime", "goVersion", "startTime", "uptime"} {
+		if decoded[field] == "" {
+			t.Fatalf("expected field %q to be set, got %v", field, decoded)
+		}
+	}
+	if decoded["version"] != "dev" {
+		t.Fatalf("expected default version dev, got %q", decoded["version"])
+	}
+}
@@ -17,6 +17,7 @@ var (
 	ErrInviteCodeNotFound   = errors.New("invite code not found")
 	ErrAlreadyBoundAgent    = errors.New("user already bound to agent")
 	ErrUserBanned           = errors.New("user is banned")
+	ErrRegionBlocked        = errors.New("region not allowed")
 	ErrUserNotFound         = errors.New("user not found")
 	ErrInvalidUserStatus    = errors.New("invalid user status")
 	ErrAdminNotFound        = errors.New("admin not found")
@@ -28,5 +29,40 @@ var (
 	ErrInvalidWalletPayload = errors.New("invalid wallet payload")
 	ErrUnauthorized         = errors.New("unauthorized")
 	ErrTableNotFound        = errors.New("table not found")
+	ErrFeatureFlagNotFound  = errors.New("feature flag not found")
+	ErrJobNotFound          = errors.New("job not found")
+	ErrJobAlreadyRunning    = errors.New("job already running")
+	ErrInvalidFlagRules     = errors.New("invalid feature flag rules")
 	ErrTableAccessDenied    = errors.New("table access denied")
+	ErrSpectatorCannotAct   = errors.New("spectator cannot act")
+	ErrTableMidHand         = errors.New("table is mid-hand")
+	ErrTableFrozen          = errors.New("table frozen awaiting admin review")
+	ErrInvalidAdjustment    = errors.New("invalid runtime adjustment")
+	ErrTableFull            = errors.New("table is full")
+	ErrInvitationNotFound   = errors.New("invitation not found or expired")
+	ErrAlreadySeated        = errors.New("user already seated at table")
+	ErrTooManyQueues        = errors.New("too many concurrent queues")
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrSceneClosed          = errors.New("scene is closed")
+	ErrCorruptTable         = errors.New("table data corrupted")
+	ErrActionDisabled       = errors.New("action disabled for this scene")
+	ErrStaleTurn            = errors.New("STALE_TURN: action targets a previous turn")
+	ErrWalletNotInitialized = errors.New("wallet not initialized")
+	ErrSceneBusy            = errors.New("scene has active tables or queued players")
+	ErrMuted                = errors.New("MUTED: chat is muted at this table")
+	ErrWalletBusy           = errors.New("wallet is locked by another operation")
+	ErrPanicStop            = errors.New("money movement is suspended by panic stop")
+	ErrServerBusy           = errors.New("server at capacity")
+	// ErrMatchmakingUnavailable is returned while the queueing circuit
+	// breaker is open and Redis-backed matchmaking cannot be trusted.
+	ErrMatchmakingUnavailable = errors.New("matchmaking temporarily unavailable")
+
+	ErrVerificationRequired = errors.New("real-name verification required")
+	ErrAlreadyVerified      = errors.New("user already verified")
+	ErrInvalidVerification  = errors.New("invalid verification payload")
+	ErrVerificationNotFound = errors.New("verification request not found")
+
+	ErrDeliveryFailureNotFound = errors.New("delivery failure not found")
+	ErrDeliveryAlreadyReplayed = errors.New("delivery failure already replayed")
+	ErrUnknownDeliveryType     = errors.New("no replay handler for delivery type")
 )
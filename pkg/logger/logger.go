@@ -1,13 +1,33 @@
 package logger
 
 import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"os"
 )
 
 var Log *zap.Logger
 
+// WithContext returns the global logger enriched with the trace and span IDs
+// from ctx when a recording span is present, so log lines can be cross-linked
+// with traces. Without an active span it returns the plain global logger.
+func WithContext(ctx context.Context) *zap.Logger {
+	if ctx == nil {
+		return Log
+	}
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return Log
+	}
+	return Log.With(
+		zap.String("traceId", spanCtx.TraceID().String()),
+		zap.String("spanId", spanCtx.SpanID().String()),
+	)
+}
+
 func InitLogger(mode string) {
 	var config zap.Config
 
@@ -0,0 +1,57 @@
+// Package storage is the shared blob-storage seam. Anything that writes
+// binary artifacts — round-log archives, uploaded media — goes through Store,
+// so swapping the local-disk backend for object storage touches one
+// constructor instead of every writer.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store reads and writes blobs by path. Paths are forward-slash relative
+// keys; backends map them to their own namespace.
+type Store interface {
+	Put(ctx context.Context, path string, data []byte) error
+	Get(ctx context.Context, path string) ([]byte, error)
+}
+
+// FileStore keeps blobs under a root directory, creating parent directories
+// as needed.
+type FileStore struct {
+	root string
+}
+
+func NewFileStore(root string) *FileStore {
+	return &FileStore{root: root}
+}
+
+func (s *FileStore) resolve(path string) (string, error) {
+	cleaned := filepath.Clean(path)
+	if cleaned == "." || strings.HasPrefix(cleaned, "..") || filepath.IsAbs(cleaned) {
+		return "", fmt.Errorf("invalid blob path %q", path)
+	}
+	return filepath.Join(s.root, cleaned), nil
+}
+
+func (s *FileStore) Put(_ context.Context, path string, data []byte) error {
+	full, err := s.resolve(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(full, data, 0o644)
+}
+
+func (s *FileStore) Get(_ context.Context, path string) ([]byte, error) {
+	full, err := s.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(full)
+}
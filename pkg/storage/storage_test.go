@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	s := NewFileStore(t.TempDir())
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "round_logs/a.jsonl.gz", []byte("payload")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	data, err := s.Get(ctx, "round_logs/a.jsonl.gz")
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if string(data) != "payload" {
+		t.Fatalf("unexpected blob: %q", data)
+	}
+
+	if _, err := s.Get(ctx, "round_logs/missing"); err == nil {
+		t.Fatal("expected missing blob to error")
+	}
+}
+
+func TestFileStoreRejectsEscapingPaths(t *testing.T) {
+	s := NewFileStore(t.TempDir())
+	ctx := context.Background()
+
+	for _, path := range []string{"../outside", "/etc/passwd", "a/../../b"} {
+		if err := s.Put(ctx, path, []byte("x")); err == nil {
+			t.Fatalf("expected put %q rejected", path)
+		}
+		if _, err := s.Get(ctx, path); err == nil {
+			t.Fatalf("expected get %q rejected", path)
+		}
+	}
+}
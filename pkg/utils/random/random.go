@@ -16,6 +16,32 @@ func Code(length int) string {
 	return pickFromSet(letters, length)
 }
 
+// Source yields a uniform int in [0, n). *math/rand.Rand satisfies it, so
+// callers that need reproducible shuffles can inject a seeded source.
+type Source interface {
+	Intn(n int) int
+}
+
+// Shuffle performs a Fisher–Yates shuffle of n elements via swap, drawing
+// indices from crypto/rand. A non-nil src replaces the crypto source for
+// deterministic use.
+func Shuffle(n int, swap func(i, j int), src Source) {
+	for i := n - 1; i > 0; i-- {
+		swap(i, intn(i+1, src))
+	}
+}
+
+func intn(n int, src Source) int {
+	if src != nil {
+		return src.Intn(n)
+	}
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+	return int(v.Int64())
+}
+
 func pickFromSet(set string, length int) string {
 	if length <= 0 {
 		return ""
@@ -0,0 +1,62 @@
+package random
+
+import (
+	mrand "math/rand"
+	"testing"
+)
+
+func shuffledInts(n int, src Source) []int {
+	deck := make([]int, n)
+	for i := range deck {
+		deck[i] = i
+	}
+	Shuffle(n, func(i, j int) { deck[i], deck[j] = deck[j], deck[i] }, src)
+	return deck
+}
+
+// Chi-square sanity check: over many crypto shuffles, card 0 should land on
+// every position about equally often. The threshold is far out in the tail
+// (7 degrees of freedom), so a correct shuffle essentially never trips it.
+func TestShuffleUniformity(t *testing.T) {
+	const n, trials = 8, 8000
+	counts := make([]int, n)
+	for trial := 0; trial < trials; trial++ {
+		deck := shuffledInts(n, nil)
+		for pos, v := range deck {
+			if v == 0 {
+				counts[pos]++
+			}
+		}
+	}
+
+	expected := float64(trials) / float64(n)
+	var chi2 float64
+	for _, obs := range counts {
+		diff := float64(obs) - expected
+		chi2 += diff * diff / expected
+	}
+	if chi2 > 50 {
+		t.Fatalf("position distribution too skewed: chi2=%.1f counts=%v", chi2, counts)
+	}
+}
+
+func TestConsecutiveShufflesDiffer(t *testing.T) {
+	first := shuffledInts(32, nil)
+	second := shuffledInts(32, nil)
+	for i := range first {
+		if first[i] != second[i] {
+			return
+		}
+	}
+	t.Fatal("two consecutive shuffles produced the same order")
+}
+
+func TestShuffleDeterministicWithInjectedSource(t *testing.T) {
+	first := shuffledInts(32, mrand.New(mrand.NewSource(7)))
+	second := shuffledInts(32, mrand.New(mrand.NewSource(7)))
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("seeded shuffles diverge at %d: %v vs %v", i, first, second)
+		}
+	}
+}
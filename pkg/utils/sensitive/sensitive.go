@@ -0,0 +1,83 @@
+package sensitive
+
+import "strings"
+
+// node is one trie level keyed by rune; end marks a complete word.
+type node struct {
+	children map[rune]*node
+	end      bool
+}
+
+// Trie matches a word list against arbitrary text for masking. Matching is
+// case-insensitive and longest-match-first, so "外挂群" wins over "外挂".
+type Trie struct {
+	root *node
+}
+
+func NewTrie(words []string) *Trie {
+	t := &Trie{root: &node{children: make(map[rune]*node)}}
+	for _, w := range words {
+		t.insert(w)
+	}
+	return t
+}
+
+func (t *Trie) insert(word string) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return
+	}
+	cur := t.root
+	for _, r := range word {
+		next, ok := cur.children[r]
+		if !ok {
+			next = &node{children: make(map[rune]*node)}
+			cur.children[r] = next
+		}
+		cur = next
+	}
+	cur.end = true
+}
+
+// Mask replaces every matched word with '*' per rune and reports whether
+// anything was replaced.
+func (t *Trie) Mask(text string) (string, bool) {
+	runes := []rune(text)
+	lowered := []rune(strings.ToLower(text))
+	masked := false
+
+	for i := 0; i < len(runes); {
+		length := t.matchAt(lowered, i)
+		if length == 0 {
+			i++
+			continue
+		}
+		for j := i; j < i+length; j++ {
+			runes[j] = '*'
+		}
+		masked = true
+		i += length
+	}
+	if !masked {
+		return text, false
+	}
+	return string(runes), true
+}
+
+// matchAt returns the length in runes of the longest word starting at pos,
+// or 0 when nothing matches.
+func (t *Trie) matchAt(runes []rune, pos int) int {
+	cur := t.root
+	longest := 0
+	for i := pos; i < len(runes); i++ {
+		next, ok := cur.children[runes[i]]
+		if !ok {
+			break
+		}
+		cur = next
+		if cur.end {
+			longest = i - pos + 1
+		}
+	}
+	return longest
+}
@@ -0,0 +1,35 @@
+package sensitive
+
+import "testing"
+
+func TestMaskReplacesMatchedWords(t *testing.T) {
+	trie := NewTrie([]string{"外挂", "傻逼", "scam"})
+
+	masked, hit := trie.Mask("你用外挂了吧")
+	if !hit || masked != "你用**了吧" {
+		t.Fatalf("unexpected mask result: %q (hit=%v)", masked, hit)
+	}
+
+	masked, hit = trie.Mask("this is a SCAM table")
+	if !hit || masked != "this is a **** table" {
+		t.Fatalf("expected case-insensitive match, got %q (hit=%v)", masked, hit)
+	}
+}
+
+func TestMaskPrefersLongestMatch(t *testing.T) {
+	trie := NewTrie([]string{"外挂", "外挂群"})
+
+	masked, hit := trie.Mask("加外挂群啊")
+	if !hit || masked != "加***啊" {
+		t.Fatalf("expected longest match, got %q (hit=%v)", masked, hit)
+	}
+}
+
+func TestMaskLeavesCleanTextUntouched(t *testing.T) {
+	trie := NewTrie([]string{"外挂"})
+
+	masked, hit := trie.Mask("打得不错")
+	if hit || masked != "打得不错" {
+		t.Fatalf("clean text changed: %q (hit=%v)", masked, hit)
+	}
+}